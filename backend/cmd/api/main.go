@@ -6,11 +6,17 @@ import (
 	"mindtrace/backend/interno/aplicacao/middlewares"
 	"mindtrace/backend/interno/aplicacao/servicos"
 	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/cifragem"
+	"mindtrace/backend/interno/persistencia/memoria"
 	postgres_repo "mindtrace/backend/interno/persistencia/postgres"
 	"mindtrace/backend/interno/persistencia/repositorios"
 	"mindtrace/backend/interno/persistencia/seeds"
 	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
 	"os"
+	"strconv"
+	"time"
+	// Base de fusos embutida, para a analise agregada aceitar nomes IANA mesmo em imagens sem zoneinfo
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -38,6 +44,17 @@ func main() {
 		log.Fatalf("DB_DRIVER invalido: %s", dbDriver)
 	}
 
+	// Cifragem em envelope dos campos sensiveis e das notas clinicas
+	// Chaves do arquivo em CIFRAGEM_CHAVES_ARQUIVO ou, sem rotacao, da CIFRAGEM_CHAVE
+	provedorChaves, err := cifragem.NovoProvedorDoAmbiente()
+	if err != nil {
+		log.Fatalf("falha ao carregar chaves de cifragem: %v", err)
+	}
+	cifrador := cifragem.NovoEnvelope(provedorChaves)
+	if err := cifragem.Registrar(db, cifrador); err != nil {
+		log.Fatalf("falha ao registrar cifragem: %v", err)
+	}
+
 	skipDBInit := os.Getenv("SKIP_DB_INIT") == "true"
 
 	if !skipDBInit {
@@ -46,7 +63,14 @@ func main() {
 			&dominio.Usuario{},
 			&dominio.Profissional{},
 			&dominio.Paciente{},
+			&dominio.Vinculo{},
+			&dominio.EncerramentoVinculo{},
 			&dominio.RegistroHumor{},
+			&dominio.RevisaoRegistroHumor{},
+			&dominio.AtividadeAutoCuidado{},
+			&dominio.RegistroHumorAtividade{},
+			&dominio.MetricaPersonalizada{},
+			&dominio.ValorMetricaRegistro{},
 			&dominio.Notificacao{},
 			&dominio.Convite{},
 			&dominio.Instrumento{},
@@ -54,10 +78,35 @@ func main() {
 			&dominio.OpcaoEscala{},
 			&dominio.Atribuicao{},
 			&dominio.Resposta{},
+			&dominio.RedefinicaoSenha{},
+			&dominio.VerificacaoEmail{},
+			&dominio.TentativaLogin{},
+			&dominio.BloqueioLogin{},
+			&dominio.DoisFatores{},
+			&dominio.CodigoRecuperacao{},
+			&dominio.DesafioDoisFatores{},
+			&dominio.ExportacaoDados{},
+			&dominio.ExclusaoConta{},
+			&dominio.Consentimento{},
+			&dominio.Responsavel{},
+			&dominio.VinculoResponsavel{},
+			&dominio.AcaoResponsavel{},
+			&dominio.NotaClinica{},
+			&dominio.RevisaoNotaClinica{},
+			&dominio.CompartilhamentoNota{},
+			&dominio.Consulta{},
+			&dominio.CalendarioProfissional{},
+			&dominio.RegistroAuditoria{},
 		)
 		if err != nil {
 			log.Fatalf("falha ao migrar o banco de dados: %v", err)
 		}
+		// O indice unico sobre todas as colunas do registro de humor foi substituido pela chave de idempotencia
+		if db.Migrator().HasIndex(&dominio.RegistroHumor{}, "idx_registro_humor_completo") {
+			if err := db.Migrator().DropIndex(&dominio.RegistroHumor{}, "idx_registro_humor_completo"); err != nil {
+				log.Fatalf("falha ao remover o indice antigo de registros de humor: %v", err)
+			}
+		}
 
 		// Instrumentos imutaveis seedados
 		seeds.ExecutarSeeds(db)
@@ -69,6 +118,23 @@ func main() {
 	var registroHumorRepo repositorios.RegistroHumorRepositorio
 	var conviteRepo repositorios.ConviteRepositorio
 	var instrumentoRepo repositorios.InstrumentoRepositorio
+	var redefinicaoSenhaRepo repositorios.RedefinicaoSenhaRepositorio
+	var verificacaoEmailRepo repositorios.VerificacaoEmailRepositorio
+	var tentativaLoginRepo repositorios.TentativaLoginRepositorio
+	var bloqueioLoginRepo repositorios.BloqueioLoginRepositorio
+	var doisFatoresRepo repositorios.DoisFatoresRepositorio
+	var exportacaoDadosRepo repositorios.ExportacaoDadosRepositorio
+	var exclusaoContaRepo repositorios.ExclusaoContaRepositorio
+	var notificacaoRepo repositorios.NotificacaoRepositorio
+	var consentimentoRepo repositorios.ConsentimentoRepositorio
+	var vinculoRepo repositorios.VinculoRepositorio
+	var responsavelRepo repositorios.ResponsavelRepositorio
+	var notaClinicaRepo repositorios.NotaClinicaRepositorio
+	var consultaRepo repositorios.ConsultaRepositorio
+	var auditoriaRepo repositorios.AuditoriaRepositorio
+	var autoCuidadoRepo repositorios.AutoCuidadoRepositorio
+	var metricaRepo repositorios.MetricaPersonalizadaRepositorio
+	var painelRepo repositorios.PainelProfissionalRepositorio
 
 	// Seleciona implementacoes de repositorio conforme driver ativo
 	switch dbDriver {
@@ -77,30 +143,162 @@ func main() {
 		registroHumorRepo = postgres_repo.NovoGormRegistroHumorRepositorio(db)
 		conviteRepo = postgres_repo.NovoGormConviteRepositorio(db)
 		instrumentoRepo = postgres_repo.NovoGormInstrumentoRepositorio(db)
+		redefinicaoSenhaRepo = postgres_repo.NovoGormRedefinicaoSenhaRepositorio(db)
+		verificacaoEmailRepo = postgres_repo.NovoGormVerificacaoEmailRepositorio(db)
+		tentativaLoginRepo = postgres_repo.NovoGormTentativaLoginRepositorio(db)
+		bloqueioLoginRepo = postgres_repo.NovoGormBloqueioLoginRepositorio(db)
+		doisFatoresRepo = postgres_repo.NovoGormDoisFatoresRepositorio(db)
+		exportacaoDadosRepo = postgres_repo.NovoGormExportacaoDadosRepositorio(db)
+		exclusaoContaRepo = postgres_repo.NovoGormExclusaoContaRepositorio(db)
+		notificacaoRepo = postgres_repo.NovoGormNotificacaoRepositorio(db)
+		consentimentoRepo = postgres_repo.NovoGormConsentimentoRepositorio(db)
+		vinculoRepo = postgres_repo.NovoGormVinculoRepositorio(db)
+		responsavelRepo = postgres_repo.NovoGormResponsavelRepositorio(db)
+		notaClinicaRepo = postgres_repo.NovoGormNotaClinicaRepositorio(db)
+		consultaRepo = postgres_repo.NovoGormConsultaRepositorio(db)
+		auditoriaRepo = postgres_repo.NovoGormAuditoriaRepositorio(db)
+		autoCuidadoRepo = postgres_repo.NovoGormAutoCuidadoRepositorio(db)
+		metricaRepo = postgres_repo.NovoGormMetricaPersonalizadaRepositorio(db)
+		painelRepo = postgres_repo.NovoGormPainelProfissionalRepositorio(db)
 	case "sqlite":
 		usuarioRepo = sqlite_repo.NovoGormUsuarioRepositorio(db)
 		registroHumorRepo = sqlite_repo.NovoGormRegistroHumorRepositorio(db)
 		conviteRepo = sqlite_repo.NovoGormConviteRepositorio(db)
+		instrumentoRepo = sqlite_repo.NovoGormInstrumentoRepositorio(db)
+		redefinicaoSenhaRepo = sqlite_repo.NovoGormRedefinicaoSenhaRepositorio(db)
+		verificacaoEmailRepo = sqlite_repo.NovoGormVerificacaoEmailRepositorio(db)
+		tentativaLoginRepo = sqlite_repo.NovoGormTentativaLoginRepositorio(db)
+		bloqueioLoginRepo = sqlite_repo.NovoGormBloqueioLoginRepositorio(db)
+		doisFatoresRepo = sqlite_repo.NovoGormDoisFatoresRepositorio(db)
+		exportacaoDadosRepo = sqlite_repo.NovoGormExportacaoDadosRepositorio(db)
+		exclusaoContaRepo = sqlite_repo.NovoGormExclusaoContaRepositorio(db)
+		notificacaoRepo = sqlite_repo.NovoGormNotificacaoRepositorio(db)
+		consentimentoRepo = sqlite_repo.NovoGormConsentimentoRepositorio(db)
+		vinculoRepo = sqlite_repo.NovoGormVinculoRepositorio(db)
+		responsavelRepo = sqlite_repo.NovoGormResponsavelRepositorio(db)
+		notaClinicaRepo = sqlite_repo.NovoGormNotaClinicaRepositorio(db)
+		consultaRepo = sqlite_repo.NovoGormConsultaRepositorio(db)
+		auditoriaRepo = sqlite_repo.NovoGormAuditoriaRepositorio(db)
+		autoCuidadoRepo = sqlite_repo.NovoGormAutoCuidadoRepositorio(db)
+		metricaRepo = sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db)
+		painelRepo = sqlite_repo.NovoGormPainelProfissionalRepositorio(db)
+	}
+
+	// Contadores de login em memoria servem para uma unica instancia; com varias, use o banco
+	if os.Getenv("LOGIN_STORE") == "memoria" {
+		tentativaLoginRepo = memoria.NovoTentativaLoginRepositorio()
+	}
+
+	// Com EXIGIR_2FA_PROFISSIONAIS=true profissionais so acessam a api apos configurar o segundo fator
+	exigir2FA := os.Getenv("EXIGIR_2FA_PROFISSIONAIS") == "true"
+
+	// Chaves de assinatura dos tokens (HS256 legado ou RS256/EdDSA via JWT_CHAVES_DIR)
+	chavesJWT, err := servicos.NovoChavesJWTDoAmbiente()
+	if err != nil {
+		log.Fatalf("falha ao carregar chaves jwt: %v", err)
+	}
+
+	// REGISTRO_HUMOR_JANELA_CORRECAO_HORAS define o prazo para o paciente corrigir um registro (padrao 24 horas)
+	janelaCorrecao := time.Duration(0)
+	if horas, err := strconv.Atoi(os.Getenv("REGISTRO_HUMOR_JANELA_CORRECAO_HORAS")); err == nil && horas > 0 {
+		janelaCorrecao = time.Duration(horas) * time.Hour
+	}
+	// REGISTRO_HUMOR_JANELA_RETROATIVA_DIAS define ate quantos dias atras a sincronizacao aceita registros (padrao 7 dias)
+	janelaRetroativa := time.Duration(0)
+	if dias, err := strconv.Atoi(os.Getenv("REGISTRO_HUMOR_JANELA_RETROATIVA_DIAS")); err == nil && dias > 0 {
+		janelaRetroativa = time.Duration(dias) * 24 * time.Hour
 	}
 
 	// Inicializa servicos
-	usuarioSvc := servicos.NovoUsuarioServico(db, usuarioRepo)
-	analiseSvc := servicos.NovoAnaliseServico(db, registroHumorRepo, usuarioRepo)
-	registroHumorSvc := servicos.NovoRegistroHumorServico(db, registroHumorRepo, usuarioRepo, analiseSvc)
+	emailSvc, err := servicos.NovoEmailServico()
+	if err != nil {
+		log.Fatalf("falha ao configurar o envio de e-mails: %v", err)
+	}
+	verificacaoEmailSvc := servicos.NovoVerificacaoEmailServico(db, usuarioRepo, verificacaoEmailRepo, emailSvc)
+	protecaoLoginSvc := servicos.NovoProtecaoLoginServico(tentativaLoginRepo, bloqueioLoginRepo)
+	doisFatoresSvc := servicos.NovoDoisFatoresServico(db, usuarioRepo, doisFatoresRepo, exigir2FA)
+	usuarioSvc := servicos.NovoUsuarioServico(db, usuarioRepo, vinculoRepo, conviteRepo, consentimentoRepo, verificacaoEmailSvc, protecaoLoginSvc, doisFatoresSvc, chavesJWT)
+	analiseSvc := servicos.NovoAnaliseServico(db, registroHumorRepo, usuarioRepo, consentimentoRepo, vinculoRepo, notificacaoRepo, responsavelRepo, metricaRepo)
+	registroHumorSvc := servicos.NovoRegistroHumorServico(db, registroHumorRepo, usuarioRepo, consentimentoRepo, vinculoRepo, autoCuidadoRepo, metricaRepo, analiseSvc, janelaCorrecao, janelaRetroativa)
 	resumoSvc := servicos.NovoResumoServico(db, registroHumorRepo, usuarioRepo)
-	conviteSvc := servicos.NovoConviteServico(db, conviteRepo, usuarioRepo)
-	instrumentoSvc := servicos.NovoInstrumentoServico(db, instrumentoRepo, usuarioRepo)
+	painelSvc := servicos.NovoPainelServico(db, usuarioRepo, consentimentoRepo, painelRepo)
+	conviteSvc := servicos.NovoConviteServico(db, conviteRepo, usuarioRepo, consentimentoRepo, vinculoRepo, emailSvc)
+	instrumentoSvc := servicos.NovoInstrumentoServico(db, instrumentoRepo, usuarioRepo, consentimentoRepo, responsavelRepo, notificacaoRepo)
+	consentimentoSvc := servicos.NovoConsentimentoServico(db, usuarioRepo, consentimentoRepo, responsavelRepo)
+	vinculoSvc := servicos.NovoVinculoServico(db, usuarioRepo, vinculoRepo, consentimentoRepo, notificacaoRepo)
+	responsavelSvc := servicos.NovoResponsavelServico(db, usuarioRepo, responsavelRepo, consentimentoRepo, instrumentoRepo, notificacaoRepo, verificacaoEmailSvc, resumoSvc, instrumentoSvc)
+	notaClinicaSvc := servicos.NovoNotaClinicaServico(db, usuarioRepo, vinculoRepo, notaClinicaRepo, cifrador)
+	consultaSvc := servicos.NovoConsultaServico(db, usuarioRepo, vinculoRepo, consultaRepo, instrumentoRepo, consentimentoRepo, notificacaoRepo, responsavelRepo)
+	auditoriaSvc := servicos.NovoAuditoriaServico(db, usuarioRepo, auditoriaRepo)
+	metricaSvc := servicos.NovoMetricaPersonalizadaServico(db, usuarioRepo, vinculoRepo, metricaRepo)
+	autoCuidadoSvc := servicos.NovoAutoCuidadoServico(db, usuarioRepo, vinculoRepo, consentimentoRepo, autoCuidadoRepo, registroHumorRepo)
+	redefinicaoSenhaSvc := servicos.NovoRedefinicaoSenhaServico(db, usuarioRepo, redefinicaoSenhaRepo, emailSvc)
+	exportacaoDadosSvc := servicos.NovoExportacaoDadosServico(db, exportacaoDadosRepo, usuarioRepo, emailSvc, os.Getenv("EXPORTACOES_DIR"))
+
+	// EXCLUSAO_CARENCIA_DIAS define o prazo para cancelar a exclusao de conta (padrao 30 dias)
+	carenciaExclusao := time.Duration(0)
+	if dias, err := strconv.Atoi(os.Getenv("EXCLUSAO_CARENCIA_DIAS")); err == nil && dias > 0 {
+		carenciaExclusao = time.Duration(dias) * 24 * time.Hour
+	}
+	exclusaoContaSvc := servicos.NovoExclusaoContaServico(db, usuarioRepo, exclusaoContaRepo, notificacaoRepo, tentativaLoginRepo, emailSvc, carenciaExclusao)
+
+	// Vinculos anteriores ao consentimento recebem a versao inicial com o acesso que ja tinham
+	if err := consentimentoSvc.CriarConsentimentosLegados(); err != nil {
+		log.Printf("falha ao criar consentimentos de vinculos antigos: %v", err)
+	}
+
+	// Retoma exportacoes interrompidas e executa periodicamente as rotinas de limpeza
+	if err := exportacaoDadosSvc.RetomarExportacoesPendentes(); err != nil {
+		log.Printf("falha ao retomar exportacoes pendentes: %v", err)
+	}
+	go func() {
+		for range time.Tick(time.Hour) {
+			if err := exportacaoDadosSvc.LimparExportacoesExpiradas(); err != nil {
+				log.Printf("falha ao limpar exportacoes expiradas: %v", err)
+			}
+			if err := exclusaoContaSvc.ExecutarExclusoesVencidas(); err != nil {
+				log.Printf("falha ao executar exclusoes de conta: %v", err)
+			}
+		}
+	}()
+
+	// Questionarios pre-sessao sao atribuidos 24h antes da consulta; a verificacao roda a cada 15 minutos
+	go func() {
+		for range time.Tick(15 * time.Minute) {
+			if err := consultaSvc.AtribuirQuestionariosPreSessao(); err != nil {
+				log.Printf("falha ao atribuir questionarios pre-sessao: %v", err)
+			}
+		}
+	}()
 
 	// Inicializa controladores
 	profissionalCtrl := controladores.NovoProfissionalControlador(usuarioSvc)
 	pacienteCtrl := controladores.NovoPacienteControlador(usuarioSvc)
-	autCtrl := controladores.NovoAutControlador(usuarioSvc)
+	autCtrl := controladores.NovoAutControlador(usuarioSvc, redefinicaoSenhaSvc, verificacaoEmailSvc)
 	usuarioCtrl := controladores.NovoUsuarioControlador(usuarioSvc)
 	registroHumorCtrl := controladores.NovoRegistroHumorControlador(registroHumorSvc)
+	autoCuidadoCtrl := controladores.NovoAutoCuidadoControlador(autoCuidadoSvc)
+	metricaCtrl := controladores.NovoMetricaPersonalizadaControlador(metricaSvc)
 	relatorioCtrl := controladores.NovoRelatorioControlador(analiseSvc)
 	resumoCtrl := controladores.NovoResumoControlador(resumoSvc)
+	painelCtrl := controladores.NovoPainelControlador(painelSvc)
 	conviteCtrl := controladores.NovoConviteControlador(conviteSvc)
 	instrumentoCtrl := controladores.NovoInstrumentoControlador(instrumentoSvc)
+	doisFatoresCtrl := controladores.NovoDoisFatoresControlador(doisFatoresSvc)
+	jwksCtrl := controladores.NovoJWKSControlador(chavesJWT)
+	exportacaoCtrl := controladores.NovoExportacaoControlador(exportacaoDadosSvc)
+	exclusaoContaCtrl := controladores.NovoExclusaoContaControlador(exclusaoContaSvc)
+	consentimentoCtrl := controladores.NovoConsentimentoControlador(consentimentoSvc)
+	vinculoCtrl := controladores.NovoVinculoControlador(vinculoSvc)
+	responsavelCtrl := controladores.NovoResponsavelControlador(responsavelSvc)
+	notaClinicaCtrl := controladores.NovoNotaClinicaControlador(notaClinicaSvc)
+	consultaCtrl := controladores.NovoConsultaControlador(consultaSvc)
+	auditoriaCtrl := controladores.NovoAuditoriaControlador(auditoriaSvc)
+
+	// auditar registra o acesso aos dados de pacientes na trilha de auditoria
+	auditar := func(acao, tipoRecurso, parametroRecurso string) gin.HandlerFunc {
+		return middlewares.AuditoriaMiddleware(auditoriaSvc, acao, tipoRecurso, parametroRecurso)
+	}
 
 	// Configura roteador http com middlewares e grupos de rotas
 	roteador := gin.Default()
@@ -108,12 +306,20 @@ func main() {
 	// Inclui middleware cors padrao aceitando chamadas do frontend
 	roteador.Use(middlewares.CORSMiddleware())
 
+	// Chaves publicas para validacao dos tokens por outros servicos
+	roteador.GET("/.well-known/jwks.json", jwksCtrl.Publicar)
+
 	api := roteador.Group("/api/v1")
 	{
 		// --- ROTAS PUBLICAS ---
 		auth := api.Group("/entrar")
 		{
 			auth.POST("/login", autCtrl.Login)
+			auth.POST("/login/2fa", autCtrl.ConcluirLoginDoisFatores)
+			auth.POST("/esqueci-senha", autCtrl.SolicitarRedefinicaoSenha)
+			auth.POST("/redefinir-senha", autCtrl.ConfirmarRedefinicaoSenha)
+			auth.POST("/verificar-email", autCtrl.ConfirmarEmail)
+			auth.POST("/reenviar-verificacao", autCtrl.ReenviarVerificacaoEmail)
 		}
 
 		profissionais := api.Group("/profissionais")
@@ -128,32 +334,76 @@ func main() {
 			pacientes.POST("/registrar", pacienteCtrl.Registrar)
 		}
 
+		// Registro de responsaveis disponivel sem token
+		api.POST("/responsaveis/registrar", responsavelCtrl.Registrar)
+
+		// Download do pacote exportado e autorizado pelo token do link
+		api.GET("/exportacoes/download", exportacaoCtrl.Download)
+
+		// Feed .ics da agenda autorizado pelo token do link, para aplicativos de calendario
+		api.GET("/calendario.ics", consultaCtrl.Calendario)
+
+		// Configuracao do segundo fator fica fora da exigencia de 2FA para permitir a inscricao
+		doisFatores := api.Group("/2fa")
+		doisFatores.Use(middlewares.AutMiddleware(usuarioRepo, chavesJWT))
+		{
+			doisFatores.POST("/inscrever", doisFatoresCtrl.Inscrever)
+			doisFatores.POST("/ativar", doisFatoresCtrl.Ativar)
+			doisFatores.POST("/desativar", doisFatoresCtrl.Desativar)
+			doisFatores.POST("/codigos-recuperacao", doisFatoresCtrl.RegenerarCodigosRecuperacao)
+		}
+
 		// --- ROTAS PROTEGIDAS ---
 		// Todas as rotas deste grupo exigirao token jwt valido
 		protegido := api.Group("/")
-		protegido.Use(middlewares.AutMiddleware())
+		protegido.Use(middlewares.AutMiddleware(usuarioRepo, chavesJWT), middlewares.ExigirDoisFatoresMiddleware(exigir2FA))
 		{
 			usuarios := protegido.Group("/usuarios")
 			{
 				usuarios.GET("/", usuarioCtrl.BuscarPerfil)
 				usuarios.GET("/paciente", pacienteCtrl.ProprioPerfilPaciente)
 				usuarios.GET("/profissional", profissionalCtrl.ProprioPerfilProfissional)
-				usuarios.GET("/profissional/pacientes", usuarioCtrl.ListarPacientesDoProfissional)
+				usuarios.GET("/profissional/pacientes", auditar(dominio.AcaoAuditoriaListarPacientes, dominio.RecursoAuditoriaPaciente, ""), usuarioCtrl.ListarPacientesDoProfissional)
+				usuarios.GET("/profissional/painel", auditar(dominio.AcaoAuditoriaVerPainelPacientes, dominio.RecursoAuditoriaPaciente, ""), painelCtrl.GerarPainel)
 				usuarios.PUT("/perfil", usuarioCtrl.AtualizarPerfil)
 				usuarios.PUT("/perfil/alterar-senha", usuarioCtrl.AlterarSenha)
-				usuarios.DELETE("/perfil/apagar-conta", usuarioCtrl.DeletarPerfil)
+				usuarios.DELETE("/perfil/apagar-conta", exclusaoContaCtrl.Solicitar)
+				usuarios.GET("/perfil/exclusao", exclusaoContaCtrl.Status)
+				usuarios.POST("/perfil/cancelar-exclusao", exclusaoContaCtrl.Cancelar)
 			}
 
 			registroHumor := protegido.Group("/registro-humor")
 			{
+				registroHumor.GET("/", auditar(dominio.AcaoAuditoriaListarRegistrosHumor, dominio.RecursoAuditoriaRegistroHumor, ""), registroHumorCtrl.Listar)
 				registroHumor.POST("/", registroHumorCtrl.Criar)
+				registroHumor.POST("/sincronizar", registroHumorCtrl.Sincronizar)
+				registroHumor.PUT("/", registroHumorCtrl.Atualizar)
+				registroHumor.DELETE("/", registroHumorCtrl.Excluir)
+				registroHumor.GET("/revisoes", registroHumorCtrl.ListarRevisoes)
+			}
 
+			autoCuidado := protegido.Group("/auto-cuidado")
+			{
+				autoCuidado.GET("/atividades", autoCuidadoCtrl.ListarAtividades)
+				autoCuidado.POST("/atividades", autoCuidadoCtrl.CriarAtividade)
+				autoCuidado.DELETE("/atividades", autoCuidadoCtrl.DesativarAtividade)
+				autoCuidado.GET("/correlacao", auditar(dominio.AcaoAuditoriaVerCorrelacaoAutoCuidado, dominio.RecursoAuditoriaRegistroHumor, ""), autoCuidadoCtrl.GerarCorrelacao)
+			}
+
+			metricas := protegido.Group("/metricas")
+			{
+				metricas.GET("/", metricaCtrl.Listar)
+				metricas.POST("/", metricaCtrl.Criar)
+				metricas.DELETE("/", metricaCtrl.Desativar)
 			}
 
 			relatorios := protegido.Group("/relatorios")
 			{
 				relatorios.GET("/", relatorioCtrl.GerarRelatorio)
-				relatorios.GET("/paciente-lista", relatorioCtrl.GerarAnaliseHistorica)
+				relatorios.GET("/paciente-lista", auditar(dominio.AcaoAuditoriaVerHistorico, dominio.RecursoAuditoriaRegistroHumor, ""), relatorioCtrl.GerarAnaliseHistorica)
+				relatorios.GET("/correlacoes", auditar(dominio.AcaoAuditoriaVerCorrelacaoMetricas, dominio.RecursoAuditoriaRegistroHumor, ""), relatorioCtrl.GerarCorrelacoes)
+				relatorios.GET("/agregada", auditar(dominio.AcaoAuditoriaVerHistorico, dominio.RecursoAuditoriaRegistroHumor, ""), relatorioCtrl.GerarAnaliseAgregada)
+				relatorios.GET("/comparacao", auditar(dominio.AcaoAuditoriaVerHistorico, dominio.RecursoAuditoriaRegistroHumor, ""), relatorioCtrl.CompararPeriodos)
 			}
 
 			resumo := protegido.Group("/resumo")
@@ -164,9 +414,72 @@ func main() {
 			convites := protegido.Group("/convites")
 			{
 				convites.POST("/gerar", conviteCtrl.GerarConvite)
+				convites.GET("/", conviteCtrl.ListarConvites)
+				convites.POST("/revogar", conviteCtrl.RevogarConvite)
+				convites.POST("/enviar", conviteCtrl.EnviarConvite)
+				convites.GET("/qrcode", conviteCtrl.QRCodeConvite)
 				convites.POST("/vincular", conviteCtrl.VincularPaciente)
 			}
 
+			consentimentos := protegido.Group("/consentimentos")
+			{
+				consentimentos.GET("/", consentimentoCtrl.Listar)
+				consentimentos.PUT("/", consentimentoCtrl.Atualizar)
+				consentimentos.POST("/revogar", consentimentoCtrl.Revogar)
+				consentimentos.GET("/historico", consentimentoCtrl.Historico)
+				consentimentos.GET("/paciente", auditar(dominio.AcaoAuditoriaVerConsentimento, dominio.RecursoAuditoriaConsentimento, ""), consentimentoCtrl.DoPaciente)
+			}
+
+			vinculos := protegido.Group("/vinculos")
+			{
+				vinculos.POST("/encerrar", vinculoCtrl.Encerrar)
+				vinculos.POST("/alta", vinculoCtrl.DarAlta)
+			}
+
+			responsaveis := protegido.Group("/responsaveis")
+			{
+				// Rotas do paciente
+				responsaveis.POST("/autorizar", responsavelCtrl.Autorizar)
+				responsaveis.GET("/", responsavelCtrl.ListarResponsaveis)
+				responsaveis.POST("/remover", responsavelCtrl.Remover)
+				responsaveis.GET("/acoes", responsavelCtrl.ListarAcoes)
+				// Rotas do responsavel
+				responsaveis.GET("/dependentes", responsavelCtrl.ListarDependentes)
+				responsaveis.GET("/dependentes/resumo", auditar(dominio.AcaoAuditoriaVerResumo, dominio.RecursoAuditoriaRegistroHumor, ""), responsavelCtrl.ResumoDependente)
+				responsaveis.GET("/dependentes/atribuicoes", auditar(dominio.AcaoAuditoriaListarAtribuicoes, dominio.RecursoAuditoriaAtribuicao, ""), responsavelCtrl.ListarAtribuicoesDependente)
+				responsaveis.GET("/dependentes/atribuicao", auditar(dominio.AcaoAuditoriaVerAtribuicao, dominio.RecursoAuditoriaAtribuicao, "atribuicaoID"), responsavelCtrl.PerguntasAtribuicaoDependente)
+				responsaveis.POST("/dependentes/registrar-respostas", responsavelCtrl.ResponderAtribuicaoDependente)
+				responsaveis.GET("/dependentes/consentimentos", auditar(dominio.AcaoAuditoriaVerConsentimento, dominio.RecursoAuditoriaConsentimento, ""), responsavelCtrl.ConsentimentosDependente)
+				responsaveis.PUT("/dependentes/consentimentos", responsavelCtrl.AtualizarConsentimentoDependente)
+				responsaveis.POST("/dependentes/consentimentos/revogar", responsavelCtrl.RevogarConsentimentoDependente)
+				responsaveis.POST("/dependentes/encerrar", responsavelCtrl.EncerrarDependente)
+				responsaveis.GET("/notificacoes", responsavelCtrl.ListarNotificacoes)
+			}
+
+			prontuario := protegido.Group("/prontuario")
+			{
+				prontuario.POST("/notas", notaClinicaCtrl.CriarNota)
+				prontuario.GET("/notas", auditar(dominio.AcaoAuditoriaListarNotas, dominio.RecursoAuditoriaNotaClinica, ""), notaClinicaCtrl.ListarNotas)
+				prontuario.GET("/nota", auditar(dominio.AcaoAuditoriaVerNota, dominio.RecursoAuditoriaNotaClinica, "notaID"), notaClinicaCtrl.BuscarNota)
+				prontuario.PUT("/nota", notaClinicaCtrl.RevisarNota)
+				prontuario.GET("/nota/revisoes", notaClinicaCtrl.ListarRevisoes)
+				prontuario.POST("/nota/compartilhar", notaClinicaCtrl.CompartilharNota)
+				prontuario.POST("/nota/descompartilhar", notaClinicaCtrl.DescompartilharNota)
+			}
+
+			consultas := protegido.Group("/consultas")
+			{
+				// Rotas do profissional
+				consultas.POST("/", consultaCtrl.Agendar)
+				consultas.GET("/", consultaCtrl.ListarAgenda)
+				consultas.PUT("/remarcar", consultaCtrl.Remarcar)
+				consultas.POST("/status", consultaCtrl.AlterarStatus)
+				consultas.POST("/calendario", consultaCtrl.GerarLinkCalendario)
+				// Rotas do paciente
+				consultas.GET("/paciente", consultaCtrl.ListarDoPaciente)
+				consultas.POST("/paciente/cancelar", consultaCtrl.CancelarPeloPaciente)
+			}
+
 			instrumentos := protegido.Group("/instrumentos")
 			{
 				instrumentos.GET("/listar-instrumentos", instrumentoCtrl.ListarInstrumentos)
@@ -175,8 +488,21 @@ func main() {
 				instrumentos.GET("/listar-atribuicoes-profissional", instrumentoCtrl.ListarAtribuicoesProfissional)
 				instrumentos.GET("/atribuicao", instrumentoCtrl.ApresentarPerguntasAtribuicao)
 				instrumentos.POST("/registrar-respostas", instrumentoCtrl.RegistrarRespostas)
-				instrumentos.GET("/visualizar-respostas", instrumentoCtrl.VisualizarRespostas)
+				instrumentos.GET("/visualizar-respostas", auditar(dominio.AcaoAuditoriaVerResposta, dominio.RecursoAuditoriaResposta, "atribuicaoID"), instrumentoCtrl.VisualizarRespostas)
+
+			}
 
+			auditoria := protegido.Group("/auditoria")
+			{
+				// Rota do paciente
+				auditoria.GET("/acessos", auditoriaCtrl.ListarAcessos)
+			}
+
+			exportacoes := protegido.Group("/exportacoes")
+			{
+				exportacoes.POST("/solicitar", exportacaoCtrl.Solicitar)
+				exportacoes.GET("/status", exportacaoCtrl.Status)
+				exportacoes.POST("/gerar-link", exportacaoCtrl.GerarLink)
 			}
 		}
 	}
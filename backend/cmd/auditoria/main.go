@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	postgres_repo "mindtrace/backend/interno/persistencia/postgres"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
+	"os"
+
+	"gorm.io/gorm"
+)
+
+// main confere a cadeia de hashes da trilha de auditoria
+// Termina com codigo 1 ao encontrar um registro alterado, removido ou inserido fora da ordem
+func main() {
+	var db *gorm.DB
+	var err error
+	var usuarioRepo repositorios.UsuarioRepositorio
+	var auditoriaRepo repositorios.AuditoriaRepositorio
+
+	switch dbDriver := os.Getenv("DB_DRIVER"); dbDriver {
+	case "postgres":
+		db, err = postgres_repo.NewDB()
+		if err != nil {
+			log.Fatalf("falha ao conectar ao postgres: %v", err)
+		}
+		usuarioRepo = postgres_repo.NovoGormUsuarioRepositorio(db)
+		auditoriaRepo = postgres_repo.NovoGormAuditoriaRepositorio(db)
+	case "sqlite":
+		db, err = sqlite_repo.NewDB()
+		if err != nil {
+			log.Fatalf("falha ao conectar ao sqlite: %v", err)
+		}
+		usuarioRepo = sqlite_repo.NovoGormUsuarioRepositorio(db)
+		auditoriaRepo = sqlite_repo.NovoGormAuditoriaRepositorio(db)
+	default:
+		log.Fatalf("DB_DRIVER invalido: %s", dbDriver)
+	}
+
+	auditoriaSvc := servicos.NovoAuditoriaServico(db, usuarioRepo, auditoriaRepo)
+	verificados, err := auditoriaSvc.VerificarCadeia()
+	if err != nil {
+		log.Fatalf("falha apos %d registros integros: %v", verificados, err)
+	}
+	fmt.Printf("cadeia integra: %d registros verificados\n", verificados)
+}
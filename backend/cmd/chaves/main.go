@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"os"
+	"path/filepath"
+)
+
+// main gerencia as chaves de assinatura dos tokens JWT
+// Procedimento de rotacao descrito em docs/ROTACAO_CHAVES_JWT.md
+func main() {
+	if len(os.Args) < 2 {
+		uso()
+	}
+
+	switch os.Args[1] {
+	case "gerar":
+		gerar(os.Args[2:])
+	case "aposentar":
+		aposentar(os.Args[2:])
+	default:
+		uso()
+	}
+}
+
+func uso() {
+	fmt.Fprintln(os.Stderr, "uso:")
+	fmt.Fprintln(os.Stderr, "  chaves gerar -dir <diretorio> [-alg EdDSA|RS256] [-kid <kid>]")
+	fmt.Fprintln(os.Stderr, "  chaves aposentar -dir <diretorio> -kid <kid>")
+	os.Exit(2)
+}
+
+// gerar cria uma nova chave privada no diretorio e imprime o kid
+// A chave passa a ser publicada no JWKS ao reiniciar a api, mas so assina quando definida em JWT_KID_ATIVO
+func gerar(args []string) {
+	fs := flag.NewFlagSet("gerar", flag.ExitOnError)
+	dir := fs.String("dir", os.Getenv("JWT_CHAVES_DIR"), "diretorio das chaves")
+	alg := fs.String("alg", servicos.AlgoritmoJWTEdDSA, "algoritmo (EdDSA ou RS256)")
+	kid := fs.String("kid", "", "identificador da chave (padrao: data + sufixo aleatorio)")
+	fs.Parse(args)
+
+	if *dir == "" {
+		log.Fatal("informe -dir ou JWT_CHAVES_DIR")
+	}
+
+	kidGerado, privadaPEM, err := servicos.GerarChaveJWT(*alg)
+	if err != nil {
+		log.Fatalf("falha ao gerar chave: %v", err)
+	}
+	if *kid != "" {
+		kidGerado = *kid
+	}
+
+	if err := os.MkdirAll(*dir, 0o700); err != nil {
+		log.Fatalf("falha ao criar diretorio: %v", err)
+	}
+
+	caminho := filepath.Join(*dir, kidGerado+servicos.ExtensaoChavePrivadaJWT)
+	arquivo, err := os.OpenFile(caminho, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		log.Fatalf("falha ao criar %s: %v", caminho, err)
+	}
+	defer arquivo.Close()
+
+	if _, err := arquivo.Write(privadaPEM); err != nil {
+		log.Fatalf("falha ao gravar %s: %v", caminho, err)
+	}
+
+	fmt.Println(kidGerado)
+}
+
+// aposentar substitui a chave privada pela publica: a chave para de assinar
+// mas continua validando tokens ja emitidos ate ser removida do diretorio
+func aposentar(args []string) {
+	fs := flag.NewFlagSet("aposentar", flag.ExitOnError)
+	dir := fs.String("dir", os.Getenv("JWT_CHAVES_DIR"), "diretorio das chaves")
+	kid := fs.String("kid", "", "identificador da chave")
+	fs.Parse(args)
+
+	if *dir == "" || *kid == "" {
+		uso()
+	}
+
+	caminhoPrivada := filepath.Join(*dir, *kid+servicos.ExtensaoChavePrivadaJWT)
+	privadaPEM, err := os.ReadFile(caminhoPrivada)
+	if err != nil {
+		log.Fatalf("falha ao ler %s: %v", caminhoPrivada, err)
+	}
+
+	publicaPEM, err := servicos.ExtrairChavePublicaPEM(privadaPEM)
+	if err != nil {
+		log.Fatalf("falha ao extrair chave publica: %v", err)
+	}
+
+	caminhoPublica := filepath.Join(*dir, *kid+servicos.ExtensaoChavePublicaJWT)
+	if err := os.WriteFile(caminhoPublica, publicaPEM, 0o644); err != nil {
+		log.Fatalf("falha ao gravar %s: %v", caminhoPublica, err)
+	}
+	if err := os.Remove(caminhoPrivada); err != nil {
+		log.Fatalf("falha ao remover %s: %v", caminhoPrivada, err)
+	}
+
+	fmt.Printf("chave %s aposentada; remova %s apos a expiracao dos tokens emitidos\n", *kid, caminhoPublica)
+}
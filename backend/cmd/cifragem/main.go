@@ -0,0 +1,252 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"mindtrace/backend/interno/persistencia/cifragem"
+	postgres_repo "mindtrace/backend/interno/persistencia/postgres"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
+	"os"
+
+	"gorm.io/gorm"
+)
+
+// coluna descreve um campo cifrado em envelope
+// chave e a coluna inteira usada para percorrer a tabela em lotes
+// legado indica que a coluna pode ter valores em claro, gravados antes da cifragem
+type coluna struct {
+	tabela string
+	chave  string
+	nome   string
+	legado bool
+}
+
+// colunasCifradas sao percorridas por recifrar
+// As notas clinicas sempre foram cifradas; as demais colunas ganham a cifragem aqui
+var colunasCifradas = []coluna{
+	{tabela: "usuarios", chave: "id", nome: "cpf", legado: true},
+	{tabela: "usuarios", chave: "id", nome: "contato", legado: true},
+	{tabela: "registros_humor", chave: "id", nome: "observacoes", legado: true},
+	{tabela: "revisoes_registros_humor", chave: "id", nome: "observacoes", legado: true},
+	{tabela: "respostas", chave: "id", nome: "dados_brutos", legado: true},
+	{tabela: "dois_fatores", chave: "usuario_id", nome: "segredo", legado: true},
+	{tabela: "revisoes_notas_clinicas", chave: "id", nome: "conteudo_cifrado"},
+}
+
+// main gerencia as chaves mestras da cifragem de campos
+// Procedimento de rotacao descrito em docs/CIFRAGEM.md
+func main() {
+	if len(os.Args) < 2 {
+		uso()
+	}
+
+	switch os.Args[1] {
+	case "gerar":
+		gerar(os.Args[2:])
+	case "recifrar":
+		recifrar(os.Args[2:])
+	default:
+		uso()
+	}
+}
+
+func uso() {
+	fmt.Fprintln(os.Stderr, "uso:")
+	fmt.Fprintln(os.Stderr, "  cifragem gerar -arquivo <arquivo.json> [-id <id>]")
+	fmt.Fprintln(os.Stderr, "  cifragem recifrar [-arquivo <arquivo.json>] [-lote <n>]")
+	os.Exit(2)
+}
+
+// gerar adiciona uma chave mestra ao arquivo e a torna a atual, criando o arquivo se preciso
+// Ao criar o arquivo, importa a CIFRAGEM_CHAVE, se definida
+// As chaves anteriores continuam no arquivo para decifrar os valores ainda nao recifrados
+func gerar(args []string) {
+	fs := flag.NewFlagSet("gerar", flag.ExitOnError)
+	caminho := fs.String("arquivo", os.Getenv("CIFRAGEM_CHAVES_ARQUIVO"), "arquivo de chaves")
+	id := fs.String("id", "", "identificador da chave (padrao: data + sufixo aleatorio)")
+	fs.Parse(args)
+
+	if *caminho == "" {
+		log.Fatal("informe -arquivo ou CIFRAGEM_CHAVES_ARQUIVO")
+	}
+
+	arquivo := &cifragem.ArquivoChaves{}
+	if _, err := os.Stat(*caminho); err == nil {
+		if arquivo, err = cifragem.LerArquivoChaves(*caminho); err != nil {
+			log.Fatalf("falha ao ler %s: %v", *caminho, err)
+		}
+	} else if os.IsNotExist(err) {
+		// Na migracao da CIFRAGEM_CHAVE para o arquivo, a chave unica segue valida para os dados ja cifrados
+		chave, err := cifragem.ChaveDoAmbiente()
+		if err != nil {
+			log.Fatalf("falha ao ler CIFRAGEM_CHAVE: %v", err)
+		}
+		if chave != nil {
+			arquivo.ImportarChaveUnica(chave)
+		}
+	} else {
+		log.Fatalf("falha ao ler %s: %v", *caminho, err)
+	}
+
+	idGerado, err := arquivo.AdicionarChave(*id)
+	if err != nil {
+		log.Fatalf("falha ao gerar chave: %v", err)
+	}
+	if err := arquivo.Salvar(*caminho); err != nil {
+		log.Fatalf("falha ao gravar %s: %v", *caminho, err)
+	}
+
+	fmt.Println(idGerado)
+}
+
+// recifrar cifra os valores legados em claro, troca a chave mestra das DEKs antigas pela atual
+// e recalcula o indice cego do CPF
+// Pode ser executado com a api no ar e repetido sem efeito sobre valores ja atualizados
+func recifrar(args []string) {
+	fs := flag.NewFlagSet("recifrar", flag.ExitOnError)
+	caminho := fs.String("arquivo", "", "arquivo de chaves (padrao: CIFRAGEM_CHAVES_ARQUIVO ou CIFRAGEM_CHAVE)")
+	lote := fs.Int("lote", 500, "linhas por transacao")
+	fs.Parse(args)
+
+	var provedor cifragem.ProvedorChaves
+	var err error
+	if *caminho != "" {
+		provedor, err = cifragem.NovoProvedorArquivo(*caminho)
+	} else {
+		provedor, err = cifragem.NovoProvedorDoAmbiente()
+	}
+	if err != nil {
+		log.Fatalf("falha ao carregar chaves de cifragem: %v", err)
+	}
+	envelope := cifragem.NovoEnvelope(provedor)
+
+	var db *gorm.DB
+	switch dbDriver := os.Getenv("DB_DRIVER"); dbDriver {
+	case "postgres":
+		db, err = postgres_repo.NewDB()
+	case "sqlite":
+		db, err = sqlite_repo.NewDB()
+	default:
+		log.Fatalf("DB_DRIVER invalido: %s", dbDriver)
+	}
+	if err != nil {
+		log.Fatalf("falha ao conectar ao banco: %v", err)
+	}
+
+	for _, c := range colunasCifradas {
+		alterados, err := recifrarColuna(db, envelope, c, *lote)
+		if err != nil {
+			log.Fatalf("falha ao recifrar %s.%s: %v", c.tabela, c.nome, err)
+		}
+		fmt.Printf("%s.%s: %d valores atualizados\n", c.tabela, c.nome, alterados)
+	}
+
+	alterados, err := recalcularIndiceCPF(db, envelope, *lote)
+	if err != nil {
+		log.Fatalf("falha ao recalcular usuarios.cpf_indice: %v", err)
+	}
+	fmt.Printf("usuarios.cpf_indice: %d valores atualizados\n", alterados)
+}
+
+// linha e um valor lido diretamente da tabela, sem passar pelo serializador do GORM
+type linha struct {
+	ID    uint
+	Valor []byte
+}
+
+// percorrer le a coluna em lotes pela chave, cada lote em uma transacao
+func percorrer(db *gorm.DB, tabela, chave, nome string, lote int, fn func(tx *gorm.DB, l linha) (bool, error)) (int, error) {
+	var ultimoID uint
+	alterados := 0
+	for {
+		var linhas []linha
+		err := db.Table(tabela).Select(chave+" AS id, "+nome+" AS valor").
+			Where(chave+" > ? AND "+nome+" IS NOT NULL", ultimoID).
+			Order(chave).Limit(lote).Scan(&linhas).Error
+		if err != nil {
+			return alterados, err
+		}
+		if len(linhas) == 0 {
+			return alterados, nil
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for _, l := range linhas {
+				alterado, err := fn(tx, l)
+				if err != nil {
+					return fmt.Errorf("id %d: %w", l.ID, err)
+				}
+				if alterado {
+					alterados++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return alterados, err
+		}
+		ultimoID = linhas[len(linhas)-1].ID
+	}
+}
+
+func recifrarColuna(db *gorm.DB, envelope *cifragem.Envelope, c coluna, lote int) (int, error) {
+	return percorrer(db, c.tabela, c.chave, c.nome, lote, func(tx *gorm.DB, l linha) (bool, error) {
+		if len(l.Valor) == 0 {
+			return false, nil
+		}
+
+		var novo []byte
+		if cifragem.Cifrado(l.Valor) {
+			var alterado bool
+			var err error
+			if novo, alterado, err = envelope.Reembalar(l.Valor); err != nil || !alterado {
+				return false, err
+			}
+		} else {
+			if !c.legado {
+				return false, cifragem.ErrTextoCifradoInvalido
+			}
+			var err error
+			if novo, err = envelope.Cifrar(l.Valor, cifragem.DadosAssociados(c.tabela, c.nome)); err != nil {
+				return false, err
+			}
+		}
+
+		// Colunas de texto recebem string; a das notas e binaria
+		var antigo, valor interface{} = l.Valor, novo
+		if c.legado {
+			antigo, valor = string(l.Valor), string(novo)
+		}
+		// A condicao pelo valor lido evita sobrescrever uma gravacao feita pela api nesse meio tempo
+		resultado := tx.Table(c.tabela).Where(c.chave+" = ? AND "+c.nome+" = ?", l.ID, antigo).Update(c.nome, valor)
+		return resultado.RowsAffected > 0, resultado.Error
+	})
+}
+
+// recalcularIndiceCPF grava o indice cego de cada CPF, preenchendo contas antigas
+// e acompanhando uma eventual troca da chave do indice
+func recalcularIndiceCPF(db *gorm.DB, envelope *cifragem.Envelope, lote int) (int, error) {
+	return percorrer(db, "usuarios", "id", "cpf", lote, func(tx *gorm.DB, l linha) (bool, error) {
+		cpf := l.Valor
+		if cifragem.Cifrado(cpf) {
+			var err error
+			if cpf, err = envelope.Decifrar(cpf, cifragem.DadosAssociados("usuarios", "cpf")); err != nil {
+				return false, err
+			}
+		}
+
+		consulta := tx.Table("usuarios").Where("id = ?", l.ID)
+		var resultado *gorm.DB
+		if len(cpf) == 0 {
+			resultado = consulta.Where("cpf_indice IS NOT NULL").Update("cpf_indice", gorm.Expr("NULL"))
+		} else {
+			indice, err := envelope.Indice(string(cpf))
+			if err != nil {
+				return false, err
+			}
+			resultado = consulta.Where("cpf_indice IS NULL OR cpf_indice <> ?", indice).Update("cpf_indice", indice)
+		}
+		return resultado.RowsAffected > 0, resultado.Error
+	})
+}
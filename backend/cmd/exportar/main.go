@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/cifragem"
+	postgres_repo "mindtrace/backend/interno/persistencia/postgres"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
+	"os"
+
+	"gorm.io/gorm"
+)
+
+// main gera o pacote de dados de um titular (LGPD) diretamente do banco
+// Usado para atender pedidos recebidos fora da aplicacao, como por e-mail ou pelo encarregado de dados
+func main() {
+	usuarioID := flag.Uint("usuario", 0, "ID do usuario titular")
+	email := flag.String("email", "", "e-mail do usuario titular (alternativa a -usuario)")
+	formato := flag.String("formato", dominio.FormatoExportacaoZIP, "formato do pacote (json ou zip)")
+	saida := flag.String("saida", "", "arquivo de saida (padrao: saida padrao)")
+	flag.Parse()
+
+	if *usuarioID == 0 && *email == "" {
+		log.Fatal("informe -usuario ou -email")
+	}
+
+	var db *gorm.DB
+	var err error
+	var usuarioRepo repositorios.UsuarioRepositorio
+	var exportacaoDadosRepo repositorios.ExportacaoDadosRepositorio
+
+	switch dbDriver := os.Getenv("DB_DRIVER"); dbDriver {
+	case "postgres":
+		db, err = postgres_repo.NewDB()
+		if err != nil {
+			log.Fatalf("falha ao conectar ao postgres: %v", err)
+		}
+		usuarioRepo = postgres_repo.NovoGormUsuarioRepositorio(db)
+		exportacaoDadosRepo = postgres_repo.NovoGormExportacaoDadosRepositorio(db)
+	case "sqlite":
+		db, err = sqlite_repo.NewDB()
+		if err != nil {
+			log.Fatalf("falha ao conectar ao sqlite: %v", err)
+		}
+		usuarioRepo = sqlite_repo.NovoGormUsuarioRepositorio(db)
+		exportacaoDadosRepo = sqlite_repo.NovoGormExportacaoDadosRepositorio(db)
+	default:
+		log.Fatalf("DB_DRIVER invalido: %s", dbDriver)
+	}
+
+	// O pacote leva os campos cifrados ja decifrados
+	provedorChaves, err := cifragem.NovoProvedorDoAmbiente()
+	if err != nil {
+		log.Fatalf("falha ao carregar chaves de cifragem: %v", err)
+	}
+	if err := cifragem.Registrar(db, cifragem.NovoEnvelope(provedorChaves)); err != nil {
+		log.Fatalf("falha ao registrar cifragem: %v", err)
+	}
+
+	if *usuarioID == 0 {
+		usuario, err := usuarioRepo.BuscarPorEmail(*email)
+		if err != nil {
+			log.Fatalf("usuario nao encontrado: %v", err)
+		}
+		*usuarioID = usuario.ID
+	}
+
+	var w io.Writer = os.Stdout
+	if *saida != "" {
+		arquivo, err := os.OpenFile(*saida, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			log.Fatalf("falha ao criar %s: %v", *saida, err)
+		}
+		defer arquivo.Close()
+		w = arquivo
+	}
+
+	// O pacote e gravado direto na saida; a linha de comando nao envia e-mails
+	exportacaoDadosSvc := servicos.NovoExportacaoDadosServico(db, exportacaoDadosRepo, usuarioRepo, servicos.NovoEmailServicoLocal(), "")
+	if err := exportacaoDadosSvc.GerarPacote(*usuarioID, *formato, w); err != nil {
+		log.Fatalf("falha ao gerar pacote: %v", err)
+	}
+	if *saida != "" {
+		log.Printf("pacote do usuario %d gravado em %s", *usuarioID, *saida)
+	}
+}
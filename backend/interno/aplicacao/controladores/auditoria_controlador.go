@@ -0,0 +1,54 @@
+package controladores
+
+import (
+	"mindtrace/backend/interno/aplicacao/middlewares"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditoriaControlador expoe ao paciente a trilha de acessos aos seus dados
+type AuditoriaControlador struct {
+	auditoriaServico servicos.AuditoriaServico
+}
+
+func NovoAuditoriaControlador(as servicos.AuditoriaServico) *AuditoriaControlador {
+	return &AuditoriaControlador{auditoriaServico: as}
+}
+
+// ListarAcessos mostra ao paciente autenticado quem acessou seus dados
+// Filtros opcionais de e ate no formato AAAA-MM-DD
+func (ac *AuditoriaControlador) ListarAcessos(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	de, ok := lerDataDaQuery(c, "de")
+	if !ok {
+		return
+	}
+	ate, ok := lerDataDaQuery(c, "ate")
+	if !ok {
+		return
+	}
+
+	acessosOut, err := ac.auditoriaServico.ListarAcessosDoPaciente(userID.(uint), de, ate)
+	if err != nil {
+		if err == dominio.ErrUsuarioNaoEncontrado {
+			c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao listar os acessos aos dados"})
+		return
+	}
+
+	c.JSON(http.StatusOK, acessosOut)
+}
+
+// auditarPacientes informa ao AuditoriaMiddleware os pacientes cujos dados sairam na resposta
+func auditarPacientes(c *gin.Context, pacienteIDs ...uint) {
+	c.Set(middlewares.ChavePacientesAuditados, pacienteIDs)
+}
@@ -1,8 +1,10 @@
 package controladores
 
 import (
+	"log"
 	"mindtrace/backend/interno/aplicacao/dtos"
 	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -10,12 +12,14 @@ import (
 
 // AutControlador gerencia requisicoes HTTP relacionadas a autenticacao
 type AutControlador struct {
-	usuarioServico servicos.UsuarioServico
+	usuarioServico          servicos.UsuarioServico
+	redefinicaoSenhaServico servicos.RedefinicaoSenhaServico
+	verificacaoEmailServico servicos.VerificacaoEmailServico
 }
 
-// NovoAutControlador cria uma nova instancia de AutControlador com o UsuarioServico fornecido
-func NovoAutControlador(us servicos.UsuarioServico) *AutControlador {
-	return &AutControlador{usuarioServico: us}
+// NovoAutControlador cria uma nova instancia de AutControlador com os servicos fornecidos
+func NovoAutControlador(us servicos.UsuarioServico, rss servicos.RedefinicaoSenhaServico, ves servicos.VerificacaoEmailServico) *AutControlador {
+	return &AutControlador{usuarioServico: us, redefinicaoSenhaServico: rss, verificacaoEmailServico: ves}
 }
 
 // Login lida com o login do usuario
@@ -27,12 +31,123 @@ func (ac *AutControlador) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := ac.usuarioServico.Login(req.Email, req.Senha)
+	loginOut, err := ac.usuarioServico.Login(req.Email, req.Senha, c.ClientIP())
 	if err != nil {
+		if err == dominio.ErrLoginBloqueado {
+			c.JSON(http.StatusTooManyRequests, gin.H{"erro": err.Error()})
+			return
+		}
+		if err == dominio.ErrEmailNaoVerificado {
+			c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+			return
+		}
 		// Retorna 401 para credenciais invalidas
 		c.JSON(http.StatusUnauthorized, gin.H{"erro": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusOK, loginOut)
+}
+
+// ConcluirLoginDoisFatores conclui o login de contas com dois fatores ativo
+// Recebe o token de desafio emitido no Login e o codigo TOTP ou de recuperacao
+func (ac *AutControlador) ConcluirLoginDoisFatores(c *gin.Context) {
+	var req dtos.ConcluirLoginDoisFatoresDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	loginOut, err := ac.usuarioServico.ConcluirLoginDoisFatores(&req, c.ClientIP())
+	if err != nil {
+		switch err {
+		case dominio.ErrLoginBloqueado:
+			c.JSON(http.StatusTooManyRequests, gin.H{"erro": err.Error()})
+		case dominio.ErrCodigoDoisFatoresInvalido, dominio.ErrDesafioDoisFatoresInvalido, dominio.ErrDesafioDoisFatoresExpirado,
+			dominio.ErrDoisFatoresNaoAtivo:
+			c.JSON(http.StatusUnauthorized, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao concluir login"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, loginOut)
+}
+
+// SolicitarRedefinicaoSenha inicia o fluxo de "esqueci minha senha"
+// A resposta e sempre a mesma, exista ou nao uma conta com o e-mail informado
+func (ac *AutControlador) SolicitarRedefinicaoSenha(c *gin.Context) {
+	var req dtos.SolicitarRedefinicaoSenhaDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	if err := ac.redefinicaoSenhaServico.SolicitarRedefinicao(req.Email); err != nil {
+		log.Printf("falha ao solicitar redefinicao de senha: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mensagem": "Se o e-mail estiver cadastrado, enviaremos as instruções para redefinir a senha"})
+}
+
+// ConfirmarRedefinicaoSenha define uma nova senha a partir do token recebido por e-mail
+func (ac *AutControlador) ConfirmarRedefinicaoSenha(c *gin.Context) {
+	var req dtos.ConfirmarRedefinicaoSenhaDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	err := ac.redefinicaoSenhaServico.ConfirmarRedefinicao(&req)
+	if err != nil {
+		switch err {
+		case dominio.ErrTokenRedefinicaoInvalido, dominio.ErrTokenRedefinicaoExpirado, dominio.ErrTokenRedefinicaoJaUtilizado,
+			dominio.ErrSenhaNaoConfere, dominio.ErrSenhaFraca, dominio.ErrSenhaInvalida:
+			c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao redefinir senha"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mensagem": "Senha redefinida com sucesso"})
+}
+
+// ConfirmarEmail confirma o e-mail do usuario a partir do token enviado no cadastro
+func (ac *AutControlador) ConfirmarEmail(c *gin.Context) {
+	var req dtos.ConfirmarEmailDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	err := ac.verificacaoEmailServico.ConfirmarEmail(req.Token)
+	if err != nil {
+		switch err {
+		case dominio.ErrTokenVerificacaoInvalido, dominio.ErrTokenVerificacaoExpirado, dominio.ErrTokenVerificacaoJaUtilizado:
+			c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao confirmar e-mail"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mensagem": "E-mail confirmado com sucesso"})
+}
+
+// ReenviarVerificacaoEmail envia novamente o link de confirmacao de e-mail
+// A resposta e a mesma para e-mails desconhecidos, ja verificados ou com reenvio limitado
+func (ac *AutControlador) ReenviarVerificacaoEmail(c *gin.Context) {
+	var req dtos.ReenviarVerificacaoEmailDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	if err := ac.verificacaoEmailServico.ReenviarVerificacao(req.Email); err != nil {
+		log.Printf("reenvio de verificacao de e-mail nao realizado: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mensagem": "Se houver uma conta pendente de verificação com este e-mail, enviaremos um novo link"})
 }
@@ -0,0 +1,128 @@
+package controladores
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AutoCuidadoControlador gerencia o catalogo de auto cuidado e a correlacao com o humor
+type AutoCuidadoControlador struct {
+	autoCuidadoServico servicos.AutoCuidadoServico
+}
+
+func NovoAutoCuidadoControlador(acs servicos.AutoCuidadoServico) *AutoCuidadoControlador {
+	return &AutoCuidadoControlador{autoCuidadoServico: acs}
+}
+
+// respostaErroAutoCuidado traduz os erros do catalogo e da correlacao em status HTTP
+func respostaErroAutoCuidado(c *gin.Context, err error) {
+	switch err {
+	case dominio.ErrUsuarioNaoEncontrado, dominio.ErrAtividadeAutoCuidadoNaoEncontrada, dominio.ErrVinculoNaoEncontrado:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case dominio.ErrConsentimentoNegado:
+		c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+	case dominio.ErrAtividadeAutoCuidadoDuplicada:
+		c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+	case dominio.ErrAtividadeAutoCuidadoInvalida, dominio.ErrPeriodoCorrelacaoInvalido:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao processar o auto cuidado"})
+	}
+}
+
+// ListarAtividades retorna o catalogo de auto cuidado visivel ao usuario autenticado
+func (acc *AutoCuidadoControlador) ListarAtividades(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	atividades, err := acc.autoCuidadoServico.ListarAtividades(userID.(uint), c.GetString("tipo"))
+	if err != nil {
+		respostaErroAutoCuidado(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, atividades)
+}
+
+// CriarAtividade inclui uma atividade personalizada no catalogo do profissional autenticado
+func (acc *AutoCuidadoControlador) CriarAtividade(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	var req dtos.CriarAtividadeAutoCuidadoDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	atividade, err := acc.autoCuidadoServico.CriarAtividade(userID.(uint), &req)
+	if err != nil {
+		respostaErroAutoCuidado(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, atividade)
+}
+
+// DesativarAtividade retira uma atividade do catalogo do profissional autenticado
+func (acc *AutoCuidadoControlador) DesativarAtividade(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	atividadeID, ok := lerIDDaQuery(c, "atividadeID")
+	if !ok {
+		return
+	}
+
+	if err := acc.autoCuidadoServico.DesativarAtividade(userID.(uint), atividadeID); err != nil {
+		respostaErroAutoCuidado(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GerarCorrelacao compara o humor do dia seguinte aos dias com e sem cada atividade
+// O paciente ve os proprios dados; o profissional informa o pacienteID na query
+func (acc *AutoCuidadoControlador) GerarCorrelacao(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	tipoUsuario := c.GetString("tipo")
+
+	var pacienteID uint
+	if dominio.StringParaTipoUsuario(tipoUsuario) != dominio.TipoUsuarioPaciente {
+		id, ok := lerIDDaQuery(c, "pacienteID")
+		if !ok {
+			return
+		}
+		pacienteID = id
+	}
+	dias, err := strconv.Atoi(c.DefaultQuery("dias", "0"))
+	if err != nil || dias < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Parametro 'dias' invalido"})
+		return
+	}
+
+	correlacao, err := acc.autoCuidadoServico.GerarCorrelacao(userID.(uint), tipoUsuario, pacienteID, dias)
+	if err != nil {
+		respostaErroAutoCuidado(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, correlacao)
+}
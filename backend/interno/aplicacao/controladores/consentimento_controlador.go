@@ -0,0 +1,167 @@
+package controladores
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConsentimentoControlador gerencia requisicoes HTTP do consentimento do paciente por profissional
+type ConsentimentoControlador struct {
+	consentimentoServico servicos.ConsentimentoServico
+}
+
+// NovoConsentimentoControlador cria uma nova instancia de ConsentimentoControlador com o ConsentimentoServico fornecido
+func NovoConsentimentoControlador(cs servicos.ConsentimentoServico) *ConsentimentoControlador {
+	return &ConsentimentoControlador{consentimentoServico: cs}
+}
+
+// respostaErroConsentimento traduz os erros de dominio do consentimento para status HTTP
+func respostaErroConsentimento(c *gin.Context, err error) {
+	switch err {
+	case dominio.ErrUsuarioNaoEncontrado, dominio.ErrVinculoNaoEncontrado, dominio.ErrConsentimentoNaoEncontrado:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case dominio.ErrCategoriaConsentimentoInvalida, dominio.ErrExpiracaoConsentimentoInvalida:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	case dominio.ErrConsentimentoExigeResponsavel:
+		c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao processar o consentimento"})
+	}
+}
+
+// Listar retorna o consentimento vigente do paciente autenticado com cada profissional
+func (cc *ConsentimentoControlador) Listar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	consentimentosOut, err := cc.consentimentoServico.ListarConsentimentos(userID.(uint))
+	if err != nil {
+		respostaErroConsentimento(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consentimentosOut)
+}
+
+// Atualizar grava uma nova versao do consentimento com o profissional informado
+func (cc *ConsentimentoControlador) Atualizar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	profissionalID, ok := lerProfissionalID(c)
+	if !ok {
+		return
+	}
+
+	var req dtos.AtualizarConsentimentoDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	consentimentoOut, err := cc.consentimentoServico.AtualizarConsentimento(userID.(uint), profissionalID, &req)
+	if err != nil {
+		respostaErroConsentimento(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consentimentoOut)
+}
+
+// Revogar interrompe todo o compartilhamento com o profissional informado
+func (cc *ConsentimentoControlador) Revogar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	profissionalID, ok := lerProfissionalID(c)
+	if !ok {
+		return
+	}
+
+	consentimentoOut, err := cc.consentimentoServico.RevogarConsentimento(userID.(uint), profissionalID)
+	if err != nil {
+		respostaErroConsentimento(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consentimentoOut)
+}
+
+// Historico retorna todas as versoes do consentimento com o profissional informado
+func (cc *ConsentimentoControlador) Historico(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	profissionalID, ok := lerProfissionalID(c)
+	if !ok {
+		return
+	}
+
+	versoesOut, err := cc.consentimentoServico.ListarHistorico(userID.(uint), profissionalID)
+	if err != nil {
+		respostaErroConsentimento(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, versoesOut)
+}
+
+// DoPaciente mostra ao profissional autenticado o que o paciente compartilha com ele
+func (cc *ConsentimentoControlador) DoPaciente(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	pacienteIDStr := c.DefaultQuery("pacienteID", "0")
+	if pacienteIDStr == "0" {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "ID de paciente invalido"})
+		return
+	}
+	pacienteID, err := strconv.Atoi(pacienteIDStr)
+	if err != nil || pacienteID < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Parametro 'pacienteID' invalido"})
+		return
+	}
+
+	consentimentoOut, err := cc.consentimentoServico.BuscarConsentimentoDoPaciente(userID.(uint), uint(pacienteID))
+	if err != nil {
+		respostaErroConsentimento(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consentimentoOut)
+}
+
+// lerProfissionalID le o parametro profissionalID da query, respondendo 400 quando invalido
+func lerProfissionalID(c *gin.Context) (uint, bool) {
+	profissionalIDStr := c.DefaultQuery("profissionalID", "0")
+	if profissionalIDStr == "0" {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "ID de profissional invalido"})
+		return 0, false
+	}
+	profissionalID, err := strconv.Atoi(profissionalIDStr)
+	if err != nil || profissionalID < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Parametro 'profissionalID' invalido"})
+		return 0, false
+	}
+	return uint(profissionalID), true
+}
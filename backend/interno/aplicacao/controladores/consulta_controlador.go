@@ -0,0 +1,220 @@
+package controladores
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConsultaControlador gerencia requisicoes HTTP da agenda de consultas
+type ConsultaControlador struct {
+	consultaServico servicos.ConsultaServico
+}
+
+// NovoConsultaControlador cria uma nova instancia de ConsultaControlador com o ConsultaServico fornecido
+func NovoConsultaControlador(cs servicos.ConsultaServico) *ConsultaControlador {
+	return &ConsultaControlador{consultaServico: cs}
+}
+
+// respostaErroConsulta traduz os erros de dominio da agenda para status HTTP
+func respostaErroConsulta(c *gin.Context, err error) {
+	switch err {
+	case dominio.ErrUsuarioNaoEncontrado, dominio.ErrVinculoNaoEncontrado, dominio.ErrConsultaNaoEncontrada,
+		dominio.ErrInstrumentoNaoEncontrado, dominio.ErrConsentimentoNaoEncontrado, dominio.ErrCalendarioLinkInvalido:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case dominio.ErrConsentimentoNegado:
+		c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+	case dominio.ErrConflitoAgenda, dominio.ErrTransicaoConsultaInvalida, dominio.ErrConsultaAindaNaoOcorreu:
+		c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+	case dominio.ErrHorarioConsultaInvalido, dominio.ErrDuracaoConsultaInvalida, dominio.ErrConsultaNoPassado,
+		dominio.ErrModalidadeInvalida, dominio.ErrLocalConsultaLongo, dominio.ErrRepeticoesInvalidas,
+		dominio.ErrStatusConsultaInvalido, dominio.ErrPeriodoAgendaInvalido:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao processar a consulta"})
+	}
+}
+
+// Agendar cria uma consulta, ou uma serie semanal, com o paciente informado
+func (cc *ConsultaControlador) Agendar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	pacienteID, ok := lerIDDaQuery(c, "pacienteID")
+	if !ok {
+		return
+	}
+
+	var req dtos.ConsultaDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	consultasOut, err := cc.consultaServico.AgendarConsulta(userID.(uint), pacienteID, &req)
+	if err != nil {
+		respostaErroConsulta(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, consultasOut)
+}
+
+// Remarcar move uma consulta agendada para outro horario
+func (cc *ConsultaControlador) Remarcar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	consultaID, ok := lerIDDaQuery(c, "consultaID")
+	if !ok {
+		return
+	}
+
+	var req dtos.RemarcarConsultaDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	consultaOut, err := cc.consultaServico.RemarcarConsulta(userID.(uint), consultaID, &req)
+	if err != nil {
+		respostaErroConsulta(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consultaOut)
+}
+
+// AlterarStatus registra a consulta como realizada, falta ou cancelada
+func (cc *ConsultaControlador) AlterarStatus(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	consultaID, ok := lerIDDaQuery(c, "consultaID")
+	if !ok {
+		return
+	}
+
+	var req dtos.AlterarStatusConsultaDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	consultasOut, err := cc.consultaServico.AlterarStatusConsulta(userID.(uint), consultaID, &req)
+	if err != nil {
+		respostaErroConsulta(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consultasOut)
+}
+
+// ListarAgenda retorna as consultas do profissional autenticado no periodo de/ate (AAAA-MM-DD)
+func (cc *ConsultaControlador) ListarAgenda(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	de, ok := lerDataDaQuery(c, "de")
+	if !ok {
+		return
+	}
+	ate, ok := lerDataDaQuery(c, "ate")
+	if !ok {
+		return
+	}
+
+	consultasOut, err := cc.consultaServico.ListarConsultasProfissional(userID.(uint), de, ate)
+	if err != nil {
+		respostaErroConsulta(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consultasOut)
+}
+
+// ListarDoPaciente retorna as consultas do paciente autenticado no periodo de/ate (AAAA-MM-DD)
+func (cc *ConsultaControlador) ListarDoPaciente(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	de, ok := lerDataDaQuery(c, "de")
+	if !ok {
+		return
+	}
+	ate, ok := lerDataDaQuery(c, "ate")
+	if !ok {
+		return
+	}
+
+	consultasOut, err := cc.consultaServico.ListarConsultasPaciente(userID.(uint), de, ate)
+	if err != nil {
+		respostaErroConsulta(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consultasOut)
+}
+
+// CancelarPeloPaciente permite ao paciente desmarcar uma consulta
+func (cc *ConsultaControlador) CancelarPeloPaciente(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	consultaID, ok := lerIDDaQuery(c, "consultaID")
+	if !ok {
+		return
+	}
+
+	consultaOut, err := cc.consultaServico.CancelarConsultaPaciente(userID.(uint), consultaID)
+	if err != nil {
+		respostaErroConsulta(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consultaOut)
+}
+
+// GerarLinkCalendario emite um novo link .ics para o profissional autenticado
+func (cc *ConsultaControlador) GerarLinkCalendario(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	calendarioOut, err := cc.consultaServico.GerarLinkCalendario(userID.(uint))
+	if err != nil {
+		respostaErroConsulta(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, calendarioOut)
+}
+
+// Calendario entrega o feed .ics autorizado pelo token do link
+func (cc *ConsultaControlador) Calendario(c *gin.Context) {
+	ics, err := cc.consultaServico.CalendarioICS(c.Query("token"))
+	if err != nil {
+		respostaErroConsulta(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", ics)
+}
@@ -1,10 +1,14 @@
 package controladores
 
 import (
+	"errors"
+	"io"
 	"mindtrace/backend/interno/aplicacao/dtos"
 	"mindtrace/backend/interno/aplicacao/servicos"
 	"mindtrace/backend/interno/dominio"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,7 +23,28 @@ func NovoConviteControlador(cs servicos.ConviteServico) *ConviteControlador {
 	return &ConviteControlador{conviteServico: cs}
 }
 
+// respostaErroConvite traduz os erros de dominio do convite para status HTTP
+func respostaErroConvite(c *gin.Context, err error) {
+	switch err {
+	case dominio.ErrUsuarioNaoEncontrado, dominio.ErrConviteNaoEncontrado:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case dominio.ErrTokenConviteInvalido, dominio.ErrConviteExpirado, dominio.ErrConviteJaUtilizado, dominio.ErrConviteRevogado,
+		dominio.ErrMaxUsosConviteInvalido, dominio.ErrConviteEmailVariosUsos, dominio.ErrDataExpiracaoNoPassado,
+		dominio.ErrFormatoQRCodeInvalido:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	case dominio.ErrConviteOutroEmail:
+		c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+	case dominio.ErrVinculoJaAtivo:
+		c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+	case dominio.ErrEnvioConviteFalhou:
+		c.JSON(http.StatusBadGateway, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao processar o convite"})
+	}
+}
+
 // GerarConvite gera um novo convite para o usuario autenticado
+// O corpo e opcional; sem ele o convite vale por 24h e para um unico paciente
 func (cc *ConviteControlador) GerarConvite(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -27,19 +52,137 @@ func (cc *ConviteControlador) GerarConvite(c *gin.Context) {
 		return
 	}
 
-	conviteOut, err := cc.conviteServico.GerarConvite(userID.(uint))
+	var req dtos.GerarConviteDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	conviteOut, err := cc.conviteServico.GerarConvite(userID.(uint), &req)
 	if err != nil {
-		if err == dominio.ErrUsuarioNaoEncontrado {
-			c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"erro": err.Error()})
-		}
+		respostaErroConvite(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conviteOut)
+}
+
+// ListarConvites lista os convites do profissional autenticado, com filtro opcional por status
+func (cc *ConviteControlador) ListarConvites(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	status := strings.ToUpper(c.Query("status"))
+	switch status {
+	case "", dominio.ConviteAtivo, dominio.ConviteUsado, dominio.ConviteExpirado, dominio.ConviteRevogado:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Parametro 'status' invalido"})
+		return
+	}
+
+	convitesOut, err := cc.conviteServico.ListarConvites(userID.(uint), status)
+	if err != nil {
+		respostaErroConvite(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, convitesOut)
+}
+
+// RevogarConvite impede novos usos do convite informado
+func (cc *ConviteControlador) RevogarConvite(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	conviteID, ok := lerConviteID(c)
+	if !ok {
+		return
+	}
+
+	conviteOut, err := cc.conviteServico.RevogarConvite(userID.(uint), conviteID)
+	if err != nil {
+		respostaErroConvite(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, conviteOut)
 }
 
+// EnviarConvite envia o link do convite informado para o e-mail do corpo da requisicao
+func (cc *ConviteControlador) EnviarConvite(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	conviteID, ok := lerConviteID(c)
+	if !ok {
+		return
+	}
+
+	var req dtos.EnviarConviteDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	conviteOut, err := cc.conviteServico.EnviarConvite(userID.(uint), conviteID, req.Email)
+	if err != nil {
+		respostaErroConvite(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conviteOut)
+}
+
+// QRCodeConvite retorna a imagem do QR code com o link de resgate do convite
+// O formato padrao e png; formato=svg retorna uma imagem vetorial
+func (cc *ConviteControlador) QRCodeConvite(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	conviteID, ok := lerConviteID(c)
+	if !ok {
+		return
+	}
+	formato := strings.ToLower(c.DefaultQuery("formato", servicos.FormatoQRCodePNG))
+
+	imagem, contentType, err := cc.conviteServico.GerarQRCodeConvite(userID.(uint), conviteID, formato)
+	if err != nil {
+		respostaErroConvite(c, err)
+		return
+	}
+
+	// O QR code carrega o token do convite e nao deve ficar em caches compartilhados
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, contentType, imagem)
+}
+
+// lerConviteID le e valida o parametro conviteID; em caso de erro a resposta ja foi escrita
+func lerConviteID(c *gin.Context) (uint, bool) {
+	conviteIDStr := c.DefaultQuery("conviteID", "0")
+	if conviteIDStr == "0" {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "ID de convite invalido"})
+		return 0, false
+	}
+	conviteID, err := strconv.Atoi(conviteIDStr)
+	if err != nil || conviteID < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Parametro 'conviteID' invalido"})
+		return 0, false
+	}
+	return uint(conviteID), true
+}
+
 // VincularPaciente vincula um paciente usando um token de convite
 // Valida a entrada e chama o servico para realizar o vinculo
 func (cc *ConviteControlador) VincularPaciente(c *gin.Context) {
@@ -58,8 +201,11 @@ func (cc *ConviteControlador) VincularPaciente(c *gin.Context) {
 	err := cc.conviteServico.VincularPaciente(userID.(uint), req.Token)
 	if err != nil {
 		switch err {
-		case dominio.ErrTokenConviteInvalido, dominio.ErrConviteExpirado, dominio.ErrConviteExpirado, dominio.ErrUsuarioNaoEncontrado:
+		case dominio.ErrUsuarioNaoEncontrado:
 			c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		case dominio.ErrTokenConviteInvalido, dominio.ErrConviteExpirado, dominio.ErrConviteJaUtilizado, dominio.ErrConviteRevogado,
+			dominio.ErrConviteOutroEmail, dominio.ErrVinculoJaAtivo:
+			respostaErroConvite(c, err)
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao vincular paciente"})
 		}
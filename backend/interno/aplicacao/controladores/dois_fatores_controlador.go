@@ -0,0 +1,121 @@
+package controladores
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DoisFatoresControlador gerencia requisicoes HTTP da autenticacao em dois fatores
+type DoisFatoresControlador struct {
+	doisFatoresServico servicos.DoisFatoresServico
+}
+
+// NovoDoisFatoresControlador cria uma nova instancia de DoisFatoresControlador com o DoisFatoresServico fornecido
+func NovoDoisFatoresControlador(dfs servicos.DoisFatoresServico) *DoisFatoresControlador {
+	return &DoisFatoresControlador{doisFatoresServico: dfs}
+}
+
+// respostaErroDoisFatores traduz os erros de dominio do segundo fator para status HTTP
+func respostaErroDoisFatores(c *gin.Context, err error) {
+	switch err {
+	case dominio.ErrUsuarioNaoEncontrado:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case dominio.ErrDoisFatoresApenasProfissionais, dominio.ErrDoisFatoresObrigatorio:
+		c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+	case dominio.ErrDoisFatoresJaAtivo, dominio.ErrDoisFatoresNaoIniciado, dominio.ErrDoisFatoresNaoAtivo:
+		c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+	case dominio.ErrCodigoDoisFatoresInvalido, dominio.ErrCrendenciaisInvalidas:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha na autenticação em dois fatores"})
+	}
+}
+
+// Inscrever gera o segredo TOTP e a URI otpauth do usuario autenticado
+func (dc *DoisFatoresControlador) Inscrever(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	inscricaoOut, err := dc.doisFatoresServico.IniciarInscricao(userID.(uint))
+	if err != nil {
+		respostaErroDoisFatores(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, inscricaoOut)
+}
+
+// Ativar confirma a inscricao com o primeiro codigo e retorna os codigos de recuperacao
+func (dc *DoisFatoresControlador) Ativar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	var req dtos.CodigoDoisFatoresDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	codigosOut, err := dc.doisFatoresServico.AtivarDoisFatores(userID.(uint), req.Codigo)
+	if err != nil {
+		respostaErroDoisFatores(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codigosOut)
+}
+
+// Desativar remove o segundo fator do usuario autenticado
+func (dc *DoisFatoresControlador) Desativar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	var req dtos.DesativarDoisFatoresDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	if err := dc.doisFatoresServico.DesativarDoisFatores(userID.(uint), &req); err != nil {
+		respostaErroDoisFatores(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mensagem": "Autenticação em dois fatores desativada"})
+}
+
+// RegenerarCodigosRecuperacao invalida os codigos atuais e retorna um novo conjunto
+func (dc *DoisFatoresControlador) RegenerarCodigosRecuperacao(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	var req dtos.CodigoDoisFatoresDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	codigosOut, err := dc.doisFatoresServico.RegenerarCodigosRecuperacao(userID.(uint), req.Codigo)
+	if err != nil {
+		respostaErroDoisFatores(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codigosOut)
+}
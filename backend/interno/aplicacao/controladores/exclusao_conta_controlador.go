@@ -0,0 +1,92 @@
+package controladores
+
+import (
+	"errors"
+	"io"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExclusaoContaControlador gerencia requisicoes HTTP da exclusao de conta
+type ExclusaoContaControlador struct {
+	exclusaoContaServico servicos.ExclusaoContaServico
+}
+
+// NovoExclusaoContaControlador cria uma nova instancia de ExclusaoContaControlador com o ExclusaoContaServico fornecido
+func NovoExclusaoContaControlador(ecs servicos.ExclusaoContaServico) *ExclusaoContaControlador {
+	return &ExclusaoContaControlador{exclusaoContaServico: ecs}
+}
+
+// respostaErroExclusaoConta traduz os erros de dominio da exclusao de conta para status HTTP
+func respostaErroExclusaoConta(c *gin.Context, err error) {
+	switch err {
+	case dominio.ErrUsuarioNaoEncontrado, dominio.ErrExclusaoNaoAgendada:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case dominio.ErrExclusaoJaAgendada:
+		c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao processar a exclusão da conta"})
+	}
+}
+
+// Solicitar agenda a exclusao da conta do usuario autenticado
+// A conta continua acessivel ate o fim do periodo de carencia para permitir o cancelamento
+func (ec *ExclusaoContaControlador) Solicitar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	// O corpo e opcional
+	var req dtos.SolicitarExclusaoContaDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	exclusaoOut, err := ec.exclusaoContaServico.SolicitarExclusao(userID.(uint), &req)
+	if err != nil {
+		respostaErroExclusaoConta(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, exclusaoOut)
+}
+
+// Status retorna a exclusao agendada do usuario autenticado
+func (ec *ExclusaoContaControlador) Status(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	exclusaoOut, err := ec.exclusaoContaServico.BuscarExclusao(userID.(uint))
+	if err != nil {
+		respostaErroExclusaoConta(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, exclusaoOut)
+}
+
+// Cancelar desiste da exclusao agendada do usuario autenticado
+func (ec *ExclusaoContaControlador) Cancelar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	if err := ec.exclusaoContaServico.CancelarExclusao(userID.(uint)); err != nil {
+		respostaErroExclusaoConta(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mensagem": "Exclusão da conta cancelada"})
+}
@@ -0,0 +1,134 @@
+package controladores
+
+import (
+	"errors"
+	"io"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportacaoControlador gerencia requisicoes HTTP da exportacao de dados do titular (LGPD)
+type ExportacaoControlador struct {
+	exportacaoServico servicos.ExportacaoDadosServico
+}
+
+// NovoExportacaoControlador cria uma nova instancia de ExportacaoControlador com o ExportacaoDadosServico fornecido
+func NovoExportacaoControlador(es servicos.ExportacaoDadosServico) *ExportacaoControlador {
+	return &ExportacaoControlador{exportacaoServico: es}
+}
+
+// respostaErroExportacao traduz os erros de dominio da exportacao para status HTTP
+func respostaErroExportacao(c *gin.Context, err error) {
+	switch err {
+	case dominio.ErrExportacaoNaoEncontrada, dominio.ErrUsuarioNaoEncontrado:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case dominio.ErrFormatoExportacaoInvalido, dominio.ErrLinkDownloadInvalido:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	case dominio.ErrExportacaoEmAndamento, dominio.ErrExportacaoNaoConcluida:
+		c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+	case dominio.ErrExportacaoExpirada:
+		c.JSON(http.StatusGone, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao processar a exportação de dados"})
+	}
+}
+
+// Solicitar inicia a geracao assincrona do pacote com os dados do usuario autenticado
+func (ec *ExportacaoControlador) Solicitar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	// O corpo e opcional; sem ele o pacote e gerado em zip
+	var req dtos.SolicitarExportacaoDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	exportacaoOut, err := ec.exportacaoServico.SolicitarExportacao(userID.(uint), &req)
+	if err != nil {
+		respostaErroExportacao(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, exportacaoOut)
+}
+
+// Status retorna o andamento de uma exportacao do usuario autenticado
+func (ec *ExportacaoControlador) Status(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	exportacaoID, ok := lerExportacaoID(c)
+	if !ok {
+		return
+	}
+
+	exportacaoOut, err := ec.exportacaoServico.BuscarExportacao(userID.(uint), exportacaoID)
+	if err != nil {
+		respostaErroExportacao(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, exportacaoOut)
+}
+
+// GerarLink emite um novo link de download para uma exportacao concluida
+func (ec *ExportacaoControlador) GerarLink(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	exportacaoID, ok := lerExportacaoID(c)
+	if !ok {
+		return
+	}
+
+	exportacaoOut, err := ec.exportacaoServico.GerarLinkDownload(userID.(uint), exportacaoID)
+	if err != nil {
+		respostaErroExportacao(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, exportacaoOut)
+}
+
+// Download entrega o pacote a partir do token do link, sem exigir sessao
+func (ec *ExportacaoControlador) Download(c *gin.Context) {
+	caminho, nomeArquivo, err := ec.exportacaoServico.AbrirDownload(c.Query("token"))
+	if err != nil {
+		respostaErroExportacao(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.FileAttachment(caminho, nomeArquivo)
+}
+
+// lerExportacaoID le o parametro exportacaoID da query, respondendo 400 quando invalido
+func lerExportacaoID(c *gin.Context) (uint, bool) {
+	exportacaoIDStr := c.DefaultQuery("exportacaoID", "0")
+	if exportacaoIDStr == "0" {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "ID de exportação inválido"})
+		return 0, false
+	}
+	exportacaoID, err := strconv.Atoi(exportacaoIDStr)
+	if err != nil || exportacaoID < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Parametro 'exportacaoID' invalido"})
+		return 0, false
+	}
+	return uint(exportacaoID), true
+}
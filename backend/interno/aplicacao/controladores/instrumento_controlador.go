@@ -18,6 +18,21 @@ type InstrumentoControlador struct {
 func NovoInstrumentoControlador(is servicos.InstrumentoServico) *InstrumentoControlador {
 	return &InstrumentoControlador{instrumentoServico: is}
 }
+
+// respostaErroInstrumento traduz os erros de acesso aos questionarios do paciente para status HTTP
+func respostaErroInstrumento(c *gin.Context, err error) {
+	switch err {
+	case dominio.ErrUsuarioNaoEncontrado:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case dominio.ErrVinculoNaoEncontrado, dominio.ErrConsentimentoNegado:
+		c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+	case dominio.ErrAtribuicaoCancelada:
+		c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": err.Error()})
+	}
+}
+
 func (ic *InstrumentoControlador) ListarInstrumentos(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -71,11 +86,7 @@ func (ic *InstrumentoControlador) AtribuirInstrumento(c *gin.Context) {
 
 	err = ic.instrumentoServico.CriarAtribuicao(userID.(uint), uint(pacienteID), uint(instrumentoID), instrumentoCodigoStr)
 	if err != nil {
-		if err == dominio.ErrUsuarioNaoEncontrado {
-			c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"erro": err.Error()})
-		}
+		respostaErroInstrumento(c, err)
 		return
 	}
 
@@ -166,7 +177,7 @@ func (ic *InstrumentoControlador) RegistrarRespostas(c *gin.Context) {
 
 	err := ic.instrumentoServico.CriarRespostasAtribuicao(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": err.Error()})
+		respostaErroInstrumento(c, err)
 		return
 	}
 
@@ -174,7 +185,7 @@ func (ic *InstrumentoControlador) RegistrarRespostas(c *gin.Context) {
 }
 
 func (ic *InstrumentoControlador) VisualizarRespostas(c *gin.Context) {
-	_, exists := c.Get("userID")
+	userID, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
 		return
@@ -191,13 +202,12 @@ func (ic *InstrumentoControlador) VisualizarRespostas(c *gin.Context) {
 		return
 	}
 
-	respostaOut, err := ic.instrumentoServico.VisualizarRespostaAtribuicao(uint(atribuicaoID))
+	respostaOut, err := ic.instrumentoServico.VisualizarRespostaAtribuicao(userID.(uint), uint(atribuicaoID))
 	if err != nil {
-
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": err.Error()})
-
+		respostaErroInstrumento(c, err)
 		return
 	}
+	auditarPacientes(c, respostaOut.Paciente.ID)
 
 	c.JSON(http.StatusOK, respostaOut)
 }
@@ -0,0 +1,24 @@
+package controladores
+
+import (
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSControlador publica as chaves publicas usadas para validar os tokens
+type JWKSControlador struct {
+	chavesJWT servicos.ChavesJWT
+}
+
+// NovoJWKSControlador cria uma nova instancia de JWKSControlador com o conjunto de chaves fornecido
+func NovoJWKSControlador(cj servicos.ChavesJWT) *JWKSControlador {
+	return &JWKSControlador{chavesJWT: cj}
+}
+
+// Publicar retorna o JWKS; o cache curto permite que novas chaves sejam vistas antes de assinarem tokens
+func (jc *JWKSControlador) Publicar(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jc.chavesJWT.JWKS())
+}
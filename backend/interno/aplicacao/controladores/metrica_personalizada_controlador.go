@@ -0,0 +1,106 @@
+package controladores
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricaPersonalizadaControlador gerencia as metricas personalizadas do diario
+type MetricaPersonalizadaControlador struct {
+	metricaServico servicos.MetricaPersonalizadaServico
+}
+
+func NovoMetricaPersonalizadaControlador(mps servicos.MetricaPersonalizadaServico) *MetricaPersonalizadaControlador {
+	return &MetricaPersonalizadaControlador{metricaServico: mps}
+}
+
+// respostaErroMetricaPersonalizada traduz os erros das metricas personalizadas em status HTTP
+func respostaErroMetricaPersonalizada(c *gin.Context, err error) {
+	switch err {
+	case dominio.ErrUsuarioNaoEncontrado, dominio.ErrVinculoNaoEncontrado, dominio.ErrMetricaPersonalizadaNaoEncontrada:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case dominio.ErrMetricaPersonalizadaDuplicada, dominio.ErrLimiteMetricasPersonalizadas:
+		c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+	case dominio.ErrRotuloMetricaInvalido, dominio.ErrTipoMetricaInvalido, dominio.ErrFaixaMetricaPersonalizadaInvalida:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao processar as metricas personalizadas"})
+	}
+}
+
+// Listar retorna as metricas ativas do paciente autenticado ou, para o profissional, do pacienteID da query
+func (mpc *MetricaPersonalizadaControlador) Listar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	tipoUsuario := c.GetString("tipo")
+
+	var pacienteID uint
+	if dominio.StringParaTipoUsuario(tipoUsuario) != dominio.TipoUsuarioPaciente {
+		id, ok := lerIDDaQuery(c, "pacienteID")
+		if !ok {
+			return
+		}
+		pacienteID = id
+	}
+
+	metricas, err := mpc.metricaServico.ListarMetricas(userID.(uint), tipoUsuario, pacienteID)
+	if err != nil {
+		respostaErroMetricaPersonalizada(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, metricas)
+}
+
+// Criar inclui uma metrica no diario do pacienteID da query
+func (mpc *MetricaPersonalizadaControlador) Criar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	pacienteID, ok := lerIDDaQuery(c, "pacienteID")
+	if !ok {
+		return
+	}
+	var req dtos.CriarMetricaPersonalizadaDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	metrica, err := mpc.metricaServico.CriarMetrica(userID.(uint), pacienteID, &req)
+	if err != nil {
+		respostaErroMetricaPersonalizada(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, metrica)
+}
+
+// Desativar retira a metrica do formulario do diario
+func (mpc *MetricaPersonalizadaControlador) Desativar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	metricaID, ok := lerIDDaQuery(c, "metricaID")
+	if !ok {
+		return
+	}
+
+	if err := mpc.metricaServico.DesativarMetrica(userID.(uint), metricaID); err != nil {
+		respostaErroMetricaPersonalizada(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
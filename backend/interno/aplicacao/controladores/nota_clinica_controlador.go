@@ -0,0 +1,215 @@
+package controladores
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotaClinicaControlador gerencia requisicoes HTTP do prontuario do profissional
+type NotaClinicaControlador struct {
+	notaClinicaServico servicos.NotaClinicaServico
+}
+
+// NovoNotaClinicaControlador cria uma nova instancia de NotaClinicaControlador com o NotaClinicaServico fornecido
+func NovoNotaClinicaControlador(ns servicos.NotaClinicaServico) *NotaClinicaControlador {
+	return &NotaClinicaControlador{notaClinicaServico: ns}
+}
+
+// respostaErroNotaClinica traduz os erros de dominio do prontuario para status HTTP
+func respostaErroNotaClinica(c *gin.Context, err error) {
+	switch err {
+	case dominio.ErrUsuarioNaoEncontrado, dominio.ErrVinculoNaoEncontrado, dominio.ErrNotaClinicaNaoEncontrada,
+		dominio.ErrCompartilhamentoNaoEncontrado:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case dominio.ErrNotaApenasAutor, dominio.ErrCompartilhamentoNotaInvalido:
+		c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+	case dominio.ErrTextoNotaVazio, dominio.ErrTextoNotaLongo, dominio.ErrTipoSessaoInvalido, dominio.ErrDataSessaoInvalida,
+		dominio.ErrTagNotaInvalida, dominio.ErrMuitasTagsNota:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao processar a nota clinica"})
+	}
+}
+
+// CriarNota grava uma nota do profissional autenticado sobre um paciente vinculado
+func (nc *NotaClinicaControlador) CriarNota(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	pacienteID, ok := lerIDDaQuery(c, "pacienteID")
+	if !ok {
+		return
+	}
+
+	var req dtos.NotaClinicaDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	notaOut, err := nc.notaClinicaServico.CriarNota(userID.(uint), pacienteID, &req)
+	if err != nil {
+		respostaErroNotaClinica(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, notaOut)
+}
+
+// ListarNotas busca nas notas de um paciente visiveis ao profissional autenticado
+func (nc *NotaClinicaControlador) ListarNotas(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	pacienteID, ok := lerIDDaQuery(c, "pacienteID")
+	if !ok {
+		return
+	}
+
+	filtro := dtos.FiltroNotasClinicasDTOIn{
+		Busca:      c.Query("busca"),
+		Tag:        c.Query("tag"),
+		TipoSessao: c.Query("tipo_sessao"),
+	}
+	if filtro.De, ok = lerDataDaQuery(c, "de"); !ok {
+		return
+	}
+	if filtro.Ate, ok = lerDataDaQuery(c, "ate"); !ok {
+		return
+	}
+
+	notasOut, err := nc.notaClinicaServico.ListarNotas(userID.(uint), pacienteID, &filtro)
+	if err != nil {
+		respostaErroNotaClinica(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, notasOut)
+}
+
+// BuscarNota retorna a revisao atual de uma nota
+func (nc *NotaClinicaControlador) BuscarNota(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	notaID, ok := lerIDDaQuery(c, "notaID")
+	if !ok {
+		return
+	}
+
+	notaOut, err := nc.notaClinicaServico.BuscarNota(userID.(uint), notaID)
+	if err != nil {
+		respostaErroNotaClinica(c, err)
+		return
+	}
+	auditarPacientes(c, notaOut.PacienteID)
+
+	c.JSON(http.StatusOK, notaOut)
+}
+
+// RevisarNota grava uma nova revisao da nota; apenas o autor pode revisar
+func (nc *NotaClinicaControlador) RevisarNota(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	notaID, ok := lerIDDaQuery(c, "notaID")
+	if !ok {
+		return
+	}
+
+	var req dtos.NotaClinicaDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	notaOut, err := nc.notaClinicaServico.RevisarNota(userID.(uint), notaID, &req)
+	if err != nil {
+		respostaErroNotaClinica(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, notaOut)
+}
+
+// ListarRevisoes retorna o historico de revisoes de uma nota
+func (nc *NotaClinicaControlador) ListarRevisoes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	notaID, ok := lerIDDaQuery(c, "notaID")
+	if !ok {
+		return
+	}
+
+	revisoesOut, err := nc.notaClinicaServico.ListarRevisoes(userID.(uint), notaID)
+	if err != nil {
+		respostaErroNotaClinica(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revisoesOut)
+}
+
+// CompartilharNota libera a leitura da nota a outro profissional do paciente
+func (nc *NotaClinicaControlador) CompartilharNota(c *gin.Context) {
+	nc.alterarCompartilhamento(c, nc.notaClinicaServico.CompartilharNota)
+}
+
+// DescompartilharNota retira a leitura da nota de um profissional
+func (nc *NotaClinicaControlador) DescompartilharNota(c *gin.Context) {
+	nc.alterarCompartilhamento(c, nc.notaClinicaServico.RevogarCompartilhamento)
+}
+
+func (nc *NotaClinicaControlador) alterarCompartilhamento(c *gin.Context, alterar func(userID, notaID, profissionalID uint) (*dtos.NotaClinicaDTOOut, error)) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	notaID, ok := lerIDDaQuery(c, "notaID")
+	if !ok {
+		return
+	}
+	profissionalID, ok := lerProfissionalID(c)
+	if !ok {
+		return
+	}
+
+	notaOut, err := alterar(userID.(uint), notaID, profissionalID)
+	if err != nil {
+		respostaErroNotaClinica(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, notaOut)
+}
+
+// lerDataDaQuery le um parametro opcional no formato AAAA-MM-DD
+func lerDataDaQuery(c *gin.Context, parametro string) (*time.Time, bool) {
+	valor := c.Query(parametro)
+	if valor == "" {
+		return nil, true
+	}
+	data, err := time.Parse("2006-01-02", valor)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Parametro '" + parametro + "' invalido, use AAAA-MM-DD"})
+		return nil, false
+	}
+	return &data, true
+}
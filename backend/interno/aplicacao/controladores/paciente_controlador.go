@@ -31,7 +31,7 @@ func (pc *PacienteControlador) Registrar(c *gin.Context) {
 	pacienteOut, err := pc.usuarioServico.RegistrarPaciente(&req)
 	if err != nil {
 		switch err {
-		case dominio.ErrEmailJaCadastrado:
+		case dominio.ErrEmailJaCadastrado, dominio.ErrCPFJaCadastrado:
 			c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
 		case dominio.ErrSenhaFraca:
 			c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
@@ -39,6 +39,9 @@ func (pc *PacienteControlador) Registrar(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
 		case dominio.ErrNomeVazio:
 			c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		case dominio.ErrTokenConviteInvalido, dominio.ErrConviteExpirado, dominio.ErrConviteJaUtilizado, dominio.ErrConviteRevogado,
+			dominio.ErrConviteOutroEmail:
+			respostaErroConvite(c, err)
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"erro": err.Error()})
 		}
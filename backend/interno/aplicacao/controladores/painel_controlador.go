@@ -0,0 +1,45 @@
+package controladores
+
+import (
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PainelControlador entrega o painel da carteira de pacientes do profissional
+type PainelControlador struct {
+	painelServico servicos.PainelServico
+}
+
+func NovoPainelControlador(ps servicos.PainelServico) *PainelControlador {
+	return &PainelControlador{painelServico: ps}
+}
+
+// GerarPainel retorna, em uma chamada, o resumo de todos os pacientes com vinculo ativo, ordenados por risco
+func (pc *PainelControlador) GerarPainel(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	painel, err := pc.painelServico.GerarPainelProfissional(userID.(uint))
+	if err != nil {
+		if err == dominio.ErrUsuarioNaoEncontrado {
+			c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao gerar o painel de pacientes"})
+		return
+	}
+
+	pacienteIDs := make([]uint, len(painel.Pacientes))
+	for i, p := range painel.Pacientes {
+		pacienteIDs[i] = p.PacienteID
+	}
+	auditarPacientes(c, pacienteIDs...)
+
+	c.JSON(http.StatusOK, painel)
+}
@@ -31,7 +31,7 @@ func (pc *ProfissionalControlador) Registrar(c *gin.Context) {
 	profissionalOut, err := pc.usuarioServico.RegistrarProfissional(&req)
 	if err != nil {
 		switch err {
-		case dominio.ErrEmailJaCadastrado:
+		case dominio.ErrEmailJaCadastrado, dominio.ErrCPFJaCadastrado:
 			c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
 		case dominio.ErrSenhaFraca:
 			c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
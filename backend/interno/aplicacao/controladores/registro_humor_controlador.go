@@ -3,7 +3,9 @@ package controladores
 import (
 	"mindtrace/backend/interno/aplicacao/dtos"
 	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,6 +20,24 @@ func NovoRegistroHumorControlador(us servicos.RegistroHumorServico) *RegistroHum
 	return &RegistroHumorControlador{registroHumorServico: us}
 }
 
+// respostaErroRegistroHumor traduz os erros de correcao do registro de humor em status HTTP
+func respostaErroRegistroHumor(c *gin.Context, err error) {
+	switch err {
+	case dominio.ErrUsuarioNaoEncontrado, dominio.ErrRegistroHumorNaoEncontrado, dominio.ErrVinculoNaoEncontrado:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case dominio.ErrJanelaCorrecaoEncerrada, dominio.ErrConsentimentoNegado:
+		c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+	case dominio.ErrNivelHumorInvalido, dominio.ErrHorasSonoInvalido, dominio.ErrNivelEnergiaInvalido, dominio.ErrNivelStressInvalido,
+		dominio.ErrAutoCuidadoVazio, dominio.ErrAutoCuidadoInvalido, dominio.ErrDataHoraRegistroNoFuturo,
+		dominio.ErrFaixaMetricaInvalida, dominio.ErrCursorDiarioInvalido, dominio.ErrLoteRegistrosHumorInvalido,
+		dominio.ErrAtividadeAutoCuidadoNaoEncontrada, dominio.ErrMetricaPersonalizadaNaoEncontrada, dominio.ErrValorMetricaInvalido,
+		dominio.ErrValorMetricaDuplicado:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao processar o registro de humor"})
+	}
+}
+
 // Criar cria um novo registro de humor para o usuario autenticado
 // Valida a entrada e chama o servico para criar o registro
 func (rhc *RegistroHumorControlador) Criar(c *gin.Context) {
@@ -35,10 +55,178 @@ func (rhc *RegistroHumorControlador) Criar(c *gin.Context) {
 	registro_humor, err := rhc.registroHumorServico.CriarRegistroHumor(&req, userID.(uint))
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": err.Error()})
+		respostaErroRegistroHumor(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, registro_humor)
 
 }
+
+// Sincronizar grava os registros feitos sem conexao pelo paciente autenticado
+// Responde 200 com o resultado de cada item, mesmo quando parte do lote e recusada
+func (rhc *RegistroHumorControlador) Sincronizar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	var req dtos.SincronizarRegistrosHumorDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	sincronizacao, err := rhc.registroHumorServico.SincronizarRegistrosHumor(userID.(uint), &req)
+	if err != nil {
+		respostaErroRegistroHumor(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sincronizacao)
+}
+
+// Atualizar corrige um registro de humor do paciente autenticado dentro do prazo de correcao
+func (rhc *RegistroHumorControlador) Atualizar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	registroID, ok := lerIDDaQuery(c, "registroID")
+	if !ok {
+		return
+	}
+	var req dtos.CriarRegistroHumorDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	registroOut, err := rhc.registroHumorServico.AtualizarRegistroHumor(registroID, userID.(uint), &req)
+	if err != nil {
+		respostaErroRegistroHumor(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, registroOut)
+}
+
+// Excluir apaga um registro de humor do paciente autenticado dentro do prazo de correcao
+func (rhc *RegistroHumorControlador) Excluir(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	registroID, ok := lerIDDaQuery(c, "registroID")
+	if !ok {
+		return
+	}
+
+	if err := rhc.registroHumorServico.ExcluirRegistroHumor(registroID, userID.(uint)); err != nil {
+		respostaErroRegistroHumor(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListarRevisoes retorna os valores anteriores de um registro de humor do paciente autenticado
+func (rhc *RegistroHumorControlador) ListarRevisoes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	registroID, ok := lerIDDaQuery(c, "registroID")
+	if !ok {
+		return
+	}
+
+	revisoesOut, err := rhc.registroHumorServico.ListarRevisoesRegistroHumor(registroID, userID.(uint))
+	if err != nil {
+		respostaErroRegistroHumor(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revisoesOut)
+}
+
+// Listar retorna o diario de humor paginado, do mais recente ao mais antigo
+// O paciente ve os proprios registros; o profissional informa o pacienteID na query
+func (rhc *RegistroHumorControlador) Listar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+	tipoUsuario := c.GetString("tipo")
+
+	var pacienteID uint
+	if dominio.StringParaTipoUsuario(tipoUsuario) != dominio.TipoUsuarioPaciente {
+		id, ok := lerIDDaQuery(c, "pacienteID")
+		if !ok {
+			return
+		}
+		pacienteID = id
+	}
+
+	filtro := dtos.FiltroRegistrosHumorDTOIn{
+		AutoCuidado: c.QueryArray("auto_cuidado"),
+		Busca:       c.Query("busca"),
+		Cursor:      c.Query("cursor"),
+	}
+	var ok bool
+	if filtro.De, ok = lerDataDaQuery(c, "de"); !ok {
+		return
+	}
+	if filtro.Ate, ok = lerDataDaQuery(c, "ate"); !ok {
+		return
+	}
+	faixas := []struct {
+		parametro string
+		destino   **int16
+	}{
+		{"humor_min", &filtro.HumorMin}, {"humor_max", &filtro.HumorMax},
+		{"sono_min", &filtro.SonoMin}, {"sono_max", &filtro.SonoMax},
+		{"energia_min", &filtro.EnergiaMin}, {"energia_max", &filtro.EnergiaMax},
+		{"stress_min", &filtro.StressMin}, {"stress_max", &filtro.StressMax},
+	}
+	for _, faixa := range faixas {
+		if *faixa.destino, ok = lerMetricaDaQuery(c, faixa.parametro); !ok {
+			return
+		}
+	}
+	if valor := c.Query("limite"); valor != "" {
+		limite, err := strconv.Atoi(valor)
+		if err != nil || limite <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "Parametro 'limite' invalido"})
+			return
+		}
+		filtro.Limite = limite
+	}
+
+	pagina, err := rhc.registroHumorServico.ListarRegistrosHumor(userID.(uint), tipoUsuario, pacienteID, &filtro)
+	if err != nil {
+		respostaErroRegistroHumor(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pagina)
+}
+
+// lerMetricaDaQuery le um limite opcional de metrica; ausente resulta em nil
+func lerMetricaDaQuery(c *gin.Context, parametro string) (*int16, bool) {
+	valor := c.Query(parametro)
+	if valor == "" {
+		return nil, true
+	}
+	numero, err := strconv.ParseInt(valor, 10, 16)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Parametro '" + parametro + "' invalido"})
+		return nil, false
+	}
+	metrica := int16(numero)
+	return &metrica, true
+}
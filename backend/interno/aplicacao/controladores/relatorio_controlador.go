@@ -1,7 +1,9 @@
 package controladores
 
 import (
+	"mindtrace/backend/interno/aplicacao/dtos"
 	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
 	"net/http"
 	"strconv"
 
@@ -18,6 +20,42 @@ func NovoRelatorioControlador(rs servicos.AnaliseServico) *RelatorioControlador
 	return &RelatorioControlador{analiseServico: rs}
 }
 
+// respostaErroAnalise traduz os erros de acesso ao historico do paciente para status HTTP
+func respostaErroAnalise(c *gin.Context, err error) {
+	switch err {
+	case dominio.ErrUsuarioNaoEncontrado:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case dominio.ErrVinculoNaoEncontrado, dominio.ErrConsentimentoNegado:
+		c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+	case dominio.ErrPeriodoCorrelacaoInvalido, dominio.ErrPeriodoAnaliseInvalido, dominio.ErrAgrupamentoInvalido,
+		dominio.ErrAgregacaoInvalida, dominio.ErrFusoInvalido, dominio.ErrPeriodoHistoricoInvalido, dominio.ErrIntervaloAnaliseInvalido:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": err.Error()})
+	}
+}
+
+// lerIntervaloDaQuery le as datas de inicio e fim (AAAA-MM-DD); nil quando nenhuma foi informada
+// Retorna false e responde 400 quando a data e invalida ou so uma das duas foi informada
+func lerIntervaloDaQuery(c *gin.Context, parametroDe, parametroAte string) (*dtos.IntervaloAnaliseDTOIn, bool) {
+	de, ok := lerDataDaQuery(c, parametroDe)
+	if !ok {
+		return nil, false
+	}
+	ate, ok := lerDataDaQuery(c, parametroAte)
+	if !ok {
+		return nil, false
+	}
+	if de == nil && ate == nil {
+		return nil, true
+	}
+	if de == nil || ate == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Informe os parametros '" + parametroDe + "' e '" + parametroAte + "' juntos"})
+		return nil, false
+	}
+	return &dtos.IntervaloAnaliseDTOIn{De: *de, Ate: *ate}, true
+}
+
 // GerarRelatorio gera um relatorio para o paciente autenticado
 // Extrai o periodo da query e chama o servico para gerar o relatorio
 func (rc *RelatorioControlador) GerarRelatorio(c *gin.Context) {
@@ -30,6 +68,20 @@ func (rc *RelatorioControlador) GerarRelatorio(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"erro": "tipo de usuario nao encontrado no token"})
 	}
 
+	intervalo, ok := lerIntervaloDaQuery(c, "de", "ate")
+	if !ok {
+		return
+	}
+	if intervalo != nil {
+		relatorio, err := rc.analiseServico.GerarAnaliseIntervalo(userID.(uint), 0, tipoUsuario.(string), *intervalo)
+		if err != nil {
+			respostaErroAnalise(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, relatorio)
+		return
+	}
+
 	periodoStr := c.DefaultQuery("periodo", "7")
 	periodo, err := strconv.Atoi(periodoStr)
 	if err != nil {
@@ -55,36 +107,159 @@ func (rc *RelatorioControlador) GerarAnaliseHistorica(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID de usuario nao encontrado no token"})
+		return
 	}
 	tipoUsuario, exists := c.Get("tipo")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"erro": "tipo de usuario nao encontrado no token"})
+		return
 	}
 
 	pacienteIDStr := c.DefaultQuery("pacienteID", "0")
 	if pacienteIDStr == "0" {
 		c.JSON(http.StatusBadRequest, gin.H{"erro": "ID de paciente invalido"})
+		return
 	}
 	pacienteID, err := strconv.Atoi(pacienteIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"erro": "Parametro 'pacienteID' invalido"})
+		return
 	}
 
-	periodoStr := c.DefaultQuery("periodo", "7")
-	periodo, err := strconv.Atoi(periodoStr)
+	intervalo, ok := lerIntervaloDaQuery(c, "de", "ate")
+	if !ok {
+		return
+	}
+	var relatorio *dtos.AnalisePacienteDTOOut
+	if intervalo != nil {
+		relatorio, err = rc.analiseServico.GerarAnaliseIntervalo(userID.(uint), uint(pacienteID), tipoUsuario.(string), *intervalo)
+	} else {
+		periodoStr := c.DefaultQuery("periodo", "7")
+		periodo, errPeriodo := strconv.Atoi(periodoStr)
+		if errPeriodo != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "Parametro 'periodo' invalido"})
+			return
+		}
+		relatorio, err = rc.analiseServico.GerarAnaliseHistorica(userID.(uint), uint(pacienteID), tipoUsuario.(string), int(periodo))
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"erro": "Parametro 'periodo' invalido"})
+		respostaErroAnalise(c, err)
+		return
 	}
-	relatorio, err := rc.analiseServico.GerarAnaliseHistorica(userID.(uint), uint(pacienteID), tipoUsuario.(string), int(periodo))
 
-	if relatorio == nil {
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"erro": err.Error()})
+	c.JSON(http.StatusOK, relatorio)
+}
+
+// GerarCorrelacoes calcula as correlacoes entre as metricas diarias do paciente
+// O paciente ve os proprios dados; o profissional informa o pacienteID na query
+func (rc *RelatorioControlador) GerarCorrelacoes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID de usuario nao encontrado no token"})
+		return
+	}
+	tipoUsuario := c.GetString("tipo")
+
+	var pacienteID uint
+	if dominio.StringParaTipoUsuario(tipoUsuario) != dominio.TipoUsuarioPaciente {
+		id, ok := lerIDDaQuery(c, "pacienteID")
+		if !ok {
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"erro": "sem dados de humor registrados para este periodo"})
+		pacienteID = id
+	}
+	dias, err := strconv.Atoi(c.DefaultQuery("dias", "0"))
+	if err != nil || dias < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Parametro 'dias' invalido"})
+		return
+	}
 
+	correlacoes, err := rc.analiseServico.GerarCorrelacoes(userID.(uint), pacienteID, tipoUsuario, dias)
+	if err != nil {
+		respostaErroAnalise(c, err)
+		return
 	}
 
-	c.JSON(http.StatusOK, relatorio)
+	c.JSON(http.StatusOK, correlacoes)
+}
+
+// GerarAnaliseAgregada agrupa as metricas do paciente por dia ou semana
+// Query: dias, agrupamento (DIA ou SEMANA), agregacao (MEDIA, MINIMO, MAXIMO ou ULTIMO) e fuso (nome IANA)
+// O paciente ve os proprios dados; o profissional informa o pacienteID na query
+func (rc *RelatorioControlador) GerarAnaliseAgregada(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID de usuario nao encontrado no token"})
+		return
+	}
+	tipoUsuario := c.GetString("tipo")
+
+	var pacienteID uint
+	if dominio.StringParaTipoUsuario(tipoUsuario) != dominio.TipoUsuarioPaciente {
+		id, ok := lerIDDaQuery(c, "pacienteID")
+		if !ok {
+			return
+		}
+		pacienteID = id
+	}
+	dias, err := strconv.Atoi(c.DefaultQuery("dias", "0"))
+	if err != nil || dias < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Parametro 'dias' invalido"})
+		return
+	}
+	filtro := dtos.FiltroAnaliseAgregadaDTOIn{
+		Dias:        dias,
+		Agrupamento: c.Query("agrupamento"),
+		Agregacao:   c.Query("agregacao"),
+		Fuso:        c.Query("fuso"),
+	}
+
+	analise, err := rc.analiseServico.GerarAnaliseAgregada(userID.(uint), pacienteID, tipoUsuario, &filtro)
+	if err != nil {
+		respostaErroAnalise(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, analise)
+}
+
+// CompararPeriodos compara a analise de dois intervalos do paciente
+// Query: de e ate do periodo, comparar_de e comparar_ate da referencia (AAAA-MM-DD)
+// O paciente ve os proprios dados; o profissional informa o pacienteID na query
+func (rc *RelatorioControlador) CompararPeriodos(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID de usuario nao encontrado no token"})
+		return
+	}
+	tipoUsuario := c.GetString("tipo")
+
+	var pacienteID uint
+	if dominio.StringParaTipoUsuario(tipoUsuario) != dominio.TipoUsuarioPaciente {
+		id, ok := lerIDDaQuery(c, "pacienteID")
+		if !ok {
+			return
+		}
+		pacienteID = id
+	}
+	periodo, ok := lerIntervaloDaQuery(c, "de", "ate")
+	if !ok {
+		return
+	}
+	referencia, ok := lerIntervaloDaQuery(c, "comparar_de", "comparar_ate")
+	if !ok {
+		return
+	}
+	if periodo == nil || referencia == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Informe os parametros 'de', 'ate', 'comparar_de' e 'comparar_ate'"})
+		return
+	}
+
+	comparacao, err := rc.analiseServico.CompararPeriodos(userID.(uint), pacienteID, tipoUsuario, *periodo, *referencia)
+	if err != nil {
+		respostaErroAnalise(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, comparacao)
 }
@@ -0,0 +1,377 @@
+package controladores
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResponsavelControlador gerencia requisicoes HTTP do responsavel e da autorizacao feita pelo paciente
+type ResponsavelControlador struct {
+	responsavelServico servicos.ResponsavelServico
+}
+
+// NovoResponsavelControlador cria uma nova instancia de ResponsavelControlador com o ResponsavelServico fornecido
+func NovoResponsavelControlador(rs servicos.ResponsavelServico) *ResponsavelControlador {
+	return &ResponsavelControlador{responsavelServico: rs}
+}
+
+// respostaErroResponsavel traduz os erros de dominio do responsavel para status HTTP
+func respostaErroResponsavel(c *gin.Context, err error) {
+	switch err {
+	case dominio.ErrUsuarioNaoEncontrado, dominio.ErrResponsavelNaoEncontrado, dominio.ErrDependenteNaoEncontrado,
+		dominio.ErrVinculoNaoEncontrado, dominio.ErrConsentimentoNaoEncontrado:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case dominio.ErrPacienteNaoDependente, dominio.ErrMenorNaoRemoveResponsavel, dominio.ErrAtribuicaoDeOutroPaciente:
+		c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+	case dominio.ErrEmailJaCadastrado, dominio.ErrCPFJaCadastrado, dominio.ErrResponsavelJaVinculado, dominio.ErrVinculoJaEncerrado, dominio.ErrAtribuicaoCancelada:
+		c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+	case dominio.ErrSenhaFraca, dominio.ErrSenhaInvalida, dominio.ErrEmailInvalido, dominio.ErrNomeVazio, dominio.ErrParentescoLongo,
+		dominio.ErrCategoriaConsentimentoInvalida, dominio.ErrExpiracaoConsentimentoInvalida:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao processar a solicitacao do responsavel"})
+	}
+}
+
+// Registrar cria a conta de um responsavel
+func (rc *ResponsavelControlador) Registrar(c *gin.Context) {
+	var req dtos.RegistrarResponsavelDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	responsavelOut, err := rc.responsavelServico.RegistrarResponsavel(&req)
+	if err != nil {
+		respostaErroResponsavel(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, responsavelOut)
+}
+
+// Autorizar permite ao paciente autenticado vincular um responsavel pelo e-mail da conta
+func (rc *ResponsavelControlador) Autorizar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	var req dtos.AutorizarResponsavelDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	vinculoOut, err := rc.responsavelServico.AutorizarResponsavel(userID.(uint), &req)
+	if err != nil {
+		respostaErroResponsavel(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, vinculoOut)
+}
+
+// ListarResponsaveis retorna os responsaveis ativos do paciente autenticado
+func (rc *ResponsavelControlador) ListarResponsaveis(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	vinculosOut, err := rc.responsavelServico.ListarResponsaveis(userID.(uint))
+	if err != nil {
+		respostaErroResponsavel(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, vinculosOut)
+}
+
+// Remover encerra, a pedido do paciente autenticado, o vinculo com o responsavel informado
+func (rc *ResponsavelControlador) Remover(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	responsavelID, ok := lerIDDaQuery(c, "responsavelID")
+	if !ok {
+		return
+	}
+
+	if err := rc.responsavelServico.RemoverResponsavel(userID.(uint), responsavelID); err != nil {
+		respostaErroResponsavel(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mensagem": "Responsavel removido com sucesso"})
+}
+
+// ListarAcoes mostra ao paciente autenticado as acoes dos responsaveis em seu nome
+func (rc *ResponsavelControlador) ListarAcoes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	acoesOut, err := rc.responsavelServico.ListarAcoesSobrePaciente(userID.(uint))
+	if err != nil {
+		respostaErroResponsavel(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, acoesOut)
+}
+
+// ListarDependentes retorna os pacientes acompanhados pelo responsavel autenticado
+func (rc *ResponsavelControlador) ListarDependentes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	dependentesOut, err := rc.responsavelServico.ListarDependentes(userID.(uint))
+	if err != nil {
+		respostaErroResponsavel(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dependentesOut)
+}
+
+// ResumoDependente retorna o resumo do dependente informado
+func (rc *ResponsavelControlador) ResumoDependente(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	pacienteID, ok := lerIDDaQuery(c, "pacienteID")
+	if !ok {
+		return
+	}
+
+	resumoOut, err := rc.responsavelServico.ResumoDependente(userID.(uint), pacienteID)
+	if err != nil {
+		respostaErroResponsavel(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resumoOut)
+}
+
+// ListarAtribuicoesDependente retorna os questionarios atribuidos ao dependente informado
+func (rc *ResponsavelControlador) ListarAtribuicoesDependente(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	pacienteID, ok := lerIDDaQuery(c, "pacienteID")
+	if !ok {
+		return
+	}
+
+	atribuicoesOut, err := rc.responsavelServico.ListarAtribuicoesDependente(userID.(uint), pacienteID)
+	if err != nil {
+		respostaErroResponsavel(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, atribuicoesOut)
+}
+
+// PerguntasAtribuicaoDependente apresenta as perguntas de uma atribuicao do dependente
+func (rc *ResponsavelControlador) PerguntasAtribuicaoDependente(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	pacienteID, ok := lerIDDaQuery(c, "pacienteID")
+	if !ok {
+		return
+	}
+	atribuicaoID, ok := lerIDDaQuery(c, "atribuicaoID")
+	if !ok {
+		return
+	}
+
+	atribuicaoOut, err := rc.responsavelServico.PerguntasAtribuicaoDependente(userID.(uint), pacienteID, atribuicaoID)
+	if err != nil {
+		respostaErroResponsavel(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, atribuicaoOut)
+}
+
+// ResponderAtribuicaoDependente registra as respostas em nome do dependente
+func (rc *ResponsavelControlador) ResponderAtribuicaoDependente(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	pacienteID, ok := lerIDDaQuery(c, "pacienteID")
+	if !ok {
+		return
+	}
+
+	var req dtos.RegistroRespostaDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	if err := rc.responsavelServico.ResponderAtribuicaoDependente(userID.(uint), pacienteID, &req); err != nil {
+		respostaErroResponsavel(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"mensagem": "Respostas registradas com sucesso"})
+}
+
+// ConsentimentosDependente retorna o consentimento vigente do dependente com cada profissional
+func (rc *ResponsavelControlador) ConsentimentosDependente(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	pacienteID, ok := lerIDDaQuery(c, "pacienteID")
+	if !ok {
+		return
+	}
+
+	consentimentosOut, err := rc.responsavelServico.ConsentimentosDependente(userID.(uint), pacienteID)
+	if err != nil {
+		respostaErroResponsavel(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consentimentosOut)
+}
+
+// AtualizarConsentimentoDependente grava uma nova versao do consentimento do dependente com o profissional
+func (rc *ResponsavelControlador) AtualizarConsentimentoDependente(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	pacienteID, ok := lerIDDaQuery(c, "pacienteID")
+	if !ok {
+		return
+	}
+	profissionalID, ok := lerProfissionalID(c)
+	if !ok {
+		return
+	}
+
+	var req dtos.AtualizarConsentimentoDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	consentimentoOut, err := rc.responsavelServico.AtualizarConsentimentoDependente(userID.(uint), pacienteID, profissionalID, &req)
+	if err != nil {
+		respostaErroResponsavel(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consentimentoOut)
+}
+
+// RevogarConsentimentoDependente interrompe o compartilhamento do dependente com o profissional
+func (rc *ResponsavelControlador) RevogarConsentimentoDependente(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	pacienteID, ok := lerIDDaQuery(c, "pacienteID")
+	if !ok {
+		return
+	}
+	profissionalID, ok := lerProfissionalID(c)
+	if !ok {
+		return
+	}
+
+	consentimentoOut, err := rc.responsavelServico.RevogarConsentimentoDependente(userID.(uint), pacienteID, profissionalID)
+	if err != nil {
+		respostaErroResponsavel(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consentimentoOut)
+}
+
+// EncerrarDependente encerra o acompanhamento do dependente pelo responsavel autenticado
+func (rc *ResponsavelControlador) EncerrarDependente(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	pacienteID, ok := lerIDDaQuery(c, "pacienteID")
+	if !ok {
+		return
+	}
+
+	if err := rc.responsavelServico.EncerrarDependente(userID.(uint), pacienteID); err != nil {
+		respostaErroResponsavel(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mensagem": "Acompanhamento encerrado com sucesso"})
+}
+
+// ListarNotificacoes retorna as notificacoes recentes do responsavel autenticado
+func (rc *ResponsavelControlador) ListarNotificacoes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	notificacoesOut, err := rc.responsavelServico.ListarNotificacoes(userID.(uint))
+	if err != nil {
+		respostaErroResponsavel(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, notificacoesOut)
+}
+
+// lerIDDaQuery le um ID positivo do parametro de query informado
+// Em caso de erro a resposta 400 ja e enviada
+func lerIDDaQuery(c *gin.Context, parametro string) (uint, bool) {
+	id, err := strconv.Atoi(c.DefaultQuery(parametro, "0"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Parametro '" + parametro + "' invalido"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/controladores"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// setupRelatorios grava o paciente 1 (usuario 10), vinculado ao profissional 5 (usuario 50) com consentimento,
+// o profissional 6 (usuario 60) sem vinculo, o responsavel 4 (usuario 40) de outro paciente
+// e o responsavel 7 (usuario 70), cuja tutela do paciente 1 ja foi encerrada
+func setupRelatorios(t *testing.T) *gin.Engine {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Falha ao abrir banco de dados de teste: %v", err)
+	}
+	assert.NoError(t, db.AutoMigrate(&dominio.Vinculo{}))
+	assert.NoError(t, db.AutoMigrate(&dominio.Usuario{}, &dominio.Profissional{}, &dominio.Paciente{}, &dominio.Responsavel{},
+		&dominio.VinculoResponsavel{}, &dominio.Consentimento{}, &dominio.RegistroHumor{}, &dominio.MetricaPersonalizada{},
+		&dominio.ValorMetricaRegistro{}, &dominio.Notificacao{}))
+
+	agora := time.Now()
+	nascimento := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, u := range []*dominio.Usuario{
+		{ID: 10, TipoUsuario: dominio.TipoUsuarioPaciente, Nome: "Ana", Email: "ana@teste.com", Senha: "x"},
+		{ID: 20, TipoUsuario: dominio.TipoUsuarioPaciente, Nome: "Bia", Email: "bia@teste.com", Senha: "x"},
+		{ID: 40, TipoUsuario: dominio.TipoUsuarioResponsavel, Nome: "Carlos", Email: "carlos@teste.com", Senha: "x"},
+		{ID: 50, TipoUsuario: dominio.TipoUsuarioProfissional, Nome: "Dra. Eva", Email: "eva@teste.com", Senha: "x"},
+		{ID: 60, TipoUsuario: dominio.TipoUsuarioProfissional, Nome: "Dr. Davi", Email: "davi@teste.com", Senha: "x"},
+		{ID: 70, TipoUsuario: dominio.TipoUsuarioResponsavel, Nome: "Fabio", Email: "fabio@teste.com", Senha: "x"},
+	} {
+		assert.NoError(t, db.Create(u).Error)
+	}
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Paciente{ID: 1, UsuarioID: 10, DataNascimento: nascimento}).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Paciente{ID: 2, UsuarioID: 20, DataNascimento: nascimento}).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Profissional{ID: 5, UsuarioID: 50, RegistroProfissional: "CRP5", Especialidade: "Psicologia"}).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Profissional{ID: 6, UsuarioID: 60, RegistroProfissional: "CRP6", Especialidade: "Psicologia"}).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Responsavel{ID: 4, UsuarioID: 40}).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Responsavel{ID: 7, UsuarioID: 70}).Error)
+	assert.NoError(t, db.Create(&dominio.Vinculo{PacienteID: 1, ProfissionalID: 5}).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(dominio.NovoConsentimentoPadrao(1, 5, agora.AddDate(0, -6, 0))).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.VinculoResponsavel{ResponsavelID: 4, PacienteID: 2, VinculadoEm: agora}).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.VinculoResponsavel{ResponsavelID: 7, PacienteID: 1, VinculadoEm: agora.AddDate(0, -1, 0), EncerradoEm: &agora}).Error)
+
+	analiseServico := servicos.NovoAnaliseServico(db, sqlite_repo.NovoGormRegistroHumorRepositorio(db), sqlite_repo.NovoGormUsuarioRepositorio(db),
+		sqlite_repo.NovoGormConsentimentoRepositorio(db), sqlite_repo.NovoGormVinculoRepositorio(db), sqlite_repo.NovoGormNotificacaoRepositorio(db),
+		sqlite_repo.NovoGormResponsavelRepositorio(db), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db))
+	relatorioCtrl := controladores.NovoRelatorioControlador(analiseServico)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	// Simula o middleware de autenticacao a partir dos cabecalhos do teste
+	r.Use(func(c *gin.Context) {
+		userID, _ := strconv.ParseUint(c.GetHeader("X-Usuario"), 10, 64)
+		c.Set("userID", uint(userID))
+		c.Set("tipo", c.GetHeader("X-Tipo"))
+	})
+	r.GET("/relatorios/correlacoes", relatorioCtrl.GerarCorrelacoes)
+	r.GET("/relatorios/agregada", relatorioCtrl.GerarAnaliseAgregada)
+	r.GET("/relatorios/comparacao", relatorioCtrl.CompararPeriodos)
+	return r
+}
+
+func requisicaoRelatorio(r *gin.Engine, rota, usuario, tipo string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, rota, nil)
+	req.Header.Set("X-Usuario", usuario)
+	req.Header.Set("X-Tipo", tipo)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRelatorioControlador_AcessoAoPaciente(t *testing.T) {
+	r := setupRelatorios(t)
+	hoje := time.Now()
+	periodo := "de=" + hoje.AddDate(0, 0, -7).Format("2006-01-02") + "&ate=" + hoje.Format("2006-01-02") +
+		"&comparar_de=" + hoje.AddDate(0, 0, -14).Format("2006-01-02") + "&comparar_ate=" + hoje.AddDate(0, 0, -8).Format("2006-01-02")
+	rotas := []string{
+		"/relatorios/correlacoes?pacienteID=1",
+		"/relatorios/agregada?pacienteID=1",
+		"/relatorios/comparacao?pacienteID=1&" + periodo,
+	}
+
+	casos := []struct {
+		nome    string
+		usuario string
+		tipo    string
+		status  int
+	}{
+		{"profissional vinculado", "50", "profissional", http.StatusOK},
+		{"profissional sem vinculo", "60", "profissional", http.StatusForbidden},
+		{"responsavel de outro paciente", "40", "responsavel", http.StatusForbidden},
+		{"responsavel com tutela encerrada", "70", "responsavel", http.StatusForbidden},
+		{"responsavel sem cadastro", "41", "responsavel", http.StatusNotFound},
+		{"tipo de usuario desconhecido", "60", "admin", http.StatusForbidden},
+	}
+
+	for _, caso := range casos {
+		for _, rota := range rotas {
+			w := requisicaoRelatorio(r, rota, caso.usuario, caso.tipo)
+			assert.Equal(t, caso.status, w.Code, "%s em %s: %s", caso.nome, rota, w.Body.String())
+		}
+	}
+}
+
+func TestRelatorioControlador_PacienteNaoVeOutroPaciente(t *testing.T) {
+	r := setupRelatorios(t)
+
+	// O paciente sempre consulta os proprios dados: o pacienteID da query e ignorado
+	w := requisicaoRelatorio(r, "/relatorios/correlacoes?pacienteID=2", "10", "paciente")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"paciente_id":1`)
+}
@@ -103,23 +103,11 @@ func (uc *UsuarioControlador) ListarPacientesDoProfissional(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, pacientesOut)
-}
-
-// DeletarPerfil deleta o perfil do usuario autenticado
-// Extrai o ID do usuario e chama o servico para deletar a conta
-func (uc *UsuarioControlador) DeletarPerfil(c *gin.Context) {
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
-		return
+	pacienteIDs := make([]uint, len(pacientesOut))
+	for i, p := range pacientesOut {
+		pacienteIDs[i] = p.ID
 	}
+	auditarPacientes(c, pacienteIDs...)
 
-	err := uc.usuarioServico.DeletarPerfil(userID.(uint))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"mensagem": "Conta deletada com sucesso"})
+	c.JSON(http.StatusOK, pacientesOut)
 }
@@ -0,0 +1,97 @@
+package controladores
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VinculoControlador gerencia requisicoes HTTP de encerramento de vinculo e alta
+type VinculoControlador struct {
+	vinculoServico servicos.VinculoServico
+}
+
+// NovoVinculoControlador cria uma nova instancia de VinculoControlador com o VinculoServico fornecido
+func NovoVinculoControlador(vs servicos.VinculoServico) *VinculoControlador {
+	return &VinculoControlador{vinculoServico: vs}
+}
+
+// respostaErroVinculo traduz os erros de dominio do vinculo para status HTTP
+func respostaErroVinculo(c *gin.Context, err error) {
+	switch err {
+	case dominio.ErrUsuarioNaoEncontrado, dominio.ErrVinculoNaoEncontrado:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case dominio.ErrVinculoJaEncerrado:
+		c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+	case dominio.ErrMotivoEncerramentoVazio, dominio.ErrMotivoEncerramentoLongo:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao encerrar o vinculo"})
+	}
+}
+
+// Encerrar permite ao paciente autenticado encerrar o vinculo com o profissional informado
+func (vc *VinculoControlador) Encerrar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	profissionalID, ok := lerProfissionalID(c)
+	if !ok {
+		return
+	}
+
+	var req dtos.EncerrarVinculoDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	encerramentoOut, err := vc.vinculoServico.EncerrarVinculo(userID.(uint), profissionalID, &req)
+	if err != nil {
+		respostaErroVinculo(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, encerramentoOut)
+}
+
+// DarAlta permite ao profissional autenticado encerrar o acompanhamento do paciente informado
+func (vc *VinculoControlador) DarAlta(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "ID do usuário não encontrado no token"})
+		return
+	}
+
+	pacienteIDStr := c.DefaultQuery("pacienteID", "0")
+	if pacienteIDStr == "0" {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "ID de paciente invalido"})
+		return
+	}
+	pacienteID, err := strconv.Atoi(pacienteIDStr)
+	if err != nil || pacienteID < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Parametro 'pacienteID' invalido"})
+		return
+	}
+
+	var req dtos.EncerrarVinculoDTOIn
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	encerramentoOut, err := vc.vinculoServico.DarAlta(userID.(uint), uint(pacienteID), &req)
+	if err != nil {
+		respostaErroVinculo(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, encerramentoOut)
+}
@@ -1,6 +1,7 @@
 package dtos
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/datatypes"
@@ -20,13 +21,35 @@ type LoginDTOIn struct {
 
 // CriarRegistroHumorDTOOut representa os dados para criar um registro de humor
 type CriarRegistroHumorDTOIn struct {
-	NivelHumor       int16     `json:"nivel_humor" binding:"required,min=1,max=5"`
-	HorasSono        *int16    `json:"horas_sono" binding:"required,min=0,max=12"`
-	NivelStress      int16     `json:"nivel_stress" binding:"required,min=1,max=10"`
-	NivelEnergia     int16     `json:"nivel_energia" binding:"required,min=1,max=10"`
-	AutoCuidado      []string  `json:"auto_cuidado" binding:"required"`
+	NivelHumor   int16  `json:"nivel_humor" binding:"required,min=1,max=5"`
+	HorasSono    *int16 `json:"horas_sono" binding:"required,min=0,max=12"`
+	NivelStress  int16  `json:"nivel_stress" binding:"required,min=1,max=10"`
+	NivelEnergia int16  `json:"nivel_energia" binding:"required,min=1,max=10"`
+	// AutoCuidadoIDs referencia o catalogo; AutoCuidado aceita os nomes do catalogo para aplicativos antigos
+	AutoCuidadoIDs   []uint    `json:"auto_cuidado_ids"`
+	AutoCuidado      []string  `json:"auto_cuidado"`
 	Observacoes      string    `json:"observacoes"`
 	DataHoraRegistro time.Time `json:"data_hora_registro"`
+	// Metricas traz os valores das metricas personalizadas do paciente; nenhuma e obrigatoria
+	Metricas []ValorMetricaDTOIn `json:"metricas"`
+}
+
+// ValorMetricaDTOIn e o valor de uma metrica personalizada; em SIM_NAO, 1 e sim e 0 e nao
+type ValorMetricaDTOIn struct {
+	MetricaID uint     `json:"metrica_id"`
+	Valor     *float64 `json:"valor"`
+}
+
+// SincronizarRegistrosHumorDTOIn traz os registros de humor feitos sem conexao
+// Os itens sao validados um a um, para que um registro invalido nao descarte o lote
+type SincronizarRegistrosHumorDTOIn struct {
+	Registros []ItemSincronizacaoRegistroHumorDTOIn `json:"registros" binding:"required"`
+}
+
+// ItemSincronizacaoRegistroHumorDTOIn e um registro do lote com a chave gerada pelo aplicativo
+type ItemSincronizacaoRegistroHumorDTOIn struct {
+	ChaveIdempotencia string `json:"chave_idempotencia"`
+	CriarRegistroHumorDTOIn
 }
 
 // RegistrarProfissionalDTOIn representa os dados para criar um profissional
@@ -54,6 +77,23 @@ type RegistrarPacienteDTOIn struct {
 	NomeResponsavel      string     `json:"nome_responsavel,omitempty"`
 	ContatoResponsavel   string     `json:"contato_responsavel,omitempty"`
 	Contato              string     `json:"contato"`
+	// TokenConvite vincula o paciente ao profissional do convite no proprio cadastro
+	TokenConvite string `json:"token_convite,omitempty"`
+}
+
+// RegistrarResponsavelDTOIn representa os dados para criar a conta de um responsavel
+type RegistrarResponsavelDTOIn struct {
+	Nome    string `json:"nome" binding:"required"`
+	Email   string `json:"email" binding:"required,email"`
+	Senha   string `json:"senha" binding:"required,min=8"`
+	CPF     string `json:"cpf" binding:"required"`
+	Contato string `json:"contato"`
+}
+
+// AutorizarResponsavelDTOIn identifica, pelo e-mail da conta, o responsavel autorizado pelo paciente
+type AutorizarResponsavelDTOIn struct {
+	Email      string `json:"email" binding:"required,email"`
+	Parentesco string `json:"parentesco" binding:"max=50"`
 }
 
 // AtualizarPerfilDTOIn representa os dados para atualizar o perfil do usuario
@@ -79,6 +119,145 @@ type AlterarSenhaDTOIn struct {
 	NovaSenhaRe string `json:"nova_senha_re" binding:"required,min=8"`
 }
 
+// SolicitarRedefinicaoSenhaDTOIn representa o pedido de "esqueci minha senha"
+type SolicitarRedefinicaoSenhaDTOIn struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ConfirmarRedefinicaoSenhaDTOIn representa a troca de senha usando o token recebido por e-mail
+type ConfirmarRedefinicaoSenhaDTOIn struct {
+	Token       string `json:"token" binding:"required"`
+	NovaSenha   string `json:"nova_senha" binding:"required,min=8"`
+	NovaSenhaRe string `json:"nova_senha_re" binding:"required,min=8"`
+}
+
+// ConfirmarEmailDTOIn representa a confirmacao de e-mail com o token recebido
+type ConfirmarEmailDTOIn struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ReenviarVerificacaoEmailDTOIn representa o pedido de reenvio do e-mail de verificacao
+type ReenviarVerificacaoEmailDTOIn struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ConcluirLoginDoisFatoresDTOIn representa a segunda etapa do login com codigo TOTP ou de recuperacao
+type ConcluirLoginDoisFatoresDTOIn struct {
+	DesafioToken string `json:"desafio_token" binding:"required"`
+	Codigo       string `json:"codigo" binding:"required"`
+}
+
+// CodigoDoisFatoresDTOIn representa a confirmacao de uma operacao com o codigo do autenticador
+type CodigoDoisFatoresDTOIn struct {
+	Codigo string `json:"codigo" binding:"required"`
+}
+
+// DesativarDoisFatoresDTOIn exige senha e codigo para remover o segundo fator
+type DesativarDoisFatoresDTOIn struct {
+	Senha  string `json:"senha" binding:"required"`
+	Codigo string `json:"codigo" binding:"required"`
+}
+
+// SolicitarExclusaoContaDTOIn representa o pedido de exclusao da conta; o motivo e opcional
+type SolicitarExclusaoContaDTOIn struct {
+	Motivo string `json:"motivo" binding:"omitempty,max=1000"`
+}
+
+// SolicitarExportacaoDTOIn representa o pedido de exportacao dos dados do titular (LGPD)
+type SolicitarExportacaoDTOIn struct {
+	Formato string `json:"formato" binding:"omitempty,oneof=json zip"`
+}
+
+// AtualizarConsentimentoDTOIn representa uma nova versao do consentimento do paciente
+// Sem data_inicio, a nova versao mantem a data da versao anterior
+type AtualizarConsentimentoDTOIn struct {
+	Categorias    []string   `json:"categorias" binding:"required,dive,oneof=humor sono observacoes questionarios"`
+	DataInicio    *time.Time `json:"data_inicio"`
+	DataExpiracao *time.Time `json:"data_expiracao"`
+}
+
+// NotaClinicaDTOIn representa o conteudo de uma nota clinica nova ou de uma nova revisao
+type NotaClinicaDTOIn struct {
+	Texto      string    `json:"texto" binding:"required,max=20000"`
+	DataSessao time.Time `json:"data_sessao" binding:"required"`
+	TipoSessao string    `json:"tipo_sessao" binding:"required,oneof=INDIVIDUAL CASAL FAMILIA GRUPO AVALIACAO DEVOLUTIVA"`
+	Tags       []string  `json:"tags" binding:"max=10,dive,min=1,max=30"`
+}
+
+// FiltroNotasClinicasDTOIn representa a busca nas notas de um paciente, lida da query
+// De e Ate limitam a data da sessao e sao inclusivos
+type FiltroNotasClinicasDTOIn struct {
+	Busca      string
+	Tag        string
+	TipoSessao string
+	De         *time.Time
+	Ate        *time.Time
+}
+
+// FiltroRegistrosHumorDTOIn representa a busca no diario de humor, lida da query
+// De e Ate limitam a data do registro e sao inclusivos; as faixas de metricas tambem
+type FiltroRegistrosHumorDTOIn struct {
+	De          *time.Time
+	Ate         *time.Time
+	HumorMin    *int16
+	HumorMax    *int16
+	SonoMin     *int16
+	SonoMax     *int16
+	EnergiaMin  *int16
+	EnergiaMax  *int16
+	StressMin   *int16
+	StressMax   *int16
+	AutoCuidado []string
+	Busca       string
+	Cursor      string
+	Limite      int
+}
+
+// ConsultaDTOIn representa o agendamento de uma consulta
+// Com repeticoes_semanais maior que 1, a consulta se repete no mesmo horario nas semanas seguintes
+type ConsultaDTOIn struct {
+	Inicio             time.Time `json:"inicio" binding:"required"`
+	Fim                time.Time `json:"fim" binding:"required"`
+	Modalidade         string    `json:"modalidade" binding:"required,oneof=PRESENCIAL ONLINE"`
+	Local              string    `json:"local" binding:"max=255"`
+	RepeticoesSemanais int       `json:"repeticoes_semanais" binding:"omitempty,min=1,max=52"`
+	// InstrumentoID e o questionario atribuido ao paciente 24h antes de cada consulta
+	InstrumentoID *uint `json:"instrumento_id"`
+}
+
+// RemarcarConsultaDTOIn representa o novo horario de uma consulta
+type RemarcarConsultaDTOIn struct {
+	Inicio time.Time `json:"inicio" binding:"required"`
+	Fim    time.Time `json:"fim" binding:"required"`
+}
+
+// AlterarStatusConsultaDTOIn representa o desfecho de uma consulta
+// Serie cancela tambem as proximas consultas agendadas da mesma recorrencia
+type AlterarStatusConsultaDTOIn struct {
+	Status string `json:"status" binding:"required,oneof=REALIZADA FALTA CANCELADA"`
+	Serie  bool   `json:"serie"`
+}
+
+// EncerrarVinculoDTOIn representa o motivo informado ao encerrar um vinculo ou dar alta
+type EncerrarVinculoDTOIn struct {
+	Motivo string `json:"motivo" binding:"required,min=3,max=1000"`
+}
+
+// GerarConviteDTOIn configura um novo convite; campos omitidos usam validade de 24h e uso unico
+// Com email, apenas o paciente cadastrado com esse e-mail pode usar o convite
+// Com enviar_para, o link do convite e enviado por e-mail a esse endereco
+type GerarConviteDTOIn struct {
+	ValidadeHoras int    `json:"validade_horas" binding:"omitempty,min=1,max=720"`
+	MaxUsos       uint   `json:"max_usos" binding:"omitempty,min=1,max=500"`
+	Email         string `json:"email" binding:"omitempty,email"`
+	EnviarPara    string `json:"enviar_para" binding:"omitempty,email"`
+}
+
+// EnviarConviteDTOIn informa o endereco que recebe o link de um convite ja gerado
+type EnviarConviteDTOIn struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
 type VincularPacienteDTOIn struct {
 	Token string `json:"token" binding:"required,min=10"`
 }
@@ -90,19 +269,47 @@ type RegistroRespostaDTOIn struct {
 }
 
 // PontoDeDadosDTOOut representa um ponto de dados para graficos
+// Com amostragem por dia ou semana, Valor e a media do periodo e Data o seu inicio
 type PontoDeDadosDTOOut struct {
 	Data  time.Time `json:"data"`
-	Valor int16     `json:"valor"`
+	Valor float64   `json:"valor"`
 	// Humor é opcional, útil se quiser colorir o ponto do gráfico baseado no humor do dia
 	Humor int16 `json:"humor,omitempty"`
 }
 
+// GraficoMetricaDTOOut e a serie de uma metrica personalizada; em SIM_NAO a media e a fracao de registros com sim
+type GraficoMetricaDTOOut struct {
+	MetricaID uint                 `json:"metrica_id"`
+	Rotulo    string               `json:"rotulo"`
+	Tipo      string               `json:"tipo"`
+	Minimo    float64              `json:"minimo"`
+	Maximo    float64              `json:"maximo"`
+	Media     float64              `json:"media"`
+	Pontos    []PontoMetricaDTOOut `json:"pontos"`
+}
+
+// PontoMetricaDTOOut e um valor de metrica personalizada com o humor do mesmo registro
+type PontoMetricaDTOOut struct {
+	Data  time.Time `json:"data"`
+	Valor float64   `json:"valor"`
+	Humor int16     `json:"humor,omitempty"`
+}
+
 // AnalisePacienteDTOOut unifica Relatorio e Monitoramento
 type AnalisePacienteDTOOut struct {
+	// Intervalo efetivo, ja limitado pelo consentimento, e amostragem dos graficos (REGISTRO, DIA ou SEMANA)
+	Inicio         time.Time `json:"inicio"`
+	Fim            time.Time `json:"fim"`
+	Fuso           string    `json:"fuso"`
+	Amostragem     string    `json:"amostragem"`
+	TotalRegistros int       `json:"total_registros"`
+
 	// Dados para Visualização (Antigo Relatorio)
 	GraficoSono    []PontoDeDadosDTOOut `json:"grafico_sono"`
 	GraficoEnergia []PontoDeDadosDTOOut `json:"grafico_energia"`
 	GraficoStress  []PontoDeDadosDTOOut `json:"grafico_stress"`
+	// Uma serie por metrica personalizada com valores no periodo
+	GraficosMetricas []GraficoMetricaDTOOut `json:"graficos_metricas"`
 
 	// Dados Estatísticos
 	MediaSono    float64 `json:"media_sono"`
@@ -111,8 +318,122 @@ type AnalisePacienteDTOOut struct {
 	MediaHumor   float64 `json:"media_humor"`
 
 	// Dados de Inteligência (Antigo Monitoramento)
-	StatusAtual   string    `json:"status_atual"` // REGULAR, ATENCAO, PREOCUPANTE
+	StatusAtual   string    `json:"status_atual"` // REGULAR, ATENCAO, PREOCUPANTE; vazio se o consentimento omite metricas
 	UltimaAnalise time.Time `json:"ultima_analise"`
+
+	// Categorias que o paciente compartilha (apenas na visao do profissional)
+	CategoriasCompartilhadas []string `json:"categorias_compartilhadas,omitempty"`
+}
+
+// FiltroAnaliseAgregadaDTOIn representa os parametros da analise agregada, lidos da query
+// Fuso e um nome IANA, como America/Sao_Paulo; vazio usa o fuso do servidor
+type FiltroAnaliseAgregadaDTOIn struct {
+	Dias        int
+	Agrupamento string
+	Agregacao   string
+	Fuso        string
+}
+
+// AnaliseAgregadaDTOOut traz as metricas do paciente agrupadas por dia ou semana no fuso informado
+type AnaliseAgregadaDTOOut struct {
+	PacienteID  uint                  `json:"paciente_id"`
+	Inicio      time.Time             `json:"inicio"`
+	Fim         time.Time             `json:"fim"`
+	Fuso        string                `json:"fuso"`
+	Agrupamento string                `json:"agrupamento"`
+	Agregacao   string                `json:"agregacao"`
+	Series      []SerieAgregadaDTOOut `json:"series"`
+	// Categorias que o paciente compartilha (apenas na visao do profissional)
+	CategoriasCompartilhadas []string `json:"categorias_compartilhadas,omitempty"`
+}
+
+// SerieAgregadaDTOOut traz uma metrica por periodo; media_diaria e a media das medias de cada dia
+type SerieAgregadaDTOOut struct {
+	Metrica       string                  `json:"metrica"`
+	Rotulo        string                  `json:"rotulo"`
+	MediaDiaria   *float64                `json:"media_diaria"`
+	Periodos      []PeriodoAgregadoDTOOut `json:"periodos"`
+	PerfilSemanal []PerfilDiaSemanaDTOOut `json:"perfil_semanal"`
+}
+
+// PeriodoAgregadoDTOOut e um dia ou semana; sem registros, valor e nulo
+type PeriodoAgregadoDTOOut struct {
+	Inicio    time.Time `json:"inicio"`
+	Registros int       `json:"registros"`
+	Valor     *float64  `json:"valor"`
+	// Apenas no agrupamento por dia
+	MediaMovel7Dias *float64 `json:"media_movel_7_dias,omitempty"`
+}
+
+// PerfilDiaSemanaDTOOut resume as medias diarias de um dia da semana
+type PerfilDiaSemanaDTOOut struct {
+	DiaSemana string   `json:"dia_semana"`
+	Dias      int      `json:"dias"`
+	Media     *float64 `json:"media"`
+}
+
+// IntervaloAnaliseDTOIn e um intervalo de dias do calendario; De e Ate sao inclusivos
+type IntervaloAnaliseDTOIn struct {
+	De  time.Time
+	Ate time.Time
+}
+
+// ComparacaoAnaliseDTOOut traz as analises de dois intervalos e as diferencas do periodo para a referencia
+type ComparacaoAnaliseDTOOut struct {
+	Periodo    *AnalisePacienteDTOOut  `json:"periodo"`
+	Referencia *AnalisePacienteDTOOut  `json:"referencia"`
+	Diferencas DiferencasAnaliseDTOOut `json:"diferencas"`
+}
+
+// DiferencasAnaliseDTOOut e o periodo menos a referencia; medias nulas quando a categoria esta oculta ou falta registro
+type DiferencasAnaliseDTOOut struct {
+	TotalRegistros int                      `json:"total_registros"`
+	MediaSono      *float64                 `json:"media_sono"`
+	MediaEnergia   *float64                 `json:"media_energia"`
+	MediaStress    *float64                 `json:"media_stress"`
+	MediaHumor     *float64                 `json:"media_humor"`
+	Metricas       []DiferencaMetricaDTOOut `json:"metricas"`
+}
+
+// DiferencaMetricaDTOOut e a diferenca das medias de uma metrica personalizada com valores nos dois periodos
+type DiferencaMetricaDTOOut struct {
+	MetricaID uint    `json:"metrica_id"`
+	Rotulo    string  `json:"rotulo"`
+	Diferenca float64 `json:"diferenca"`
+}
+
+// PainelProfissionalDTOOut e a carteira de pacientes do profissional, do maior risco ao menor
+type PainelProfissionalDTOOut struct {
+	GeradoEm time.Time `json:"gerado_em"`
+	// DiasStatus e o periodo, em dias, das medias usadas no status de cada paciente
+	DiasStatus int                    `json:"dias_status"`
+	Pacientes  []PacientePainelDTOOut `json:"pacientes"`
+}
+
+// PacientePainelDTOOut resume um paciente da carteira; cada campo depende da categoria compartilhada no consentimento
+type PacientePainelDTOOut struct {
+	PacienteID               uint       `json:"paciente_id"`
+	Nome                     string     `json:"nome"`
+	VinculadoEm              *time.Time `json:"vinculado_em"`
+	CategoriasCompartilhadas []string   `json:"categorias_compartilhadas"`
+	UltimoRegistro           *time.Time `json:"ultimo_registro"`
+	DiasSemRegistro          *int       `json:"dias_sem_registro"`
+	RegistrosRecentes        int        `json:"registros_recentes"`
+	// StatusAtual fica vazio sem registros no periodo ou sem as categorias humor e sono
+	StatusAtual          string                  `json:"status_atual"`
+	AtribuicoesPendentes int                     `json:"atribuicoes_pendentes"`
+	AtribuicoesExpiradas int                     `json:"atribuicoes_expiradas"`
+	UltimasPontuacoes    []PontuacaoPainelDTOOut `json:"ultimas_pontuacoes"`
+}
+
+// PontuacaoPainelDTOOut e a resposta mais recente do paciente a um instrumento
+type PontuacaoPainelDTOOut struct {
+	InstrumentoID  uint      `json:"instrumento_id"`
+	Codigo         string    `json:"codigo"`
+	Nome           string    `json:"nome"`
+	PontuacaoTotal float64   `json:"pontuacao_total"`
+	Classificacao  string    `json:"classificacao"`
+	DataResposta   time.Time `json:"data_resposta"`
 }
 
 // ResumoPacienteDTOOut representa o resumo de um paciente <=> ultimo registro
@@ -134,17 +455,151 @@ type RegistroHumorDTOOut struct {
 	Observacoes      string    `json:"observacoes,omitempty"`
 	DataHoraRegistro time.Time `json:"data_hora_registro"`
 	CreatedAt        time.Time `json:"created_at"`
+	// Metricas acompanha a categoria humor do consentimento
+	Metricas []ValorMetricaDTOOut `json:"metricas,omitempty"`
+}
+
+// ValorMetricaDTOOut e o valor de uma metrica personalizada em um registro
+type ValorMetricaDTOOut struct {
+	MetricaID uint    `json:"metrica_id"`
+	Rotulo    string  `json:"rotulo"`
+	Valor     float64 `json:"valor"`
+}
+
+// CriarMetricaPersonalizadaDTOIn define uma metrica do diario do paciente
+// Em SIM_NAO a faixa e ignorada
+type CriarMetricaPersonalizadaDTOIn struct {
+	Rotulo string   `json:"rotulo" binding:"required"`
+	Tipo   string   `json:"tipo" binding:"required"`
+	Minimo *float64 `json:"minimo"`
+	Maximo *float64 `json:"maximo"`
+}
+
+// MetricaPersonalizadaDTOOut representa uma metrica personalizada do paciente
+type MetricaPersonalizadaDTOOut struct {
+	ID             uint    `json:"id"`
+	PacienteID     uint    `json:"paciente_id"`
+	ProfissionalID uint    `json:"profissional_id"`
+	Rotulo         string  `json:"rotulo"`
+	Tipo           string  `json:"tipo"`
+	Minimo         float64 `json:"minimo"`
+	Maximo         float64 `json:"maximo"`
+}
+
+// CriarAtividadeAutoCuidadoDTOIn representa uma atividade personalizada do profissional
+type CriarAtividadeAutoCuidadoDTOIn struct {
+	Nome string `json:"nome" binding:"required"`
+}
+
+// AtividadeAutoCuidadoDTOOut representa uma atividade do catalogo de auto cuidado
+type AtividadeAutoCuidadoDTOOut struct {
+	ID             uint   `json:"id"`
+	Nome           string `json:"nome"`
+	ProfissionalID *uint  `json:"profissional_id,omitempty"`
+	Personalizada  bool   `json:"personalizada"`
+}
+
+// CorrelacaoAutoCuidadoDTOOut compara o humor do dia seguinte aos dias com e sem cada atividade
+type CorrelacaoAutoCuidadoDTOOut struct {
+	PacienteID    uint                        `json:"paciente_id"`
+	Dias          int                         `json:"dias"`
+	AmostraMinima int                         `json:"amostra_minima"`
+	Atividades    []CorrelacaoAtividadeDTOOut `json:"atividades"`
+}
+
+// CorrelacaoAtividadeDTOOut traz o resultado de uma atividade
+// Com amostra insuficiente, a diferenca e apenas indicativa
+type CorrelacaoAtividadeDTOOut struct {
+	AtividadeID         uint    `json:"atividade_id"`
+	Nome                string  `json:"nome"`
+	DiasCom             int     `json:"dias_com"`
+	DiasSem             int     `json:"dias_sem"`
+	HumorDiaSeguinteCom float64 `json:"humor_dia_seguinte_com"`
+	HumorDiaSeguinteSem float64 `json:"humor_dia_seguinte_sem"`
+	Diferenca           float64 `json:"diferenca"`
+	AmostraSuficiente   bool    `json:"amostra_suficiente"`
+}
+
+// CorrelacoesMetricasDTOOut traz as correlacoes entre as metricas diarias do paciente
+// Os registros sao agrupados pelo dia no fuso informado
+type CorrelacoesMetricasDTOOut struct {
+	PacienteID    uint                      `json:"paciente_id"`
+	Dias          int                       `json:"dias"`
+	Fuso          string                    `json:"fuso"`
+	AmostraMinima int                       `json:"amostra_minima"`
+	Metricas      []MetricaCorrelacaoDTOOut `json:"metricas"`
+	Correlacoes   []CorrelacaoParDTOOut     `json:"correlacoes"`
+}
+
+// MetricaCorrelacaoDTOOut identifica uma metrica presente nas correlacoes
+type MetricaCorrelacaoDTOOut struct {
+	Chave        string `json:"chave"`
+	Rotulo       string `json:"rotulo"`
+	DiasComValor int    `json:"dias_com_valor"`
+}
+
+// CorrelacaoParDTOOut compara a metrica A do dia t com a metrica B do dia t+defasagem
+// Os coeficientes ficam nulos com menos de 3 pares ou sem variacao
+type CorrelacaoParDTOOut struct {
+	MetricaA          string   `json:"metrica_a"`
+	MetricaB          string   `json:"metrica_b"`
+	Defasagem         int      `json:"defasagem"`
+	Pares             int      `json:"pares"`
+	Pearson           *float64 `json:"pearson"`
+	Spearman          *float64 `json:"spearman"`
+	AmostraSuficiente bool     `json:"amostra_suficiente"`
+	Avisos            []string `json:"avisos"`
+}
+
+// SincronizacaoRegistrosHumorDTOOut traz o resultado de cada item do lote, na ordem do envio
+type SincronizacaoRegistrosHumorDTOOut struct {
+	Resultados []ResultadoSincronizacaoDTOOut `json:"resultados"`
+	Criados    int                            `json:"criados"`
+	Duplicados int                            `json:"duplicados"`
+	Invalidos  int                            `json:"invalidos"`
+}
+
+// ResultadoSincronizacaoDTOOut informa se o item foi criado, ja existia ou foi recusado
+type ResultadoSincronizacaoDTOOut struct {
+	ChaveIdempotencia string               `json:"chave_idempotencia"`
+	Status            string               `json:"status"`
+	Registro          *RegistroHumorDTOOut `json:"registro,omitempty"`
+	Erro              string               `json:"erro,omitempty"`
+}
+
+// RevisaoRegistroHumorDTOOut representa os valores de um registro de humor antes de uma correcao
+type RevisaoRegistroHumorDTOOut struct {
+	RegistroHumorID  uint      `json:"registro_humor_id"`
+	Revisao          uint      `json:"revisao"`
+	Operacao         string    `json:"operacao"`
+	NivelHumor       int16     `json:"nivel_humor"`
+	HorasSono        int16     `json:"horas_sono"`
+	NivelEnergia     int16     `json:"nivel_energia"`
+	NivelStress      int16     `json:"nivel_stress"`
+	AutoCuidado      string    `json:"auto_cuidado"`
+	Observacoes      string    `json:"observacoes,omitempty"`
+	DataHoraRegistro time.Time `json:"data_hora_registro"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// PaginaRegistrosHumorDTOOut representa uma pagina do diario de humor
+// Sem proximo_cursor, nao ha mais registros; com ele, a pagina pode vir com menos itens que o limite
+type PaginaRegistrosHumorDTOOut struct {
+	Registros                []*RegistroHumorDTOOut `json:"registros"`
+	ProximoCursor            string                 `json:"proximo_cursor,omitempty"`
+	CategoriasCompartilhadas []string               `json:"categorias_compartilhadas,omitempty"`
 }
 
 type UsuarioDTOOut struct {
-	ID          uint      `json:"id"`
-	Email       string    `json:"email"`
-	Nome        string    `json:"nome"`
-	TipoUsuario string    `json:"tipo_usuario"`
-	Contato     string    `json:"contato,omitempty"`
-	Bio         string    `json:"bio,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID              uint      `json:"id"`
+	Email           string    `json:"email"`
+	Nome            string    `json:"nome"`
+	TipoUsuario     string    `json:"tipo_usuario"`
+	Contato         string    `json:"contato,omitempty"`
+	Bio             string    `json:"bio,omitempty"`
+	EmailVerificado bool      `json:"email_verificado"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 type ProfissionalDTOOut struct {
@@ -163,15 +618,80 @@ type PacienteDTOOut struct {
 	DataNascimento time.Time            `json:"data_nascimento"`
 	Dependente     *bool                `json:"dependente"`
 	Profissionais  []ProfissionalDTOOut `json:"profissionais,omitempty"`
+	Vinculo        *VinculoDTOOut       `json:"vinculo,omitempty"`
 	CreatedAt      time.Time            `json:"created_at"`
 	UpdatedAt      time.Time            `json:"updated_at"`
 }
 
+// VinculoDTOOut representa a situacao do vinculo na lista de pacientes do profissional
+type VinculoDTOOut struct {
+	Ativo        bool       `json:"ativo"`
+	VinculadoEm  *time.Time `json:"vinculado_em,omitempty"`
+	EncerradoEm  *time.Time `json:"encerrado_em,omitempty"`
+	EncerradoPor string     `json:"encerrado_por,omitempty"`
+}
+
+// EncerramentoVinculoDTOOut representa o registro de um vinculo encerrado
+type EncerramentoVinculoDTOOut struct {
+	ID                    uint       `json:"id"`
+	PacienteID            uint       `json:"paciente_id"`
+	ProfissionalID        uint       `json:"profissional_id"`
+	EncerradoPor          string     `json:"encerrado_por"`
+	Motivo                string     `json:"motivo"`
+	VinculadoEm           *time.Time `json:"vinculado_em,omitempty"`
+	EncerradoEm           time.Time  `json:"encerrado_em"`
+	AtribuicoesCanceladas int64      `json:"atribuicoes_canceladas"`
+}
+
+// LoginDTOOut representa o resultado do login
+// Com dois fatores ativo, o token so e emitido apos a etapa do desafio
+type LoginDTOOut struct {
+	Token                 string `json:"token,omitempty"`
+	DoisFatoresNecessario bool   `json:"dois_fatores_necessario"`
+	DesafioToken          string `json:"desafio_token,omitempty"`
+}
+
+// InscricaoDoisFatoresDTOOut traz o segredo para cadastro no aplicativo autenticador
+type InscricaoDoisFatoresDTOOut struct {
+	URIOtpauth string `json:"uri_otpauth"`
+	Segredo    string `json:"segredo"`
+}
+
+// CodigosRecuperacaoDTOOut lista os codigos de recuperacao, exibidos uma unica vez
+type CodigosRecuperacaoDTOOut struct {
+	Codigos []string `json:"codigos"`
+}
+
+// JWKDTOOut representa uma chave publica de verificacao no formato JWK (RFC 7517)
+type JWKDTOOut struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSDTOOut representa o conjunto de chaves publicado em /.well-known/jwks.json
+type JWKSDTOOut struct {
+	Keys []JWKDTOOut `json:"keys"`
+}
+
 type ConviteDTOOut struct {
-	Token         string    `json:"token"`
-	DataExpiracao time.Time `json:"data_expiracao"`
-	Usado         bool      `json:"usado"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID                uint       `json:"id"`
+	Token             string     `json:"token"`
+	DataExpiracao     time.Time  `json:"data_expiracao"`
+	Usado             bool       `json:"usado"`
+	Status            string     `json:"status"`
+	MaxUsos           uint       `json:"max_usos"`
+	Usos              uint       `json:"usos"`
+	EmailDestinatario string     `json:"email_destinatario,omitempty"`
+	RevogadoEm        *time.Time `json:"revogado_em,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	Link              string     `json:"link,omitempty"`
+	EnviadoPara       string     `json:"enviado_para,omitempty"`
 }
 
 type InstrumentoDTOOut struct {
@@ -250,3 +770,223 @@ type ProfissionalResumidoDTOOut struct {
 	Email         string `json:"email"`
 	Especialidade string `json:"especialidade"`
 }
+
+// ExportacaoDadosDTOOut representa o andamento de uma exportacao de dados
+// UrlDownload so e preenchido quando um novo link e emitido
+type ExportacaoDadosDTOOut struct {
+	ID            uint       `json:"id"`
+	Formato       string     `json:"formato"`
+	Status        string     `json:"status"`
+	Erro          string     `json:"erro,omitempty"`
+	UrlDownload   string     `json:"url_download,omitempty"`
+	DataExpiracao *time.Time `json:"data_expiracao,omitempty"`
+	ConcluidaEm   *time.Time `json:"concluida_em,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ManifestoExportacaoDTOOut descreve o conteudo do pacote exportado
+type ManifestoExportacaoDTOOut struct {
+	Versao      string                  `json:"versao"`
+	GeradoEm    time.Time               `json:"gerado_em"`
+	UsuarioID   uint                    `json:"usuario_id"`
+	TipoUsuario string                  `json:"tipo_usuario"`
+	Formato     string                  `json:"formato"`
+	Secoes      []SecaoExportacaoDTOOut `json:"secoes"`
+}
+
+// SecaoExportacaoDTOOut descreve uma secao do pacote; Arquivo so e usado no formato zip
+type SecaoExportacaoDTOOut struct {
+	Nome      string `json:"nome"`
+	Descricao string `json:"descricao"`
+	Arquivo   string `json:"arquivo,omitempty"`
+	Registros int    `json:"registros"`
+	SHA256    string `json:"sha256"`
+}
+
+// PacoteExportacaoDTOOut e o pacote completo no formato json
+type PacoteExportacaoDTOOut struct {
+	Manifesto ManifestoExportacaoDTOOut  `json:"manifesto"`
+	Dados     map[string]json.RawMessage `json:"dados"`
+}
+
+// TitularExportacaoDTOOut reune os dados cadastrais do titular
+type TitularExportacaoDTOOut struct {
+	Usuario           UsuarioDTOOut             `json:"usuario"`
+	CPF               string                    `json:"cpf,omitempty"`
+	EmailVerificadoEm *time.Time                `json:"email_verificado_em,omitempty"`
+	Profissional      *ProfissionalDTOOut       `json:"profissional,omitempty"`
+	Paciente          *PacienteExportacaoDTOOut `json:"paciente,omitempty"`
+}
+
+// PacienteExportacaoDTOOut traz o perfil completo do paciente, incluindo dados do responsavel
+type PacienteExportacaoDTOOut struct {
+	ID                   uint       `json:"id"`
+	DataNascimento       time.Time  `json:"data_nascimento"`
+	Dependente           bool       `json:"dependente"`
+	NomeResponsavel      string     `json:"nome_responsavel,omitempty"`
+	ContatoResponsavel   string     `json:"contato_responsavel,omitempty"`
+	DataInicioTratamento *time.Time `json:"data_inicio_tratamento,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+// VinculoExportacaoDTOOut representa um vinculo profissional-paciente do titular
+// Para profissionais, os pacientes aparecem apenas pelo ID
+type VinculoExportacaoDTOOut struct {
+	ProfissionalID   uint   `json:"profissional_id"`
+	PacienteID       uint   `json:"paciente_id"`
+	NomeProfissional string `json:"nome_profissional,omitempty"`
+	Especialidade    string `json:"especialidade,omitempty"`
+}
+
+// AtribuicaoExportacaoDTOOut representa uma atribuicao de questionario na exportacao
+type AtribuicaoExportacaoDTOOut struct {
+	ID                uint                      `json:"id"`
+	Status            string                    `json:"status"`
+	DataAtribuicao    time.Time                 `json:"data_atribuicao"`
+	DataResposta      *time.Time                `json:"data_resposta,omitempty"`
+	InstrumentoCodigo string                    `json:"instrumento_codigo"`
+	InstrumentoNome   string                    `json:"instrumento_nome"`
+	ProfissionalID    uint                      `json:"profissional_id"`
+	PacienteID        uint                      `json:"paciente_id"`
+	Resposta          *RespostaExportacaoDTOOut `json:"resposta,omitempty"`
+}
+
+// RespostaExportacaoDTOOut representa as respostas do paciente a um questionario
+type RespostaExportacaoDTOOut struct {
+	PontuacaoTotal float64         `json:"pontuacao_total"`
+	Classificacao  string          `json:"classificacao"`
+	DadosBrutos    json.RawMessage `json:"dados_brutos,omitempty"`
+	DataResposta   time.Time       `json:"data_resposta"`
+}
+
+// ValorMetricaExportacaoDTOOut representa o valor de uma metrica personalizada em um registro de humor
+type ValorMetricaExportacaoDTOOut struct {
+	RegistroHumorID uint    `json:"registro_humor_id"`
+	MetricaID       uint    `json:"metrica_id"`
+	Rotulo          string  `json:"rotulo"`
+	Valor           float64 `json:"valor"`
+}
+
+// NotificacaoExportacaoDTOOut representa uma notificacao recebida pelo titular
+type NotificacaoExportacaoDTOOut struct {
+	ID        uint      `json:"id"`
+	Conteudo  string    `json:"conteudo"`
+	Status    string    `json:"status"`
+	DataEnvio time.Time `json:"data_envio"`
+}
+
+// ExclusaoContaDTOOut representa uma exclusao de conta agendada
+type ExclusaoContaDTOOut struct {
+	ID           uint      `json:"id"`
+	Status       string    `json:"status"`
+	Motivo       string    `json:"motivo,omitempty"`
+	DataExecucao time.Time `json:"data_execucao"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ConsentimentoDTOOut representa uma versao do consentimento entre paciente e profissional
+type ConsentimentoDTOOut struct {
+	ID             uint                        `json:"id"`
+	Versao         uint                        `json:"versao"`
+	PacienteID     uint                        `json:"paciente_id"`
+	ProfissionalID uint                        `json:"profissional_id"`
+	Profissional   *ProfissionalResumidoDTOOut `json:"profissional,omitempty"`
+	Categorias     []string                    `json:"categorias"`
+	DataInicio     time.Time                   `json:"data_inicio"`
+	DataExpiracao  *time.Time                  `json:"data_expiracao,omitempty"`
+	Revogado       bool                        `json:"revogado"`
+	Vigente        bool                        `json:"vigente"`
+	CreatedAt      time.Time                   `json:"created_at"`
+}
+
+// ResponsavelDTOOut representa a conta de um responsavel
+type ResponsavelDTOOut struct {
+	ID        uint          `json:"id"`
+	Usuario   UsuarioDTOOut `json:"usuario"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// VinculoResponsavelDTOOut representa o vinculo entre um responsavel e um dependente
+// Na visao do paciente vem o responsavel; na visao do responsavel, o dependente
+type VinculoResponsavelDTOOut struct {
+	ResponsavelID   uint       `json:"responsavel_id"`
+	PacienteID      uint       `json:"paciente_id"`
+	NomeResponsavel string     `json:"nome_responsavel,omitempty"`
+	NomeDependente  string     `json:"nome_dependente,omitempty"`
+	Parentesco      string     `json:"parentesco,omitempty"`
+	MenorDeIdade    bool       `json:"menor_de_idade"`
+	VinculadoEm     time.Time  `json:"vinculado_em"`
+	EncerradoEm     *time.Time `json:"encerrado_em,omitempty"`
+}
+
+// AcaoResponsavelDTOOut representa uma acao do responsavel registrada na auditoria
+type AcaoResponsavelDTOOut struct {
+	ID            uint      `json:"id"`
+	ResponsavelID uint      `json:"responsavel_id"`
+	PacienteID    uint      `json:"paciente_id"`
+	Acao          string    `json:"acao"`
+	Detalhe       string    `json:"detalhe,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AcessoDadosDTOOut representa um acesso de outro usuario aos dados do paciente
+type AcessoDadosDTOOut struct {
+	ID          uint      `json:"id"`
+	NomeAtor    string    `json:"nome_ator"`
+	TipoAtor    string    `json:"tipo_ator"`
+	Acao        string    `json:"acao"`
+	TipoRecurso string    `json:"tipo_recurso"`
+	RecursoID   *uint     `json:"recurso_id,omitempty"`
+	Resultado   string    `json:"resultado"`
+	CriadoEm    time.Time `json:"criado_em"`
+}
+
+// ConsultaDTOOut representa uma consulta da agenda
+type ConsultaDTOOut struct {
+	ID               uint      `json:"id"`
+	ProfissionalID   uint      `json:"profissional_id"`
+	NomeProfissional string    `json:"nome_profissional,omitempty"`
+	PacienteID       uint      `json:"paciente_id"`
+	NomePaciente     string    `json:"nome_paciente,omitempty"`
+	Inicio           time.Time `json:"inicio"`
+	Fim              time.Time `json:"fim"`
+	Modalidade       string    `json:"modalidade"`
+	Local            string    `json:"local,omitempty"`
+	Status           string    `json:"status"`
+	SerieID          *uint     `json:"serie_id,omitempty"`
+	InstrumentoID    *uint     `json:"instrumento_id,omitempty"`
+	AtribuicaoID     *uint     `json:"atribuicao_id,omitempty"`
+}
+
+// CalendarioDTOOut representa o link da agenda no formato iCalendar
+type CalendarioDTOOut struct {
+	UrlCalendario string `json:"url_calendario"`
+}
+
+// NotaClinicaDTOOut representa a revisao atual de uma nota clinica
+// CompartilhadaCom so e enviado ao autor
+type NotaClinicaDTOOut struct {
+	ID               uint      `json:"id"`
+	PacienteID       uint      `json:"paciente_id"`
+	ProfissionalID   uint      `json:"profissional_id"`
+	Propria          bool      `json:"propria"`
+	Revisao          uint      `json:"revisao"`
+	Texto            string    `json:"texto"`
+	DataSessao       time.Time `json:"data_sessao"`
+	TipoSessao       string    `json:"tipo_sessao"`
+	Tags             []string  `json:"tags"`
+	CompartilhadaCom []uint    `json:"compartilhada_com,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	AtualizadaEm     time.Time `json:"atualizada_em"`
+}
+
+// RevisaoNotaClinicaDTOOut representa uma versao de uma nota clinica
+type RevisaoNotaClinicaDTOOut struct {
+	Revisao    uint      `json:"revisao"`
+	Texto      string    `json:"texto"`
+	DataSessao time.Time `json:"data_sessao"`
+	TipoSessao string    `json:"tipo_sessao"`
+	Tags       []string  `json:"tags"`
+	CreatedAt  time.Time `json:"created_at"`
+}
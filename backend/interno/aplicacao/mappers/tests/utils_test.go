@@ -405,26 +405,28 @@ func TestRegistrarPacienteDTOInParaEntidade_Dependente(t *testing.T) {
 
 func TestCriarRegistroHumorDTOInParaEntidade(t *testing.T) {
 	now := time.Now()
+	horasSono := int16(8)
 	dtoIn := &dtos.CriarRegistroHumorDTOIn{
 		NivelHumor:       4,
-		HorasSono:        8,
+		HorasSono:        &horasSono,
 		NivelStress:      3,
 		NivelEnergia:     7,
-		AutoCuidado:      "Exercício físico",
+		AutoCuidado:      []string{"Exercício físico"},
 		Observacoes:      "Dia produtivo",
 		DataHoraRegistro: now,
 	}
 	pacienteID := uint(5)
 
-	result := mappers.CriarRegistroHumorDTOInParaEntidade(dtoIn, pacienteID)
+	result, err := mappers.CriarRegistroHumorDTOInParaEntidade(dtoIn, pacienteID)
 
+	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Equal(t, pacienteID, result.PacienteID)
 	assert.Equal(t, dtoIn.NivelHumor, result.NivelHumor)
-	assert.Equal(t, dtoIn.HorasSono, result.HorasSono)
+	assert.Equal(t, horasSono, result.HorasSono)
 	assert.Equal(t, dtoIn.NivelStress, result.NivelStress)
 	assert.Equal(t, dtoIn.NivelEnergia, result.NivelEnergia)
-	assert.Equal(t, dtoIn.AutoCuidado, result.AutoCuidado)
+	assert.Equal(t, `["Exercício físico"]`, result.AutoCuidado)
 	assert.Equal(t, dtoIn.Observacoes, result.Observacoes)
 	assert.Equal(t, dtoIn.DataHoraRegistro, result.DataHoraRegistro)
 }
@@ -4,20 +4,22 @@ import (
 	"encoding/json"
 	"mindtrace/backend/interno/aplicacao/dtos"
 	"mindtrace/backend/interno/dominio"
+	"time"
 )
 
 // ===== MAPEADORES PARA SAÍDA =====
 
 func UsuarioParaDTOOut(usuario *dominio.Usuario) *dtos.UsuarioDTOOut {
 	return &dtos.UsuarioDTOOut{
-		ID:          usuario.ID,
-		Email:       usuario.Email,
-		Nome:        usuario.Nome,
-		TipoUsuario: dominio.TipoUsuarioParaString(usuario.TipoUsuario),
-		Contato:     usuario.Contato,
-		Bio:         usuario.Bio,
-		CreatedAt:   usuario.CreatedAt,
-		UpdatedAt:   usuario.UpdatedAt,
+		ID:              usuario.ID,
+		Email:           usuario.Email,
+		Nome:            usuario.Nome,
+		TipoUsuario:     dominio.TipoUsuarioParaString(usuario.TipoUsuario),
+		Contato:         usuario.Contato,
+		Bio:             usuario.Bio,
+		EmailVerificado: usuario.EmailVerificado(),
+		CreatedAt:       usuario.CreatedAt,
+		UpdatedAt:       usuario.UpdatedAt,
 	}
 }
 
@@ -72,9 +74,129 @@ func RegistroHumorParaDTOOut(reg *dominio.RegistroHumor) *dtos.RegistroHumorDTOO
 		Observacoes:      reg.Observacoes,
 		DataHoraRegistro: reg.DataHoraRegistro,
 		CreatedAt:        reg.CreatedAt,
+		Metricas:         ValoresMetricasParaDTOOut(reg.Metricas),
 	}
 }
 
+// ValoresMetricasParaDTOOut retorna nil sem valores, para que o campo fique fora da resposta
+func ValoresMetricasParaDTOOut(valores []*dominio.ValorMetricaRegistro) []dtos.ValorMetricaDTOOut {
+	if len(valores) == 0 {
+		return nil
+	}
+	valoresOut := make([]dtos.ValorMetricaDTOOut, 0, len(valores))
+	for _, valor := range valores {
+		valorOut := dtos.ValorMetricaDTOOut{MetricaID: valor.MetricaID, Valor: valor.Valor}
+		if valor.Metrica != nil {
+			valorOut.Rotulo = valor.Metrica.Rotulo
+		}
+		valoresOut = append(valoresOut, valorOut)
+	}
+	return valoresOut
+}
+
+func MetricasPersonalizadasParaDTOOut(metricas []*dominio.MetricaPersonalizada) []*dtos.MetricaPersonalizadaDTOOut {
+	metricasOut := make([]*dtos.MetricaPersonalizadaDTOOut, 0, len(metricas))
+	for _, metrica := range metricas {
+		metricasOut = append(metricasOut, MetricaPersonalizadaParaDTOOut(metrica))
+	}
+	return metricasOut
+}
+
+func MetricaPersonalizadaParaDTOOut(metrica *dominio.MetricaPersonalizada) *dtos.MetricaPersonalizadaDTOOut {
+	return &dtos.MetricaPersonalizadaDTOOut{
+		ID:             metrica.ID,
+		PacienteID:     metrica.PacienteID,
+		ProfissionalID: metrica.ProfissionalID,
+		Rotulo:         metrica.Rotulo,
+		Tipo:           metrica.Tipo,
+		Minimo:         metrica.Minimo,
+		Maximo:         metrica.Maximo,
+	}
+}
+
+func AtividadesAutoCuidadoParaDTOOut(atividades []*dominio.AtividadeAutoCuidado) []*dtos.AtividadeAutoCuidadoDTOOut {
+	atividadesOut := make([]*dtos.AtividadeAutoCuidadoDTOOut, 0, len(atividades))
+	for _, atividade := range atividades {
+		atividadesOut = append(atividadesOut, AtividadeAutoCuidadoParaDTOOut(atividade))
+	}
+	return atividadesOut
+}
+
+func AtividadeAutoCuidadoParaDTOOut(atividade *dominio.AtividadeAutoCuidado) *dtos.AtividadeAutoCuidadoDTOOut {
+	return &dtos.AtividadeAutoCuidadoDTOOut{
+		ID:             atividade.ID,
+		Nome:           atividade.Nome,
+		ProfissionalID: atividade.ProfissionalID,
+		Personalizada:  atividade.ProfissionalID != nil,
+	}
+}
+
+// CorrelacoesAutoCuidadoParaDTOOut nomeia as atividades da correlacao; atividades fora do mapa ficam sem nome
+func CorrelacoesAutoCuidadoParaDTOOut(correlacoes []*dominio.CorrelacaoAutoCuidado, atividades map[uint]*dominio.AtividadeAutoCuidado) []dtos.CorrelacaoAtividadeDTOOut {
+	correlacoesOut := make([]dtos.CorrelacaoAtividadeDTOOut, 0, len(correlacoes))
+	for _, c := range correlacoes {
+		var nome string
+		if atividade, ok := atividades[c.AtividadeID]; ok {
+			nome = atividade.Nome
+		}
+		correlacoesOut = append(correlacoesOut, dtos.CorrelacaoAtividadeDTOOut{
+			AtividadeID:         c.AtividadeID,
+			Nome:                nome,
+			DiasCom:             c.DiasCom,
+			DiasSem:             c.DiasSem,
+			HumorDiaSeguinteCom: c.HumorSeguinteCom,
+			HumorDiaSeguinteSem: c.HumorSeguinteSem,
+			Diferenca:           c.Diferenca,
+			AmostraSuficiente:   c.AmostraSuficiente,
+		})
+	}
+	return correlacoesOut
+}
+
+// SeriesCorrelacaoParaDTOOut lista as metricas comparadas, na ordem das series
+func SeriesCorrelacaoParaDTOOut(series []*dominio.SerieDiaria) []dtos.MetricaCorrelacaoDTOOut {
+	metricasOut := make([]dtos.MetricaCorrelacaoDTOOut, 0, len(series))
+	for _, serie := range series {
+		metricasOut = append(metricasOut, dtos.MetricaCorrelacaoDTOOut{Chave: serie.Chave, Rotulo: serie.Rotulo, DiasComValor: len(serie.Dias())})
+	}
+	return metricasOut
+}
+
+func CorrelacoesMetricasParaDTOOut(correlacoes []*dominio.CorrelacaoMetricas) []dtos.CorrelacaoParDTOOut {
+	correlacoesOut := make([]dtos.CorrelacaoParDTOOut, 0, len(correlacoes))
+	for _, c := range correlacoes {
+		correlacoesOut = append(correlacoesOut, dtos.CorrelacaoParDTOOut{
+			MetricaA:          c.MetricaA,
+			MetricaB:          c.MetricaB,
+			Defasagem:         c.Defasagem,
+			Pares:             c.Pares,
+			Pearson:           c.Pearson,
+			Spearman:          c.Spearman,
+			AmostraSuficiente: c.AmostraSuficiente,
+			Avisos:            c.Avisos,
+		})
+	}
+	return correlacoesOut
+}
+
+var nomesDiasSemana = [7]string{"DOMINGO", "SEGUNDA", "TERCA", "QUARTA", "QUINTA", "SEXTA", "SABADO"}
+
+func PeriodosAgregadosParaDTOOut(periodos []*dominio.PeriodoAgregado) []dtos.PeriodoAgregadoDTOOut {
+	periodosOut := make([]dtos.PeriodoAgregadoDTOOut, 0, len(periodos))
+	for _, p := range periodos {
+		periodosOut = append(periodosOut, dtos.PeriodoAgregadoDTOOut{Inicio: p.Inicio, Registros: p.Registros, Valor: p.Valor, MediaMovel7Dias: p.MediaMovel})
+	}
+	return periodosOut
+}
+
+func PerfilSemanalParaDTOOut(perfil []*dominio.PerfilDiaSemana) []dtos.PerfilDiaSemanaDTOOut {
+	perfilOut := make([]dtos.PerfilDiaSemanaDTOOut, 0, len(perfil))
+	for _, p := range perfil {
+		perfilOut = append(perfilOut, dtos.PerfilDiaSemanaDTOOut{DiaSemana: nomesDiasSemana[p.DiaSemana], Dias: p.Dias, Media: p.Media})
+	}
+	return perfilOut
+}
+
 func ResumoPacienteParaDTOOut(reg *dominio.RegistroHumor) *dtos.ResumoPacienteDTOOut {
 	return &dtos.ResumoPacienteDTOOut{
 		Data:     reg.DataHoraRegistro,
@@ -94,6 +216,40 @@ func PacientesParaDTOOut(pacientes []dominio.Paciente) []dtos.PacienteDTOOut {
 	return dtos
 }
 
+// VinculoParaDTOOut converte a situacao do vinculo para o DTO da lista de pacientes
+func VinculoParaDTOOut(v *dominio.Vinculo) *dtos.VinculoDTOOut {
+	if v == nil {
+		return nil
+	}
+	return &dtos.VinculoDTOOut{
+		Ativo:        v.Ativo(),
+		VinculadoEm:  v.VinculadoEm,
+		EncerradoEm:  v.EncerradoEm,
+		EncerradoPor: v.EncerradoPor,
+	}
+}
+
+func EncerramentoVinculoParaDTOOut(e *dominio.EncerramentoVinculo) *dtos.EncerramentoVinculoDTOOut {
+	return &dtos.EncerramentoVinculoDTOOut{
+		ID:                    e.ID,
+		PacienteID:            e.PacienteID,
+		ProfissionalID:        e.ProfissionalID,
+		EncerradoPor:          e.EncerradoPor,
+		Motivo:                e.Motivo,
+		VinculadoEm:           e.VinculadoEm,
+		EncerradoEm:           e.EncerradoEm,
+		AtribuicoesCanceladas: e.AtribuicoesCanceladas,
+	}
+}
+
+func EncerramentosVinculoParaDTOOut(encerramentos []*dominio.EncerramentoVinculo) []*dtos.EncerramentoVinculoDTOOut {
+	dtosOut := make([]*dtos.EncerramentoVinculoDTOOut, len(encerramentos))
+	for i, encerramento := range encerramentos {
+		dtosOut[i] = EncerramentoVinculoParaDTOOut(encerramento)
+	}
+	return dtosOut
+}
+
 // ProfissionaisParaDTOOut converte um slice de Profissionais para DTOs de saída
 func ProfissionaisParaDTOOut(profissionais []dominio.Profissional) []dtos.ProfissionalDTOOut {
 	dtos := make([]dtos.ProfissionalDTOOut, len(profissionais))
@@ -158,6 +314,13 @@ func CriarRegistroHumorDTOInParaEntidade(dto *dtos.CriarRegistroHumorDTOIn, paci
 	if err != nil {
 		return nil, err
 	}
+	metricas := make([]*dominio.ValorMetricaRegistro, 0, len(dto.Metricas))
+	for _, valor := range dto.Metricas {
+		if valor.Valor == nil {
+			return nil, dominio.ErrValorMetricaInvalido
+		}
+		metricas = append(metricas, &dominio.ValorMetricaRegistro{MetricaID: valor.MetricaID, Valor: *valor.Valor})
+	}
 
 	return &dominio.RegistroHumor{
 		PacienteID:       pacienteID,
@@ -168,6 +331,7 @@ func CriarRegistroHumorDTOInParaEntidade(dto *dtos.CriarRegistroHumorDTOIn, paci
 		AutoCuidado:      string(autoCuidadoJSONB),
 		Observacoes:      dto.Observacoes,
 		DataHoraRegistro: dto.DataHoraRegistro,
+		Metricas:         metricas,
 	}, nil
 }
 
@@ -194,10 +358,16 @@ func ConviteParaDTOOut(convite *dominio.Convite) *dtos.ConviteDTOOut {
 		return nil
 	}
 	return &dtos.ConviteDTOOut{
-		Token:         convite.Token,
-		DataExpiracao: convite.DataExpiracao,
-		Usado:         convite.Usado,
-		CreatedAt:     convite.CreatedAt,
+		ID:                convite.ID,
+		Token:             convite.Token,
+		DataExpiracao:     convite.DataExpiracao,
+		Usado:             convite.Usado,
+		Status:            convite.Status(time.Now()),
+		MaxUsos:           convite.MaxUsos,
+		Usos:              convite.Usos,
+		EmailDestinatario: convite.EmailDestinatario,
+		RevogadoEm:        convite.RevogadoEm,
+		CreatedAt:         convite.CreatedAt,
 	}
 }
 
@@ -410,3 +580,347 @@ func RespostaDetalhadaDTOOut(resp *dominio.Resposta, dadosBrutos []map[string]an
 		Detalhes:       dadosProcessados.Detalhes,
 	}
 }
+
+func ExclusaoContaParaDTOOut(exclusao *dominio.ExclusaoConta) *dtos.ExclusaoContaDTOOut {
+	if exclusao == nil {
+		return nil
+	}
+	return &dtos.ExclusaoContaDTOOut{
+		ID:           exclusao.ID,
+		Status:       exclusao.Status,
+		Motivo:       exclusao.Motivo,
+		DataExecucao: exclusao.DataExecucao,
+		CreatedAt:    exclusao.CreatedAt,
+	}
+}
+
+// ConsentimentoParaDTOOut converte uma versao de consentimento, avaliando a vigencia em agora
+func ConsentimentoParaDTOOut(consentimento *dominio.Consentimento, agora time.Time) *dtos.ConsentimentoDTOOut {
+	if consentimento == nil {
+		return nil
+	}
+	dto := &dtos.ConsentimentoDTOOut{
+		ID:             consentimento.ID,
+		Versao:         consentimento.Versao,
+		PacienteID:     consentimento.PacienteID,
+		ProfissionalID: consentimento.ProfissionalID,
+		Categorias:     consentimento.Categorias(),
+		DataInicio:     consentimento.DataInicio,
+		DataExpiracao:  consentimento.DataExpiracao,
+		Revogado:       consentimento.Revogado,
+		Vigente:        consentimento.Vigente(agora),
+		CreatedAt:      consentimento.CreatedAt,
+	}
+	if consentimento.Profissional.ID != 0 {
+		dto.Profissional = &dtos.ProfissionalResumidoDTOOut{
+			ID:            consentimento.Profissional.ID,
+			Nome:          consentimento.Profissional.Usuario.Nome,
+			Email:         consentimento.Profissional.Usuario.Email,
+			Especialidade: consentimento.Profissional.Especialidade,
+		}
+	}
+	return dto
+}
+
+func ConsentimentosParaDTOOut(consentimentos []*dominio.Consentimento, agora time.Time) []*dtos.ConsentimentoDTOOut {
+	dtosOut := make([]*dtos.ConsentimentoDTOOut, 0, len(consentimentos))
+	for _, consentimento := range consentimentos {
+		dtosOut = append(dtosOut, ConsentimentoParaDTOOut(consentimento, agora))
+	}
+	return dtosOut
+}
+
+// ===== MAPEADORES PARA EXPORTACAO DE DADOS (LGPD) =====
+
+func ExportacaoDadosParaDTOOut(exportacao *dominio.ExportacaoDados) *dtos.ExportacaoDadosDTOOut {
+	if exportacao == nil {
+		return nil
+	}
+	return &dtos.ExportacaoDadosDTOOut{
+		ID:            exportacao.ID,
+		Formato:       exportacao.Formato,
+		Status:        exportacao.Status,
+		Erro:          exportacao.Erro,
+		DataExpiracao: exportacao.DataExpiracao,
+		ConcluidaEm:   exportacao.ConcluidaEm,
+		CreatedAt:     exportacao.CreatedAt,
+	}
+}
+
+func TitularParaExportacaoDTOOut(dados *dominio.DadosTitular) *dtos.TitularExportacaoDTOOut {
+	titular := &dtos.TitularExportacaoDTOOut{
+		Usuario:           *UsuarioParaDTOOut(&dados.Usuario),
+		CPF:               dados.Usuario.CPF,
+		EmailVerificadoEm: dados.Usuario.EmailVerificadoEm,
+	}
+	if dados.Profissional != nil {
+		prof := *dados.Profissional
+		prof.Usuario = dados.Usuario
+		titular.Profissional = ProfissionalParaDTOOut(&prof)
+	}
+	if pac := dados.Paciente; pac != nil {
+		titular.Paciente = &dtos.PacienteExportacaoDTOOut{
+			ID:                   pac.ID,
+			DataNascimento:       pac.DataNascimento,
+			Dependente:           pac.Dependente,
+			NomeResponsavel:      pac.NomeResponsavel,
+			ContatoResponsavel:   pac.ContatoResponsavel,
+			DataInicioTratamento: pac.DataInicioTratamento,
+			CreatedAt:            pac.CreatedAt,
+			UpdatedAt:            pac.UpdatedAt,
+		}
+	}
+	return titular
+}
+
+// VinculosParaExportacaoDTOOut lista os vinculos do titular
+// Do lado do profissional, os pacientes sao identificados apenas pelo ID para nao expor dados de terceiros
+func VinculosParaExportacaoDTOOut(dados *dominio.DadosTitular) []dtos.VinculoExportacaoDTOOut {
+	vinculos := []dtos.VinculoExportacaoDTOOut{}
+	if dados.Paciente != nil {
+		for _, prof := range dados.Paciente.Profissionais {
+			vinculos = append(vinculos, dtos.VinculoExportacaoDTOOut{
+				ProfissionalID:   prof.ID,
+				PacienteID:       dados.Paciente.ID,
+				NomeProfissional: prof.Usuario.Nome,
+				Especialidade:    prof.Especialidade,
+			})
+		}
+	}
+	if dados.Profissional != nil {
+		for _, pac := range dados.Profissional.Pacientes {
+			vinculos = append(vinculos, dtos.VinculoExportacaoDTOOut{
+				ProfissionalID: dados.Profissional.ID,
+				PacienteID:     pac.ID,
+			})
+		}
+	}
+	return vinculos
+}
+
+func RegistrosHumorParaDTOOut(registros []*dominio.RegistroHumor) []*dtos.RegistroHumorDTOOut {
+	dtosOut := make([]*dtos.RegistroHumorDTOOut, len(registros))
+	for i, reg := range registros {
+		dtosOut[i] = RegistroHumorParaDTOOut(reg)
+	}
+	return dtosOut
+}
+
+func RevisoesRegistroHumorParaDTOOut(revisoes []*dominio.RevisaoRegistroHumor) []*dtos.RevisaoRegistroHumorDTOOut {
+	dtosOut := make([]*dtos.RevisaoRegistroHumorDTOOut, len(revisoes))
+	for i, rev := range revisoes {
+		dtosOut[i] = &dtos.RevisaoRegistroHumorDTOOut{
+			RegistroHumorID:  rev.RegistroHumorID,
+			Revisao:          rev.Revisao,
+			Operacao:         rev.Operacao,
+			NivelHumor:       rev.NivelHumor,
+			HorasSono:        rev.HorasSono,
+			NivelEnergia:     rev.NivelEnergia,
+			NivelStress:      rev.NivelStress,
+			AutoCuidado:      rev.AutoCuidado,
+			Observacoes:      rev.Observacoes,
+			DataHoraRegistro: rev.DataHoraRegistro,
+			CreatedAt:        rev.CreatedAt,
+		}
+	}
+	return dtosOut
+}
+
+func ValoresMetricasParaExportacaoDTOOut(valores []*dominio.ValorMetricaRegistro) []*dtos.ValorMetricaExportacaoDTOOut {
+	dtosOut := make([]*dtos.ValorMetricaExportacaoDTOOut, len(valores))
+	for i, valor := range valores {
+		dtosOut[i] = &dtos.ValorMetricaExportacaoDTOOut{
+			RegistroHumorID: valor.RegistroHumorID,
+			MetricaID:       valor.MetricaID,
+			Valor:           valor.Valor,
+		}
+		if valor.Metrica != nil {
+			dtosOut[i].Rotulo = valor.Metrica.Rotulo
+		}
+	}
+	return dtosOut
+}
+
+// AtribuicoesParaExportacaoDTOOut converte as atribuicoes do titular
+// As respostas so sao incluidas na exportacao do proprio paciente
+func AtribuicoesParaExportacaoDTOOut(atribuicoes []*dominio.Atribuicao, incluirRespostas bool) []*dtos.AtribuicaoExportacaoDTOOut {
+	dtosOut := make([]*dtos.AtribuicaoExportacaoDTOOut, len(atribuicoes))
+	for i, atrib := range atribuicoes {
+		dto := &dtos.AtribuicaoExportacaoDTOOut{
+			ID:                atrib.ID,
+			Status:            atrib.Status,
+			DataAtribuicao:    atrib.DataAtribuicao,
+			DataResposta:      atrib.DataResposta,
+			InstrumentoCodigo: atrib.Instrumento.Codigo,
+			InstrumentoNome:   atrib.Instrumento.Nome,
+			ProfissionalID:    atrib.ProfissionalID,
+			PacienteID:        atrib.PacienteID,
+		}
+		if incluirRespostas && atrib.Resposta != nil {
+			dto.Resposta = &dtos.RespostaExportacaoDTOOut{
+				PontuacaoTotal: atrib.Resposta.PontuacaoTotal,
+				Classificacao:  atrib.Resposta.Classificacao,
+				DadosBrutos:    json.RawMessage(atrib.Resposta.DadosBrutos),
+				DataResposta:   atrib.Resposta.DataResposta,
+			}
+		}
+		dtosOut[i] = dto
+	}
+	return dtosOut
+}
+
+func ConvitesParaDTOOut(convites []*dominio.Convite) []*dtos.ConviteDTOOut {
+	dtosOut := make([]*dtos.ConviteDTOOut, len(convites))
+	for i, convite := range convites {
+		dtosOut[i] = ConviteParaDTOOut(convite)
+	}
+	return dtosOut
+}
+
+func NotificacoesParaExportacaoDTOOut(notificacoes []*dominio.Notificacao) []*dtos.NotificacaoExportacaoDTOOut {
+	dtosOut := make([]*dtos.NotificacaoExportacaoDTOOut, len(notificacoes))
+	for i, n := range notificacoes {
+		dtosOut[i] = &dtos.NotificacaoExportacaoDTOOut{
+			ID:        n.ID,
+			Conteudo:  n.Conteudo,
+			Status:    n.Status,
+			DataEnvio: n.DataEnvio,
+		}
+	}
+	return dtosOut
+}
+
+func ResponsavelParaDTOOut(responsavel *dominio.Responsavel) *dtos.ResponsavelDTOOut {
+	return &dtos.ResponsavelDTOOut{
+		ID:        responsavel.ID,
+		Usuario:   *UsuarioParaDTOOut(&responsavel.Usuario),
+		CreatedAt: responsavel.CreatedAt,
+	}
+}
+
+// VinculosResponsavelParaDTOOut usa os nomes das associacoes carregadas pelo repositorio
+func VinculosResponsavelParaDTOOut(vinculos []*dominio.VinculoResponsavel, agora time.Time) []*dtos.VinculoResponsavelDTOOut {
+	dtosOut := make([]*dtos.VinculoResponsavelDTOOut, len(vinculos))
+	for i, v := range vinculos {
+		dtosOut[i] = VinculoResponsavelParaDTOOut(v, agora)
+	}
+	return dtosOut
+}
+
+func VinculoResponsavelParaDTOOut(v *dominio.VinculoResponsavel, agora time.Time) *dtos.VinculoResponsavelDTOOut {
+	return &dtos.VinculoResponsavelDTOOut{
+		ResponsavelID:   v.ResponsavelID,
+		PacienteID:      v.PacienteID,
+		NomeResponsavel: v.Responsavel.Usuario.Nome,
+		NomeDependente:  v.Paciente.Usuario.Nome,
+		Parentesco:      v.Parentesco,
+		MenorDeIdade:    v.Paciente.MenorDeIdade(agora),
+		VinculadoEm:     v.VinculadoEm,
+		EncerradoEm:     v.EncerradoEm,
+	}
+}
+
+func AcoesResponsavelParaDTOOut(acoes []*dominio.AcaoResponsavel) []*dtos.AcaoResponsavelDTOOut {
+	dtosOut := make([]*dtos.AcaoResponsavelDTOOut, len(acoes))
+	for i, a := range acoes {
+		dtosOut[i] = &dtos.AcaoResponsavelDTOOut{
+			ID:            a.ID,
+			ResponsavelID: a.ResponsavelID,
+			PacienteID:    a.PacienteID,
+			Acao:          a.Acao,
+			Detalhe:       a.Detalhe,
+			CreatedAt:     a.CreatedAt,
+		}
+	}
+	return dtosOut
+}
+
+func RegistrosAuditoriaParaAcessosDTOOut(registros []*dominio.RegistroAuditoria) []*dtos.AcessoDadosDTOOut {
+	dtosOut := make([]*dtos.AcessoDadosDTOOut, len(registros))
+	for i, r := range registros {
+		nomeAtor := ""
+		if r.Ator != nil {
+			nomeAtor = r.Ator.Nome
+		}
+		dtosOut[i] = &dtos.AcessoDadosDTOOut{
+			ID:          r.ID,
+			NomeAtor:    nomeAtor,
+			TipoAtor:    r.TipoAtor,
+			Acao:        r.Acao,
+			TipoRecurso: r.TipoRecurso,
+			RecursoID:   r.RecursoID,
+			Resultado:   r.Resultado,
+			CriadoEm:    r.CriadoEm,
+		}
+	}
+	return dtosOut
+}
+
+func ConsultaParaDTOOut(consulta *dominio.Consulta) *dtos.ConsultaDTOOut {
+	return &dtos.ConsultaDTOOut{
+		ID:               consulta.ID,
+		ProfissionalID:   consulta.ProfissionalID,
+		NomeProfissional: consulta.Profissional.Usuario.Nome,
+		PacienteID:       consulta.PacienteID,
+		NomePaciente:     consulta.Paciente.Usuario.Nome,
+		Inicio:           consulta.Inicio,
+		Fim:              consulta.Fim,
+		Modalidade:       consulta.Modalidade,
+		Local:            consulta.Local,
+		Status:           consulta.Status,
+		SerieID:          consulta.SerieID,
+		InstrumentoID:    consulta.InstrumentoID,
+		AtribuicaoID:     consulta.AtribuicaoID,
+	}
+}
+
+func ConsultasParaDTOOut(consultas []*dominio.Consulta) []*dtos.ConsultaDTOOut {
+	dtosOut := make([]*dtos.ConsultaDTOOut, len(consultas))
+	for i, c := range consultas {
+		dtosOut[i] = ConsultaParaDTOOut(c)
+	}
+	return dtosOut
+}
+
+// NotaClinicaParaDTOOut usa a revisao atual ja decifrada pelo servico
+func NotaClinicaParaDTOOut(nota *dominio.NotaClinica, profissionalID uint) *dtos.NotaClinicaDTOOut {
+	dtoOut := &dtos.NotaClinicaDTOOut{
+		ID:             nota.ID,
+		PacienteID:     nota.PacienteID,
+		ProfissionalID: nota.ProfissionalID,
+		Propria:        nota.Autor(profissionalID),
+		Revisao:        nota.RevisaoAtual,
+		CreatedAt:      nota.CreatedAt,
+		AtualizadaEm:   nota.UpdatedAt,
+	}
+	if nota.Atual != nil {
+		dtoOut.Texto = nota.Atual.Conteudo.Texto
+		dtoOut.DataSessao = nota.Atual.DataSessao
+		dtoOut.TipoSessao = nota.Atual.TipoSessao
+		dtoOut.Tags = nota.Atual.Conteudo.Tags
+		dtoOut.AtualizadaEm = nota.Atual.CreatedAt
+	}
+	if dtoOut.Propria {
+		for _, c := range nota.Compartilhamentos {
+			if c.RevogadoEm == nil {
+				dtoOut.CompartilhadaCom = append(dtoOut.CompartilhadaCom, c.ProfissionalID)
+			}
+		}
+	}
+	return dtoOut
+}
+
+func RevisoesNotaClinicaParaDTOOut(revisoes []*dominio.RevisaoNotaClinica) []*dtos.RevisaoNotaClinicaDTOOut {
+	dtosOut := make([]*dtos.RevisaoNotaClinicaDTOOut, len(revisoes))
+	for i, r := range revisoes {
+		dtosOut[i] = &dtos.RevisaoNotaClinicaDTOOut{
+			Revisao:    r.Revisao,
+			Texto:      r.Conteudo.Texto,
+			DataSessao: r.DataSessao,
+			TipoSessao: r.TipoSessao,
+			Tags:       r.Conteudo.Tags,
+			CreatedAt:  r.CreatedAt,
+		}
+	}
+	return dtosOut
+}
@@ -0,0 +1,71 @@
+package middlewares
+
+import (
+	"log"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChavePacientesAuditados e a chave do contexto em que os controladores informam
+// os pacientes cujos dados foram devolvidos na resposta
+const ChavePacientesAuditados = "pacientesAuditados"
+
+// AuditoriaMiddleware registra na trilha de auditoria o acesso feito pela rota, com o resultado
+// Os pacientes vem do controlador (ChavePacientesAuditados) ou, na falta, do parametro pacienteID
+// parametroRecurso nomeia o parametro da query com o ID do recurso; vazio quando nao ha
+// Deve ser usado apos o AutMiddleware
+func AuditoriaMiddleware(as servicos.AuditoriaServico, acao, tipoRecurso, parametroRecurso string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		base := dominio.RegistroAuditoria{
+			TipoAtor:    c.GetString("tipo"),
+			Acao:        acao,
+			TipoRecurso: tipoRecurso,
+			IP:          c.ClientIP(),
+			UserAgent:   c.Request.UserAgent(),
+			Resultado:   dominio.ResultadoAuditoriaPorStatus(c.Writer.Status()),
+		}
+		if userID, ok := c.Get("userID"); ok {
+			id := userID.(uint)
+			base.AtorID = &id
+		}
+		if parametroRecurso != "" {
+			base.RecursoID = lerIDOpcional(c.Query(parametroRecurso))
+		}
+
+		var pacientes []uint
+		if valor, ok := c.Get(ChavePacientesAuditados); ok {
+			pacientes = valor.([]uint)
+		} else if pacienteID := lerIDOpcional(c.Query("pacienteID")); pacienteID != nil {
+			pacientes = []uint{*pacienteID}
+		}
+
+		// Um registro por paciente permite a cada um consultar os proprios acessos
+		var registros []*dominio.RegistroAuditoria
+		for _, pacienteID := range pacientes {
+			registro := base
+			registro.PacienteID = &pacienteID
+			registros = append(registros, &registro)
+		}
+		if len(registros) == 0 {
+			registros = append(registros, &base)
+		}
+
+		if err := as.Registrar(registros...); err != nil {
+			log.Printf("falha ao registrar auditoria de %s: %v", acao, err)
+		}
+	}
+}
+
+func lerIDOpcional(valor string) *uint {
+	id, err := strconv.ParseUint(valor, 10, 64)
+	if err != nil || id == 0 {
+		return nil
+	}
+	resultado := uint(id)
+	return &resultado
+}
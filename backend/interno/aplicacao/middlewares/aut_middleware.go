@@ -1,18 +1,19 @@
 package middlewares
 
 import (
-	"fmt"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/persistencia/repositorios"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 // AutMiddleware cria um middleware para autenticacao JWT
 // Verifica o token no header Authorization e extrai o userID para o contexto
-func AutMiddleware() gin.HandlerFunc {
+// Tokens com versao de sessao diferente da atual do usuario sao rejeitados (sessao revogada)
+// A assinatura e conferida pelo conjunto de chaves, que aceita todas as chaves de verificacao ativas
+func AutMiddleware(usuarioRepo repositorios.UsuarioRepositorio, chavesJWT servicos.ChavesJWT) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -28,32 +29,37 @@ func AutMiddleware() gin.HandlerFunc {
 		}
 
 		tokenString := parts[1]
-		jwtSecret := os.Getenv("JWT_SECRET")
-
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("método de assinatura inesperado: %v", token.Header["alg"])
-			}
-			return []byte(jwtSecret), nil
-		})
 
+		claims, err := chavesJWT.Validar(tokenString)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"erro": "Token inválido"})
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			// Extrai o ID do usuario do token e coloca no contexto do Gin
-			// Facilita os controladores a identificar qual usuario fez a requisicao
-			userIDFloat := claims["sub"].(float64)
-			role := claims["role"]
-			c.Set("userID", uint(userIDFloat))
-			c.Set("tipo", role.(string))
-		} else {
+		// Extrai o ID do usuario do token e coloca no contexto do Gin
+		// Facilita os controladores a identificar qual usuario fez a requisicao
+		userIDFloat, okSub := claims["sub"].(float64)
+		role, okRole := claims["role"].(string)
+		if !okSub || !okRole {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"erro": "Token inválido"})
 			return
 		}
 
+		// Tokens emitidos antes da ultima revogacao nao sao mais aceitos
+		versaoSessao, _ := claims["sv"].(float64)
+		usuario, err := usuarioRepo.BuscarUsuarioPorID(uint(userIDFloat))
+		if err != nil || usuario.VersaoSessao != uint(versaoSessao) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"erro": "Sessão expirada ou revogada"})
+			return
+		}
+
+		// Tokens anteriores ao segundo fator nao possuem a claim e contam como sem mfa
+		mfa, _ := claims["mfa"].(bool)
+
+		c.Set("userID", uint(userIDFloat))
+		c.Set("tipo", role)
+		c.Set("mfa", mfa)
+
 		c.Next() // Passa para o proximo handler
 	}
 }
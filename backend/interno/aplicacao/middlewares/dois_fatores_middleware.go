@@ -0,0 +1,24 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExigirDoisFatoresMiddleware bloqueia profissionais que entraram sem segundo fator
+// quando a organizacao torna a autenticacao em dois fatores obrigatoria
+// Deve ser usado apos o AutMiddleware
+func ExigirDoisFatoresMiddleware(obrigatorio bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !obrigatorio || c.GetString("tipo") != "profissional" || c.GetBool("mfa") {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"erro":                     "Autenticação em dois fatores obrigatória: configure o autenticador e entre novamente",
+			"dois_fatores_obrigatorio": true,
+		})
+	}
+}
@@ -2,10 +2,14 @@ package servicos
 
 import (
 	"errors"
+	"fmt"
 	"log"
+	"math"
 	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/mappers"
 	"mindtrace/backend/interno/dominio"
 	"mindtrace/backend/interno/persistencia/repositorios"
+	"sort"
 	"time"
 
 	"gorm.io/gorm"
@@ -17,68 +21,186 @@ const (
 	StatusRegular     = "REGULAR"
 )
 
+// Periodos das correlacoes entre metricas e da analise agregada, em dias
+const (
+	diasPadraoCorrelacaoMetricas = 90
+	diasMaximoCorrelacaoMetricas = 365
+	diasPadraoAnaliseAgregada    = 30
+	diasMaximoAnaliseAgregada    = 365
+)
+
+// Periodo da analise historica e limites da amostragem dos graficos, em dias
+const (
+	diasMaximoAnaliseHistorica = 365
+	// O intervalo de/ate cobre um ano inteiro, inclusive bissexto
+	diasMaximoIntervaloAnalise = 366
+	diasAmostragemDiaria       = 90
+	diasAmostragemSemanal      = 180
+)
+
 type AnaliseServico interface {
 	// GerarAnaliseHistorica: Para o frontend desenhar gráficos (substitui GerarRelatorio)
 	GerarAnaliseHistorica(usuarioID, pacienteID uint, tipoUsuario string, dias int) (*dtos.AnalisePacienteDTOOut, error)
 
+	// GerarAnaliseIntervalo: Como GerarAnaliseHistorica, para as datas de/ate
+	GerarAnaliseIntervalo(usuarioID, pacienteID uint, tipoUsuario string, intervalo dtos.IntervaloAnaliseDTOIn) (*dtos.AnalisePacienteDTOOut, error)
+
+	// CompararPeriodos: Analise de dois intervalos lado a lado, com as diferencas das medias
+	CompararPeriodos(usuarioID, pacienteID uint, tipoUsuario string, periodo, referencia dtos.IntervaloAnaliseDTOIn) (*dtos.ComparacaoAnaliseDTOOut, error)
+
+	// GerarCorrelacoes: Correlacoes de Pearson e Spearman entre as medias diarias das metricas, no mesmo dia e no dia seguinte
+	GerarCorrelacoes(usuarioID, pacienteID uint, tipoUsuario string, dias int) (*dtos.CorrelacoesMetricasDTOOut, error)
+
+	// GerarAnaliseAgregada: Metricas por dia ou semana, com lacunas explicitas, media movel de 7 dias e perfil por dia da semana
+	GerarAnaliseAgregada(usuarioID, pacienteID uint, tipoUsuario string, filtro *dtos.FiltroAnaliseAgregadaDTOIn) (*dtos.AnaliseAgregadaDTOOut, error)
+
 	// ExecutarMonitoramento: Chamado automaticamente após novos registros ou via cron job
 	ExecutarMonitoramento(pacienteID uint) error
 }
 
 type analiseServico struct {
-	db           *gorm.DB
-	registroRepo repositorios.RegistroHumorRepositorio
-	usuarioRepo  repositorios.UsuarioRepositorio
+	db                *gorm.DB
+	registroRepo      repositorios.RegistroHumorRepositorio
+	usuarioRepo       repositorios.UsuarioRepositorio
+	consentimentoRepo repositorios.ConsentimentoRepositorio
+	vinculoRepo       repositorios.VinculoRepositorio
+	notificacaoRepo   repositorios.NotificacaoRepositorio
+	responsavelRepo   repositorios.ResponsavelRepositorio
+	metricaRepo       repositorios.MetricaPersonalizadaRepositorio
 	// alertaRepo  repositorios.AlertaRepositorio // Futuro: para persistir o alerta
 }
 
-func NovoAnaliseServico(db *gorm.DB, regRepo repositorios.RegistroHumorRepositorio, userRepo repositorios.UsuarioRepositorio) AnaliseServico {
+func NovoAnaliseServico(db *gorm.DB, regRepo repositorios.RegistroHumorRepositorio, userRepo repositorios.UsuarioRepositorio, consentRepo repositorios.ConsentimentoRepositorio, vincRepo repositorios.VinculoRepositorio, notifRepo repositorios.NotificacaoRepositorio, respRepo repositorios.ResponsavelRepositorio, metricaRepo repositorios.MetricaPersonalizadaRepositorio) AnaliseServico {
 	return &analiseServico{
-		db:           db,
-		registroRepo: regRepo,
-		usuarioRepo:  userRepo,
+		db:                db,
+		registroRepo:      regRepo,
+		usuarioRepo:       userRepo,
+		consentimentoRepo: consentRepo,
+		vinculoRepo:       vincRepo,
+		notificacaoRepo:   notifRepo,
+		responsavelRepo:   respRepo,
+		metricaRepo:       metricaRepo,
 	}
 }
 
 func (s *analiseServico) GerarAnaliseHistorica(usuarioID, pacienteID uint, tipoUsuario string, dias int) (*dtos.AnalisePacienteDTOOut, error) {
-	if dias <= 0 || dias > 90 {
-		return nil, errors.New("periodo invalido")
+	if dias <= 0 || dias > diasMaximoAnaliseHistorica {
+		return nil, dominio.ErrPeriodoHistoricoInvalido
 	}
 
 	now := time.Now()
-	dataInicio := now.AddDate(0, 0, -dias)
+	return s.gerarAnalise(usuarioID, pacienteID, tipoUsuario, now.AddDate(0, 0, -dias), now)
+}
 
-	if dominio.StringParaTipoUsuario(tipoUsuario) == dominio.TipoUsuarioPaciente && pacienteID == 0 {
-		pacienteInfo, err := s.usuarioRepo.BuscarPacientePorUsuarioID(s.db, usuarioID)
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return nil, dominio.ErrUsuarioNaoEncontrado
-			}
-			return nil, err
-		}
+// GerarAnaliseIntervalo usa os dias do calendario no fuso do servidor, de 'de' ate o fim de 'ate'
+func (s *analiseServico) GerarAnaliseIntervalo(usuarioID, pacienteID uint, tipoUsuario string, intervalo dtos.IntervaloAnaliseDTOIn) (*dtos.AnalisePacienteDTOOut, error) {
+	inicio, fim, err := limitesIntervalo(intervalo, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.gerarAnalise(usuarioID, pacienteID, tipoUsuario, inicio, fim)
+}
+
+// CompararPeriodos gera a analise de cada intervalo; as diferencas sao do periodo menos a referencia
+func (s *analiseServico) CompararPeriodos(usuarioID, pacienteID uint, tipoUsuario string, periodo, referencia dtos.IntervaloAnaliseDTOIn) (*dtos.ComparacaoAnaliseDTOOut, error) {
+	agora := time.Now()
+	inicio, fim, err := limitesIntervalo(periodo, agora)
+	if err != nil {
+		return nil, err
+	}
+	inicioReferencia, fimReferencia, err := limitesIntervalo(referencia, agora)
+	if err != nil {
+		return nil, err
+	}
 
-		pacienteID = pacienteInfo.ID
+	analisePeriodo, err := s.gerarAnalise(usuarioID, pacienteID, tipoUsuario, inicio, fim)
+	if err != nil {
+		return nil, err
+	}
+	analiseReferencia, err := s.gerarAnalise(usuarioID, pacienteID, tipoUsuario, inicioReferencia, fimReferencia)
+	if err != nil {
+		return nil, err
+	}
+	return &dtos.ComparacaoAnaliseDTOOut{
+		Periodo:    analisePeriodo,
+		Referencia: analiseReferencia,
+		Diferencas: diferencasAnalise(analisePeriodo, analiseReferencia),
+	}, nil
+}
+
+// limitesIntervalo converte as datas do intervalo em instantes no fuso do servidor; um fim no futuro vira agora
+func limitesIntervalo(intervalo dtos.IntervaloAnaliseDTOIn, agora time.Time) (time.Time, time.Time, error) {
+	inicio := time.Date(intervalo.De.Year(), intervalo.De.Month(), intervalo.De.Day(), 0, 0, 0, 0, time.Local)
+	ultimoDia := time.Date(intervalo.Ate.Year(), intervalo.Ate.Month(), intervalo.Ate.Day(), 0, 0, 0, 0, time.Local)
+	if ultimoDia.Before(inicio) || inicio.After(agora) || ultimoDia.After(inicio.AddDate(0, 0, diasMaximoIntervaloAnalise-1)) {
+		return time.Time{}, time.Time{}, dominio.ErrIntervaloAnaliseInvalido
+	}
+	fim := ultimoDia.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	if fim.After(agora) {
+		fim = agora
+	}
+	return inicio, fim, nil
+}
+
+// gerarAnalise monta os graficos e medias dos registros entre inicio e fim
+// Acima de 90 dias os graficos trazem a media de cada dia e, acima de 180, a de cada semana
+func (s *analiseServico) gerarAnalise(usuarioID, pacienteID uint, tipoUsuario string, inicio, fim time.Time) (*dtos.AnalisePacienteDTOOut, error) {
+	now := time.Now()
+	pacienteID, consentimento, err := s.resolverAcesso(usuarioID, pacienteID, tipoUsuario)
+	if err != nil {
+		return nil, err
 	}
 
-	registros, err := s.registroRepo.BuscarPorPacienteEPeriodo(pacienteID, dataInicio, now)
+	// O proprio paciente ve tudo; o profissional ve apenas o que o consentimento vigente libera
+	compartilhaHumor, compartilhaSono, dataInicio, err := permissoesAnalise(consentimento, inicio, now)
 	if err != nil {
 		return nil, err
 	}
 
+	registros, err := s.registroRepo.BuscarPorPacienteEPeriodo(pacienteID, dataInicio, fim)
+	if err != nil {
+		return nil, err
+	}
+
+	fuso := time.Local
 	analise := &dtos.AnalisePacienteDTOOut{
+		Inicio:         dataInicio,
+		Fim:            fim,
+		Fuso:           descreverFuso(fuso, now),
+		Amostragem:     amostragemDoIntervalo(dataInicio, fim),
+		TotalRegistros: len(registros),
 		GraficoSono:    make([]dtos.PontoDeDadosDTOOut, 0),
 		GraficoEnergia: make([]dtos.PontoDeDadosDTOOut, 0),
 		GraficoStress:  make([]dtos.PontoDeDadosDTOOut, 0),
-		StatusAtual:    StatusRegular, // Default
+		// Metricas personalizadas acompanham a categoria humor
+		GraficosMetricas: make([]dtos.GraficoMetricaDTOOut, 0),
+		StatusAtual:      StatusRegular, // Default
+	}
+	if consentimento != nil {
+		// O status depende de todas as metricas e nao e exposto quando alguma categoria esta oculta
+		if !compartilhaHumor || !compartilhaSono {
+			analise.StatusAtual = ""
+		}
+		analise.CategoriasCompartilhadas = categoriasCompartilhadas(consentimento, compartilhaHumor, compartilhaSono)
 	}
 
 	var somaSono, somaEnergia, somaStress, somaHumor int
 
 	for _, reg := range registros {
+		// Sem a categoria humor, o ponto de sono nao carrega o humor do dia
+		var humor int16
+		if compartilhaHumor {
+			humor = reg.NivelHumor
+		}
+
 		// Popula gráficos
-		analise.GraficoSono = append(analise.GraficoSono, dtos.PontoDeDadosDTOOut{Data: reg.DataHoraRegistro, Valor: reg.HorasSono, Humor: reg.NivelHumor})
-		analise.GraficoEnergia = append(analise.GraficoEnergia, dtos.PontoDeDadosDTOOut{Data: reg.DataHoraRegistro, Valor: reg.NivelEnergia, Humor: reg.NivelHumor})
-		analise.GraficoStress = append(analise.GraficoStress, dtos.PontoDeDadosDTOOut{Data: reg.DataHoraRegistro, Valor: reg.NivelStress, Humor: reg.NivelHumor})
+		if compartilhaSono {
+			analise.GraficoSono = append(analise.GraficoSono, dtos.PontoDeDadosDTOOut{Data: reg.DataHoraRegistro, Valor: float64(reg.HorasSono), Humor: humor})
+		}
+		if compartilhaHumor {
+			analise.GraficoEnergia = append(analise.GraficoEnergia, dtos.PontoDeDadosDTOOut{Data: reg.DataHoraRegistro, Valor: float64(reg.NivelEnergia), Humor: humor})
+			analise.GraficoStress = append(analise.GraficoStress, dtos.PontoDeDadosDTOOut{Data: reg.DataHoraRegistro, Valor: float64(reg.NivelStress), Humor: humor})
+		}
 
 		// Acumula para médias
 		somaSono += int(reg.HorasSono)
@@ -87,20 +209,446 @@ func (s *analiseServico) GerarAnaliseHistorica(usuarioID, pacienteID uint, tipoU
 		somaHumor += int(reg.NivelHumor)
 	}
 
+	if compartilhaHumor && len(registros) > 0 {
+		registroIDs := make([]uint, 0, len(registros))
+		for _, reg := range registros {
+			registroIDs = append(registroIDs, reg.ID)
+		}
+		valores, err := s.metricaRepo.ListarValoresDosRegistros(s.db, registroIDs)
+		if err != nil {
+			return nil, err
+		}
+		analise.GraficosMetricas = graficosMetricas(registros, valores)
+	}
+
 	if len(registros) > 0 {
 		count := float64(len(registros))
-		analise.MediaSono = float64(somaSono) / count
-		analise.MediaEnergia = float64(somaEnergia) / count
-		analise.MediaStress = float64(somaStress) / count
-		analise.MediaHumor = float64(somaHumor) / count
+		if compartilhaSono {
+			analise.MediaSono = float64(somaSono) / count
+		}
+		if compartilhaHumor {
+			analise.MediaEnergia = float64(somaEnergia) / count
+			analise.MediaStress = float64(somaStress) / count
+			analise.MediaHumor = float64(somaHumor) / count
+		}
 
 		// Recalcula o status baseado nos dados carregados
-		analise.StatusAtual = s.calcularStatus(analise.MediaSono, analise.MediaHumor, analise.MediaStress, analise.MediaEnergia)
+		if compartilhaHumor && compartilhaSono {
+			analise.StatusAtual = calcularStatus(analise.MediaSono, analise.MediaHumor, analise.MediaStress, analise.MediaEnergia)
+		}
+	}
+
+	// As medias acima usam todos os registros; apenas os graficos sao reduzidos
+	if analise.Amostragem != dominio.AmostragemRegistro {
+		analise.GraficoSono = amostrarPontos(analise.GraficoSono, dataInicio, fim, analise.Amostragem, fuso)
+		analise.GraficoEnergia = amostrarPontos(analise.GraficoEnergia, dataInicio, fim, analise.Amostragem, fuso)
+		analise.GraficoStress = amostrarPontos(analise.GraficoStress, dataInicio, fim, analise.Amostragem, fuso)
+		for i := range analise.GraficosMetricas {
+			pontos := make([]dtos.PontoDeDadosDTOOut, 0, len(analise.GraficosMetricas[i].Pontos))
+			for _, ponto := range analise.GraficosMetricas[i].Pontos {
+				pontos = append(pontos, dtos.PontoDeDadosDTOOut(ponto))
+			}
+			analise.GraficosMetricas[i].Pontos = analise.GraficosMetricas[i].Pontos[:0]
+			for _, ponto := range amostrarPontos(pontos, dataInicio, fim, analise.Amostragem, fuso) {
+				analise.GraficosMetricas[i].Pontos = append(analise.GraficosMetricas[i].Pontos, dtos.PontoMetricaDTOOut(ponto))
+			}
+		}
 	}
 
 	return analise, nil
 }
 
+// amostragemDoIntervalo escolhe um ponto por registro ate 90 dias, por dia ate 180 e por semana acima disso
+func amostragemDoIntervalo(inicio, fim time.Time) string {
+	switch duracao := fim.Sub(inicio); {
+	case duracao > diasAmostragemSemanal*24*time.Hour:
+		return dominio.AmostragemSemana
+	case duracao > diasAmostragemDiaria*24*time.Hour:
+		return dominio.AmostragemDia
+	}
+	return dominio.AmostragemRegistro
+}
+
+// amostrarPontos troca os pontos de cada dia ou semana pela media, datada no inicio do periodo
+// O humor do ponto e a media arredondada do humor dos registros do periodo; periodos sem registro ficam de fora
+func amostrarPontos(pontos []dtos.PontoDeDadosDTOOut, inicio, fim time.Time, agrupamento string, fuso *time.Location) []dtos.PontoDeDadosDTOOut {
+	valores, humores := &dominio.SerieMetrica{}, &dominio.SerieMetrica{}
+	for _, ponto := range pontos {
+		valores.Pontos = append(valores.Pontos, dominio.PontoMetrica{Instante: ponto.Data, Valor: ponto.Valor})
+		if ponto.Humor != 0 {
+			humores.Pontos = append(humores.Pontos, dominio.PontoMetrica{Instante: ponto.Data, Valor: float64(ponto.Humor)})
+		}
+	}
+	periodosValor := dominio.AgregarSerie(valores, inicio, fim, agrupamento, dominio.AgregacaoMedia, fuso)
+	periodosHumor := dominio.AgregarSerie(humores, inicio, fim, agrupamento, dominio.AgregacaoMedia, fuso)
+
+	amostrados := make([]dtos.PontoDeDadosDTOOut, 0, len(periodosValor))
+	for i, periodo := range periodosValor {
+		if periodo.Valor == nil {
+			continue
+		}
+		ponto := dtos.PontoDeDadosDTOOut{Data: periodo.Inicio, Valor: *periodo.Valor}
+		if humor := periodosHumor[i].Valor; humor != nil {
+			ponto.Humor = int16(math.Round(*humor))
+		}
+		amostrados = append(amostrados, ponto)
+	}
+	return amostrados
+}
+
+// diferencasAnalise subtrai as medias da referencia das do periodo
+// Uma media fica nula quando a categoria esta oculta ou um dos periodos nao tem registros
+func diferencasAnalise(periodo, referencia *dtos.AnalisePacienteDTOOut) dtos.DiferencasAnaliseDTOOut {
+	diferencas := dtos.DiferencasAnaliseDTOOut{
+		TotalRegistros: periodo.TotalRegistros - referencia.TotalRegistros,
+		Metricas:       make([]dtos.DiferencaMetricaDTOOut, 0),
+	}
+	if periodo.TotalRegistros == 0 || referencia.TotalRegistros == 0 {
+		return diferencas
+	}
+	diferenca := func(a, b float64) *float64 {
+		d := a - b
+		return &d
+	}
+	compartilhaHumor, compartilhaSono := true, true
+	if periodo.CategoriasCompartilhadas != nil {
+		compartilhaHumor, compartilhaSono = false, false
+		for _, categoria := range periodo.CategoriasCompartilhadas {
+			compartilhaHumor = compartilhaHumor || categoria == dominio.CategoriaHumor
+			compartilhaSono = compartilhaSono || categoria == dominio.CategoriaSono
+		}
+	}
+	if compartilhaSono {
+		diferencas.MediaSono = diferenca(periodo.MediaSono, referencia.MediaSono)
+	}
+	if compartilhaHumor {
+		diferencas.MediaHumor = diferenca(periodo.MediaHumor, referencia.MediaHumor)
+		diferencas.MediaEnergia = diferenca(periodo.MediaEnergia, referencia.MediaEnergia)
+		diferencas.MediaStress = diferenca(periodo.MediaStress, referencia.MediaStress)
+	}
+
+	// Metricas personalizadas entram quando tem valores nos dois periodos
+	mediasReferencia := make(map[uint]float64, len(referencia.GraficosMetricas))
+	for _, grafico := range referencia.GraficosMetricas {
+		mediasReferencia[grafico.MetricaID] = grafico.Media
+	}
+	for _, grafico := range periodo.GraficosMetricas {
+		if media, ok := mediasReferencia[grafico.MetricaID]; ok {
+			diferencas.Metricas = append(diferencas.Metricas, dtos.DiferencaMetricaDTOOut{MetricaID: grafico.MetricaID, Rotulo: grafico.Rotulo, Diferenca: grafico.Media - media})
+		}
+	}
+	return diferencas
+}
+
+// GerarCorrelacoes agrupa os registros por dia no fuso do servidor
+// O profissional recebe apenas as metricas das categorias consentidas e o periodo desde o inicio do consentimento;
+// as metricas personalizadas acompanham a categoria humor
+func (s *analiseServico) GerarCorrelacoes(usuarioID, pacienteID uint, tipoUsuario string, dias int) (*dtos.CorrelacoesMetricasDTOOut, error) {
+	if dias <= 0 {
+		dias = diasPadraoCorrelacaoMetricas
+	}
+	if dias > diasMaximoCorrelacaoMetricas {
+		return nil, dominio.ErrPeriodoCorrelacaoInvalido
+	}
+
+	agora := time.Now()
+	pacienteID, consentimento, err := s.resolverAcesso(usuarioID, pacienteID, tipoUsuario)
+	if err != nil {
+		return nil, err
+	}
+	compartilhaHumor, compartilhaSono, inicio, err := permissoesAnalise(consentimento, agora.AddDate(0, 0, -dias), agora)
+	if err != nil {
+		return nil, err
+	}
+
+	registros, err := s.registroRepo.BuscarPorPacienteEPeriodo(pacienteID, inicio, agora)
+	if err != nil {
+		return nil, err
+	}
+	series, err := s.seriesVisiveis(registros, compartilhaHumor, compartilhaSono)
+	if err != nil {
+		return nil, err
+	}
+
+	fuso := time.Local
+	diarias := make([]*dominio.SerieDiaria, 0, len(series))
+	for _, serie := range series {
+		diarias = append(diarias, serie.Diaria(fuso))
+	}
+	return &dtos.CorrelacoesMetricasDTOOut{
+		PacienteID:    pacienteID,
+		Dias:          dias,
+		Fuso:          descreverFuso(fuso, agora),
+		AmostraMinima: dominio.AmostraMinimaCorrelacao,
+		Metricas:      mappers.SeriesCorrelacaoParaDTOOut(diarias),
+		Correlacoes:   mappers.CorrelacoesMetricasParaDTOOut(dominio.CorrelacionarSeries(diarias)),
+	}, nil
+}
+
+// GerarAnaliseAgregada resume cada metrica por dia ou semana, com os periodos sem registro como lacunas
+// O periodo comeca a meia-noite, no fuso pedido, do primeiro dos ultimos `dias` dias, ou no inicio do consentimento
+func (s *analiseServico) GerarAnaliseAgregada(usuarioID, pacienteID uint, tipoUsuario string, filtro *dtos.FiltroAnaliseAgregadaDTOIn) (*dtos.AnaliseAgregadaDTOOut, error) {
+	dias := filtro.Dias
+	if dias == 0 {
+		dias = diasPadraoAnaliseAgregada
+	}
+	if dias < 0 || dias > diasMaximoAnaliseAgregada {
+		return nil, dominio.ErrPeriodoAnaliseInvalido
+	}
+	agrupamento, err := dominio.ValidarAgrupamento(filtro.Agrupamento)
+	if err != nil {
+		return nil, err
+	}
+	agregacao, err := dominio.ValidarAgregacao(filtro.Agregacao)
+	if err != nil {
+		return nil, err
+	}
+	fuso := time.Local
+	if filtro.Fuso != "" {
+		if fuso, err = time.LoadLocation(filtro.Fuso); err != nil {
+			return nil, dominio.ErrFusoInvalido
+		}
+	}
+
+	agora := time.Now()
+	pacienteID, consentimento, err := s.resolverAcesso(usuarioID, pacienteID, tipoUsuario)
+	if err != nil {
+		return nil, err
+	}
+	compartilhaHumor, compartilhaSono, inicio, err := permissoesAnalise(consentimento, dominio.InicioDoDia(agora, fuso).AddDate(0, 0, 1-dias), agora)
+	if err != nil {
+		return nil, err
+	}
+
+	registros, err := s.registroRepo.BuscarPorPacienteEPeriodo(pacienteID, inicio, agora)
+	if err != nil {
+		return nil, err
+	}
+	series, err := s.seriesVisiveis(registros, compartilhaHumor, compartilhaSono)
+	if err != nil {
+		return nil, err
+	}
+
+	analise := &dtos.AnaliseAgregadaDTOOut{
+		PacienteID:               pacienteID,
+		Inicio:                   inicio,
+		Fim:                      agora,
+		Fuso:                     descreverFuso(fuso, agora),
+		Agrupamento:              agrupamento,
+		Agregacao:                agregacao,
+		Series:                   make([]dtos.SerieAgregadaDTOOut, 0, len(series)),
+		CategoriasCompartilhadas: categoriasCompartilhadas(consentimento, compartilhaHumor, compartilhaSono),
+	}
+	for _, serie := range series {
+		medias := serie.Diaria(fuso)
+		analise.Series = append(analise.Series, dtos.SerieAgregadaDTOOut{
+			Metrica:       serie.Chave,
+			Rotulo:        serie.Rotulo,
+			MediaDiaria:   dominio.MediaDasMediasDiarias(medias),
+			Periodos:      mappers.PeriodosAgregadosParaDTOOut(dominio.AgregarSerie(serie, inicio, agora, agrupamento, agregacao, fuso)),
+			PerfilSemanal: mappers.PerfilSemanalParaDTOOut(dominio.PerfilSemanal(medias)),
+		})
+	}
+	return analise, nil
+}
+
+// permissoesAnalise aplica o consentimento vigente do profissional: categorias visiveis e inicio do periodo
+// Sem consentimento, a consulta e do proprio paciente e ve tudo
+func permissoesAnalise(consentimento *dominio.Consentimento, inicio, agora time.Time) (bool, bool, time.Time, error) {
+	if consentimento == nil {
+		return true, true, inicio, nil
+	}
+	compartilhaHumor := consentimento.Permite(dominio.CategoriaHumor, agora)
+	compartilhaSono := consentimento.Permite(dominio.CategoriaSono, agora)
+	if !compartilhaHumor && !compartilhaSono {
+		return false, false, inicio, dominio.ErrConsentimentoNegado
+	}
+	if consentimento.DataInicio.After(inicio) {
+		inicio = consentimento.DataInicio
+	}
+	return compartilhaHumor, compartilhaSono, inicio, nil
+}
+
+// categoriasCompartilhadas lista as categorias visiveis ao profissional; vazio na consulta do proprio paciente
+func categoriasCompartilhadas(consentimento *dominio.Consentimento, compartilhaHumor, compartilhaSono bool) []string {
+	if consentimento == nil {
+		return nil
+	}
+	categorias := make([]string, 0, 2)
+	if compartilhaHumor {
+		categorias = append(categorias, dominio.CategoriaHumor)
+	}
+	if compartilhaSono {
+		categorias = append(categorias, dominio.CategoriaSono)
+	}
+	return categorias
+}
+
+// descreverFuso informa o fuso pelo nome IANA; o fuso local do servidor e descrito pela sigla e pelo deslocamento
+func descreverFuso(fuso *time.Location, em time.Time) string {
+	if fuso != time.Local {
+		return fuso.String()
+	}
+	sigla, deslocamento := em.In(fuso).Zone()
+	return fmt.Sprintf("%s (UTC%+03d:%02d)", sigla, deslocamento/3600, abs(deslocamento%3600)/60)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// seriesVisiveis monta as series das metricas fixas e personalizadas que a consulta pode ver
+// Sono depende da categoria sono; humor, energia, stress e as metricas personalizadas, da categoria humor
+func (s *analiseServico) seriesVisiveis(registros []*dominio.RegistroHumor, compartilhaHumor, compartilhaSono bool) ([]*dominio.SerieMetrica, error) {
+	series := make([]*dominio.SerieMetrica, 0)
+	for _, serie := range dominio.SeriesMetricasDosRegistros(registros) {
+		if serie.Chave == dominio.ChaveMetricaSono && compartilhaSono || serie.Chave != dominio.ChaveMetricaSono && compartilhaHumor {
+			series = append(series, serie)
+		}
+	}
+	if !compartilhaHumor || len(registros) == 0 {
+		return series, nil
+	}
+	personalizadas, err := s.seriesMetricasPersonalizadas(registros)
+	if err != nil {
+		return nil, err
+	}
+	return append(series, personalizadas...), nil
+}
+
+// seriesMetricasPersonalizadas monta uma serie por metrica personalizada com valores nos registros, ordenadas pelo ID
+func (s *analiseServico) seriesMetricasPersonalizadas(registros []*dominio.RegistroHumor) ([]*dominio.SerieMetrica, error) {
+	registroIDs := make([]uint, 0, len(registros))
+	dataDoRegistro := make(map[uint]time.Time, len(registros))
+	for _, reg := range registros {
+		registroIDs = append(registroIDs, reg.ID)
+		dataDoRegistro[reg.ID] = reg.DataHoraRegistro
+	}
+	valores, err := s.metricaRepo.ListarValoresDosRegistros(s.db, registroIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	porMetrica := make(map[uint]*dominio.SerieMetrica)
+	metricaIDs := make([]uint, 0)
+	for _, valor := range valores {
+		serie, ok := porMetrica[valor.MetricaID]
+		if !ok {
+			serie = &dominio.SerieMetrica{Chave: fmt.Sprintf("metrica_%d", valor.MetricaID)}
+			if valor.Metrica != nil {
+				serie.Rotulo = valor.Metrica.Rotulo
+			}
+			porMetrica[valor.MetricaID] = serie
+			metricaIDs = append(metricaIDs, valor.MetricaID)
+		}
+		serie.Pontos = append(serie.Pontos, dominio.PontoMetrica{Instante: dataDoRegistro[valor.RegistroHumorID], Valor: valor.Valor})
+	}
+
+	sort.Slice(metricaIDs, func(i, j int) bool { return metricaIDs[i] < metricaIDs[j] })
+	series := make([]*dominio.SerieMetrica, 0, len(metricaIDs))
+	for _, id := range metricaIDs {
+		series = append(series, porMetrica[id])
+	}
+	return series, nil
+}
+
+// resolverAcesso identifica o paciente da analise e confirma que o usuario pode ve-lo
+// O paciente so ve os proprios dados e o responsavel, os do dependente com delegacao ativa;
+// o profissional precisa de vinculo ativo e recebe tambem o consentimento vigente
+func (s *analiseServico) resolverAcesso(usuarioID, pacienteID uint, tipoUsuario string) (uint, *dominio.Consentimento, error) {
+	switch dominio.StringParaTipoUsuario(tipoUsuario) {
+	case dominio.TipoUsuarioPaciente:
+		pacienteInfo, err := s.usuarioRepo.BuscarPacientePorUsuarioID(s.db, usuarioID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, nil, dominio.ErrUsuarioNaoEncontrado
+			}
+			return 0, nil, err
+		}
+		if pacienteID != 0 && pacienteID != pacienteInfo.ID {
+			return 0, nil, dominio.ErrConsentimentoNegado
+		}
+		return pacienteInfo.ID, nil, nil
+
+	case dominio.TipoUsuarioResponsavel:
+		responsavel, err := s.responsavelRepo.BuscarResponsavelPorUsuarioID(s.db, usuarioID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, nil, dominio.ErrUsuarioNaoEncontrado
+			}
+			return 0, nil, err
+		}
+		delegacao, err := s.responsavelRepo.BuscarVinculoResponsavel(s.db, responsavel.ID, pacienteID)
+		if err != nil || !delegacao.Ativo() {
+			if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, nil, dominio.ErrVinculoNaoEncontrado
+			}
+			return 0, nil, err
+		}
+		return pacienteID, nil, nil
+
+	case dominio.TipoUsuarioProfissional:
+		profissional, err := s.usuarioRepo.BuscarProfissionalPorUsuarioID(s.db, usuarioID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, nil, dominio.ErrUsuarioNaoEncontrado
+			}
+			return 0, nil, err
+		}
+		// Vinculos encerrados continuam na tabela, entao o vinculo precisa estar ativo
+		vinculo, err := s.vinculoRepo.BuscarVinculo(s.db, pacienteID, profissional.ID)
+		if err != nil || !vinculo.Ativo() {
+			if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, nil, dominio.ErrVinculoNaoEncontrado
+			}
+			return 0, nil, err
+		}
+		consentimento, err := buscarConsentimentoAtual(s.db, s.consentimentoRepo, pacienteID, profissional.ID)
+		if err != nil {
+			return 0, nil, err
+		}
+		return pacienteID, consentimento, nil
+	}
+
+	return 0, nil, dominio.ErrVinculoNaoEncontrado
+}
+
+// graficosMetricas monta uma serie por metrica personalizada, na ordem dos registros
+// Metricas desativadas continuam com os valores do periodo
+func graficosMetricas(registros []*dominio.RegistroHumor, valores []*dominio.ValorMetricaRegistro) []dtos.GraficoMetricaDTOOut {
+	valoresPorRegistro := make(map[uint][]*dominio.ValorMetricaRegistro)
+	for _, valor := range valores {
+		valoresPorRegistro[valor.RegistroHumorID] = append(valoresPorRegistro[valor.RegistroHumorID], valor)
+	}
+
+	graficos := make([]dtos.GraficoMetricaDTOOut, 0)
+	posicao := make(map[uint]int)
+	for _, reg := range registros {
+		for _, valor := range valoresPorRegistro[reg.ID] {
+			i, ok := posicao[valor.MetricaID]
+			if !ok {
+				i = len(graficos)
+				posicao[valor.MetricaID] = i
+				graficos = append(graficos, dtos.GraficoMetricaDTOOut{MetricaID: valor.MetricaID, Pontos: make([]dtos.PontoMetricaDTOOut, 0)})
+				if valor.Metrica != nil {
+					graficos[i].Rotulo, graficos[i].Tipo = valor.Metrica.Rotulo, valor.Metrica.Tipo
+					graficos[i].Minimo, graficos[i].Maximo = valor.Metrica.Minimo, valor.Metrica.Maximo
+				}
+			}
+			graficos[i].Pontos = append(graficos[i].Pontos, dtos.PontoMetricaDTOOut{Data: reg.DataHoraRegistro, Valor: valor.Valor, Humor: reg.NivelHumor})
+			graficos[i].Media += valor.Valor
+		}
+	}
+	for i := range graficos {
+		graficos[i].Media /= float64(len(graficos[i].Pontos))
+	}
+	sort.Slice(graficos, func(i, j int) bool { return graficos[i].MetricaID < graficos[j].MetricaID })
+	return graficos
+}
+
 // ExecutarMonitoramento é o método "Trigger"
 func (s *analiseServico) ExecutarMonitoramento(pacienteID uint) error {
 	// 1. Busca os últimos X registros (ex: 7 dias ou 5 registros)
@@ -126,13 +674,15 @@ func (s *analiseServico) ExecutarMonitoramento(pacienteID uint) error {
 	mediaEnergia := float64(somaEnergia) / float64(len(registros))
 
 	// 3. Verifica Padrão
-	status := s.calcularStatus(mediaSono, mediaHumor, mediaStress, mediaEnergia) // Simplificado para exemplo
+	status := calcularStatus(mediaSono, mediaHumor, mediaStress, mediaEnergia) // Simplificado para exemplo
 
 	if status == StatusPreocupante {
 		// TODO: PERSISTE O ALERTA
 		// s.alertaRepo.Criar(dominio.Alerta{PacienteID: pacienteID, Tipo: status, Mensagem: "Padrão preocupante detectado"})
 
-		// TODO: ENVIA EMAIL/NOTIFICAÇÃO
+		if err := s.notificarProfissionais(pacienteID); err != nil {
+			return err
+		}
 	}
 	log.Printf(
 		"Monitoramento realizado as: %v\nPaciente ID: %d\nDados:\n mediaHumor: %.2f, mediaStress: %.2f, mediaSono: %.2f, mediaEnergia: %.2f\nStatus: %s",
@@ -140,7 +690,39 @@ func (s *analiseServico) ExecutarMonitoramento(pacienteID uint) error {
 	return nil
 }
 
-func (s *analiseServico) calcularStatus(sono, humor, stress, energia float64) string {
+// notificarProfissionais avisa apenas os profissionais com vinculo ativo que recebem o humor do paciente
+// Profissionais que deram alta, ou com quem o paciente encerrou o vinculo, nao recebem alertas
+// Os responsaveis do paciente dependente tambem sao avisados
+func (s *analiseServico) notificarProfissionais(pacienteID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		profissionais, err := s.vinculoRepo.ListarProfissionaisAtivosDoPaciente(tx, pacienteID)
+		if err != nil {
+			return err
+		}
+		agora := time.Now()
+		for _, profissional := range profissionais {
+			consentimento, err := s.consentimentoRepo.BuscarConsentimentoAtual(tx, pacienteID, profissional.ID)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					continue
+				}
+				return err
+			}
+			if !consentimento.Permite(dominio.CategoriaHumor, agora) {
+				continue
+			}
+			conteudo := "Os registros recentes de um paciente indicam um padrao preocupante. Confira a lista de pacientes."
+			if err := criarNotificacao(tx, s.notificacaoRepo, profissional.UsuarioID, conteudo); err != nil {
+				return err
+			}
+		}
+		return notificarResponsaveis(tx, s.responsavelRepo, s.notificacaoRepo, pacienteID,
+			"Os registros recentes de %s indicam um padrao preocupante. Converse com o profissional que acompanha o tratamento.")
+	})
+}
+
+// calcularStatus classifica as medias do periodo; usado na analise, no monitoramento e no painel do profissional
+func calcularStatus(sono, humor, stress, energia float64) string {
 	if humor < 2.5 || stress > 8.0 || (sono < 4.0 || sono > 11.0) || energia < 2.5 {
 		return StatusPreocupante
 	}
@@ -0,0 +1,123 @@
+package servicos
+
+import (
+	"fmt"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/mappers"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// limiteListagemAuditoria limita os acessos devolvidos ao paciente de uma vez
+const limiteListagemAuditoria = 200
+
+// loteVerificacaoAuditoria e a quantidade de registros lidos por vez na verificacao da cadeia
+const loteVerificacaoAuditoria = 1000
+
+// AuditoriaServico grava e consulta a trilha de acessos aos dados de pacientes
+type AuditoriaServico interface {
+	// Registrar encadeia e grava os registros na ordem recebida
+	Registrar(registros ...*dominio.RegistroAuditoria) error
+	// ListarAcessosDoPaciente mostra ao paciente quem acessou seus dados
+	ListarAcessosDoPaciente(userID uint, de, ate *time.Time) ([]*dtos.AcessoDadosDTOOut, error)
+	// VerificarCadeia recalcula os hashes de toda a trilha e retorna quantos registros foram conferidos
+	VerificarCadeia() (int, error)
+}
+
+type auditoriaServico struct {
+	db            *gorm.DB
+	usuarioRepo   repositorios.UsuarioRepositorio
+	auditoriaRepo repositorios.AuditoriaRepositorio
+	// mu evita que requisicoes da mesma instancia disputem a trava da cadeia no banco
+	mu sync.Mutex
+}
+
+func NovoAuditoriaServico(db *gorm.DB, ur repositorios.UsuarioRepositorio, ar repositorios.AuditoriaRepositorio) AuditoriaServico {
+	return &auditoriaServico{
+		db:            db,
+		usuarioRepo:   ur,
+		auditoriaRepo: ar,
+	}
+}
+
+func (s *auditoriaServico) Registrar(registros ...*dominio.RegistroAuditoria) error {
+	if len(registros) == 0 {
+		return nil
+	}
+	for _, r := range registros {
+		if err := r.Validar(); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.auditoriaRepo.TravarCadeia(tx); err != nil {
+			return err
+		}
+		ultimo, err := s.auditoriaRepo.BuscarUltimoRegistro(tx)
+		if err != nil {
+			return err
+		}
+		hashAnterior := ""
+		if ultimo != nil {
+			hashAnterior = ultimo.Hash
+		}
+
+		agora := time.Now()
+		for _, r := range registros {
+			r.Encadear(hashAnterior, agora)
+			if err := s.auditoriaRepo.CriarRegistro(tx, r); err != nil {
+				return err
+			}
+			hashAnterior = r.Hash
+		}
+		return nil
+	})
+}
+
+// ListarAcessosDoPaciente omite os acessos do proprio paciente e nao expoe IP nem user-agent
+// O dia informado em ate entra no periodo
+func (s *auditoriaServico) ListarAcessosDoPaciente(userID uint, de, ate *time.Time) ([]*dtos.AcessoDadosDTOOut, error) {
+	paciente, err := buscarPacienteDoUsuario(s.db, s.usuarioRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	if ate != nil {
+		fim := ate.AddDate(0, 0, 1)
+		ate = &fim
+	}
+
+	registros, err := s.auditoriaRepo.ListarAcessosDoPaciente(s.db, paciente.ID, userID, de, ate, limiteListagemAuditoria)
+	if err != nil {
+		return nil, err
+	}
+	return mappers.RegistrosAuditoriaParaAcessosDTOOut(registros), nil
+}
+
+func (s *auditoriaServico) VerificarCadeia() (int, error) {
+	var ultimoID uint
+	hashAnterior := ""
+	verificados := 0
+	for {
+		registros, err := s.auditoriaRepo.ListarRegistrosAposID(s.db, ultimoID, loteVerificacaoAuditoria)
+		if err != nil {
+			return verificados, err
+		}
+		if len(registros) == 0 {
+			return verificados, nil
+		}
+		if adulterado := dominio.VerificarCadeia(hashAnterior, registros); adulterado != nil {
+			return verificados, fmt.Errorf("%w no registro %d", dominio.ErrCadeiaAuditoriaViolada, adulterado.ID)
+		}
+		verificados += len(registros)
+		ultimo := registros[len(registros)-1]
+		ultimoID, hashAnterior = ultimo.ID, ultimo.Hash
+	}
+}
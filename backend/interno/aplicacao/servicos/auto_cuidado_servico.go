@@ -0,0 +1,226 @@
+package servicos
+
+import (
+	"errors"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/mappers"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Periodo da correlacao de auto cuidado, em dias
+const (
+	diasPadraoCorrelacaoAutoCuidado = 90
+	diasMaximoCorrelacaoAutoCuidado = 365
+)
+
+// AutoCuidadoServico gerencia o catalogo de atividades de auto cuidado e a correlacao com o humor
+type AutoCuidadoServico interface {
+	// ListarAtividades retorna o catalogo visivel ao usuario: o do sistema e, para o paciente,
+	// o dos profissionais vinculados; para o profissional, o proprio
+	ListarAtividades(userID uint, tipoUsuario string) ([]*dtos.AtividadeAutoCuidadoDTOOut, error)
+	CriarAtividade(userID uint, dto *dtos.CriarAtividadeAutoCuidadoDTOIn) (*dtos.AtividadeAutoCuidadoDTOOut, error)
+	// DesativarAtividade retira uma atividade do profissional do catalogo; os registros que a citam nao mudam
+	DesativarAtividade(userID, atividadeID uint) error
+	// GerarCorrelacao compara, para cada atividade, o humor do dia seguinte aos dias com e sem ela
+	GerarCorrelacao(userID uint, tipoUsuario string, pacienteID uint, dias int) (*dtos.CorrelacaoAutoCuidadoDTOOut, error)
+}
+
+type autoCuidadoServico struct {
+	db                *gorm.DB
+	usuarioRepo       repositorios.UsuarioRepositorio
+	vinculoRepo       repositorios.VinculoRepositorio
+	consentimentoRepo repositorios.ConsentimentoRepositorio
+	autoCuidadoRepo   repositorios.AutoCuidadoRepositorio
+	registroRepo      repositorios.RegistroHumorRepositorio
+}
+
+func NovoAutoCuidadoServico(db *gorm.DB, ur repositorios.UsuarioRepositorio, vr repositorios.VinculoRepositorio, cr repositorios.ConsentimentoRepositorio, acr repositorios.AutoCuidadoRepositorio, rhr repositorios.RegistroHumorRepositorio) AutoCuidadoServico {
+	return &autoCuidadoServico{
+		db:                db,
+		usuarioRepo:       ur,
+		vinculoRepo:       vr,
+		consentimentoRepo: cr,
+		autoCuidadoRepo:   acr,
+		registroRepo:      rhr,
+	}
+}
+
+func (s *autoCuidadoServico) ListarAtividades(userID uint, tipoUsuario string) ([]*dtos.AtividadeAutoCuidadoDTOOut, error) {
+	var atividades []*dominio.AtividadeAutoCuidado
+	if dominio.StringParaTipoUsuario(tipoUsuario) == dominio.TipoUsuarioPaciente {
+		paciente, err := buscarPacienteDoUsuario(s.db, s.usuarioRepo, userID)
+		if err != nil {
+			return nil, err
+		}
+		if atividades, err = s.autoCuidadoRepo.ListarCatalogoDoPaciente(s.db, paciente.ID); err != nil {
+			return nil, err
+		}
+	} else {
+		profissional, err := s.buscarProfissional(userID)
+		if err != nil {
+			return nil, err
+		}
+		if atividades, err = s.autoCuidadoRepo.ListarCatalogoDoProfissional(s.db, profissional.ID); err != nil {
+			return nil, err
+		}
+	}
+	return mappers.AtividadesAutoCuidadoParaDTOOut(atividades), nil
+}
+
+// CriarAtividade inclui uma atividade no catalogo do profissional
+// Um nome ja presente no catalogo do sistema ou do profissional e recusado; uma atividade
+// desativada com o mesmo nome volta ao catalogo
+func (s *autoCuidadoServico) CriarAtividade(userID uint, dto *dtos.CriarAtividadeAutoCuidadoDTOIn) (*dtos.AtividadeAutoCuidadoDTOOut, error) {
+	var atividade *dominio.AtividadeAutoCuidado
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		profissional, err := s.buscarProfissional(userID)
+		if err != nil {
+			return err
+		}
+		nova, err := dominio.NovaAtividadeAutoCuidado(profissional.ID, dto.Nome)
+		if err != nil {
+			return err
+		}
+
+		catalogo, err := s.autoCuidadoRepo.ListarCatalogoDoProfissional(tx, profissional.ID)
+		if err != nil {
+			return err
+		}
+		for _, existente := range catalogo {
+			if existente.NomeNormalizado == nova.NomeNormalizado {
+				return dominio.ErrAtividadeAutoCuidadoDuplicada
+			}
+		}
+
+		desativada, err := s.autoCuidadoRepo.BuscarAtividadeDoProfissionalPorNome(tx, profissional.ID, nova.NomeNormalizado)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if desativada != nil {
+			desativada.Nome, desativada.Ativa = nova.Nome, true
+			atividade = desativada
+			return s.autoCuidadoRepo.AtualizarAtividade(tx, atividade)
+		}
+		atividade = nova
+		return s.autoCuidadoRepo.CriarAtividade(tx, atividade)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.AtividadeAutoCuidadoParaDTOOut(atividade), nil
+}
+
+func (s *autoCuidadoServico) DesativarAtividade(userID, atividadeID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		profissional, err := s.buscarProfissional(userID)
+		if err != nil {
+			return err
+		}
+		atividade, err := s.autoCuidadoRepo.BuscarAtividadePorID(tx, atividadeID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrAtividadeAutoCuidadoNaoEncontrada
+			}
+			return err
+		}
+		// Atividades do sistema e de outros profissionais respondem como inexistentes
+		if atividade.ProfissionalID == nil || *atividade.ProfissionalID != profissional.ID || !atividade.Ativa {
+			return dominio.ErrAtividadeAutoCuidadoNaoEncontrada
+		}
+		atividade.Ativa = false
+		return s.autoCuidadoRepo.AtualizarAtividade(tx, atividade)
+	})
+}
+
+// GerarCorrelacao usa os registros do periodo; o profissional precisa de vinculo ativo e do
+// consentimento da categoria humor, que cobre o auto cuidado, e ve apenas o periodo consentido
+func (s *autoCuidadoServico) GerarCorrelacao(userID uint, tipoUsuario string, pacienteID uint, dias int) (*dtos.CorrelacaoAutoCuidadoDTOOut, error) {
+	if dias <= 0 {
+		dias = diasPadraoCorrelacaoAutoCuidado
+	}
+	if dias > diasMaximoCorrelacaoAutoCuidado {
+		return nil, dominio.ErrPeriodoCorrelacaoInvalido
+	}
+
+	agora := time.Now()
+	inicio := agora.AddDate(0, 0, -dias)
+	if dominio.StringParaTipoUsuario(tipoUsuario) == dominio.TipoUsuarioPaciente {
+		paciente, err := buscarPacienteDoUsuario(s.db, s.usuarioRepo, userID)
+		if err != nil {
+			return nil, err
+		}
+		pacienteID = paciente.ID
+	} else {
+		profissional, err := s.buscarProfissional(userID)
+		if err != nil {
+			return nil, err
+		}
+		vinculo, err := s.vinculoRepo.BuscarVinculo(s.db, pacienteID, profissional.ID)
+		if err != nil || !vinculo.Ativo() {
+			if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, dominio.ErrVinculoNaoEncontrado
+			}
+			return nil, err
+		}
+		consentimento, err := buscarConsentimentoAtual(s.db, s.consentimentoRepo, pacienteID, profissional.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !consentimento.Permite(dominio.CategoriaHumor, agora) {
+			return nil, dominio.ErrConsentimentoNegado
+		}
+		if consentimento.DataInicio.After(inicio) {
+			inicio = consentimento.DataInicio
+		}
+	}
+
+	registros, err := s.registroRepo.BuscarPorPacienteEPeriodo(pacienteID, inicio, agora)
+	if err != nil {
+		return nil, err
+	}
+	registroIDs := make([]uint, 0, len(registros))
+	for _, registro := range registros {
+		registroIDs = append(registroIDs, registro.ID)
+	}
+	vinculos, err := s.autoCuidadoRepo.ListarAtividadesDosRegistros(s.db, registroIDs)
+	if err != nil {
+		return nil, err
+	}
+	correlacoes := dominio.CorrelacionarAutoCuidado(registros, vinculos, time.Local)
+
+	// Atividades desativadas ou de profissionais sem vinculo continuam nomeadas no resultado
+	atividadeIDs := make([]uint, 0, len(correlacoes))
+	for _, c := range correlacoes {
+		atividadeIDs = append(atividadeIDs, c.AtividadeID)
+	}
+	atividades, err := s.autoCuidadoRepo.BuscarAtividadesPorIDs(s.db, atividadeIDs)
+	if err != nil {
+		return nil, err
+	}
+	porID := make(map[uint]*dominio.AtividadeAutoCuidado, len(atividades))
+	for _, atividade := range atividades {
+		porID[atividade.ID] = atividade
+	}
+
+	return &dtos.CorrelacaoAutoCuidadoDTOOut{
+		PacienteID:    pacienteID,
+		Dias:          dias,
+		AmostraMinima: dominio.AmostraMinimaCorrelacaoAutoCuidado,
+		Atividades:    mappers.CorrelacoesAutoCuidadoParaDTOOut(correlacoes, porID),
+	}, nil
+}
+
+func (s *autoCuidadoServico) buscarProfissional(userID uint) (*dominio.Profissional, error) {
+	profissional, err := s.usuarioRepo.BuscarProfissionalPorUsuarioID(s.db, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrUsuarioNaoEncontrado
+		}
+		return nil, err
+	}
+	return profissional, nil
+}
@@ -0,0 +1,312 @@
+package servicos
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algoritmos de assinatura suportados
+const (
+	AlgoritmoJWTHS256 = "HS256"
+	AlgoritmoJWTRS256 = "RS256"
+	AlgoritmoJWTEdDSA = "EdDSA"
+
+	// Arquivos <kid>.pem guardam a chave privada; <kid>.pub.pem apenas a publica (chave aposentada)
+	ExtensaoChavePrivadaJWT = ".pem"
+	ExtensaoChavePublicaJWT = ".pub.pem"
+
+	tamanhoChaveRSA = 3072
+)
+
+var (
+	ErrChaveJWTDesconhecida    = errors.New("chave de assinatura desconhecida")
+	ErrAlgoritmoJWTInvalido    = errors.New("algoritmo de assinatura nao suportado")
+	ErrChaveJWTAtivaAusente    = errors.New("chave ativa de assinatura nao encontrada")
+	ErrChaveJWTAtivaAmbigua    = errors.New("mais de uma chave privada disponivel, defina JWT_KID_ATIVO")
+	ErrSegredoJWTAusente       = errors.New("JWT_SECRET nao definido")
+	ErrFormatoChaveJWTInvalido = errors.New("formato de chave nao suportado")
+)
+
+// ChavesJWT assina e valida os tokens de sessao
+// Com chaves assimetricas, outros servicos validam os tokens pelo JWKS sem conhecer segredo algum
+type ChavesJWT interface {
+	Assinar(claims jwt.MapClaims) (string, error)
+	Validar(tokenString string) (jwt.MapClaims, error)
+	JWKS() *dtos.JWKSDTOOut
+}
+
+// chaveVerificacaoJWT e uma chave publica aceita na validacao, identificada pelo kid
+type chaveVerificacaoJWT struct {
+	kid     string
+	alg     string
+	publica crypto.PublicKey
+}
+
+// conjuntoChavesJWT implementa a interface ChavesJWT
+type conjuntoChavesJWT struct {
+	kidAtivo     string
+	algAtivo     string
+	privadaAtiva crypto.Signer
+	verificacao  map[string]chaveVerificacaoJWT
+	// segredoHMAC mantem aceitos os tokens HS256 emitidos antes da migracao
+	segredoHMAC []byte
+}
+
+// NovoChavesJWTHMAC cria um conjunto que assina e valida apenas com HS256 (modo legado)
+func NovoChavesJWTHMAC(segredo []byte) ChavesJWT {
+	return &conjuntoChavesJWT{algAtivo: AlgoritmoJWTHS256, segredoHMAC: segredo, verificacao: map[string]chaveVerificacaoJWT{}}
+}
+
+// NovoChavesJWTDoAmbiente configura as chaves a partir das variaveis de ambiente
+// Sem JWT_CHAVES_DIR o comportamento legado (HS256 com JWT_SECRET) e mantido
+// Com JWT_CHAVES_DIR, assina com JWT_KID_ATIVO e, se JWT_SECRET existir, ainda aceita tokens HS256 antigos
+func NovoChavesJWTDoAmbiente() (ChavesJWT, error) {
+	segredo := os.Getenv("JWT_SECRET")
+	dir := strings.TrimSpace(os.Getenv("JWT_CHAVES_DIR"))
+	if dir == "" {
+		if segredo == "" {
+			return nil, ErrSegredoJWTAusente
+		}
+		return NovoChavesJWTHMAC([]byte(segredo)), nil
+	}
+
+	var segredoLegado []byte
+	if segredo != "" {
+		segredoLegado = []byte(segredo)
+	}
+	return CarregarChavesJWT(dir, strings.TrimSpace(os.Getenv("JWT_KID_ATIVO")), segredoLegado)
+}
+
+// CarregarChavesJWT le as chaves PEM do diretorio; todas sao publicadas e aceitas na validacao
+// kidAtivo pode ser vazio quando houver exatamente uma chave privada
+func CarregarChavesJWT(dir, kidAtivo string, segredoLegado []byte) (ChavesJWT, error) {
+	arquivos, err := filepath.Glob(filepath.Join(dir, "*"+ExtensaoChavePrivadaJWT))
+	if err != nil {
+		return nil, err
+	}
+
+	conjunto := &conjuntoChavesJWT{verificacao: map[string]chaveVerificacaoJWT{}, segredoHMAC: segredoLegado}
+	privadas := map[string]crypto.Signer{}
+
+	for _, arquivo := range arquivos {
+		nome := filepath.Base(arquivo)
+		conteudo, err := os.ReadFile(arquivo)
+		if err != nil {
+			return nil, err
+		}
+
+		var kid string
+		var publica crypto.PublicKey
+		if strings.HasSuffix(nome, ExtensaoChavePublicaJWT) {
+			kid = strings.TrimSuffix(nome, ExtensaoChavePublicaJWT)
+			publica, err = decodificarChavePublicaPEM(conteudo)
+		} else {
+			kid = strings.TrimSuffix(nome, ExtensaoChavePrivadaJWT)
+			var privada crypto.Signer
+			privada, err = decodificarChavePrivadaPEM(conteudo)
+			if err == nil {
+				privadas[kid] = privada
+				publica = privada.Public()
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("chave %s: %w", nome, err)
+		}
+
+		alg, err := algoritmoDaChave(publica)
+		if err != nil {
+			return nil, fmt.Errorf("chave %s: %w", nome, err)
+		}
+		conjunto.verificacao[kid] = chaveVerificacaoJWT{kid: kid, alg: alg, publica: publica}
+	}
+
+	if kidAtivo == "" {
+		if len(privadas) > 1 {
+			return nil, ErrChaveJWTAtivaAmbigua
+		}
+		for kid := range privadas {
+			kidAtivo = kid
+		}
+	}
+	privada, ok := privadas[kidAtivo]
+	if !ok {
+		return nil, ErrChaveJWTAtivaAusente
+	}
+
+	conjunto.kidAtivo = kidAtivo
+	conjunto.algAtivo = conjunto.verificacao[kidAtivo].alg
+	conjunto.privadaAtiva = privada
+	return conjunto, nil
+}
+
+// Assinar emite o token com a chave ativa, informando o kid no cabecalho
+func (c *conjuntoChavesJWT) Assinar(claims jwt.MapClaims) (string, error) {
+	if c.privadaAtiva == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(c.segredoHMAC)
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(c.algAtivo), claims)
+	token.Header["kid"] = c.kidAtivo
+	return token.SignedString(c.privadaAtiva)
+}
+
+// Validar verifica assinatura e validade do token usando a chave indicada pelo kid
+// O algoritmo e sempre o da chave cadastrada, nunca o declarado pelo token
+func (c *conjuntoChavesJWT) Validar(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			if c.segredoHMAC == nil || token.Method.Alg() != AlgoritmoJWTHS256 {
+				return nil, ErrChaveJWTDesconhecida
+			}
+			return c.segredoHMAC, nil
+		}
+
+		chave, ok := c.verificacao[kid]
+		if !ok {
+			return nil, ErrChaveJWTDesconhecida
+		}
+		if token.Method.Alg() != chave.alg {
+			return nil, fmt.Errorf("método de assinatura inesperado: %v", token.Header["alg"])
+		}
+		return chave.publica, nil
+	}, jwt.WithValidMethods([]string{AlgoritmoJWTHS256, AlgoritmoJWTRS256, AlgoritmoJWTEdDSA}))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
+// JWKS publica as chaves publicas de verificacao; segredos HMAC nunca sao expostos
+func (c *conjuntoChavesJWT) JWKS() *dtos.JWKSDTOOut {
+	kids := make([]string, 0, len(c.verificacao))
+	for kid := range c.verificacao {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := &dtos.JWKSDTOOut{Keys: []dtos.JWKDTOOut{}}
+	for _, kid := range kids {
+		chave := c.verificacao[kid]
+		jwk := dtos.JWKDTOOut{Kid: kid, Use: "sig", Alg: chave.alg}
+		switch publica := chave.publica.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publica.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publica.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publica)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+// GerarChaveJWT cria um novo par de chaves e retorna o kid sugerido e a chave privada em PEM (PKCS8)
+func GerarChaveJWT(alg string) (string, []byte, error) {
+	var privada crypto.Signer
+	var err error
+	switch alg {
+	case AlgoritmoJWTRS256:
+		privada, err = rsa.GenerateKey(rand.Reader, tamanhoChaveRSA)
+	case AlgoritmoJWTEdDSA:
+		_, privada, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return "", nil, ErrAlgoritmoJWTInvalido
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privada)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sufixo, err := gerarTokenAleatorio(4)
+	if err != nil {
+		return "", nil, err
+	}
+	kid := time.Now().Format("20060102") + "-" + sufixo
+
+	return kid, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// ExtrairChavePublicaPEM converte uma chave privada PEM na chave publica correspondente (PKIX)
+// Usado ao aposentar uma chave: ela deixa de assinar mas continua validando tokens ja emitidos
+func ExtrairChavePublicaPEM(privadaPEM []byte) ([]byte, error) {
+	privada, err := decodificarChavePrivadaPEM(privadaPEM)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(privada.Public())
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+func decodificarChavePrivadaPEM(conteudo []byte) (crypto.Signer, error) {
+	bloco, _ := pem.Decode(conteudo)
+	if bloco == nil {
+		return nil, ErrFormatoChaveJWTInvalido
+	}
+
+	switch bloco.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(bloco.Bytes)
+	case "PRIVATE KEY":
+		chave, err := x509.ParsePKCS8PrivateKey(bloco.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch privada := chave.(type) {
+		case *rsa.PrivateKey:
+			return privada, nil
+		case ed25519.PrivateKey:
+			return privada, nil
+		}
+	}
+	return nil, ErrFormatoChaveJWTInvalido
+}
+
+func decodificarChavePublicaPEM(conteudo []byte) (crypto.PublicKey, error) {
+	bloco, _ := pem.Decode(conteudo)
+	if bloco == nil || bloco.Type != "PUBLIC KEY" {
+		return nil, ErrFormatoChaveJWTInvalido
+	}
+	return x509.ParsePKIXPublicKey(bloco.Bytes)
+}
+
+func algoritmoDaChave(publica crypto.PublicKey) (string, error) {
+	switch publica.(type) {
+	case *rsa.PublicKey:
+		return AlgoritmoJWTRS256, nil
+	case ed25519.PublicKey:
+		return AlgoritmoJWTEdDSA, nil
+	}
+	return "", ErrFormatoChaveJWTInvalido
+}
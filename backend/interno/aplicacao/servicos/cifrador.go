@@ -0,0 +1,9 @@
+package servicos
+
+// Cifrador protege dados sensiveis gravados no banco
+// dadosAssociados amarram o texto cifrado ao registro, impedindo que seja copiado para outra linha
+// A implementacao e o envelope de interno/persistencia/cifragem, o mesmo dos campos cifrados pelo GORM
+type Cifrador interface {
+	Cifrar(texto, dadosAssociados []byte) ([]byte, error)
+	Decifrar(cifrado, dadosAssociados []byte) ([]byte, error)
+}
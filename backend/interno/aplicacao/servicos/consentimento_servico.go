@@ -0,0 +1,234 @@
+package servicos
+
+import (
+	"errors"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/mappers"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ConsentimentoServico define os metodos de gestao do consentimento do paciente por profissional
+type ConsentimentoServico interface {
+	ListarConsentimentos(userID uint) ([]*dtos.ConsentimentoDTOOut, error)
+	AtualizarConsentimento(userID, profissionalID uint, dtoIn *dtos.AtualizarConsentimentoDTOIn) (*dtos.ConsentimentoDTOOut, error)
+	RevogarConsentimento(userID, profissionalID uint) (*dtos.ConsentimentoDTOOut, error)
+	ListarHistorico(userID, profissionalID uint) ([]*dtos.ConsentimentoDTOOut, error)
+	BuscarConsentimentoDoPaciente(userID, pacienteID uint) (*dtos.ConsentimentoDTOOut, error)
+	CriarConsentimentosLegados() error
+}
+
+// consentimentoServico implementa a interface ConsentimentoServico
+type consentimentoServico struct {
+	db                       *gorm.DB
+	usuarioRepositorio       repositorios.UsuarioRepositorio
+	consentimentoRepositorio repositorios.ConsentimentoRepositorio
+	responsavelRepositorio   repositorios.ResponsavelRepositorio
+}
+
+// NovoConsentimentoServico cria uma nova instancia de ConsentimentoServico
+func NovoConsentimentoServico(db *gorm.DB, ur repositorios.UsuarioRepositorio, cr repositorios.ConsentimentoRepositorio, rr repositorios.ResponsavelRepositorio) ConsentimentoServico {
+	return &consentimentoServico{
+		db:                       db,
+		usuarioRepositorio:       ur,
+		consentimentoRepositorio: cr,
+		responsavelRepositorio:   rr,
+	}
+}
+
+// ListarConsentimentos retorna a versao vigente do consentimento com cada profissional vinculado
+func (s *consentimentoServico) ListarConsentimentos(userID uint) ([]*dtos.ConsentimentoDTOOut, error) {
+	paciente, err := s.buscarPaciente(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	consentimentos, err := s.consentimentoRepositorio.ListarConsentimentosAtuaisDoPaciente(s.db, paciente.ID)
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ConsentimentosParaDTOOut(consentimentos, time.Now()), nil
+}
+
+// AtualizarConsentimento grava uma nova versao com as categorias e o periodo escolhidos pelo paciente
+// O paciente menor de idade com responsavel vinculado so pode restringir o compartilhamento
+func (s *consentimentoServico) AtualizarConsentimento(userID, profissionalID uint, dtoIn *dtos.AtualizarConsentimentoDTOIn) (*dtos.ConsentimentoDTOOut, error) {
+	paciente, err := s.buscarPaciente(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	agora := time.Now()
+	apenasRestringir := false
+	if paciente.MenorDeIdade(agora) {
+		responsaveis, err := s.responsavelRepositorio.ListarResponsaveisDoPaciente(s.db, paciente.ID)
+		if err != nil {
+			return nil, err
+		}
+		apenasRestringir = len(responsaveis) > 0
+	}
+
+	var novo *dominio.Consentimento
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		novo, err = gravarNovaVersaoConsentimento(tx, s.consentimentoRepositorio, paciente.ID, profissionalID, dtoIn, apenasRestringir, agora)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ConsentimentoParaDTOOut(novo, agora), nil
+}
+
+// RevogarConsentimento grava uma nova versao que interrompe todo o compartilhamento com o profissional
+// O vinculo continua; o paciente pode voltar a compartilhar com uma nova versao
+func (s *consentimentoServico) RevogarConsentimento(userID, profissionalID uint) (*dtos.ConsentimentoDTOOut, error) {
+	paciente, err := s.buscarPaciente(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var revogado *dominio.Consentimento
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		revogado, err = revogarConsentimentoDoPar(tx, s.consentimentoRepositorio, paciente.ID, profissionalID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ConsentimentoParaDTOOut(revogado, time.Now()), nil
+}
+
+// ListarHistorico retorna todas as versoes do consentimento com um profissional, da mais recente a mais antiga
+func (s *consentimentoServico) ListarHistorico(userID, profissionalID uint) ([]*dtos.ConsentimentoDTOOut, error) {
+	paciente, err := s.buscarPaciente(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	versoes, err := s.consentimentoRepositorio.ListarVersoesConsentimento(s.db, paciente.ID, profissionalID)
+	if err != nil {
+		return nil, err
+	}
+	if len(versoes) == 0 {
+		return nil, dominio.ErrConsentimentoNaoEncontrado
+	}
+	return mappers.ConsentimentosParaDTOOut(versoes, time.Now()), nil
+}
+
+// BuscarConsentimentoDoPaciente mostra ao profissional o que o paciente compartilha com ele
+func (s *consentimentoServico) BuscarConsentimentoDoPaciente(userID, pacienteID uint) (*dtos.ConsentimentoDTOOut, error) {
+	profissional, err := s.usuarioRepositorio.BuscarProfissionalPorUsuarioID(s.db, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrUsuarioNaoEncontrado
+		}
+		return nil, err
+	}
+
+	consentimento, err := buscarConsentimentoAtual(s.db, s.consentimentoRepositorio, pacienteID, profissional.ID)
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ConsentimentoParaDTOOut(consentimento, time.Now()), nil
+}
+
+// CriarConsentimentosLegados grava a versao inicial dos vinculos criados antes do consentimento
+func (s *consentimentoServico) CriarConsentimentosLegados() error {
+	_, err := s.consentimentoRepositorio.CriarConsentimentosLegados(s.db, time.Now())
+	return err
+}
+
+func (s *consentimentoServico) buscarPaciente(userID uint) (*dominio.Paciente, error) {
+	paciente, err := s.usuarioRepositorio.BuscarPacientePorUsuarioID(s.db, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrUsuarioNaoEncontrado
+		}
+		return nil, err
+	}
+	return paciente, nil
+}
+
+// buscarConsentimentoAtual retorna a versao vigente do par ou ErrVinculoNaoEncontrado
+func buscarConsentimentoAtual(tx *gorm.DB, repo repositorios.ConsentimentoRepositorio, pacienteID, profissionalID uint) (*dominio.Consentimento, error) {
+	consentimento, err := repo.BuscarConsentimentoAtual(tx, pacienteID, profissionalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrVinculoNaoEncontrado
+		}
+		return nil, err
+	}
+	return consentimento, nil
+}
+
+// gravarNovaVersaoConsentimento grava a proxima versao do consentimento do par
+// Sem data_inicio, a nova versao mantem a data da versao vigente
+// Com apenasRestringir, uma versao que amplia o compartilhamento e recusada
+func gravarNovaVersaoConsentimento(tx *gorm.DB, repo repositorios.ConsentimentoRepositorio, pacienteID, profissionalID uint, dtoIn *dtos.AtualizarConsentimentoDTOIn, apenasRestringir bool, agora time.Time) (*dominio.Consentimento, error) {
+	atual, err := buscarConsentimentoAtual(tx, repo, pacienteID, profissionalID)
+	if err != nil {
+		return nil, err
+	}
+
+	dataInicio := atual.DataInicio
+	if dtoIn.DataInicio != nil {
+		dataInicio = *dtoIn.DataInicio
+	}
+	novo, err := atual.NovaVersao(dtoIn.Categorias, dataInicio, dtoIn.DataExpiracao, agora)
+	if err != nil {
+		return nil, err
+	}
+	if apenasRestringir && atual.AmpliadoPor(novo, agora) {
+		return nil, dominio.ErrConsentimentoExigeResponsavel
+	}
+	if err := repo.CriarConsentimento(tx, novo); err != nil {
+		return nil, err
+	}
+	return novo, nil
+}
+
+// revogarConsentimentoDoPar grava a versao revogada do consentimento do par
+func revogarConsentimentoDoPar(tx *gorm.DB, repo repositorios.ConsentimentoRepositorio, pacienteID, profissionalID uint) (*dominio.Consentimento, error) {
+	atual, err := buscarConsentimentoAtual(tx, repo, pacienteID, profissionalID)
+	if err != nil {
+		return nil, err
+	}
+	revogado := atual.Revogar()
+	if err := repo.CriarConsentimento(tx, revogado); err != nil {
+		return nil, err
+	}
+	return revogado, nil
+}
+
+// verificarConsentimento confirma que o paciente compartilha a categoria com o profissional
+// Usado pelas consultas do profissional antes de ler dados do paciente
+func verificarConsentimento(tx *gorm.DB, repo repositorios.ConsentimentoRepositorio, pacienteID, profissionalID uint, categoria string) (*dominio.Consentimento, error) {
+	consentimento, err := buscarConsentimentoAtual(tx, repo, pacienteID, profissionalID)
+	if err != nil {
+		return nil, err
+	}
+	if !consentimento.Permite(categoria, time.Now()) {
+		return nil, dominio.ErrConsentimentoNegado
+	}
+	return consentimento, nil
+}
+
+// garantirConsentimentoDoVinculo grava a versao padrao ao criar o vinculo
+// Um consentimento ainda vigente de um vinculo anterior e mantido
+func garantirConsentimentoDoVinculo(tx *gorm.DB, repo repositorios.ConsentimentoRepositorio, pacienteID, profissionalID uint, agora time.Time) error {
+	padrao := dominio.NovoConsentimentoPadrao(pacienteID, profissionalID, agora)
+	atual, err := repo.BuscarConsentimentoAtual(tx, pacienteID, profissionalID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if atual != nil {
+		if atual.Vigente(agora) {
+			return nil
+		}
+		padrao.Versao = atual.Versao + 1
+	}
+	return repo.CriarConsentimento(tx, padrao)
+}
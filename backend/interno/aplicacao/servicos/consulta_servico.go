@@ -0,0 +1,461 @@
+package servicos
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/mappers"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// historicoCalendario e quanto do passado o feed .ics inclui
+const historicoCalendario = 30 * 24 * time.Hour
+
+// ConsultaServico define a agenda de consultas entre profissional e paciente
+type ConsultaServico interface {
+	AgendarConsulta(userID, pacienteID uint, dtoIn *dtos.ConsultaDTOIn) ([]*dtos.ConsultaDTOOut, error)
+	RemarcarConsulta(userID, consultaID uint, dtoIn *dtos.RemarcarConsultaDTOIn) (*dtos.ConsultaDTOOut, error)
+	AlterarStatusConsulta(userID, consultaID uint, dtoIn *dtos.AlterarStatusConsultaDTOIn) ([]*dtos.ConsultaDTOOut, error)
+	ListarConsultasProfissional(userID uint, de, ate *time.Time) ([]*dtos.ConsultaDTOOut, error)
+	ListarConsultasPaciente(userID uint, de, ate *time.Time) ([]*dtos.ConsultaDTOOut, error)
+	CancelarConsultaPaciente(userID, consultaID uint) (*dtos.ConsultaDTOOut, error)
+	GerarLinkCalendario(userID uint) (*dtos.CalendarioDTOOut, error)
+	CalendarioICS(token string) ([]byte, error)
+	// AtribuirQuestionariosPreSessao atribui o questionario das consultas que comecam nas proximas 24h
+	AtribuirQuestionariosPreSessao() error
+}
+
+// consultaServico implementa a interface ConsultaServico
+type consultaServico struct {
+	db                *gorm.DB
+	usuarioRepo       repositorios.UsuarioRepositorio
+	vinculoRepo       repositorios.VinculoRepositorio
+	consultaRepo      repositorios.ConsultaRepositorio
+	instrumentoRepo   repositorios.InstrumentoRepositorio
+	consentimentoRepo repositorios.ConsentimentoRepositorio
+	notificacaoRepo   repositorios.NotificacaoRepositorio
+	responsavelRepo   repositorios.ResponsavelRepositorio
+}
+
+// NovoConsultaServico cria uma nova instancia de ConsultaServico
+func NovoConsultaServico(db *gorm.DB, ur repositorios.UsuarioRepositorio, vr repositorios.VinculoRepositorio, cr repositorios.ConsultaRepositorio, ir repositorios.InstrumentoRepositorio, consentRepo repositorios.ConsentimentoRepositorio, nr repositorios.NotificacaoRepositorio, rr repositorios.ResponsavelRepositorio) ConsultaServico {
+	return &consultaServico{
+		db:                db,
+		usuarioRepo:       ur,
+		vinculoRepo:       vr,
+		consultaRepo:      cr,
+		instrumentoRepo:   ir,
+		consentimentoRepo: consentRepo,
+		notificacaoRepo:   nr,
+		responsavelRepo:   rr,
+	}
+}
+
+// AgendarConsulta agenda a consulta e suas repeticoes semanais
+// Se qualquer horario conflitar com a agenda do profissional, nada e gravado
+func (s *consultaServico) AgendarConsulta(userID, pacienteID uint, dtoIn *dtos.ConsultaDTOIn) ([]*dtos.ConsultaDTOOut, error) {
+	var consultas []*dominio.Consulta
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		profissional, err := s.buscarProfissional(tx, userID)
+		if err != nil {
+			return err
+		}
+		paciente, err := s.usuarioRepo.BuscarPacientePorID(tx, pacienteID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrUsuarioNaoEncontrado
+			}
+			return err
+		}
+		if !s.vinculoAtivo(tx, paciente.ID, profissional.ID) {
+			return dominio.ErrVinculoNaoEncontrado
+		}
+
+		if dtoIn.InstrumentoID != nil {
+			if _, err := s.instrumentoRepo.BuscarInstrumentoPorID(tx, *dtoIn.InstrumentoID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return dominio.ErrInstrumentoNaoEncontrado
+				}
+				return err
+			}
+			// O questionario pre-sessao segue o mesmo consentimento do envio manual
+			if _, err := verificarConsentimento(tx, s.consentimentoRepo, paciente.ID, profissional.ID, dominio.CategoriaQuestionarios); err != nil {
+				return err
+			}
+		}
+
+		repeticoes := dtoIn.RepeticoesSemanais
+		if repeticoes == 0 {
+			repeticoes = 1
+		}
+		consultas, err = dominio.NovasConsultasSemanais(profissional.ID, paciente.ID, dtoIn.Inicio, dtoIn.Fim,
+			dtoIn.Modalidade, dtoIn.Local, dtoIn.InstrumentoID, repeticoes, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, consulta := range consultas {
+			if err := s.verificarConflitos(tx, consulta); err != nil {
+				return err
+			}
+		}
+
+		// A primeira consulta da serie empresta o ID para agrupar as demais
+		for i, consulta := range consultas {
+			if i > 0 {
+				consulta.SerieID = consultas[0].SerieID
+			}
+			if err := s.consultaRepo.CriarConsulta(tx, consulta); err != nil {
+				return err
+			}
+			if i == 0 && len(consultas) > 1 {
+				consulta.SerieID = &consulta.ID
+				if err := s.consultaRepo.AtualizarConsulta(tx, consulta); err != nil {
+					return err
+				}
+			}
+			consulta.Profissional = *profissional
+			consulta.Paciente = *paciente
+		}
+
+		mensagem := fmt.Sprintf("%s agendou uma consulta para %s.", profissional.Usuario.Nome, consultas[0].Inicio.Format("02/01/2006 15:04"))
+		if len(consultas) > 1 {
+			mensagem = fmt.Sprintf("%s agendou %d consultas semanais a partir de %s.", profissional.Usuario.Nome, len(consultas), consultas[0].Inicio.Format("02/01/2006 15:04"))
+		}
+		return criarNotificacao(tx, s.notificacaoRepo, paciente.UsuarioID, mensagem)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ConsultasParaDTOOut(consultas), nil
+}
+
+// RemarcarConsulta move uma consulta agendada para outro horario livre
+func (s *consultaServico) RemarcarConsulta(userID, consultaID uint, dtoIn *dtos.RemarcarConsultaDTOIn) (*dtos.ConsultaDTOOut, error) {
+	var consulta *dominio.Consulta
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		profissional, err := s.buscarProfissional(tx, userID)
+		if err != nil {
+			return err
+		}
+		if consulta, err = s.buscarConsultaDoProfissional(tx, profissional.ID, consultaID); err != nil {
+			return err
+		}
+		anterior := consulta.Inicio
+
+		if err := consulta.Remarcar(dtoIn.Inicio, dtoIn.Fim, time.Now()); err != nil {
+			return err
+		}
+		if err := s.verificarConflitos(tx, consulta); err != nil {
+			return err
+		}
+		if err := s.consultaRepo.AtualizarConsulta(tx, consulta); err != nil {
+			return err
+		}
+
+		return criarNotificacao(tx, s.notificacaoRepo, consulta.Paciente.UsuarioID,
+			fmt.Sprintf("%s remarcou a consulta de %s para %s.", profissional.Usuario.Nome,
+				anterior.Format("02/01/2006 15:04"), consulta.Inicio.Format("02/01/2006 15:04")))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ConsultaParaDTOOut(consulta), nil
+}
+
+// AlterarStatusConsulta registra o desfecho da consulta ou a cancela
+// Cancelar com Serie tambem cancela as proximas consultas agendadas da recorrencia
+func (s *consultaServico) AlterarStatusConsulta(userID, consultaID uint, dtoIn *dtos.AlterarStatusConsultaDTOIn) ([]*dtos.ConsultaDTOOut, error) {
+	var alteradas []*dominio.Consulta
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		profissional, err := s.buscarProfissional(tx, userID)
+		if err != nil {
+			return err
+		}
+		consulta, err := s.buscarConsultaDoProfissional(tx, profissional.ID, consultaID)
+		if err != nil {
+			return err
+		}
+
+		agora := time.Now()
+		if err := consulta.AlterarStatus(dtoIn.Status, agora); err != nil {
+			return err
+		}
+		alteradas = []*dominio.Consulta{consulta}
+
+		if dtoIn.Status == dominio.StatusConsultaCancelada && dtoIn.Serie && consulta.SerieID != nil {
+			seguintes, err := s.consultaRepo.ListarConsultasFuturasDaSerie(tx, *consulta.SerieID, consulta.Inicio)
+			if err != nil {
+				return err
+			}
+			for _, seguinte := range seguintes {
+				if seguinte.ID == consulta.ID {
+					continue
+				}
+				if err := seguinte.AlterarStatus(dominio.StatusConsultaCancelada, agora); err != nil {
+					return err
+				}
+				seguinte.Paciente = consulta.Paciente
+				alteradas = append(alteradas, seguinte)
+			}
+		}
+
+		for _, alterada := range alteradas {
+			if err := s.salvarAlteracaoStatus(tx, alterada); err != nil {
+				return err
+			}
+		}
+
+		if dtoIn.Status != dominio.StatusConsultaCancelada {
+			return nil
+		}
+		mensagem := fmt.Sprintf("%s cancelou a consulta de %s.", profissional.Usuario.Nome, consulta.Inicio.Format("02/01/2006 15:04"))
+		if len(alteradas) > 1 {
+			mensagem = fmt.Sprintf("%s cancelou as consultas semanais a partir de %s.", profissional.Usuario.Nome, consulta.Inicio.Format("02/01/2006 15:04"))
+		}
+		return criarNotificacao(tx, s.notificacaoRepo, consulta.Paciente.UsuarioID, mensagem)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ConsultasParaDTOOut(alteradas), nil
+}
+
+// ListarConsultasProfissional retorna a agenda do profissional no periodo
+func (s *consultaServico) ListarConsultasProfissional(userID uint, de, ate *time.Time) ([]*dtos.ConsultaDTOOut, error) {
+	inicio, fim, err := dominio.PeriodoAgenda(de, ate, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	profissional, err := s.buscarProfissional(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	consultas, err := s.consultaRepo.ListarConsultasDoProfissional(s.db, profissional.ID, inicio, fim)
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ConsultasParaDTOOut(consultas), nil
+}
+
+// ListarConsultasPaciente retorna as consultas do paciente com todos os profissionais no periodo
+func (s *consultaServico) ListarConsultasPaciente(userID uint, de, ate *time.Time) ([]*dtos.ConsultaDTOOut, error) {
+	inicio, fim, err := dominio.PeriodoAgenda(de, ate, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	paciente, err := buscarPacienteDoUsuario(s.db, s.usuarioRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	consultas, err := s.consultaRepo.ListarConsultasDoPaciente(s.db, paciente.ID, inicio, fim)
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ConsultasParaDTOOut(consultas), nil
+}
+
+// CancelarConsultaPaciente permite ao paciente desmarcar uma consulta agendada; o profissional e notificado
+func (s *consultaServico) CancelarConsultaPaciente(userID, consultaID uint) (*dtos.ConsultaDTOOut, error) {
+	var consulta *dominio.Consulta
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		paciente, err := buscarPacienteDoUsuario(tx, s.usuarioRepo, userID)
+		if err != nil {
+			return err
+		}
+		consulta, err = s.consultaRepo.BuscarConsultaPorID(tx, consultaID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrConsultaNaoEncontrada
+			}
+			return err
+		}
+		if consulta.PacienteID != paciente.ID {
+			return dominio.ErrConsultaNaoEncontrada
+		}
+
+		if err := consulta.AlterarStatus(dominio.StatusConsultaCancelada, time.Now()); err != nil {
+			return err
+		}
+		if err := s.salvarAlteracaoStatus(tx, consulta); err != nil {
+			return err
+		}
+
+		return criarNotificacao(tx, s.notificacaoRepo, consulta.Profissional.UsuarioID,
+			fmt.Sprintf("%s cancelou a consulta de %s.", paciente.Usuario.Nome, consulta.Inicio.Format("02/01/2006 15:04")))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ConsultaParaDTOOut(consulta), nil
+}
+
+// GerarLinkCalendario emite o link .ics do profissional; o link anterior deixa de funcionar
+func (s *consultaServico) GerarLinkCalendario(userID uint) (*dtos.CalendarioDTOOut, error) {
+	profissional, err := s.buscarProfissional(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	token, err := gerarTokenAleatorio(32)
+	if err != nil {
+		return nil, err
+	}
+
+	calendario := &dominio.CalendarioProfissional{
+		ProfissionalID: profissional.ID,
+		TokenHash:      hashToken(token),
+		CriadoEm:       time.Now(),
+	}
+	if err := s.consultaRepo.SalvarCalendario(s.db, calendario); err != nil {
+		return nil, err
+	}
+	return &dtos.CalendarioDTOOut{UrlCalendario: urlCalendario(token)}, nil
+}
+
+// CalendarioICS monta o feed do profissional dono do token com os ultimos 30 dias e o proximo ano
+func (s *consultaServico) CalendarioICS(token string) ([]byte, error) {
+	if token == "" {
+		return nil, dominio.ErrCalendarioLinkInvalido
+	}
+	calendario, err := s.consultaRepo.BuscarCalendarioPorTokenHash(s.db, hashToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrCalendarioLinkInvalido
+		}
+		return nil, err
+	}
+
+	agora := time.Now()
+	consultas, err := s.consultaRepo.ListarConsultasDoProfissional(s.db, calendario.ProfissionalID,
+		agora.Add(-historicoCalendario), agora.Add(dominio.PeriodoMaximoAgenda))
+	if err != nil {
+		return nil, err
+	}
+	return gerarICalendario(consultas, agora), nil
+}
+
+func (s *consultaServico) AtribuirQuestionariosPreSessao() error {
+	agora := time.Now()
+	consultas, err := s.consultaRepo.ListarConsultasAguardandoQuestionario(s.db, agora, agora.Add(dominio.AntecedenciaQuestionarioPreSessao))
+	if err != nil {
+		return err
+	}
+	for _, consulta := range consultas {
+		if err := s.atribuirQuestionarioPreSessao(consulta, agora); err != nil {
+			log.Printf("falha ao atribuir o questionario da consulta %d: %v", consulta.ID, err)
+		}
+	}
+	return nil
+}
+
+// atribuirQuestionarioPreSessao cria a atribuicao e avisa o paciente e os responsaveis
+// Sem vinculo ativo a consulta e cancelada; sem consentimento o questionario nao e enviado
+func (s *consultaServico) atribuirQuestionarioPreSessao(consulta *dominio.Consulta, agora time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if !consulta.AguardaQuestionario(agora) {
+			return nil
+		}
+		if !s.vinculoAtivo(tx, consulta.PacienteID, consulta.ProfissionalID) {
+			if err := consulta.AlterarStatus(dominio.StatusConsultaCancelada, agora); err != nil {
+				return err
+			}
+			return s.consultaRepo.AtualizarConsulta(tx, consulta)
+		}
+		if _, err := verificarConsentimento(tx, s.consentimentoRepo, consulta.PacienteID, consulta.ProfissionalID, dominio.CategoriaQuestionarios); err != nil {
+			return err
+		}
+
+		instrumento, err := s.instrumentoRepo.BuscarInstrumentoPorID(tx, *consulta.InstrumentoID)
+		if err != nil {
+			return err
+		}
+		atribuicao := &dominio.Atribuicao{
+			ProfissionalID: consulta.ProfissionalID,
+			Profissional:   consulta.Profissional,
+			PacienteID:     consulta.PacienteID,
+			Paciente:       consulta.Paciente,
+			InstrumentoID:  instrumento.ID,
+			Instrumento:    *instrumento,
+		}
+		if err := s.instrumentoRepo.CriarAtribuicao(tx, atribuicao); err != nil {
+			return err
+		}
+		consulta.AtribuicaoID = &atribuicao.ID
+		if err := s.consultaRepo.AtualizarConsulta(tx, consulta); err != nil {
+			return err
+		}
+
+		if err := criarNotificacao(tx, s.notificacaoRepo, consulta.Paciente.UsuarioID,
+			fmt.Sprintf("Responda o questionario %s antes da sua consulta de %s.", instrumento.Nome, consulta.Inicio.Format("02/01/2006 15:04"))); err != nil {
+			return err
+		}
+		return notificarResponsaveis(tx, s.responsavelRepo, s.notificacaoRepo, consulta.PacienteID,
+			fmt.Sprintf("%%s recebeu o questionario %s para responder antes da consulta.", instrumento.Nome))
+	})
+}
+
+func (s *consultaServico) buscarProfissional(tx *gorm.DB, userID uint) (*dominio.Profissional, error) {
+	profissional, err := s.usuarioRepo.BuscarProfissionalPorUsuarioID(tx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrUsuarioNaoEncontrado
+		}
+		return nil, err
+	}
+	return profissional, nil
+}
+
+// buscarConsultaDoProfissional responde consultas de outros profissionais como inexistentes
+func (s *consultaServico) buscarConsultaDoProfissional(tx *gorm.DB, profissionalID, consultaID uint) (*dominio.Consulta, error) {
+	consulta, err := s.consultaRepo.BuscarConsultaPorID(tx, consultaID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrConsultaNaoEncontrada
+		}
+		return nil, err
+	}
+	if consulta.ProfissionalID != profissionalID {
+		return nil, dominio.ErrConsultaNaoEncontrada
+	}
+	return consulta, nil
+}
+
+func (s *consultaServico) vinculoAtivo(tx *gorm.DB, pacienteID, profissionalID uint) bool {
+	vinculo, err := s.vinculoRepo.BuscarVinculo(tx, pacienteID, profissionalID)
+	return err == nil && vinculo.Ativo()
+}
+
+func (s *consultaServico) verificarConflitos(tx *gorm.DB, consulta *dominio.Consulta) error {
+	conflitos, err := s.consultaRepo.BuscarConflitos(tx, consulta.ProfissionalID, consulta.Inicio, consulta.Fim, consulta.ID)
+	if err != nil {
+		return err
+	}
+	if len(conflitos) > 0 {
+		return dominio.ErrConflitoAgenda
+	}
+	return nil
+}
+
+// salvarAlteracaoStatus grava o novo status; uma consulta cancelada leva junto o questionario ainda pendente
+func (s *consultaServico) salvarAlteracaoStatus(tx *gorm.DB, consulta *dominio.Consulta) error {
+	if err := s.consultaRepo.AtualizarConsulta(tx, consulta); err != nil {
+		return err
+	}
+	if consulta.Status == dominio.StatusConsultaCancelada && consulta.AtribuicaoID != nil {
+		return s.consultaRepo.CancelarAtribuicaoPendente(tx, *consulta.AtribuicaoID)
+	}
+	return nil
+}
+
+// urlCalendario monta o link publico do feed .ics
+func urlCalendario(token string) string {
+	return fmt.Sprintf("%s/api/v1/calendario.ics?token=%s", urlFrontend(), token)
+}
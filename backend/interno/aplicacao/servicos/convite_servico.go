@@ -4,10 +4,13 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"fmt"
+	"log"
 	"mindtrace/backend/interno/aplicacao/dtos"
 	"mindtrace/backend/interno/aplicacao/mappers"
 	"mindtrace/backend/interno/dominio"
 	"mindtrace/backend/interno/persistencia/repositorios"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -15,31 +18,62 @@ import (
 
 // ConviteServico define os metodos para gerenciamento de convites
 type ConviteServico interface {
-	GerarConvite(userID uint) (*dtos.ConviteDTOOut, error)
+	// GerarConvite usa validade de 24h e uso unico quando dtoIn e nil ou omite os campos
+	GerarConvite(userID uint, dtoIn *dtos.GerarConviteDTOIn) (*dtos.ConviteDTOOut, error)
+	ListarConvites(userID uint, status string) ([]*dtos.ConviteDTOOut, error)
+	RevogarConvite(userID, conviteID uint) (*dtos.ConviteDTOOut, error)
+	// EnviarConvite envia o link de um convite ativo do profissional para o e-mail informado
+	EnviarConvite(userID, conviteID uint, email string) (*dtos.ConviteDTOOut, error)
+	// GerarQRCodeConvite retorna a imagem do link de resgate e o content type correspondente
+	GerarQRCodeConvite(userID, conviteID uint, formato string) ([]byte, string, error)
 	VincularPaciente(userID uint, token string) error
 }
 
+// validadePadraoConvite e a validade de um convite gerado sem configuracao
+const validadePadraoConvite = 24 * time.Hour
+
 // conviteServico implementa a interface ConviteServico
 type conviteServico struct {
-	db                 *gorm.DB
-	conviteRepositorio repositorios.ConviteRepositorio
-	usuarioRepositorio repositorios.UsuarioRepositorio
+	db                       *gorm.DB
+	conviteRepositorio       repositorios.ConviteRepositorio
+	usuarioRepositorio       repositorios.UsuarioRepositorio
+	consentimentoRepositorio repositorios.ConsentimentoRepositorio
+	vinculoRepositorio       repositorios.VinculoRepositorio
+	emailServico             EmailServico
 }
 
 // NovoConviteServico cria uma nova instancia de ConviteServico
-func NovoConviteServico(db *gorm.DB, cr repositorios.ConviteRepositorio, ur repositorios.UsuarioRepositorio) ConviteServico {
+func NovoConviteServico(db *gorm.DB, cr repositorios.ConviteRepositorio, ur repositorios.UsuarioRepositorio, csr repositorios.ConsentimentoRepositorio, vr repositorios.VinculoRepositorio, es EmailServico) ConviteServico {
 	return &conviteServico{
-		db:                 db,
-		conviteRepositorio: cr,
-		usuarioRepositorio: ur,
+		db:                       db,
+		conviteRepositorio:       cr,
+		usuarioRepositorio:       ur,
+		consentimentoRepositorio: csr,
+		vinculoRepositorio:       vr,
+		emailServico:             es,
 	}
 }
 
 // GerarConvite gera um novo convite para o profissional
-func (s *conviteServico) GerarConvite(userID uint) (*dtos.ConviteDTOOut, error) {
+func (s *conviteServico) GerarConvite(userID uint, dtoIn *dtos.GerarConviteDTOIn) (*dtos.ConviteDTOOut, error) {
+	if dtoIn == nil {
+		dtoIn = &dtos.GerarConviteDTOIn{}
+	}
+	validade := validadePadraoConvite
+	if dtoIn.ValidadeHoras > 0 {
+		validade = time.Duration(dtoIn.ValidadeHoras) * time.Hour
+	}
+	maxUsos := uint(1)
+	if dtoIn.MaxUsos > 0 {
+		maxUsos = dtoIn.MaxUsos
+	}
+	enviarPara := strings.ToLower(strings.TrimSpace(dtoIn.EnviarPara))
+
 	var conviteGerado *dominio.Convite
+	var profissional *dominio.Profissional
 	err := s.db.Transaction(func(tx *gorm.DB) error {
-		profissional, err := s.usuarioRepositorio.BuscarProfissionalPorUsuarioID(tx, userID)
+		var err error
+		profissional, err = s.usuarioRepositorio.BuscarProfissionalPorUsuarioID(tx, userID)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return dominio.ErrUsuarioNaoEncontrado
@@ -56,16 +90,22 @@ func (s *conviteServico) GerarConvite(userID uint) (*dtos.ConviteDTOOut, error)
 		token := hex.EncodeToString(tokenBytes)
 
 		convite := &dominio.Convite{
-			ProfissionalID: profissional.ID,
-			Token:          token,
-			DataExpiracao:  time.Now().Add(24 * time.Hour),
-			Usado:          false,
+			ProfissionalID:    profissional.ID,
+			Token:             token,
+			DataExpiracao:     time.Now().Add(validade),
+			Usado:             false,
+			MaxUsos:           maxUsos,
+			EmailDestinatario: strings.ToLower(strings.TrimSpace(dtoIn.Email)),
 		}
 
 		// Validar convite antes de criar
 		if err := convite.Validar(); err != nil {
 			return err
 		}
+		// Um convite restrito a um e-mail so e enviado para esse mesmo e-mail
+		if enviarPara != "" && !convite.AceitaEmail(enviarPara) {
+			return dominio.ErrConviteOutroEmail
+		}
 
 		if err := s.conviteRepositorio.CriarConvite(tx, convite); err != nil {
 			return err
@@ -75,30 +115,162 @@ func (s *conviteServico) GerarConvite(userID uint) (*dtos.ConviteDTOOut, error)
 		return nil
 
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	conviteOut := conviteParaDTOOut(conviteGerado)
+	if enviarPara != "" {
+		// O convite ja foi criado; a falha de entrega fica visivel pela ausencia de enviado_para
+		if err := s.enviarEmailConvite(profissional, conviteGerado, enviarPara); err != nil {
+			log.Printf("falha ao enviar convite %d por e-mail: %v", conviteGerado.ID, err)
+		} else {
+			conviteOut.EnviadoPara = enviarPara
+		}
+	}
+	return conviteOut, nil
+}
+
+// ListarConvites retorna os convites do profissional, do mais recente ao mais antigo
+// Com status informado, retorna apenas os convites nessa situacao
+func (s *conviteServico) ListarConvites(userID uint, status string) ([]*dtos.ConviteDTOOut, error) {
+	profissional, err := s.usuarioRepositorio.BuscarProfissionalPorUsuarioID(s.db, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrUsuarioNaoEncontrado
+		}
+		return nil, err
+	}
+
+	convites, err := s.conviteRepositorio.ListarConvitesDoProfissional(s.db, profissional.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	agora := time.Now()
+	filtrados := make([]*dominio.Convite, 0, len(convites))
+	for _, convite := range convites {
+		if status == "" || convite.Status(agora) == status {
+			filtrados = append(filtrados, convite)
+		}
+	}
+	convitesOut := mappers.ConvitesParaDTOOut(filtrados)
+	for _, conviteOut := range convitesOut {
+		conviteOut.Link = urlConvite(conviteOut.Token)
+	}
+	return convitesOut, nil
+}
+
+// RevogarConvite impede novos usos de um convite ativo do profissional
+func (s *conviteServico) RevogarConvite(userID, conviteID uint) (*dtos.ConviteDTOOut, error) {
+	var revogado *dominio.Convite
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		_, convite, err := s.buscarConviteDoProfissional(tx, userID, conviteID)
+		if err != nil {
+			return err
+		}
+
+		if err := convite.Revogar(time.Now()); err != nil {
+			return err
+		}
+		if err := s.conviteRepositorio.RevogarConvite(tx, convite); err != nil {
+			return err
+		}
+		revogado = convite
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ConviteParaDTOOut(revogado), nil
+}
+
+// EnviarConvite envia por e-mail o link de um convite que ainda pode ser usado
+func (s *conviteServico) EnviarConvite(userID, conviteID uint, email string) (*dtos.ConviteDTOOut, error) {
+	profissional, convite, err := s.buscarConviteDoProfissional(s.db, userID, conviteID)
+	if err != nil {
+		return nil, err
+	}
+	if err := convite.Disponivel(time.Now()); err != nil {
+		return nil, err
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !convite.AceitaEmail(email) {
+		return nil, dominio.ErrConviteOutroEmail
+	}
+
+	if err := s.enviarEmailConvite(profissional, convite, email); err != nil {
+		log.Printf("falha ao enviar convite %d por e-mail: %v", convite.ID, err)
+		return nil, dominio.ErrEnvioConviteFalhou
+	}
+	conviteOut := conviteParaDTOOut(convite)
+	conviteOut.EnviadoPara = email
+	return conviteOut, nil
+}
 
-	return mappers.ConviteParaDTOOut(conviteGerado), err // err = nil
+// GerarQRCodeConvite renderiza o link de resgate de um convite ativo em PNG ou SVG
+func (s *conviteServico) GerarQRCodeConvite(userID, conviteID uint, formato string) ([]byte, string, error) {
+	if formato != FormatoQRCodePNG && formato != FormatoQRCodeSVG {
+		return nil, "", dominio.ErrFormatoQRCodeInvalido
+	}
+	_, convite, err := s.buscarConviteDoProfissional(s.db, userID, conviteID)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := convite.Disponivel(time.Now()); err != nil {
+		return nil, "", err
+	}
+
+	if formato == FormatoQRCodeSVG {
+		imagem, err := gerarQRCodeSVG(urlConvite(convite.Token))
+		return imagem, "image/svg+xml", err
+	}
+	imagem, err := gerarQRCodePNG(urlConvite(convite.Token))
+	return imagem, "image/png", err
+}
+
+// buscarConviteDoProfissional carrega o convite garantindo que pertence ao profissional autenticado
+// Convites de outro profissional sao tratados como inexistentes
+func (s *conviteServico) buscarConviteDoProfissional(tx *gorm.DB, userID, conviteID uint) (*dominio.Profissional, *dominio.Convite, error) {
+	profissional, err := s.usuarioRepositorio.BuscarProfissionalPorUsuarioID(tx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, dominio.ErrUsuarioNaoEncontrado
+		}
+		return nil, nil, err
+	}
+
+	convite, err := s.conviteRepositorio.BuscarConvitePorID(tx, conviteID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, dominio.ErrConviteNaoEncontrado
+		}
+		return nil, nil, err
+	}
+	if convite.ProfissionalID != profissional.ID {
+		return nil, nil, dominio.ErrConviteNaoEncontrado
+	}
+	return profissional, convite, nil
+}
+
+// enviarEmailConvite envia o link de resgate do convite em nome do profissional
+func (s *conviteServico) enviarEmailConvite(profissional *dominio.Profissional, convite *dominio.Convite, email string) error {
+	return s.emailServico.Enviar(MensagemEmail{
+		Para:    email,
+		Assunto: "MindTrace - Convite para acompanhamento",
+		Corpo: fmt.Sprintf(
+			"Ola.\n\n%s convidou voce para registrar seu acompanhamento no MindTrace. Acesse o link abaixo para criar sua conta ou entrar e aceitar o convite:\n\n%s\n\nO convite vale ate %s. Se voce nao esperava este convite, ignore este e-mail.",
+			profissional.Usuario.Nome, urlConvite(convite.Token), convite.DataExpiracao.Format("02/01/2006 15:04")),
+	})
 }
 
 // VincularPaciente vincula um paciente a um profissional usando um token de convite
 func (s *conviteServico) VincularPaciente(userID uint, token string) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		// Buscar convite pelo token recebido
-		convite, err := s.conviteRepositorio.BuscarConvitePorToken(tx, token)
+		convite, err := buscarConviteDisponivel(tx, s.conviteRepositorio, token)
 		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return dominio.ErrTokenConviteInvalido
-			}
 			return err
 		}
-		// Validar se o convite recebido esta valido com os metodos de dominio
-		if !convite.EstaValido() {
-			if convite.EstaExpirado() {
-				return dominio.ErrConviteExpirado
-			}
-			if convite.JaFoiUtilizado() {
-				return dominio.ErrConviteJaUtilizado
-			}
-		}
 		// Busca paciente
 		paciente, err := s.usuarioRepositorio.BuscarPacientePorUsuarioID(tx, userID)
 		if err != nil {
@@ -107,15 +279,70 @@ func (s *conviteServico) VincularPaciente(userID uint, token string) error {
 			}
 			return err
 		}
+		return resgatarConvite(tx, s.conviteRepositorio, s.vinculoRepositorio, s.consentimentoRepositorio, convite, paciente)
+	})
+}
 
-		// Vincular paciente ao profissional (insere na tabela de associacao profissionais_pacientes)
-		profissionalShell := &dominio.Profissional{ID: convite.ProfissionalID}
-		if err := tx.Model(profissionalShell).Association("Pacientes").Append(paciente); err != nil {
-			return err
+// buscarConviteDisponivel busca o convite do token e confere se ainda pode ser usado
+func buscarConviteDisponivel(tx *gorm.DB, cr repositorios.ConviteRepositorio, token string) (*dominio.Convite, error) {
+	// Buscar convite pelo token recebido
+	convite, err := cr.BuscarConvitePorToken(tx, token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrTokenConviteInvalido
+		}
+		return nil, err
+	}
+	// Validar se o convite recebido esta valido com os metodos de dominio
+	if !convite.EstaValido() {
+		if convite.EstaRevogado() {
+			return nil, dominio.ErrConviteRevogado
+		}
+		if convite.EstaExpirado() {
+			return nil, dominio.ErrConviteExpirado
 		}
+		if convite.JaFoiUtilizado() {
+			return nil, dominio.ErrConviteJaUtilizado
+		}
+	}
+	return convite, nil
+}
 
-		convite.UtilizarConvite(paciente.ID)
+// resgatarConvite consome um uso do convite e vincula o paciente ao profissional que o gerou
+// Usado tanto por pacientes ja cadastrados quanto no cadastro com convite
+func resgatarConvite(tx *gorm.DB, cr repositorios.ConviteRepositorio, vr repositorios.VinculoRepositorio, csr repositorios.ConsentimentoRepositorio, convite *dominio.Convite, paciente *dominio.Paciente) error {
+	if !convite.AceitaEmail(paciente.Usuario.Email) {
+		return dominio.ErrConviteOutroEmail
+	}
 
-		return s.conviteRepositorio.MarcarConviteComoUsado(tx, convite)
-	})
+	// Vincular paciente ao profissional; um vinculo encerrado e reativado
+	agora := time.Now()
+	if err := ativarVinculo(tx, vr, paciente.ID, convite.ProfissionalID, agora); err != nil {
+		return err
+	}
+
+	convite.UtilizarConvite(paciente.ID)
+
+	if err := cr.MarcarConviteComoUsado(tx, convite); err != nil {
+		// Outro paciente consumiu o ultimo uso, ou o convite foi revogado, depois da leitura
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dominio.ErrConviteJaUtilizado
+		}
+		return err
+	}
+
+	// O vinculo passa a valer com o consentimento padrao, que o paciente pode ajustar
+	return garantirConsentimentoDoVinculo(tx, csr, paciente.ID, convite.ProfissionalID, agora)
+}
+
+// conviteParaDTOOut inclui no DTO o link de resgate do convite
+func conviteParaDTOOut(convite *dominio.Convite) *dtos.ConviteDTOOut {
+	conviteOut := mappers.ConviteParaDTOOut(convite)
+	conviteOut.Link = urlConvite(convite.Token)
+	return conviteOut
+}
+
+// urlConvite monta o link de resgate aberto pelo paciente, tambem usado no QR code
+func urlConvite(token string) string {
+	return fmt.Sprintf("%s/convite?token=%s", urlFrontend(), token)
 }
@@ -0,0 +1,333 @@
+package servicos
+
+import (
+	"crypto/rand"
+	"errors"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// DoisFatoresServico define os metodos da autenticacao em dois fatores (TOTP, RFC 6238)
+type DoisFatoresServico interface {
+	IniciarInscricao(userID uint) (*dtos.InscricaoDoisFatoresDTOOut, error)
+	AtivarDoisFatores(userID uint, codigo string) (*dtos.CodigosRecuperacaoDTOOut, error)
+	DesativarDoisFatores(userID uint, dtoIn *dtos.DesativarDoisFatoresDTOIn) error
+	RegenerarCodigosRecuperacao(userID uint, codigo string) (*dtos.CodigosRecuperacaoDTOOut, error)
+	DoisFatoresAtivo(userID uint) (bool, error)
+	IniciarDesafio(userID uint) (string, error)
+	UsuarioDoDesafio(desafioToken string) (uint, error)
+	ConcluirDesafio(desafioToken, codigo string) (uint, error)
+}
+
+// doisFatoresServico implementa a interface DoisFatoresServico
+type doisFatoresServico struct {
+	db                     *gorm.DB
+	usuarioRepositorio     repositorios.UsuarioRepositorio
+	doisFatoresRepositorio repositorios.DoisFatoresRepositorio
+	// obrigatorioProfissionais impede que profissionais desativem o segundo fator
+	obrigatorioProfissionais bool
+}
+
+// NovoDoisFatoresServico cria uma nova instancia de DoisFatoresServico
+func NovoDoisFatoresServico(db *gorm.DB, ur repositorios.UsuarioRepositorio, dfr repositorios.DoisFatoresRepositorio, obrigatorioProfissionais bool) DoisFatoresServico {
+	return &doisFatoresServico{
+		db:                       db,
+		usuarioRepositorio:       ur,
+		doisFatoresRepositorio:   dfr,
+		obrigatorioProfissionais: obrigatorioProfissionais,
+	}
+}
+
+// IniciarInscricao gera um novo segredo e retorna a URI otpauth para o aplicativo autenticador
+// O segundo fator so passa a valer apos AtivarDoisFatores com um codigo valido
+func (s *doisFatoresServico) IniciarInscricao(userID uint) (*dtos.InscricaoDoisFatoresDTOOut, error) {
+	usuario, err := s.usuarioRepositorio.BuscarUsuarioPorID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrUsuarioNaoEncontrado
+		}
+		return nil, err
+	}
+	if usuario.TipoUsuario != dominio.TipoUsuarioProfissional {
+		return nil, dominio.ErrDoisFatoresApenasProfissionais
+	}
+
+	existente, err := s.doisFatoresRepositorio.BuscarDoisFatores(s.db, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if existente != nil && existente.Ativo {
+		return nil, dominio.ErrDoisFatoresJaAtivo
+	}
+
+	segredo := make([]byte, dominio.TamanhoSegredoTOTP)
+	if _, err := rand.Read(segredo); err != nil {
+		return nil, err
+	}
+	segredoBase32 := dominio.CodificarSegredoTOTP(segredo)
+
+	doisFatores := &dominio.DoisFatores{UsuarioID: userID, Segredo: segredoBase32}
+	if err := s.doisFatoresRepositorio.SalvarDoisFatores(s.db, doisFatores); err != nil {
+		return nil, err
+	}
+
+	return &dtos.InscricaoDoisFatoresDTOOut{
+		URIOtpauth: dominio.URIOtpauth(dominio.EmissorDoisFatores, usuario.Email, segredoBase32),
+		Segredo:    segredoBase32,
+	}, nil
+}
+
+// AtivarDoisFatores confirma a inscricao com o primeiro codigo e gera os codigos de recuperacao
+func (s *doisFatoresServico) AtivarDoisFatores(userID uint, codigo string) (*dtos.CodigosRecuperacaoDTOOut, error) {
+	var codigos []string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		doisFatores, err := s.doisFatoresRepositorio.BuscarDoisFatores(tx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrDoisFatoresNaoIniciado
+			}
+			return err
+		}
+		if doisFatores.Ativo {
+			return dominio.ErrDoisFatoresJaAtivo
+		}
+
+		agora := time.Now()
+		if !doisFatores.VerificarCodigo(codigo, agora) {
+			return dominio.ErrCodigoDoisFatoresInvalido
+		}
+		doisFatores.Ativar(agora)
+		if err := s.doisFatoresRepositorio.SalvarDoisFatores(tx, doisFatores); err != nil {
+			return err
+		}
+
+		codigos, err = s.gerarCodigosRecuperacao(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dtos.CodigosRecuperacaoDTOOut{Codigos: codigos}, nil
+}
+
+// DesativarDoisFatores remove o segundo fator apos confirmar senha e codigo
+func (s *doisFatoresServico) DesativarDoisFatores(userID uint, dtoIn *dtos.DesativarDoisFatoresDTOIn) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		usuario, err := s.usuarioRepositorio.BuscarUsuarioPorID(userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrUsuarioNaoEncontrado
+			}
+			return err
+		}
+		if s.obrigatorioProfissionais && usuario.TipoUsuario == dominio.TipoUsuarioProfissional {
+			return dominio.ErrDoisFatoresObrigatorio
+		}
+
+		if err := bcrypt.CompareHashAndPassword([]byte(usuario.Senha), []byte(dtoIn.Senha)); err != nil {
+			return dominio.ErrCrendenciaisInvalidas
+		}
+
+		if err := s.verificarSegundoFator(tx, userID, dtoIn.Codigo); err != nil {
+			return err
+		}
+
+		return s.doisFatoresRepositorio.RemoverDoisFatores(tx, userID)
+	})
+}
+
+// RegenerarCodigosRecuperacao invalida os codigos atuais e gera um novo conjunto
+func (s *doisFatoresServico) RegenerarCodigosRecuperacao(userID uint, codigo string) (*dtos.CodigosRecuperacaoDTOOut, error) {
+	var codigos []string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.verificarSegundoFator(tx, userID, codigo); err != nil {
+			return err
+		}
+
+		var err error
+		codigos, err = s.gerarCodigosRecuperacao(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dtos.CodigosRecuperacaoDTOOut{Codigos: codigos}, nil
+}
+
+// DoisFatoresAtivo indica se o login do usuario exige a etapa do segundo fator
+func (s *doisFatoresServico) DoisFatoresAtivo(userID uint) (bool, error) {
+	doisFatores, err := s.doisFatoresRepositorio.BuscarDoisFatores(s.db, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return doisFatores.Ativo, nil
+}
+
+// IniciarDesafio cria o token de curta duracao que liga a senha validada ao codigo TOTP
+func (s *doisFatoresServico) IniciarDesafio(userID uint) (string, error) {
+	token, err := gerarTokenAleatorio(32)
+	if err != nil {
+		return "", err
+	}
+
+	desafio := &dominio.DesafioDoisFatores{
+		UsuarioID:     userID,
+		TokenHash:     hashToken(token),
+		DataExpiracao: time.Now().Add(dominio.ValidadeDesafioDoisFatores),
+	}
+	if err := s.doisFatoresRepositorio.CriarDesafio(s.db, desafio); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// UsuarioDoDesafio retorna o usuario de um desafio ainda utilizavel, sem consumir tentativas
+// Permite verificar o bloqueio da conta antes de aceitar o codigo
+func (s *doisFatoresServico) UsuarioDoDesafio(desafioToken string) (uint, error) {
+	desafio, err := s.doisFatoresRepositorio.BuscarDesafioPorTokenHash(s.db, hashToken(desafioToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, dominio.ErrDesafioDoisFatoresInvalido
+		}
+		return 0, err
+	}
+	if err := desafio.VerificarUso(); err != nil {
+		return 0, err
+	}
+	return desafio.UsuarioID, nil
+}
+
+// ConcluirDesafio valida o codigo informado para o desafio e retorna o usuario autenticado
+// Cada desafio aceita no maximo LimiteTentativasDesafio codigos incorretos
+func (s *doisFatoresServico) ConcluirDesafio(desafioToken, codigo string) (uint, error) {
+	var usuarioID uint
+	codigoInvalido := false
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		desafio, err := s.doisFatoresRepositorio.BuscarDesafioPorTokenHash(tx, hashToken(desafioToken))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrDesafioDoisFatoresInvalido
+			}
+			return err
+		}
+		if err := desafio.VerificarUso(); err != nil {
+			return err
+		}
+
+		err = s.verificarSegundoFator(tx, desafio.UsuarioID, codigo)
+		if errors.Is(err, dominio.ErrCodigoDoisFatoresInvalido) {
+			// A tentativa e persistida mesmo com o codigo incorreto
+			codigoInvalido = true
+			desafio.RegistrarTentativa()
+			return s.doisFatoresRepositorio.AtualizarDesafio(tx, desafio)
+		}
+		if err != nil {
+			return err
+		}
+
+		desafio.Utilizar()
+		usuarioID = desafio.UsuarioID
+		return s.doisFatoresRepositorio.AtualizarDesafio(tx, desafio)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if codigoInvalido {
+		return 0, dominio.ErrCodigoDoisFatoresInvalido
+	}
+
+	return usuarioID, nil
+}
+
+// verificarSegundoFator aceita um codigo TOTP ou um codigo de recuperacao ainda nao utilizado
+func (s *doisFatoresServico) verificarSegundoFator(tx *gorm.DB, userID uint, codigo string) error {
+	doisFatores, err := s.doisFatoresRepositorio.BuscarDoisFatores(tx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dominio.ErrDoisFatoresNaoAtivo
+		}
+		return err
+	}
+	if !doisFatores.Ativo {
+		return dominio.ErrDoisFatoresNaoAtivo
+	}
+
+	if doisFatores.VerificarCodigo(codigo, time.Now()) {
+		return s.doisFatoresRepositorio.SalvarDoisFatores(tx, doisFatores)
+	}
+
+	// Os codigos sao guardados com bcrypt, entao o codigo informado e comparado com cada um ainda disponivel
+	disponiveis, err := s.doisFatoresRepositorio.ListarCodigosRecuperacaoDisponiveis(tx, userID)
+	if err != nil {
+		return err
+	}
+	normalizado := []byte(normalizarCodigoRecuperacao(codigo))
+	for _, recuperacao := range disponiveis {
+		if bcrypt.CompareHashAndPassword([]byte(recuperacao.CodigoHash), normalizado) == nil {
+			recuperacao.Utilizar()
+			return s.doisFatoresRepositorio.MarcarCodigoRecuperacaoComoUsado(tx, recuperacao)
+		}
+	}
+	return dominio.ErrCodigoDoisFatoresInvalido
+}
+
+// gerarCodigosRecuperacao substitui os codigos do usuario e retorna os novos em texto claro
+// Cada codigo tem 80 bits de entropia e apenas o hash bcrypt e persistido, como nas senhas
+func (s *doisFatoresServico) gerarCodigosRecuperacao(tx *gorm.DB, userID uint) ([]string, error) {
+	codigos := make([]string, 0, dominio.QuantidadeCodigosRecuperacao)
+	registros := make([]*dominio.CodigoRecuperacao, 0, dominio.QuantidadeCodigosRecuperacao)
+
+	for i := 0; i < dominio.QuantidadeCodigosRecuperacao; i++ {
+		aleatorio, err := gerarTokenAleatorio(dominio.BytesCodigoRecuperacao)
+		if err != nil {
+			return nil, err
+		}
+		codigo := formatarCodigoRecuperacao(aleatorio)
+		hash, err := bcrypt.GenerateFromPassword([]byte(normalizarCodigoRecuperacao(codigo)), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		codigos = append(codigos, codigo)
+		registros = append(registros, &dominio.CodigoRecuperacao{
+			UsuarioID:  userID,
+			CodigoHash: string(hash),
+		})
+	}
+
+	if err := s.doisFatoresRepositorio.SubstituirCodigosRecuperacao(tx, userID, registros); err != nil {
+		return nil, err
+	}
+	return codigos, nil
+}
+
+// formatarCodigoRecuperacao separa o codigo em grupos de cinco caracteres para facilitar a digitacao
+func formatarCodigoRecuperacao(aleatorio string) string {
+	grupos := make([]string, 0, len(aleatorio)/5+1)
+	for len(aleatorio) > 5 {
+		grupos = append(grupos, aleatorio[:5])
+		aleatorio = aleatorio[5:]
+	}
+	return strings.Join(append(grupos, aleatorio), "-")
+}
+
+// normalizarCodigoRecuperacao ignora hifens, espacos e caixa ao comparar codigos
+func normalizarCodigoRecuperacao(codigo string) string {
+	codigo = strings.ToLower(strings.TrimSpace(codigo))
+	codigo = strings.ReplaceAll(codigo, "-", "")
+	return strings.ReplaceAll(codigo, " ", "")
+}
@@ -0,0 +1,113 @@
+package servicos
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+)
+
+// MensagemEmail representa um e-mail a ser entregue
+type MensagemEmail struct {
+	Para    string
+	Assunto string
+	Corpo   string
+}
+
+// EmailServico define o contrato para envio de e-mails
+// Implementacoes podem ser trocadas sem alterar os servicos que enviam mensagens
+type EmailServico interface {
+	Enviar(msg MensagemEmail) error
+}
+
+// NovoEmailServico seleciona a implementacao conforme EMAIL_DRIVER
+// "smtp" usa as variaveis SMTP_* e EMAIL_REMETENTE; "log" usa a caixa local em memoria, apenas para desenvolvimento
+// Qualquer outro valor, inclusive vazio, e recusado para que a aplicacao nao suba sem entregar e-mails
+func NovoEmailServico() (EmailServico, error) {
+	switch driver := os.Getenv("EMAIL_DRIVER"); driver {
+	case "smtp":
+		servico := &emailServicoSMTP{
+			host:      os.Getenv("SMTP_HOST"),
+			porta:     os.Getenv("SMTP_PORT"),
+			usuario:   os.Getenv("SMTP_USER"),
+			senha:     os.Getenv("SMTP_PASSWORD"),
+			remetente: os.Getenv("EMAIL_REMETENTE"),
+		}
+		if servico.host == "" || servico.porta == "" || servico.remetente == "" {
+			return nil, errors.New("EMAIL_DRIVER=smtp exige SMTP_HOST, SMTP_PORT e EMAIL_REMETENTE")
+		}
+		return servico, nil
+	case "log":
+		// O corpo leva tokens de redefinicao, verificacao, convite e download; so vai para o log em desenvolvimento
+		return &EmailServicoLocal{registrarCorpo: os.Getenv("GO_ENV") == "dev"}, nil
+	default:
+		return nil, fmt.Errorf("EMAIL_DRIVER invalido: %q (use smtp ou log)", driver)
+	}
+}
+
+// emailServicoSMTP envia mensagens atraves de um servidor SMTP
+type emailServicoSMTP struct {
+	host      string
+	porta     string
+	usuario   string
+	senha     string
+	remetente string
+}
+
+func (s *emailServicoSMTP) Enviar(msg MensagemEmail) error {
+	endereco := fmt.Sprintf("%s:%s", s.host, s.porta)
+	var auth smtp.Auth
+	if s.usuario != "" {
+		auth = smtp.PlainAuth("", s.usuario, s.senha, s.host)
+	}
+
+	corpo := strings.Join([]string{
+		"From: " + s.remetente,
+		"To: " + msg.Para,
+		"Subject: " + msg.Assunto,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=\"UTF-8\"",
+		"",
+		msg.Corpo,
+	}, "\r\n")
+
+	return smtp.SendMail(endereco, auth, s.remetente, []string{msg.Para}, []byte(corpo))
+}
+
+// EmailServicoLocal guarda as mensagens em memoria e registra no log
+// Usado em desenvolvimento e nos testes para inspecionar o que seria enviado
+type EmailServicoLocal struct {
+	mu        sync.Mutex
+	mensagens []MensagemEmail
+	// registrarCorpo inclui o corpo da mensagem no log; fora de desenvolvimento so o destinatario e o assunto
+	registrarCorpo bool
+}
+
+// NovoEmailServicoLocal cria uma caixa de saida local vazia
+func NovoEmailServicoLocal() *EmailServicoLocal {
+	return &EmailServicoLocal{}
+}
+
+func (s *EmailServicoLocal) Enviar(msg MensagemEmail) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mensagens = append(s.mensagens, msg)
+	if s.registrarCorpo {
+		log.Printf("[EMAIL LOCAL] para=%s assunto=%q\n%s", msg.Para, msg.Assunto, msg.Corpo)
+		return nil
+	}
+	log.Printf("[EMAIL LOCAL] para=%s assunto=%q", msg.Para, msg.Assunto)
+	return nil
+}
+
+// Mensagens retorna uma copia das mensagens enviadas ate o momento
+func (s *EmailServicoLocal) Mensagens() []MensagemEmail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copia := make([]MensagemEmail, len(s.mensagens))
+	copy(copia, s.mensagens)
+	return copia
+}
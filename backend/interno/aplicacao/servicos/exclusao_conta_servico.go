@@ -0,0 +1,256 @@
+package servicos
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/mappers"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExclusaoContaServico define os metodos do fluxo de exclusao de conta
+// A exclusao e agendada, pode ser cancelada durante a carencia e termina com a anonimizacao do titular
+type ExclusaoContaServico interface {
+	SolicitarExclusao(userID uint, dtoIn *dtos.SolicitarExclusaoContaDTOIn) (*dtos.ExclusaoContaDTOOut, error)
+	BuscarExclusao(userID uint) (*dtos.ExclusaoContaDTOOut, error)
+	CancelarExclusao(userID uint) error
+	ExecutarExclusoesVencidas() error
+}
+
+// exclusaoContaServico implementa a interface ExclusaoContaServico
+type exclusaoContaServico struct {
+	db                        *gorm.DB
+	usuarioRepositorio        repositorios.UsuarioRepositorio
+	exclusaoRepositorio       repositorios.ExclusaoContaRepositorio
+	notificacaoRepositorio    repositorios.NotificacaoRepositorio
+	tentativaLoginRepositorio repositorios.TentativaLoginRepositorio
+	emailServico              EmailServico
+	carencia                  time.Duration
+}
+
+// NovoExclusaoContaServico cria uma nova instancia de ExclusaoContaServico
+// carencia zero usa dominio.PeriodoCarenciaExclusao
+func NovoExclusaoContaServico(db *gorm.DB, ur repositorios.UsuarioRepositorio, er repositorios.ExclusaoContaRepositorio, nr repositorios.NotificacaoRepositorio, tr repositorios.TentativaLoginRepositorio, es EmailServico, carencia time.Duration) ExclusaoContaServico {
+	if carencia <= 0 {
+		carencia = dominio.PeriodoCarenciaExclusao
+	}
+	return &exclusaoContaServico{
+		db:                        db,
+		usuarioRepositorio:        ur,
+		exclusaoRepositorio:       er,
+		notificacaoRepositorio:    nr,
+		tentativaLoginRepositorio: tr,
+		emailServico:              es,
+		carencia:                  carencia,
+	}
+}
+
+// SolicitarExclusao agenda a exclusao da conta e avisa o titular e os usuarios vinculados
+func (s *exclusaoContaServico) SolicitarExclusao(userID uint, dtoIn *dtos.SolicitarExclusaoContaDTOIn) (*dtos.ExclusaoContaDTOOut, error) {
+	usuario, err := s.usuarioRepositorio.BuscarUsuarioPorID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrUsuarioNaoEncontrado
+		}
+		return nil, err
+	}
+
+	exclusao := dominio.NovaExclusaoConta(userID, dtoIn.Motivo, time.Now(), s.carencia)
+	aviso := fmt.Sprintf(
+		"%s solicitou a exclusao da conta. O vinculo e os dados pessoais serao removidos em %s, salvo cancelamento.",
+		usuario.Nome, exclusao.DataExecucao.Format("02/01/2006"))
+	var vinculados []*dominio.Usuario
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		_, err := s.exclusaoRepositorio.BuscarExclusaoAgendada(tx, userID)
+		if err == nil {
+			return dominio.ErrExclusaoJaAgendada
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := s.exclusaoRepositorio.CriarExclusao(tx, exclusao); err != nil {
+			return err
+		}
+
+		vinculados, err = s.exclusaoRepositorio.ListarUsuariosVinculados(tx, userID)
+		if err != nil {
+			return err
+		}
+		return s.notificarVinculados(tx, vinculados, aviso)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.avisarVinculadosPorEmail(vinculados, aviso)
+
+	s.enviarEmail(usuario.Email, "MindTrace - Exclusao de conta agendada", fmt.Sprintf(
+		"Ola, %s.\n\nRecebemos o pedido de exclusao da sua conta. Ela sera removida em %s.\n\nAte la, voce pode cancelar o pedido entrando no MindTrace: %s\n\nSe quiser guardar uma copia dos seus dados, solicite a exportacao antes dessa data.",
+		usuario.Nome, exclusao.DataExecucao.Format("02/01/2006"), urlFrontend()))
+
+	return mappers.ExclusaoContaParaDTOOut(exclusao), nil
+}
+
+// BuscarExclusao retorna a exclusao agendada do usuario
+func (s *exclusaoContaServico) BuscarExclusao(userID uint) (*dtos.ExclusaoContaDTOOut, error) {
+	exclusao, err := s.exclusaoRepositorio.BuscarExclusaoAgendada(s.db, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrExclusaoNaoAgendada
+		}
+		return nil, err
+	}
+	return mappers.ExclusaoContaParaDTOOut(exclusao), nil
+}
+
+// CancelarExclusao desiste da exclusao durante o periodo de carencia
+func (s *exclusaoContaServico) CancelarExclusao(userID uint) error {
+	usuario, err := s.usuarioRepositorio.BuscarUsuarioPorID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dominio.ErrUsuarioNaoEncontrado
+		}
+		return err
+	}
+
+	aviso := fmt.Sprintf("%s cancelou a exclusao da conta. O vinculo continua ativo.", usuario.Nome)
+	var vinculados []*dominio.Usuario
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		exclusao, err := s.exclusaoRepositorio.BuscarExclusaoAgendada(tx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrExclusaoNaoAgendada
+			}
+			return err
+		}
+		if err := exclusao.Cancelar(time.Now()); err != nil {
+			return err
+		}
+		if err := s.exclusaoRepositorio.AtualizarExclusao(tx, exclusao); err != nil {
+			return err
+		}
+
+		vinculados, err = s.exclusaoRepositorio.ListarUsuariosVinculados(tx, userID)
+		if err != nil {
+			return err
+		}
+		return s.notificarVinculados(tx, vinculados, aviso)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.avisarVinculadosPorEmail(vinculados, aviso)
+	return nil
+}
+
+// ExecutarExclusoesVencidas anonimiza as contas cujo periodo de carencia terminou
+// Cada conta e processada em sua propria transacao para que uma falha nao bloqueie as demais
+func (s *exclusaoContaServico) ExecutarExclusoesVencidas() error {
+	exclusoes, err := s.exclusaoRepositorio.ListarExclusoesVencidas(s.db, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, exclusao := range exclusoes {
+		if err := s.executarExclusao(exclusao); err != nil {
+			log.Printf("falha ao excluir a conta do usuario %d: %v", exclusao.UsuarioID, err)
+		}
+	}
+	return nil
+}
+
+// executarExclusao anonimiza o titular, revoga tokens e convites e avisa os vinculados
+func (s *exclusaoContaServico) executarExclusao(exclusao *dominio.ExclusaoConta) error {
+	usuario, err := s.usuarioRepositorio.BuscarUsuarioPorID(exclusao.UsuarioID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	senhaInutilizavel, err := gerarTokenAleatorio(32)
+	if err != nil {
+		return err
+	}
+
+	var arquivos []string
+	var emailOriginal, aviso string
+	var vinculados []*dominio.Usuario
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if usuario != nil {
+			emailOriginal = usuario.Email
+			aviso = fmt.Sprintf("A conta de %s foi excluida. Os dados pessoais foram removidos e o vinculo foi encerrado.", usuario.Nome)
+
+			// Os vinculos sao desfeitos na anonimizacao, por isso o aviso vem antes
+			vinculados, err = s.exclusaoRepositorio.ListarUsuariosVinculados(tx, usuario.ID)
+			if err != nil {
+				return err
+			}
+			if err := s.notificarVinculados(tx, vinculados, aviso); err != nil {
+				return err
+			}
+
+			// A senha aleatoria nao e um hash bcrypt valido, entao nenhuma senha confere
+			usuario.Anonimizar("!" + senhaInutilizavel)
+			arquivos, err = s.exclusaoRepositorio.AnonimizarTitular(tx, usuario, emailOriginal)
+			if err != nil {
+				return err
+			}
+		}
+
+		exclusao.Concluir(time.Now())
+		return s.exclusaoRepositorio.AtualizarExclusao(tx, exclusao)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.avisarVinculadosPorEmail(vinculados, aviso)
+	for _, arquivo := range arquivos {
+		if err := os.Remove(arquivo); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("falha ao remover exportacao do usuario %d: %v", exclusao.UsuarioID, err)
+		}
+	}
+	if emailOriginal != "" {
+		if err := s.tentativaLoginRepositorio.RemoverTentativa(dominio.ChaveTentativa(dominio.TipoTentativaConta, emailOriginal)); err != nil {
+			log.Printf("falha ao remover tentativas de login do usuario %d: %v", exclusao.UsuarioID, err)
+		}
+		s.enviarEmail(emailOriginal, "MindTrace - Conta excluida",
+			"Sua conta no MindTrace foi excluida e os seus dados pessoais foram removidos.\n\nRegistros clinicos exigidos por lei foram mantidos sem identificacao.")
+	}
+	return nil
+}
+
+// notificarVinculados registra uma notificacao para cada usuario vinculado
+func (s *exclusaoContaServico) notificarVinculados(tx *gorm.DB, vinculados []*dominio.Usuario, conteudo string) error {
+	for _, vinculado := range vinculados {
+		notificacao := &dominio.Notificacao{
+			UsuarioID: vinculado.ID,
+			Conteudo:  conteudo,
+			Status:    dominio.NotificacaoNaoLida,
+			DataEnvio: time.Now(),
+		}
+		if err := s.notificacaoRepositorio.CriarNotificacao(tx, notificacao); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// avisarVinculadosPorEmail repete o aviso por e-mail apos a confirmacao da transacao
+func (s *exclusaoContaServico) avisarVinculadosPorEmail(vinculados []*dominio.Usuario, conteudo string) {
+	for _, vinculado := range vinculados {
+		s.enviarEmail(vinculado.Email, "MindTrace - Aviso sobre um vinculo", conteudo)
+	}
+}
+
+// enviarEmail registra falhas de envio sem interromper o fluxo
+func (s *exclusaoContaServico) enviarEmail(para, assunto, corpo string) {
+	if err := s.emailServico.Enviar(MensagemEmail{Para: para, Assunto: assunto, Corpo: corpo}); err != nil {
+		log.Printf("falha ao enviar e-mail de exclusao de conta: %v", err)
+	}
+}
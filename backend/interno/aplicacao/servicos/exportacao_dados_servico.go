@@ -0,0 +1,377 @@
+package servicos
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/mappers"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// limiteExportacoesSimultaneas evita que varios pacotes grandes sejam montados ao mesmo tempo
+const limiteExportacoesSimultaneas = 2
+
+// ExportacaoDadosServico define os metodos para exportar os dados do titular (LGPD)
+type ExportacaoDadosServico interface {
+	SolicitarExportacao(userID uint, dtoIn *dtos.SolicitarExportacaoDTOIn) (*dtos.ExportacaoDadosDTOOut, error)
+	BuscarExportacao(userID, exportacaoID uint) (*dtos.ExportacaoDadosDTOOut, error)
+	GerarLinkDownload(userID, exportacaoID uint) (*dtos.ExportacaoDadosDTOOut, error)
+	AbrirDownload(token string) (caminho string, nomeArquivo string, err error)
+	GerarPacote(userID uint, formato string, w io.Writer) error
+	RetomarExportacoesPendentes() error
+	LimparExportacoesExpiradas() error
+}
+
+// exportacaoDadosServico implementa a interface ExportacaoDadosServico
+type exportacaoDadosServico struct {
+	db                    *gorm.DB
+	exportacaoRepositorio repositorios.ExportacaoDadosRepositorio
+	usuarioRepositorio    repositorios.UsuarioRepositorio
+	emailServico          EmailServico
+	diretorio             string
+	processamentoSemaforo chan struct{}
+}
+
+// NovoExportacaoDadosServico cria uma nova instancia de ExportacaoDadosServico
+// Os pacotes sao gravados em diretorio; vazio usa uma pasta no diretorio temporario do sistema
+func NovoExportacaoDadosServico(db *gorm.DB, er repositorios.ExportacaoDadosRepositorio, ur repositorios.UsuarioRepositorio, es EmailServico, diretorio string) ExportacaoDadosServico {
+	if diretorio == "" {
+		diretorio = filepath.Join(os.TempDir(), "mindtrace-exportacoes")
+	}
+	return &exportacaoDadosServico{
+		db:                    db,
+		exportacaoRepositorio: er,
+		usuarioRepositorio:    ur,
+		emailServico:          es,
+		diretorio:             diretorio,
+		processamentoSemaforo: make(chan struct{}, limiteExportacoesSimultaneas),
+	}
+}
+
+// SolicitarExportacao registra o pedido e inicia a geracao do pacote em segundo plano
+// Apenas uma exportacao por usuario pode estar em andamento
+func (s *exportacaoDadosServico) SolicitarExportacao(userID uint, dtoIn *dtos.SolicitarExportacaoDTOIn) (*dtos.ExportacaoDadosDTOOut, error) {
+	formato := dtoIn.Formato
+	if formato == "" {
+		formato = dominio.FormatoExportacaoZIP
+	}
+	if err := dominio.ValidarFormatoExportacao(formato); err != nil {
+		return nil, err
+	}
+
+	exportacao := &dominio.ExportacaoDados{
+		UsuarioID: userID,
+		Formato:   formato,
+		Status:    dominio.StatusExportacaoPendente,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		_, err := s.exportacaoRepositorio.BuscarExportacaoEmAndamento(tx, userID)
+		if err == nil {
+			return dominio.ErrExportacaoEmAndamento
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		return s.exportacaoRepositorio.CriarExportacao(tx, exportacao)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go s.processar(exportacao.ID)
+
+	return mappers.ExportacaoDadosParaDTOOut(exportacao), nil
+}
+
+// BuscarExportacao retorna o andamento de uma exportacao do proprio usuario
+func (s *exportacaoDadosServico) BuscarExportacao(userID, exportacaoID uint) (*dtos.ExportacaoDadosDTOOut, error) {
+	exportacao, err := s.buscarExportacaoDoUsuario(s.db, userID, exportacaoID)
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ExportacaoDadosParaDTOOut(exportacao), nil
+}
+
+// GerarLinkDownload emite um novo link para um pacote concluido
+// O link anterior deixa de funcionar; a validade continua sendo a do arquivo
+func (s *exportacaoDadosServico) GerarLinkDownload(userID, exportacaoID uint) (*dtos.ExportacaoDadosDTOOut, error) {
+	token, err := gerarTokenAleatorio(32)
+	if err != nil {
+		return nil, err
+	}
+
+	var exportacao *dominio.ExportacaoDados
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		exportacao, err = s.buscarExportacaoDoUsuario(tx, userID, exportacaoID)
+		if err != nil {
+			return err
+		}
+		if err := exportacao.VerificarDownload(time.Now()); err != nil {
+			return err
+		}
+		exportacao.TokenDownloadHash = hashToken(token)
+		return s.exportacaoRepositorio.AtualizarExportacao(tx, exportacao)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dtoOut := mappers.ExportacaoDadosParaDTOOut(exportacao)
+	dtoOut.UrlDownload = urlDownloadExportacao(token)
+	return dtoOut, nil
+}
+
+// AbrirDownload valida o token do link e retorna o arquivo a ser entregue
+func (s *exportacaoDadosServico) AbrirDownload(token string) (string, string, error) {
+	if token == "" {
+		return "", "", dominio.ErrLinkDownloadInvalido
+	}
+	exportacao, err := s.exportacaoRepositorio.BuscarExportacaoPorTokenHash(s.db, hashToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", "", dominio.ErrLinkDownloadInvalido
+		}
+		return "", "", err
+	}
+	if err := exportacao.VerificarDownload(time.Now()); err != nil {
+		return "", "", err
+	}
+	return exportacao.CaminhoArquivo, filepath.Base(exportacao.CaminhoArquivo), nil
+}
+
+// GerarPacote monta o pacote com todos os dados do usuario e o grava em w
+// No formato json o manifesto e os dados ficam em um unico documento; no zip cada secao vira um arquivo
+// O sha256 de cada secao e calculado sobre o seu json compacto, exatamente como gravado no pacote
+func (s *exportacaoDadosServico) GerarPacote(userID uint, formato string, w io.Writer) error {
+	if err := dominio.ValidarFormatoExportacao(formato); err != nil {
+		return err
+	}
+
+	dados, err := s.exportacaoRepositorio.BuscarDadosTitular(s.db, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dominio.ErrUsuarioNaoEncontrado
+		}
+		return err
+	}
+
+	manifesto := dtos.ManifestoExportacaoDTOOut{
+		Versao:      dominio.VersaoManifestoExportacao,
+		GeradoEm:    time.Now().UTC(),
+		UsuarioID:   dados.Usuario.ID,
+		TipoUsuario: dominio.TipoUsuarioParaString(dados.Usuario.TipoUsuario),
+		Formato:     formato,
+	}
+	conteudos := make(map[string]json.RawMessage)
+
+	// Respostas de questionarios pertencem ao paciente; o profissional recebe apenas os metadados
+	incluirRespostas := dados.Paciente != nil
+	vinculos := mappers.VinculosParaExportacaoDTOOut(dados)
+	secoes := []struct {
+		nome      string
+		descricao string
+		registros int
+		conteudo  any
+	}{
+		{"titular", "Dados cadastrais e perfil do titular", 1, mappers.TitularParaExportacaoDTOOut(dados)},
+		{"vinculos", "Vinculos entre profissional e paciente", len(vinculos), vinculos},
+		{"registros_humor", "Registros diarios de humor", len(dados.RegistrosHumor), mappers.RegistrosHumorParaDTOOut(dados.RegistrosHumor)},
+		{"revisoes_registros_humor", "Valores anteriores de registros de humor corrigidos ou apagados", len(dados.RevisoesRegistrosHumor), mappers.RevisoesRegistroHumorParaDTOOut(dados.RevisoesRegistrosHumor)},
+		{"valores_metricas", "Valores das metricas personalizadas nos registros de humor", len(dados.ValoresMetricas), mappers.ValoresMetricasParaExportacaoDTOOut(dados.ValoresMetricas)},
+		{"atribuicoes", "Questionarios atribuidos e respectivas respostas", len(dados.Atribuicoes), mappers.AtribuicoesParaExportacaoDTOOut(dados.Atribuicoes, incluirRespostas)},
+		{"convites", "Convites gerados ou utilizados", len(dados.Convites), mappers.ConvitesParaDTOOut(dados.Convites)},
+		{"consentimentos", "Todas as versoes dos consentimentos de compartilhamento", len(dados.Consentimentos), mappers.ConsentimentosParaDTOOut(dados.Consentimentos, manifesto.GeradoEm)},
+		{"encerramentos_vinculo", "Historico de vinculos encerrados e motivos informados", len(dados.EncerramentosVinculo), mappers.EncerramentosVinculoParaDTOOut(dados.EncerramentosVinculo)},
+		{"consultas", "Consultas agendadas, realizadas ou canceladas", len(dados.Consultas), mappers.ConsultasParaDTOOut(dados.Consultas)},
+		{"notificacoes", "Notificacoes recebidas", len(dados.Notificacoes), mappers.NotificacoesParaExportacaoDTOOut(dados.Notificacoes)},
+		{"acessos_dados", "Acessos de outros usuarios aos dados do paciente", len(dados.AcessosDados), mappers.RegistrosAuditoriaParaAcessosDTOOut(dados.AcessosDados)},
+	}
+
+	for _, secao := range secoes {
+		conteudo, err := json.Marshal(secao.conteudo)
+		if err != nil {
+			return err
+		}
+		soma := sha256.Sum256(conteudo)
+		dtoSecao := dtos.SecaoExportacaoDTOOut{
+			Nome:      secao.nome,
+			Descricao: secao.descricao,
+			Registros: secao.registros,
+			SHA256:    hex.EncodeToString(soma[:]),
+		}
+		if formato == dominio.FormatoExportacaoZIP {
+			dtoSecao.Arquivo = secao.nome + ".json"
+		}
+		manifesto.Secoes = append(manifesto.Secoes, dtoSecao)
+		conteudos[secao.nome] = conteudo
+	}
+
+	if formato == dominio.FormatoExportacaoJSON {
+		return json.NewEncoder(w).Encode(dtos.PacoteExportacaoDTOOut{Manifesto: manifesto, Dados: conteudos})
+	}
+
+	arquivoZip := zip.NewWriter(w)
+	manifestoJSON, err := json.MarshalIndent(manifesto, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := escreverArquivoZip(arquivoZip, "manifesto.json", manifestoJSON); err != nil {
+		return err
+	}
+	for _, secao := range manifesto.Secoes {
+		if err := escreverArquivoZip(arquivoZip, secao.Arquivo, conteudos[secao.Nome]); err != nil {
+			return err
+		}
+	}
+	return arquivoZip.Close()
+}
+
+// RetomarExportacoesPendentes reprocessa pedidos interrompidos por uma reinicializacao
+func (s *exportacaoDadosServico) RetomarExportacoesPendentes() error {
+	exportacoes, err := s.exportacaoRepositorio.ListarExportacoesPendentes(s.db)
+	if err != nil {
+		return err
+	}
+	for _, exportacao := range exportacoes {
+		go s.processar(exportacao.ID)
+	}
+	return nil
+}
+
+// LimparExportacoesExpiradas remove os arquivos cuja validade terminou
+// O registro e mantido como historico do atendimento ao pedido
+func (s *exportacaoDadosServico) LimparExportacoesExpiradas() error {
+	exportacoes, err := s.exportacaoRepositorio.ListarExportacoesExpiradas(s.db, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, exportacao := range exportacoes {
+		if err := os.Remove(exportacao.CaminhoArquivo); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("falha ao remover exportacao %d: %v", exportacao.ID, err)
+			continue
+		}
+		exportacao.CaminhoArquivo = ""
+		exportacao.TokenDownloadHash = ""
+		if err := s.exportacaoRepositorio.AtualizarExportacao(s.db, exportacao); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processar gera o arquivo de uma exportacao pendente e envia o link por e-mail
+func (s *exportacaoDadosServico) processar(exportacaoID uint) {
+	s.processamentoSemaforo <- struct{}{}
+	defer func() { <-s.processamentoSemaforo }()
+
+	exportacao, err := s.exportacaoRepositorio.BuscarExportacaoPorID(s.db, exportacaoID)
+	if err != nil || !exportacao.EmAndamento() {
+		return
+	}
+	exportacao.IniciarProcessamento()
+	if err := s.exportacaoRepositorio.AtualizarExportacao(s.db, exportacao); err != nil {
+		log.Printf("falha ao iniciar exportacao %d: %v", exportacao.ID, err)
+		return
+	}
+
+	token, err := s.gerarArquivo(exportacao)
+	if err != nil {
+		log.Printf("falha ao gerar exportacao %d: %v", exportacao.ID, err)
+		exportacao.Falhar("nao foi possivel gerar o pacote de dados")
+		if err := s.exportacaoRepositorio.AtualizarExportacao(s.db, exportacao); err != nil {
+			log.Printf("falha ao registrar erro da exportacao %d: %v", exportacao.ID, err)
+		}
+		return
+	}
+
+	usuario, err := s.usuarioRepositorio.BuscarUsuarioPorID(exportacao.UsuarioID)
+	if err != nil {
+		log.Printf("falha ao buscar titular da exportacao %d: %v", exportacao.ID, err)
+		return
+	}
+	err = s.emailServico.Enviar(MensagemEmail{
+		Para:    usuario.Email,
+		Assunto: "MindTrace - Sua exportacao de dados esta pronta",
+		Corpo: fmt.Sprintf(
+			"Ola, %s.\n\nO pacote com os seus dados esta pronto. Baixe-o pelo link abaixo:\n\n%s\n\nO link expira em %d horas. Se voce nao fez este pedido, altere sua senha.",
+			usuario.Nome, urlDownloadExportacao(token), int(dominio.ValidadeExportacao.Hours())),
+	})
+	if err != nil {
+		log.Printf("falha ao enviar link da exportacao %d: %v", exportacao.ID, err)
+	}
+}
+
+// gerarArquivo grava o pacote em disco e conclui a exportacao, retornando o token do link
+func (s *exportacaoDadosServico) gerarArquivo(exportacao *dominio.ExportacaoDados) (string, error) {
+	if err := os.MkdirAll(s.diretorio, 0o700); err != nil {
+		return "", err
+	}
+	token, err := gerarTokenAleatorio(32)
+	if err != nil {
+		return "", err
+	}
+
+	nomeArquivo := fmt.Sprintf("mindtrace-dados-%d-%s.%s", exportacao.UsuarioID, time.Now().Format("20060102150405"), exportacao.Formato)
+	caminho := filepath.Join(s.diretorio, nomeArquivo)
+	arquivo, err := os.OpenFile(caminho, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", err
+	}
+	if err := s.GerarPacote(exportacao.UsuarioID, exportacao.Formato, arquivo); err != nil {
+		arquivo.Close()
+		os.Remove(caminho)
+		return "", err
+	}
+	if err := arquivo.Close(); err != nil {
+		os.Remove(caminho)
+		return "", err
+	}
+
+	exportacao.Concluir(caminho, hashToken(token), time.Now())
+	if err := s.exportacaoRepositorio.AtualizarExportacao(s.db, exportacao); err != nil {
+		os.Remove(caminho)
+		return "", err
+	}
+	return token, nil
+}
+
+// buscarExportacaoDoUsuario garante que a exportacao pertence ao usuario autenticado
+func (s *exportacaoDadosServico) buscarExportacaoDoUsuario(tx *gorm.DB, userID, exportacaoID uint) (*dominio.ExportacaoDados, error) {
+	exportacao, err := s.exportacaoRepositorio.BuscarExportacaoPorID(tx, exportacaoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrExportacaoNaoEncontrada
+		}
+		return nil, err
+	}
+	if exportacao.UsuarioID != userID {
+		return nil, dominio.ErrExportacaoNaoEncontrada
+	}
+	return exportacao, nil
+}
+
+func escreverArquivoZip(arquivoZip *zip.Writer, nome string, conteudo []byte) error {
+	f, err := arquivoZip.Create(nome)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(conteudo)
+	return err
+}
+
+// urlDownloadExportacao monta o link publico de download do pacote
+func urlDownloadExportacao(token string) string {
+	return fmt.Sprintf("%s/api/v1/exportacoes/download?token=%s", urlFrontend(), token)
+}
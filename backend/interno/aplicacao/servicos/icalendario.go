@@ -0,0 +1,73 @@
+package servicos
+
+import (
+	"fmt"
+	"mindtrace/backend/interno/dominio"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// formatoDataICS e o formato UTC de data e hora do iCalendar (RFC 5545)
+const formatoDataICS = "20060102T150405Z"
+
+// tamanhoMaximoLinhaICS e o limite de octetos por linha antes da dobra
+const tamanhoMaximoLinhaICS = 75
+
+// gerarICalendario monta o feed .ics da agenda do profissional
+// Consultas canceladas seguem no feed com STATUS:CANCELLED para sumirem dos calendarios ja sincronizados
+func gerarICalendario(consultas []*dominio.Consulta, agora time.Time) []byte {
+	var b strings.Builder
+	escreverLinhaICS(&b, "BEGIN:VCALENDAR")
+	escreverLinhaICS(&b, "VERSION:2.0")
+	escreverLinhaICS(&b, "PRODID:-//MindTrace//Agenda//PT-BR")
+	escreverLinhaICS(&b, "CALSCALE:GREGORIAN")
+	escreverLinhaICS(&b, "METHOD:PUBLISH")
+	escreverLinhaICS(&b, "X-WR-CALNAME:MindTrace")
+
+	for _, c := range consultas {
+		status := "CONFIRMED"
+		if c.Status == dominio.StatusConsultaCancelada {
+			status = "CANCELLED"
+		}
+		escreverLinhaICS(&b, "BEGIN:VEVENT")
+		escreverLinhaICS(&b, fmt.Sprintf("UID:consulta-%d@mindtrace", c.ID))
+		escreverLinhaICS(&b, "DTSTAMP:"+agora.UTC().Format(formatoDataICS))
+		escreverLinhaICS(&b, "DTSTART:"+c.Inicio.UTC().Format(formatoDataICS))
+		escreverLinhaICS(&b, "DTEND:"+c.Fim.UTC().Format(formatoDataICS))
+		escreverLinhaICS(&b, "LAST-MODIFIED:"+c.UpdatedAt.UTC().Format(formatoDataICS))
+		escreverLinhaICS(&b, "SUMMARY:"+escaparTextoICS("Sessao - "+c.Paciente.Usuario.Nome))
+		escreverLinhaICS(&b, "DESCRIPTION:"+escaparTextoICS(fmt.Sprintf("Modalidade: %s\nStatus: %s", c.Modalidade, c.Status)))
+		if c.Local != "" {
+			escreverLinhaICS(&b, "LOCATION:"+escaparTextoICS(c.Local))
+		}
+		escreverLinhaICS(&b, "STATUS:"+status)
+		escreverLinhaICS(&b, "END:VEVENT")
+	}
+
+	escreverLinhaICS(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// escaparTextoICS escapa os caracteres reservados dos campos de texto
+func escaparTextoICS(texto string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(texto)
+}
+
+// escreverLinhaICS grava a linha terminada em CRLF, dobrando-a a cada 75 octetos sem partir caracteres UTF-8
+func escreverLinhaICS(b *strings.Builder, linha string) {
+	limite := tamanhoMaximoLinhaICS
+	for len(linha) > limite {
+		corte := limite
+		for corte > 0 && !utf8.RuneStart(linha[corte]) {
+			corte--
+		}
+		b.WriteString(linha[:corte])
+		b.WriteString("\r\n ")
+		linha = linha[corte:]
+		// As linhas seguintes comecam com o espaco da dobra
+		limite = tamanhoMaximoLinhaICS - 1
+	}
+	b.WriteString(linha)
+	b.WriteString("\r\n")
+}
@@ -8,6 +8,7 @@ import (
 	"mindtrace/backend/interno/aplicacao/mappers"
 	"mindtrace/backend/interno/dominio"
 	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -19,19 +20,25 @@ type InstrumentoServico interface {
 	ListarAtribuicoesPaciente(pacId uint) ([]*dtos.AtribuicaoDTOOut, error)
 	ListarPerguntasAtribuicao(usuarioId, atribuicaoId uint) (*dtos.AtribuicaoDTOOut, error)
 	CriarRespostasAtribuicao(dto *dtos.RegistroRespostaDTOIn) error
-	VisualizarRespostaAtribuicao(atribuicaoId uint) (*dtos.RespostaDetalhadaDTOOut, error)
+	VisualizarRespostaAtribuicao(usuarioId, atribuicaoId uint) (*dtos.RespostaDetalhadaDTOOut, error)
 }
 type instrumentoServico struct {
-	db              *gorm.DB
-	instrumentoRepo repositorios.InstrumentoRepositorio
-	usuarioRepo     repositorios.UsuarioRepositorio
+	db                *gorm.DB
+	instrumentoRepo   repositorios.InstrumentoRepositorio
+	usuarioRepo       repositorios.UsuarioRepositorio
+	consentimentoRepo repositorios.ConsentimentoRepositorio
+	responsavelRepo   repositorios.ResponsavelRepositorio
+	notificacaoRepo   repositorios.NotificacaoRepositorio
 }
 
-func NovoInstrumentoServico(db *gorm.DB, instrumentoRepo repositorios.InstrumentoRepositorio, usuarioRepo repositorios.UsuarioRepositorio) InstrumentoServico {
+func NovoInstrumentoServico(db *gorm.DB, instrumentoRepo repositorios.InstrumentoRepositorio, usuarioRepo repositorios.UsuarioRepositorio, consentimentoRepo repositorios.ConsentimentoRepositorio, responsavelRepo repositorios.ResponsavelRepositorio, notificacaoRepo repositorios.NotificacaoRepositorio) InstrumentoServico {
 	return &instrumentoServico{
-		db:              db,
-		instrumentoRepo: instrumentoRepo,
-		usuarioRepo:     usuarioRepo,
+		db:                db,
+		instrumentoRepo:   instrumentoRepo,
+		usuarioRepo:       usuarioRepo,
+		consentimentoRepo: consentimentoRepo,
+		responsavelRepo:   responsavelRepo,
+		notificacaoRepo:   notificacaoRepo,
 	}
 }
 
@@ -88,6 +95,11 @@ func (is *instrumentoServico) CriarAtribuicao(userID, pacienteID, instrumentoID
 			return err
 		}
 
+		// Questionarios so podem ser enviados a pacientes que os compartilham com o profissional
+		if _, err := verificarConsentimento(tx, is.consentimentoRepo, paciente.ID, profissional.ID, dominio.CategoriaQuestionarios); err != nil {
+			return err
+		}
+
 		instrumento, err := is.instrumentoRepo.BuscarInstrumentoPorID(tx, instrumentoID)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -112,7 +124,10 @@ func (is *instrumentoServico) CriarAtribuicao(userID, pacienteID, instrumentoID
 		if err = atribuicao.Validar(); err != nil {
 			return err
 		}
-		return nil
+
+		// O responsavel pode responder em nome do dependente
+		return notificarResponsaveis(tx, is.responsavelRepo, is.notificacaoRepo, paciente.ID,
+			fmt.Sprintf("%%s recebeu o questionario %s para responder.", instrumento.Nome))
 
 	})
 
@@ -158,13 +173,28 @@ func (is *instrumentoServico) ListarAtribuicoesProfissional(usuarioId uint) ([]*
 		return nil, err
 	}
 
+	consentimentos, err := is.consentimentoRepo.ListarConsentimentosAtuaisDoProfissional(is.db, profissional.ID)
+	if err != nil {
+		return nil, err
+	}
+	consentimentoPorPaciente := make(map[uint]*dominio.Consentimento, len(consentimentos))
+	for _, consentimento := range consentimentos {
+		consentimentoPorPaciente[consentimento.PacienteID] = consentimento
+	}
+
+	// Mantem apenas as atribuicoes cobertas pelo consentimento vigente de cada paciente
+	agora := time.Now()
+	visiveis := make([]*dominio.Atribuicao, 0, len(atribuicoes))
 	for _, atribc := range atribuicoes {
 		if err = atribc.Validar(); err != nil {
 			return nil, err
 		}
+		if atribuicaoCompartilhada(consentimentoPorPaciente[atribc.PacienteID], atribc, agora) {
+			visiveis = append(visiveis, atribc)
+		}
 	}
 
-	return mappers.AtribuicoesParaDTOOutProfissional(atribuicoes), nil
+	return mappers.AtribuicoesParaDTOOutProfissional(visiveis), nil
 }
 
 func (is *instrumentoServico) ListarPerguntasAtribuicao(usuarioId, atribuicaoId uint) (*dtos.AtribuicaoDTOOut, error) {
@@ -198,6 +228,9 @@ func (is *instrumentoServico) CriarRespostasAtribuicao(dto *dtos.RegistroRespost
 		if err != nil {
 			return err
 		}
+		if atribuicao.Status == dominio.StatusCancelado {
+			return dominio.ErrAtribuicaoCancelada
+		}
 		classificacao := ""
 		novoRegistroResposta, err := mappers.CriarRegistroRespostasDTOInParaEntidade(dto, atribuicao.ID, classificacao)
 		if err != nil {
@@ -213,7 +246,7 @@ func (is *instrumentoServico) CriarRespostasAtribuicao(dto *dtos.RegistroRespost
 	return err
 }
 
-func (is *instrumentoServico) VisualizarRespostaAtribuicao(atribuicaoId uint) (*dtos.RespostaDetalhadaDTOOut, error) {
+func (is *instrumentoServico) VisualizarRespostaAtribuicao(usuarioId, atribuicaoId uint) (*dtos.RespostaDetalhadaDTOOut, error) {
 
 	var resposta *dominio.Resposta
 	var dadosBrutos []map[string]any
@@ -225,6 +258,10 @@ func (is *instrumentoServico) VisualizarRespostaAtribuicao(atribuicaoId uint) (*
 			return err
 		}
 
+		if err := is.autorizarLeituraResposta(tx, usuarioId, &resposta.Atribuicao); err != nil {
+			return err
+		}
+
 		if err := json.Unmarshal(resposta.DadosBrutos, &dadosBrutos); err != nil {
 			return err
 		}
@@ -240,5 +277,45 @@ func (is *instrumentoServico) VisualizarRespostaAtribuicao(atribuicaoId uint) (*
 		return nil
 	})
 
-	return mappers.RespostaDetalhadaDTOOut(resposta, dadosBrutos, dadosProcessados), err
+	if err != nil {
+		return nil, err
+	}
+	return mappers.RespostaDetalhadaDTOOut(resposta, dadosBrutos, dadosProcessados), nil
+}
+
+// autorizarLeituraResposta libera as respostas ao proprio paciente e ao profissional da atribuicao,
+// este ultimo apenas enquanto o paciente compartilhar questionarios do periodo da resposta
+func (is *instrumentoServico) autorizarLeituraResposta(tx *gorm.DB, usuarioId uint, atribuicao *dominio.Atribuicao) error {
+	if atribuicao.Paciente.UsuarioID == usuarioId {
+		return nil
+	}
+
+	profissional, err := is.usuarioRepo.BuscarProfissionalPorUsuarioID(tx, usuarioId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dominio.ErrVinculoNaoEncontrado
+		}
+		return err
+	}
+	if profissional.ID != atribuicao.ProfissionalID {
+		return dominio.ErrVinculoNaoEncontrado
+	}
+
+	consentimento, err := verificarConsentimento(tx, is.consentimentoRepo, atribuicao.PacienteID, profissional.ID, dominio.CategoriaQuestionarios)
+	if err != nil {
+		return err
+	}
+	if !atribuicaoCompartilhada(consentimento, atribuicao, time.Now()) {
+		return dominio.ErrConsentimentoNegado
+	}
+	return nil
+}
+
+// atribuicaoCompartilhada indica se o consentimento libera a atribuicao ao profissional
+// Respostas anteriores a data de inicio do consentimento ficam ocultas
+func atribuicaoCompartilhada(consentimento *dominio.Consentimento, atribuicao *dominio.Atribuicao, agora time.Time) bool {
+	if consentimento == nil || !consentimento.Permite(dominio.CategoriaQuestionarios, agora) {
+		return false
+	}
+	return atribuicao.DataResposta == nil || consentimento.CobreData(*atribuicao.DataResposta)
 }
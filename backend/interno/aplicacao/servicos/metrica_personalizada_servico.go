@@ -0,0 +1,139 @@
+package servicos
+
+import (
+	"errors"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/mappers"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+
+	"gorm.io/gorm"
+)
+
+// MetricaPersonalizadaServico gerencia as metricas do diario definidas pelos profissionais para cada paciente
+type MetricaPersonalizadaServico interface {
+	// ListarMetricas retorna as metricas ativas do paciente, para montar o formulario do diario
+	ListarMetricas(userID uint, tipoUsuario string, pacienteID uint) ([]*dtos.MetricaPersonalizadaDTOOut, error)
+	CriarMetrica(userID, pacienteID uint, dto *dtos.CriarMetricaPersonalizadaDTOIn) (*dtos.MetricaPersonalizadaDTOOut, error)
+	// DesativarMetrica retira a metrica do formulario; os valores ja registrados continuam nos graficos
+	DesativarMetrica(userID, metricaID uint) error
+}
+
+type metricaPersonalizadaServico struct {
+	db          *gorm.DB
+	usuarioRepo repositorios.UsuarioRepositorio
+	vinculoRepo repositorios.VinculoRepositorio
+	metricaRepo repositorios.MetricaPersonalizadaRepositorio
+}
+
+func NovoMetricaPersonalizadaServico(db *gorm.DB, ur repositorios.UsuarioRepositorio, vr repositorios.VinculoRepositorio, mpr repositorios.MetricaPersonalizadaRepositorio) MetricaPersonalizadaServico {
+	return &metricaPersonalizadaServico{
+		db:          db,
+		usuarioRepo: ur,
+		vinculoRepo: vr,
+		metricaRepo: mpr,
+	}
+}
+
+func (s *metricaPersonalizadaServico) ListarMetricas(userID uint, tipoUsuario string, pacienteID uint) ([]*dtos.MetricaPersonalizadaDTOOut, error) {
+	if dominio.StringParaTipoUsuario(tipoUsuario) == dominio.TipoUsuarioPaciente {
+		paciente, err := buscarPacienteDoUsuario(s.db, s.usuarioRepo, userID)
+		if err != nil {
+			return nil, err
+		}
+		pacienteID = paciente.ID
+	} else if _, err := s.buscarProfissionalVinculado(s.db, userID, pacienteID); err != nil {
+		return nil, err
+	}
+
+	metricas, err := s.metricaRepo.ListarMetricasAtivasDoPaciente(s.db, pacienteID)
+	if err != nil {
+		return nil, err
+	}
+	return mappers.MetricasPersonalizadasParaDTOOut(metricas), nil
+}
+
+// CriarMetrica exige vinculo ativo com o paciente
+// O rotulo nao pode repetir o de outra metrica ativa do paciente, de qualquer profissional
+func (s *metricaPersonalizadaServico) CriarMetrica(userID, pacienteID uint, dto *dtos.CriarMetricaPersonalizadaDTOIn) (*dtos.MetricaPersonalizadaDTOOut, error) {
+	var minimo, maximo float64
+	if dto.Minimo != nil {
+		minimo = *dto.Minimo
+	}
+	if dto.Maximo != nil {
+		maximo = *dto.Maximo
+	}
+
+	var metrica *dominio.MetricaPersonalizada
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		profissional, err := s.buscarProfissionalVinculado(tx, userID, pacienteID)
+		if err != nil {
+			return err
+		}
+		if metrica, err = dominio.NovaMetricaPersonalizada(pacienteID, profissional.ID, dto.Rotulo, dto.Tipo, minimo, maximo); err != nil {
+			return err
+		}
+
+		ativas, err := s.metricaRepo.ListarMetricasAtivasDoPaciente(tx, pacienteID)
+		if err != nil {
+			return err
+		}
+		if len(ativas) >= dominio.LimiteMetricasPersonalizadas {
+			return dominio.ErrLimiteMetricasPersonalizadas
+		}
+		for _, existente := range ativas {
+			if existente.MesmoRotulo(metrica.Rotulo) {
+				return dominio.ErrMetricaPersonalizadaDuplicada
+			}
+		}
+		return s.metricaRepo.CriarMetrica(tx, metrica)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.MetricaPersonalizadaParaDTOOut(metrica), nil
+}
+
+// DesativarMetrica vale para qualquer profissional com vinculo ativo com o paciente da metrica,
+// para que a metrica nao fique presa ao diario quando quem a criou deixa o acompanhamento
+func (s *metricaPersonalizadaServico) DesativarMetrica(userID, metricaID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		metrica, err := s.metricaRepo.BuscarMetricaPorID(tx, metricaID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrMetricaPersonalizadaNaoEncontrada
+			}
+			return err
+		}
+		if _, err := s.buscarProfissionalVinculado(tx, userID, metrica.PacienteID); err != nil {
+			// Metricas de pacientes sem vinculo respondem como inexistentes
+			if errors.Is(err, dominio.ErrVinculoNaoEncontrado) {
+				return dominio.ErrMetricaPersonalizadaNaoEncontrada
+			}
+			return err
+		}
+		if !metrica.Ativa {
+			return dominio.ErrMetricaPersonalizadaNaoEncontrada
+		}
+		metrica.Ativa = false
+		return s.metricaRepo.AtualizarMetrica(tx, metrica)
+	})
+}
+
+func (s *metricaPersonalizadaServico) buscarProfissionalVinculado(tx *gorm.DB, userID, pacienteID uint) (*dominio.Profissional, error) {
+	profissional, err := s.usuarioRepo.BuscarProfissionalPorUsuarioID(tx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrUsuarioNaoEncontrado
+		}
+		return nil, err
+	}
+	vinculo, err := s.vinculoRepo.BuscarVinculo(tx, pacienteID, profissional.ID)
+	if err != nil || !vinculo.Ativo() {
+		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrVinculoNaoEncontrado
+		}
+		return nil, err
+	}
+	return profissional, nil
+}
@@ -0,0 +1,342 @@
+package servicos
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/mappers"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NotaClinicaServico define o prontuario do profissional
+// Apenas o autor le a nota, salvo quando ele a compartilha com outro profissional do paciente
+type NotaClinicaServico interface {
+	CriarNota(userID, pacienteID uint, dtoIn *dtos.NotaClinicaDTOIn) (*dtos.NotaClinicaDTOOut, error)
+	RevisarNota(userID, notaID uint, dtoIn *dtos.NotaClinicaDTOIn) (*dtos.NotaClinicaDTOOut, error)
+	BuscarNota(userID, notaID uint) (*dtos.NotaClinicaDTOOut, error)
+	ListarRevisoes(userID, notaID uint) ([]*dtos.RevisaoNotaClinicaDTOOut, error)
+	ListarNotas(userID, pacienteID uint, filtro *dtos.FiltroNotasClinicasDTOIn) ([]*dtos.NotaClinicaDTOOut, error)
+	CompartilharNota(userID, notaID, profissionalID uint) (*dtos.NotaClinicaDTOOut, error)
+	RevogarCompartilhamento(userID, notaID, profissionalID uint) (*dtos.NotaClinicaDTOOut, error)
+}
+
+// notaClinicaServico implementa a interface NotaClinicaServico
+type notaClinicaServico struct {
+	db          *gorm.DB
+	usuarioRepo repositorios.UsuarioRepositorio
+	vinculoRepo repositorios.VinculoRepositorio
+	notaRepo    repositorios.NotaClinicaRepositorio
+	cifrador    Cifrador
+}
+
+// NovoNotaClinicaServico cria uma nova instancia de NotaClinicaServico
+func NovoNotaClinicaServico(db *gorm.DB, ur repositorios.UsuarioRepositorio, vr repositorios.VinculoRepositorio, nr repositorios.NotaClinicaRepositorio, cifrador Cifrador) NotaClinicaServico {
+	return &notaClinicaServico{db: db, usuarioRepo: ur, vinculoRepo: vr, notaRepo: nr, cifrador: cifrador}
+}
+
+// CriarNota grava a primeira revisao de uma nota sobre um paciente vinculado ao profissional
+func (s *notaClinicaServico) CriarNota(userID, pacienteID uint, dtoIn *dtos.NotaClinicaDTOIn) (*dtos.NotaClinicaDTOOut, error) {
+	var nota *dominio.NotaClinica
+	var profissionalID uint
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		profissional, err := s.buscarProfissional(tx, userID)
+		if err != nil {
+			return err
+		}
+		profissionalID = profissional.ID
+		if !s.vinculoAtivo(tx, pacienteID, profissional.ID) {
+			return dominio.ErrVinculoNaoEncontrado
+		}
+
+		nota = &dominio.NotaClinica{ProfissionalID: profissional.ID, PacienteID: pacienteID}
+		revisao, conteudo, err := novaRevisao(nota, dtoIn)
+		if err != nil {
+			return err
+		}
+		if err := s.notaRepo.CriarNota(tx, nota); err != nil {
+			return err
+		}
+		revisao.NotaID = nota.ID
+		return s.gravarRevisao(tx, nota, revisao, conteudo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.NotaClinicaParaDTOOut(nota, profissionalID), nil
+}
+
+// RevisarNota grava uma nova revisao; as anteriores continuam disponiveis no historico
+func (s *notaClinicaServico) RevisarNota(userID, notaID uint, dtoIn *dtos.NotaClinicaDTOIn) (*dtos.NotaClinicaDTOOut, error) {
+	var nota *dominio.NotaClinica
+	var profissionalID uint
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		profissional, err := s.buscarProfissional(tx, userID)
+		if err != nil {
+			return err
+		}
+		profissionalID = profissional.ID
+		if nota, err = s.buscarNotaDoAutor(tx, profissional.ID, notaID); err != nil {
+			return err
+		}
+
+		revisao, conteudo, err := novaRevisao(nota, dtoIn)
+		if err != nil {
+			return err
+		}
+		return s.gravarRevisao(tx, nota, revisao, conteudo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.NotaClinicaParaDTOOut(nota, profissionalID), nil
+}
+
+// BuscarNota retorna a revisao atual de uma nota visivel ao profissional
+func (s *notaClinicaServico) BuscarNota(userID, notaID uint) (*dtos.NotaClinicaDTOOut, error) {
+	profissional, err := s.buscarProfissional(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	nota, err := s.buscarNotaVisivel(s.db, profissional.ID, notaID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decifrarRevisao(nota.Atual); err != nil {
+		return nil, err
+	}
+	return mappers.NotaClinicaParaDTOOut(nota, profissional.ID), nil
+}
+
+// ListarRevisoes retorna todas as versoes de uma nota visivel ao profissional, da mais recente a mais antiga
+func (s *notaClinicaServico) ListarRevisoes(userID, notaID uint) ([]*dtos.RevisaoNotaClinicaDTOOut, error) {
+	profissional, err := s.buscarProfissional(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.buscarNotaVisivel(s.db, profissional.ID, notaID); err != nil {
+		return nil, err
+	}
+
+	revisoes, err := s.notaRepo.ListarRevisoes(s.db, notaID)
+	if err != nil {
+		return nil, err
+	}
+	for _, revisao := range revisoes {
+		if err := s.decifrarRevisao(revisao); err != nil {
+			return nil, err
+		}
+	}
+	return mappers.RevisoesNotaClinicaParaDTOOut(revisoes), nil
+}
+
+// ListarNotas busca nas notas do paciente visiveis ao profissional, da sessao mais recente a mais antiga
+// O conteudo e cifrado no banco, entao o filtro de texto e de tag e aplicado apos decifrar
+func (s *notaClinicaServico) ListarNotas(userID, pacienteID uint, filtro *dtos.FiltroNotasClinicasDTOIn) ([]*dtos.NotaClinicaDTOOut, error) {
+	profissional, err := s.buscarProfissional(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	notas, err := s.notaRepo.ListarNotasVisiveis(s.db, profissional.ID, pacienteID)
+	if err != nil {
+		return nil, err
+	}
+	// Notas de colegas deixam de ser visiveis quando o profissional nao acompanha mais o paciente
+	compartilhadasVisiveis := s.vinculoAtivo(s.db, pacienteID, profissional.ID)
+
+	encontradas := make([]*dominio.NotaClinica, 0, len(notas))
+	for _, nota := range notas {
+		if nota.Atual == nil || (!nota.Autor(profissional.ID) && !compartilhadasVisiveis) {
+			continue
+		}
+		if !notaAtendeFiltroSemConteudo(nota.Atual, filtro) {
+			continue
+		}
+		if err := s.decifrarRevisao(nota.Atual); err != nil {
+			return nil, err
+		}
+		if !nota.Atual.Conteudo.Contem(filtro.Busca) {
+			continue
+		}
+		if filtro.Tag != "" && !nota.Atual.Conteudo.PossuiTag(filtro.Tag) {
+			continue
+		}
+		encontradas = append(encontradas, nota)
+	}
+
+	sort.SliceStable(encontradas, func(i, j int) bool {
+		if !encontradas[i].Atual.DataSessao.Equal(encontradas[j].Atual.DataSessao) {
+			return encontradas[i].Atual.DataSessao.After(encontradas[j].Atual.DataSessao)
+		}
+		return encontradas[i].ID > encontradas[j].ID
+	})
+
+	dtosOut := make([]*dtos.NotaClinicaDTOOut, len(encontradas))
+	for i, nota := range encontradas {
+		dtosOut[i] = mappers.NotaClinicaParaDTOOut(nota, profissional.ID)
+	}
+	return dtosOut, nil
+}
+
+// CompartilharNota libera a leitura da nota a outro profissional com vinculo ativo com o paciente
+func (s *notaClinicaServico) CompartilharNota(userID, notaID, profissionalID uint) (*dtos.NotaClinicaDTOOut, error) {
+	return s.alterarCompartilhamento(userID, notaID, func(tx *gorm.DB, nota *dominio.NotaClinica, agora time.Time) (*dominio.CompartilhamentoNota, error) {
+		if !s.vinculoAtivo(tx, nota.PacienteID, profissionalID) {
+			return nil, dominio.ErrCompartilhamentoNotaInvalido
+		}
+		return nota.Compartilhar(profissionalID, agora)
+	})
+}
+
+// RevogarCompartilhamento retira a leitura da nota do profissional informado
+func (s *notaClinicaServico) RevogarCompartilhamento(userID, notaID, profissionalID uint) (*dtos.NotaClinicaDTOOut, error) {
+	return s.alterarCompartilhamento(userID, notaID, func(tx *gorm.DB, nota *dominio.NotaClinica, agora time.Time) (*dominio.CompartilhamentoNota, error) {
+		return nota.RevogarCompartilhamento(profissionalID, agora)
+	})
+}
+
+func (s *notaClinicaServico) alterarCompartilhamento(userID, notaID uint, alterar func(tx *gorm.DB, nota *dominio.NotaClinica, agora time.Time) (*dominio.CompartilhamentoNota, error)) (*dtos.NotaClinicaDTOOut, error) {
+	var nota *dominio.NotaClinica
+	var autorID uint
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		profissional, err := s.buscarProfissional(tx, userID)
+		if err != nil {
+			return err
+		}
+		autorID = profissional.ID
+		if nota, err = s.buscarNotaDoAutor(tx, profissional.ID, notaID); err != nil {
+			return err
+		}
+
+		compartilhamento, err := alterar(tx, nota, time.Now())
+		if err != nil {
+			return err
+		}
+		if err := s.notaRepo.SalvarCompartilhamento(tx, compartilhamento); err != nil {
+			return err
+		}
+		return s.decifrarRevisao(nota.Atual)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.NotaClinicaParaDTOOut(nota, autorID), nil
+}
+
+func (s *notaClinicaServico) buscarProfissional(tx *gorm.DB, userID uint) (*dominio.Profissional, error) {
+	profissional, err := s.usuarioRepo.BuscarProfissionalPorUsuarioID(tx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrUsuarioNaoEncontrado
+		}
+		return nil, err
+	}
+	return profissional, nil
+}
+
+func (s *notaClinicaServico) vinculoAtivo(tx *gorm.DB, pacienteID, profissionalID uint) bool {
+	vinculo, err := s.vinculoRepo.BuscarVinculo(tx, pacienteID, profissionalID)
+	return err == nil && vinculo.Ativo()
+}
+
+// buscarNotaVisivel retorna a nota ao autor ou ao profissional com quem ela foi compartilhada
+// Notas que o profissional nao pode ler respondem como inexistentes
+func (s *notaClinicaServico) buscarNotaVisivel(tx *gorm.DB, profissionalID, notaID uint) (*dominio.NotaClinica, error) {
+	nota, err := s.notaRepo.BuscarNotaPorID(tx, notaID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrNotaClinicaNaoEncontrada
+		}
+		return nil, err
+	}
+	if nota.Autor(profissionalID) {
+		return nota, nil
+	}
+	if nota.CompartilhadaCom(profissionalID) && s.vinculoAtivo(tx, nota.PacienteID, profissionalID) {
+		return nota, nil
+	}
+	return nil, dominio.ErrNotaClinicaNaoEncontrada
+}
+
+// buscarNotaDoAutor retorna a nota apenas ao autor; quem so tem leitura recebe ErrNotaApenasAutor
+func (s *notaClinicaServico) buscarNotaDoAutor(tx *gorm.DB, profissionalID, notaID uint) (*dominio.NotaClinica, error) {
+	nota, err := s.buscarNotaVisivel(tx, profissionalID, notaID)
+	if err != nil {
+		return nil, err
+	}
+	if !nota.Autor(profissionalID) {
+		return nil, dominio.ErrNotaApenasAutor
+	}
+	return nota, nil
+}
+
+// gravarRevisao cifra o conteudo, grava a revisao e avanca a revisao atual da nota
+func (s *notaClinicaServico) gravarRevisao(tx *gorm.DB, nota *dominio.NotaClinica, revisao *dominio.RevisaoNotaClinica, conteudo dominio.ConteudoNota) error {
+	texto, err := json.Marshal(conteudo)
+	if err != nil {
+		return err
+	}
+	if revisao.ConteudoCifrado, err = s.cifrador.Cifrar(texto, dadosAssociadosNota(revisao)); err != nil {
+		return err
+	}
+	if err := s.notaRepo.CriarRevisao(tx, revisao); err != nil {
+		return err
+	}
+
+	nota.RevisaoAtual = revisao.Revisao
+	if err := s.notaRepo.AtualizarRevisaoAtual(tx, nota); err != nil {
+		return err
+	}
+	revisao.Conteudo = conteudo
+	nota.Atual = revisao
+	return nil
+}
+
+func (s *notaClinicaServico) decifrarRevisao(revisao *dominio.RevisaoNotaClinica) error {
+	if revisao == nil {
+		return nil
+	}
+	texto, err := s.cifrador.Decifrar(revisao.ConteudoCifrado, dadosAssociadosNota(revisao))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(texto, &revisao.Conteudo)
+}
+
+// novaRevisao valida o DTO e prepara a proxima revisao da nota
+func novaRevisao(nota *dominio.NotaClinica, dtoIn *dtos.NotaClinicaDTOIn) (*dominio.RevisaoNotaClinica, dominio.ConteudoNota, error) {
+	conteudo := dominio.ConteudoNota{Texto: dtoIn.Texto, Tags: dtoIn.Tags}
+	if err := conteudo.Normalizar(); err != nil {
+		return nil, conteudo, err
+	}
+	revisao, err := dominio.NovaRevisaoNota(nota, dtoIn.DataSessao, dtoIn.TipoSessao, time.Now())
+	return revisao, conteudo, err
+}
+
+// dadosAssociadosNota amarra o texto cifrado a nota e a revisao
+func dadosAssociadosNota(revisao *dominio.RevisaoNotaClinica) []byte {
+	return fmt.Appendf(nil, "nota_clinica:%d:%d", revisao.NotaID, revisao.Revisao)
+}
+
+// notaAtendeFiltroSemConteudo aplica os filtros que nao exigem decifrar a nota
+func notaAtendeFiltroSemConteudo(revisao *dominio.RevisaoNotaClinica, filtro *dtos.FiltroNotasClinicasDTOIn) bool {
+	if filtro.TipoSessao != "" && revisao.TipoSessao != filtro.TipoSessao {
+		return false
+	}
+	if filtro.De != nil && revisao.DataSessao.Before(*filtro.De) {
+		return false
+	}
+	if filtro.Ate != nil && !revisao.DataSessao.Before(filtro.Ate.AddDate(0, 0, 1)) {
+		return false
+	}
+	return true
+}
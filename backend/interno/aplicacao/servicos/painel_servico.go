@@ -0,0 +1,238 @@
+package servicos
+
+import (
+	"errors"
+	"math"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// diasStatusPainel e o periodo das medias do status no painel, o mesmo do relatorio padrao
+const diasStatusPainel = 7
+
+// PainelServico monta a visao geral da carteira de pacientes do profissional
+type PainelServico interface {
+	// GerarPainelProfissional resume todos os pacientes com vinculo ativo, ordenados por risco
+	GerarPainelProfissional(usuarioID uint) (*dtos.PainelProfissionalDTOOut, error)
+}
+
+type painelServico struct {
+	db                *gorm.DB
+	usuarioRepo       repositorios.UsuarioRepositorio
+	consentimentoRepo repositorios.ConsentimentoRepositorio
+	painelRepo        repositorios.PainelProfissionalRepositorio
+}
+
+func NovoPainelServico(db *gorm.DB, usuarioRepo repositorios.UsuarioRepositorio, consentimentoRepo repositorios.ConsentimentoRepositorio, painelRepo repositorios.PainelProfissionalRepositorio) PainelServico {
+	return &painelServico{
+		db:                db,
+		usuarioRepo:       usuarioRepo,
+		consentimentoRepo: consentimentoRepo,
+		painelRepo:        painelRepo,
+	}
+}
+
+// GerarPainelProfissional faz um numero fixo de consultas, qualquer que seja o tamanho da carteira
+// Cada informacao so aparece quando o consentimento vigente libera a categoria correspondente:
+// ultimo registro e status pelo humor e sono, atribuicoes e pontuacoes pelos questionarios
+func (s *painelServico) GerarPainelProfissional(usuarioID uint) (*dtos.PainelProfissionalDTOOut, error) {
+	agora := time.Now()
+	inicioStatus := agora.AddDate(0, 0, -diasStatusPainel)
+
+	var pacientes []*dominio.PacienteDaCarteira
+	var consentimentos []*dominio.Consentimento
+	var ultimos []*dominio.UltimoRegistroPaciente
+	var registros []*dominio.RegistroHumor
+	var atribuicoes []*dominio.AtribuicoesPorStatus
+	var pontuacoes []*dominio.UltimaPontuacao
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		profissional, err := s.usuarioRepo.BuscarProfissionalPorUsuarioID(tx, usuarioID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrUsuarioNaoEncontrado
+			}
+			return err
+		}
+		if pacientes, err = s.painelRepo.ListarPacientesDaCarteira(tx, profissional.ID); err != nil {
+			return err
+		}
+		pacienteIDs := make([]uint, 0, len(pacientes))
+		for _, paciente := range pacientes {
+			pacienteIDs = append(pacienteIDs, paciente.PacienteID)
+		}
+		if consentimentos, err = s.consentimentoRepo.ListarConsentimentosAtuaisDoProfissional(tx, profissional.ID); err != nil {
+			return err
+		}
+		if ultimos, err = s.painelRepo.ListarUltimosRegistros(tx, pacienteIDs); err != nil {
+			return err
+		}
+		if registros, err = s.painelRepo.ListarRegistrosDosPacientes(tx, pacienteIDs, inicioStatus, agora); err != nil {
+			return err
+		}
+		if atribuicoes, err = s.painelRepo.ContarAtribuicoesAbertas(tx, profissional.ID); err != nil {
+			return err
+		}
+		pontuacoes, err = s.painelRepo.ListarUltimasPontuacoes(tx, profissional.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	consentimentoPorPaciente := make(map[uint]*dominio.Consentimento, len(consentimentos))
+	for _, consentimento := range consentimentos {
+		consentimentoPorPaciente[consentimento.PacienteID] = consentimento
+	}
+	painel := &dtos.PainelProfissionalDTOOut{
+		GeradoEm:   agora,
+		DiasStatus: diasStatusPainel,
+		Pacientes:  make([]dtos.PacientePainelDTOOut, 0, len(pacientes)),
+	}
+	posicao := make(map[uint]int, len(pacientes))
+	for _, paciente := range pacientes {
+		posicao[paciente.PacienteID] = len(painel.Pacientes)
+		painel.Pacientes = append(painel.Pacientes, dtos.PacientePainelDTOOut{
+			PacienteID:               paciente.PacienteID,
+			Nome:                     paciente.Nome,
+			VinculadoEm:              paciente.VinculadoEm,
+			CategoriasCompartilhadas: categoriasDoPainel(consentimentoPorPaciente[paciente.PacienteID], agora),
+			UltimasPontuacoes:        make([]dtos.PontuacaoPainelDTOOut, 0),
+		})
+	}
+
+	// Ultimo registro e dias sem registro: registros anteriores ao inicio do consentimento ficam ocultos
+	for _, ultimo := range ultimos {
+		i, ok := posicao[ultimo.PacienteID]
+		consentimento := consentimentoPorPaciente[ultimo.PacienteID]
+		if !ok || !compartilhaDiario(consentimento, agora) || !consentimento.CobreData(ultimo.DataHoraRegistro) {
+			continue
+		}
+		data := ultimo.DataHoraRegistro
+		dias := diasEntre(data, agora)
+		painel.Pacientes[i].UltimoRegistro, painel.Pacientes[i].DiasSemRegistro = &data, &dias
+	}
+
+	// Status com as medias por registro do periodo, como na analise historica
+	medias := make(map[uint]*[4]float64)
+	for _, registro := range registros {
+		i, ok := posicao[registro.PacienteID]
+		consentimento := consentimentoPorPaciente[registro.PacienteID]
+		if !ok || !compartilhaDiario(consentimento, agora) || !consentimento.CobreData(registro.DataHoraRegistro) {
+			continue
+		}
+		painel.Pacientes[i].RegistrosRecentes++
+		if !consentimento.Permite(dominio.CategoriaHumor, agora) || !consentimento.Permite(dominio.CategoriaSono, agora) {
+			continue
+		}
+		soma, ok := medias[registro.PacienteID]
+		if !ok {
+			soma = &[4]float64{}
+			medias[registro.PacienteID] = soma
+		}
+		soma[0] += float64(registro.HorasSono)
+		soma[1] += float64(registro.NivelHumor)
+		soma[2] += float64(registro.NivelStress)
+		soma[3] += float64(registro.NivelEnergia)
+	}
+	for pacienteID, soma := range medias {
+		p := &painel.Pacientes[posicao[pacienteID]]
+		n := float64(p.RegistrosRecentes)
+		p.StatusAtual = calcularStatus(soma[0]/n, soma[1]/n, soma[2]/n, soma[3]/n)
+	}
+
+	for _, contagem := range atribuicoes {
+		i, ok := posicao[contagem.PacienteID]
+		consentimento := consentimentoPorPaciente[contagem.PacienteID]
+		if !ok || consentimento == nil || !consentimento.Permite(dominio.CategoriaQuestionarios, agora) {
+			continue
+		}
+		switch contagem.Status {
+		case dominio.StatusPendente:
+			painel.Pacientes[i].AtribuicoesPendentes = contagem.Total
+		case dominio.StatusExpirado:
+			painel.Pacientes[i].AtribuicoesExpiradas = contagem.Total
+		}
+	}
+
+	// Duas respostas no mesmo instante mantem a primeira
+	vistas := make(map[[2]uint]bool, len(pontuacoes))
+	for _, pontuacao := range pontuacoes {
+		i, ok := posicao[pontuacao.PacienteID]
+		consentimento := consentimentoPorPaciente[pontuacao.PacienteID]
+		chave := [2]uint{pontuacao.PacienteID, pontuacao.InstrumentoID}
+		if !ok || vistas[chave] || consentimento == nil || !consentimento.Permite(dominio.CategoriaQuestionarios, agora) ||
+			!consentimento.CobreData(pontuacao.DataResposta) {
+			continue
+		}
+		vistas[chave] = true
+		painel.Pacientes[i].UltimasPontuacoes = append(painel.Pacientes[i].UltimasPontuacoes, dtos.PontuacaoPainelDTOOut{
+			InstrumentoID:  pontuacao.InstrumentoID,
+			Codigo:         pontuacao.Codigo,
+			Nome:           pontuacao.Nome,
+			PontuacaoTotal: pontuacao.PontuacaoTotal,
+			Classificacao:  pontuacao.Classificacao,
+			DataResposta:   pontuacao.DataResposta,
+		})
+	}
+
+	ordenarPorRisco(painel.Pacientes)
+	return painel, nil
+}
+
+// compartilhaDiario indica se o profissional ve algum dado dos registros de humor do paciente
+func compartilhaDiario(consentimento *dominio.Consentimento, agora time.Time) bool {
+	return consentimento != nil && (consentimento.Permite(dominio.CategoriaHumor, agora) || consentimento.Permite(dominio.CategoriaSono, agora))
+}
+
+// categoriasDoPainel lista as categorias compartilhadas no momento; vazio sem consentimento vigente
+func categoriasDoPainel(consentimento *dominio.Consentimento, agora time.Time) []string {
+	categorias := make([]string, 0, 4)
+	if consentimento == nil || !consentimento.Vigente(agora) {
+		return categorias
+	}
+	return append(categorias, consentimento.Categorias()...)
+}
+
+// diasEntre conta os dias do calendario entre as duas datas no fuso do servidor
+func diasEntre(de, ate time.Time) int {
+	return int(math.Round(dominio.InicioDoDia(ate, time.Local).Sub(dominio.InicioDoDia(de, time.Local)).Hours() / 24))
+}
+
+// prioridadeStatus ordena do status mais grave ao regular; sem status fica antes do regular
+func prioridadeStatus(status string) int {
+	switch status {
+	case StatusPreocupante:
+		return 0
+	case StatusAtencao:
+		return 1
+	case StatusRegular:
+		return 3
+	}
+	return 2
+}
+
+// ordenarPorRisco coloca primeiro os status mais graves; no mesmo status, quem esta ha mais dias sem registrar,
+// depois quem tem mais atribuicoes expiradas e pendentes. Dias sem registro desconhecidos vao depois dos conhecidos
+func ordenarPorRisco(pacientes []dtos.PacientePainelDTOOut) {
+	sort.SliceStable(pacientes, func(i, j int) bool {
+		a, b := pacientes[i], pacientes[j]
+		if pa, pb := prioridadeStatus(a.StatusAtual), prioridadeStatus(b.StatusAtual); pa != pb {
+			return pa < pb
+		}
+		if (a.DiasSemRegistro == nil) != (b.DiasSemRegistro == nil) {
+			return b.DiasSemRegistro == nil
+		}
+		if a.DiasSemRegistro != nil && *a.DiasSemRegistro != *b.DiasSemRegistro {
+			return *a.DiasSemRegistro > *b.DiasSemRegistro
+		}
+		if a.AtribuicoesExpiradas != b.AtribuicoesExpiradas {
+			return a.AtribuicoesExpiradas > b.AtribuicoesExpiradas
+		}
+		return a.AtribuicoesPendentes > b.AtribuicoesPendentes
+	})
+}
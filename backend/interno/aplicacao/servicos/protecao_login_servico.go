@@ -0,0 +1,104 @@
+package servicos
+
+import (
+	"log"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+)
+
+// ProtecaoLoginServico controla falhas de login por conta e por IP
+type ProtecaoLoginServico interface {
+	VerificarBloqueio(email, ip string) error
+	RegistrarFalha(email, ip string)
+	RegistrarSucesso(email, ip string)
+}
+
+// protecaoLoginServico implementa a interface ProtecaoLoginServico
+type protecaoLoginServico struct {
+	tentativaRepositorio repositorios.TentativaLoginRepositorio
+	bloqueioRepositorio  repositorios.BloqueioLoginRepositorio
+}
+
+// NovoProtecaoLoginServico cria uma nova instancia de ProtecaoLoginServico
+func NovoProtecaoLoginServico(tr repositorios.TentativaLoginRepositorio, br repositorios.BloqueioLoginRepositorio) ProtecaoLoginServico {
+	return &protecaoLoginServico{
+		tentativaRepositorio: tr,
+		bloqueioRepositorio:  br,
+	}
+}
+
+// chaveLimite associa uma chave de controle ao seu limite de falhas
+type chaveLimite struct {
+	tipo   string
+	chave  string
+	limite int
+}
+
+func (s *protecaoLoginServico) chaves(email, ip string) []chaveLimite {
+	chaves := []chaveLimite{{
+		tipo:   dominio.TipoTentativaConta,
+		chave:  dominio.ChaveTentativa(dominio.TipoTentativaConta, email),
+		limite: dominio.LimiteFalhasPorConta,
+	}}
+	if ip != "" {
+		chaves = append(chaves, chaveLimite{
+			tipo:   dominio.TipoTentativaIP,
+			chave:  dominio.ChaveTentativa(dominio.TipoTentativaIP, ip),
+			limite: dominio.LimiteFalhasPorIP,
+		})
+	}
+	return chaves
+}
+
+// VerificarBloqueio retorna ErrLoginBloqueado se a conta ou o IP estiverem bloqueados
+// O erro e o mesmo exista ou nao a conta, evitando enumeracao
+func (s *protecaoLoginServico) VerificarBloqueio(email, ip string) error {
+	agora := time.Now()
+	for _, c := range s.chaves(email, ip) {
+		tentativa, err := s.tentativaRepositorio.BuscarTentativa(c.chave)
+		if err != nil {
+			return err
+		}
+		if tentativa.EstaBloqueada(agora) {
+			return dominio.ErrLoginBloqueado
+		}
+	}
+	return nil
+}
+
+// RegistrarFalha contabiliza a falha e audita os bloqueios gerados
+// O repositorio incrementa o contador atomicamente, para que tentativas em paralelo nao escapem do limite
+func (s *protecaoLoginServico) RegistrarFalha(email, ip string) {
+	agora := time.Now()
+	for _, c := range s.chaves(email, ip) {
+		tentativa, bloqueou, err := s.tentativaRepositorio.RegistrarFalhaTentativa(c.chave, agora, c.limite)
+		if err != nil {
+			log.Printf("falha ao registrar tentativa de login (%s): %v", c.tipo, err)
+			continue
+		}
+
+		if bloqueou {
+			log.Printf("login bloqueado: chave=%s ate=%v reincidencia=%d", c.chave, tentativa.BloqueadoAte, tentativa.Bloqueios)
+			bloqueio := &dominio.BloqueioLogin{
+				Chave:        c.chave,
+				Tipo:         c.tipo,
+				IP:           ip,
+				Reincidencia: tentativa.Bloqueios,
+				BloqueadoAte: *tentativa.BloqueadoAte,
+			}
+			if err := s.bloqueioRepositorio.CriarBloqueio(bloqueio); err != nil {
+				log.Printf("falha ao auditar bloqueio de login: %v", err)
+			}
+		}
+	}
+}
+
+// RegistrarSucesso zera o contador da conta
+// O contador do IP e mantido para nao permitir que um login valido mascare tentativas em outras contas
+func (s *protecaoLoginServico) RegistrarSucesso(email, ip string) {
+	chave := dominio.ChaveTentativa(dominio.TipoTentativaConta, email)
+	if err := s.tentativaRepositorio.RemoverTentativa(chave); err != nil {
+		log.Printf("falha ao limpar tentativas de login: %v", err)
+	}
+}
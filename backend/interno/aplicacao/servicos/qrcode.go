@@ -0,0 +1,47 @@
+package servicos
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// Formatos aceitos na renderizacao do QR code
+const (
+	FormatoQRCodePNG = "png"
+	FormatoQRCodeSVG = "svg"
+)
+
+// tamanhoQRCodePNG e a largura em pixels da imagem PNG; o SVG escala sem perda
+const tamanhoQRCodePNG = 512
+
+// gerarQRCodePNG codifica o conteudo em uma imagem PNG
+func gerarQRCodePNG(conteudo string) ([]byte, error) {
+	return qrcode.Encode(conteudo, qrcode.Medium, tamanhoQRCodePNG)
+}
+
+// gerarQRCodeSVG codifica o conteudo em um SVG com um quadrado por modulo escuro
+// A margem de quatro modulos exigida pelos leitores ja vem no bitmap
+func gerarQRCodeSVG(conteudo string) ([]byte, error) {
+	q, err := qrcode.New(conteudo, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	bitmap := q.Bitmap()
+	lado := len(bitmap)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" shape-rendering="crispEdges">`,
+		lado, lado, tamanhoQRCodePNG, tamanhoQRCodePNG)
+	b.WriteString(`<rect width="100%" height="100%" fill="#ffffff"/><path fill="#000000" d="`)
+	for y, linha := range bitmap {
+		for x, escuro := range linha {
+			if escuro {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return []byte(b.String()), nil
+}
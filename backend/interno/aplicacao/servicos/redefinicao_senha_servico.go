@@ -0,0 +1,138 @@
+package servicos
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// RedefinicaoSenhaServico define os metodos do fluxo de "esqueci minha senha"
+type RedefinicaoSenhaServico interface {
+	SolicitarRedefinicao(email string) error
+	ConfirmarRedefinicao(dtoIn *dtos.ConfirmarRedefinicaoSenhaDTOIn) error
+}
+
+// redefinicaoSenhaServico implementa a interface RedefinicaoSenhaServico
+type redefinicaoSenhaServico struct {
+	db                     *gorm.DB
+	usuarioRepositorio     repositorios.UsuarioRepositorio
+	redefinicaoRepositorio repositorios.RedefinicaoSenhaRepositorio
+	emailServico           EmailServico
+}
+
+// NovoRedefinicaoSenhaServico cria uma nova instancia de RedefinicaoSenhaServico
+func NovoRedefinicaoSenhaServico(db *gorm.DB, ur repositorios.UsuarioRepositorio, rr repositorios.RedefinicaoSenhaRepositorio, es EmailServico) RedefinicaoSenhaServico {
+	return &redefinicaoSenhaServico{
+		db:                     db,
+		usuarioRepositorio:     ur,
+		redefinicaoRepositorio: rr,
+		emailServico:           es,
+	}
+}
+
+// SolicitarRedefinicao gera um token de uso unico e envia o link por e-mail
+// Sempre retorna nil para e-mails desconhecidos para nao revelar quais contas existem
+func (s *redefinicaoSenhaServico) SolicitarRedefinicao(email string) error {
+	usuario, err := s.usuarioRepositorio.BuscarPorEmail(email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := gerarTokenAleatorio(32)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Apenas o token mais recente permanece valido
+		if err := s.redefinicaoRepositorio.InvalidarRedefinicoesDoUsuario(tx, usuario.ID); err != nil {
+			return err
+		}
+
+		redefinicao := &dominio.RedefinicaoSenha{
+			UsuarioID:     usuario.ID,
+			TokenHash:     hashToken(token),
+			DataExpiracao: time.Now().Add(dominio.ValidadeTokenRedefinicaoSenha),
+		}
+		if err := redefinicao.Validar(); err != nil {
+			return err
+		}
+
+		return s.redefinicaoRepositorio.CriarRedefinicao(tx, redefinicao)
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := MensagemEmail{
+		Para:    usuario.Email,
+		Assunto: "MindTrace - Redefinicao de senha",
+		Corpo: fmt.Sprintf(
+			"Ola, %s.\n\nRecebemos uma solicitacao para redefinir sua senha. Acesse o link abaixo em ate %d minutos:\n\n%s/redefinir-senha?token=%s\n\nSe voce nao fez esta solicitacao, ignore este e-mail.",
+			usuario.Nome, int(dominio.ValidadeTokenRedefinicaoSenha.Minutes()), urlFrontend(), token),
+	}
+	if err := s.emailServico.Enviar(msg); err != nil {
+		// Falha de entrega nao e repassada ao cliente para nao diferenciar contas existentes
+		log.Printf("falha ao enviar e-mail de redefinicao para usuario %d: %v", usuario.ID, err)
+	}
+
+	return nil
+}
+
+// ConfirmarRedefinicao troca a senha usando um token valido e revoga as sessoes ativas
+func (s *redefinicaoSenhaServico) ConfirmarRedefinicao(dtoIn *dtos.ConfirmarRedefinicaoSenhaDTOIn) error {
+	if dtoIn.NovaSenha != dtoIn.NovaSenhaRe {
+		return dominio.ErrSenhaNaoConfere
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		redefinicao, err := s.redefinicaoRepositorio.BuscarRedefinicaoPorTokenHash(tx, hashToken(dtoIn.Token))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrTokenRedefinicaoInvalido
+			}
+			return err
+		}
+
+		if err := redefinicao.VerificarUso(); err != nil {
+			return err
+		}
+		// Marca o uso antes de trocar a senha: com o mesmo token em paralelo, so uma transacao consegue
+		redefinicao.Utilizar()
+		if err := s.redefinicaoRepositorio.MarcarRedefinicaoComoUsada(tx, redefinicao); err != nil {
+			return err
+		}
+
+		usuario, err := s.usuarioRepositorio.BuscarUsuarioPorID(redefinicao.UsuarioID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrTokenRedefinicaoInvalido
+			}
+			return err
+		}
+
+		if err := usuario.ValidarSenha(dtoIn.NovaSenha); err != nil {
+			return err
+		}
+
+		novaSenhaHash, err := bcrypt.GenerateFromPassword([]byte(dtoIn.NovaSenha), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		usuario.Senha = string(novaSenhaHash)
+		// Tokens JWT emitidos antes da redefinicao deixam de ser aceitos
+		usuario.RevogarSessoes()
+
+		return s.usuarioRepositorio.Atualizar(tx, usuario)
+	})
+}
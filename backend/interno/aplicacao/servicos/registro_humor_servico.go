@@ -1,18 +1,47 @@
 package servicos
 
 import (
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"mindtrace/backend/interno/aplicacao/dtos"
 	"mindtrace/backend/interno/aplicacao/mappers"
 	"mindtrace/backend/interno/dominio"
 	"mindtrace/backend/interno/persistencia/repositorios"
+	"strconv"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// Paginacao do diario de humor
+// varreduraMaximaDiario limita os registros lidos por pagina quando os filtros de conteudo descartam muitos
+const (
+	limitePadraoDiario    = 30
+	limiteMaximoDiario    = 100
+	varreduraMaximaDiario = 1000
+	loteVarreduraDiario   = 200
+)
+
+// Resultado de cada item da sincronizacao em lote
+const (
+	StatusSincronizacaoCriado    = "CRIADO"
+	StatusSincronizacaoDuplicado = "DUPLICADO"
+	StatusSincronizacaoInvalido  = "INVALIDO"
+)
+
 // RegistroHumorServico define os metodos para gerenciamento de registros de humor
 type RegistroHumorServico interface {
 	CriarRegistroHumor(dto *dtos.CriarRegistroHumorDTOIn, userID uint) (*dominio.RegistroHumor, error)
+	// SincronizarRegistrosHumor grava os registros feitos sem conexao, deduplicando reenvios pela chave de idempotencia
+	SincronizarRegistrosHumor(userID uint, dto *dtos.SincronizarRegistrosHumorDTOIn) (*dtos.SincronizacaoRegistrosHumorDTOOut, error)
+	// Correcoes do proprio paciente, dentro do prazo contado da criacao do registro
+	AtualizarRegistroHumor(registroID, userID uint, dto *dtos.CriarRegistroHumorDTOIn) (*dtos.RegistroHumorDTOOut, error)
+	ExcluirRegistroHumor(registroID, userID uint) error
+	ListarRevisoesRegistroHumor(registroID, userID uint) ([]*dtos.RevisaoRegistroHumorDTOOut, error)
+	// ListarRegistrosHumor pagina o diario do proprio paciente ou, para o profissional, do paciente informado
+	ListarRegistrosHumor(userID uint, tipoUsuario string, pacienteID uint, filtro *dtos.FiltroRegistrosHumorDTOIn) (*dtos.PaginaRegistrosHumorDTOOut, error)
 }
 
 // registroHumorServico implementa a interface RegistroHumorServico
@@ -20,12 +49,36 @@ type registroHumorServico struct {
 	db                 *gorm.DB
 	repositorio        repositorios.RegistroHumorRepositorio
 	usuarioRepositorio repositorios.UsuarioRepositorio
+	consentimentoRepo  repositorios.ConsentimentoRepositorio
+	vinculoRepo        repositorios.VinculoRepositorio
+	autoCuidadoRepo    repositorios.AutoCuidadoRepositorio
+	metricaRepo        repositorios.MetricaPersonalizadaRepositorio
 	analiseServico     AnaliseServico
+	janelaCorrecao     time.Duration
+	janelaRetroativa   time.Duration
 }
 
 // NovoRegistroHumorServico cria uma nova instancia de registroHumorServico
-func NovoRegistroHumorServico(db *gorm.DB, repo repositorios.RegistroHumorRepositorio, userRepo repositorios.UsuarioRepositorio, analiseSvc AnaliseServico) *registroHumorServico {
-	return &registroHumorServico{db: db, repositorio: repo, usuarioRepositorio: userRepo, analiseServico: analiseSvc}
+// Janelas zeradas usam dominio.JanelaCorrecaoRegistroHumor e dominio.JanelaRetroativaRegistroHumor
+func NovoRegistroHumorServico(db *gorm.DB, repo repositorios.RegistroHumorRepositorio, userRepo repositorios.UsuarioRepositorio, cr repositorios.ConsentimentoRepositorio, vr repositorios.VinculoRepositorio, acr repositorios.AutoCuidadoRepositorio, mpr repositorios.MetricaPersonalizadaRepositorio, analiseSvc AnaliseServico, janelaCorrecao, janelaRetroativa time.Duration) *registroHumorServico {
+	if janelaCorrecao <= 0 {
+		janelaCorrecao = dominio.JanelaCorrecaoRegistroHumor
+	}
+	if janelaRetroativa <= 0 {
+		janelaRetroativa = dominio.JanelaRetroativaRegistroHumor
+	}
+	return &registroHumorServico{
+		db:                 db,
+		repositorio:        repo,
+		usuarioRepositorio: userRepo,
+		consentimentoRepo:  cr,
+		vinculoRepo:        vr,
+		autoCuidadoRepo:    acr,
+		metricaRepo:        mpr,
+		analiseServico:     analiseSvc,
+		janelaCorrecao:     janelaCorrecao,
+		janelaRetroativa:   janelaRetroativa,
+	}
 }
 
 // CriarRegistroHumor cria um novo registro de humor para o paciente
@@ -45,6 +98,14 @@ func (rhs *registroHumorServico) CriarRegistroHumor(dto *dtos.CriarRegistroHumor
 		if err != nil {
 			return err
 		}
+		catalogos, err := rhs.carregarCatalogos(tx, paciente.ID)
+		if err != nil {
+			return err
+		}
+		atividadeIDs, err := aplicarCatalogos(novoRegistroHumor, catalogos, dto)
+		if err != nil {
+			return err
+		}
 		// Validar o registro de humor antes de criar
 		if err := novoRegistroHumor.Validar(); err != nil {
 			return err
@@ -53,6 +114,9 @@ func (rhs *registroHumorServico) CriarRegistroHumor(dto *dtos.CriarRegistroHumor
 		if err := rhs.repositorio.CriarRegistroHumor(tx, novoRegistroHumor); err != nil {
 			return err
 		}
+		if err := rhs.gravarLigacoes(tx, novoRegistroHumor, atividadeIDs); err != nil {
+			return err
+		}
 
 		registroHumorRealizado = novoRegistroHumor
 		return nil
@@ -62,12 +126,525 @@ func (rhs *registroHumorServico) CriarRegistroHumor(dto *dtos.CriarRegistroHumor
 		return nil, err
 	}
 
-	// --- TRIGGER DE MONITORAMENTO ---
-	// Executa em uma goroutine para não bloquear a resposta da API
+	rhs.dispararMonitoramento(registroHumorRealizado.PacienteID)
+
+	return registroHumorRealizado, nil
+}
+
+// SincronizarRegistrosHumor grava o lote em uma transacao e responde item a item, na ordem do envio
+// Itens invalidos sao recusados sem descartar os demais; uma chave ja gravada devolve o registro existente
+// O monitoramento roda uma unica vez, apos o lote, quando algum registro foi criado
+func (rhs *registroHumorServico) SincronizarRegistrosHumor(userID uint, dto *dtos.SincronizarRegistrosHumorDTOIn) (*dtos.SincronizacaoRegistrosHumorDTOOut, error) {
+	if len(dto.Registros) == 0 || len(dto.Registros) > dominio.TamanhoMaximoLoteRegistrosHumor {
+		return nil, dominio.ErrLoteRegistrosHumorInvalido
+	}
+
+	var pacienteID uint
+	saida := &dtos.SincronizacaoRegistrosHumorDTOOut{Resultados: make([]dtos.ResultadoSincronizacaoDTOOut, 0, len(dto.Registros))}
+	err := rhs.db.Transaction(func(tx *gorm.DB) error {
+		paciente, err := buscarPacienteDoUsuario(tx, rhs.usuarioRepositorio, userID)
+		if err != nil {
+			return err
+		}
+		pacienteID = paciente.ID
+
+		chaves := make([]string, 0, len(dto.Registros))
+		for _, item := range dto.Registros {
+			if dominio.ValidarChaveIdempotencia(item.ChaveIdempotencia) == nil {
+				chaves = append(chaves, item.ChaveIdempotencia)
+			}
+		}
+		existentes, err := rhs.repositorio.BuscarRegistrosHumorPorChaves(tx, paciente.ID, chaves)
+		if err != nil {
+			return err
+		}
+		gravados := make(map[string]*dominio.RegistroHumor, len(chaves))
+		for _, registro := range existentes {
+			gravados[*registro.ChaveIdempotencia] = registro
+		}
+		catalogos, err := rhs.carregarCatalogos(tx, paciente.ID)
+		if err != nil {
+			return err
+		}
+
+		agora := time.Now()
+		for i := range dto.Registros {
+			item := &dto.Registros[i]
+			resultado := dtos.ResultadoSincronizacaoDTOOut{ChaveIdempotencia: item.ChaveIdempotencia}
+
+			if registro, ok := gravados[item.ChaveIdempotencia]; ok {
+				resultado.Status, resultado.Registro = StatusSincronizacaoDuplicado, mappers.RegistroHumorParaDTOOut(registro)
+			} else if registro, atividadeIDs, err := rhs.novoRegistroSincronizado(item, paciente.ID, catalogos, agora); err != nil {
+				resultado.Status, resultado.Erro = StatusSincronizacaoInvalido, err.Error()
+			} else {
+				criado, err := rhs.repositorio.CriarRegistroHumorSeNovo(tx, registro)
+				if err != nil {
+					return err
+				}
+				status := StatusSincronizacaoCriado
+				if criado {
+					if err := rhs.gravarLigacoes(tx, registro, atividadeIDs); err != nil {
+						return err
+					}
+				} else {
+					// Outro envio com a mesma chave foi gravado entre a busca e a insercao
+					concorrentes, err := rhs.repositorio.BuscarRegistrosHumorPorChaves(tx, paciente.ID, []string{item.ChaveIdempotencia})
+					if err != nil {
+						return err
+					}
+					if len(concorrentes) == 0 {
+						return dominio.ErrRegistroHumorNaoEncontrado
+					}
+					registro, status = concorrentes[0], StatusSincronizacaoDuplicado
+				}
+				gravados[item.ChaveIdempotencia] = registro
+				resultado.Status, resultado.Registro = status, mappers.RegistroHumorParaDTOOut(registro)
+			}
+
+			switch resultado.Status {
+			case StatusSincronizacaoCriado:
+				saida.Criados++
+			case StatusSincronizacaoDuplicado:
+				saida.Duplicados++
+			default:
+				saida.Invalidos++
+			}
+			saida.Resultados = append(saida.Resultados, resultado)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if saida.Criados > 0 {
+		rhs.dispararMonitoramento(pacienteID)
+	}
+	return saida, nil
+}
+
+// novoRegistroSincronizado valida um item do lote, incluindo o prazo para registros retroativos
+func (rhs *registroHumorServico) novoRegistroSincronizado(item *dtos.ItemSincronizacaoRegistroHumorDTOIn, pacienteID uint, catalogos *catalogosPaciente, agora time.Time) (*dominio.RegistroHumor, []uint, error) {
+	if err := dominio.ValidarChaveIdempotencia(item.ChaveIdempotencia); err != nil {
+		return nil, nil, err
+	}
+	if item.HorasSono == nil {
+		return nil, nil, dominio.ErrHorasSonoInvalido
+	}
+	registro, err := mappers.CriarRegistroHumorDTOInParaEntidade(&item.CriarRegistroHumorDTOIn, pacienteID)
+	if err != nil {
+		return nil, nil, err
+	}
+	atividadeIDs, err := aplicarCatalogos(registro, catalogos, &item.CriarRegistroHumorDTOIn)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := registro.Validar(); err != nil {
+		return nil, nil, err
+	}
+	if err := registro.ValidarJanelaRetroativa(agora, rhs.janelaRetroativa); err != nil {
+		return nil, nil, err
+	}
+	chave := item.ChaveIdempotencia
+	registro.ChaveIdempotencia = &chave
+	return registro, atividadeIDs, nil
+}
+
+// catalogosPaciente reune o que um registro do paciente pode referenciar
+type catalogosPaciente struct {
+	atividades []*dominio.AtividadeAutoCuidado
+	metricas   []*dominio.MetricaPersonalizada
+}
+
+func (rhs *registroHumorServico) carregarCatalogos(tx *gorm.DB, pacienteID uint) (*catalogosPaciente, error) {
+	atividades, err := rhs.autoCuidadoRepo.ListarCatalogoDoPaciente(tx, pacienteID)
+	if err != nil {
+		return nil, err
+	}
+	metricas, err := rhs.metricaRepo.ListarMetricasAtivasDoPaciente(tx, pacienteID)
+	if err != nil {
+		return nil, err
+	}
+	return &catalogosPaciente{atividades: atividades, metricas: metricas}, nil
+}
+
+// aplicarCatalogos resolve o auto cuidado e valida os valores das metricas personalizadas do registro
+// Retorna os IDs das atividades, para ligar ao registro
+func aplicarCatalogos(registro *dominio.RegistroHumor, catalogos *catalogosPaciente, dto *dtos.CriarRegistroHumorDTOIn) ([]uint, error) {
+	atividadeIDs, err := aplicarAutoCuidado(registro, catalogos.atividades, dto)
+	if err != nil {
+		return nil, err
+	}
+	if registro.Metricas, err = dominio.ResolverValoresMetricas(catalogos.metricas, registro.Metricas); err != nil {
+		return nil, err
+	}
+	return atividadeIDs, nil
+}
+
+// gravarLigacoes substitui as atividades e os valores de metricas gravados para o registro
+func (rhs *registroHumorServico) gravarLigacoes(tx *gorm.DB, registro *dominio.RegistroHumor, atividadeIDs []uint) error {
+	if err := rhs.autoCuidadoRepo.SubstituirAtividadesDoRegistro(tx, registro.ID, atividadeIDs); err != nil {
+		return err
+	}
+	return rhs.metricaRepo.SubstituirValoresDoRegistro(tx, registro.ID, registro.Metricas)
+}
+
+// aplicarAutoCuidado troca o auto cuidado enviado pelos nomes do catalogo do paciente
+// e retorna os IDs das atividades, para ligar ao registro
+func aplicarAutoCuidado(registro *dominio.RegistroHumor, catalogo []*dominio.AtividadeAutoCuidado, dto *dtos.CriarRegistroHumorDTOIn) ([]uint, error) {
+	atividades, err := dominio.ResolverAutoCuidado(catalogo, dto.AutoCuidadoIDs, dto.AutoCuidado)
+	if err != nil {
+		return nil, err
+	}
+	if err := registro.AplicarAutoCuidado(atividades); err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(atividades))
+	for _, atividade := range atividades {
+		ids = append(ids, atividade.ID)
+	}
+	return ids, nil
+}
+
+// AtualizarRegistroHumor corrige um registro do paciente e guarda os valores anteriores como revisao
+// Sem data_hora_registro, o momento original do registro e mantido
+func (rhs *registroHumorServico) AtualizarRegistroHumor(registroID, userID uint, dto *dtos.CriarRegistroHumorDTOIn) (*dtos.RegistroHumorDTOOut, error) {
+	var registro *dominio.RegistroHumor
+
+	err := rhs.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if registro, err = rhs.buscarRegistroCorrigivel(tx, registroID, userID); err != nil {
+			return err
+		}
+
+		correcao, err := mappers.CriarRegistroHumorDTOInParaEntidade(dto, registro.PacienteID)
+		if err != nil {
+			return err
+		}
+		if correcao.DataHoraRegistro.IsZero() {
+			correcao.DataHoraRegistro = registro.DataHoraRegistro
+		}
+		catalogos, err := rhs.carregarCatalogos(tx, registro.PacienteID)
+		if err != nil {
+			return err
+		}
+		atividadeIDs, err := aplicarCatalogos(correcao, catalogos, dto)
+		if err != nil {
+			return err
+		}
+		if err := correcao.Validar(); err != nil {
+			return err
+		}
+
+		if err := rhs.criarRevisao(tx, registro, dominio.OperacaoRevisaoEdicao); err != nil {
+			return err
+		}
+		registro.Corrigir(correcao)
+		if err := rhs.repositorio.AtualizarRegistroHumor(tx, registro); err != nil {
+			return err
+		}
+		return rhs.gravarLigacoes(tx, registro, atividadeIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rhs.dispararMonitoramento(registro.PacienteID)
+
+	return mappers.RegistroHumorParaDTOOut(registro), nil
+}
+
+// ExcluirRegistroHumor apaga um registro do paciente; os valores ficam guardados como a ultima revisao
+func (rhs *registroHumorServico) ExcluirRegistroHumor(registroID, userID uint) error {
+	var pacienteID uint
+
+	err := rhs.db.Transaction(func(tx *gorm.DB) error {
+		registro, err := rhs.buscarRegistroCorrigivel(tx, registroID, userID)
+		if err != nil {
+			return err
+		}
+		pacienteID = registro.PacienteID
+
+		if err := rhs.criarRevisao(tx, registro, dominio.OperacaoRevisaoExclusao); err != nil {
+			return err
+		}
+		return rhs.repositorio.ExcluirRegistroHumor(tx, registro.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	rhs.dispararMonitoramento(pacienteID)
+	return nil
+}
+
+// ListarRevisoesRegistroHumor mostra ao paciente os valores anteriores de um registro, sem limite de prazo
+func (rhs *registroHumorServico) ListarRevisoesRegistroHumor(registroID, userID uint) ([]*dtos.RevisaoRegistroHumorDTOOut, error) {
+	registro, err := rhs.buscarRegistroDoPaciente(rhs.db, registroID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisoes, err := rhs.repositorio.ListarRevisoesRegistroHumor(rhs.db, registro.ID)
+	if err != nil {
+		return nil, err
+	}
+	return mappers.RevisoesRegistroHumorParaDTOOut(revisoes), nil
+}
+
+// ListarRegistrosHumor percorre o diario do mais recente ao mais antigo
+// As observacoes sao cifradas no banco, entao auto cuidado e texto sao filtrados apos a leitura
+// O profissional precisa de vinculo ativo e ve apenas as categorias liberadas pelo consentimento vigente
+func (rhs *registroHumorServico) ListarRegistrosHumor(userID uint, tipoUsuario string, pacienteID uint, filtroIn *dtos.FiltroRegistrosHumorDTOIn) (*dtos.PaginaRegistrosHumorDTOOut, error) {
+	filtro, err := filtroRegistrosHumor(filtroIn)
+	if err != nil {
+		return nil, err
+	}
+	limite := filtroIn.Limite
+	if limite <= 0 {
+		limite = limitePadraoDiario
+	}
+	if limite > limiteMaximoDiario {
+		limite = limiteMaximoDiario
+	}
+
+	var consentimento *dominio.Consentimento
+	agora := time.Now()
+	if dominio.StringParaTipoUsuario(tipoUsuario) == dominio.TipoUsuarioPaciente {
+		paciente, err := buscarPacienteDoUsuario(rhs.db, rhs.usuarioRepositorio, userID)
+		if err != nil {
+			return nil, err
+		}
+		pacienteID = paciente.ID
+	} else {
+		if consentimento, err = rhs.consentimentoParaDiario(userID, pacienteID, filtro, agora); err != nil {
+			return nil, err
+		}
+		if filtro.De == nil || consentimento.DataInicio.After(*filtro.De) {
+			filtro.De = &consentimento.DataInicio
+		}
+	}
+
+	pagina := &dtos.PaginaRegistrosHumorDTOOut{Registros: make([]*dtos.RegistroHumorDTOOut, 0, limite)}
+	var ultimoLido *dominio.RegistroHumor
+	lidos := 0
+	for lidos < varreduraMaximaDiario {
+		lote, err := rhs.repositorio.ListarRegistrosHumor(rhs.db, pacienteID, filtro, loteVarreduraDiario)
+		if err != nil {
+			return nil, err
+		}
+		for _, registro := range lote {
+			lidos++
+			if !registro.AtendeFiltroConteudo(filtro) {
+				ultimoLido = registro
+				continue
+			}
+			// Um registro alem do limite apenas confirma que ha proxima pagina
+			if len(pagina.Registros) == limite {
+				pagina.ProximoCursor = codificarCursorDiario(ultimoLido)
+				return rhs.concluirPagina(pagina, consentimento, agora)
+			}
+			pagina.Registros = append(pagina.Registros, mappers.RegistroHumorParaDTOOut(registro))
+			ultimoLido = registro
+		}
+		if len(lote) < loteVarreduraDiario {
+			return rhs.concluirPagina(pagina, consentimento, agora)
+		}
+		filtro.CursorDataHora, filtro.CursorID = &ultimoLido.DataHoraRegistro, ultimoLido.ID
+	}
+
+	// Varredura esgotada: a proxima pagina continua de onde a leitura parou
+	pagina.ProximoCursor = codificarCursorDiario(ultimoLido)
+	return rhs.concluirPagina(pagina, consentimento, agora)
+}
+
+// concluirPagina anexa os valores das metricas personalizadas e aplica o consentimento
+func (rhs *registroHumorServico) concluirPagina(pagina *dtos.PaginaRegistrosHumorDTOOut, consentimento *dominio.Consentimento, agora time.Time) (*dtos.PaginaRegistrosHumorDTOOut, error) {
+	registroIDs := make([]uint, 0, len(pagina.Registros))
+	for _, registro := range pagina.Registros {
+		registroIDs = append(registroIDs, registro.ID)
+	}
+	valores, err := rhs.metricaRepo.ListarValoresDosRegistros(rhs.db, registroIDs)
+	if err != nil {
+		return nil, err
+	}
+	porRegistro := make(map[uint][]*dominio.ValorMetricaRegistro, len(registroIDs))
+	for _, valor := range valores {
+		porRegistro[valor.RegistroHumorID] = append(porRegistro[valor.RegistroHumorID], valor)
+	}
+	for _, registro := range pagina.Registros {
+		registro.Metricas = mappers.ValoresMetricasParaDTOOut(porRegistro[registro.ID])
+	}
+	return rhs.ocultarCategorias(pagina, consentimento, agora), nil
+}
+
+// consentimentoParaDiario confere o vinculo e o consentimento do profissional
+// Filtrar por uma categoria oculta revelaria os valores dela, por isso tambem e recusado
+func (rhs *registroHumorServico) consentimentoParaDiario(userID, pacienteID uint, filtro *dominio.FiltroRegistrosHumor, agora time.Time) (*dominio.Consentimento, error) {
+	profissional, err := rhs.usuarioRepositorio.BuscarProfissionalPorUsuarioID(rhs.db, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrUsuarioNaoEncontrado
+		}
+		return nil, err
+	}
+	vinculo, err := rhs.vinculoRepo.BuscarVinculo(rhs.db, pacienteID, profissional.ID)
+	if err != nil || !vinculo.Ativo() {
+		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrVinculoNaoEncontrado
+		}
+		return nil, err
+	}
+	consentimento, err := buscarConsentimentoAtual(rhs.db, rhs.consentimentoRepo, pacienteID, profissional.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	humor := consentimento.Permite(dominio.CategoriaHumor, agora)
+	sono := consentimento.Permite(dominio.CategoriaSono, agora)
+	observacoes := consentimento.Permite(dominio.CategoriaObservacoes, agora)
+	if !humor && !sono {
+		return nil, dominio.ErrConsentimentoNegado
+	}
+	if !humor && (filtro.Humor.Restringe() || filtro.Energia.Restringe() || filtro.Stress.Restringe() || len(filtro.AutoCuidado) > 0) {
+		return nil, dominio.ErrConsentimentoNegado
+	}
+	if !sono && filtro.Sono.Restringe() {
+		return nil, dominio.ErrConsentimentoNegado
+	}
+	if !observacoes && strings.TrimSpace(filtro.Busca) != "" {
+		return nil, dominio.ErrConsentimentoNegado
+	}
+	return consentimento, nil
+}
+
+// ocultarCategorias zera no diario do profissional os campos das categorias nao compartilhadas
+// Auto cuidado e metricas personalizadas acompanham a categoria humor
+func (rhs *registroHumorServico) ocultarCategorias(pagina *dtos.PaginaRegistrosHumorDTOOut, consentimento *dominio.Consentimento, agora time.Time) *dtos.PaginaRegistrosHumorDTOOut {
+	if consentimento == nil {
+		return pagina
+	}
+	pagina.CategoriasCompartilhadas = make([]string, 0, 3)
+	for _, categoria := range []string{dominio.CategoriaHumor, dominio.CategoriaSono, dominio.CategoriaObservacoes} {
+		if consentimento.Permite(categoria, agora) {
+			pagina.CategoriasCompartilhadas = append(pagina.CategoriasCompartilhadas, categoria)
+		}
+	}
+	humor := consentimento.Permite(dominio.CategoriaHumor, agora)
+	sono := consentimento.Permite(dominio.CategoriaSono, agora)
+	observacoes := consentimento.Permite(dominio.CategoriaObservacoes, agora)
+	for _, registro := range pagina.Registros {
+		if !humor {
+			registro.NivelHumor, registro.NivelEnergia, registro.NivelStress, registro.AutoCuidado = 0, 0, 0, ""
+			registro.Metricas = nil
+		}
+		if !sono {
+			registro.HorasSono = 0
+		}
+		if !observacoes {
+			registro.Observacoes = ""
+		}
+	}
+	return pagina
+}
+
+// filtroRegistrosHumor converte a busca da query no filtro do dominio
+func filtroRegistrosHumor(dto *dtos.FiltroRegistrosHumorDTOIn) (*dominio.FiltroRegistrosHumor, error) {
+	filtro := &dominio.FiltroRegistrosHumor{
+		De:          dto.De,
+		Humor:       dominio.FaixaMetrica{Min: dto.HumorMin, Max: dto.HumorMax},
+		Sono:        dominio.FaixaMetrica{Min: dto.SonoMin, Max: dto.SonoMax},
+		Energia:     dominio.FaixaMetrica{Min: dto.EnergiaMin, Max: dto.EnergiaMax},
+		Stress:      dominio.FaixaMetrica{Min: dto.StressMin, Max: dto.StressMax},
+		AutoCuidado: dto.AutoCuidado,
+		Busca:       dto.Busca,
+	}
+	if dto.Ate != nil {
+		antesDe := dto.Ate.AddDate(0, 0, 1)
+		filtro.AntesDe = &antesDe
+	}
+	if dto.Cursor != "" {
+		dataHora, id, err := decodificarCursorDiario(dto.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filtro.CursorDataHora, filtro.CursorID = &dataHora, id
+	}
+	if err := filtro.Validar(); err != nil {
+		return nil, err
+	}
+	return filtro, nil
+}
+
+// codificarCursorDiario identifica o ultimo registro lido pelo momento e pelo ID
+func codificarCursorDiario(registro *dominio.RegistroHumor) string {
+	valor := fmt.Sprintf("%d:%d", registro.DataHoraRegistro.UnixNano(), registro.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(valor))
+}
+
+func decodificarCursorDiario(cursor string) (time.Time, uint, error) {
+	valor, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, dominio.ErrCursorDiarioInvalido
+	}
+	partes := strings.Split(string(valor), ":")
+	if len(partes) != 2 {
+		return time.Time{}, 0, dominio.ErrCursorDiarioInvalido
+	}
+	nanos, errNanos := strconv.ParseInt(partes[0], 10, 64)
+	id, errID := strconv.ParseUint(partes[1], 10, 64)
+	if errNanos != nil || errID != nil {
+		return time.Time{}, 0, dominio.ErrCursorDiarioInvalido
+	}
+	return time.Unix(0, nanos).UTC(), uint(id), nil
+}
+
+// buscarRegistroDoPaciente carrega o registro apenas se ele pertencer ao paciente autenticado
+func (rhs *registroHumorServico) buscarRegistroDoPaciente(tx *gorm.DB, registroID, userID uint) (*dominio.RegistroHumor, error) {
+	paciente, err := buscarPacienteDoUsuario(tx, rhs.usuarioRepositorio, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	registro, err := rhs.repositorio.BuscarRegistroHumorPorID(tx, registroID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrRegistroHumorNaoEncontrado
+		}
+		return nil, err
+	}
+	// Registro de outro paciente e tratado como inexistente
+	if registro.PacienteID != paciente.ID {
+		return nil, dominio.ErrRegistroHumorNaoEncontrado
+	}
+	return registro, nil
+}
+
+func (rhs *registroHumorServico) buscarRegistroCorrigivel(tx *gorm.DB, registroID, userID uint) (*dominio.RegistroHumor, error) {
+	registro, err := rhs.buscarRegistroDoPaciente(tx, registroID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := registro.PodeCorrigir(time.Now(), rhs.janelaCorrecao); err != nil {
+		return nil, err
+	}
+	return registro, nil
+}
+
+// criarRevisao numera a revisao em sequencia as anteriores do registro
+func (rhs *registroHumorServico) criarRevisao(tx *gorm.DB, registro *dominio.RegistroHumor, operacao string) error {
+	anteriores, err := rhs.repositorio.ListarRevisoesRegistroHumor(tx, registro.ID)
+	if err != nil {
+		return err
+	}
+	return rhs.repositorio.CriarRevisaoRegistroHumor(tx, dominio.NovaRevisaoRegistroHumor(registro, uint(len(anteriores))+1, operacao))
+}
+
+// dispararMonitoramento reavalia os registros recentes do paciente apos cada mudanca
+// Executa em uma goroutine para não bloquear a resposta da API
+func (rhs *registroHumorServico) dispararMonitoramento(pacienteID uint) {
 	go func(pacID uint) {
 		// TODO: tratar erros/logs aqui internamente
 		_ = rhs.analiseServico.ExecutarMonitoramento(pacID)
-	}(registroHumorRealizado.PacienteID)
-
-	return registroHumorRealizado, nil
+	}(pacienteID)
 }
@@ -0,0 +1,505 @@
+package servicos
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/mappers"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// limiteListagemResponsavel limita as notificacoes e as acoes devolvidas de uma vez
+const limiteListagemResponsavel = 50
+
+// ResponsavelServico define o acesso delegado do responsavel aos pacientes dependentes
+type ResponsavelServico interface {
+	RegistrarResponsavel(dtoIn *dtos.RegistrarResponsavelDTOIn) (*dtos.ResponsavelDTOOut, error)
+	// Visao do paciente
+	AutorizarResponsavel(userID uint, dtoIn *dtos.AutorizarResponsavelDTOIn) (*dtos.VinculoResponsavelDTOOut, error)
+	ListarResponsaveis(userID uint) ([]*dtos.VinculoResponsavelDTOOut, error)
+	RemoverResponsavel(userID, responsavelID uint) error
+	ListarAcoesSobrePaciente(userID uint) ([]*dtos.AcaoResponsavelDTOOut, error)
+	// Visao do responsavel
+	ListarDependentes(userID uint) ([]*dtos.VinculoResponsavelDTOOut, error)
+	ResumoDependente(userID, pacienteID uint) (*dtos.ResumoPacienteDTOOut, error)
+	ListarAtribuicoesDependente(userID, pacienteID uint) ([]*dtos.AtribuicaoDTOOut, error)
+	PerguntasAtribuicaoDependente(userID, pacienteID, atribuicaoID uint) (*dtos.AtribuicaoDTOOut, error)
+	ResponderAtribuicaoDependente(userID, pacienteID uint, dtoIn *dtos.RegistroRespostaDTOIn) error
+	ConsentimentosDependente(userID, pacienteID uint) ([]*dtos.ConsentimentoDTOOut, error)
+	AtualizarConsentimentoDependente(userID, pacienteID, profissionalID uint, dtoIn *dtos.AtualizarConsentimentoDTOIn) (*dtos.ConsentimentoDTOOut, error)
+	RevogarConsentimentoDependente(userID, pacienteID, profissionalID uint) (*dtos.ConsentimentoDTOOut, error)
+	EncerrarDependente(userID, pacienteID uint) error
+	ListarNotificacoes(userID uint) ([]*dtos.NotificacaoExportacaoDTOOut, error)
+}
+
+// responsavelServico implementa a interface ResponsavelServico
+type responsavelServico struct {
+	db                      *gorm.DB
+	usuarioRepo             repositorios.UsuarioRepositorio
+	responsavelRepo         repositorios.ResponsavelRepositorio
+	consentimentoRepo       repositorios.ConsentimentoRepositorio
+	instrumentoRepo         repositorios.InstrumentoRepositorio
+	notificacaoRepo         repositorios.NotificacaoRepositorio
+	verificacaoEmailServico VerificacaoEmailServico
+	resumoServico           ResumoServico
+	instrumentoServico      InstrumentoServico
+}
+
+// NovoResponsavelServico cria uma nova instancia de ResponsavelServico
+// As leituras do dependente reaproveitam os servicos usados pelo proprio paciente
+func NovoResponsavelServico(db *gorm.DB, ur repositorios.UsuarioRepositorio, rr repositorios.ResponsavelRepositorio, cr repositorios.ConsentimentoRepositorio, ir repositorios.InstrumentoRepositorio, nr repositorios.NotificacaoRepositorio, ves VerificacaoEmailServico, rs ResumoServico, is InstrumentoServico) ResponsavelServico {
+	return &responsavelServico{
+		db:                      db,
+		usuarioRepo:             ur,
+		responsavelRepo:         rr,
+		consentimentoRepo:       cr,
+		instrumentoRepo:         ir,
+		notificacaoRepo:         nr,
+		verificacaoEmailServico: ves,
+		resumoServico:           rs,
+		instrumentoServico:      is,
+	}
+}
+
+// RegistrarResponsavel cria a conta do responsavel, liberada apos a confirmacao do e-mail
+func (s *responsavelServico) RegistrarResponsavel(dtoIn *dtos.RegistrarResponsavelDTOIn) (*dtos.ResponsavelDTOOut, error) {
+	var responsavel *dominio.Responsavel
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		_, err := s.usuarioRepo.BuscarPorEmail(dtoIn.Email)
+		if err == nil {
+			return dominio.ErrEmailJaCadastrado
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		usuario := &dominio.Usuario{
+			Nome:        dtoIn.Nome,
+			Email:       dtoIn.Email,
+			TipoUsuario: dominio.TipoUsuarioResponsavel,
+			CPF:         dtoIn.CPF,
+			Contato:     dtoIn.Contato,
+			// Conta so e liberada apos a confirmacao do e-mail
+			VerificacaoEmailPendente: true,
+		}
+		if err := usuario.Validar(); err != nil {
+			return err
+		}
+		if err := usuario.ValidarSenha(dtoIn.Senha); err != nil {
+			return err
+		}
+		if err := verificarCPFDisponivel(s.usuarioRepo, usuario.CPF); err != nil {
+			return err
+		}
+
+		hashSenha, err := bcrypt.GenerateFromPassword([]byte(dtoIn.Senha), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		usuario.Senha = string(hashSenha)
+
+		if err := s.usuarioRepo.CriarUsuario(tx, usuario); err != nil {
+			return err
+		}
+
+		responsavel = &dominio.Responsavel{UsuarioID: usuario.ID, Usuario: *usuario}
+		return s.responsavelRepo.CriarResponsavel(tx, responsavel)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verificacaoEmailServico.IniciarVerificacao(&responsavel.Usuario); err != nil {
+		log.Printf("falha ao iniciar verificacao de e-mail do usuario %d: %v", responsavel.UsuarioID, err)
+	}
+
+	return mappers.ResponsavelParaDTOOut(responsavel), nil
+}
+
+// AutorizarResponsavel vincula ao paciente a conta de responsavel com o e-mail informado
+// Um vinculo encerrado com o mesmo responsavel e reativado
+func (s *responsavelServico) AutorizarResponsavel(userID uint, dtoIn *dtos.AutorizarResponsavelDTOIn) (*dtos.VinculoResponsavelDTOOut, error) {
+	agora := time.Now()
+	var vinculo *dominio.VinculoResponsavel
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		paciente, err := buscarPacienteDoUsuario(tx, s.usuarioRepo, userID)
+		if err != nil {
+			return err
+		}
+		if !paciente.AceitaResponsavel(agora) {
+			return dominio.ErrPacienteNaoDependente
+		}
+
+		usuario, err := s.usuarioRepo.BuscarPorEmail(dtoIn.Email)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrResponsavelNaoEncontrado
+			}
+			return err
+		}
+		responsavel, err := s.buscarResponsavel(tx, usuario.ID)
+		if err != nil {
+			return err
+		}
+
+		vinculo, err = s.responsavelRepo.BuscarVinculoResponsavel(tx, responsavel.ID, paciente.ID)
+		switch {
+		case err == nil:
+			if err := vinculo.Reativar(dtoIn.Parentesco, agora); err != nil {
+				return err
+			}
+			if err := s.responsavelRepo.AtualizarVinculoResponsavel(tx, vinculo); err != nil {
+				return err
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if vinculo, err = dominio.NovoVinculoResponsavel(responsavel.ID, paciente.ID, dtoIn.Parentesco, agora); err != nil {
+				return err
+			}
+			if err := s.responsavelRepo.CriarVinculoResponsavel(tx, vinculo); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+		vinculo.Responsavel = *responsavel
+		vinculo.Paciente = *paciente
+
+		conteudo := fmt.Sprintf("%s autorizou voce como responsavel.", paciente.Usuario.Nome)
+		return criarNotificacao(tx, s.notificacaoRepo, responsavel.UsuarioID, conteudo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.VinculoResponsavelParaDTOOut(vinculo, agora), nil
+}
+
+// ListarResponsaveis retorna os responsaveis com vinculo ativo do paciente
+func (s *responsavelServico) ListarResponsaveis(userID uint) ([]*dtos.VinculoResponsavelDTOOut, error) {
+	paciente, err := buscarPacienteDoUsuario(s.db, s.usuarioRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	vinculos, err := s.responsavelRepo.ListarResponsaveisDoPaciente(s.db, paciente.ID)
+	if err != nil {
+		return nil, err
+	}
+	return mappers.VinculosResponsavelParaDTOOut(vinculos, time.Now()), nil
+}
+
+// RemoverResponsavel encerra o vinculo a pedido do paciente
+// O paciente menor de idade nao pode remover o responsavel
+func (s *responsavelServico) RemoverResponsavel(userID, responsavelID uint) error {
+	agora := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		paciente, err := buscarPacienteDoUsuario(tx, s.usuarioRepo, userID)
+		if err != nil {
+			return err
+		}
+		vinculo, err := s.responsavelRepo.BuscarVinculoResponsavel(tx, responsavelID, paciente.ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrResponsavelNaoEncontrado
+			}
+			return err
+		}
+		if err := vinculo.Encerrar(dominio.TutelaEncerradaPeloPaciente, paciente, agora); err != nil {
+			return err
+		}
+		if err := s.responsavelRepo.AtualizarVinculoResponsavel(tx, vinculo); err != nil {
+			return err
+		}
+
+		conteudo := fmt.Sprintf("%s removeu voce como responsavel.", paciente.Usuario.Nome)
+		return criarNotificacao(tx, s.notificacaoRepo, vinculo.Responsavel.UsuarioID, conteudo)
+	})
+}
+
+// ListarAcoesSobrePaciente mostra ao paciente o que os responsaveis fizeram em seu nome
+func (s *responsavelServico) ListarAcoesSobrePaciente(userID uint) ([]*dtos.AcaoResponsavelDTOOut, error) {
+	paciente, err := buscarPacienteDoUsuario(s.db, s.usuarioRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	acoes, err := s.responsavelRepo.ListarAcoesSobrePaciente(s.db, paciente.ID, limiteListagemResponsavel)
+	if err != nil {
+		return nil, err
+	}
+	return mappers.AcoesResponsavelParaDTOOut(acoes), nil
+}
+
+// ListarDependentes retorna os pacientes acompanhados pelo responsavel
+func (s *responsavelServico) ListarDependentes(userID uint) ([]*dtos.VinculoResponsavelDTOOut, error) {
+	responsavel, err := s.buscarResponsavel(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	vinculos, err := s.responsavelRepo.ListarDependentes(s.db, responsavel.ID)
+	if err != nil {
+		return nil, err
+	}
+	return mappers.VinculosResponsavelParaDTOOut(vinculos, time.Now()), nil
+}
+
+// ResumoDependente retorna o resumo do dependente sem as anotacoes livres do diario
+func (s *responsavelServico) ResumoDependente(userID, pacienteID uint) (*dtos.ResumoPacienteDTOOut, error) {
+	vinculo, err := s.buscarDependente(s.db, userID, pacienteID)
+	if err != nil {
+		return nil, err
+	}
+
+	resumo, err := s.resumoServico.GerarResumoPaciente(vinculo.Paciente.UsuarioID)
+	if err != nil {
+		return nil, err
+	}
+	resumo.Anotacao = ""
+
+	if err := s.registrarAcao(s.db, vinculo, dominio.AcaoResponsavelVerResumo, ""); err != nil {
+		return nil, err
+	}
+	return resumo, nil
+}
+
+// ListarAtribuicoesDependente retorna os questionarios atribuidos ao dependente
+func (s *responsavelServico) ListarAtribuicoesDependente(userID, pacienteID uint) ([]*dtos.AtribuicaoDTOOut, error) {
+	vinculo, err := s.buscarDependente(s.db, userID, pacienteID)
+	if err != nil {
+		return nil, err
+	}
+
+	atribuicoes, err := s.instrumentoServico.ListarAtribuicoesPaciente(vinculo.Paciente.UsuarioID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.registrarAcao(s.db, vinculo, dominio.AcaoResponsavelListarAtribuicoes, ""); err != nil {
+		return nil, err
+	}
+	return atribuicoes, nil
+}
+
+// PerguntasAtribuicaoDependente apresenta as perguntas de uma atribuicao do dependente
+func (s *responsavelServico) PerguntasAtribuicaoDependente(userID, pacienteID, atribuicaoID uint) (*dtos.AtribuicaoDTOOut, error) {
+	vinculo, err := s.buscarDependente(s.db, userID, pacienteID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verificarAtribuicaoDoDependente(vinculo, atribuicaoID); err != nil {
+		return nil, err
+	}
+
+	atribuicao, err := s.instrumentoServico.ListarPerguntasAtribuicao(vinculo.Paciente.UsuarioID, atribuicaoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.registrarAcao(s.db, vinculo, dominio.AcaoResponsavelVerAtribuicao, fmt.Sprintf("atribuicao %d", atribuicaoID)); err != nil {
+		return nil, err
+	}
+	return atribuicao, nil
+}
+
+// ResponderAtribuicaoDependente registra as respostas do questionario em nome do dependente
+func (s *responsavelServico) ResponderAtribuicaoDependente(userID, pacienteID uint, dtoIn *dtos.RegistroRespostaDTOIn) error {
+	vinculo, err := s.buscarDependente(s.db, userID, pacienteID)
+	if err != nil {
+		return err
+	}
+	if err := s.verificarAtribuicaoDoDependente(vinculo, dtoIn.AtribuicaoID); err != nil {
+		return err
+	}
+
+	if err := s.instrumentoServico.CriarRespostasAtribuicao(dtoIn); err != nil {
+		return err
+	}
+	return s.registrarAcao(s.db, vinculo, dominio.AcaoResponsavelResponderAtribuicao, fmt.Sprintf("atribuicao %d", dtoIn.AtribuicaoID))
+}
+
+// ConsentimentosDependente retorna a versao vigente do consentimento do dependente com cada profissional
+func (s *responsavelServico) ConsentimentosDependente(userID, pacienteID uint) ([]*dtos.ConsentimentoDTOOut, error) {
+	vinculo, err := s.buscarDependente(s.db, userID, pacienteID)
+	if err != nil {
+		return nil, err
+	}
+
+	consentimentos, err := s.consentimentoRepo.ListarConsentimentosAtuaisDoPaciente(s.db, vinculo.PacienteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.registrarAcao(s.db, vinculo, dominio.AcaoResponsavelVerConsentimentos, ""); err != nil {
+		return nil, err
+	}
+	return mappers.ConsentimentosParaDTOOut(consentimentos, time.Now()), nil
+}
+
+// AtualizarConsentimentoDependente grava uma nova versao do consentimento do dependente
+// Ao contrario do paciente menor de idade, o responsavel pode ampliar o compartilhamento
+func (s *responsavelServico) AtualizarConsentimentoDependente(userID, pacienteID, profissionalID uint, dtoIn *dtos.AtualizarConsentimentoDTOIn) (*dtos.ConsentimentoDTOOut, error) {
+	agora := time.Now()
+	var novo *dominio.Consentimento
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		vinculo, err := s.buscarDependente(tx, userID, pacienteID)
+		if err != nil {
+			return err
+		}
+		if novo, err = gravarNovaVersaoConsentimento(tx, s.consentimentoRepo, vinculo.PacienteID, profissionalID, dtoIn, false, agora); err != nil {
+			return err
+		}
+		return s.registrarAcao(tx, vinculo, dominio.AcaoResponsavelAtualizarConsentimento,
+			fmt.Sprintf("profissional %d, versao %d", profissionalID, novo.Versao))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ConsentimentoParaDTOOut(novo, agora), nil
+}
+
+// RevogarConsentimentoDependente interrompe o compartilhamento do dependente com o profissional
+func (s *responsavelServico) RevogarConsentimentoDependente(userID, pacienteID, profissionalID uint) (*dtos.ConsentimentoDTOOut, error) {
+	var revogado *dominio.Consentimento
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		vinculo, err := s.buscarDependente(tx, userID, pacienteID)
+		if err != nil {
+			return err
+		}
+		if revogado, err = revogarConsentimentoDoPar(tx, s.consentimentoRepo, vinculo.PacienteID, profissionalID); err != nil {
+			return err
+		}
+		return s.registrarAcao(tx, vinculo, dominio.AcaoResponsavelRevogarConsentimento,
+			fmt.Sprintf("profissional %d, versao %d", profissionalID, revogado.Versao))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.ConsentimentoParaDTOOut(revogado, time.Now()), nil
+}
+
+// EncerrarDependente encerra o acompanhamento a pedido do responsavel e avisa o paciente
+func (s *responsavelServico) EncerrarDependente(userID, pacienteID uint) error {
+	agora := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		vinculo, err := s.buscarDependente(tx, userID, pacienteID)
+		if err != nil {
+			return err
+		}
+		if err := vinculo.Encerrar(dominio.TutelaEncerradaPeloResponsavel, &vinculo.Paciente, agora); err != nil {
+			return err
+		}
+		if err := s.responsavelRepo.AtualizarVinculoResponsavel(tx, vinculo); err != nil {
+			return err
+		}
+		if err := s.registrarAcao(tx, vinculo, dominio.AcaoResponsavelEncerrarVinculo, ""); err != nil {
+			return err
+		}
+		return criarNotificacao(tx, s.notificacaoRepo, vinculo.Paciente.UsuarioID, "Um responsavel encerrou o acompanhamento da sua conta.")
+	})
+}
+
+// ListarNotificacoes retorna as notificacoes mais recentes do responsavel
+func (s *responsavelServico) ListarNotificacoes(userID uint) ([]*dtos.NotificacaoExportacaoDTOOut, error) {
+	if _, err := s.buscarResponsavel(s.db, userID); err != nil {
+		return nil, err
+	}
+	notificacoes, err := s.notificacaoRepo.ListarNotificacoesDoUsuario(s.db, userID, limiteListagemResponsavel)
+	if err != nil {
+		return nil, err
+	}
+	return mappers.NotificacoesParaExportacaoDTOOut(notificacoes), nil
+}
+
+func (s *responsavelServico) buscarResponsavel(tx *gorm.DB, userID uint) (*dominio.Responsavel, error) {
+	responsavel, err := s.responsavelRepo.BuscarResponsavelPorUsuarioID(tx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrResponsavelNaoEncontrado
+		}
+		return nil, err
+	}
+	return responsavel, nil
+}
+
+// buscarDependente confirma que o responsavel ainda acompanha o paciente
+// Vinculos encerrados respondem como dependente inexistente
+func (s *responsavelServico) buscarDependente(tx *gorm.DB, userID, pacienteID uint) (*dominio.VinculoResponsavel, error) {
+	responsavel, err := s.buscarResponsavel(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	vinculo, err := s.responsavelRepo.BuscarVinculoResponsavel(tx, responsavel.ID, pacienteID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrDependenteNaoEncontrado
+		}
+		return nil, err
+	}
+	if !vinculo.Ativo() {
+		return nil, dominio.ErrDependenteNaoEncontrado
+	}
+	return vinculo, nil
+}
+
+// verificarAtribuicaoDoDependente impede o acesso a atribuicoes de outros pacientes
+func (s *responsavelServico) verificarAtribuicaoDoDependente(vinculo *dominio.VinculoResponsavel, atribuicaoID uint) error {
+	atribuicao, err := s.instrumentoRepo.BuscarAtribuicaoPorID(s.db, atribuicaoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dominio.ErrAtribuicaoDeOutroPaciente
+		}
+		return err
+	}
+	if atribuicao.PacienteID != vinculo.PacienteID {
+		return dominio.ErrAtribuicaoDeOutroPaciente
+	}
+	return nil
+}
+
+// registrarAcao grava a acao do responsavel na auditoria consultada pelo paciente
+func (s *responsavelServico) registrarAcao(tx *gorm.DB, vinculo *dominio.VinculoResponsavel, acao, detalhe string) error {
+	registro := &dominio.AcaoResponsavel{
+		ResponsavelID: vinculo.ResponsavelID,
+		PacienteID:    vinculo.PacienteID,
+		Acao:          acao,
+		Detalhe:       detalhe,
+	}
+	if err := registro.Validar(); err != nil {
+		return err
+	}
+	return s.responsavelRepo.CriarAcao(tx, registro)
+}
+
+// buscarPacienteDoUsuario retorna o paciente do usuario autenticado
+func buscarPacienteDoUsuario(tx *gorm.DB, repo repositorios.UsuarioRepositorio, userID uint) (*dominio.Paciente, error) {
+	paciente, err := repo.BuscarPacientePorUsuarioID(tx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrUsuarioNaoEncontrado
+		}
+		return nil, err
+	}
+	return paciente, nil
+}
+
+// notificarResponsaveis avisa os responsaveis ativos do paciente
+// A mensagem recebe o nome do dependente no lugar de %s
+func notificarResponsaveis(tx *gorm.DB, rr repositorios.ResponsavelRepositorio, nr repositorios.NotificacaoRepositorio, pacienteID uint, mensagem string) error {
+	vinculos, err := rr.ListarResponsaveisDoPaciente(tx, pacienteID)
+	if err != nil {
+		return err
+	}
+	for _, vinculo := range vinculos {
+		if err := criarNotificacao(tx, nr, vinculo.Responsavel.UsuarioID, fmt.Sprintf(mensagem, vinculo.Paciente.Usuario.Nome)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// criarSemanaAgregada grava registros nos 6 dias anteriores a hoje no fuso, exceto ha 3 dias;
+// ontem tem dois registros, com humor 1 e 5
+func criarSemanaAgregada(t *testing.T, db *gorm.DB, fuso *time.Location) time.Time {
+	agora := time.Now().In(fuso)
+	hoje := time.Date(agora.Year(), agora.Month(), agora.Day(), 0, 0, 0, 0, fuso)
+	criar := func(diasAtras, hora int, humor int16) {
+		assert.NoError(t, db.Create(&dominio.RegistroHumor{
+			PacienteID: 1, NivelHumor: humor, HorasSono: 7, NivelEnergia: 5, NivelStress: 4, AutoCuidado: "[]",
+			DataHoraRegistro: hoje.AddDate(0, 0, -diasAtras).Add(time.Duration(hora) * time.Hour),
+		}).Error)
+	}
+	for _, diasAtras := range []int{6, 5, 4, 2} {
+		criar(diasAtras, 12, 3)
+	}
+	criar(1, 8, 1)
+	criar(1, 20, 5)
+	return hoje
+}
+
+func TestAnaliseServico_GerarAnaliseAgregada_PorDia(t *testing.T) {
+	svc, db := setupMetricas(t, nil)
+	fuso, _ := time.LoadLocation("America/Sao_Paulo")
+	hoje := criarSemanaAgregada(t, db, fuso)
+
+	analise, err := svc.analise.GerarAnaliseAgregada(10, 0, "paciente", &dtos.FiltroAnaliseAgregadaDTOIn{Dias: 7, Agregacao: "ultimo", Fuso: "America/Sao_Paulo"})
+	assert.NoError(t, err)
+	assert.Equal(t, "America/Sao_Paulo", analise.Fuso)
+	assert.Equal(t, dominio.AgrupamentoDia, analise.Agrupamento)
+	assert.Equal(t, dominio.AgregacaoUltimo, analise.Agregacao)
+	assert.True(t, analise.Inicio.Equal(hoje.AddDate(0, 0, -6)))
+	assert.Nil(t, analise.CategoriasCompartilhadas)
+	assert.Len(t, analise.Series, 4)
+
+	humor := analise.Series[0]
+	assert.Equal(t, "humor", humor.Metrica)
+	assert.Len(t, humor.Periodos, 7)
+	// Ha 3 dias e hoje ficam como lacunas
+	assert.Nil(t, humor.Periodos[3].Valor)
+	assert.Equal(t, 0, humor.Periodos[3].Registros)
+	assert.Nil(t, humor.Periodos[6].Valor)
+	assert.Equal(t, 2, humor.Periodos[5].Registros)
+	assert.Equal(t, 5.0, *humor.Periodos[5].Valor)
+	assert.NotNil(t, humor.Periodos[3].MediaMovel7Dias)
+	// Cada dia pesa o mesmo na media do periodo: ontem conta como 3
+	assert.InDelta(t, 3.0, *humor.MediaDiaria, 1e-9)
+	assert.Len(t, humor.PerfilSemanal, 7)
+	assert.Equal(t, "DOMINGO", humor.PerfilSemanal[0].DiaSemana)
+}
+
+func TestAnaliseServico_GerarAnaliseAgregada_ProfissionalPorSemana(t *testing.T) {
+	svc, db := setupMetricas(t, consentimentoDiario(true, false, false, time.Now().AddDate(0, 0, -60)))
+	criarSemanaAgregada(t, db, time.Local)
+
+	analise, err := svc.analise.GerarAnaliseAgregada(50, 1, "profissional", &dtos.FiltroAnaliseAgregadaDTOIn{Dias: 28, Agrupamento: "SEMANA"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{dominio.CategoriaHumor}, analise.CategoriasCompartilhadas)
+	// Sem a categoria sono, a serie de sono fica de fora
+	chaves := make([]string, 0, len(analise.Series))
+	for _, serie := range analise.Series {
+		chaves = append(chaves, serie.Metrica)
+	}
+	assert.Equal(t, []string{"humor", "energia", "stress"}, chaves)
+
+	registros := 0
+	for _, periodo := range analise.Series[0].Periodos {
+		assert.Equal(t, time.Monday, periodo.Inicio.Weekday())
+		assert.Nil(t, periodo.MediaMovel7Dias)
+		registros += periodo.Registros
+	}
+	assert.Equal(t, 6, registros)
+}
+
+func TestAnaliseServico_GerarAnaliseAgregada_ParametrosInvalidos(t *testing.T) {
+	svc, _ := setupMetricas(t, nil)
+
+	tests := []struct {
+		name    string
+		filtro  dtos.FiltroAnaliseAgregadaDTOIn
+		wantErr error
+	}{
+		{name: "Periodo longo", filtro: dtos.FiltroAnaliseAgregadaDTOIn{Dias: 400}, wantErr: dominio.ErrPeriodoAnaliseInvalido},
+		{name: "Agrupamento", filtro: dtos.FiltroAnaliseAgregadaDTOIn{Agrupamento: "MES"}, wantErr: dominio.ErrAgrupamentoInvalido},
+		{name: "Agregacao", filtro: dtos.FiltroAnaliseAgregadaDTOIn{Agregacao: "MEDIANA"}, wantErr: dominio.ErrAgregacaoInvalida},
+		{name: "Fuso", filtro: dtos.FiltroAnaliseAgregadaDTOIn{Fuso: "Marte/Olympus"}, wantErr: dominio.ErrFusoInvalido},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.analise.GerarAnaliseAgregada(10, 0, "paciente", &tt.filtro)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
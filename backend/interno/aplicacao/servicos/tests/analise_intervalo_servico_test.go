@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// criarRegistrosDiarios grava dois registros por dia, as 9h e as 21h, nos dias informados (contados a partir de hoje)
+func criarRegistrosDiarios(t *testing.T, db *gorm.DB, diasAtras []int, humor, sono int16) time.Time {
+	agora := time.Now()
+	hoje := time.Date(agora.Year(), agora.Month(), agora.Day(), 0, 0, 0, 0, time.Local)
+	for _, d := range diasAtras {
+		for _, hora := range []int{9, 21} {
+			assert.NoError(t, db.Create(&dominio.RegistroHumor{
+				PacienteID: 1, NivelHumor: humor, HorasSono: sono, NivelEnergia: 5, NivelStress: int16(hora / 3), AutoCuidado: "[]",
+				DataHoraRegistro: hoje.AddDate(0, 0, -d).Add(time.Duration(hora) * time.Hour),
+			}).Error)
+		}
+	}
+	return hoje
+}
+
+func TestAnaliseServico_GerarAnaliseIntervalo_AmostragemPorTamanho(t *testing.T) {
+	svc, db := setupMetricas(t, nil)
+	hoje := criarRegistrosDiarios(t, db, []int{10, 20, 150, 300}, 3, 7)
+
+	tests := []struct {
+		name       string
+		dias       int
+		amostragem string
+		pontos     int
+		registros  int
+	}{
+		{name: "Ate 90 dias, um ponto por registro", dias: 30, amostragem: dominio.AmostragemRegistro, pontos: 4, registros: 4},
+		{name: "Ate 180 dias, um ponto por dia", dias: 180, amostragem: dominio.AmostragemDia, pontos: 3, registros: 6},
+		{name: "Acima de 180 dias, um ponto por semana", dias: 366, amostragem: dominio.AmostragemSemana, pontos: 4, registros: 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intervalo := dtos.IntervaloAnaliseDTOIn{De: hoje.AddDate(0, 0, 1-tt.dias), Ate: hoje}
+			analise, err := svc.analise.GerarAnaliseIntervalo(10, 0, "paciente", intervalo)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.amostragem, analise.Amostragem)
+			assert.True(t, analise.Inicio.Equal(intervalo.De))
+			assert.Len(t, analise.GraficoStress, tt.pontos)
+			assert.Equal(t, tt.registros, analise.TotalRegistros)
+		})
+	}
+
+	// Na amostragem por dia, o valor e a media dos dois registros e a data e o inicio do dia
+	analise, err := svc.analise.GerarAnaliseIntervalo(10, 0, "paciente", dtos.IntervaloAnaliseDTOIn{De: hoje.AddDate(0, 0, -179), Ate: hoje})
+	assert.NoError(t, err)
+	ponto := analise.GraficoStress[len(analise.GraficoStress)-1]
+	assert.True(t, ponto.Data.Equal(hoje.AddDate(0, 0, -10)))
+	assert.InDelta(t, 5.0, ponto.Valor, 1e-9)
+	assert.Equal(t, int16(3), ponto.Humor)
+	// As medias continuam usando todos os registros
+	assert.InDelta(t, 7.0, analise.MediaSono, 1e-9)
+}
+
+func TestAnaliseServico_GerarAnaliseIntervalo_LimitaAoIntervalo(t *testing.T) {
+	svc, db := setupMetricas(t, nil)
+	hoje := criarRegistrosDiarios(t, db, []int{3, 5, 8}, 3, 7)
+
+	analise, err := svc.analise.GerarAnaliseIntervalo(10, 0, "paciente", dtos.IntervaloAnaliseDTOIn{De: hoje.AddDate(0, 0, -5), Ate: hoje.AddDate(0, 0, -3)})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, analise.TotalRegistros)
+	assert.True(t, analise.Fim.Equal(hoje.AddDate(0, 0, -2).Add(-time.Nanosecond)))
+
+	// 'ate' no futuro vale ate agora
+	analise, err = svc.analise.GerarAnaliseIntervalo(10, 0, "paciente", dtos.IntervaloAnaliseDTOIn{De: hoje.AddDate(0, 0, -5), Ate: hoje.AddDate(0, 0, 10)})
+	assert.NoError(t, err)
+	assert.False(t, analise.Fim.After(time.Now()))
+}
+
+func TestAnaliseServico_GerarAnaliseIntervalo_Invalido(t *testing.T) {
+	svc, _ := setupMetricas(t, nil)
+	hoje := time.Now()
+
+	tests := []struct {
+		name      string
+		intervalo dtos.IntervaloAnaliseDTOIn
+	}{
+		{name: "De depois de ate", intervalo: dtos.IntervaloAnaliseDTOIn{De: hoje.AddDate(0, 0, -1), Ate: hoje.AddDate(0, 0, -2)}},
+		{name: "De no futuro", intervalo: dtos.IntervaloAnaliseDTOIn{De: hoje.AddDate(0, 0, 2), Ate: hoje.AddDate(0, 0, 3)}},
+		{name: "Mais de 366 dias", intervalo: dtos.IntervaloAnaliseDTOIn{De: hoje.AddDate(0, 0, -366), Ate: hoje}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.analise.GerarAnaliseIntervalo(10, 0, "paciente", tt.intervalo)
+			assert.Equal(t, dominio.ErrIntervaloAnaliseInvalido, err)
+		})
+	}
+
+	_, err := svc.analise.GerarAnaliseHistorica(10, 0, "paciente", 400)
+	assert.Equal(t, dominio.ErrPeriodoHistoricoInvalido, err)
+}
+
+func TestAnaliseServico_CompararPeriodos(t *testing.T) {
+	svc, db := setupMetricas(t, nil)
+	hoje := criarRegistrosDiarios(t, db, []int{1, 2, 3}, 4, 8)
+	criarRegistrosDiarios(t, db, []int{31, 32}, 2, 6)
+
+	periodo := dtos.IntervaloAnaliseDTOIn{De: hoje.AddDate(0, 0, -7), Ate: hoje}
+	referencia := dtos.IntervaloAnaliseDTOIn{De: hoje.AddDate(0, 0, -37), Ate: hoje.AddDate(0, 0, -30)}
+	comparacao, err := svc.analise.CompararPeriodos(10, 0, "paciente", periodo, referencia)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, comparacao.Periodo.TotalRegistros)
+	assert.Equal(t, 4, comparacao.Referencia.TotalRegistros)
+	assert.Equal(t, 2, comparacao.Diferencas.TotalRegistros)
+	assert.InDelta(t, 2.0, *comparacao.Diferencas.MediaHumor, 1e-9)
+	assert.InDelta(t, 2.0, *comparacao.Diferencas.MediaSono, 1e-9)
+	assert.InDelta(t, 0.0, *comparacao.Diferencas.MediaEnergia, 1e-9)
+
+	// Sem registros na referencia nao ha diferenca de medias
+	vazio := dtos.IntervaloAnaliseDTOIn{De: hoje.AddDate(0, 0, -20), Ate: hoje.AddDate(0, 0, -15)}
+	comparacao, err = svc.analise.CompararPeriodos(10, 0, "paciente", periodo, vazio)
+	assert.NoError(t, err)
+	assert.Nil(t, comparacao.Diferencas.MediaHumor)
+	assert.Equal(t, 6, comparacao.Diferencas.TotalRegistros)
+}
+
+func TestAnaliseServico_CompararPeriodos_ProfissionalSemSono(t *testing.T) {
+	svc, db := setupMetricas(t, consentimentoDiario(true, false, false, time.Now().AddDate(0, 0, -60)))
+	hoje := criarRegistrosDiarios(t, db, []int{1, 31}, 3, 7)
+
+	comparacao, err := svc.analise.CompararPeriodos(50, 1, "profissional",
+		dtos.IntervaloAnaliseDTOIn{De: hoje.AddDate(0, 0, -7), Ate: hoje},
+		dtos.IntervaloAnaliseDTOIn{De: hoje.AddDate(0, 0, -37), Ate: hoje.AddDate(0, 0, -30)})
+	assert.NoError(t, err)
+	assert.Nil(t, comparacao.Diferencas.MediaSono)
+	assert.NotNil(t, comparacao.Diferencas.MediaHumor)
+
+}
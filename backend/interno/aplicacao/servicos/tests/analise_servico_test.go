@@ -4,6 +4,7 @@ import (
 	"errors"
 	"mindtrace/backend/interno/aplicacao/servicos"
 	"mindtrace/backend/interno/dominio"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
 	"testing"
 	"time"
 
@@ -44,6 +45,38 @@ func (m *MockRegistroHumorRepositorioRelatorio) BuscarPorNUltimosRegistros(pacie
 	return args.Get(0).([]*dominio.RegistroHumor), args.Error(1)
 }
 
+func (m *MockRegistroHumorRepositorioRelatorio) BuscarRegistroHumorPorID(tx *gorm.DB, registroID uint) (*dominio.RegistroHumor, error) {
+	return nil, nil
+}
+
+func (m *MockRegistroHumorRepositorioRelatorio) AtualizarRegistroHumor(tx *gorm.DB, registro *dominio.RegistroHumor) error {
+	return nil
+}
+
+func (m *MockRegistroHumorRepositorioRelatorio) ExcluirRegistroHumor(tx *gorm.DB, registroID uint) error {
+	return nil
+}
+
+func (m *MockRegistroHumorRepositorioRelatorio) CriarRevisaoRegistroHumor(tx *gorm.DB, revisao *dominio.RevisaoRegistroHumor) error {
+	return nil
+}
+
+func (m *MockRegistroHumorRepositorioRelatorio) ListarRevisoesRegistroHumor(tx *gorm.DB, registroID uint) ([]*dominio.RevisaoRegistroHumor, error) {
+	return nil, nil
+}
+
+func (m *MockRegistroHumorRepositorioRelatorio) ListarRegistrosHumor(tx *gorm.DB, pacienteID uint, filtro *dominio.FiltroRegistrosHumor, limite int) ([]*dominio.RegistroHumor, error) {
+	return nil, nil
+}
+
+func (m *MockRegistroHumorRepositorioRelatorio) CriarRegistroHumorSeNovo(tx *gorm.DB, registro *dominio.RegistroHumor) (bool, error) {
+	return false, nil
+}
+
+func (m *MockRegistroHumorRepositorioRelatorio) BuscarRegistrosHumorPorChaves(tx *gorm.DB, pacienteID uint, chaves []string) ([]*dominio.RegistroHumor, error) {
+	return nil, nil
+}
+
 // MockUsuarioRepositorioRelatorio simula o repositorio de usuarios
 type MockUsuarioRepositorioRelatorio struct {
 	mock.Mock
@@ -65,6 +98,10 @@ func (m *MockUsuarioRepositorioRelatorio) BuscarPorEmail(email string) (*dominio
 	return nil, nil
 }
 
+func (m *MockUsuarioRepositorioRelatorio) BuscarPorCPF(cpf string) (*dominio.Usuario, error) {
+	return nil, nil
+}
+
 func (m *MockUsuarioRepositorioRelatorio) BuscarUsuarioPorID(id uint) (*dominio.Usuario, error) {
 	return nil, nil
 }
@@ -105,10 +142,6 @@ func (m *MockUsuarioRepositorioRelatorio) AtualizarPaciente(tx *gorm.DB, pacient
 	return nil
 }
 
-func (m *MockUsuarioRepositorioRelatorio) DeletarUsuario(tx *gorm.DB, id uint) error {
-	return nil
-}
-
 // ========== Helper Functions ==========
 
 func setupTestDBRelatorio(t *testing.T) *gorm.DB {
@@ -117,7 +150,7 @@ func setupTestDBRelatorio(t *testing.T) *gorm.DB {
 		t.Fatalf("Falha ao abrir banco de dados de teste: %v", err)
 	}
 
-	err = db.AutoMigrate(&dominio.Usuario{}, &dominio.Paciente{}, &dominio.RegistroHumor{})
+	err = db.AutoMigrate(&dominio.Usuario{}, &dominio.Paciente{}, &dominio.RegistroHumor{}, &dominio.MetricaPersonalizada{}, &dominio.ValorMetricaRegistro{})
 	if err != nil {
 		t.Fatalf("Falha ao migrar esquema: %v", err)
 	}
@@ -132,7 +165,7 @@ func TestAnaliseServico_GerarAnaliseHistorica_Sucesso(t *testing.T) {
 	mockRegistroHumorRepo := new(MockRegistroHumorRepositorioRelatorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioRelatorio)
 
-	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo)
+	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), new(MockNotificacaoRepositorio), new(MockResponsavelRepositorio), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db))
 
 	now := time.Now()
 	registros := []*dominio.RegistroHumor{
@@ -156,9 +189,10 @@ func TestAnaliseServico_GerarAnaliseHistorica_Sucesso(t *testing.T) {
 		},
 	}
 
+	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{ID: 1, UsuarioID: 10}, nil)
 	mockRegistroHumorRepo.On("BuscarPorPacienteEPeriodo", uint(1), mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(registros, nil)
 
-	resultado, err := servico.GerarAnaliseHistorica(1, 7)
+	resultado, err := servico.GerarAnaliseHistorica(10, 0, "paciente", 7)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resultado)
@@ -181,9 +215,9 @@ func TestAnaliseServico_GerarAnaliseHistorica_PeriodoInvalido_Zero(t *testing.T)
 	mockRegistroHumorRepo := new(MockRegistroHumorRepositorioRelatorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioRelatorio)
 
-	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo)
+	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), new(MockNotificacaoRepositorio), new(MockResponsavelRepositorio), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db))
 
-	resultado, err := servico.GerarAnaliseHistorica(1, 0)
+	resultado, err := servico.GerarAnaliseHistorica(10, 0, "paciente", 0)
 
 	assert.Error(t, err)
 	assert.Nil(t, resultado)
@@ -195,9 +229,9 @@ func TestAnaliseServico_GerarAnaliseHistorica_PeriodoInvalido_Negativo(t *testin
 	mockRegistroHumorRepo := new(MockRegistroHumorRepositorioRelatorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioRelatorio)
 
-	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo)
+	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), new(MockNotificacaoRepositorio), new(MockResponsavelRepositorio), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db))
 
-	resultado, err := servico.GerarAnaliseHistorica(1, -5)
+	resultado, err := servico.GerarAnaliseHistorica(10, 0, "paciente", -5)
 
 	assert.Error(t, err)
 	assert.Nil(t, resultado)
@@ -209,9 +243,9 @@ func TestAnaliseServico_GerarAnaliseHistorica_PeriodoExcedeLimite(t *testing.T)
 	mockRegistroHumorRepo := new(MockRegistroHumorRepositorioRelatorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioRelatorio)
 
-	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo)
+	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), new(MockNotificacaoRepositorio), new(MockResponsavelRepositorio), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db))
 
-	resultado, err := servico.GerarAnaliseHistorica(1, 91)
+	resultado, err := servico.GerarAnaliseHistorica(10, 0, "paciente", 366)
 
 	assert.Error(t, err)
 	assert.Nil(t, resultado)
@@ -223,12 +257,13 @@ func TestAnaliseServico_GerarAnaliseHistorica_ErroAoBuscarRegistros(t *testing.T
 	mockRegistroHumorRepo := new(MockRegistroHumorRepositorioRelatorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioRelatorio)
 
-	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo)
+	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), new(MockNotificacaoRepositorio), new(MockResponsavelRepositorio), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db))
 
 	erroGenerico := errors.New("erro de conexão com banco de dados")
+	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{ID: 1, UsuarioID: 10}, nil)
 	mockRegistroHumorRepo.On("BuscarPorPacienteEPeriodo", uint(1), mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(nil, erroGenerico)
 
-	resultado, err := servico.GerarAnaliseHistorica(1, 7)
+	resultado, err := servico.GerarAnaliseHistorica(10, 0, "paciente", 7)
 
 	assert.Error(t, err)
 	assert.Nil(t, resultado)
@@ -241,13 +276,14 @@ func TestAnaliseServico_GerarAnaliseHistorica_SemRegistros(t *testing.T) {
 	mockRegistroHumorRepo := new(MockRegistroHumorRepositorioRelatorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioRelatorio)
 
-	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo)
+	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), new(MockNotificacaoRepositorio), new(MockResponsavelRepositorio), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db))
 
 	registrosVazios := []*dominio.RegistroHumor{}
 
+	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{ID: 1, UsuarioID: 10}, nil)
 	mockRegistroHumorRepo.On("BuscarPorPacienteEPeriodo", uint(1), mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(registrosVazios, nil)
 
-	resultado, err := servico.GerarAnaliseHistorica(1, 7)
+	resultado, err := servico.GerarAnaliseHistorica(10, 0, "paciente", 7)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resultado)
@@ -270,7 +306,7 @@ func TestAnaliseServico_GerarAnaliseHistorica_UmRegistro(t *testing.T) {
 	mockRegistroHumorRepo := new(MockRegistroHumorRepositorioRelatorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioRelatorio)
 
-	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo)
+	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), new(MockNotificacaoRepositorio), new(MockResponsavelRepositorio), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db))
 
 	registros := []*dominio.RegistroHumor{
 		{
@@ -284,9 +320,10 @@ func TestAnaliseServico_GerarAnaliseHistorica_UmRegistro(t *testing.T) {
 		},
 	}
 
+	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{ID: 1, UsuarioID: 10}, nil)
 	mockRegistroHumorRepo.On("BuscarPorPacienteEPeriodo", uint(1), mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(registros, nil)
 
-	resultado, err := servico.GerarAnaliseHistorica(1, 7)
+	resultado, err := servico.GerarAnaliseHistorica(10, 0, "paciente", 7)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resultado)
@@ -307,7 +344,7 @@ func TestAnaliseServico_GerarAnaliseHistorica_CalculoMediasCorreto(t *testing.T)
 	mockRegistroHumorRepo := new(MockRegistroHumorRepositorioRelatorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioRelatorio)
 
-	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo)
+	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), new(MockNotificacaoRepositorio), new(MockResponsavelRepositorio), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db))
 
 	now := time.Now()
 	registros := []*dominio.RegistroHumor{
@@ -316,9 +353,10 @@ func TestAnaliseServico_GerarAnaliseHistorica_CalculoMediasCorreto(t *testing.T)
 		{HorasSono: 9, NivelEnergia: 8, NivelStress: 2, DataHoraRegistro: now},
 	}
 
+	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{ID: 1, UsuarioID: 10}, nil)
 	mockRegistroHumorRepo.On("BuscarPorPacienteEPeriodo", uint(1), mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(registros, nil)
 
-	resultado, err := servico.GerarAnaliseHistorica(1, 30)
+	resultado, err := servico.GerarAnaliseHistorica(10, 0, "paciente", 30)
 
 	assert.NoError(t, err)
 
@@ -328,14 +366,83 @@ func TestAnaliseServico_GerarAnaliseHistorica_CalculoMediasCorreto(t *testing.T)
 	assert.InDelta(t, 4.0, resultado.MediaStress, 0.01)
 }
 
+// ========== Testes de acesso a analise ==========
+
+func TestAnaliseServico_GerarAnaliseHistorica_PacienteNaoVeOutroPaciente(t *testing.T) {
+	db := setupTestDBRelatorio(t)
+	mockRegistroHumorRepo := new(MockRegistroHumorRepositorioRelatorio)
+	mockUsuarioRepo := new(MockUsuarioRepositorioRelatorio)
+	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), new(MockNotificacaoRepositorio), new(MockResponsavelRepositorio), new(MockMetricaPersonalizadaRepositorio))
+
+	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{ID: 1, UsuarioID: 10}, nil)
+
+	resultado, err := servico.GerarAnaliseHistorica(10, 2, "paciente", 7)
+
+	assert.Equal(t, dominio.ErrConsentimentoNegado, err)
+	assert.Nil(t, resultado)
+	mockRegistroHumorRepo.AssertNotCalled(t, "BuscarPorPacienteEPeriodo", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAnaliseServico_GerarAnaliseHistorica_ProfissionalSemVinculo(t *testing.T) {
+	registroRepo := new(MockRegistroHumorRepositorioConsentimento)
+	usuarioRepo := new(MockUsuarioRepositorio)
+	consentimentoRepo := new(MockConsentimentoRepositorio)
+	vinculoRepo := new(MockVinculoRepositorio)
+	servico := servicos.NovoAnaliseServico(setupTestDB(t), registroRepo, usuarioRepo, consentimentoRepo, vinculoRepo, new(MockNotificacaoRepositorio), new(MockResponsavelRepositorio), new(MockMetricaPersonalizadaRepositorio))
+
+	// O profissional 6 nunca atendeu o paciente 1 e teve o vinculo com o paciente 2 encerrado
+	encerrado := time.Now().AddDate(0, 0, -1)
+	usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(60)).Return(&dominio.Profissional{ID: 6}, nil)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(1), uint(6)).Return(nil, gorm.ErrRecordNotFound)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(2), uint(6)).Return(&dominio.Vinculo{PacienteID: 2, ProfissionalID: 6, EncerradoEm: &encerrado}, nil)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, mock.Anything, uint(6)).Return(dominio.NovoConsentimentoPadrao(2, 6, time.Now().AddDate(0, 0, -30)), nil).Maybe()
+
+	_, err := servico.GerarAnaliseHistorica(60, 1, "profissional", 7)
+	assert.Equal(t, dominio.ErrVinculoNaoEncontrado, err)
+	_, err = servico.GerarAnaliseHistorica(60, 2, "profissional", 7)
+	assert.Equal(t, dominio.ErrVinculoNaoEncontrado, err)
+
+	// Tipos de usuario sem acesso a analise de pacientes
+	_, err = servico.GerarAnaliseHistorica(60, 1, "admin", 7)
+	assert.Equal(t, dominio.ErrVinculoNaoEncontrado, err)
+	registroRepo.AssertNotCalled(t, "BuscarPorPacienteEPeriodo", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAnaliseServico_GerarAnaliseHistorica_ResponsavelSemDelegacao(t *testing.T) {
+	registroRepo := new(MockRegistroHumorRepositorioConsentimento)
+	responsavelRepo := new(MockResponsavelRepositorio)
+	servico := servicos.NovoAnaliseServico(setupTestDB(t), registroRepo, new(MockUsuarioRepositorio), new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), new(MockNotificacaoRepositorio), responsavelRepo, new(MockMetricaPersonalizadaRepositorio))
+
+	// O responsavel 4 nunca foi autorizado pelo paciente 1 e teve a delegacao do paciente 2 encerrada
+	encerrada := time.Now().AddDate(0, 0, -1)
+	responsavelRepo.On("BuscarResponsavelPorUsuarioID", mock.Anything, uint(40)).Return(&dominio.Responsavel{ID: 4, UsuarioID: 40}, nil)
+	responsavelRepo.On("BuscarResponsavelPorUsuarioID", mock.Anything, uint(41)).Return(nil, gorm.ErrRecordNotFound)
+	responsavelRepo.On("BuscarVinculoResponsavel", mock.Anything, uint(4), uint(1)).Return(nil, gorm.ErrRecordNotFound)
+	responsavelRepo.On("BuscarVinculoResponsavel", mock.Anything, uint(4), uint(2)).Return(&dominio.VinculoResponsavel{ResponsavelID: 4, PacienteID: 2, EncerradoEm: &encerrada}, nil)
+
+	_, err := servico.GerarAnaliseHistorica(40, 1, "responsavel", 7)
+	assert.Equal(t, dominio.ErrVinculoNaoEncontrado, err)
+	_, err = servico.GerarAnaliseHistorica(40, 2, "responsavel", 7)
+	assert.Equal(t, dominio.ErrVinculoNaoEncontrado, err)
+
+	// Usuario do tipo responsavel sem cadastro de responsavel
+	_, err = servico.GerarAnaliseHistorica(41, 1, "responsavel", 7)
+	assert.Equal(t, dominio.ErrUsuarioNaoEncontrado, err)
+	registroRepo.AssertNotCalled(t, "BuscarPorPacienteEPeriodo", mock.Anything, mock.Anything, mock.Anything)
+}
+
 // ========== Testes ExecutarMonitoramento ==========
 
 func TestAnaliseServico_ExecutarMonitoramento_Sucesso(t *testing.T) {
 	db := setupTestDBRelatorio(t)
 	mockRegistroHumorRepo := new(MockRegistroHumorRepositorioRelatorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioRelatorio)
+	consentimentoRepo := new(MockConsentimentoRepositorio)
+	vinculoRepo := new(MockVinculoRepositorio)
+	notificacaoRepo := new(MockNotificacaoRepositorio)
+	responsavelRepo := new(MockResponsavelRepositorio)
 
-	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo)
+	servico := servicos.NovoAnaliseServico(db, mockRegistroHumorRepo, mockUsuarioRepo, consentimentoRepo, vinculoRepo, notificacaoRepo, responsavelRepo, sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db))
 
 	registros := []*dominio.RegistroHumor{
 		{NivelHumor: 3, NivelStress: 5},
@@ -343,9 +450,19 @@ func TestAnaliseServico_ExecutarMonitoramento_Sucesso(t *testing.T) {
 	}
 
 	mockRegistroHumorRepo.On("BuscarPorNUltimosRegistros", uint(1), 5).Return(registros, nil)
+	// So o profissional vinculado que recebe o humor e avisado do padrao preocupante
+	vinculoRepo.On("ListarProfissionaisAtivosDoPaciente", mock.Anything, uint(1)).
+		Return([]*dominio.Profissional{{ID: 7, UsuarioID: 70}, {ID: 8, UsuarioID: 80}}, nil)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(1), uint(7)).Return(dominio.NovoConsentimentoPadrao(1, 7, time.Now()), nil)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(1), uint(8)).Return(nil, gorm.ErrRecordNotFound)
+	notificacaoRepo.On("CriarNotificacao", mock.Anything, mock.MatchedBy(func(n *dominio.Notificacao) bool {
+		return n.UsuarioID == 70
+	})).Return(nil)
+	responsavelRepo.On("ListarResponsaveisDoPaciente", mock.Anything, uint(1)).Return([]*dominio.VinculoResponsavel{}, nil)
 
 	err := servico.ExecutarMonitoramento(1)
 
 	assert.NoError(t, err)
 	mockRegistroHumorRepo.AssertExpectations(t)
+	notificacaoRepo.AssertNumberOfCalls(t, "CriarNotificacao", 1)
 }
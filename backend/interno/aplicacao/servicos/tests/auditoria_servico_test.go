@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"errors"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func setupAuditoria(t *testing.T) (servicos.AuditoriaServico, *gorm.DB, *MockUsuarioRepositorio) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&dominio.Usuario{}, &dominio.RegistroAuditoria{}))
+	usuarioRepo := new(MockUsuarioRepositorio)
+	return servicos.NovoAuditoriaServico(db, usuarioRepo, sqlite_repo.NovoGormAuditoriaRepositorio(db)), db, usuarioRepo
+}
+
+func registroAcesso(atorID, pacienteID uint, acao string) *dominio.RegistroAuditoria {
+	return &dominio.RegistroAuditoria{
+		AtorID:      &atorID,
+		TipoAtor:    "profissional",
+		Acao:        acao,
+		TipoRecurso: dominio.RecursoAuditoriaPaciente,
+		PacienteID:  &pacienteID,
+		IP:          "10.0.0.1",
+		UserAgent:   "teste",
+		Resultado:   dominio.ResultadoAuditoriaSucesso,
+	}
+}
+
+func TestAuditoriaServico_Registrar_EncadeiaRegistros(t *testing.T) {
+	svc, db, _ := setupAuditoria(t)
+
+	assert.NoError(t, svc.Registrar(registroAcesso(1, 7, dominio.AcaoAuditoriaListarPacientes), registroAcesso(1, 8, dominio.AcaoAuditoriaListarPacientes)))
+	assert.NoError(t, svc.Registrar(registroAcesso(1, 7, dominio.AcaoAuditoriaVerHistorico)))
+
+	var registros []*dominio.RegistroAuditoria
+	assert.NoError(t, db.Order("id").Find(&registros).Error)
+	assert.Len(t, registros, 3)
+	assert.Equal(t, "", registros[0].HashAnterior)
+	assert.Equal(t, registros[0].Hash, registros[1].HashAnterior)
+	assert.Equal(t, registros[1].Hash, registros[2].HashAnterior)
+
+	verificados, err := svc.VerificarCadeia()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, verificados)
+}
+
+func TestAuditoriaServico_VerificarCadeia_DetectaAdulteracao(t *testing.T) {
+	svc, db, _ := setupAuditoria(t)
+	assert.NoError(t, svc.Registrar(registroAcesso(1, 7, dominio.AcaoAuditoriaVerNota), registroAcesso(1, 7, dominio.AcaoAuditoriaVerNota), registroAcesso(1, 7, dominio.AcaoAuditoriaVerNota)))
+
+	// Trocar o autor de um acesso muda o hash do registro
+	assert.NoError(t, db.Model(&dominio.RegistroAuditoria{}).Where("id = ?", 2).Update("ator_id", 99).Error)
+	verificados, err := svc.VerificarCadeia()
+	assert.True(t, errors.Is(err, dominio.ErrCadeiaAuditoriaViolada))
+	assert.Equal(t, 0, verificados)
+	assert.Contains(t, err.Error(), "registro 2")
+
+	// Remover um registro quebra o encadeamento do seguinte
+	assert.NoError(t, db.Model(&dominio.RegistroAuditoria{}).Where("id = ?", 2).Update("ator_id", 1).Error)
+	_, err = svc.VerificarCadeia()
+	assert.NoError(t, err)
+	assert.NoError(t, db.Delete(&dominio.RegistroAuditoria{}, 2).Error)
+	_, err = svc.VerificarCadeia()
+	assert.True(t, errors.Is(err, dominio.ErrCadeiaAuditoriaViolada))
+	assert.Contains(t, err.Error(), "registro 3")
+}
+
+func TestAuditoriaServico_Registrar_RejeitaRegistroInvalido(t *testing.T) {
+	svc, db, _ := setupAuditoria(t)
+
+	invalido := registroAcesso(1, 7, "")
+	assert.Equal(t, dominio.ErrAcaoAuditoriaVazia, svc.Registrar(registroAcesso(1, 7, dominio.AcaoAuditoriaVerNota), invalido))
+
+	var total int64
+	db.Model(&dominio.RegistroAuditoria{}).Count(&total)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestAuditoriaServico_ListarAcessosDoPaciente(t *testing.T) {
+	svc, db, usuarioRepo := setupAuditoria(t)
+
+	profissional := &dominio.Usuario{TipoUsuario: dominio.TipoUsuarioProfissional, Nome: "Dra. Ana", Email: "ana@example.com", Senha: "x"}
+	pacienteUsuario := &dominio.Usuario{TipoUsuario: dominio.TipoUsuarioPaciente, Nome: "Bia", Email: "bia@example.com", Senha: "x"}
+	assert.NoError(t, db.Create(profissional).Error)
+	assert.NoError(t, db.Create(pacienteUsuario).Error)
+	usuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, pacienteUsuario.ID).Return(&dominio.Paciente{ID: 7, UsuarioID: pacienteUsuario.ID}, nil)
+
+	negado := registroAcesso(profissional.ID, 7, dominio.AcaoAuditoriaVerNota)
+	negado.Resultado = dominio.ResultadoAuditoriaNegado
+	proprio := registroAcesso(pacienteUsuario.ID, 7, dominio.AcaoAuditoriaVerHistorico)
+	assert.NoError(t, svc.Registrar(
+		registroAcesso(profissional.ID, 7, dominio.AcaoAuditoriaVerHistorico),
+		registroAcesso(profissional.ID, 8, dominio.AcaoAuditoriaVerHistorico),
+		negado,
+		proprio,
+	))
+
+	acessos, err := svc.ListarAcessosDoPaciente(pacienteUsuario.ID, nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, acessos, 2)
+	assert.Equal(t, "Dra. Ana", acessos[0].NomeAtor)
+	assert.Equal(t, dominio.ResultadoAuditoriaNegado, acessos[0].Resultado)
+	assert.Equal(t, dominio.AcaoAuditoriaVerHistorico, acessos[1].Acao)
+
+	amanha := time.Now().AddDate(0, 0, 1)
+	acessos, err = svc.ListarAcessosDoPaciente(pacienteUsuario.ID, &amanha, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, acessos)
+}
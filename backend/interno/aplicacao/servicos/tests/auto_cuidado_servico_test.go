@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// setupAutoCuidado prepara o paciente 1 (usuario 10) vinculado ao profissional 5 (usuario 50)
+// e com vinculo encerrado com o profissional 6 (usuario 60); o catalogo do sistema tem Caminhada e Leitura
+func setupAutoCuidado(t *testing.T, consentimento *dominio.Consentimento) (servicos.AutoCuidadoServico, servicos.RegistroHumorServico, *gorm.DB) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&dominio.RegistroHumor{}, &dominio.AtividadeAutoCuidado{}, &dominio.RegistroHumorAtividade{}, &dominio.MetricaPersonalizada{}, &dominio.ValorMetricaRegistro{}))
+	assert.NoError(t, db.AutoMigrate(&dominio.Vinculo{}))
+
+	encerradoEm := time.Now().Add(-time.Hour)
+	assert.NoError(t, db.Create(&dominio.Vinculo{PacienteID: 1, ProfissionalID: 5}).Error)
+	assert.NoError(t, db.Create(&dominio.Vinculo{PacienteID: 1, ProfissionalID: 6, EncerradoEm: &encerradoEm}).Error)
+	for _, nome := range []string{"Caminhada", "Leitura"} {
+		assert.NoError(t, db.Create(&dominio.AtividadeAutoCuidado{Nome: nome, NomeNormalizado: dominio.NormalizarNomeAtividade(nome), Ativa: true}).Error)
+	}
+
+	usuarioRepo := new(MockUsuarioRepositorio)
+	usuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{ID: 1, UsuarioID: 10}, nil)
+	usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(50)).Return(&dominio.Profissional{ID: 5, UsuarioID: 50}, nil)
+	usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(60)).Return(&dominio.Profissional{ID: 6, UsuarioID: 60}, nil)
+
+	vinculoRepo := new(MockVinculoRepositorio)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(1), uint(5)).Return(&dominio.Vinculo{PacienteID: 1, ProfissionalID: 5}, nil)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(1), uint(6)).Return(&dominio.Vinculo{PacienteID: 1, ProfissionalID: 6, EncerradoEm: &encerradoEm}, nil)
+
+	consentimentoRepo := new(MockConsentimentoRepositorio)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(1), uint(5)).Return(consentimento, nil)
+
+	registroRepo := sqlite_repo.NovoGormRegistroHumorRepositorio(db)
+	autoCuidadoRepo := sqlite_repo.NovoGormAutoCuidadoRepositorio(db)
+	svc := servicos.NovoAutoCuidadoServico(db, usuarioRepo, vinculoRepo, consentimentoRepo, autoCuidadoRepo, registroRepo)
+	registroSvc := servicos.NovoRegistroHumorServico(db, registroRepo, usuarioRepo, consentimentoRepo, vinculoRepo, autoCuidadoRepo, sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db), &monitoramentoEspiao{pacientes: make(chan uint, 4)}, 0, 0)
+	return svc, registroSvc, db
+}
+
+func nomesAtividades(atividades []*dtos.AtividadeAutoCuidadoDTOOut) []string {
+	nomes := make([]string, 0, len(atividades))
+	for _, atividade := range atividades {
+		nomes = append(nomes, atividade.Nome)
+	}
+	return nomes
+}
+
+func TestAutoCuidadoServico_CatalogoVisivelPorVinculo(t *testing.T) {
+	svc, _, _ := setupAutoCuidado(t, nil)
+
+	_, err := svc.CriarAtividade(50, &dtos.CriarAtividadeAutoCuidadoDTOIn{Nome: "Diário de gratidão"})
+	assert.NoError(t, err)
+	_, err = svc.CriarAtividade(60, &dtos.CriarAtividadeAutoCuidadoDTOIn{Nome: "Respiração guiada"})
+	assert.NoError(t, err)
+
+	// O paciente nao ve as atividades do profissional com vinculo encerrado
+	doPaciente, err := svc.ListarAtividades(10, "paciente")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Caminhada", "Leitura", "Diário de gratidão"}, nomesAtividades(doPaciente))
+	assert.False(t, doPaciente[0].Personalizada)
+	assert.True(t, doPaciente[2].Personalizada)
+
+	doProfissional, err := svc.ListarAtividades(60, "profissional")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Caminhada", "Leitura", "Respiração guiada"}, nomesAtividades(doProfissional))
+}
+
+func TestAutoCuidadoServico_CriarAtividade_DuplicadaEReativada(t *testing.T) {
+	svc, _, _ := setupAutoCuidado(t, nil)
+
+	_, err := svc.CriarAtividade(50, &dtos.CriarAtividadeAutoCuidadoDTOIn{Nome: "CAMINHADA"})
+	assert.Equal(t, dominio.ErrAtividadeAutoCuidadoDuplicada, err)
+
+	criada, err := svc.CriarAtividade(50, &dtos.CriarAtividadeAutoCuidadoDTOIn{Nome: "Alongamento"})
+	assert.NoError(t, err)
+	_, err = svc.CriarAtividade(50, &dtos.CriarAtividadeAutoCuidadoDTOIn{Nome: "alongamento"})
+	assert.Equal(t, dominio.ErrAtividadeAutoCuidadoDuplicada, err)
+
+	assert.NoError(t, svc.DesativarAtividade(50, criada.ID))
+	reativada, err := svc.CriarAtividade(50, &dtos.CriarAtividadeAutoCuidadoDTOIn{Nome: "Alongamento matinal"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, criada.ID, reativada.ID)
+
+	denovo, err := svc.CriarAtividade(50, &dtos.CriarAtividadeAutoCuidadoDTOIn{Nome: "Alongamento"})
+	assert.NoError(t, err)
+	assert.Equal(t, criada.ID, denovo.ID)
+}
+
+func TestAutoCuidadoServico_DesativarAtividade_SomenteDoProprioProfissional(t *testing.T) {
+	svc, _, _ := setupAutoCuidado(t, nil)
+
+	criada, err := svc.CriarAtividade(50, &dtos.CriarAtividadeAutoCuidadoDTOIn{Nome: "Alongamento"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, dominio.ErrAtividadeAutoCuidadoNaoEncontrada, svc.DesativarAtividade(60, criada.ID))
+	// Atividades do sistema nao podem ser desativadas por profissionais
+	assert.Equal(t, dominio.ErrAtividadeAutoCuidadoNaoEncontrada, svc.DesativarAtividade(50, 1))
+	assert.Equal(t, dominio.ErrAtividadeAutoCuidadoNaoEncontrada, svc.DesativarAtividade(50, 999))
+
+	assert.NoError(t, svc.DesativarAtividade(50, criada.ID))
+	catalogo, err := svc.ListarAtividades(10, "paciente")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Caminhada", "Leitura"}, nomesAtividades(catalogo))
+}
+
+func TestRegistroHumorServico_CriarRegistroHumor_LigaAtividadesDoCatalogo(t *testing.T) {
+	svc, registroSvc, db := setupAutoCuidado(t, nil)
+	personalizada, err := svc.CriarAtividade(50, &dtos.CriarAtividadeAutoCuidadoDTOIn{Nome: "Diário de gratidão"})
+	assert.NoError(t, err)
+
+	dto := correcaoHumor(4)
+	dto.DataHoraRegistro = time.Now().Add(-time.Hour)
+	dto.AutoCuidadoIDs = []uint{personalizada.ID}
+	dto.AutoCuidado = []string{"leitura"}
+	registro, err := registroSvc.CriarRegistroHumor(dto, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, `["Diário de gratidão","Leitura"]`, registro.AutoCuidado)
+
+	var ligacoes []dominio.RegistroHumorAtividade
+	assert.NoError(t, db.Where("registro_humor_id = ?", registro.ID).Order("atividade_id").Find(&ligacoes).Error)
+	assert.Len(t, ligacoes, 2)
+
+	dto.AutoCuidadoIDs = nil
+	dto.AutoCuidado = []string{"natação"}
+	_, err = registroSvc.CriarRegistroHumor(dto, 10)
+	assert.Equal(t, dominio.ErrAtividadeAutoCuidadoNaoEncontrada, err)
+}
+
+func TestAutoCuidadoServico_GerarCorrelacao(t *testing.T) {
+	agora := time.Now()
+	svc, _, db := setupAutoCuidado(t, consentimentoDiario(true, false, false, agora.AddDate(0, 0, -3)))
+
+	// Caminhada nos dias pares; o dia seguinte a caminhada tem humor 5, os demais humor 2
+	hoje := time.Date(agora.Year(), agora.Month(), agora.Day(), 12, 0, 0, 0, time.Local)
+	for i := 12; i >= 1; i-- {
+		humor := int16(2)
+		if i%2 == 1 {
+			humor = 5
+		}
+		registro := &dominio.RegistroHumor{PacienteID: 1, NivelHumor: humor, HorasSono: 7, NivelEnergia: 5, NivelStress: 5, AutoCuidado: "[]", DataHoraRegistro: hoje.AddDate(0, 0, -i)}
+		assert.NoError(t, db.Create(registro).Error)
+		if i%2 == 0 {
+			assert.NoError(t, db.Create(&dominio.RegistroHumorAtividade{RegistroHumorID: registro.ID, AtividadeID: 1}).Error)
+		}
+	}
+
+	correlacao, err := svc.GerarCorrelacao(10, "paciente", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), correlacao.PacienteID)
+	assert.Equal(t, 90, correlacao.Dias)
+	assert.Len(t, correlacao.Atividades, 1)
+	caminhada := correlacao.Atividades[0]
+	assert.Equal(t, "Caminhada", caminhada.Nome)
+	assert.Equal(t, 6, caminhada.DiasCom)
+	assert.Equal(t, 5, caminhada.DiasSem)
+	assert.InDelta(t, 3.0, caminhada.Diferenca, 0.001)
+	assert.True(t, caminhada.AmostraSuficiente)
+
+	// O profissional ve apenas o periodo consentido
+	doProfissional, err := svc.GerarCorrelacao(50, "profissional", 1, 30)
+	assert.NoError(t, err)
+	assert.Len(t, doProfissional.Atividades, 1)
+	assert.Equal(t, 1, doProfissional.Atividades[0].DiasCom)
+	assert.False(t, doProfissional.Atividades[0].AmostraSuficiente)
+}
+
+func TestAutoCuidadoServico_GerarCorrelacao_AcessoNegado(t *testing.T) {
+	svc, _, _ := setupAutoCuidado(t, consentimentoDiario(false, true, false, time.Now().AddDate(0, 0, -30)))
+
+	_, err := svc.GerarCorrelacao(50, "profissional", 1, 30)
+	assert.Equal(t, dominio.ErrConsentimentoNegado, err)
+
+	_, err = svc.GerarCorrelacao(60, "profissional", 1, 30)
+	assert.Equal(t, dominio.ErrVinculoNaoEncontrado, err)
+
+	_, err = svc.GerarCorrelacao(10, "paciente", 0, 400)
+	assert.Equal(t, dominio.ErrPeriodoCorrelacaoInvalido, err)
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// gravarChaveJWT gera uma chave no diretorio e retorna o kid
+func gravarChaveJWT(t *testing.T, dir, alg string) string {
+	kid, privadaPEM, err := servicos.GerarChaveJWT(alg)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, kid+servicos.ExtensaoChavePrivadaJWT), privadaPEM, 0o600))
+	return kid
+}
+
+func claimsTeste() jwt.MapClaims {
+	return jwt.MapClaims{"sub": 1, "role": "profissional", "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+// ========== Testes para ChavesJWT ==========
+
+func TestChavesJWT_AssinarEValidar(t *testing.T) {
+	for _, alg := range []string{servicos.AlgoritmoJWTEdDSA, servicos.AlgoritmoJWTRS256} {
+		t.Run(alg, func(t *testing.T) {
+			dir := t.TempDir()
+			kid := gravarChaveJWT(t, dir, alg)
+
+			chaves, err := servicos.CarregarChavesJWT(dir, "", nil)
+			assert.NoError(t, err)
+
+			tokenString, err := chaves.Assinar(claimsTeste())
+			assert.NoError(t, err)
+
+			token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+			assert.NoError(t, err)
+			assert.Equal(t, kid, token.Header["kid"])
+			assert.Equal(t, alg, token.Header["alg"])
+
+			claims, err := chaves.Validar(tokenString)
+			assert.NoError(t, err)
+			assert.Equal(t, float64(1), claims["sub"])
+
+			jwks := chaves.JWKS()
+			assert.Len(t, jwks.Keys, 1)
+			assert.Equal(t, kid, jwks.Keys[0].Kid)
+			assert.Equal(t, alg, jwks.Keys[0].Alg)
+		})
+	}
+}
+
+func TestChavesJWT_RotacaoMantemTokensAntigos(t *testing.T) {
+	dir := t.TempDir()
+	kidAntigo := gravarChaveJWT(t, dir, servicos.AlgoritmoJWTEdDSA)
+
+	chavesAntigas, err := servicos.CarregarChavesJWT(dir, "", nil)
+	assert.NoError(t, err)
+	tokenAntigo, err := chavesAntigas.Assinar(claimsTeste())
+	assert.NoError(t, err)
+
+	// Nova chave publicada e ativada
+	kidNovo := gravarChaveJWT(t, dir, servicos.AlgoritmoJWTRS256)
+	_, err = servicos.CarregarChavesJWT(dir, "", nil)
+	assert.ErrorIs(t, err, servicos.ErrChaveJWTAtivaAmbigua)
+
+	chaves, err := servicos.CarregarChavesJWT(dir, kidNovo, nil)
+	assert.NoError(t, err)
+	assert.Len(t, chaves.JWKS().Keys, 2)
+
+	_, err = chaves.Validar(tokenAntigo)
+	assert.NoError(t, err)
+
+	// Chave antiga aposentada: ainda valida, mas nao pode mais ser a ativa
+	caminhoAntigo := filepath.Join(dir, kidAntigo+servicos.ExtensaoChavePrivadaJWT)
+	privadaPEM, err := os.ReadFile(caminhoAntigo)
+	assert.NoError(t, err)
+	publicaPEM, err := servicos.ExtrairChavePublicaPEM(privadaPEM)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, kidAntigo+servicos.ExtensaoChavePublicaJWT), publicaPEM, 0o644))
+	assert.NoError(t, os.Remove(caminhoAntigo))
+
+	chaves, err = servicos.CarregarChavesJWT(dir, kidNovo, nil)
+	assert.NoError(t, err)
+	_, err = chaves.Validar(tokenAntigo)
+	assert.NoError(t, err)
+
+	_, err = servicos.CarregarChavesJWT(dir, kidAntigo, nil)
+	assert.ErrorIs(t, err, servicos.ErrChaveJWTAtivaAusente)
+
+	// Chave removida: tokens antigos deixam de valer
+	assert.NoError(t, os.Remove(filepath.Join(dir, kidAntigo+servicos.ExtensaoChavePublicaJWT)))
+	chaves, err = servicos.CarregarChavesJWT(dir, kidNovo, nil)
+	assert.NoError(t, err)
+	_, err = chaves.Validar(tokenAntigo)
+	assert.Error(t, err)
+}
+
+func TestChavesJWT_TokensHS256Legados(t *testing.T) {
+	segredo := []byte("segredo-legado")
+	tokenLegado, err := servicos.NovoChavesJWTHMAC(segredo).Assinar(claimsTeste())
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	gravarChaveJWT(t, dir, servicos.AlgoritmoJWTEdDSA)
+
+	// Durante a migracao o segredo antigo ainda valida
+	chaves, err := servicos.CarregarChavesJWT(dir, "", segredo)
+	assert.NoError(t, err)
+	_, err = chaves.Validar(tokenLegado)
+	assert.NoError(t, err)
+
+	// Sem o segredo, tokens HS256 sao recusados
+	chaves, err = servicos.CarregarChavesJWT(dir, "", nil)
+	assert.NoError(t, err)
+	_, err = chaves.Validar(tokenLegado)
+	assert.Error(t, err)
+}
+
+func TestChavesJWT_RecusaAlgoritmoDiferenteDaChave(t *testing.T) {
+	dir := t.TempDir()
+	kid := gravarChaveJWT(t, dir, servicos.AlgoritmoJWTEdDSA)
+
+	chaves, err := servicos.CarregarChavesJWT(dir, "", []byte("segredo"))
+	assert.NoError(t, err)
+
+	// Token HS256 que aponta para o kid de uma chave assimetrica
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claimsTeste())
+	token.Header["kid"] = kid
+	tokenString, err := token.SignedString([]byte("segredo"))
+	assert.NoError(t, err)
+
+	_, err = chaves.Validar(tokenString)
+	assert.Error(t, err)
+}
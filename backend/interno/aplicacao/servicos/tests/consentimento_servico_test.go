@@ -0,0 +1,270 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// ========== Mocks ==========
+
+// MockConsentimentoRepositorio simula o repositorio de consentimentos
+type MockConsentimentoRepositorio struct {
+	mock.Mock
+}
+
+func (m *MockConsentimentoRepositorio) CriarConsentimento(tx *gorm.DB, consentimento *dominio.Consentimento) error {
+	args := m.Called(tx, consentimento)
+	return args.Error(0)
+}
+
+func (m *MockConsentimentoRepositorio) BuscarConsentimentoAtual(tx *gorm.DB, pacienteID, profissionalID uint) (*dominio.Consentimento, error) {
+	args := m.Called(tx, pacienteID, profissionalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dominio.Consentimento), args.Error(1)
+}
+
+func (m *MockConsentimentoRepositorio) ListarConsentimentosAtuaisDoPaciente(tx *gorm.DB, pacienteID uint) ([]*dominio.Consentimento, error) {
+	args := m.Called(tx, pacienteID)
+	return args.Get(0).([]*dominio.Consentimento), args.Error(1)
+}
+
+func (m *MockConsentimentoRepositorio) ListarConsentimentosAtuaisDoProfissional(tx *gorm.DB, profissionalID uint) ([]*dominio.Consentimento, error) {
+	args := m.Called(tx, profissionalID)
+	return args.Get(0).([]*dominio.Consentimento), args.Error(1)
+}
+
+func (m *MockConsentimentoRepositorio) ListarVersoesConsentimento(tx *gorm.DB, pacienteID, profissionalID uint) ([]*dominio.Consentimento, error) {
+	args := m.Called(tx, pacienteID, profissionalID)
+	return args.Get(0).([]*dominio.Consentimento), args.Error(1)
+}
+
+func (m *MockConsentimentoRepositorio) CriarConsentimentosLegados(tx *gorm.DB, agora time.Time) (int64, error) {
+	args := m.Called(tx, agora)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockRegistroHumorRepositorioConsentimento simula os registros lidos pela analise
+type MockRegistroHumorRepositorioConsentimento struct {
+	mock.Mock
+}
+
+func (m *MockRegistroHumorRepositorioConsentimento) CriarRegistroHumor(tx *gorm.DB, registro *dominio.RegistroHumor) error {
+	return nil
+}
+
+func (m *MockRegistroHumorRepositorioConsentimento) BuscarPorPacienteEPeriodo(pacienteID uint, inicio, fim time.Time) ([]*dominio.RegistroHumor, error) {
+	args := m.Called(pacienteID, inicio, fim)
+	return args.Get(0).([]*dominio.RegistroHumor), args.Error(1)
+}
+
+func (m *MockRegistroHumorRepositorioConsentimento) BuscarUltimoRegistroDePaciente(pacienteID uint) (*dominio.RegistroHumor, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MockRegistroHumorRepositorioConsentimento) BuscarPorNUltimosRegistros(pacienteID uint, numLimite int) ([]*dominio.RegistroHumor, error) {
+	args := m.Called(pacienteID, numLimite)
+	return args.Get(0).([]*dominio.RegistroHumor), args.Error(1)
+}
+
+func (m *MockRegistroHumorRepositorioConsentimento) BuscarRegistroHumorPorID(tx *gorm.DB, registroID uint) (*dominio.RegistroHumor, error) {
+	return nil, nil
+}
+
+func (m *MockRegistroHumorRepositorioConsentimento) AtualizarRegistroHumor(tx *gorm.DB, registro *dominio.RegistroHumor) error {
+	return nil
+}
+
+func (m *MockRegistroHumorRepositorioConsentimento) ExcluirRegistroHumor(tx *gorm.DB, registroID uint) error {
+	return nil
+}
+
+func (m *MockRegistroHumorRepositorioConsentimento) CriarRevisaoRegistroHumor(tx *gorm.DB, revisao *dominio.RevisaoRegistroHumor) error {
+	return nil
+}
+
+func (m *MockRegistroHumorRepositorioConsentimento) ListarRevisoesRegistroHumor(tx *gorm.DB, registroID uint) ([]*dominio.RevisaoRegistroHumor, error) {
+	return nil, nil
+}
+
+func (m *MockRegistroHumorRepositorioConsentimento) ListarRegistrosHumor(tx *gorm.DB, pacienteID uint, filtro *dominio.FiltroRegistrosHumor, limite int) ([]*dominio.RegistroHumor, error) {
+	return nil, nil
+}
+
+func (m *MockRegistroHumorRepositorioConsentimento) CriarRegistroHumorSeNovo(tx *gorm.DB, registro *dominio.RegistroHumor) (bool, error) {
+	return false, nil
+}
+
+func (m *MockRegistroHumorRepositorioConsentimento) BuscarRegistrosHumorPorChaves(tx *gorm.DB, pacienteID uint, chaves []string) ([]*dominio.RegistroHumor, error) {
+	return nil, nil
+}
+
+// ========== Testes do Serviço ==========
+
+func TestConsentimentoServico_AtualizarConsentimento_CriaNovaVersao(t *testing.T) {
+	usuarioRepo := new(MockUsuarioRepositorio)
+	consentimentoRepo := new(MockConsentimentoRepositorio)
+	servico := servicos.NovoConsentimentoServico(setupTestDB(t), usuarioRepo, consentimentoRepo, new(MockResponsavelRepositorio))
+
+	atual := dominio.NovoConsentimentoPadrao(3, 7, time.Now().AddDate(0, -1, 0))
+	usuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{ID: 3}, nil)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(3), uint(7)).Return(atual, nil)
+	consentimentoRepo.On("CriarConsentimento", mock.Anything, mock.AnythingOfType("*dominio.Consentimento")).Return(nil)
+
+	expiracao := time.Now().AddDate(0, 3, 0)
+	consentimentoOut, err := servico.AtualizarConsentimento(10, 7, &dtos.AtualizarConsentimentoDTOIn{
+		Categorias:    []string{dominio.CategoriaHumor, dominio.CategoriaObservacoes},
+		DataExpiracao: &expiracao,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), consentimentoOut.Versao)
+	assert.Equal(t, []string{dominio.CategoriaHumor, dominio.CategoriaObservacoes}, consentimentoOut.Categorias)
+	assert.Equal(t, atual.DataInicio, consentimentoOut.DataInicio)
+	assert.True(t, consentimentoOut.Vigente)
+	consentimentoRepo.AssertExpectations(t)
+}
+
+func TestConsentimentoServico_AtualizarConsentimento_SemVinculo(t *testing.T) {
+	usuarioRepo := new(MockUsuarioRepositorio)
+	consentimentoRepo := new(MockConsentimentoRepositorio)
+	servico := servicos.NovoConsentimentoServico(setupTestDB(t), usuarioRepo, consentimentoRepo, new(MockResponsavelRepositorio))
+
+	usuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{ID: 3}, nil)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(3), uint(7)).Return(nil, gorm.ErrRecordNotFound)
+
+	consentimentoOut, err := servico.AtualizarConsentimento(10, 7, &dtos.AtualizarConsentimentoDTOIn{Categorias: []string{dominio.CategoriaSono}})
+
+	assert.Nil(t, consentimentoOut)
+	assert.Equal(t, dominio.ErrVinculoNaoEncontrado, err)
+	consentimentoRepo.AssertNotCalled(t, "CriarConsentimento", mock.Anything, mock.Anything)
+}
+
+func TestConsentimentoServico_AtualizarConsentimento_ExpiracaoNoPassado(t *testing.T) {
+	usuarioRepo := new(MockUsuarioRepositorio)
+	consentimentoRepo := new(MockConsentimentoRepositorio)
+	servico := servicos.NovoConsentimentoServico(setupTestDB(t), usuarioRepo, consentimentoRepo, new(MockResponsavelRepositorio))
+
+	usuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{ID: 3}, nil)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(3), uint(7)).
+		Return(dominio.NovoConsentimentoPadrao(3, 7, time.Now().AddDate(0, -1, 0)), nil)
+
+	ontem := time.Now().AddDate(0, 0, -1)
+	_, err := servico.AtualizarConsentimento(10, 7, &dtos.AtualizarConsentimentoDTOIn{
+		Categorias:    []string{dominio.CategoriaHumor},
+		DataExpiracao: &ontem,
+	})
+
+	assert.Equal(t, dominio.ErrExpiracaoConsentimentoInvalida, err)
+	consentimentoRepo.AssertNotCalled(t, "CriarConsentimento", mock.Anything, mock.Anything)
+}
+
+func TestConsentimentoServico_AtualizarConsentimento_MenorComResponsavelApenasRestringe(t *testing.T) {
+	usuarioRepo := new(MockUsuarioRepositorio)
+	consentimentoRepo := new(MockConsentimentoRepositorio)
+	responsavelRepo := new(MockResponsavelRepositorio)
+	servico := servicos.NovoConsentimentoServico(setupTestDB(t), usuarioRepo, consentimentoRepo, responsavelRepo)
+
+	usuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).
+		Return(&dominio.Paciente{ID: 3, DataNascimento: time.Now().AddDate(-14, 0, 0)}, nil)
+	responsavelRepo.On("ListarResponsaveisDoPaciente", mock.Anything, uint(3)).
+		Return([]*dominio.VinculoResponsavel{{ResponsavelID: 5, PacienteID: 3}}, nil)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(3), uint(7)).
+		Return(dominio.NovoConsentimentoPadrao(3, 7, time.Now().AddDate(0, -1, 0)), nil)
+	consentimentoRepo.On("CriarConsentimento", mock.Anything, mock.AnythingOfType("*dominio.Consentimento")).Return(nil).Once()
+
+	// Observacoes nao fazem parte do padrao: incluir amplia o compartilhamento
+	_, err := servico.AtualizarConsentimento(10, 7, &dtos.AtualizarConsentimentoDTOIn{
+		Categorias: []string{dominio.CategoriaHumor, dominio.CategoriaObservacoes},
+	})
+	assert.Equal(t, dominio.ErrConsentimentoExigeResponsavel, err)
+
+	// Retirar categorias continua permitido
+	consentimentoOut, err := servico.AtualizarConsentimento(10, 7, &dtos.AtualizarConsentimentoDTOIn{
+		Categorias: []string{dominio.CategoriaHumor},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{dominio.CategoriaHumor}, consentimentoOut.Categorias)
+	consentimentoRepo.AssertNumberOfCalls(t, "CriarConsentimento", 1)
+}
+
+func TestConsentimentoServico_RevogarConsentimento(t *testing.T) {
+	usuarioRepo := new(MockUsuarioRepositorio)
+	consentimentoRepo := new(MockConsentimentoRepositorio)
+	servico := servicos.NovoConsentimentoServico(setupTestDB(t), usuarioRepo, consentimentoRepo, new(MockResponsavelRepositorio))
+
+	usuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{ID: 3}, nil)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(3), uint(7)).
+		Return(dominio.NovoConsentimentoPadrao(3, 7, time.Now()), nil)
+	consentimentoRepo.On("CriarConsentimento", mock.Anything, mock.MatchedBy(func(c *dominio.Consentimento) bool {
+		return c.Revogado && c.Versao == 2
+	})).Return(nil)
+
+	consentimentoOut, err := servico.RevogarConsentimento(10, 7)
+
+	assert.NoError(t, err)
+	assert.False(t, consentimentoOut.Vigente)
+	assert.Empty(t, consentimentoOut.Categorias)
+	consentimentoRepo.AssertExpectations(t)
+}
+
+func TestAnaliseServico_GerarAnaliseHistorica_ProfissionalSemConsentimentoDeSono(t *testing.T) {
+	usuarioRepo := new(MockUsuarioRepositorio)
+	registroRepo := new(MockRegistroHumorRepositorioConsentimento)
+	consentimentoRepo := new(MockConsentimentoRepositorio)
+	vinculoRepo := new(MockVinculoRepositorio)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(3), uint(7)).Return(&dominio.Vinculo{PacienteID: 3, ProfissionalID: 7}, nil)
+	metricaRepo := new(MockMetricaPersonalizadaRepositorio)
+	metricaRepo.On("ListarValoresDosRegistros", mock.Anything, mock.Anything).Return([]*dominio.ValorMetricaRegistro{}, nil)
+	servico := servicos.NovoAnaliseServico(setupTestDB(t), registroRepo, usuarioRepo, consentimentoRepo, vinculoRepo, new(MockNotificacaoRepositorio), new(MockResponsavelRepositorio), metricaRepo)
+
+	inicioConsentimento := time.Now().AddDate(0, 0, -3)
+	consentimento := &dominio.Consentimento{
+		PacienteID: 3, ProfissionalID: 7, Versao: 2,
+		CompartilharHumor: true, DataInicio: inicioConsentimento,
+	}
+	usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(20)).Return(&dominio.Profissional{ID: 7}, nil)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(3), uint(7)).Return(consentimento, nil)
+	// A busca comeca na data do consentimento, e nao 30 dias atras
+	registroRepo.On("BuscarPorPacienteEPeriodo", uint(3), inicioConsentimento, mock.Anything).Return([]*dominio.RegistroHumor{
+		{PacienteID: 3, NivelHumor: 4, HorasSono: 7, NivelEnergia: 6, NivelStress: 3, DataHoraRegistro: time.Now()},
+	}, nil)
+
+	analise, err := servico.GerarAnaliseHistorica(20, 3, "profissional", 30)
+
+	assert.NoError(t, err)
+	assert.Empty(t, analise.GraficoSono)
+	assert.Zero(t, analise.MediaSono)
+	assert.Len(t, analise.GraficoEnergia, 1)
+	assert.Equal(t, 4.0, analise.MediaHumor)
+	assert.Empty(t, analise.StatusAtual)
+	assert.Equal(t, []string{dominio.CategoriaHumor}, analise.CategoriasCompartilhadas)
+	registroRepo.AssertExpectations(t)
+}
+
+func TestAnaliseServico_GerarAnaliseHistorica_ConsentimentoRevogado(t *testing.T) {
+	usuarioRepo := new(MockUsuarioRepositorio)
+	registroRepo := new(MockRegistroHumorRepositorioConsentimento)
+	consentimentoRepo := new(MockConsentimentoRepositorio)
+	vinculoRepo := new(MockVinculoRepositorio)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(3), uint(7)).Return(&dominio.Vinculo{PacienteID: 3, ProfissionalID: 7}, nil)
+	servico := servicos.NovoAnaliseServico(setupTestDB(t), registroRepo, usuarioRepo, consentimentoRepo, vinculoRepo, new(MockNotificacaoRepositorio), new(MockResponsavelRepositorio), new(MockMetricaPersonalizadaRepositorio))
+
+	revogado := dominio.NovoConsentimentoPadrao(3, 7, time.Now()).Revogar()
+	usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(20)).Return(&dominio.Profissional{ID: 7}, nil)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(3), uint(7)).Return(revogado, nil)
+
+	analise, err := servico.GerarAnaliseHistorica(20, 3, "profissional", 7)
+
+	assert.Nil(t, analise)
+	assert.Equal(t, dominio.ErrConsentimentoNegado, err)
+	registroRepo.AssertNotCalled(t, "BuscarPorPacienteEPeriodo", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,292 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// ========== Mocks ==========
+
+// MockConsultaRepositorio simula o repositorio de consultas
+type MockConsultaRepositorio struct {
+	mock.Mock
+}
+
+func (m *MockConsultaRepositorio) CriarConsulta(tx *gorm.DB, consulta *dominio.Consulta) error {
+	args := m.Called(tx, consulta)
+	return args.Error(0)
+}
+
+func (m *MockConsultaRepositorio) AtualizarConsulta(tx *gorm.DB, consulta *dominio.Consulta) error {
+	args := m.Called(tx, consulta)
+	return args.Error(0)
+}
+
+func (m *MockConsultaRepositorio) BuscarConsultaPorID(tx *gorm.DB, consultaID uint) (*dominio.Consulta, error) {
+	args := m.Called(tx, consultaID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dominio.Consulta), args.Error(1)
+}
+
+func (m *MockConsultaRepositorio) ListarConsultasDoProfissional(tx *gorm.DB, profissionalID uint, de, ate time.Time) ([]*dominio.Consulta, error) {
+	args := m.Called(tx, profissionalID, de, ate)
+	return args.Get(0).([]*dominio.Consulta), args.Error(1)
+}
+
+func (m *MockConsultaRepositorio) ListarConsultasDoPaciente(tx *gorm.DB, pacienteID uint, de, ate time.Time) ([]*dominio.Consulta, error) {
+	args := m.Called(tx, pacienteID, de, ate)
+	return args.Get(0).([]*dominio.Consulta), args.Error(1)
+}
+
+func (m *MockConsultaRepositorio) BuscarConflitos(tx *gorm.DB, profissionalID uint, inicio, fim time.Time, ignorarID uint) ([]*dominio.Consulta, error) {
+	args := m.Called(tx, profissionalID, inicio, fim, ignorarID)
+	return args.Get(0).([]*dominio.Consulta), args.Error(1)
+}
+
+func (m *MockConsultaRepositorio) ListarConsultasFuturasDaSerie(tx *gorm.DB, serieID uint, aPartirDe time.Time) ([]*dominio.Consulta, error) {
+	args := m.Called(tx, serieID, aPartirDe)
+	return args.Get(0).([]*dominio.Consulta), args.Error(1)
+}
+
+func (m *MockConsultaRepositorio) ListarConsultasAguardandoQuestionario(tx *gorm.DB, agora, limite time.Time) ([]*dominio.Consulta, error) {
+	args := m.Called(tx, agora, limite)
+	return args.Get(0).([]*dominio.Consulta), args.Error(1)
+}
+
+func (m *MockConsultaRepositorio) CancelarAtribuicaoPendente(tx *gorm.DB, atribuicaoID uint) error {
+	args := m.Called(tx, atribuicaoID)
+	return args.Error(0)
+}
+
+func (m *MockConsultaRepositorio) SalvarCalendario(tx *gorm.DB, calendario *dominio.CalendarioProfissional) error {
+	args := m.Called(tx, calendario)
+	return args.Error(0)
+}
+
+func (m *MockConsultaRepositorio) BuscarCalendarioPorTokenHash(tx *gorm.DB, tokenHash string) (*dominio.CalendarioProfissional, error) {
+	args := m.Called(tx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dominio.CalendarioProfissional), args.Error(1)
+}
+
+// MockInstrumentoRepositorioConsulta registra a busca do instrumento e a criacao da atribuicao
+type MockInstrumentoRepositorioConsulta struct {
+	MockInstrumentoRepositorioResponsavel
+}
+
+func (m *MockInstrumentoRepositorioConsulta) BuscarInstrumentoPorID(tx *gorm.DB, instrumentoID uint) (*dominio.Instrumento, error) {
+	args := m.Called(tx, instrumentoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dominio.Instrumento), args.Error(1)
+}
+
+func (m *MockInstrumentoRepositorioConsulta) CriarAtribuicao(tx *gorm.DB, atribuicao *dominio.Atribuicao) error {
+	args := m.Called(tx, atribuicao)
+	return args.Error(0)
+}
+
+// ========== Helpers ==========
+
+type dependenciasConsulta struct {
+	usuarioRepo       *MockUsuarioRepositorio
+	vinculoRepo       *MockVinculoRepositorio
+	consultaRepo      *MockConsultaRepositorio
+	instrumentoRepo   *MockInstrumentoRepositorioConsulta
+	consentimentoRepo *MockConsentimentoRepositorio
+	notificacaoRepo   *MockNotificacaoRepositorio
+	responsavelRepo   *MockResponsavelRepositorio
+}
+
+// setupConsulta prepara o profissional 7 (usuario 20) com vinculo ativo com o paciente 3 (usuario 10)
+func setupConsulta(t *testing.T) (servicos.ConsultaServico, *dependenciasConsulta) {
+	deps := &dependenciasConsulta{
+		usuarioRepo:       new(MockUsuarioRepositorio),
+		vinculoRepo:       new(MockVinculoRepositorio),
+		consultaRepo:      new(MockConsultaRepositorio),
+		instrumentoRepo:   new(MockInstrumentoRepositorioConsulta),
+		consentimentoRepo: new(MockConsentimentoRepositorio),
+		notificacaoRepo:   new(MockNotificacaoRepositorio),
+		responsavelRepo:   new(MockResponsavelRepositorio),
+	}
+	servico := servicos.NovoConsultaServico(setupTestDB(t), deps.usuarioRepo, deps.vinculoRepo, deps.consultaRepo,
+		deps.instrumentoRepo, deps.consentimentoRepo, deps.notificacaoRepo, deps.responsavelRepo)
+
+	deps.usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(20)).
+		Return(&dominio.Profissional{ID: 7, UsuarioID: 20, Usuario: dominio.Usuario{Nome: "Dra. Lia"}}, nil)
+	deps.usuarioRepo.On("BuscarPacientePorID", mock.Anything, uint(3)).Return(&dominio.Paciente{ID: 3, UsuarioID: 10}, nil)
+	deps.vinculoRepo.On("BuscarVinculo", mock.Anything, uint(3), uint(7)).Return(&dominio.Vinculo{PacienteID: 3, ProfissionalID: 7}, nil)
+	return servico, deps
+}
+
+// ========== Testes do Serviço ==========
+
+func TestConsultaServico_AgendarConsulta_SerieSemanal(t *testing.T) {
+	servico, deps := setupConsulta(t)
+
+	inicio := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	deps.consultaRepo.On("BuscarConflitos", mock.Anything, uint(7), mock.Anything, mock.Anything, uint(0)).Return([]*dominio.Consulta{}, nil)
+	proximoID := uint(100)
+	deps.consultaRepo.On("CriarConsulta", mock.Anything, mock.AnythingOfType("*dominio.Consulta")).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*dominio.Consulta).ID = proximoID
+			proximoID++
+		}).Return(nil)
+	deps.consultaRepo.On("AtualizarConsulta", mock.Anything, mock.MatchedBy(func(c *dominio.Consulta) bool {
+		return c.ID == 100 && c.SerieID != nil && *c.SerieID == 100
+	})).Return(nil).Once()
+	deps.notificacaoRepo.On("CriarNotificacao", mock.Anything, mock.MatchedBy(func(n *dominio.Notificacao) bool {
+		return n.UsuarioID == 10 && strings.Contains(n.Conteudo, "3 consultas semanais")
+	})).Return(nil)
+
+	consultas, err := servico.AgendarConsulta(20, 3, &dtos.ConsultaDTOIn{
+		Inicio: inicio, Fim: inicio.Add(50 * time.Minute), Modalidade: dominio.ModalidadeOnline, RepeticoesSemanais: 3,
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, consultas, 3)
+	for i, c := range consultas {
+		assert.Equal(t, uint(100), *c.SerieID)
+		assert.Equal(t, dominio.StatusConsultaAgendada, c.Status)
+		assert.True(t, c.Inicio.Equal(inicio.AddDate(0, 0, 7*i)))
+	}
+	deps.consultaRepo.AssertExpectations(t)
+	deps.notificacaoRepo.AssertExpectations(t)
+}
+
+func TestConsultaServico_AgendarConsulta_ConflitoNaoGravaNada(t *testing.T) {
+	servico, deps := setupConsulta(t)
+
+	inicio := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	// A segunda semana conflita com outra consulta do profissional
+	deps.consultaRepo.On("BuscarConflitos", mock.Anything, uint(7), inicio, mock.Anything, uint(0)).Return([]*dominio.Consulta{}, nil)
+	deps.consultaRepo.On("BuscarConflitos", mock.Anything, uint(7), inicio.AddDate(0, 0, 7), mock.Anything, uint(0)).
+		Return([]*dominio.Consulta{{ID: 55}}, nil)
+
+	_, err := servico.AgendarConsulta(20, 3, &dtos.ConsultaDTOIn{
+		Inicio: inicio, Fim: inicio.Add(time.Hour), Modalidade: dominio.ModalidadePresencial, RepeticoesSemanais: 2,
+	})
+
+	assert.Equal(t, dominio.ErrConflitoAgenda, err)
+	deps.consultaRepo.AssertNotCalled(t, "CriarConsulta", mock.Anything, mock.Anything)
+}
+
+func TestConsultaServico_AgendarConsulta_SemVinculoAtivo(t *testing.T) {
+	servico, deps := setupConsulta(t)
+	deps.usuarioRepo.On("BuscarPacientePorID", mock.Anything, uint(4)).Return(&dominio.Paciente{ID: 4, UsuarioID: 11}, nil)
+	deps.vinculoRepo.On("BuscarVinculo", mock.Anything, uint(4), uint(7)).Return(nil, gorm.ErrRecordNotFound)
+
+	inicio := time.Now().Add(48 * time.Hour)
+	_, err := servico.AgendarConsulta(20, 4, &dtos.ConsultaDTOIn{Inicio: inicio, Fim: inicio.Add(time.Hour), Modalidade: dominio.ModalidadeOnline})
+
+	assert.Equal(t, dominio.ErrVinculoNaoEncontrado, err)
+}
+
+func TestConsultaServico_AlterarStatus_CancelaSerie(t *testing.T) {
+	servico, deps := setupConsulta(t)
+
+	serieID := uint(100)
+	atribuicaoID := uint(900)
+	inicio := time.Now().Add(2 * time.Hour)
+	consulta := &dominio.Consulta{ID: 100, ProfissionalID: 7, PacienteID: 3, Inicio: inicio, Fim: inicio.Add(time.Hour),
+		Status: dominio.StatusConsultaAgendada, SerieID: &serieID, AtribuicaoID: &atribuicaoID, Paciente: dominio.Paciente{ID: 3, UsuarioID: 10}}
+	seguinte := &dominio.Consulta{ID: 101, ProfissionalID: 7, PacienteID: 3, Inicio: inicio.AddDate(0, 0, 7), Fim: inicio.AddDate(0, 0, 7).Add(time.Hour),
+		Status: dominio.StatusConsultaAgendada, SerieID: &serieID}
+
+	deps.consultaRepo.On("BuscarConsultaPorID", mock.Anything, uint(100)).Return(consulta, nil)
+	deps.consultaRepo.On("ListarConsultasFuturasDaSerie", mock.Anything, uint(100), inicio).Return([]*dominio.Consulta{consulta, seguinte}, nil)
+	deps.consultaRepo.On("AtualizarConsulta", mock.Anything, mock.MatchedBy(func(c *dominio.Consulta) bool {
+		return c.Status == dominio.StatusConsultaCancelada
+	})).Return(nil)
+	deps.consultaRepo.On("CancelarAtribuicaoPendente", mock.Anything, uint(900)).Return(nil)
+	deps.notificacaoRepo.On("CriarNotificacao", mock.Anything, mock.MatchedBy(func(n *dominio.Notificacao) bool {
+		return n.UsuarioID == 10 && strings.Contains(n.Conteudo, "consultas semanais")
+	})).Return(nil)
+
+	alteradas, err := servico.AlterarStatusConsulta(20, 100, &dtos.AlterarStatusConsultaDTOIn{Status: dominio.StatusConsultaCancelada, Serie: true})
+
+	assert.NoError(t, err)
+	assert.Len(t, alteradas, 2)
+	deps.consultaRepo.AssertNumberOfCalls(t, "AtualizarConsulta", 2)
+	deps.consultaRepo.AssertCalled(t, "CancelarAtribuicaoPendente", mock.Anything, uint(900))
+}
+
+func TestConsultaServico_AlterarStatus_RealizadaAntesDoInicio(t *testing.T) {
+	servico, deps := setupConsulta(t)
+
+	inicio := time.Now().Add(2 * time.Hour)
+	deps.consultaRepo.On("BuscarConsultaPorID", mock.Anything, uint(100)).
+		Return(&dominio.Consulta{ID: 100, ProfissionalID: 7, PacienteID: 3, Inicio: inicio, Fim: inicio.Add(time.Hour), Status: dominio.StatusConsultaAgendada}, nil)
+
+	_, err := servico.AlterarStatusConsulta(20, 100, &dtos.AlterarStatusConsultaDTOIn{Status: dominio.StatusConsultaRealizada})
+
+	assert.Equal(t, dominio.ErrConsultaAindaNaoOcorreu, err)
+	deps.consultaRepo.AssertNotCalled(t, "AtualizarConsulta", mock.Anything, mock.Anything)
+}
+
+func TestConsultaServico_AtribuirQuestionariosPreSessao(t *testing.T) {
+	servico, deps := setupConsulta(t)
+
+	instrumentoID := uint(2)
+	inicio := time.Now().Add(20 * time.Hour)
+	consulta := &dominio.Consulta{ID: 100, ProfissionalID: 7, PacienteID: 3, Inicio: inicio, Fim: inicio.Add(time.Hour),
+		Status: dominio.StatusConsultaAgendada, InstrumentoID: &instrumentoID, Paciente: dominio.Paciente{ID: 3, UsuarioID: 10}}
+
+	deps.consultaRepo.On("ListarConsultasAguardandoQuestionario", mock.Anything, mock.Anything, mock.Anything).Return([]*dominio.Consulta{consulta}, nil)
+	deps.consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(3), uint(7)).Return(dominio.NovoConsentimentoPadrao(3, 7, time.Now().AddDate(0, -1, 0)), nil)
+	deps.instrumentoRepo.On("BuscarInstrumentoPorID", mock.Anything, uint(2)).Return(&dominio.Instrumento{ID: 2, Nome: "PHQ-9"}, nil)
+	deps.instrumentoRepo.On("CriarAtribuicao", mock.Anything, mock.MatchedBy(func(a *dominio.Atribuicao) bool {
+		return a.PacienteID == 3 && a.ProfissionalID == 7 && a.InstrumentoID == 2
+	})).Run(func(args mock.Arguments) { args.Get(1).(*dominio.Atribuicao).ID = 900 }).Return(nil)
+	deps.consultaRepo.On("AtualizarConsulta", mock.Anything, mock.MatchedBy(func(c *dominio.Consulta) bool {
+		return c.AtribuicaoID != nil && *c.AtribuicaoID == 900
+	})).Return(nil)
+	deps.notificacaoRepo.On("CriarNotificacao", mock.Anything, mock.MatchedBy(func(n *dominio.Notificacao) bool {
+		return n.UsuarioID == 10 && strings.Contains(n.Conteudo, "PHQ-9")
+	})).Return(nil)
+	deps.responsavelRepo.On("ListarResponsaveisDoPaciente", mock.Anything, uint(3)).Return([]*dominio.VinculoResponsavel{}, nil)
+
+	assert.NoError(t, servico.AtribuirQuestionariosPreSessao())
+	deps.instrumentoRepo.AssertExpectations(t)
+	deps.consultaRepo.AssertExpectations(t)
+	deps.notificacaoRepo.AssertExpectations(t)
+}
+
+func TestConsultaServico_CalendarioICS(t *testing.T) {
+	servico, deps := setupConsulta(t)
+
+	deps.consultaRepo.On("BuscarCalendarioPorTokenHash", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound).Once()
+	_, err := servico.CalendarioICS("token-antigo")
+	assert.Equal(t, dominio.ErrCalendarioLinkInvalido, err)
+
+	inicio := time.Date(2030, 3, 4, 13, 0, 0, 0, time.UTC)
+	deps.consultaRepo.On("BuscarCalendarioPorTokenHash", mock.Anything, mock.Anything).Return(&dominio.CalendarioProfissional{ProfissionalID: 7}, nil)
+	deps.consultaRepo.On("ListarConsultasDoProfissional", mock.Anything, uint(7), mock.Anything, mock.Anything).Return([]*dominio.Consulta{
+		{ID: 100, Inicio: inicio, Fim: inicio.Add(time.Hour), Modalidade: dominio.ModalidadeOnline, Status: dominio.StatusConsultaAgendada,
+			Local: "Sala 2, Bloco B", Paciente: dominio.Paciente{Usuario: dominio.Usuario{Nome: "Ana"}}},
+		{ID: 101, Inicio: inicio.AddDate(0, 0, 7), Fim: inicio.AddDate(0, 0, 7).Add(time.Hour), Status: dominio.StatusConsultaCancelada},
+	}, nil)
+
+	ics, err := servico.CalendarioICS("token-atual")
+	assert.NoError(t, err)
+	texto := string(ics)
+	assert.True(t, strings.HasPrefix(texto, "BEGIN:VCALENDAR\r\n"))
+	assert.Contains(t, texto, "UID:consulta-100@mindtrace\r\n")
+	assert.Contains(t, texto, "DTSTART:20300304T130000Z\r\n")
+	assert.Contains(t, texto, "LOCATION:Sala 2\\, Bloco B\r\n")
+	assert.Contains(t, texto, "STATUS:CANCELLED\r\n")
+	assert.True(t, strings.HasSuffix(texto, "END:VCALENDAR\r\n"))
+}
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"errors"
+	"mindtrace/backend/interno/aplicacao/dtos"
 	"mindtrace/backend/interno/aplicacao/servicos"
 	"mindtrace/backend/interno/dominio"
 	"testing"
@@ -38,6 +39,24 @@ func (m *MockConviteRepositorio) MarcarConviteComoUsado(tx *gorm.DB, convite *do
 	return args.Error(0)
 }
 
+func (m *MockConviteRepositorio) BuscarConvitePorID(tx *gorm.DB, id uint) (*dominio.Convite, error) {
+	args := m.Called(tx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dominio.Convite), args.Error(1)
+}
+
+func (m *MockConviteRepositorio) ListarConvitesDoProfissional(tx *gorm.DB, profissionalID uint) ([]*dominio.Convite, error) {
+	args := m.Called(tx, profissionalID)
+	return args.Get(0).([]*dominio.Convite), args.Error(1)
+}
+
+func (m *MockConviteRepositorio) RevogarConvite(tx *gorm.DB, convite *dominio.Convite) error {
+	args := m.Called(tx, convite)
+	return args.Error(0)
+}
+
 func (m *MockConviteRepositorio) BuscarConvitesAtivos(profissionalID uint) ([]*dominio.Convite, error) {
 	args := m.Called(profissionalID)
 	if args.Get(0) == nil {
@@ -67,6 +86,10 @@ func (m *MockUsuarioRepositorioConvite) BuscarPorEmail(email string) (*dominio.U
 	return nil, nil
 }
 
+func (m *MockUsuarioRepositorioConvite) BuscarPorCPF(cpf string) (*dominio.Usuario, error) {
+	return nil, nil
+}
+
 func (m *MockUsuarioRepositorioConvite) BuscarUsuarioPorID(id uint) (*dominio.Usuario, error) {
 	return nil, nil
 }
@@ -111,10 +134,6 @@ func (m *MockUsuarioRepositorioConvite) AtualizarPaciente(tx *gorm.DB, paciente
 	return nil
 }
 
-func (m *MockUsuarioRepositorioConvite) DeletarUsuario(tx *gorm.DB, id uint) error {
-	return nil
-}
-
 // ========== Helper Functions ==========
 
 func setupTestDBConvite(t *testing.T) *gorm.DB {
@@ -137,8 +156,10 @@ func TestConviteServico_GerarConvite_Sucesso(t *testing.T) {
 	db := setupTestDBConvite(t)
 	mockConviteRepo := new(MockConviteRepositorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockConsentimentoRepo := new(MockConsentimentoRepositorio)
+	mockVinculoRepo := new(MockVinculoRepositorio)
 
-	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo)
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, mockConsentimentoRepo, mockVinculoRepo, servicos.NovoEmailServicoLocal())
 
 	profissionalExistente := &dominio.Profissional{
 		ID:        1,
@@ -148,7 +169,7 @@ func TestConviteServico_GerarConvite_Sucesso(t *testing.T) {
 	mockUsuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(10)).Return(profissionalExistente, nil)
 	mockConviteRepo.On("CriarConvite", mock.Anything, mock.AnythingOfType("*dominio.Convite")).Return(nil)
 
-	resultado, err := servico.GerarConvite(10)
+	resultado, err := servico.GerarConvite(10, nil)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resultado)
@@ -163,12 +184,14 @@ func TestConviteServico_GerarConvite_ProfissionalNaoEncontrado(t *testing.T) {
 	db := setupTestDBConvite(t)
 	mockConviteRepo := new(MockConviteRepositorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockConsentimentoRepo := new(MockConsentimentoRepositorio)
+	mockVinculoRepo := new(MockVinculoRepositorio)
 
-	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo)
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, mockConsentimentoRepo, mockVinculoRepo, servicos.NovoEmailServicoLocal())
 
 	mockUsuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(999)).Return(nil, gorm.ErrRecordNotFound)
 
-	resultado, err := servico.GerarConvite(999)
+	resultado, err := servico.GerarConvite(999, nil)
 
 	assert.Error(t, err)
 	assert.Equal(t, dominio.ErrUsuarioNaoEncontrado, err)
@@ -181,13 +204,15 @@ func TestConviteServico_GerarConvite_ErroAoBuscarProfissional(t *testing.T) {
 	db := setupTestDBConvite(t)
 	mockConviteRepo := new(MockConviteRepositorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockConsentimentoRepo := new(MockConsentimentoRepositorio)
+	mockVinculoRepo := new(MockVinculoRepositorio)
 
-	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo)
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, mockConsentimentoRepo, mockVinculoRepo, servicos.NovoEmailServicoLocal())
 
 	erroGenerico := errors.New("erro de conexão com banco de dados")
 	mockUsuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(10)).Return(nil, erroGenerico)
 
-	resultado, err := servico.GerarConvite(10)
+	resultado, err := servico.GerarConvite(10, nil)
 
 	assert.Error(t, err)
 	assert.Equal(t, erroGenerico, err)
@@ -200,8 +225,10 @@ func TestConviteServico_GerarConvite_ErroAoCriarConvite(t *testing.T) {
 	db := setupTestDBConvite(t)
 	mockConviteRepo := new(MockConviteRepositorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockConsentimentoRepo := new(MockConsentimentoRepositorio)
+	mockVinculoRepo := new(MockVinculoRepositorio)
 
-	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo)
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, mockConsentimentoRepo, mockVinculoRepo, servicos.NovoEmailServicoLocal())
 
 	profissionalExistente := &dominio.Profissional{
 		ID:        1,
@@ -212,7 +239,7 @@ func TestConviteServico_GerarConvite_ErroAoCriarConvite(t *testing.T) {
 	mockUsuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(10)).Return(profissionalExistente, nil)
 	mockConviteRepo.On("CriarConvite", mock.Anything, mock.AnythingOfType("*dominio.Convite")).Return(erroGenerico)
 
-	resultado, err := servico.GerarConvite(10)
+	resultado, err := servico.GerarConvite(10, nil)
 
 	assert.Error(t, err)
 	assert.Equal(t, erroGenerico, err)
@@ -225,8 +252,10 @@ func TestConviteServico_GerarConvite_TokenAleatorio(t *testing.T) {
 	db := setupTestDBConvite(t)
 	mockConviteRepo := new(MockConviteRepositorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockConsentimentoRepo := new(MockConsentimentoRepositorio)
+	mockVinculoRepo := new(MockVinculoRepositorio)
 
-	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo)
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, mockConsentimentoRepo, mockVinculoRepo, servicos.NovoEmailServicoLocal())
 
 	profissionalExistente := &dominio.Profissional{
 		ID:        1,
@@ -240,7 +269,7 @@ func TestConviteServico_GerarConvite_TokenAleatorio(t *testing.T) {
 	tokens := make(map[string]bool)
 
 	for i := 0; i < 3; i++ {
-		resultado, err := servico.GerarConvite(10)
+		resultado, err := servico.GerarConvite(10, nil)
 		assert.NoError(t, err)
 		assert.NotNil(t, resultado)
 		assert.NotEmpty(t, resultado.Token)
@@ -259,6 +288,8 @@ func TestConviteServico_VincularPaciente_Sucesso(t *testing.T) {
 	db := setupTestDBConvite(t)
 	mockConviteRepo := new(MockConviteRepositorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockConsentimentoRepo := new(MockConsentimentoRepositorio)
+	mockVinculoRepo := new(MockVinculoRepositorio)
 
 	// Criar tabela de associação many-to-many
 	db.Exec(`CREATE TABLE IF NOT EXISTS profissionais_pacientes (
@@ -267,7 +298,7 @@ func TestConviteServico_VincularPaciente_Sucesso(t *testing.T) {
 		PRIMARY KEY (profissional_id, paciente_id)
 	)`)
 
-	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo)
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, mockConsentimentoRepo, mockVinculoRepo, servicos.NovoEmailServicoLocal())
 
 	conviteValido := &dominio.Convite{
 		ID:             1,
@@ -284,21 +315,30 @@ func TestConviteServico_VincularPaciente_Sucesso(t *testing.T) {
 
 	mockConviteRepo.On("BuscarConvitePorToken", mock.Anything, "abc123def456").Return(conviteValido, nil)
 	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(20)).Return(pacienteExistente, nil)
+	mockVinculoRepo.On("BuscarVinculo", mock.Anything, uint(1), uint(1)).Return(nil, gorm.ErrRecordNotFound)
+	mockVinculoRepo.On("CriarVinculo", mock.Anything, mock.AnythingOfType("*dominio.Vinculo")).Return(nil)
 	mockConviteRepo.On("MarcarConviteComoUsado", mock.Anything, conviteValido).Return(nil)
+	mockConsentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(1), uint(1)).Return(nil, gorm.ErrRecordNotFound)
+	mockConsentimentoRepo.On("CriarConsentimento", mock.Anything, mock.MatchedBy(func(c *dominio.Consentimento) bool {
+		return c.Versao == 1 && c.PacienteID == 1 && c.ProfissionalID == 1 && !c.CompartilharObservacoes
+	})).Return(nil)
 
 	err := servico.VincularPaciente(20, "abc123def456")
 
 	assert.NoError(t, err)
 	mockConviteRepo.AssertExpectations(t)
 	mockUsuarioRepo.AssertExpectations(t)
+	mockConsentimentoRepo.AssertExpectations(t)
 }
 
 func TestConviteServico_VincularPaciente_TokenNaoEncontrado(t *testing.T) {
 	db := setupTestDBConvite(t)
 	mockConviteRepo := new(MockConviteRepositorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockConsentimentoRepo := new(MockConsentimentoRepositorio)
+	mockVinculoRepo := new(MockVinculoRepositorio)
 
-	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo)
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, mockConsentimentoRepo, mockVinculoRepo, servicos.NovoEmailServicoLocal())
 
 	mockConviteRepo.On("BuscarConvitePorToken", mock.Anything, "token-invalido").Return(nil, gorm.ErrRecordNotFound)
 
@@ -314,8 +354,10 @@ func TestConviteServico_VincularPaciente_ConviteExpirado(t *testing.T) {
 	db := setupTestDBConvite(t)
 	mockConviteRepo := new(MockConviteRepositorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockConsentimentoRepo := new(MockConsentimentoRepositorio)
+	mockVinculoRepo := new(MockVinculoRepositorio)
 
-	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo)
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, mockConsentimentoRepo, mockVinculoRepo, servicos.NovoEmailServicoLocal())
 
 	conviteExpirado := &dominio.Convite{
 		ID:             1,
@@ -339,8 +381,10 @@ func TestConviteServico_VincularPaciente_ConviteJaUtilizado(t *testing.T) {
 	db := setupTestDBConvite(t)
 	mockConviteRepo := new(MockConviteRepositorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockConsentimentoRepo := new(MockConsentimentoRepositorio)
+	mockVinculoRepo := new(MockVinculoRepositorio)
 
-	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo)
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, mockConsentimentoRepo, mockVinculoRepo, servicos.NovoEmailServicoLocal())
 
 	pacienteIDExistente := uint(99)
 	conviteUsado := &dominio.Convite{
@@ -366,8 +410,10 @@ func TestConviteServico_VincularPaciente_PacienteNaoEncontrado(t *testing.T) {
 	db := setupTestDBConvite(t)
 	mockConviteRepo := new(MockConviteRepositorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockConsentimentoRepo := new(MockConsentimentoRepositorio)
+	mockVinculoRepo := new(MockVinculoRepositorio)
 
-	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo)
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, mockConsentimentoRepo, mockVinculoRepo, servicos.NovoEmailServicoLocal())
 
 	conviteValido := &dominio.Convite{
 		ID:             1,
@@ -392,8 +438,10 @@ func TestConviteServico_VincularPaciente_ErroAoBuscarPaciente(t *testing.T) {
 	db := setupTestDBConvite(t)
 	mockConviteRepo := new(MockConviteRepositorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockConsentimentoRepo := new(MockConsentimentoRepositorio)
+	mockVinculoRepo := new(MockVinculoRepositorio)
 
-	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo)
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, mockConsentimentoRepo, mockVinculoRepo, servicos.NovoEmailServicoLocal())
 
 	conviteValido := &dominio.Convite{
 		ID:             1,
@@ -419,6 +467,8 @@ func TestConviteServico_VincularPaciente_ErroAoMarcarComoUsado(t *testing.T) {
 	db := setupTestDBConvite(t)
 	mockConviteRepo := new(MockConviteRepositorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockConsentimentoRepo := new(MockConsentimentoRepositorio)
+	mockVinculoRepo := new(MockVinculoRepositorio)
 
 	// Criar tabela de associação many-to-many
 	db.Exec(`CREATE TABLE IF NOT EXISTS profissionais_pacientes (
@@ -427,7 +477,7 @@ func TestConviteServico_VincularPaciente_ErroAoMarcarComoUsado(t *testing.T) {
 		PRIMARY KEY (profissional_id, paciente_id)
 	)`)
 
-	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo)
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, mockConsentimentoRepo, mockVinculoRepo, servicos.NovoEmailServicoLocal())
 
 	conviteValido := &dominio.Convite{
 		ID:             1,
@@ -445,6 +495,8 @@ func TestConviteServico_VincularPaciente_ErroAoMarcarComoUsado(t *testing.T) {
 	erroGenerico := errors.New("erro ao atualizar convite")
 	mockConviteRepo.On("BuscarConvitePorToken", mock.Anything, "abc123def456").Return(conviteValido, nil)
 	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(20)).Return(pacienteExistente, nil)
+	mockVinculoRepo.On("BuscarVinculo", mock.Anything, uint(1), uint(1)).Return(nil, gorm.ErrRecordNotFound)
+	mockVinculoRepo.On("CriarVinculo", mock.Anything, mock.AnythingOfType("*dominio.Vinculo")).Return(nil)
 	mockConviteRepo.On("MarcarConviteComoUsado", mock.Anything, conviteValido).Return(erroGenerico)
 
 	err := servico.VincularPaciente(20, "abc123def456")
@@ -459,8 +511,10 @@ func TestConviteServico_VincularPaciente_ConviteExpiraEmSegundos(t *testing.T) {
 	db := setupTestDBConvite(t)
 	mockConviteRepo := new(MockConviteRepositorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockConsentimentoRepo := new(MockConsentimentoRepositorio)
+	mockVinculoRepo := new(MockVinculoRepositorio)
 
-	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo)
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, mockConsentimentoRepo, mockVinculoRepo, servicos.NovoEmailServicoLocal())
 
 	// Convite que expira em poucos segundos (ainda válido)
 	conviteQuaseExpirando := &dominio.Convite{
@@ -486,7 +540,13 @@ func TestConviteServico_VincularPaciente_ConviteExpiraEmSegundos(t *testing.T) {
 	)`)
 
 	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(20)).Return(pacienteExistente, nil)
+	mockVinculoRepo.On("BuscarVinculo", mock.Anything, uint(1), uint(1)).Return(nil, gorm.ErrRecordNotFound)
+	mockVinculoRepo.On("CriarVinculo", mock.Anything, mock.AnythingOfType("*dominio.Vinculo")).Return(nil)
 	mockConviteRepo.On("MarcarConviteComoUsado", mock.Anything, conviteQuaseExpirando).Return(nil)
+	mockConsentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(1), uint(1)).Return(nil, gorm.ErrRecordNotFound)
+	mockConsentimentoRepo.On("CriarConsentimento", mock.Anything, mock.MatchedBy(func(c *dominio.Consentimento) bool {
+		return c.Versao == 1 && c.PacienteID == 1 && c.ProfissionalID == 1 && !c.CompartilharObservacoes
+	})).Return(nil)
 
 	err := servico.VincularPaciente(20, "abc123def456")
 
@@ -495,3 +555,297 @@ func TestConviteServico_VincularPaciente_ConviteExpiraEmSegundos(t *testing.T) {
 	mockConviteRepo.AssertExpectations(t)
 	mockUsuarioRepo.AssertExpectations(t)
 }
+
+func TestConviteServico_VincularPaciente_ReativaVinculoEncerrado(t *testing.T) {
+	db := setupTestDBConvite(t)
+	mockConviteRepo := new(MockConviteRepositorio)
+	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockConsentimentoRepo := new(MockConsentimentoRepositorio)
+	mockVinculoRepo := new(MockVinculoRepositorio)
+
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, mockConsentimentoRepo, mockVinculoRepo, servicos.NovoEmailServicoLocal())
+
+	conviteValido := &dominio.Convite{
+		ID:             1,
+		ProfissionalID: 1,
+		Token:          "abc123def456",
+		DataExpiracao:  time.Now().Add(24 * time.Hour),
+	}
+	encerradoEm := time.Now().AddDate(0, -1, 0)
+	vinculoEncerrado := &dominio.Vinculo{
+		PacienteID:     1,
+		ProfissionalID: 1,
+		EncerradoEm:    &encerradoEm,
+		EncerradoPor:   dominio.EncerradoPeloProfissional,
+	}
+
+	mockConviteRepo.On("BuscarConvitePorToken", mock.Anything, "abc123def456").Return(conviteValido, nil)
+	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(20)).Return(&dominio.Paciente{ID: 1, UsuarioID: 20}, nil)
+	mockVinculoRepo.On("BuscarVinculo", mock.Anything, uint(1), uint(1)).Return(vinculoEncerrado, nil)
+	mockVinculoRepo.On("AtualizarVinculo", mock.Anything, mock.MatchedBy(func(v *dominio.Vinculo) bool {
+		return v.Ativo() && v.EncerradoPor == "" && v.VinculadoEm != nil
+	})).Return(nil)
+	mockConviteRepo.On("MarcarConviteComoUsado", mock.Anything, conviteValido).Return(nil)
+	mockConsentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(1), uint(1)).
+		Return(dominio.NovoConsentimentoPadrao(1, 1, encerradoEm), nil)
+
+	err := servico.VincularPaciente(20, "abc123def456")
+
+	assert.NoError(t, err)
+	mockVinculoRepo.AssertExpectations(t)
+	mockVinculoRepo.AssertNotCalled(t, "CriarVinculo", mock.Anything, mock.Anything)
+	mockConsentimentoRepo.AssertNotCalled(t, "CriarConsentimento", mock.Anything, mock.Anything)
+}
+
+func TestConviteServico_GerarConvite_Configurado(t *testing.T) {
+	db := setupTestDBConvite(t)
+	mockConviteRepo := new(MockConviteRepositorio)
+	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), servicos.NovoEmailServicoLocal())
+
+	mockUsuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Profissional{ID: 1, UsuarioID: 10}, nil)
+	mockConviteRepo.On("CriarConvite", mock.Anything, mock.AnythingOfType("*dominio.Convite")).Return(nil)
+
+	inicio := time.Now()
+	resultado, err := servico.GerarConvite(10, &dtos.GerarConviteDTOIn{ValidadeHoras: 72, MaxUsos: 20})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(20), resultado.MaxUsos)
+	assert.Equal(t, dominio.ConviteAtivo, resultado.Status)
+	assert.WithinDuration(t, inicio.Add(72*time.Hour), resultado.DataExpiracao, time.Minute)
+}
+
+func TestConviteServico_GerarConvite_EmailComVariosUsos(t *testing.T) {
+	db := setupTestDBConvite(t)
+	mockConviteRepo := new(MockConviteRepositorio)
+	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), servicos.NovoEmailServicoLocal())
+
+	mockUsuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Profissional{ID: 1, UsuarioID: 10}, nil)
+
+	resultado, err := servico.GerarConvite(10, &dtos.GerarConviteDTOIn{MaxUsos: 5, Email: "ana@example.com"})
+
+	assert.Nil(t, resultado)
+	assert.Equal(t, dominio.ErrConviteEmailVariosUsos, err)
+	mockConviteRepo.AssertNotCalled(t, "CriarConvite", mock.Anything, mock.Anything)
+}
+
+func TestConviteServico_GerarConvite_EnviaPorEmail(t *testing.T) {
+	db := setupTestDBConvite(t)
+	mockConviteRepo := new(MockConviteRepositorio)
+	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	email := servicos.NovoEmailServicoLocal()
+
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), email)
+
+	mockUsuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(10)).
+		Return(&dominio.Profissional{ID: 1, UsuarioID: 10, Usuario: dominio.Usuario{Nome: "Dra. Ana"}}, nil)
+	mockConviteRepo.On("CriarConvite", mock.Anything, mock.AnythingOfType("*dominio.Convite")).Return(nil)
+
+	resultado, err := servico.GerarConvite(10, &dtos.GerarConviteDTOIn{EnviarPara: "Paciente@Example.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "paciente@example.com", resultado.EnviadoPara)
+	assert.Contains(t, resultado.Link, "/convite?token="+resultado.Token)
+	mensagens := email.Mensagens()
+	assert.Len(t, mensagens, 1)
+	assert.Equal(t, "paciente@example.com", mensagens[0].Para)
+	assert.Contains(t, mensagens[0].Corpo, resultado.Link)
+	assert.Contains(t, mensagens[0].Corpo, "Dra. Ana")
+}
+
+func TestConviteServico_GerarConvite_EnviarParaOutroEmail(t *testing.T) {
+	db := setupTestDBConvite(t)
+	mockConviteRepo := new(MockConviteRepositorio)
+	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	email := servicos.NovoEmailServicoLocal()
+
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), email)
+
+	mockUsuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Profissional{ID: 1, UsuarioID: 10}, nil)
+
+	resultado, err := servico.GerarConvite(10, &dtos.GerarConviteDTOIn{Email: "ana@example.com", EnviarPara: "bia@example.com"})
+
+	assert.Nil(t, resultado)
+	assert.Equal(t, dominio.ErrConviteOutroEmail, err)
+	assert.Empty(t, email.Mensagens())
+	mockConviteRepo.AssertNotCalled(t, "CriarConvite", mock.Anything, mock.Anything)
+}
+
+func TestConviteServico_EnviarConvite_Expirado(t *testing.T) {
+	db := setupTestDBConvite(t)
+	mockConviteRepo := new(MockConviteRepositorio)
+	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	email := servicos.NovoEmailServicoLocal()
+
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), email)
+
+	mockUsuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Profissional{ID: 1, UsuarioID: 10}, nil)
+	mockConviteRepo.On("BuscarConvitePorID", mock.Anything, uint(5)).
+		Return(&dominio.Convite{ID: 5, ProfissionalID: 1, Token: "abc123def456", DataExpiracao: time.Now().Add(-time.Hour), MaxUsos: 1}, nil)
+
+	resultado, err := servico.EnviarConvite(10, 5, "paciente@example.com")
+
+	assert.Nil(t, resultado)
+	assert.Equal(t, dominio.ErrConviteExpirado, err)
+	assert.Empty(t, email.Mensagens())
+}
+
+func TestConviteServico_GerarQRCodeConvite_Formatos(t *testing.T) {
+	db := setupTestDBConvite(t)
+	mockConviteRepo := new(MockConviteRepositorio)
+	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), servicos.NovoEmailServicoLocal())
+
+	mockUsuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Profissional{ID: 1, UsuarioID: 10}, nil)
+	mockConviteRepo.On("BuscarConvitePorID", mock.Anything, uint(5)).
+		Return(&dominio.Convite{ID: 5, ProfissionalID: 1, Token: "abc123def456", DataExpiracao: time.Now().Add(time.Hour), MaxUsos: 1}, nil)
+
+	png, contentType, err := servico.GerarQRCodeConvite(10, 5, servicos.FormatoQRCodePNG)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	assert.Equal(t, []byte("\x89PNG"), png[:4])
+
+	svg, contentType, err := servico.GerarQRCodeConvite(10, 5, servicos.FormatoQRCodeSVG)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/svg+xml", contentType)
+	assert.Contains(t, string(svg), "<svg")
+
+	_, _, err = servico.GerarQRCodeConvite(10, 5, "gif")
+	assert.Equal(t, dominio.ErrFormatoQRCodeInvalido, err)
+}
+
+func TestConviteServico_ListarConvites_FiltraPorStatus(t *testing.T) {
+	db := setupTestDBConvite(t)
+	mockConviteRepo := new(MockConviteRepositorio)
+	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), servicos.NovoEmailServicoLocal())
+
+	agora := time.Now()
+	ontem := agora.AddDate(0, 0, -1)
+	mockUsuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Profissional{ID: 1, UsuarioID: 10}, nil)
+	mockConviteRepo.On("ListarConvitesDoProfissional", mock.Anything, uint(1)).Return([]*dominio.Convite{
+		{ID: 1, Token: "ativo123456", DataExpiracao: agora.Add(time.Hour), MaxUsos: 1},
+		{ID: 2, Token: "usado123456", DataExpiracao: agora.Add(time.Hour), MaxUsos: 1, Usos: 1, Usado: true},
+		{ID: 3, Token: "expirado123", DataExpiracao: ontem, MaxUsos: 1},
+		{ID: 4, Token: "revogado123", DataExpiracao: agora.Add(time.Hour), MaxUsos: 1, RevogadoEm: &ontem},
+	}, nil)
+
+	todos, err := servico.ListarConvites(10, "")
+	assert.NoError(t, err)
+	assert.Len(t, todos, 4)
+	assert.Equal(t, []string{dominio.ConviteAtivo, dominio.ConviteUsado, dominio.ConviteExpirado, dominio.ConviteRevogado},
+		[]string{todos[0].Status, todos[1].Status, todos[2].Status, todos[3].Status})
+
+	expirados, err := servico.ListarConvites(10, dominio.ConviteExpirado)
+	assert.NoError(t, err)
+	assert.Len(t, expirados, 1)
+	assert.Equal(t, uint(3), expirados[0].ID)
+}
+
+func TestConviteServico_RevogarConvite_Sucesso(t *testing.T) {
+	db := setupTestDBConvite(t)
+	mockConviteRepo := new(MockConviteRepositorio)
+	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), servicos.NovoEmailServicoLocal())
+
+	convite := &dominio.Convite{ID: 5, ProfissionalID: 1, Token: "abc123def456", DataExpiracao: time.Now().Add(time.Hour), MaxUsos: 10, Usos: 3}
+	mockUsuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Profissional{ID: 1, UsuarioID: 10}, nil)
+	mockConviteRepo.On("BuscarConvitePorID", mock.Anything, uint(5)).Return(convite, nil)
+	mockConviteRepo.On("RevogarConvite", mock.Anything, convite).Return(nil)
+
+	resultado, err := servico.RevogarConvite(10, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, dominio.ConviteRevogado, resultado.Status)
+	assert.NotNil(t, resultado.RevogadoEm)
+	mockConviteRepo.AssertExpectations(t)
+}
+
+func TestConviteServico_RevogarConvite_DeOutroProfissional(t *testing.T) {
+	db := setupTestDBConvite(t)
+	mockConviteRepo := new(MockConviteRepositorio)
+	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), new(MockVinculoRepositorio), servicos.NovoEmailServicoLocal())
+
+	mockUsuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Profissional{ID: 1, UsuarioID: 10}, nil)
+	mockConviteRepo.On("BuscarConvitePorID", mock.Anything, uint(5)).
+		Return(&dominio.Convite{ID: 5, ProfissionalID: 2, DataExpiracao: time.Now().Add(time.Hour)}, nil)
+
+	resultado, err := servico.RevogarConvite(10, 5)
+
+	assert.Nil(t, resultado)
+	assert.Equal(t, dominio.ErrConviteNaoEncontrado, err)
+	mockConviteRepo.AssertNotCalled(t, "RevogarConvite", mock.Anything, mock.Anything)
+}
+
+func TestConviteServico_VincularPaciente_EmailDiferente(t *testing.T) {
+	db := setupTestDBConvite(t)
+	mockConviteRepo := new(MockConviteRepositorio)
+	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockVinculoRepo := new(MockVinculoRepositorio)
+
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), mockVinculoRepo, servicos.NovoEmailServicoLocal())
+
+	convite := &dominio.Convite{
+		ID: 1, ProfissionalID: 1, Token: "abc123def456", MaxUsos: 1,
+		DataExpiracao:     time.Now().Add(time.Hour),
+		EmailDestinatario: "ana@example.com",
+	}
+	mockConviteRepo.On("BuscarConvitePorToken", mock.Anything, "abc123def456").Return(convite, nil)
+	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(20)).
+		Return(&dominio.Paciente{ID: 1, UsuarioID: 20, Usuario: dominio.Usuario{Email: "bruno@example.com"}}, nil)
+
+	err := servico.VincularPaciente(20, "abc123def456")
+
+	assert.Equal(t, dominio.ErrConviteOutroEmail, err)
+	mockVinculoRepo.AssertNotCalled(t, "BuscarVinculo", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestConviteServico_VincularPaciente_ConviteColetivoEsgotadoNaConcorrencia(t *testing.T) {
+	db := setupTestDBConvite(t)
+	mockConviteRepo := new(MockConviteRepositorio)
+	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockVinculoRepo := new(MockVinculoRepositorio)
+
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), mockVinculoRepo, servicos.NovoEmailServicoLocal())
+
+	convite := &dominio.Convite{ID: 1, ProfissionalID: 1, Token: "abc123def456", MaxUsos: 10, Usos: 9, DataExpiracao: time.Now().Add(time.Hour)}
+	mockConviteRepo.On("BuscarConvitePorToken", mock.Anything, "abc123def456").Return(convite, nil)
+	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(20)).Return(&dominio.Paciente{ID: 1, UsuarioID: 20}, nil)
+	mockVinculoRepo.On("BuscarVinculo", mock.Anything, uint(1), uint(1)).Return(nil, gorm.ErrRecordNotFound)
+	mockVinculoRepo.On("CriarVinculo", mock.Anything, mock.AnythingOfType("*dominio.Vinculo")).Return(nil)
+	// Outro paciente consumiu o ultimo uso entre a leitura e a gravacao
+	mockConviteRepo.On("MarcarConviteComoUsado", mock.Anything, mock.MatchedBy(func(c *dominio.Convite) bool {
+		return c.Usos == 10 && c.Usado
+	})).Return(gorm.ErrRecordNotFound)
+
+	err := servico.VincularPaciente(20, "abc123def456")
+
+	assert.Equal(t, dominio.ErrConviteJaUtilizado, err)
+}
+
+func TestConviteServico_VincularPaciente_JaVinculado(t *testing.T) {
+	db := setupTestDBConvite(t)
+	mockConviteRepo := new(MockConviteRepositorio)
+	mockUsuarioRepo := new(MockUsuarioRepositorioConvite)
+	mockVinculoRepo := new(MockVinculoRepositorio)
+
+	servico := servicos.NovoConviteServico(db, mockConviteRepo, mockUsuarioRepo, new(MockConsentimentoRepositorio), mockVinculoRepo, servicos.NovoEmailServicoLocal())
+
+	convite := &dominio.Convite{ID: 1, ProfissionalID: 1, Token: "abc123def456", MaxUsos: 10, DataExpiracao: time.Now().Add(time.Hour)}
+	mockConviteRepo.On("BuscarConvitePorToken", mock.Anything, "abc123def456").Return(convite, nil)
+	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(20)).Return(&dominio.Paciente{ID: 1, UsuarioID: 20}, nil)
+	mockVinculoRepo.On("BuscarVinculo", mock.Anything, uint(1), uint(1)).Return(&dominio.Vinculo{PacienteID: 1, ProfissionalID: 1}, nil)
+
+	err := servico.VincularPaciente(20, "abc123def456")
+
+	assert.Equal(t, dominio.ErrVinculoJaAtivo, err)
+	mockConviteRepo.AssertNotCalled(t, "MarcarConviteComoUsado", mock.Anything, mock.Anything)
+}
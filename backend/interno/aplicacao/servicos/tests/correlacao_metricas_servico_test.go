@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// criarDiasCorrelacao grava um registro por dia nos ultimos 12 dias; o sono do dia define o humor do dia seguinte
+func criarDiasCorrelacao(t *testing.T, db *gorm.DB, metricaID uint) {
+	agora := time.Now()
+	hoje := time.Date(agora.Year(), agora.Month(), agora.Day(), 12, 0, 0, 0, time.Local)
+	for i := 12; i >= 1; i-- {
+		registro := &dominio.RegistroHumor{
+			PacienteID:       1,
+			NivelHumor:       int16(1 + (i+1)%3),
+			HorasSono:        int16(5 + 2*(i%3)),
+			NivelEnergia:     int16(3 + i%2),
+			NivelStress:      5,
+			AutoCuidado:      "[]",
+			DataHoraRegistro: hoje.AddDate(0, 0, -i),
+		}
+		assert.NoError(t, db.Create(registro).Error)
+		if metricaID != 0 {
+			assert.NoError(t, db.Create(&dominio.ValorMetricaRegistro{RegistroHumorID: registro.ID, MetricaID: metricaID, Valor: float64(i % 5)}).Error)
+		}
+	}
+}
+
+func buscarCorrelacaoDTO(correlacoes []dtos.CorrelacaoParDTOOut, a, b string, defasagem int) *dtos.CorrelacaoParDTOOut {
+	for i := range correlacoes {
+		if correlacoes[i].MetricaA == a && correlacoes[i].MetricaB == b && correlacoes[i].Defasagem == defasagem {
+			return &correlacoes[i]
+		}
+	}
+	return nil
+}
+
+func TestAnaliseServico_GerarCorrelacoes_Paciente(t *testing.T) {
+	svc, db := setupMetricas(t, nil)
+	ansiedade, err := svc.metricas.CriarMetrica(50, 1, novaMetrica("Ansiedade", "ESCALA", 0, 10))
+	assert.NoError(t, err)
+	criarDiasCorrelacao(t, db, ansiedade.ID)
+
+	correlacoes, err := svc.analise.GerarCorrelacoes(10, 0, "paciente", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), correlacoes.PacienteID)
+	assert.Equal(t, 90, correlacoes.Dias)
+	assert.Contains(t, correlacoes.Fuso, "UTC")
+	assert.Equal(t, dominio.AmostraMinimaCorrelacao, correlacoes.AmostraMinima)
+
+	assert.Len(t, correlacoes.Metricas, 5)
+	assert.Equal(t, dtos.MetricaCorrelacaoDTOOut{Chave: "metrica_1", Rotulo: "Ansiedade", DiasComValor: 12}, correlacoes.Metricas[4])
+	// 10 pares no mesmo dia e 25 pares ordenados no dia seguinte
+	assert.Len(t, correlacoes.Correlacoes, 35)
+
+	sonoHumor := buscarCorrelacaoDTO(correlacoes.Correlacoes, "sono", "humor", 1)
+	assert.Equal(t, 11, sonoHumor.Pares)
+	assert.InDelta(t, 1.0, *sonoHumor.Pearson, 1e-9)
+	assert.True(t, sonoHumor.AmostraSuficiente)
+
+	stress := buscarCorrelacaoDTO(correlacoes.Correlacoes, "humor", "stress", 0)
+	assert.Nil(t, stress.Pearson)
+	assert.Contains(t, stress.Avisos, dominio.AvisoSemVariacao)
+}
+
+func TestAnaliseServico_GerarCorrelacoes_ProfissionalRespeitaConsentimento(t *testing.T) {
+	// Apenas o sono e compartilhado, a partir de pouco antes do registro de 5 dias atras
+	agora := time.Now()
+	inicio := time.Date(agora.Year(), agora.Month(), agora.Day(), 11, 0, 0, 0, time.Local).AddDate(0, 0, -5)
+	svc, db := setupMetricas(t, consentimentoDiario(false, true, false, inicio))
+	ansiedade, _ := svc.metricas.CriarMetrica(50, 1, novaMetrica("Ansiedade", "ESCALA", 0, 10))
+	criarDiasCorrelacao(t, db, ansiedade.ID)
+
+	correlacoes, err := svc.analise.GerarCorrelacoes(50, 1, "profissional", 30)
+	assert.NoError(t, err)
+	assert.Equal(t, []dtos.MetricaCorrelacaoDTOOut{{Chave: "sono", Rotulo: "Horas de sono", DiasComValor: 5}}, correlacoes.Metricas)
+	assert.Len(t, correlacoes.Correlacoes, 1)
+	assert.Equal(t, 4, correlacoes.Correlacoes[0].Pares)
+	assert.Equal(t, []string{dominio.AvisoAmostraInsuficiente}, correlacoes.Correlacoes[0].Avisos)
+}
+
+func TestAnaliseServico_GerarCorrelacoes_Erros(t *testing.T) {
+	svc, _ := setupMetricas(t, consentimentoDiario(false, false, true, time.Now().AddDate(0, 0, -30)))
+
+	_, err := svc.analise.GerarCorrelacoes(50, 1, "profissional", 30)
+	assert.Equal(t, dominio.ErrConsentimentoNegado, err)
+
+	_, err = svc.analise.GerarCorrelacoes(10, 0, "paciente", 400)
+	assert.Equal(t, dominio.ErrPeriodoCorrelacaoInvalido, err)
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/cifragem"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ativarDoisFatoresTeste inscreve o profissional e retorna os codigos de recuperacao gerados na ativacao
+func ativarDoisFatoresTeste(t *testing.T, svc servicos.DoisFatoresServico, userID uint) []string {
+	inscricao, err := svc.IniciarInscricao(userID)
+	assert.NoError(t, err)
+	segredo, err := dominio.DecodificarSegredoTOTP(inscricao.Segredo)
+	assert.NoError(t, err)
+
+	codigosOut, err := svc.AtivarDoisFatores(userID, dominio.GerarCodigoTOTP(segredo, time.Now()))
+	assert.NoError(t, err)
+	return codigosOut.Codigos
+}
+
+func TestDoisFatoresServico_CodigosRecuperacao(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&dominio.Usuario{}, &dominio.DoisFatores{}, &dominio.CodigoRecuperacao{}, &dominio.DesafioDoisFatores{}))
+	profissional := &dominio.Usuario{ID: 10, Nome: "Ana", Email: "ana@example.com", Senha: "x", TipoUsuario: dominio.TipoUsuarioProfissional}
+	assert.NoError(t, db.Create(profissional).Error)
+
+	usuarioRepo := new(MockUsuarioRepositorio)
+	usuarioRepo.On("BuscarUsuarioPorID", uint(10)).Return(profissional, nil)
+	svc := servicos.NovoDoisFatoresServico(db, usuarioRepo, sqlite_repo.NovoGormDoisFatoresRepositorio(db), false)
+
+	codigos := ativarDoisFatoresTeste(t, svc, 10)
+	assert.Len(t, codigos, dominio.QuantidadeCodigosRecuperacao)
+
+	t.Run("codigos com 80 bits guardados com bcrypt", func(t *testing.T) {
+		for _, codigo := range codigos {
+			assert.Len(t, strings.ReplaceAll(codigo, "-", ""), dominio.BytesCodigoRecuperacao*2)
+		}
+
+		var registros []dominio.CodigoRecuperacao
+		assert.NoError(t, db.Where("usuario_id = ?", 10).Find(&registros).Error)
+		assert.Len(t, registros, dominio.QuantidadeCodigosRecuperacao)
+		for _, registro := range registros {
+			assert.True(t, strings.HasPrefix(registro.CodigoHash, "$2"))
+		}
+		// Cada hash traz o proprio sal do bcrypt
+		assert.NotEqual(t, registros[0].CodigoHash, registros[1].CodigoHash)
+	})
+
+	t.Run("segredo totp gravado cifrado", func(t *testing.T) {
+		var bruto string
+		assert.NoError(t, db.Table("dois_fatores").Select("segredo").Where("usuario_id = ?", 10).Scan(&bruto).Error)
+		assert.True(t, cifragem.Cifrado([]byte(bruto)))
+
+		// A leitura pelo modelo devolve o segredo em claro, que continua validando os codigos
+		var doisFatores dominio.DoisFatores
+		assert.NoError(t, db.First(&doisFatores, "usuario_id = ?", 10).Error)
+		_, err := dominio.DecodificarSegredoTOTP(doisFatores.Segredo)
+		assert.NoError(t, err)
+	})
+
+	t.Run("codigo de recuperacao vale uma unica vez", func(t *testing.T) {
+		novos, err := svc.RegenerarCodigosRecuperacao(10, codigos[0])
+		assert.NoError(t, err)
+		assert.Len(t, novos.Codigos, dominio.QuantidadeCodigosRecuperacao)
+
+		// Os codigos anteriores deixaram de valer com a regeneracao
+		_, err = svc.RegenerarCodigosRecuperacao(10, codigos[1])
+		assert.Equal(t, dominio.ErrCodigoDoisFatoresInvalido, err)
+
+		// Hifens e caixa sao ignorados ao comparar
+		digitado := strings.ToUpper(strings.ReplaceAll(novos.Codigos[0], "-", ""))
+		_, err = svc.RegenerarCodigosRecuperacao(10, digitado)
+		assert.NoError(t, err)
+		_, err = svc.RegenerarCodigosRecuperacao(10, novos.Codigos[0])
+		assert.Equal(t, dominio.ErrCodigoDoisFatoresInvalido, err)
+	})
+}
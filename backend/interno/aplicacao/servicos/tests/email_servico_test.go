@@ -0,0 +1,40 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNovoEmailServico_Driver(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver string
+		smtp   string
+		valido bool
+	}{
+		{name: "Sem driver", driver: "", valido: false},
+		{name: "Driver desconhecido", driver: "smpt", valido: false},
+		{name: "SMTP sem servidor", driver: "smtp", valido: false},
+		{name: "SMTP configurado", driver: "smtp", smtp: "smtp.teste.com", valido: true},
+		{name: "Log explicito", driver: "log", valido: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EMAIL_DRIVER", tt.driver)
+			t.Setenv("SMTP_HOST", tt.smtp)
+			t.Setenv("SMTP_PORT", "587")
+			t.Setenv("EMAIL_REMETENTE", "nao-responda@teste.com")
+
+			servico, err := servicos.NovoEmailServico()
+			if tt.valido {
+				assert.NoError(t, err)
+				assert.NotNil(t, servico)
+			} else {
+				assert.Error(t, err)
+				assert.Nil(t, servico)
+			}
+		})
+	}
+}
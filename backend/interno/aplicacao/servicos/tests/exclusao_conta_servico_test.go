@@ -0,0 +1,388 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========== Mocks ==========
+
+// MockExclusaoContaRepositorio simula o repositorio de exclusoes de conta
+type MockExclusaoContaRepositorio struct {
+	mock.Mock
+}
+
+func (m *MockExclusaoContaRepositorio) CriarExclusao(tx *gorm.DB, exclusao *dominio.ExclusaoConta) error {
+	args := m.Called(tx, exclusao)
+	return args.Error(0)
+}
+
+func (m *MockExclusaoContaRepositorio) AtualizarExclusao(tx *gorm.DB, exclusao *dominio.ExclusaoConta) error {
+	args := m.Called(tx, exclusao)
+	return args.Error(0)
+}
+
+func (m *MockExclusaoContaRepositorio) BuscarExclusaoAgendada(tx *gorm.DB, usuarioID uint) (*dominio.ExclusaoConta, error) {
+	args := m.Called(tx, usuarioID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dominio.ExclusaoConta), args.Error(1)
+}
+
+func (m *MockExclusaoContaRepositorio) ListarExclusoesVencidas(tx *gorm.DB, agora time.Time) ([]*dominio.ExclusaoConta, error) {
+	args := m.Called(tx, agora)
+	return args.Get(0).([]*dominio.ExclusaoConta), args.Error(1)
+}
+
+func (m *MockExclusaoContaRepositorio) ListarUsuariosVinculados(tx *gorm.DB, usuarioID uint) ([]*dominio.Usuario, error) {
+	args := m.Called(tx, usuarioID)
+	return args.Get(0).([]*dominio.Usuario), args.Error(1)
+}
+
+func (m *MockExclusaoContaRepositorio) AnonimizarTitular(tx *gorm.DB, usuario *dominio.Usuario, emailOriginal string) ([]string, error) {
+	args := m.Called(tx, usuario, emailOriginal)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// MockNotificacaoRepositorio simula a gravacao de notificacoes
+type MockNotificacaoRepositorio struct {
+	mock.Mock
+}
+
+func (m *MockNotificacaoRepositorio) CriarNotificacao(tx *gorm.DB, notificacao *dominio.Notificacao) error {
+	args := m.Called(tx, notificacao)
+	return args.Error(0)
+}
+
+func (m *MockNotificacaoRepositorio) ListarNotificacoesDoUsuario(tx *gorm.DB, usuarioID uint, limite int) ([]*dominio.Notificacao, error) {
+	args := m.Called(tx, usuarioID, limite)
+	return args.Get(0).([]*dominio.Notificacao), args.Error(1)
+}
+
+// MockTentativaLoginRepositorio simula os contadores de login
+type MockTentativaLoginRepositorio struct {
+	mock.Mock
+}
+
+func (m *MockTentativaLoginRepositorio) BuscarTentativa(chave string) (*dominio.TentativaLogin, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MockTentativaLoginRepositorio) RegistrarFalhaTentativa(chave string, agora time.Time, limite int) (*dominio.TentativaLogin, bool, error) {
+	return &dominio.TentativaLogin{Chave: chave}, false, nil
+}
+
+func (m *MockTentativaLoginRepositorio) RemoverTentativa(chave string) error {
+	args := m.Called(chave)
+	return args.Error(0)
+}
+
+// ========== Helper Functions ==========
+
+type dependenciasExclusaoConta struct {
+	usuarioRepo     *MockUsuarioRepositorio
+	exclusaoRepo    *MockExclusaoContaRepositorio
+	notificacaoRepo *MockNotificacaoRepositorio
+	tentativaRepo   *MockTentativaLoginRepositorio
+	email           *servicos.EmailServicoLocal
+}
+
+func setupExclusaoConta(t *testing.T) (servicos.ExclusaoContaServico, *dependenciasExclusaoConta) {
+	deps := &dependenciasExclusaoConta{
+		usuarioRepo:     new(MockUsuarioRepositorio),
+		exclusaoRepo:    new(MockExclusaoContaRepositorio),
+		notificacaoRepo: new(MockNotificacaoRepositorio),
+		tentativaRepo:   new(MockTentativaLoginRepositorio),
+		email:           servicos.NovoEmailServicoLocal(),
+	}
+	servico := servicos.NovoExclusaoContaServico(setupTestDB(t), deps.usuarioRepo, deps.exclusaoRepo,
+		deps.notificacaoRepo, deps.tentativaRepo, deps.email, 0)
+	return servico, deps
+}
+
+// ========== Testes do Serviço ==========
+
+func TestExclusaoContaServico_SolicitarExclusao_Sucesso(t *testing.T) {
+	servico, deps := setupExclusaoConta(t)
+
+	paciente := &dominio.Usuario{ID: 1, Nome: "Maria", Email: "maria@example.com", TipoUsuario: dominio.TipoUsuarioPaciente}
+	profissional := &dominio.Usuario{ID: 2, Nome: "Dr. Joao", Email: "joao@example.com", TipoUsuario: dominio.TipoUsuarioProfissional}
+
+	deps.usuarioRepo.On("BuscarUsuarioPorID", uint(1)).Return(paciente, nil)
+	deps.exclusaoRepo.On("BuscarExclusaoAgendada", mock.Anything, uint(1)).Return(nil, gorm.ErrRecordNotFound)
+	deps.exclusaoRepo.On("CriarExclusao", mock.Anything, mock.AnythingOfType("*dominio.ExclusaoConta")).Return(nil)
+	deps.exclusaoRepo.On("ListarUsuariosVinculados", mock.Anything, uint(1)).Return([]*dominio.Usuario{profissional}, nil)
+	deps.notificacaoRepo.On("CriarNotificacao", mock.Anything, mock.MatchedBy(func(n *dominio.Notificacao) bool {
+		return n.UsuarioID == 2
+	})).Return(nil)
+
+	antes := time.Now()
+	exclusaoOut, err := servico.SolicitarExclusao(1, &dtos.SolicitarExclusaoContaDTOIn{Motivo: "nao uso mais"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, dominio.StatusExclusaoAgendada, exclusaoOut.Status)
+	assert.WithinDuration(t, antes.Add(dominio.PeriodoCarenciaExclusao), exclusaoOut.DataExecucao, time.Minute)
+
+	mensagens := deps.email.Mensagens()
+	assert.Len(t, mensagens, 2)
+	assert.Equal(t, "joao@example.com", mensagens[0].Para)
+	assert.Equal(t, "maria@example.com", mensagens[1].Para)
+
+	deps.exclusaoRepo.AssertExpectations(t)
+	deps.notificacaoRepo.AssertExpectations(t)
+}
+
+func TestExclusaoContaServico_SolicitarExclusao_JaAgendada(t *testing.T) {
+	servico, deps := setupExclusaoConta(t)
+
+	deps.usuarioRepo.On("BuscarUsuarioPorID", uint(1)).Return(&dominio.Usuario{ID: 1}, nil)
+	deps.exclusaoRepo.On("BuscarExclusaoAgendada", mock.Anything, uint(1)).Return(&dominio.ExclusaoConta{ID: 5}, nil)
+
+	exclusaoOut, err := servico.SolicitarExclusao(1, &dtos.SolicitarExclusaoContaDTOIn{})
+
+	assert.Nil(t, exclusaoOut)
+	assert.Equal(t, dominio.ErrExclusaoJaAgendada, err)
+	deps.exclusaoRepo.AssertNotCalled(t, "CriarExclusao", mock.Anything, mock.Anything)
+	assert.Empty(t, deps.email.Mensagens())
+}
+
+func TestExclusaoContaServico_CancelarExclusao_Sucesso(t *testing.T) {
+	servico, deps := setupExclusaoConta(t)
+
+	exclusao := dominio.NovaExclusaoConta(1, "", time.Now(), dominio.PeriodoCarenciaExclusao)
+	deps.usuarioRepo.On("BuscarUsuarioPorID", uint(1)).Return(&dominio.Usuario{ID: 1, Nome: "Maria"}, nil)
+	deps.exclusaoRepo.On("BuscarExclusaoAgendada", mock.Anything, uint(1)).Return(exclusao, nil)
+	deps.exclusaoRepo.On("AtualizarExclusao", mock.Anything, exclusao).Return(nil)
+	deps.exclusaoRepo.On("ListarUsuariosVinculados", mock.Anything, uint(1)).Return([]*dominio.Usuario{}, nil)
+
+	err := servico.CancelarExclusao(1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, dominio.StatusExclusaoCancelada, exclusao.Status)
+	assert.NotNil(t, exclusao.CanceladaEm)
+	deps.exclusaoRepo.AssertExpectations(t)
+}
+
+func TestExclusaoContaServico_CancelarExclusao_SemExclusaoAgendada(t *testing.T) {
+	servico, deps := setupExclusaoConta(t)
+
+	deps.usuarioRepo.On("BuscarUsuarioPorID", uint(1)).Return(&dominio.Usuario{ID: 1}, nil)
+	deps.exclusaoRepo.On("BuscarExclusaoAgendada", mock.Anything, uint(1)).Return(nil, gorm.ErrRecordNotFound)
+
+	err := servico.CancelarExclusao(1)
+
+	assert.Equal(t, dominio.ErrExclusaoNaoAgendada, err)
+}
+
+func TestExclusaoContaServico_ExecutarExclusoesVencidas_AnonimizaTitular(t *testing.T) {
+	servico, deps := setupExclusaoConta(t)
+
+	exclusao := dominio.NovaExclusaoConta(1, "", time.Now().Add(-dominio.PeriodoCarenciaExclusao), dominio.PeriodoCarenciaExclusao)
+	usuario := &dominio.Usuario{ID: 1, Nome: "Maria", Email: "maria@example.com", CPF: "12345678901", Contato: "11999999999", Senha: "hash"}
+	profissional := &dominio.Usuario{ID: 2, Email: "joao@example.com"}
+
+	deps.exclusaoRepo.On("ListarExclusoesVencidas", mock.Anything, mock.Anything).Return([]*dominio.ExclusaoConta{exclusao}, nil)
+	deps.usuarioRepo.On("BuscarUsuarioPorID", uint(1)).Return(usuario, nil)
+	deps.exclusaoRepo.On("ListarUsuariosVinculados", mock.Anything, uint(1)).Return([]*dominio.Usuario{profissional}, nil)
+	deps.notificacaoRepo.On("CriarNotificacao", mock.Anything, mock.Anything).Return(nil)
+	deps.exclusaoRepo.On("AnonimizarTitular", mock.Anything, usuario, "maria@example.com").Return([]string{}, nil)
+	deps.exclusaoRepo.On("AtualizarExclusao", mock.Anything, exclusao).Return(nil)
+	deps.tentativaRepo.On("RemoverTentativa", dominio.ChaveTentativa(dominio.TipoTentativaConta, "maria@example.com")).Return(nil)
+
+	err := servico.ExecutarExclusoesVencidas()
+
+	assert.NoError(t, err)
+	assert.Equal(t, dominio.StatusExclusaoConcluida, exclusao.Status)
+	assert.Equal(t, dominio.NomeUsuarioAnonimizado, usuario.Nome)
+	assert.Equal(t, dominio.EmailAnonimizado(1), usuario.Email)
+	assert.Empty(t, usuario.CPF)
+	assert.Empty(t, usuario.Contato)
+	assert.NotEqual(t, "hash", usuario.Senha)
+	assert.Equal(t, uint(1), usuario.VersaoSessao)
+
+	deps.exclusaoRepo.AssertExpectations(t)
+	deps.tentativaRepo.AssertExpectations(t)
+}
+
+// setupAnonimizacao grava a paciente Ana (paciente 1, usuario 10) vinculada ao profissional 5 (usuario 50)
+func setupAnonimizacao(t *testing.T) (*gorm.DB, *dominio.Usuario) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&dominio.Usuario{}, &dominio.Profissional{}, &dominio.Paciente{}, &dominio.Vinculo{},
+		&dominio.EncerramentoVinculo{}, &dominio.RegistroHumor{}, &dominio.RevisaoRegistroHumor{}, &dominio.MetricaPersonalizada{}, &dominio.ValorMetricaRegistro{}, &dominio.Atribuicao{}, &dominio.Convite{}, &dominio.Consentimento{},
+		&dominio.ExportacaoDados{}, &dominio.Notificacao{}, &dominio.RedefinicaoSenha{}, &dominio.VerificacaoEmail{},
+		&dominio.DesafioDoisFatores{}, &dominio.CodigoRecuperacao{}, &dominio.DoisFatores{}, &dominio.BloqueioLogin{},
+		&dominio.Responsavel{}, &dominio.VinculoResponsavel{}, &dominio.Consulta{}, &dominio.CalendarioProfissional{},
+		&dominio.RegistroAuditoria{}))
+
+	usuario := &dominio.Usuario{ID: 10, TipoUsuario: 3, Nome: "Ana", Email: "ana@teste.com", CPF: "11111111111", Senha: "x"}
+	assert.NoError(t, db.Create(usuario).Error)
+	assert.NoError(t, db.Create(&dominio.Usuario{ID: 50, TipoUsuario: 2, Nome: "Bruno", Email: "bruno@teste.com", CPF: "22222222222", Senha: "x"}).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Profissional{ID: 5, UsuarioID: 50, RegistroProfissional: "CRP1", Especialidade: "Psicologia"}).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Paciente{ID: 1, UsuarioID: 10, DataNascimento: time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC)}).Error)
+	assert.NoError(t, db.Create(&dominio.Vinculo{ProfissionalID: 5, PacienteID: 1}).Error)
+	return db, usuario
+}
+
+// anonimizarTeste executa a anonimizacao do titular como o job de exclusao faz
+func anonimizarTeste(t *testing.T, db *gorm.DB, usuario *dominio.Usuario) {
+	emailOriginal := usuario.Email
+	usuario.Anonimizar("inutilizavel")
+	_, err := sqlite_repo.NovoGormExclusaoContaRepositorio(db).AnonimizarTitular(db, usuario, emailOriginal)
+	assert.NoError(t, err)
+}
+
+func TestGormExclusaoContaRepositorio_AnonimizarTitular_Consentimentos(t *testing.T) {
+	db, usuario := setupAnonimizacao(t)
+	assert.NoError(t, db.Omit(clause.Associations).Create(dominio.NovoConsentimentoPadrao(1, 5, time.Now())).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(dominio.NovoConsentimentoPadrao(2, 5, time.Now())).Error)
+
+	anonimizarTeste(t, db, usuario)
+
+	// Os consentimentos da paciente sao apagados; os de outros pacientes permanecem
+	var restantes []dominio.Consentimento
+	assert.NoError(t, db.Find(&restantes).Error)
+	assert.Len(t, restantes, 1)
+	assert.Equal(t, uint(2), restantes[0].PacienteID)
+}
+
+func TestGormExclusaoContaRepositorio_AnonimizarTitular_Vinculos(t *testing.T) {
+	db, usuario := setupAnonimizacao(t)
+	assert.NoError(t, db.Create(&dominio.EncerramentoVinculo{ProfissionalID: 5, PacienteID: 1, EncerradoPor: dominio.EncerradoPeloProfissional,
+		Motivo: "Alta apos tratamento de ansiedade", EncerradoEm: time.Now()}).Error)
+
+	anonimizarTeste(t, db, usuario)
+
+	// O vinculo ativo e encerrado pela exclusao
+	var vinculo dominio.Vinculo
+	assert.NoError(t, db.Where("paciente_id = ? AND profissional_id = ?", 1, 5).First(&vinculo).Error)
+	assert.False(t, vinculo.Ativo())
+	assert.Equal(t, dominio.EncerradoPorExclusaoConta, vinculo.EncerradoPor)
+
+	// O encerramento anterior continua no historico, sem o motivo
+	var encerramento dominio.EncerramentoVinculo
+	assert.NoError(t, db.First(&encerramento).Error)
+	assert.Equal(t, dominio.EncerradoPeloProfissional, encerramento.EncerradoPor)
+	assert.Empty(t, encerramento.Motivo)
+}
+
+func TestGormExclusaoContaRepositorio_AnonimizarTitular_ConvitesPorEmail(t *testing.T) {
+	db, usuario := setupAnonimizacao(t)
+	expiracao := time.Now().AddDate(0, 0, 7)
+	// Convite de outro profissional ainda pendente para o e-mail da paciente, com caixa diferente
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Convite{ProfissionalID: 6, Token: "convite-pendente-ana",
+		DataExpiracao: expiracao, MaxUsos: 1, EmailDestinatario: "ANA@teste.com"}).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Convite{ProfissionalID: 6, Token: "convite-outro-paciente",
+		DataExpiracao: expiracao, MaxUsos: 1, EmailDestinatario: "carlos@teste.com"}).Error)
+
+	anonimizarTeste(t, db, usuario)
+
+	var convites []dominio.Convite
+	assert.NoError(t, db.Order("id").Find(&convites).Error)
+	assert.Len(t, convites, 2)
+	// O convite da paciente perde o e-mail e deixa de poder ser resgatado
+	assert.Empty(t, convites[0].EmailDestinatario)
+	assert.NotNil(t, convites[0].RevogadoEm)
+	assert.Equal(t, "carlos@teste.com", convites[1].EmailDestinatario)
+	assert.Nil(t, convites[1].RevogadoEm)
+}
+
+func TestGormExclusaoContaRepositorio_AnonimizarTitular_ConvitesDoProfissional(t *testing.T) {
+	db, _ := setupAnonimizacao(t)
+	var profissional dominio.Usuario
+	assert.NoError(t, db.First(&profissional, 50).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Convite{ProfissionalID: 5, Token: "convite-pendente-carlos",
+		DataExpiracao: time.Now().AddDate(0, 0, 7), MaxUsos: 1, EmailDestinatario: "carlos@teste.com"}).Error)
+
+	anonimizarTeste(t, db, &profissional)
+
+	// O convite nao utilizado e apagado, mas o e-mail nao fica na linha apagada
+	var convite dominio.Convite
+	assert.NoError(t, db.Unscoped().First(&convite).Error)
+	assert.True(t, convite.DeletedAt.Valid)
+	assert.Empty(t, convite.EmailDestinatario)
+}
+
+func TestGormExclusaoContaRepositorio_AnonimizarTitular_Consultas(t *testing.T) {
+	db, usuario := setupAnonimizacao(t)
+	passada := time.Now().AddDate(0, 0, -7)
+	futura := time.Now().AddDate(0, 0, 7)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Consulta{ProfissionalID: 5, PacienteID: 1, Inicio: passada,
+		Fim: passada.Add(time.Hour), Modalidade: dominio.ModalidadePresencial, Status: dominio.StatusConsultaRealizada}).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Consulta{ProfissionalID: 5, PacienteID: 1, Inicio: futura,
+		Fim: futura.Add(time.Hour), Modalidade: dominio.ModalidadePresencial, Status: dominio.StatusConsultaAgendada}).Error)
+
+	anonimizarTeste(t, db, usuario)
+
+	// A consulta realizada continua no historico do profissional; a futura e cancelada
+	var consultas []dominio.Consulta
+	assert.NoError(t, db.Order("inicio").Find(&consultas).Error)
+	assert.Len(t, consultas, 2)
+	assert.Equal(t, dominio.StatusConsultaRealizada, consultas[0].Status)
+	assert.Equal(t, dominio.StatusConsultaCancelada, consultas[1].Status)
+}
+
+func TestGormExclusaoContaRepositorio_AnonimizarTitular_AgendaDoProfissional(t *testing.T) {
+	db, _ := setupAnonimizacao(t)
+	var profissional dominio.Usuario
+	assert.NoError(t, db.First(&profissional, 50).Error)
+	inicio := time.Now().AddDate(0, 0, 7)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Consulta{ProfissionalID: 5, PacienteID: 1, Inicio: inicio,
+		Fim: inicio.Add(time.Hour), Modalidade: dominio.ModalidadeOnline, Status: dominio.StatusConsultaAgendada}).Error)
+	assert.NoError(t, db.Create(&dominio.CalendarioProfissional{ProfissionalID: 5, TokenHash: "hash-do-link", CriadoEm: time.Now()}).Error)
+
+	anonimizarTeste(t, db, &profissional)
+
+	var consulta dominio.Consulta
+	assert.NoError(t, db.First(&consulta).Error)
+	assert.Equal(t, dominio.StatusConsultaCancelada, consulta.Status)
+	var calendarios int64
+	assert.NoError(t, db.Model(&dominio.CalendarioProfissional{}).Count(&calendarios).Error)
+	assert.Zero(t, calendarios)
+}
+
+func TestGormExclusaoContaRepositorio_AnonimizarTitular_MantemTrilhaDeAuditoria(t *testing.T) {
+	db, usuario := setupAnonimizacao(t)
+	pacienteID, profissionalID := uint(1), uint(50)
+	registro := &dominio.RegistroAuditoria{AtorID: &profissionalID, TipoAtor: "profissional", Acao: dominio.AcaoAuditoriaVerHistorico, TipoRecurso: dominio.RecursoAuditoriaRegistroHumor,
+		PacienteID: &pacienteID, Resultado: dominio.ResultadoAuditoriaSucesso, CriadoEm: time.Now(), HashAnterior: strings.Repeat("0", 64), Hash: strings.Repeat("a", 64)}
+	assert.NoError(t, db.Omit("Ator").Create(registro).Error)
+
+	anonimizarTeste(t, db, usuario)
+
+	// A linha segue intacta, com o mesmo hash, para a cadeia continuar verificavel
+	var registros []dominio.RegistroAuditoria
+	assert.NoError(t, db.Find(&registros).Error)
+	assert.Len(t, registros, 1)
+	assert.Equal(t, registro.Hash, registros[0].Hash)
+	assert.Equal(t, pacienteID, *registros[0].PacienteID)
+}
+
+func TestGormExclusaoContaRepositorio_AnonimizarTitular_MetricasPersonalizadas(t *testing.T) {
+	db, usuario := setupAnonimizacao(t)
+	registro := &dominio.RegistroHumor{PacienteID: 1, NivelHumor: 3, HorasSono: 7, NivelEnergia: 5, NivelStress: 4, AutoCuidado: "[]", DataHoraRegistro: time.Now().AddDate(0, 0, -1)}
+	assert.NoError(t, db.Create(registro).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.MetricaPersonalizada{ID: 3, PacienteID: 1, ProfissionalID: 5, Rotulo: "Ansiedade",
+		Tipo: dominio.TipoMetricaEscala, Minimo: 0, Maximo: 10, Ativa: true}).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.ValorMetricaRegistro{RegistroHumorID: registro.ID, MetricaID: 3, Valor: 6}).Error)
+
+	anonimizarTeste(t, db, usuario)
+
+	// A metrica sai do formulario; o valor segue ligado ao registro anonimizado
+	var metrica dominio.MetricaPersonalizada
+	assert.NoError(t, db.First(&metrica, 3).Error)
+	assert.False(t, metrica.Ativa)
+	var valor dominio.ValorMetricaRegistro
+	assert.NoError(t, db.Where("registro_humor_id = ?", registro.ID).First(&valor).Error)
+	assert.Equal(t, 6.0, valor.Valor)
+}
@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// setupExportacao grava o paciente 1 (usuario 10) vinculado ao profissional 5 (usuario 50)
+func setupExportacao(t *testing.T) (servicos.ExportacaoDadosServico, *gorm.DB) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&dominio.Usuario{}, &dominio.Profissional{}, &dominio.Paciente{}, &dominio.Vinculo{},
+		&dominio.EncerramentoVinculo{}, &dominio.RegistroHumor{}, &dominio.RevisaoRegistroHumor{}, &dominio.MetricaPersonalizada{}, &dominio.ValorMetricaRegistro{}, &dominio.Instrumento{}, &dominio.Atribuicao{}, &dominio.Resposta{},
+		&dominio.Convite{}, &dominio.Consentimento{}, &dominio.Consulta{}, &dominio.Notificacao{},
+		&dominio.RegistroAuditoria{}))
+
+	assert.NoError(t, db.Create(&dominio.Usuario{ID: 10, TipoUsuario: 3, Nome: "Ana", Email: "ana@teste.com", CPF: "11111111111", Senha: "x"}).Error)
+	assert.NoError(t, db.Create(&dominio.Usuario{ID: 50, TipoUsuario: 2, Nome: "Dr. Bruno", Email: "bruno@teste.com", CPF: "22222222222", Senha: "x"}).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Profissional{ID: 5, UsuarioID: 50, RegistroProfissional: "CRP1", Especialidade: "Psicologia"}).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Paciente{ID: 1, UsuarioID: 10, DataNascimento: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)}).Error)
+	assert.NoError(t, db.Create(&dominio.Vinculo{ProfissionalID: 5, PacienteID: 1}).Error)
+
+	svc := servicos.NovoExportacaoDadosServico(db, sqlite_repo.NovoGormExportacaoDadosRepositorio(db), new(MockUsuarioRepositorio), nil, t.TempDir())
+	return svc, db
+}
+
+// gerarPacoteTeste gera o pacote json do usuario e retorna o documento decodificado
+func gerarPacoteTeste(t *testing.T, svc servicos.ExportacaoDadosServico, userID uint) dtos.PacoteExportacaoDTOOut {
+	var saida bytes.Buffer
+	assert.NoError(t, svc.GerarPacote(userID, dominio.FormatoExportacaoJSON, &saida))
+	var pacote dtos.PacoteExportacaoDTOOut
+	assert.NoError(t, json.Unmarshal(saida.Bytes(), &pacote))
+	return pacote
+}
+
+// registrosDaSecao retorna a quantidade de registros declarada no manifesto para a secao
+func registrosDaSecao(pacote dtos.PacoteExportacaoDTOOut, nome string) int {
+	for _, secao := range pacote.Manifesto.Secoes {
+		if secao.Nome == nome {
+			return secao.Registros
+		}
+	}
+	return -1
+}
+
+func TestExportacaoDadosServico_GerarPacote_ConsentimentosDoPaciente(t *testing.T) {
+	svc, db := setupExportacao(t)
+	agora := time.Now()
+	assert.NoError(t, db.Omit(clause.Associations).Create(dominio.NovoConsentimentoPadrao(1, 5, agora.AddDate(0, 0, -30))).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Consentimento{PacienteID: 1, ProfissionalID: 5, Versao: 2, CompartilharHumor: true, DataInicio: agora.AddDate(0, 0, -30)}).Error)
+
+	pacote := gerarPacoteTeste(t, svc, 10)
+	assert.Equal(t, 2, registrosDaSecao(pacote, "consentimentos"))
+
+	// Todas as versoes entram, com o profissional identificado
+	var consentimentos []dtos.ConsentimentoDTOOut
+	assert.NoError(t, json.Unmarshal(pacote.Dados["consentimentos"], &consentimentos))
+	assert.Equal(t, uint(1), consentimentos[0].Versao)
+	assert.True(t, consentimentos[1].Vigente)
+	assert.Equal(t, []string{dominio.CategoriaHumor}, consentimentos[1].Categorias)
+	assert.Equal(t, "Dr. Bruno", consentimentos[1].Profissional.Nome)
+}
+
+func TestExportacaoDadosServico_GerarPacote_ConsentimentosDoProfissional(t *testing.T) {
+	svc, db := setupExportacao(t)
+	assert.NoError(t, db.Omit(clause.Associations).Create(dominio.NovoConsentimentoPadrao(1, 5, time.Now())).Error)
+
+	pacote := gerarPacoteTeste(t, svc, 50)
+
+	// O profissional recebe os consentimentos que recebeu, com o paciente apenas pelo ID
+	var consentimentos []dtos.ConsentimentoDTOOut
+	assert.NoError(t, json.Unmarshal(pacote.Dados["consentimentos"], &consentimentos))
+	assert.Len(t, consentimentos, 1)
+	assert.Equal(t, uint(1), consentimentos[0].PacienteID)
+	assert.Nil(t, consentimentos[0].Profissional)
+}
+
+func TestExportacaoDadosServico_GerarPacote_EncerramentosVinculo(t *testing.T) {
+	svc, db := setupExportacao(t)
+	encerradoEm := time.Now()
+	assert.NoError(t, db.Model(&dominio.Vinculo{}).Where("paciente_id = ? AND profissional_id = ?", 1, 5).
+		Updates(map[string]any{"encerrado_em": encerradoEm, "encerrado_por": dominio.EncerradoPeloPaciente}).Error)
+	assert.NoError(t, db.Create(&dominio.EncerramentoVinculo{ProfissionalID: 5, PacienteID: 1, EncerradoPor: dominio.EncerradoPeloPaciente,
+		Motivo: "Mudei de cidade", EncerradoEm: encerradoEm, AtribuicoesCanceladas: 2}).Error)
+
+	// Paciente e profissional recebem o mesmo registro de encerramento
+	for _, userID := range []uint{10, 50} {
+		pacote := gerarPacoteTeste(t, svc, userID)
+		assert.Equal(t, 1, registrosDaSecao(pacote, "encerramentos_vinculo"))
+
+		var encerramentos []dtos.EncerramentoVinculoDTOOut
+		assert.NoError(t, json.Unmarshal(pacote.Dados["encerramentos_vinculo"], &encerramentos))
+		assert.Equal(t, "Mudei de cidade", encerramentos[0].Motivo)
+		assert.Equal(t, dominio.EncerradoPeloPaciente, encerramentos[0].EncerradoPor)
+		assert.Equal(t, int64(2), encerramentos[0].AtribuicoesCanceladas)
+	}
+}
+
+func TestExportacaoDadosServico_GerarPacote_Consultas(t *testing.T) {
+	svc, db := setupExportacao(t)
+	inicio := time.Now().AddDate(0, 0, -7)
+	for _, status := range []string{dominio.StatusConsultaRealizada, dominio.StatusConsultaAgendada} {
+		assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.Consulta{ProfissionalID: 5, PacienteID: 1, Inicio: inicio,
+			Fim: inicio.Add(time.Hour), Modalidade: dominio.ModalidadeOnline, Status: status}).Error)
+		inicio = inicio.AddDate(0, 0, 14)
+	}
+
+	pacote := gerarPacoteTeste(t, svc, 10)
+	assert.Equal(t, 2, registrosDaSecao(pacote, "consultas"))
+	var consultas []dtos.ConsultaDTOOut
+	assert.NoError(t, json.Unmarshal(pacote.Dados["consultas"], &consultas))
+	assert.Equal(t, dominio.StatusConsultaRealizada, consultas[0].Status)
+	assert.Equal(t, "Dr. Bruno", consultas[0].NomeProfissional)
+
+	// Na exportacao do profissional, o paciente aparece apenas pelo ID
+	pacote = gerarPacoteTeste(t, svc, 50)
+	assert.NoError(t, json.Unmarshal(pacote.Dados["consultas"], &consultas))
+	assert.Len(t, consultas, 2)
+	assert.Equal(t, uint(1), consultas[0].PacienteID)
+	assert.Empty(t, consultas[0].NomePaciente)
+}
+
+func TestExportacaoDadosServico_GerarPacote_AcessosDados(t *testing.T) {
+	svc, db := setupExportacao(t)
+	pacienteID, profissionalID, titularID := uint(1), uint(50), uint(10)
+	for i, atorID := range []uint{profissionalID, titularID} {
+		assert.NoError(t, db.Omit("Ator").Create(&dominio.RegistroAuditoria{AtorID: &atorID, TipoAtor: "profissional", Acao: dominio.AcaoAuditoriaVerHistorico,
+			TipoRecurso: dominio.RecursoAuditoriaRegistroHumor, PacienteID: &pacienteID, IP: "10.0.0.1", Resultado: dominio.ResultadoAuditoriaSucesso, CriadoEm: time.Now(),
+			HashAnterior: strings.Repeat("0", 64), Hash: strings.Repeat(strconv.Itoa(i+1), 64)}).Error)
+	}
+
+	pacote := gerarPacoteTeste(t, svc, 10)
+
+	// Os acessos do proprio titular ficam de fora, e o IP nao e exportado
+	assert.Equal(t, 1, registrosDaSecao(pacote, "acessos_dados"))
+	var acessos []dtos.AcessoDadosDTOOut
+	assert.NoError(t, json.Unmarshal(pacote.Dados["acessos_dados"], &acessos))
+	assert.Equal(t, "Dr. Bruno", acessos[0].NomeAtor)
+	assert.NotContains(t, string(pacote.Dados["acessos_dados"]), "10.0.0.1")
+
+	// A trilha pertence ao paciente; o profissional nao a recebe
+	pacote = gerarPacoteTeste(t, svc, 50)
+	assert.Equal(t, 0, registrosDaSecao(pacote, "acessos_dados"))
+}
+
+func TestExportacaoDadosServico_GerarPacote_ValoresMetricas(t *testing.T) {
+	svc, db := setupExportacao(t)
+	registro := &dominio.RegistroHumor{PacienteID: 1, NivelHumor: 3, HorasSono: 7, NivelEnergia: 5, NivelStress: 4, AutoCuidado: "[]", DataHoraRegistro: time.Now().AddDate(0, 0, -1)}
+	assert.NoError(t, db.Create(registro).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.MetricaPersonalizada{ID: 3, PacienteID: 1, ProfissionalID: 5, Rotulo: "Ansiedade",
+		Tipo: dominio.TipoMetricaEscala, Minimo: 0, Maximo: 10, Ativa: true}).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(&dominio.ValorMetricaRegistro{RegistroHumorID: registro.ID, MetricaID: 3, Valor: 6}).Error)
+
+	pacote := gerarPacoteTeste(t, svc, 10)
+	assert.Equal(t, 1, registrosDaSecao(pacote, "valores_metricas"))
+	var valores []dtos.ValorMetricaExportacaoDTOOut
+	assert.NoError(t, json.Unmarshal(pacote.Dados["valores_metricas"], &valores))
+	assert.Equal(t, dtos.ValorMetricaExportacaoDTOOut{RegistroHumorID: registro.ID, MetricaID: 3, Rotulo: "Ansiedade", Valor: 6}, valores[0])
+
+	// Os valores sao dados do diario do paciente e nao entram no pacote do profissional
+	pacote = gerarPacoteTeste(t, svc, 50)
+	assert.Equal(t, 0, registrosDaSecao(pacote, "valores_metricas"))
+}
@@ -0,0 +1,264 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// ========== Mocks ==========
+
+// MockMetricaPersonalizadaRepositorio simula o repositorio de metricas personalizadas
+type MockMetricaPersonalizadaRepositorio struct {
+	mock.Mock
+}
+
+func (m *MockMetricaPersonalizadaRepositorio) CriarMetrica(tx *gorm.DB, metrica *dominio.MetricaPersonalizada) error {
+	args := m.Called(tx, metrica)
+	return args.Error(0)
+}
+
+func (m *MockMetricaPersonalizadaRepositorio) AtualizarMetrica(tx *gorm.DB, metrica *dominio.MetricaPersonalizada) error {
+	args := m.Called(tx, metrica)
+	return args.Error(0)
+}
+
+func (m *MockMetricaPersonalizadaRepositorio) BuscarMetricaPorID(tx *gorm.DB, metricaID uint) (*dominio.MetricaPersonalizada, error) {
+	args := m.Called(tx, metricaID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dominio.MetricaPersonalizada), args.Error(1)
+}
+
+func (m *MockMetricaPersonalizadaRepositorio) ListarMetricasAtivasDoPaciente(tx *gorm.DB, pacienteID uint) ([]*dominio.MetricaPersonalizada, error) {
+	args := m.Called(tx, pacienteID)
+	return args.Get(0).([]*dominio.MetricaPersonalizada), args.Error(1)
+}
+
+func (m *MockMetricaPersonalizadaRepositorio) SubstituirValoresDoRegistro(tx *gorm.DB, registroID uint, valores []*dominio.ValorMetricaRegistro) error {
+	args := m.Called(tx, registroID, valores)
+	return args.Error(0)
+}
+
+func (m *MockMetricaPersonalizadaRepositorio) ListarValoresDosRegistros(tx *gorm.DB, registroIDs []uint) ([]*dominio.ValorMetricaRegistro, error) {
+	args := m.Called(tx, registroIDs)
+	return args.Get(0).([]*dominio.ValorMetricaRegistro), args.Error(1)
+}
+
+// ========== Testes do Serviço ==========
+
+type servicosMetricas struct {
+	metricas servicos.MetricaPersonalizadaServico
+	registro servicos.RegistroHumorServico
+	analise  servicos.AnaliseServico
+}
+
+// setupMetricas prepara o paciente 1 (usuario 10) com vinculo ativo com o profissional 5 (usuario 50)
+// e sem vinculo com o profissional 6 (usuario 60)
+func setupMetricas(t *testing.T, consentimento *dominio.Consentimento) (*servicosMetricas, *gorm.DB) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&dominio.RegistroHumor{}, &dominio.AtividadeAutoCuidado{}, &dominio.RegistroHumorAtividade{},
+		&dominio.MetricaPersonalizada{}, &dominio.ValorMetricaRegistro{}))
+	assert.NoError(t, db.AutoMigrate(&dominio.Vinculo{}))
+	assert.NoError(t, db.Create(&dominio.AtividadeAutoCuidado{Nome: "Caminhada", NomeNormalizado: "caminhada", Ativa: true}).Error)
+
+	usuarioRepo := new(MockUsuarioRepositorio)
+	usuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{ID: 1, UsuarioID: 10}, nil)
+	usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(50)).Return(&dominio.Profissional{ID: 5, UsuarioID: 50}, nil)
+	usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(60)).Return(&dominio.Profissional{ID: 6, UsuarioID: 60}, nil)
+
+	vinculoRepo := new(MockVinculoRepositorio)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(1), uint(5)).Return(&dominio.Vinculo{PacienteID: 1, ProfissionalID: 5}, nil)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(1), uint(6)).Return(nil, gorm.ErrRecordNotFound)
+
+	consentimentoRepo := new(MockConsentimentoRepositorio)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(1), uint(5)).Return(consentimento, nil)
+
+	registroRepo := sqlite_repo.NovoGormRegistroHumorRepositorio(db)
+	metricaRepo := sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db)
+	analiseSvc := servicos.NovoAnaliseServico(db, registroRepo, usuarioRepo, consentimentoRepo, vinculoRepo, new(MockNotificacaoRepositorio), new(MockResponsavelRepositorio), metricaRepo)
+	return &servicosMetricas{
+		metricas: servicos.NovoMetricaPersonalizadaServico(db, usuarioRepo, vinculoRepo, metricaRepo),
+		registro: servicos.NovoRegistroHumorServico(db, registroRepo, usuarioRepo, consentimentoRepo, vinculoRepo,
+			sqlite_repo.NovoGormAutoCuidadoRepositorio(db), metricaRepo, &monitoramentoEspiao{pacientes: make(chan uint, 8)}, 0, 0),
+		analise: analiseSvc,
+	}, db
+}
+
+func novaMetrica(rotulo, tipo string, minimo, maximo float64) *dtos.CriarMetricaPersonalizadaDTOIn {
+	return &dtos.CriarMetricaPersonalizadaDTOIn{Rotulo: rotulo, Tipo: tipo, Minimo: &minimo, Maximo: &maximo}
+}
+
+// registroComMetricas monta um registro de uma hora atras com os valores das metricas
+func registroComMetricas(nivelHumor int16, valores ...dtos.ValorMetricaDTOIn) *dtos.CriarRegistroHumorDTOIn {
+	dto := correcaoHumor(nivelHumor)
+	dto.DataHoraRegistro = time.Now().Add(-time.Hour)
+	dto.Metricas = valores
+	return dto
+}
+
+func valorMetrica(metricaID uint, valor float64) dtos.ValorMetricaDTOIn {
+	return dtos.ValorMetricaDTOIn{MetricaID: metricaID, Valor: &valor}
+}
+
+func TestMetricaPersonalizadaServico_CriarMetrica(t *testing.T) {
+	svc, _ := setupMetricas(t, nil)
+
+	ansiedade, err := svc.metricas.CriarMetrica(50, 1, novaMetrica("Ansiedade", "escala", 0, 10))
+	assert.NoError(t, err)
+	assert.Equal(t, dominio.TipoMetricaEscala, ansiedade.Tipo)
+	assert.Equal(t, uint(5), ansiedade.ProfissionalID)
+
+	// Em SIM_NAO a faixa e sempre 0 a 1
+	medicacao, err := svc.metricas.CriarMetrica(50, 1, &dtos.CriarMetricaPersonalizadaDTOIn{Rotulo: "Medicação tomada", Tipo: "SIM_NAO"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, medicacao.Minimo)
+	assert.Equal(t, 1.0, medicacao.Maximo)
+
+	_, err = svc.metricas.CriarMetrica(50, 1, novaMetrica(" ANSIEDADE ", "NUMERO", 0, 5))
+	assert.Equal(t, dominio.ErrMetricaPersonalizadaDuplicada, err)
+	_, err = svc.metricas.CriarMetrica(50, 1, novaMetrica("Dor", "ESCALA", 5, 2))
+	assert.Equal(t, dominio.ErrFaixaMetricaPersonalizadaInvalida, err)
+	_, err = svc.metricas.CriarMetrica(50, 1, novaMetrica("Dor", "TEXTO", 0, 10))
+	assert.Equal(t, dominio.ErrTipoMetricaInvalido, err)
+	_, err = svc.metricas.CriarMetrica(60, 1, novaMetrica("Fissura", "ESCALA", 0, 10))
+	assert.Equal(t, dominio.ErrVinculoNaoEncontrado, err)
+
+	doPaciente, err := svc.metricas.ListarMetricas(10, "paciente", 0)
+	assert.NoError(t, err)
+	assert.Len(t, doPaciente, 2)
+	_, err = svc.metricas.ListarMetricas(60, "profissional", 1)
+	assert.Equal(t, dominio.ErrVinculoNaoEncontrado, err)
+}
+
+func TestMetricaPersonalizadaServico_CriarMetrica_Limite(t *testing.T) {
+	svc, _ := setupMetricas(t, nil)
+	for i := 0; i < dominio.LimiteMetricasPersonalizadas; i++ {
+		_, err := svc.metricas.CriarMetrica(50, 1, novaMetrica("Metrica "+string(rune('A'+i)), "ESCALA", 0, 10))
+		assert.NoError(t, err)
+	}
+
+	_, err := svc.metricas.CriarMetrica(50, 1, novaMetrica("Excedente", "ESCALA", 0, 10))
+	assert.Equal(t, dominio.ErrLimiteMetricasPersonalizadas, err)
+}
+
+func TestMetricaPersonalizadaServico_DesativarMetrica(t *testing.T) {
+	svc, _ := setupMetricas(t, nil)
+	ansiedade, err := svc.metricas.CriarMetrica(50, 1, novaMetrica("Ansiedade", "ESCALA", 0, 10))
+	assert.NoError(t, err)
+
+	assert.Equal(t, dominio.ErrMetricaPersonalizadaNaoEncontrada, svc.metricas.DesativarMetrica(60, ansiedade.ID))
+	assert.NoError(t, svc.metricas.DesativarMetrica(50, ansiedade.ID))
+	assert.Equal(t, dominio.ErrMetricaPersonalizadaNaoEncontrada, svc.metricas.DesativarMetrica(50, ansiedade.ID))
+
+	ativas, err := svc.metricas.ListarMetricas(50, "profissional", 1)
+	assert.NoError(t, err)
+	assert.Empty(t, ativas)
+
+	// O rotulo fica livre para uma nova metrica, com outra faixa
+	recriada, err := svc.metricas.CriarMetrica(50, 1, novaMetrica("Ansiedade", "ESCALA", 1, 5))
+	assert.NoError(t, err)
+	assert.NotEqual(t, ansiedade.ID, recriada.ID)
+}
+
+func TestRegistroHumorServico_CriarRegistroHumor_ValidaMetricasPersonalizadas(t *testing.T) {
+	svc, db := setupMetricas(t, nil)
+	ansiedade, _ := svc.metricas.CriarMetrica(50, 1, novaMetrica("Ansiedade", "ESCALA", 0, 10))
+	dor, _ := svc.metricas.CriarMetrica(50, 1, novaMetrica("Dor", "NUMERO", 0, 10))
+
+	invalidos := []struct {
+		name     string
+		metricas []dtos.ValorMetricaDTOIn
+		wantErr  error
+	}{
+		{name: "Fora da faixa", metricas: []dtos.ValorMetricaDTOIn{valorMetrica(ansiedade.ID, 11)}, wantErr: dominio.ErrValorMetricaInvalido},
+		{name: "Decimal na escala", metricas: []dtos.ValorMetricaDTOIn{valorMetrica(ansiedade.ID, 2.5)}, wantErr: dominio.ErrValorMetricaInvalido},
+		{name: "Sem valor", metricas: []dtos.ValorMetricaDTOIn{{MetricaID: ansiedade.ID}}, wantErr: dominio.ErrValorMetricaInvalido},
+		{name: "Metrica desconhecida", metricas: []dtos.ValorMetricaDTOIn{valorMetrica(999, 1)}, wantErr: dominio.ErrMetricaPersonalizadaNaoEncontrada},
+		{name: "Metrica repetida", metricas: []dtos.ValorMetricaDTOIn{valorMetrica(dor.ID, 1), valorMetrica(dor.ID, 2)}, wantErr: dominio.ErrValorMetricaDuplicado},
+	}
+	for _, tt := range invalidos {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.registro.CriarRegistroHumor(registroComMetricas(3, tt.metricas...), 10)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+
+	registro, err := svc.registro.CriarRegistroHumor(registroComMetricas(3, valorMetrica(dor.ID, 2.5), valorMetrica(ansiedade.ID, 7)), 10)
+	assert.NoError(t, err)
+	assert.Len(t, registro.Metricas, 2)
+
+	var total int64
+	db.Model(&dominio.ValorMetricaRegistro{}).Where("registro_humor_id = ?", registro.ID).Count(&total)
+	assert.Equal(t, int64(2), total)
+
+	pagina, err := svc.registro.ListarRegistrosHumor(10, "paciente", 0, &dtos.FiltroRegistrosHumorDTOIn{})
+	assert.NoError(t, err)
+	assert.Equal(t, []dtos.ValorMetricaDTOOut{
+		{MetricaID: ansiedade.ID, Rotulo: "Ansiedade", Valor: 7},
+		{MetricaID: dor.ID, Rotulo: "Dor", Valor: 2.5},
+	}, pagina.Registros[0].Metricas)
+}
+
+func TestRegistroHumorServico_ListarRegistrosHumor_MetricasAcompanhamHumor(t *testing.T) {
+	svc, _ := setupMetricas(t, consentimentoDiario(false, true, false, time.Now().AddDate(0, 0, -30)))
+	ansiedade, _ := svc.metricas.CriarMetrica(50, 1, novaMetrica("Ansiedade", "ESCALA", 0, 10))
+
+	_, err := svc.registro.CriarRegistroHumor(registroComMetricas(3, valorMetrica(ansiedade.ID, 7)), 10)
+	assert.NoError(t, err)
+
+	pagina, err := svc.registro.ListarRegistrosHumor(50, "profissional", 1, &dtos.FiltroRegistrosHumorDTOIn{})
+	assert.NoError(t, err)
+	assert.Len(t, pagina.Registros, 1)
+	assert.Nil(t, pagina.Registros[0].Metricas)
+}
+
+func TestAnaliseServico_GerarAnaliseHistorica_GraficosMetricasPersonalizadas(t *testing.T) {
+	svc, _ := setupMetricas(t, consentimentoDiario(false, true, false, time.Now().AddDate(0, 0, -30)))
+	ansiedade, _ := svc.metricas.CriarMetrica(50, 1, novaMetrica("Ansiedade", "ESCALA", 0, 10))
+	medicacao, _ := svc.metricas.CriarMetrica(50, 1, &dtos.CriarMetricaPersonalizadaDTOIn{Rotulo: "Medicação tomada", Tipo: "SIM_NAO"})
+
+	agora := time.Now()
+	for i, valores := range [][]dtos.ValorMetricaDTOIn{
+		{valorMetrica(ansiedade.ID, 6), valorMetrica(medicacao.ID, 1)},
+		{valorMetrica(ansiedade.ID, 8), valorMetrica(medicacao.ID, 0)},
+		{valorMetrica(medicacao.ID, 1)},
+		{},
+	} {
+		dto := registroComMetricas(int16(i+1), valores...)
+		dto.DataHoraRegistro = agora.Add(time.Duration(i-4) * time.Hour)
+		_, err := svc.registro.CriarRegistroHumor(dto, 10)
+		assert.NoError(t, err)
+	}
+	// A metrica desativada continua no grafico do periodo
+	assert.NoError(t, svc.metricas.DesativarMetrica(50, medicacao.ID))
+
+	analise, err := svc.analise.GerarAnaliseHistorica(10, 0, "paciente", 30)
+	assert.NoError(t, err)
+	assert.Len(t, analise.GraficosMetricas, 2)
+
+	graficoAnsiedade := analise.GraficosMetricas[0]
+	assert.Equal(t, "Ansiedade", graficoAnsiedade.Rotulo)
+	assert.Len(t, graficoAnsiedade.Pontos, 2)
+	assert.InDelta(t, 7.0, graficoAnsiedade.Media, 0.001)
+	assert.Equal(t, int16(1), graficoAnsiedade.Pontos[0].Humor)
+
+	graficoMedicacao := analise.GraficosMetricas[1]
+	assert.Equal(t, dominio.TipoMetricaSimNao, graficoMedicacao.Tipo)
+	assert.Len(t, graficoMedicacao.Pontos, 3)
+	assert.InDelta(t, 2.0/3.0, graficoMedicacao.Media, 0.001)
+
+	// Sem a categoria humor, o profissional nao recebe as metricas personalizadas
+	doProfissional, err := svc.analise.GerarAnaliseHistorica(50, 1, "profissional", 30)
+	assert.NoError(t, err)
+	assert.Empty(t, doProfissional.GraficosMetricas)
+	assert.NotEmpty(t, doProfissional.GraficoSono)
+}
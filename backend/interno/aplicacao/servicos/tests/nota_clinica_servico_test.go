@@ -0,0 +1,248 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/cifragem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// ========== Mocks ==========
+
+// MockNotaClinicaRepositorio simula o repositorio de notas clinicas
+type MockNotaClinicaRepositorio struct {
+	mock.Mock
+}
+
+func (m *MockNotaClinicaRepositorio) CriarNota(tx *gorm.DB, nota *dominio.NotaClinica) error {
+	args := m.Called(tx, nota)
+	return args.Error(0)
+}
+
+func (m *MockNotaClinicaRepositorio) AtualizarRevisaoAtual(tx *gorm.DB, nota *dominio.NotaClinica) error {
+	args := m.Called(tx, nota)
+	return args.Error(0)
+}
+
+func (m *MockNotaClinicaRepositorio) CriarRevisao(tx *gorm.DB, revisao *dominio.RevisaoNotaClinica) error {
+	args := m.Called(tx, revisao)
+	return args.Error(0)
+}
+
+func (m *MockNotaClinicaRepositorio) BuscarNotaPorID(tx *gorm.DB, notaID uint) (*dominio.NotaClinica, error) {
+	args := m.Called(tx, notaID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dominio.NotaClinica), args.Error(1)
+}
+
+func (m *MockNotaClinicaRepositorio) ListarRevisoes(tx *gorm.DB, notaID uint) ([]*dominio.RevisaoNotaClinica, error) {
+	args := m.Called(tx, notaID)
+	return args.Get(0).([]*dominio.RevisaoNotaClinica), args.Error(1)
+}
+
+func (m *MockNotaClinicaRepositorio) ListarNotasVisiveis(tx *gorm.DB, profissionalID, pacienteID uint) ([]*dominio.NotaClinica, error) {
+	args := m.Called(tx, profissionalID, pacienteID)
+	return args.Get(0).([]*dominio.NotaClinica), args.Error(1)
+}
+
+func (m *MockNotaClinicaRepositorio) SalvarCompartilhamento(tx *gorm.DB, compartilhamento *dominio.CompartilhamentoNota) error {
+	args := m.Called(tx, compartilhamento)
+	return args.Error(0)
+}
+
+// ========== Helpers ==========
+
+func setupNotaClinica(t *testing.T) (servicos.NotaClinicaServico, *MockUsuarioRepositorio, *MockVinculoRepositorio, *MockNotaClinicaRepositorio, servicos.Cifrador) {
+	provedor, err := cifragem.NovoProvedorChaveUnica("teste", bytes.Repeat([]byte{7}, 32))
+	assert.NoError(t, err)
+	cifrador := cifragem.NovoEnvelope(provedor)
+
+	usuarioRepo := new(MockUsuarioRepositorio)
+	vinculoRepo := new(MockVinculoRepositorio)
+	notaRepo := new(MockNotaClinicaRepositorio)
+	servico := servicos.NovoNotaClinicaServico(setupTestDB(t), usuarioRepo, vinculoRepo, notaRepo, cifrador)
+
+	// Profissional 7 (usuario 20) e autor; profissional 8 (usuario 21) tambem acompanha o paciente 3
+	usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(20)).Return(&dominio.Profissional{ID: 7}, nil)
+	usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(21)).Return(&dominio.Profissional{ID: 8}, nil)
+	return servico, usuarioRepo, vinculoRepo, notaRepo, cifrador
+}
+
+// notaCifrada monta uma nota com a revisao atual cifrada como o servico grava
+func notaCifrada(t *testing.T, cifrador servicos.Cifrador, id, autorID uint, texto string, tags []string, dataSessao time.Time, tipo string) *dominio.NotaClinica {
+	conteudo, err := json.Marshal(dominio.ConteudoNota{Texto: texto, Tags: tags})
+	assert.NoError(t, err)
+	cifrado, err := cifrador.Cifrar(conteudo, []byte(fmt.Sprintf("nota_clinica:%d:1", id)))
+	assert.NoError(t, err)
+	return &dominio.NotaClinica{
+		ID: id, ProfissionalID: autorID, PacienteID: 3, RevisaoAtual: 1,
+		Atual: &dominio.RevisaoNotaClinica{NotaID: id, Revisao: 1, DataSessao: dataSessao, TipoSessao: tipo, ConteudoCifrado: cifrado},
+	}
+}
+
+// ========== Testes do Serviço ==========
+
+func TestNotaClinicaServico_CriarNota_GravaConteudoCifrado(t *testing.T) {
+	servico, _, vinculoRepo, notaRepo, cifrador := setupNotaClinica(t)
+
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(3), uint(7)).Return(&dominio.Vinculo{PacienteID: 3, ProfissionalID: 7}, nil)
+	notaRepo.On("CriarNota", mock.Anything, mock.AnythingOfType("*dominio.NotaClinica")).
+		Run(func(args mock.Arguments) { args.Get(1).(*dominio.NotaClinica).ID = 15 }).Return(nil)
+
+	var gravada *dominio.RevisaoNotaClinica
+	notaRepo.On("CriarRevisao", mock.Anything, mock.AnythingOfType("*dominio.RevisaoNotaClinica")).
+		Run(func(args mock.Arguments) { gravada = args.Get(1).(*dominio.RevisaoNotaClinica) }).Return(nil)
+	notaRepo.On("AtualizarRevisaoAtual", mock.Anything, mock.MatchedBy(func(n *dominio.NotaClinica) bool {
+		return n.ID == 15 && n.RevisaoAtual == 1
+	})).Return(nil)
+
+	notaOut, err := servico.CriarNota(20, 3, &dtos.NotaClinicaDTOIn{
+		Texto:      "  Relata insonia ha duas semanas  ",
+		DataSessao: time.Now().Add(-time.Hour),
+		TipoSessao: dominio.TipoSessaoIndividual,
+		Tags:       []string{"Sono", "sono", "ansiedade"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Relata insonia ha duas semanas", notaOut.Texto)
+	assert.Equal(t, []string{"sono", "ansiedade"}, notaOut.Tags)
+	assert.True(t, notaOut.Propria)
+	assert.Equal(t, uint(1), notaOut.Revisao)
+
+	// O banco recebe apenas o texto cifrado, amarrado a nota e a revisao
+	assert.NotContains(t, string(gravada.ConteudoCifrado), "insonia")
+	_, err = cifrador.Decifrar(gravada.ConteudoCifrado, []byte("nota_clinica:15:1"))
+	assert.NoError(t, err)
+	_, err = cifrador.Decifrar(gravada.ConteudoCifrado, []byte("nota_clinica:16:1"))
+	assert.Equal(t, cifragem.ErrTextoCifradoInvalido, err)
+}
+
+func TestNotaClinicaServico_CriarNota_SemVinculoAtivo(t *testing.T) {
+	servico, _, vinculoRepo, notaRepo, _ := setupNotaClinica(t)
+
+	encerradoEm := time.Now().AddDate(0, -1, 0)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(3), uint(7)).
+		Return(&dominio.Vinculo{PacienteID: 3, ProfissionalID: 7, EncerradoEm: &encerradoEm}, nil)
+
+	_, err := servico.CriarNota(20, 3, &dtos.NotaClinicaDTOIn{Texto: "x", DataSessao: time.Now(), TipoSessao: dominio.TipoSessaoIndividual})
+
+	assert.Equal(t, dominio.ErrVinculoNaoEncontrado, err)
+	notaRepo.AssertNotCalled(t, "CriarNota", mock.Anything, mock.Anything)
+}
+
+func TestNotaClinicaServico_RevisarNota_AcrescentaRevisao(t *testing.T) {
+	servico, _, _, notaRepo, cifrador := setupNotaClinica(t)
+
+	nota := notaCifrada(t, cifrador, 15, 7, "Primeira versao", nil, time.Now().AddDate(0, 0, -2), dominio.TipoSessaoIndividual)
+	notaRepo.On("BuscarNotaPorID", mock.Anything, uint(15)).Return(nota, nil)
+	notaRepo.On("CriarRevisao", mock.Anything, mock.MatchedBy(func(r *dominio.RevisaoNotaClinica) bool {
+		return r.NotaID == 15 && r.Revisao == 2
+	})).Return(nil)
+	notaRepo.On("AtualizarRevisaoAtual", mock.Anything, mock.MatchedBy(func(n *dominio.NotaClinica) bool {
+		return n.RevisaoAtual == 2
+	})).Return(nil)
+
+	notaOut, err := servico.RevisarNota(20, 15, &dtos.NotaClinicaDTOIn{
+		Texto: "Segunda versao", DataSessao: time.Now().AddDate(0, 0, -2), TipoSessao: dominio.TipoSessaoIndividual,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), notaOut.Revisao)
+	assert.Equal(t, "Segunda versao", notaOut.Texto)
+	notaRepo.AssertExpectations(t)
+}
+
+func TestNotaClinicaServico_RevisarNota_ApenasAutor(t *testing.T) {
+	servico, _, vinculoRepo, notaRepo, cifrador := setupNotaClinica(t)
+
+	nota := notaCifrada(t, cifrador, 15, 7, "Nota do autor", nil, time.Now(), dominio.TipoSessaoIndividual)
+	nota.Compartilhamentos = []dominio.CompartilhamentoNota{{NotaID: 15, ProfissionalID: 8, CompartilhadoEm: time.Now()}}
+	notaRepo.On("BuscarNotaPorID", mock.Anything, uint(15)).Return(nota, nil)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(3), uint(8)).Return(&dominio.Vinculo{PacienteID: 3, ProfissionalID: 8}, nil)
+
+	// O colega le a nota compartilhada, mas nao a altera
+	notaOut, err := servico.BuscarNota(21, 15)
+	assert.NoError(t, err)
+	assert.Equal(t, "Nota do autor", notaOut.Texto)
+	assert.False(t, notaOut.Propria)
+	assert.Empty(t, notaOut.CompartilhadaCom)
+
+	_, err = servico.RevisarNota(21, 15, &dtos.NotaClinicaDTOIn{Texto: "x", DataSessao: time.Now(), TipoSessao: dominio.TipoSessaoIndividual})
+	assert.Equal(t, dominio.ErrNotaApenasAutor, err)
+	notaRepo.AssertNotCalled(t, "CriarRevisao", mock.Anything, mock.Anything)
+}
+
+func TestNotaClinicaServico_BuscarNota_NaoCompartilhadaResponde404(t *testing.T) {
+	servico, _, _, notaRepo, cifrador := setupNotaClinica(t)
+
+	nota := notaCifrada(t, cifrador, 15, 7, "Privada", nil, time.Now(), dominio.TipoSessaoIndividual)
+	notaRepo.On("BuscarNotaPorID", mock.Anything, uint(15)).Return(nota, nil)
+
+	_, err := servico.BuscarNota(21, 15)
+	assert.Equal(t, dominio.ErrNotaClinicaNaoEncontrada, err)
+
+	_, err = servico.ListarRevisoes(21, 15)
+	assert.Equal(t, dominio.ErrNotaClinicaNaoEncontrada, err)
+}
+
+func TestNotaClinicaServico_CompartilharNota_ExigeCoTratamento(t *testing.T) {
+	servico, _, vinculoRepo, notaRepo, cifrador := setupNotaClinica(t)
+
+	nota := notaCifrada(t, cifrador, 15, 7, "Compartilhavel", nil, time.Now(), dominio.TipoSessaoIndividual)
+	notaRepo.On("BuscarNotaPorID", mock.Anything, uint(15)).Return(nota, nil)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(3), uint(9)).Return(nil, gorm.ErrRecordNotFound)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(3), uint(8)).Return(&dominio.Vinculo{PacienteID: 3, ProfissionalID: 8}, nil)
+	notaRepo.On("SalvarCompartilhamento", mock.Anything, mock.MatchedBy(func(c *dominio.CompartilhamentoNota) bool {
+		return c.NotaID == 15 && c.ProfissionalID == 8 && c.RevogadoEm == nil
+	})).Return(nil)
+
+	_, err := servico.CompartilharNota(20, 15, 9)
+	assert.Equal(t, dominio.ErrCompartilhamentoNotaInvalido, err)
+
+	notaOut, err := servico.CompartilharNota(20, 15, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{8}, notaOut.CompartilhadaCom)
+	notaRepo.AssertNumberOfCalls(t, "SalvarCompartilhamento", 1)
+}
+
+func TestNotaClinicaServico_ListarNotas_Busca(t *testing.T) {
+	servico, _, vinculoRepo, notaRepo, cifrador := setupNotaClinica(t)
+
+	hoje := time.Now().Truncate(24 * time.Hour)
+	notas := []*dominio.NotaClinica{
+		notaCifrada(t, cifrador, 1, 7, "Paciente relata insonia", []string{"sono"}, hoje.AddDate(0, 0, -10), dominio.TipoSessaoIndividual),
+		notaCifrada(t, cifrador, 2, 7, "Conflito com a familia", []string{"familia"}, hoje.AddDate(0, 0, -3), dominio.TipoSessaoFamilia),
+		notaCifrada(t, cifrador, 3, 8, "Insonia melhorou com rotina", []string{"sono"}, hoje.AddDate(0, 0, -1), dominio.TipoSessaoIndividual),
+	}
+	notas[2].Compartilhamentos = []dominio.CompartilhamentoNota{{NotaID: 3, ProfissionalID: 7, CompartilhadoEm: hoje}}
+	notaRepo.On("ListarNotasVisiveis", mock.Anything, uint(7), uint(3)).Return(notas, nil)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(3), uint(7)).Return(&dominio.Vinculo{PacienteID: 3, ProfissionalID: 7}, nil)
+
+	encontradas, err := servico.ListarNotas(20, 3, &dtos.FiltroNotasClinicasDTOIn{Busca: "INSONIA"})
+	assert.NoError(t, err)
+	assert.Len(t, encontradas, 2)
+	assert.Equal(t, uint(3), encontradas[0].ID, "sessao mais recente primeiro")
+	assert.False(t, encontradas[0].Propria)
+
+	porTag, err := servico.ListarNotas(20, 3, &dtos.FiltroNotasClinicasDTOIn{Tag: "familia"})
+	assert.NoError(t, err)
+	assert.Len(t, porTag, 1)
+	assert.Equal(t, uint(2), porTag[0].ID)
+
+	de := hoje.AddDate(0, 0, -5)
+	porPeriodo, err := servico.ListarNotas(20, 3, &dtos.FiltroNotasClinicasDTOIn{TipoSessao: dominio.TipoSessaoIndividual, De: &de})
+	assert.NoError(t, err)
+	assert.Len(t, porPeriodo, 1)
+	assert.Equal(t, uint(3), porPeriodo[0].ID)
+}
@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"fmt"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// painelTeste grava os dados da carteira do profissional 5 (usuario 50) direto no banco
+type painelTeste struct {
+	t     *testing.T
+	db    *gorm.DB
+	agora time.Time
+}
+
+func setupPainel(t *testing.T) (servicos.PainelServico, *painelTeste) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&dominio.Vinculo{}))
+	assert.NoError(t, db.AutoMigrate(&dominio.Usuario{}, &dominio.Profissional{}, &dominio.Paciente{}, &dominio.RegistroHumor{},
+		&dominio.Consentimento{}, &dominio.Instrumento{}, &dominio.Atribuicao{}, &dominio.Resposta{}))
+
+	usuarioRepo := new(MockUsuarioRepositorio)
+	usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(50)).Return(&dominio.Profissional{ID: 5, UsuarioID: 50}, nil)
+	usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(99)).Return(nil, gorm.ErrRecordNotFound)
+
+	svc := servicos.NovoPainelServico(db, usuarioRepo, sqlite_repo.NovoGormConsentimentoRepositorio(db), sqlite_repo.NovoGormPainelProfissionalRepositorio(db))
+	return svc, &painelTeste{t: t, db: db, agora: time.Now()}
+}
+
+// paciente cria o paciente, o vinculo com o profissional e, se houver categorias, o consentimento a partir de 60 dias atras
+func (p *painelTeste) paciente(id uint, nome string, profissionalID uint, ativo bool, categorias ...string) {
+	usuario := &dominio.Usuario{ID: 100 + id, TipoUsuario: 3, Nome: nome, Email: fmt.Sprintf("paciente%d@teste.com", id), Senha: "x"}
+	assert.NoError(p.t, p.db.Create(usuario).Error)
+	assert.NoError(p.t, p.db.Omit(clause.Associations).Create(&dominio.Paciente{ID: id, UsuarioID: usuario.ID, DataNascimento: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)}).Error)
+	vinculo := &dominio.Vinculo{PacienteID: id, ProfissionalID: profissionalID}
+	if !ativo {
+		vinculo.EncerradoEm = &p.agora
+	}
+	assert.NoError(p.t, p.db.Create(vinculo).Error)
+	if len(categorias) == 0 {
+		return
+	}
+	consentimento := &dominio.Consentimento{PacienteID: id, ProfissionalID: profissionalID, Versao: 1, DataInicio: p.agora.AddDate(0, 0, -60)}
+	for _, categoria := range categorias {
+		switch categoria {
+		case dominio.CategoriaHumor:
+			consentimento.CompartilharHumor = true
+		case dominio.CategoriaSono:
+			consentimento.CompartilharSono = true
+		case dominio.CategoriaQuestionarios:
+			consentimento.CompartilharQuestionarios = true
+		}
+	}
+	assert.NoError(p.t, p.db.Omit(clause.Associations).Create(consentimento).Error)
+}
+
+func (p *painelTeste) registro(pacienteID uint, diasAtras int, humor, sono, energia, stress int16) {
+	assert.NoError(p.t, p.db.Create(&dominio.RegistroHumor{
+		PacienteID: pacienteID, NivelHumor: humor, HorasSono: sono, NivelEnergia: energia, NivelStress: stress, AutoCuidado: "[]",
+		DataHoraRegistro: p.agora.AddDate(0, 0, -diasAtras),
+	}).Error)
+}
+
+func (p *painelTeste) atribuicao(pacienteID, instrumentoID uint, status string) *dominio.Atribuicao {
+	atribuicao := &dominio.Atribuicao{PacienteID: pacienteID, InstrumentoID: instrumentoID, ProfissionalID: 5, Status: status}
+	assert.NoError(p.t, p.db.Omit(clause.Associations).Create(atribuicao).Error)
+	return atribuicao
+}
+
+func (p *painelTeste) resposta(pacienteID, instrumentoID uint, diasAtras int, pontuacao float64, classificacao string) {
+	atribuicao := p.atribuicao(pacienteID, instrumentoID, dominio.StatusRespondido)
+	assert.NoError(p.t, p.db.Omit(clause.Associations).Create(&dominio.Resposta{
+		AtribuicaoID: atribuicao.ID, PontuacaoTotal: pontuacao, Classificacao: classificacao,
+		DataResposta: p.agora.AddDate(0, 0, -diasAtras),
+	}).Error)
+}
+
+func TestPainelServico_GerarPainelProfissional(t *testing.T) {
+	svc, p := setupPainel(t)
+	todas := []string{dominio.CategoriaHumor, dominio.CategoriaSono, dominio.CategoriaQuestionarios}
+	p.paciente(1, "Ana", 5, true, todas...)
+	p.paciente(2, "Bruno", 5, true, todas...)
+	p.paciente(3, "Carla", 5, true, dominio.CategoriaHumor)
+	p.paciente(4, "Davi", 5, true, todas...)
+	p.paciente(5, "Eva", 5, false, todas...)
+	p.paciente(6, "Fabio", 7, true, todas...)
+	assert.NoError(t, p.db.Create(&dominio.Instrumento{ID: 1, Codigo: "phq_9", Nome: "PHQ-9", AlgoritmoPontuacao: "phq_9"}).Error)
+	assert.NoError(t, p.db.Create(&dominio.Instrumento{ID: 2, Codigo: "gad_7", Nome: "GAD-7", AlgoritmoPontuacao: "gad_7"}).Error)
+
+	// Ana: registros recentes preocupantes, uma atribuicao pendente e duas respostas ao PHQ-9
+	p.registro(1, 1, 1, 6, 3, 9)
+	p.registro(1, 3, 2, 6, 3, 9)
+	p.atribuicao(1, 2, dominio.StatusPendente)
+	p.resposta(1, 1, 30, 10, "Moderada")
+	p.resposta(1, 1, 5, 15, "Moderadamente grave")
+	// Bruno: sem registros ha 20 dias e uma atribuicao expirada
+	p.registro(2, 20, 4, 8, 7, 3)
+	p.atribuicao(2, 1, dominio.StatusExpirado)
+	// Carla: so compartilha o humor; sem sono nao ha status, e as atribuicoes ficam ocultas
+	p.registro(3, 1, 5, 8, 8, 2)
+	p.atribuicao(3, 1, dominio.StatusPendente)
+	p.resposta(3, 2, 2, 4, "Minima")
+	// Davi: registros regulares
+	p.registro(4, 0, 4, 8, 7, 3)
+	p.registro(4, 2, 5, 8, 8, 2)
+	// Eva teve alta e Fabio e de outro profissional
+	p.registro(5, 1, 1, 2, 1, 10)
+	p.registro(6, 1, 1, 2, 1, 10)
+
+	painel, err := svc.GerarPainelProfissional(50)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, painel.DiasStatus)
+
+	nomes := make([]string, 0, len(painel.Pacientes))
+	for _, paciente := range painel.Pacientes {
+		nomes = append(nomes, paciente.Nome)
+	}
+	// Preocupante, depois os sem status (mais dias sem registro primeiro) e por fim os regulares
+	assert.Equal(t, []string{"Ana", "Bruno", "Carla", "Davi"}, nomes)
+
+	ana := painel.Pacientes[0]
+	assert.Equal(t, servicos.StatusPreocupante, ana.StatusAtual)
+	assert.Equal(t, 2, ana.RegistrosRecentes)
+	assert.Equal(t, 1, *ana.DiasSemRegistro)
+	assert.Equal(t, 1, ana.AtribuicoesPendentes)
+	assert.Len(t, ana.UltimasPontuacoes, 1)
+	assert.Equal(t, "phq_9", ana.UltimasPontuacoes[0].Codigo)
+	assert.Equal(t, 15.0, ana.UltimasPontuacoes[0].PontuacaoTotal)
+
+	bruno := painel.Pacientes[1]
+	assert.Empty(t, bruno.StatusAtual)
+	assert.Equal(t, 0, bruno.RegistrosRecentes)
+	assert.Equal(t, 20, *bruno.DiasSemRegistro)
+	assert.Equal(t, 1, bruno.AtribuicoesExpiradas)
+
+	carla := painel.Pacientes[2]
+	assert.Equal(t, []string{dominio.CategoriaHumor}, carla.CategoriasCompartilhadas)
+	assert.Empty(t, carla.StatusAtual)
+	assert.Equal(t, 1, carla.RegistrosRecentes)
+	assert.Equal(t, 0, carla.AtribuicoesPendentes)
+	assert.Empty(t, carla.UltimasPontuacoes)
+
+	assert.Equal(t, servicos.StatusRegular, painel.Pacientes[3].StatusAtual)
+	assert.Equal(t, 0, *painel.Pacientes[3].DiasSemRegistro)
+}
+
+func TestPainelServico_GerarPainelProfissional_RespeitaInicioDoConsentimento(t *testing.T) {
+	svc, p := setupPainel(t)
+	p.paciente(1, "Ana", 5, true, dominio.CategoriaHumor, dominio.CategoriaSono, dominio.CategoriaQuestionarios)
+	p.paciente(2, "Bruno", 5, true)
+	assert.NoError(t, p.db.Create(&dominio.Instrumento{ID: 1, Codigo: "phq_9", Nome: "PHQ-9", AlgoritmoPontuacao: "phq_9"}).Error)
+	// O consentimento comeca ha 60 dias: registro e resposta anteriores ficam ocultos
+	p.registro(1, 70, 3, 8, 6, 4)
+	p.resposta(1, 1, 65, 20, "Grave")
+	// Bruno nao tem consentimento e aparece sem dados
+	p.registro(2, 1, 1, 2, 1, 10)
+	p.atribuicao(2, 1, dominio.StatusPendente)
+
+	painel, err := svc.GerarPainelProfissional(50)
+	assert.NoError(t, err)
+	assert.Len(t, painel.Pacientes, 2)
+	for _, paciente := range painel.Pacientes {
+		assert.Nil(t, paciente.UltimoRegistro, paciente.Nome)
+		assert.Nil(t, paciente.DiasSemRegistro, paciente.Nome)
+		assert.Empty(t, paciente.StatusAtual, paciente.Nome)
+		assert.Empty(t, paciente.UltimasPontuacoes, paciente.Nome)
+		assert.Equal(t, 0, paciente.AtribuicoesPendentes, paciente.Nome)
+	}
+	assert.Empty(t, painel.Pacientes[1].CategoriasCompartilhadas)
+}
+
+func TestPainelServico_GerarPainelProfissional_Erros(t *testing.T) {
+	svc, _ := setupPainel(t)
+
+	_, err := svc.GerarPainelProfissional(99)
+	assert.Equal(t, dominio.ErrUsuarioNaoEncontrado, err)
+
+	painel, err := svc.GerarPainelProfissional(50)
+	assert.NoError(t, err)
+	assert.Empty(t, painel.Pacientes)
+}
@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/memoria"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockBloqueioLoginRepositorio simula a auditoria de bloqueios
+type MockBloqueioLoginRepositorio struct {
+	mock.Mock
+}
+
+func (m *MockBloqueioLoginRepositorio) CriarBloqueio(bloqueio *dominio.BloqueioLogin) error {
+	args := m.Called(bloqueio)
+	return args.Error(0)
+}
+
+func TestProtecaoLoginServico_RegistrarFalha_Simultaneas(t *testing.T) {
+	bloqueioRepo := new(MockBloqueioLoginRepositorio)
+	bloqueioRepo.On("CriarBloqueio", mock.Anything).Return(nil)
+	svc := servicos.NovoProtecaoLoginServico(memoria.NovoTentativaLoginRepositorio(), bloqueioRepo)
+
+	// Cada falha em paralelo conta: o limite da conta e atingido sem perder incrementos
+	var wg sync.WaitGroup
+	for i := 0; i < dominio.LimiteFalhasPorConta; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			svc.RegistrarFalha("ana@teste.com", "")
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, dominio.ErrLoginBloqueado, svc.VerificarBloqueio("ana@teste.com", ""))
+	bloqueioRepo.AssertNumberOfCalls(t, "CriarBloqueio", 1)
+}
@@ -0,0 +1,40 @@
+package tests
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRedefinicaoSenhaServico_ConfirmarRedefinicao_TokenDeUsoUnico(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&dominio.Usuario{}, &dominio.RedefinicaoSenha{}))
+	soma := sha256.Sum256([]byte("token-teste"))
+	assert.NoError(t, db.Create(&dominio.RedefinicaoSenha{UsuarioID: 10, TokenHash: hex.EncodeToString(soma[:]), DataExpiracao: time.Now().Add(time.Hour)}).Error)
+
+	redefinicaoRepo := sqlite_repo.NovoGormRedefinicaoSenhaRepositorio(db)
+	usuarioRepo := new(MockUsuarioRepositorio)
+	usuarioRepo.On("BuscarUsuarioPorID", uint(10)).Return(&dominio.Usuario{ID: 10, Senha: "x"}, nil)
+	usuarioRepo.On("Atualizar", mock.Anything, mock.Anything).Return(nil)
+	svc := servicos.NovoRedefinicaoSenhaServico(db, usuarioRepo, redefinicaoRepo, nil)
+
+	// Uma requisicao simultanea leu o token antes do primeiro uso e tambem passou na verificacao
+	concorrente, err := redefinicaoRepo.BuscarRedefinicaoPorTokenHash(db, hex.EncodeToString(soma[:]))
+	assert.NoError(t, err)
+	assert.NoError(t, concorrente.VerificarUso())
+
+	dto := &dtos.ConfirmarRedefinicaoSenhaDTOIn{Token: "token-teste", NovaSenha: "NovaSenha@123", NovaSenhaRe: "NovaSenha@123"}
+	assert.NoError(t, svc.ConfirmarRedefinicao(dto))
+
+	concorrente.Utilizar()
+	assert.Equal(t, dominio.ErrTokenRedefinicaoInvalido, redefinicaoRepo.MarcarRedefinicaoComoUsada(db, concorrente))
+	assert.Equal(t, dominio.ErrTokenRedefinicaoJaUtilizado, svc.ConfirmarRedefinicao(dto))
+}
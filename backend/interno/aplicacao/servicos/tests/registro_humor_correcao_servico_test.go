@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// monitoramentoEspiao registra os pacientes monitorados apos cada mudanca nos registros
+type monitoramentoEspiao struct {
+	pacientes chan uint
+}
+
+func (m *monitoramentoEspiao) GerarAnaliseHistorica(usuarioID, pacienteID uint, tipoUsuario string, dias int) (*dtos.AnalisePacienteDTOOut, error) {
+	return nil, nil
+}
+
+func (m *monitoramentoEspiao) GerarCorrelacoes(usuarioID, pacienteID uint, tipoUsuario string, dias int) (*dtos.CorrelacoesMetricasDTOOut, error) {
+	return nil, nil
+}
+
+func (m *monitoramentoEspiao) GerarAnaliseIntervalo(usuarioID, pacienteID uint, tipoUsuario string, intervalo dtos.IntervaloAnaliseDTOIn) (*dtos.AnalisePacienteDTOOut, error) {
+	return nil, nil
+}
+
+func (m *monitoramentoEspiao) CompararPeriodos(usuarioID, pacienteID uint, tipoUsuario string, periodo, referencia dtos.IntervaloAnaliseDTOIn) (*dtos.ComparacaoAnaliseDTOOut, error) {
+	return nil, nil
+}
+
+func (m *monitoramentoEspiao) GerarAnaliseAgregada(usuarioID, pacienteID uint, tipoUsuario string, filtro *dtos.FiltroAnaliseAgregadaDTOIn) (*dtos.AnaliseAgregadaDTOOut, error) {
+	return nil, nil
+}
+
+func (m *monitoramentoEspiao) ExecutarMonitoramento(pacienteID uint) error {
+	m.pacientes <- pacienteID
+	return nil
+}
+
+func (m *monitoramentoEspiao) esperar(t *testing.T) uint {
+	select {
+	case pacienteID := <-m.pacientes:
+		return pacienteID
+	case <-time.After(time.Second):
+		t.Fatal("monitoramento nao executado")
+		return 0
+	}
+}
+
+func setupCorrecaoRegistroHumor(t *testing.T) (servicos.RegistroHumorServico, *gorm.DB, *monitoramentoEspiao) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&dominio.RegistroHumor{}, &dominio.RevisaoRegistroHumor{}, &dominio.AtividadeAutoCuidado{}, &dominio.RegistroHumorAtividade{}, &dominio.MetricaPersonalizada{}, &dominio.ValorMetricaRegistro{}))
+	// A tabela de juncao criada pelo relacionamento recebe as colunas do vinculo
+	assert.NoError(t, db.AutoMigrate(&dominio.Vinculo{}))
+	assert.NoError(t, db.Create(&dominio.AtividadeAutoCuidado{Nome: "Caminhada", NomeNormalizado: "caminhada", Ativa: true}).Error)
+
+	usuarioRepo := new(MockUsuarioRepositorio)
+	usuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{ID: 1, UsuarioID: 10}, nil)
+	usuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(20)).Return(&dominio.Paciente{ID: 2, UsuarioID: 20}, nil)
+
+	monitoramento := &monitoramentoEspiao{pacientes: make(chan uint, 4)}
+	svc := servicos.NovoRegistroHumorServico(db, sqlite_repo.NovoGormRegistroHumorRepositorio(db), usuarioRepo, nil, nil, sqlite_repo.NovoGormAutoCuidadoRepositorio(db), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db), monitoramento, 24*time.Hour, 0)
+	return svc, db, monitoramento
+}
+
+func criarRegistroHumorTeste(t *testing.T, db *gorm.DB, criadoEm time.Time) *dominio.RegistroHumor {
+	registro := &dominio.RegistroHumor{
+		PacienteID:       1,
+		NivelHumor:       1,
+		HorasSono:        7,
+		NivelEnergia:     5,
+		NivelStress:      5,
+		AutoCuidado:      `["caminhada"]`,
+		DataHoraRegistro: criadoEm,
+		CreatedAt:        criadoEm,
+	}
+	assert.NoError(t, db.Create(registro).Error)
+	return registro
+}
+
+func correcaoHumor(nivelHumor int16) *dtos.CriarRegistroHumorDTOIn {
+	horasSono := int16(7)
+	return &dtos.CriarRegistroHumorDTOIn{
+		NivelHumor:   nivelHumor,
+		HorasSono:    &horasSono,
+		NivelStress:  5,
+		NivelEnergia: 5,
+		AutoCuidado:  []string{"caminhada"},
+	}
+}
+
+func TestRegistroHumorServico_AtualizarRegistroHumor_GuardaRevisao(t *testing.T) {
+	svc, db, monitoramento := setupCorrecaoRegistroHumor(t)
+	registro := criarRegistroHumorTeste(t, db, time.Now().Add(-time.Hour))
+
+	registroOut, err := svc.AtualizarRegistroHumor(registro.ID, 10, correcaoHumor(5))
+	assert.NoError(t, err)
+	assert.Equal(t, int16(5), registroOut.NivelHumor)
+	// Sem data_hora_registro, o momento original e mantido
+	assert.True(t, registro.DataHoraRegistro.Equal(registroOut.DataHoraRegistro))
+	assert.Equal(t, uint(1), monitoramento.esperar(t))
+
+	_, err = svc.AtualizarRegistroHumor(registro.ID, 10, correcaoHumor(4))
+	assert.NoError(t, err)
+	monitoramento.esperar(t)
+
+	revisoes, err := svc.ListarRevisoesRegistroHumor(registro.ID, 10)
+	assert.NoError(t, err)
+	assert.Len(t, revisoes, 2)
+	assert.Equal(t, uint(2), revisoes[0].Revisao)
+	assert.Equal(t, int16(5), revisoes[0].NivelHumor)
+	assert.Equal(t, uint(1), revisoes[1].Revisao)
+	assert.Equal(t, int16(1), revisoes[1].NivelHumor)
+	assert.Equal(t, dominio.OperacaoRevisaoEdicao, revisoes[1].Operacao)
+}
+
+func TestRegistroHumorServico_AtualizarRegistroHumor_ForaDoPrazo(t *testing.T) {
+	svc, db, _ := setupCorrecaoRegistroHumor(t)
+	registro := criarRegistroHumorTeste(t, db, time.Now().Add(-25*time.Hour))
+
+	_, err := svc.AtualizarRegistroHumor(registro.ID, 10, correcaoHumor(5))
+	assert.Equal(t, dominio.ErrJanelaCorrecaoEncerrada, err)
+	assert.Equal(t, dominio.ErrJanelaCorrecaoEncerrada, svc.ExcluirRegistroHumor(registro.ID, 10))
+}
+
+func TestRegistroHumorServico_CorrecaoDeOutroPaciente(t *testing.T) {
+	svc, db, _ := setupCorrecaoRegistroHumor(t)
+	registro := criarRegistroHumorTeste(t, db, time.Now())
+
+	_, err := svc.AtualizarRegistroHumor(registro.ID, 20, correcaoHumor(5))
+	assert.Equal(t, dominio.ErrRegistroHumorNaoEncontrado, err)
+	assert.Equal(t, dominio.ErrRegistroHumorNaoEncontrado, svc.ExcluirRegistroHumor(registro.ID, 20))
+	_, err = svc.ListarRevisoesRegistroHumor(registro.ID, 20)
+	assert.Equal(t, dominio.ErrRegistroHumorNaoEncontrado, err)
+}
+
+func TestRegistroHumorServico_ExcluirRegistroHumor(t *testing.T) {
+	svc, db, monitoramento := setupCorrecaoRegistroHumor(t)
+	registro := criarRegistroHumorTeste(t, db, time.Now())
+
+	assert.NoError(t, svc.ExcluirRegistroHumor(registro.ID, 10))
+	assert.Equal(t, uint(1), monitoramento.esperar(t))
+
+	var restantes int64
+	db.Model(&dominio.RegistroHumor{}).Count(&restantes)
+	assert.Equal(t, int64(0), restantes)
+
+	var revisao dominio.RevisaoRegistroHumor
+	assert.NoError(t, db.Where("registro_humor_id = ?", registro.ID).First(&revisao).Error)
+	assert.Equal(t, dominio.OperacaoRevisaoExclusao, revisao.Operacao)
+	assert.Equal(t, int16(1), revisao.NivelHumor)
+	assert.Equal(t, uint(1), revisao.PacienteID)
+}
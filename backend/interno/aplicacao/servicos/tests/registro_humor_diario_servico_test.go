@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"bytes"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/cifragem"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// setupDiarioRegistroHumor prepara o paciente 1 (usuario 10) e o profissional 5 (usuario 50)
+// com vinculo ativo e o consentimento informado
+func setupDiarioRegistroHumor(t *testing.T, consentimento *dominio.Consentimento) (servicos.RegistroHumorServico, *gorm.DB) {
+	db := setupTestDB(t)
+	provedor, err := cifragem.NovoProvedorChaveUnica("teste", bytes.Repeat([]byte{7}, 32))
+	assert.NoError(t, err)
+	assert.NoError(t, cifragem.Registrar(db, cifragem.NovoEnvelope(provedor)))
+	assert.NoError(t, db.AutoMigrate(&dominio.RegistroHumor{}, &dominio.MetricaPersonalizada{}, &dominio.ValorMetricaRegistro{}))
+
+	usuarioRepo := new(MockUsuarioRepositorio)
+	usuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{ID: 1, UsuarioID: 10}, nil)
+	usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(50)).Return(&dominio.Profissional{ID: 5, UsuarioID: 50}, nil)
+
+	vinculoRepo := new(MockVinculoRepositorio)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(1), uint(5)).Return(&dominio.Vinculo{PacienteID: 1, ProfissionalID: 5}, nil)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(2), uint(5)).Return(nil, gorm.ErrRecordNotFound)
+
+	consentimentoRepo := new(MockConsentimentoRepositorio)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(1), uint(5)).Return(consentimento, nil)
+
+	svc := servicos.NovoRegistroHumorServico(db, sqlite_repo.NovoGormRegistroHumorRepositorio(db), usuarioRepo, consentimentoRepo, vinculoRepo, nil, sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db), &monitoramentoEspiao{pacientes: make(chan uint, 4)}, 0, 0)
+	return svc, db
+}
+
+func criarRegistroDiario(t *testing.T, db *gorm.DB, dataHora time.Time, humor int16, autoCuidado, observacoes string) *dominio.RegistroHumor {
+	registro := &dominio.RegistroHumor{
+		PacienteID:       1,
+		NivelHumor:       humor,
+		HorasSono:        7,
+		NivelEnergia:     6,
+		NivelStress:      4,
+		AutoCuidado:      autoCuidado,
+		Observacoes:      observacoes,
+		DataHoraRegistro: dataHora,
+	}
+	assert.NoError(t, db.Create(registro).Error)
+	return registro
+}
+
+func consentimentoDiario(humor, sono, observacoes bool, inicio time.Time) *dominio.Consentimento {
+	return &dominio.Consentimento{
+		PacienteID:              1,
+		ProfissionalID:          5,
+		CompartilharHumor:       humor,
+		CompartilharSono:        sono,
+		CompartilharObservacoes: observacoes,
+		DataInicio:              inicio,
+	}
+}
+
+func TestRegistroHumorServico_ListarRegistrosHumor_PaginaPorCursor(t *testing.T) {
+	svc, db := setupDiarioRegistroHumor(t, nil)
+	base := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		criarRegistroDiario(t, db, base.AddDate(0, 0, i), int16(i+1), `["caminhada"]`, "")
+	}
+
+	pagina, err := svc.ListarRegistrosHumor(10, "paciente", 0, &dtos.FiltroRegistrosHumorDTOIn{Limite: 2})
+	assert.NoError(t, err)
+	assert.Len(t, pagina.Registros, 2)
+	assert.Equal(t, base.AddDate(0, 0, 4), pagina.Registros[0].DataHoraRegistro.UTC())
+	assert.NotEmpty(t, pagina.ProximoCursor)
+	assert.Nil(t, pagina.CategoriasCompartilhadas)
+
+	pagina, err = svc.ListarRegistrosHumor(10, "paciente", 0, &dtos.FiltroRegistrosHumorDTOIn{Limite: 2, Cursor: pagina.ProximoCursor})
+	assert.NoError(t, err)
+	assert.Len(t, pagina.Registros, 2)
+	assert.Equal(t, base.AddDate(0, 0, 2), pagina.Registros[0].DataHoraRegistro.UTC())
+
+	pagina, err = svc.ListarRegistrosHumor(10, "paciente", 0, &dtos.FiltroRegistrosHumorDTOIn{Limite: 2, Cursor: pagina.ProximoCursor})
+	assert.NoError(t, err)
+	assert.Len(t, pagina.Registros, 1)
+	assert.Empty(t, pagina.ProximoCursor)
+}
+
+func TestRegistroHumorServico_ListarRegistrosHumor_Filtros(t *testing.T) {
+	svc, db := setupDiarioRegistroHumor(t, nil)
+	base := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	criarRegistroDiario(t, db, base, 2, `["caminhada","leitura"]`, "Dia dificil no trabalho")
+	criarRegistroDiario(t, db, base.AddDate(0, 0, 1), 4, `["leitura"]`, "Jantar com a familia")
+	criarRegistroDiario(t, db, base.AddDate(0, 0, 2), 5, `["Caminhada"]`, "Trabalho tranquilo")
+
+	humorMin := int16(4)
+	pagina, err := svc.ListarRegistrosHumor(10, "paciente", 0, &dtos.FiltroRegistrosHumorDTOIn{HumorMin: &humorMin})
+	assert.NoError(t, err)
+	assert.Len(t, pagina.Registros, 2)
+
+	pagina, err = svc.ListarRegistrosHumor(10, "paciente", 0, &dtos.FiltroRegistrosHumorDTOIn{AutoCuidado: []string{"caminhada"}, Busca: "TRABALHO"})
+	assert.NoError(t, err)
+	assert.Len(t, pagina.Registros, 2)
+
+	ate := base.AddDate(0, 0, 1)
+	pagina, err = svc.ListarRegistrosHumor(10, "paciente", 0, &dtos.FiltroRegistrosHumorDTOIn{De: &ate, Ate: &ate})
+	assert.NoError(t, err)
+	assert.Len(t, pagina.Registros, 1)
+	assert.Equal(t, "Jantar com a familia", pagina.Registros[0].Observacoes)
+
+	humorMax := int16(1)
+	_, err = svc.ListarRegistrosHumor(10, "paciente", 0, &dtos.FiltroRegistrosHumorDTOIn{HumorMin: &humorMin, HumorMax: &humorMax})
+	assert.Equal(t, dominio.ErrFaixaMetricaInvalida, err)
+
+	_, err = svc.ListarRegistrosHumor(10, "paciente", 0, &dtos.FiltroRegistrosHumorDTOIn{Cursor: "invalido"})
+	assert.Equal(t, dominio.ErrCursorDiarioInvalido, err)
+}
+
+func TestRegistroHumorServico_ListarRegistrosHumor_ProfissionalRespeitaConsentimento(t *testing.T) {
+	base := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	svc, db := setupDiarioRegistroHumor(t, consentimentoDiario(false, true, false, base.AddDate(0, 0, 1)))
+	criarRegistroDiario(t, db, base, 2, `["caminhada"]`, "antes do consentimento")
+	criarRegistroDiario(t, db, base.AddDate(0, 0, 2), 4, `["leitura"]`, "depois do consentimento")
+
+	pagina, err := svc.ListarRegistrosHumor(50, "profissional", 1, &dtos.FiltroRegistrosHumorDTOIn{})
+	assert.NoError(t, err)
+	assert.Len(t, pagina.Registros, 1)
+	assert.Equal(t, []string{dominio.CategoriaSono}, pagina.CategoriasCompartilhadas)
+	registro := pagina.Registros[0]
+	assert.Equal(t, int16(7), registro.HorasSono)
+	assert.Zero(t, registro.NivelHumor)
+	assert.Empty(t, registro.AutoCuidado)
+	assert.Empty(t, registro.Observacoes)
+
+	// Filtrar por categoria oculta revelaria os valores dela
+	_, err = svc.ListarRegistrosHumor(50, "profissional", 1, &dtos.FiltroRegistrosHumorDTOIn{Busca: "consentimento"})
+	assert.Equal(t, dominio.ErrConsentimentoNegado, err)
+	_, err = svc.ListarRegistrosHumor(50, "profissional", 1, &dtos.FiltroRegistrosHumorDTOIn{AutoCuidado: []string{"leitura"}})
+	assert.Equal(t, dominio.ErrConsentimentoNegado, err)
+
+	_, err = svc.ListarRegistrosHumor(50, "profissional", 2, &dtos.FiltroRegistrosHumorDTOIn{})
+	assert.Equal(t, dominio.ErrVinculoNaoEncontrado, err)
+}
@@ -5,6 +5,7 @@ import (
 	"mindtrace/backend/interno/aplicacao/dtos"
 	"mindtrace/backend/interno/aplicacao/servicos"
 	"mindtrace/backend/interno/dominio"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
 	"testing"
 	"time"
 
@@ -50,6 +51,49 @@ func (m *MockRegistroHumorRepositorio) BuscarPorNUltimosRegistros(pacienteID uin
 	return args.Get(0).([]*dominio.RegistroHumor), args.Error(1)
 }
 
+func (m *MockRegistroHumorRepositorio) BuscarRegistroHumorPorID(tx *gorm.DB, registroID uint) (*dominio.RegistroHumor, error) {
+	args := m.Called(tx, registroID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dominio.RegistroHumor), args.Error(1)
+}
+
+func (m *MockRegistroHumorRepositorio) AtualizarRegistroHumor(tx *gorm.DB, registro *dominio.RegistroHumor) error {
+	args := m.Called(tx, registro)
+	return args.Error(0)
+}
+
+func (m *MockRegistroHumorRepositorio) ExcluirRegistroHumor(tx *gorm.DB, registroID uint) error {
+	args := m.Called(tx, registroID)
+	return args.Error(0)
+}
+
+func (m *MockRegistroHumorRepositorio) CriarRevisaoRegistroHumor(tx *gorm.DB, revisao *dominio.RevisaoRegistroHumor) error {
+	args := m.Called(tx, revisao)
+	return args.Error(0)
+}
+
+func (m *MockRegistroHumorRepositorio) ListarRevisoesRegistroHumor(tx *gorm.DB, registroID uint) ([]*dominio.RevisaoRegistroHumor, error) {
+	args := m.Called(tx, registroID)
+	return args.Get(0).([]*dominio.RevisaoRegistroHumor), args.Error(1)
+}
+
+func (m *MockRegistroHumorRepositorio) ListarRegistrosHumor(tx *gorm.DB, pacienteID uint, filtro *dominio.FiltroRegistrosHumor, limite int) ([]*dominio.RegistroHumor, error) {
+	args := m.Called(tx, pacienteID, filtro, limite)
+	return args.Get(0).([]*dominio.RegistroHumor), args.Error(1)
+}
+
+func (m *MockRegistroHumorRepositorio) CriarRegistroHumorSeNovo(tx *gorm.DB, registro *dominio.RegistroHumor) (bool, error) {
+	args := m.Called(tx, registro)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRegistroHumorRepositorio) BuscarRegistrosHumorPorChaves(tx *gorm.DB, pacienteID uint, chaves []string) ([]*dominio.RegistroHumor, error) {
+	args := m.Called(tx, pacienteID, chaves)
+	return args.Get(0).([]*dominio.RegistroHumor), args.Error(1)
+}
+
 // MockUsuarioRepositorioRH simula o repositorio de usuarios para testes de registro humor
 type MockUsuarioRepositorioRH struct {
 	mock.Mock
@@ -71,6 +115,10 @@ func (m *MockUsuarioRepositorioRH) BuscarPorEmail(email string) (*dominio.Usuari
 	return nil, nil
 }
 
+func (m *MockUsuarioRepositorioRH) BuscarPorCPF(cpf string) (*dominio.Usuario, error) {
+	return nil, nil
+}
+
 func (m *MockUsuarioRepositorioRH) BuscarUsuarioPorID(id uint) (*dominio.Usuario, error) {
 	return nil, nil
 }
@@ -111,23 +159,51 @@ func (m *MockUsuarioRepositorioRH) AtualizarPaciente(tx *gorm.DB, paciente *domi
 	return nil
 }
 
-func (m *MockUsuarioRepositorioRH) DeletarUsuario(tx *gorm.DB, id uint) error {
-	return nil
-}
-
 // MockAnaliseServico simula o servico de analise
 type MockAnaliseServico struct {
 	mock.Mock
 }
 
-func (m *MockAnaliseServico) GerarAnaliseHistorica(pacienteID uint, dias int) (*dtos.AnalisePacienteDTOOut, error) {
-	args := m.Called(pacienteID, dias)
+func (m *MockAnaliseServico) GerarAnaliseHistorica(usuarioID, pacienteID uint, tipoUsuario string, dias int) (*dtos.AnalisePacienteDTOOut, error) {
+	args := m.Called(usuarioID, pacienteID, tipoUsuario, dias)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dtos.AnalisePacienteDTOOut), args.Error(1)
+}
+
+func (m *MockAnaliseServico) GerarCorrelacoes(usuarioID, pacienteID uint, tipoUsuario string, dias int) (*dtos.CorrelacoesMetricasDTOOut, error) {
+	args := m.Called(usuarioID, pacienteID, tipoUsuario, dias)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dtos.CorrelacoesMetricasDTOOut), args.Error(1)
+}
+
+func (m *MockAnaliseServico) GerarAnaliseIntervalo(usuarioID, pacienteID uint, tipoUsuario string, intervalo dtos.IntervaloAnaliseDTOIn) (*dtos.AnalisePacienteDTOOut, error) {
+	args := m.Called(usuarioID, pacienteID, tipoUsuario, intervalo)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*dtos.AnalisePacienteDTOOut), args.Error(1)
 }
 
+func (m *MockAnaliseServico) CompararPeriodos(usuarioID, pacienteID uint, tipoUsuario string, periodo, referencia dtos.IntervaloAnaliseDTOIn) (*dtos.ComparacaoAnaliseDTOOut, error) {
+	args := m.Called(usuarioID, pacienteID, tipoUsuario, periodo, referencia)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dtos.ComparacaoAnaliseDTOOut), args.Error(1)
+}
+
+func (m *MockAnaliseServico) GerarAnaliseAgregada(usuarioID, pacienteID uint, tipoUsuario string, filtro *dtos.FiltroAnaliseAgregadaDTOIn) (*dtos.AnaliseAgregadaDTOOut, error) {
+	args := m.Called(usuarioID, pacienteID, tipoUsuario, filtro)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dtos.AnaliseAgregadaDTOOut), args.Error(1)
+}
+
 func (m *MockAnaliseServico) ExecutarMonitoramento(pacienteID uint) error {
 	args := m.Called(pacienteID)
 	return args.Error(0)
@@ -141,14 +217,26 @@ func setupTestDBRegistroHumor(t *testing.T) *gorm.DB {
 		t.Fatalf("Falha ao abrir banco de dados de teste: %v", err)
 	}
 
-	err = db.AutoMigrate(&dominio.Usuario{}, &dominio.Profissional{}, &dominio.Paciente{}, &dominio.RegistroHumor{})
+	err = db.AutoMigrate(&dominio.Usuario{}, &dominio.Profissional{}, &dominio.Paciente{}, &dominio.RegistroHumor{},
+		&dominio.AtividadeAutoCuidado{}, &dominio.RegistroHumorAtividade{}, &dominio.MetricaPersonalizada{}, &dominio.ValorMetricaRegistro{}, &dominio.Vinculo{})
 	if err != nil {
 		t.Fatalf("Falha ao migrar esquema: %v", err)
 	}
 
+	// O auto cuidado do registro vem do catalogo de atividades
+	for _, nome := range []string{"Exercício físico", "Meditação e yoga"} {
+		if err := db.Create(&dominio.AtividadeAutoCuidado{Nome: nome, NomeNormalizado: dominio.NormalizarNomeAtividade(nome), Ativa: true}).Error; err != nil {
+			t.Fatalf("Falha ao criar atividade: %v", err)
+		}
+	}
+
 	return db
 }
 
+func horasSono(horas int16) *int16 {
+	return &horas
+}
+
 // ========== Testes do Serviço ==========
 
 func TestRegistroHumorServico_CriarRegistroHumor_Sucesso(t *testing.T) {
@@ -159,7 +247,7 @@ func TestRegistroHumorServico_CriarRegistroHumor_Sucesso(t *testing.T) {
 
 	mockAnaliseServico.On("ExecutarMonitoramento", mock.Anything).Return(nil).Maybe()
 
-	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, mockAnaliseServico)
+	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, nil, nil, sqlite_repo.NovoGormAutoCuidadoRepositorio(db), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db), mockAnaliseServico, 0, 0)
 
 	pacienteExistente := &dominio.Paciente{
 		ID:        1,
@@ -168,10 +256,10 @@ func TestRegistroHumorServico_CriarRegistroHumor_Sucesso(t *testing.T) {
 
 	dto := dtos.CriarRegistroHumorDTOIn{
 		NivelHumor:       4,
-		HorasSono:        8,
+		HorasSono:        horasSono(8),
 		NivelEnergia:     7,
 		NivelStress:      3,
-		AutoCuidado:      "Exercício físico",
+		AutoCuidado:      []string{"Exercício físico"},
 		Observacoes:      "Dia produtivo",
 		DataHoraRegistro: time.Now(),
 	}
@@ -179,7 +267,7 @@ func TestRegistroHumorServico_CriarRegistroHumor_Sucesso(t *testing.T) {
 	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(pacienteExistente, nil)
 	mockRegistroHumorRepo.On("CriarRegistroHumor", mock.Anything, mock.AnythingOfType("*dominio.RegistroHumor")).Return(nil)
 
-	resultado, err := servico.CriarRegistroHumor(dto, 10)
+	resultado, err := servico.CriarRegistroHumor(&dto, 10)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resultado)
@@ -187,7 +275,7 @@ func TestRegistroHumorServico_CriarRegistroHumor_Sucesso(t *testing.T) {
 	assert.Equal(t, int16(8), resultado.HorasSono)
 	assert.Equal(t, int16(7), resultado.NivelEnergia)
 	assert.Equal(t, int16(3), resultado.NivelStress)
-	assert.Equal(t, "Exercício físico", resultado.AutoCuidado)
+	assert.Equal(t, `["Exercício físico"]`, resultado.AutoCuidado)
 	assert.Equal(t, uint(1), resultado.PacienteID)
 	mockUsuarioRepo.AssertExpectations(t)
 	mockRegistroHumorRepo.AssertExpectations(t)
@@ -199,20 +287,20 @@ func TestRegistroHumorServico_CriarRegistroHumor_PacienteNaoEncontrado(t *testin
 	mockUsuarioRepo := new(MockUsuarioRepositorioRH)
 	mockAnaliseServico := new(MockAnaliseServico)
 
-	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, mockAnaliseServico)
+	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, nil, nil, sqlite_repo.NovoGormAutoCuidadoRepositorio(db), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db), mockAnaliseServico, 0, 0)
 
 	dto := dtos.CriarRegistroHumorDTOIn{
 		NivelHumor:       4,
-		HorasSono:        8,
+		HorasSono:        horasSono(8),
 		NivelEnergia:     7,
 		NivelStress:      3,
-		AutoCuidado:      "Exercício físico",
+		AutoCuidado:      []string{"Exercício físico"},
 		DataHoraRegistro: time.Now(),
 	}
 
 	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(999)).Return(nil, gorm.ErrRecordNotFound)
 
-	resultado, err := servico.CriarRegistroHumor(dto, 999)
+	resultado, err := servico.CriarRegistroHumor(&dto, 999)
 
 	assert.Error(t, err)
 	assert.Equal(t, dominio.ErrUsuarioNaoEncontrado, err)
@@ -227,21 +315,21 @@ func TestRegistroHumorServico_CriarRegistroHumor_ErroAoBuscarPaciente(t *testing
 	mockUsuarioRepo := new(MockUsuarioRepositorioRH)
 	mockAnaliseServico := new(MockAnaliseServico)
 
-	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, mockAnaliseServico)
+	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, nil, nil, sqlite_repo.NovoGormAutoCuidadoRepositorio(db), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db), mockAnaliseServico, 0, 0)
 
 	dto := dtos.CriarRegistroHumorDTOIn{
 		NivelHumor:       4,
-		HorasSono:        8,
+		HorasSono:        horasSono(8),
 		NivelEnergia:     7,
 		NivelStress:      3,
-		AutoCuidado:      "Exercício físico",
+		AutoCuidado:      []string{"Exercício físico"},
 		DataHoraRegistro: time.Now(),
 	}
 
 	erroGenerico := errors.New("erro de conexão com banco de dados")
 	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(nil, erroGenerico)
 
-	resultado, err := servico.CriarRegistroHumor(dto, 10)
+	resultado, err := servico.CriarRegistroHumor(&dto, 10)
 
 	assert.Error(t, err)
 	assert.Equal(t, erroGenerico, err)
@@ -256,7 +344,7 @@ func TestRegistroHumorServico_CriarRegistroHumor_ValidacaoNivelHumorInvalido(t *
 	mockUsuarioRepo := new(MockUsuarioRepositorioRH)
 	mockAnaliseServico := new(MockAnaliseServico)
 
-	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, mockAnaliseServico)
+	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, nil, nil, sqlite_repo.NovoGormAutoCuidadoRepositorio(db), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db), mockAnaliseServico, 0, 0)
 
 	pacienteExistente := &dominio.Paciente{
 		ID:        1,
@@ -265,16 +353,16 @@ func TestRegistroHumorServico_CriarRegistroHumor_ValidacaoNivelHumorInvalido(t *
 
 	dto := dtos.CriarRegistroHumorDTOIn{
 		NivelHumor:       0, // Inválido
-		HorasSono:        8,
+		HorasSono:        horasSono(8),
 		NivelEnergia:     7,
 		NivelStress:      3,
-		AutoCuidado:      "Exercício físico",
+		AutoCuidado:      []string{"Exercício físico"},
 		DataHoraRegistro: time.Now(),
 	}
 
 	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(pacienteExistente, nil)
 
-	resultado, err := servico.CriarRegistroHumor(dto, 10)
+	resultado, err := servico.CriarRegistroHumor(&dto, 10)
 
 	assert.Error(t, err)
 	assert.Equal(t, dominio.ErrNivelHumorInvalido, err)
@@ -289,7 +377,7 @@ func TestRegistroHumorServico_CriarRegistroHumor_ValidacaoHorasSonoInvalido(t *t
 	mockUsuarioRepo := new(MockUsuarioRepositorioRH)
 	mockAnaliseServico := new(MockAnaliseServico)
 
-	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, mockAnaliseServico)
+	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, nil, nil, sqlite_repo.NovoGormAutoCuidadoRepositorio(db), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db), mockAnaliseServico, 0, 0)
 
 	pacienteExistente := &dominio.Paciente{
 		ID:        1,
@@ -298,16 +386,16 @@ func TestRegistroHumorServico_CriarRegistroHumor_ValidacaoHorasSonoInvalido(t *t
 
 	dto := dtos.CriarRegistroHumorDTOIn{
 		NivelHumor:       4,
-		HorasSono:        15, // Inválido
+		HorasSono:        horasSono(15), // Inválido
 		NivelEnergia:     7,
 		NivelStress:      3,
-		AutoCuidado:      "Exercício físico",
+		AutoCuidado:      []string{"Exercício físico"},
 		DataHoraRegistro: time.Now(),
 	}
 
 	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(pacienteExistente, nil)
 
-	resultado, err := servico.CriarRegistroHumor(dto, 10)
+	resultado, err := servico.CriarRegistroHumor(&dto, 10)
 
 	assert.Error(t, err)
 	assert.Equal(t, dominio.ErrHorasSonoInvalido, err)
@@ -322,7 +410,7 @@ func TestRegistroHumorServico_CriarRegistroHumor_ValidacaoNivelEnergiaInvalido(t
 	mockUsuarioRepo := new(MockUsuarioRepositorioRH)
 	mockAnaliseServico := new(MockAnaliseServico)
 
-	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, mockAnaliseServico)
+	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, nil, nil, sqlite_repo.NovoGormAutoCuidadoRepositorio(db), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db), mockAnaliseServico, 0, 0)
 
 	pacienteExistente := &dominio.Paciente{
 		ID:        1,
@@ -331,16 +419,16 @@ func TestRegistroHumorServico_CriarRegistroHumor_ValidacaoNivelEnergiaInvalido(t
 
 	dto := dtos.CriarRegistroHumorDTOIn{
 		NivelHumor:       4,
-		HorasSono:        8,
+		HorasSono:        horasSono(8),
 		NivelEnergia:     0, // Inválido
 		NivelStress:      3,
-		AutoCuidado:      "Exercício físico",
+		AutoCuidado:      []string{"Exercício físico"},
 		DataHoraRegistro: time.Now(),
 	}
 
 	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(pacienteExistente, nil)
 
-	resultado, err := servico.CriarRegistroHumor(dto, 10)
+	resultado, err := servico.CriarRegistroHumor(&dto, 10)
 
 	assert.Error(t, err)
 	assert.Equal(t, dominio.ErrNivelEnergiaInvalido, err)
@@ -355,7 +443,7 @@ func TestRegistroHumorServico_CriarRegistroHumor_ValidacaoNivelStressInvalido(t
 	mockUsuarioRepo := new(MockUsuarioRepositorioRH)
 	mockAnaliseServico := new(MockAnaliseServico)
 
-	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, mockAnaliseServico)
+	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, nil, nil, sqlite_repo.NovoGormAutoCuidadoRepositorio(db), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db), mockAnaliseServico, 0, 0)
 
 	pacienteExistente := &dominio.Paciente{
 		ID:        1,
@@ -364,16 +452,16 @@ func TestRegistroHumorServico_CriarRegistroHumor_ValidacaoNivelStressInvalido(t
 
 	dto := dtos.CriarRegistroHumorDTOIn{
 		NivelHumor:       4,
-		HorasSono:        8,
+		HorasSono:        horasSono(8),
 		NivelEnergia:     7,
 		NivelStress:      11, // Inválido
-		AutoCuidado:      "Exercício físico",
+		AutoCuidado:      []string{"Exercício físico"},
 		DataHoraRegistro: time.Now(),
 	}
 
 	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(pacienteExistente, nil)
 
-	resultado, err := servico.CriarRegistroHumor(dto, 10)
+	resultado, err := servico.CriarRegistroHumor(&dto, 10)
 
 	assert.Error(t, err)
 	assert.Equal(t, dominio.ErrNivelStressInvalido, err)
@@ -382,13 +470,13 @@ func TestRegistroHumorServico_CriarRegistroHumor_ValidacaoNivelStressInvalido(t
 	mockRegistroHumorRepo.AssertNotCalled(t, "CriarRegistroHumor")
 }
 
-func TestRegistroHumorServico_CriarRegistroHumor_ValidacaoAutoCuidadoVazio(t *testing.T) {
+func TestRegistroHumorServico_CriarRegistroHumor_ValidacaoAutoCuidadoForaDoCatalogo(t *testing.T) {
 	db := setupTestDBRegistroHumor(t)
 	mockRegistroHumorRepo := new(MockRegistroHumorRepositorio)
 	mockUsuarioRepo := new(MockUsuarioRepositorioRH)
 	mockAnaliseServico := new(MockAnaliseServico)
 
-	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, mockAnaliseServico)
+	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, nil, nil, sqlite_repo.NovoGormAutoCuidadoRepositorio(db), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db), mockAnaliseServico, 0, 0)
 
 	pacienteExistente := &dominio.Paciente{
 		ID:        1,
@@ -397,19 +485,19 @@ func TestRegistroHumorServico_CriarRegistroHumor_ValidacaoAutoCuidadoVazio(t *te
 
 	dto := dtos.CriarRegistroHumorDTOIn{
 		NivelHumor:       4,
-		HorasSono:        8,
+		HorasSono:        horasSono(8),
 		NivelEnergia:     7,
 		NivelStress:      3,
-		AutoCuidado:      "", // Inválido
+		AutoCuidado:      []string{"Natação"}, // Fora do catálogo
 		DataHoraRegistro: time.Now(),
 	}
 
 	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(pacienteExistente, nil)
 
-	resultado, err := servico.CriarRegistroHumor(dto, 10)
+	resultado, err := servico.CriarRegistroHumor(&dto, 10)
 
 	assert.Error(t, err)
-	assert.Equal(t, dominio.ErrAutoCuidadoVazio, err)
+	assert.Equal(t, dominio.ErrAtividadeAutoCuidadoNaoEncontrada, err)
 	assert.Nil(t, resultado)
 	mockUsuarioRepo.AssertExpectations(t)
 	mockRegistroHumorRepo.AssertNotCalled(t, "CriarRegistroHumor")
@@ -421,7 +509,7 @@ func TestRegistroHumorServico_CriarRegistroHumor_ValidacaoDataHoraRegistroVazia(
 	mockUsuarioRepo := new(MockUsuarioRepositorioRH)
 	mockAnaliseServico := new(MockAnaliseServico)
 
-	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, mockAnaliseServico)
+	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, nil, nil, sqlite_repo.NovoGormAutoCuidadoRepositorio(db), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db), mockAnaliseServico, 0, 0)
 
 	pacienteExistente := &dominio.Paciente{
 		ID:        1,
@@ -430,16 +518,16 @@ func TestRegistroHumorServico_CriarRegistroHumor_ValidacaoDataHoraRegistroVazia(
 
 	dto := dtos.CriarRegistroHumorDTOIn{
 		NivelHumor:       4,
-		HorasSono:        8,
+		HorasSono:        horasSono(8),
 		NivelEnergia:     7,
 		NivelStress:      3,
-		AutoCuidado:      "Exercício físico",
+		AutoCuidado:      []string{"Exercício físico"},
 		DataHoraRegistro: time.Time{}, // Inválido
 	}
 
 	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(pacienteExistente, nil)
 
-	resultado, err := servico.CriarRegistroHumor(dto, 10)
+	resultado, err := servico.CriarRegistroHumor(&dto, 10)
 
 	assert.Error(t, err)
 	assert.Equal(t, dominio.ErrDataHoraRegistroVazia, err)
@@ -454,7 +542,7 @@ func TestRegistroHumorServico_CriarRegistroHumor_ErroAoCriarRegistro(t *testing.
 	mockUsuarioRepo := new(MockUsuarioRepositorioRH)
 	mockAnaliseServico := new(MockAnaliseServico)
 
-	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, mockAnaliseServico)
+	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, nil, nil, sqlite_repo.NovoGormAutoCuidadoRepositorio(db), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db), mockAnaliseServico, 0, 0)
 
 	pacienteExistente := &dominio.Paciente{
 		ID:        1,
@@ -463,10 +551,10 @@ func TestRegistroHumorServico_CriarRegistroHumor_ErroAoCriarRegistro(t *testing.
 
 	dto := dtos.CriarRegistroHumorDTOIn{
 		NivelHumor:       4,
-		HorasSono:        8,
+		HorasSono:        horasSono(8),
 		NivelEnergia:     7,
 		NivelStress:      3,
-		AutoCuidado:      "Exercício físico",
+		AutoCuidado:      []string{"Exercício físico"},
 		DataHoraRegistro: time.Now(),
 	}
 
@@ -474,7 +562,7 @@ func TestRegistroHumorServico_CriarRegistroHumor_ErroAoCriarRegistro(t *testing.
 	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(pacienteExistente, nil)
 	mockRegistroHumorRepo.On("CriarRegistroHumor", mock.Anything, mock.AnythingOfType("*dominio.RegistroHumor")).Return(erroGenerico)
 
-	resultado, err := servico.CriarRegistroHumor(dto, 10)
+	resultado, err := servico.CriarRegistroHumor(&dto, 10)
 
 	assert.Error(t, err)
 	assert.Equal(t, erroGenerico, err)
@@ -491,7 +579,7 @@ func TestRegistroHumorServico_CriarRegistroHumor_ComObservacoes(t *testing.T) {
 
 	mockAnaliseServico.On("ExecutarMonitoramento", mock.Anything).Return(nil).Maybe()
 
-	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, mockAnaliseServico)
+	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, nil, nil, sqlite_repo.NovoGormAutoCuidadoRepositorio(db), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db), mockAnaliseServico, 0, 0)
 
 	pacienteExistente := &dominio.Paciente{
 		ID:        1,
@@ -500,10 +588,10 @@ func TestRegistroHumorServico_CriarRegistroHumor_ComObservacoes(t *testing.T) {
 
 	dto := dtos.CriarRegistroHumorDTOIn{
 		NivelHumor:       5,
-		HorasSono:        9,
+		HorasSono:        horasSono(9),
 		NivelEnergia:     8,
 		NivelStress:      2,
-		AutoCuidado:      "Meditação e yoga",
+		AutoCuidado:      []string{"Meditação e yoga"},
 		Observacoes:      "Excelente dia, me senti muito bem após a sessão de terapia",
 		DataHoraRegistro: time.Now(),
 	}
@@ -511,7 +599,7 @@ func TestRegistroHumorServico_CriarRegistroHumor_ComObservacoes(t *testing.T) {
 	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(pacienteExistente, nil)
 	mockRegistroHumorRepo.On("CriarRegistroHumor", mock.Anything, mock.AnythingOfType("*dominio.RegistroHumor")).Return(nil)
 
-	resultado, err := servico.CriarRegistroHumor(dto, 10)
+	resultado, err := servico.CriarRegistroHumor(&dto, 10)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resultado)
@@ -528,7 +616,7 @@ func TestRegistroHumorServico_CriarRegistroHumor_ValoresMinimos(t *testing.T) {
 
 	mockAnaliseServico.On("ExecutarMonitoramento", mock.Anything).Return(nil).Maybe()
 
-	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, mockAnaliseServico)
+	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, nil, nil, sqlite_repo.NovoGormAutoCuidadoRepositorio(db), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db), mockAnaliseServico, 0, 0)
 
 	pacienteExistente := &dominio.Paciente{
 		ID:        1,
@@ -537,17 +625,17 @@ func TestRegistroHumorServico_CriarRegistroHumor_ValoresMinimos(t *testing.T) {
 
 	dto := dtos.CriarRegistroHumorDTOIn{
 		NivelHumor:       1,
-		HorasSono:        0,
+		HorasSono:        horasSono(0),
 		NivelEnergia:     1,
 		NivelStress:      1,
-		AutoCuidado:      "Nenhum",
+		AutoCuidado:      []string{},
 		DataHoraRegistro: time.Now(),
 	}
 
 	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(pacienteExistente, nil)
 	mockRegistroHumorRepo.On("CriarRegistroHumor", mock.Anything, mock.AnythingOfType("*dominio.RegistroHumor")).Return(nil)
 
-	resultado, err := servico.CriarRegistroHumor(dto, 10)
+	resultado, err := servico.CriarRegistroHumor(&dto, 10)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resultado)
@@ -567,7 +655,7 @@ func TestRegistroHumorServico_CriarRegistroHumor_ValoresMaximos(t *testing.T) {
 
 	mockAnaliseServico.On("ExecutarMonitoramento", mock.Anything).Return(nil).Maybe()
 
-	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, mockAnaliseServico)
+	servico := servicos.NovoRegistroHumorServico(db, mockRegistroHumorRepo, mockUsuarioRepo, nil, nil, sqlite_repo.NovoGormAutoCuidadoRepositorio(db), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db), mockAnaliseServico, 0, 0)
 
 	pacienteExistente := &dominio.Paciente{
 		ID:        1,
@@ -576,17 +664,17 @@ func TestRegistroHumorServico_CriarRegistroHumor_ValoresMaximos(t *testing.T) {
 
 	dto := dtos.CriarRegistroHumorDTOIn{
 		NivelHumor:       5,
-		HorasSono:        12,
+		HorasSono:        horasSono(12),
 		NivelEnergia:     10,
 		NivelStress:      10,
-		AutoCuidado:      "Todas as atividades possíveis",
+		AutoCuidado:      []string{"Exercício físico", "Meditação e yoga"},
 		DataHoraRegistro: time.Now(),
 	}
 
 	mockUsuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(pacienteExistente, nil)
 	mockRegistroHumorRepo.On("CriarRegistroHumor", mock.Anything, mock.AnythingOfType("*dominio.RegistroHumor")).Return(nil)
 
-	resultado, err := servico.CriarRegistroHumor(dto, 10)
+	resultado, err := servico.CriarRegistroHumor(&dto, 10)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resultado)
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func itemSincronizacao(chave string, nivelHumor int16, dataHora time.Time) dtos.ItemSincronizacaoRegistroHumorDTOIn {
+	dto := correcaoHumor(nivelHumor)
+	dto.DataHoraRegistro = dataHora
+	return dtos.ItemSincronizacaoRegistroHumorDTOIn{ChaveIdempotencia: chave, CriarRegistroHumorDTOIn: *dto}
+}
+
+func TestRegistroHumorServico_SincronizarRegistrosHumor_ResultadoPorItem(t *testing.T) {
+	svc, db, monitoramento := setupCorrecaoRegistroHumor(t)
+	agora := time.Now()
+
+	lote := &dtos.SincronizarRegistrosHumorDTOIn{Registros: []dtos.ItemSincronizacaoRegistroHumorDTOIn{
+		itemSincronizacao("a1", 3, agora.Add(-48*time.Hour)),
+		// Mesmo conteudo com outra chave e um registro distinto
+		itemSincronizacao("a2", 3, agora.Add(-48*time.Hour)),
+		itemSincronizacao("a1", 4, agora.Add(-47*time.Hour)),
+		itemSincronizacao("a3", 9, agora.Add(-time.Hour)),
+		itemSincronizacao("a4", 2, agora.Add(-8*24*time.Hour)),
+		itemSincronizacao("", 2, agora.Add(-time.Hour)),
+	}}
+
+	saida, err := svc.SincronizarRegistrosHumor(10, lote)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, saida.Criados)
+	assert.Equal(t, 1, saida.Duplicados)
+	assert.Equal(t, 3, saida.Invalidos)
+
+	status := make([]string, 0, len(saida.Resultados))
+	for _, resultado := range saida.Resultados {
+		status = append(status, resultado.Status)
+	}
+	assert.Equal(t, []string{
+		servicos.StatusSincronizacaoCriado, servicos.StatusSincronizacaoCriado, servicos.StatusSincronizacaoDuplicado,
+		servicos.StatusSincronizacaoInvalido, servicos.StatusSincronizacaoInvalido, servicos.StatusSincronizacaoInvalido,
+	}, status)
+	assert.Equal(t, saida.Resultados[0].Registro.ID, saida.Resultados[2].Registro.ID)
+	assert.Equal(t, dominio.ErrNivelHumorInvalido.Error(), saida.Resultados[3].Erro)
+	assert.Equal(t, dominio.ErrDataHoraRegistroAntiga.Error(), saida.Resultados[4].Erro)
+	assert.Equal(t, dominio.ErrChaveIdempotenciaInvalida.Error(), saida.Resultados[5].Erro)
+
+	// O monitoramento roda uma vez para o lote inteiro
+	assert.Equal(t, uint(1), monitoramento.esperar(t))
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, monitoramento.pacientes, 0)
+
+	var total int64
+	db.Model(&dominio.RegistroHumor{}).Count(&total)
+	assert.Equal(t, int64(2), total)
+}
+
+func TestRegistroHumorServico_SincronizarRegistrosHumor_ReenvioNaoDuplica(t *testing.T) {
+	svc, db, monitoramento := setupCorrecaoRegistroHumor(t)
+	lote := &dtos.SincronizarRegistrosHumorDTOIn{Registros: []dtos.ItemSincronizacaoRegistroHumorDTOIn{
+		itemSincronizacao("b1", 3, time.Now().Add(-time.Hour)),
+	}}
+
+	primeira, err := svc.SincronizarRegistrosHumor(10, lote)
+	assert.NoError(t, err)
+	monitoramento.esperar(t)
+
+	reenvio, err := svc.SincronizarRegistrosHumor(10, lote)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, reenvio.Criados)
+	assert.Equal(t, servicos.StatusSincronizacaoDuplicado, reenvio.Resultados[0].Status)
+	assert.Equal(t, primeira.Resultados[0].Registro.ID, reenvio.Resultados[0].Registro.ID)
+
+	// A chave vale por paciente
+	outro, err := svc.SincronizarRegistrosHumor(20, lote)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, outro.Criados)
+	monitoramento.esperar(t)
+
+	var total int64
+	db.Model(&dominio.RegistroHumor{}).Count(&total)
+	assert.Equal(t, int64(2), total)
+}
+
+func TestRegistroHumorServico_SincronizarRegistrosHumor_LoteInvalido(t *testing.T) {
+	svc, _, _ := setupCorrecaoRegistroHumor(t)
+
+	_, err := svc.SincronizarRegistrosHumor(10, &dtos.SincronizarRegistrosHumorDTOIn{})
+	assert.Equal(t, dominio.ErrLoteRegistrosHumorInvalido, err)
+
+	grande := make([]dtos.ItemSincronizacaoRegistroHumorDTOIn, dominio.TamanhoMaximoLoteRegistrosHumor+1)
+	_, err = svc.SincronizarRegistrosHumor(10, &dtos.SincronizarRegistrosHumorDTOIn{Registros: grande})
+	assert.Equal(t, dominio.ErrLoteRegistrosHumorInvalido, err)
+}
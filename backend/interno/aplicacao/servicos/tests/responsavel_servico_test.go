@@ -0,0 +1,331 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// ========== Mocks ==========
+
+// MockResponsavelRepositorio simula o repositorio de responsaveis
+type MockResponsavelRepositorio struct {
+	mock.Mock
+}
+
+func (m *MockResponsavelRepositorio) CriarResponsavel(tx *gorm.DB, responsavel *dominio.Responsavel) error {
+	args := m.Called(tx, responsavel)
+	return args.Error(0)
+}
+
+func (m *MockResponsavelRepositorio) BuscarResponsavelPorUsuarioID(tx *gorm.DB, usuarioID uint) (*dominio.Responsavel, error) {
+	args := m.Called(tx, usuarioID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dominio.Responsavel), args.Error(1)
+}
+
+func (m *MockResponsavelRepositorio) CriarVinculoResponsavel(tx *gorm.DB, vinculo *dominio.VinculoResponsavel) error {
+	args := m.Called(tx, vinculo)
+	return args.Error(0)
+}
+
+func (m *MockResponsavelRepositorio) AtualizarVinculoResponsavel(tx *gorm.DB, vinculo *dominio.VinculoResponsavel) error {
+	args := m.Called(tx, vinculo)
+	return args.Error(0)
+}
+
+func (m *MockResponsavelRepositorio) BuscarVinculoResponsavel(tx *gorm.DB, responsavelID, pacienteID uint) (*dominio.VinculoResponsavel, error) {
+	args := m.Called(tx, responsavelID, pacienteID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dominio.VinculoResponsavel), args.Error(1)
+}
+
+func (m *MockResponsavelRepositorio) ListarDependentes(tx *gorm.DB, responsavelID uint) ([]*dominio.VinculoResponsavel, error) {
+	args := m.Called(tx, responsavelID)
+	return args.Get(0).([]*dominio.VinculoResponsavel), args.Error(1)
+}
+
+func (m *MockResponsavelRepositorio) ListarResponsaveisDoPaciente(tx *gorm.DB, pacienteID uint) ([]*dominio.VinculoResponsavel, error) {
+	args := m.Called(tx, pacienteID)
+	return args.Get(0).([]*dominio.VinculoResponsavel), args.Error(1)
+}
+
+func (m *MockResponsavelRepositorio) CriarAcao(tx *gorm.DB, acao *dominio.AcaoResponsavel) error {
+	args := m.Called(tx, acao)
+	return args.Error(0)
+}
+
+func (m *MockResponsavelRepositorio) ListarAcoesSobrePaciente(tx *gorm.DB, pacienteID uint, limite int) ([]*dominio.AcaoResponsavel, error) {
+	args := m.Called(tx, pacienteID, limite)
+	return args.Get(0).([]*dominio.AcaoResponsavel), args.Error(1)
+}
+
+// MockInstrumentoRepositorioResponsavel simula a busca de atribuicoes
+type MockInstrumentoRepositorioResponsavel struct {
+	mock.Mock
+}
+
+func (m *MockInstrumentoRepositorioResponsavel) BuscarTodosAtivos(tx *gorm.DB) ([]*dominio.Instrumento, error) {
+	return nil, nil
+}
+
+func (m *MockInstrumentoRepositorioResponsavel) BuscarInstrumentoPorID(tx *gorm.DB, instrumentoID uint) (*dominio.Instrumento, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MockInstrumentoRepositorioResponsavel) CriarAtribuicao(tx *gorm.DB, atribuicao *dominio.Atribuicao) error {
+	return nil
+}
+
+func (m *MockInstrumentoRepositorioResponsavel) BuscarAtribuicoesPaciente(tx *gorm.DB, pacId uint) ([]*dominio.Atribuicao, error) {
+	return nil, nil
+}
+
+func (m *MockInstrumentoRepositorioResponsavel) BuscarAtribuicoesProfissional(tx *gorm.DB, pacId uint) ([]*dominio.Atribuicao, error) {
+	return nil, nil
+}
+
+func (m *MockInstrumentoRepositorioResponsavel) BuscarAtribuicaoPorID(tx *gorm.DB, atribuicaoID uint) (*dominio.Atribuicao, error) {
+	args := m.Called(tx, atribuicaoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dominio.Atribuicao), args.Error(1)
+}
+
+func (m *MockInstrumentoRepositorioResponsavel) CriarReposta(tx *gorm.DB, resposta *dominio.Resposta, atribuicaoId uint) error {
+	return nil
+}
+
+func (m *MockInstrumentoRepositorioResponsavel) BuscarRespostaPorAtribuicaoID(tx *gorm.DB, atribuicaoID uint) (*dominio.Resposta, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MockInstrumentoRepositorioResponsavel) BuscarRespostaCompletaPorAtribuicaoID(tx *gorm.DB, atribuicaoID uint) (*dominio.Resposta, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+// MockResumoServico simula o resumo do paciente
+type MockResumoServico struct {
+	mock.Mock
+}
+
+func (m *MockResumoServico) GerarResumoPaciente(userID uint) (*dtos.ResumoPacienteDTOOut, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dtos.ResumoPacienteDTOOut), args.Error(1)
+}
+
+// MockInstrumentoServico simula os questionarios vistos pelo paciente
+type MockInstrumentoServico struct {
+	mock.Mock
+}
+
+func (m *MockInstrumentoServico) ListarInstrumentos(userID uint) ([]*dtos.InstrumentoDTOOut, error) {
+	return nil, nil
+}
+
+func (m *MockInstrumentoServico) CriarAtribuicao(userID, pacienteID, instrumentoID uint, instrumentoCodigo string) error {
+	return nil
+}
+
+func (m *MockInstrumentoServico) ListarAtribuicoesProfissional(profId uint) ([]*dtos.AtribuicaoDTOOut, error) {
+	return nil, nil
+}
+
+func (m *MockInstrumentoServico) ListarAtribuicoesPaciente(pacId uint) ([]*dtos.AtribuicaoDTOOut, error) {
+	args := m.Called(pacId)
+	return args.Get(0).([]*dtos.AtribuicaoDTOOut), args.Error(1)
+}
+
+func (m *MockInstrumentoServico) ListarPerguntasAtribuicao(usuarioId, atribuicaoId uint) (*dtos.AtribuicaoDTOOut, error) {
+	args := m.Called(usuarioId, atribuicaoId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dtos.AtribuicaoDTOOut), args.Error(1)
+}
+
+func (m *MockInstrumentoServico) CriarRespostasAtribuicao(dto *dtos.RegistroRespostaDTOIn) error {
+	args := m.Called(dto)
+	return args.Error(0)
+}
+
+func (m *MockInstrumentoServico) VisualizarRespostaAtribuicao(usuarioId, atribuicaoId uint) (*dtos.RespostaDetalhadaDTOOut, error) {
+	return nil, nil
+}
+
+// ========== Helpers ==========
+
+type dependenciasResponsavel struct {
+	usuarioRepo       *MockUsuarioRepositorio
+	responsavelRepo   *MockResponsavelRepositorio
+	consentimentoRepo *MockConsentimentoRepositorio
+	instrumentoRepo   *MockInstrumentoRepositorioResponsavel
+	notificacaoRepo   *MockNotificacaoRepositorio
+	resumo            *MockResumoServico
+	instrumento       *MockInstrumentoServico
+}
+
+func setupResponsavel(t *testing.T) (servicos.ResponsavelServico, *dependenciasResponsavel) {
+	deps := &dependenciasResponsavel{
+		usuarioRepo:       new(MockUsuarioRepositorio),
+		responsavelRepo:   new(MockResponsavelRepositorio),
+		consentimentoRepo: new(MockConsentimentoRepositorio),
+		instrumentoRepo:   new(MockInstrumentoRepositorioResponsavel),
+		notificacaoRepo:   new(MockNotificacaoRepositorio),
+		resumo:            new(MockResumoServico),
+		instrumento:       new(MockInstrumentoServico),
+	}
+	servico := servicos.NovoResponsavelServico(setupTestDB(t), deps.usuarioRepo, deps.responsavelRepo, deps.consentimentoRepo,
+		deps.instrumentoRepo, deps.notificacaoRepo, new(MockVerificacaoEmailServico), deps.resumo, deps.instrumento)
+	return servico, deps
+}
+
+// vinculoDependenteAtivo prepara o responsavel 5 (usuario 40) acompanhando o paciente 3 (usuario 10)
+func vinculoDependenteAtivo(deps *dependenciasResponsavel) *dominio.VinculoResponsavel {
+	vinculo := &dominio.VinculoResponsavel{
+		ResponsavelID: 5,
+		PacienteID:    3,
+		Paciente: dominio.Paciente{
+			ID:             3,
+			UsuarioID:      10,
+			DataNascimento: time.Now().AddDate(-12, 0, 0),
+			Usuario:        dominio.Usuario{ID: 10, Nome: "Ana"},
+		},
+		VinculadoEm: time.Now().AddDate(0, -1, 0),
+	}
+	deps.responsavelRepo.On("BuscarResponsavelPorUsuarioID", mock.Anything, uint(40)).Return(&dominio.Responsavel{ID: 5, UsuarioID: 40}, nil)
+	deps.responsavelRepo.On("BuscarVinculoResponsavel", mock.Anything, uint(5), uint(3)).Return(vinculo, nil)
+	return vinculo
+}
+
+// ========== Testes do Serviço ==========
+
+func TestResponsavelServico_AutorizarResponsavel_CriaVinculoENotifica(t *testing.T) {
+	servico, deps := setupResponsavel(t)
+
+	deps.usuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{
+		ID: 3, UsuarioID: 10, DataNascimento: time.Now().AddDate(-12, 0, 0), Usuario: dominio.Usuario{Nome: "Ana"},
+	}, nil)
+	deps.usuarioRepo.On("BuscarPorEmail", "mae@email.com").Return(&dominio.Usuario{ID: 40, TipoUsuario: dominio.TipoUsuarioResponsavel}, nil)
+	deps.responsavelRepo.On("BuscarResponsavelPorUsuarioID", mock.Anything, uint(40)).Return(&dominio.Responsavel{ID: 5, UsuarioID: 40}, nil)
+	deps.responsavelRepo.On("BuscarVinculoResponsavel", mock.Anything, uint(5), uint(3)).Return(nil, gorm.ErrRecordNotFound)
+	deps.responsavelRepo.On("CriarVinculoResponsavel", mock.Anything, mock.MatchedBy(func(v *dominio.VinculoResponsavel) bool {
+		return v.ResponsavelID == 5 && v.PacienteID == 3 && v.Parentesco == "mae"
+	})).Return(nil).Once()
+	deps.notificacaoRepo.On("CriarNotificacao", mock.Anything, mock.MatchedBy(func(n *dominio.Notificacao) bool {
+		return n.UsuarioID == 40
+	})).Return(nil).Once()
+
+	vinculoOut, err := servico.AutorizarResponsavel(10, &dtos.AutorizarResponsavelDTOIn{Email: "mae@email.com", Parentesco: " mae "})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(5), vinculoOut.ResponsavelID)
+	assert.Equal(t, "Ana", vinculoOut.NomeDependente)
+	assert.True(t, vinculoOut.MenorDeIdade)
+	deps.responsavelRepo.AssertExpectations(t)
+	deps.notificacaoRepo.AssertExpectations(t)
+}
+
+func TestResponsavelServico_AutorizarResponsavel_PacienteAdultoIndependente(t *testing.T) {
+	servico, deps := setupResponsavel(t)
+
+	deps.usuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{
+		ID: 3, UsuarioID: 10, DataNascimento: time.Now().AddDate(-30, 0, 0),
+	}, nil)
+
+	_, err := servico.AutorizarResponsavel(10, &dtos.AutorizarResponsavelDTOIn{Email: "mae@email.com"})
+
+	assert.Equal(t, dominio.ErrPacienteNaoDependente, err)
+	deps.responsavelRepo.AssertNotCalled(t, "CriarVinculoResponsavel", mock.Anything, mock.Anything)
+}
+
+func TestResponsavelServico_RemoverResponsavel_MenorNaoRemove(t *testing.T) {
+	servico, deps := setupResponsavel(t)
+
+	paciente := &dominio.Paciente{ID: 3, UsuarioID: 10, DataNascimento: time.Now().AddDate(-15, 0, 0)}
+	deps.usuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(paciente, nil)
+	deps.responsavelRepo.On("BuscarVinculoResponsavel", mock.Anything, uint(5), uint(3)).
+		Return(&dominio.VinculoResponsavel{ResponsavelID: 5, PacienteID: 3, VinculadoEm: time.Now()}, nil)
+
+	err := servico.RemoverResponsavel(10, 5)
+
+	assert.Equal(t, dominio.ErrMenorNaoRemoveResponsavel, err)
+	deps.responsavelRepo.AssertNotCalled(t, "AtualizarVinculoResponsavel", mock.Anything, mock.Anything)
+}
+
+func TestResponsavelServico_ResumoDependente_OcultaAnotacaoERegistraAcao(t *testing.T) {
+	servico, deps := setupResponsavel(t)
+	vinculoDependenteAtivo(deps)
+
+	deps.resumo.On("GerarResumoPaciente", uint(10)).Return(&dtos.ResumoPacienteDTOOut{Humor: 4, Anotacao: "texto do diario"}, nil)
+	deps.responsavelRepo.On("CriarAcao", mock.Anything, mock.MatchedBy(func(a *dominio.AcaoResponsavel) bool {
+		return a.ResponsavelID == 5 && a.PacienteID == 3 && a.Acao == dominio.AcaoResponsavelVerResumo
+	})).Return(nil).Once()
+
+	resumoOut, err := servico.ResumoDependente(40, 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int16(4), resumoOut.Humor)
+	assert.Empty(t, resumoOut.Anotacao)
+	deps.responsavelRepo.AssertExpectations(t)
+}
+
+func TestResponsavelServico_ResumoDependente_VinculoEncerrado(t *testing.T) {
+	servico, deps := setupResponsavel(t)
+	vinculo := vinculoDependenteAtivo(deps)
+	encerradoEm := time.Now().AddDate(0, 0, -1)
+	vinculo.EncerradoEm = &encerradoEm
+
+	_, err := servico.ResumoDependente(40, 3)
+
+	assert.Equal(t, dominio.ErrDependenteNaoEncontrado, err)
+	deps.resumo.AssertNotCalled(t, "GerarResumoPaciente", mock.Anything)
+}
+
+func TestResponsavelServico_ResponderAtribuicaoDependente_AtribuicaoDeOutroPaciente(t *testing.T) {
+	servico, deps := setupResponsavel(t)
+	vinculoDependenteAtivo(deps)
+
+	deps.instrumentoRepo.On("BuscarAtribuicaoPorID", mock.Anything, uint(90)).Return(&dominio.Atribuicao{ID: 90, PacienteID: 4}, nil)
+
+	err := servico.ResponderAtribuicaoDependente(40, 3, &dtos.RegistroRespostaDTOIn{AtribuicaoID: 90})
+
+	assert.Equal(t, dominio.ErrAtribuicaoDeOutroPaciente, err)
+	deps.instrumento.AssertNotCalled(t, "CriarRespostasAtribuicao", mock.Anything)
+	deps.responsavelRepo.AssertNotCalled(t, "CriarAcao", mock.Anything, mock.Anything)
+}
+
+func TestResponsavelServico_AtualizarConsentimentoDependente_AmpliaERegistraAcao(t *testing.T) {
+	servico, deps := setupResponsavel(t)
+	vinculoDependenteAtivo(deps)
+
+	atual := dominio.NovoConsentimentoPadrao(3, 7, time.Now().AddDate(0, -1, 0)).Revogar()
+	deps.consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(3), uint(7)).Return(atual, nil)
+	deps.consentimentoRepo.On("CriarConsentimento", mock.Anything, mock.AnythingOfType("*dominio.Consentimento")).Return(nil)
+	deps.responsavelRepo.On("CriarAcao", mock.Anything, mock.MatchedBy(func(a *dominio.AcaoResponsavel) bool {
+		return a.Acao == dominio.AcaoResponsavelAtualizarConsentimento
+	})).Return(nil).Once()
+
+	consentimentoOut, err := servico.AtualizarConsentimentoDependente(40, 3, 7, &dtos.AtualizarConsentimentoDTOIn{
+		Categorias: []string{dominio.CategoriaHumor, dominio.CategoriaQuestionarios},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, atual.Versao+1, consentimentoOut.Versao)
+	assert.True(t, consentimentoOut.Vigente)
+	deps.responsavelRepo.AssertExpectations(t)
+}
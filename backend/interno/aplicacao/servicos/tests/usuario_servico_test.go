@@ -1,10 +1,12 @@
 package tests
 
 import (
-	"errors"
+	"bytes"
 	"mindtrace/backend/interno/aplicacao/dtos"
 	"mindtrace/backend/interno/aplicacao/servicos"
 	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/cifragem"
+	"mindtrace/backend/interno/persistencia/memoria"
 	"testing"
 	"time"
 
@@ -22,6 +24,14 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatalf("failed to connect to test database: %v", err)
 	}
+	// CPF, contato e os demais campos cifrados so sao gravados com o envelope configurado
+	provedor, err := cifragem.NovoProvedorChaveUnica("teste", bytes.Repeat([]byte{7}, cifragem.TamanhoChave))
+	if err != nil {
+		t.Fatalf("failed to create test encryption key: %v", err)
+	}
+	if err := cifragem.Registrar(db, cifragem.NovoEnvelope(provedor)); err != nil {
+		t.Fatalf("failed to register field encryption: %v", err)
+	}
 	return db
 }
 
@@ -54,6 +64,14 @@ func (m *MockUsuarioRepositorio) CriarPaciente(tx *gorm.DB, paciente *dominio.Pa
 	return args.Error(0)
 }
 
+func (m *MockUsuarioRepositorio) BuscarPorCPF(cpf string) (*dominio.Usuario, error) {
+	args := m.Called(cpf)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dominio.Usuario), args.Error(1)
+}
+
 func (m *MockUsuarioRepositorio) BuscarUsuarioPorID(userID uint) (*dominio.Usuario, error) {
 	args := m.Called(userID)
 	if args.Get(0) == nil {
@@ -117,9 +135,76 @@ func (m *MockUsuarioRepositorio) BuscarPacientesDoProfissional(tx *gorm.DB, prof
 	return args.Get(0).([]dominio.Paciente), args.Error(1)
 }
 
-func (m *MockUsuarioRepositorio) DeletarUsuario(tx *gorm.DB, userID uint) error {
-	args := m.Called(tx, userID)
-	return args.Error(0)
+// MockVerificacaoEmailServico simula o envio do e-mail de verificacao no cadastro
+type MockVerificacaoEmailServico struct{}
+
+func (m *MockVerificacaoEmailServico) IniciarVerificacao(usuario *dominio.Usuario) error {
+	return nil
+}
+
+func (m *MockVerificacaoEmailServico) ConfirmarEmail(token string) error {
+	return nil
+}
+
+func (m *MockVerificacaoEmailServico) ReenviarVerificacao(email string) error {
+	return nil
+}
+
+// chavesJWTTeste assina os tokens emitidos nos testes de login
+var chavesJWTTeste = servicos.NovoChavesJWTHMAC([]byte("segredo-de-teste"))
+
+// MockProtecaoLoginServico nunca bloqueia e ignora o registro de tentativas
+type MockProtecaoLoginServico struct{}
+
+func (m *MockProtecaoLoginServico) VerificarBloqueio(email, ip string) error {
+	return nil
+}
+
+func (m *MockProtecaoLoginServico) RegistrarFalha(email, ip string) {}
+
+func (m *MockProtecaoLoginServico) RegistrarSucesso(email, ip string) {}
+
+// MockDoisFatoresServico simula usuarios com ou sem segundo fator ativo
+type MockDoisFatoresServico struct {
+	Ativo bool
+}
+
+func (m *MockDoisFatoresServico) IniciarInscricao(userID uint) (*dtos.InscricaoDoisFatoresDTOOut, error) {
+	return nil, nil
+}
+
+func (m *MockDoisFatoresServico) AtivarDoisFatores(userID uint, codigo string) (*dtos.CodigosRecuperacaoDTOOut, error) {
+	return nil, nil
+}
+
+func (m *MockDoisFatoresServico) DesativarDoisFatores(userID uint, dtoIn *dtos.DesativarDoisFatoresDTOIn) error {
+	return nil
+}
+
+func (m *MockDoisFatoresServico) RegenerarCodigosRecuperacao(userID uint, codigo string) (*dtos.CodigosRecuperacaoDTOOut, error) {
+	return nil, nil
+}
+
+func (m *MockDoisFatoresServico) DoisFatoresAtivo(userID uint) (bool, error) {
+	return m.Ativo, nil
+}
+
+func (m *MockDoisFatoresServico) IniciarDesafio(userID uint) (string, error) {
+	return "desafio-token", nil
+}
+
+func (m *MockDoisFatoresServico) UsuarioDoDesafio(desafioToken string) (uint, error) {
+	if desafioToken != "desafio-token" {
+		return 0, dominio.ErrDesafioDoisFatoresInvalido
+	}
+	return 1, nil
+}
+
+func (m *MockDoisFatoresServico) ConcluirDesafio(desafioToken, codigo string) (uint, error) {
+	if desafioToken != "desafio-token" || codigo != "123456" {
+		return 0, dominio.ErrCodigoDoisFatoresInvalido
+	}
+	return 1, nil
 }
 
 // ========== Testes para RegistrarProfissional ==========
@@ -127,7 +212,7 @@ func (m *MockUsuarioRepositorio) DeletarUsuario(tx *gorm.DB, userID uint) error
 func TestUsuarioServico_RegistrarProfissional_Sucesso(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	dtoIn := &dtos.RegistrarProfissionalDTOIn{
 		Nome:                 "Dr. João Silva",
@@ -140,6 +225,7 @@ func TestUsuarioServico_RegistrarProfissional_Sucesso(t *testing.T) {
 
 	// Mock: email nao cadastrado
 	mockRepo.On("BuscarPorEmail", dtoIn.Email).Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("BuscarPorCPF", dtoIn.CPF).Return(nil, gorm.ErrRecordNotFound)
 	mockRepo.On("CriarUsuario", mock.Anything, mock.AnythingOfType("*dominio.Usuario")).Run(func(args mock.Arguments) {
 		// Simula o banco atribuindo um ID
 		user := args.Get(1).(*dominio.Usuario)
@@ -164,7 +250,7 @@ func TestUsuarioServico_RegistrarProfissional_Sucesso(t *testing.T) {
 func TestUsuarioServico_RegistrarProfissional_EmailJaCadastrado(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	dtoIn := &dtos.RegistrarProfissionalDTOIn{
 		Nome:                 "Dr. João Silva",
@@ -189,7 +275,7 @@ func TestUsuarioServico_RegistrarProfissional_EmailJaCadastrado(t *testing.T) {
 func TestUsuarioServico_RegistrarProfissional_EmailInvalido(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	dtoIn := &dtos.RegistrarProfissionalDTOIn{
 		Nome:                 "Dr. João Silva",
@@ -213,7 +299,7 @@ func TestUsuarioServico_RegistrarProfissional_EmailInvalido(t *testing.T) {
 func TestUsuarioServico_RegistrarProfissional_SenhaFraca(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	dtoIn := &dtos.RegistrarProfissionalDTOIn{
 		Nome:                 "Dr. João Silva",
@@ -237,7 +323,7 @@ func TestUsuarioServico_RegistrarProfissional_SenhaFraca(t *testing.T) {
 func TestUsuarioServico_RegistrarProfissional_MenorDeIdade(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	dtoIn := &dtos.RegistrarProfissionalDTOIn{
 		Nome:                 "Dr. João Silva",
@@ -263,7 +349,7 @@ func TestUsuarioServico_RegistrarProfissional_MenorDeIdade(t *testing.T) {
 func TestUsuarioServico_RegistrarPaciente_Sucesso(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	dependente := false
 	dtoIn := &dtos.RegistrarPacienteDTOIn{
@@ -275,6 +361,7 @@ func TestUsuarioServico_RegistrarPaciente_Sucesso(t *testing.T) {
 	}
 
 	mockRepo.On("BuscarPorEmail", dtoIn.Email).Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("BuscarPorCPF", dtoIn.CPF).Return(nil, gorm.ErrRecordNotFound)
 	mockRepo.On("CriarUsuario", mock.Anything, mock.Anything).Return(nil)
 	mockRepo.On("CriarPaciente", mock.Anything, mock.Anything).Return(nil)
 
@@ -290,7 +377,7 @@ func TestUsuarioServico_RegistrarPaciente_Sucesso(t *testing.T) {
 func TestUsuarioServico_RegistrarPaciente_Dependente_Sucesso(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	dependente := true
 	dtoIn := &dtos.RegistrarPacienteDTOIn{
@@ -304,6 +391,7 @@ func TestUsuarioServico_RegistrarPaciente_Dependente_Sucesso(t *testing.T) {
 	}
 
 	mockRepo.On("BuscarPorEmail", dtoIn.Email).Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("BuscarPorCPF", dtoIn.CPF).Return(nil, gorm.ErrRecordNotFound)
 	mockRepo.On("CriarUsuario", mock.Anything, mock.Anything).Return(nil)
 	mockRepo.On("CriarPaciente", mock.Anything, mock.Anything).Return(nil)
 
@@ -318,10 +406,80 @@ func TestUsuarioServico_RegistrarPaciente_Dependente_Sucesso(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUsuarioServico_RegistrarPaciente_ComConvite(t *testing.T) {
+	mockRepo := new(MockUsuarioRepositorio)
+	mockVinculoRepo := new(MockVinculoRepositorio)
+	mockConviteRepo := new(MockConviteRepositorio)
+	mockConsentimentoRepo := new(MockConsentimentoRepositorio)
+	db := setupTestDB(t)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, mockVinculoRepo, mockConviteRepo, mockConsentimentoRepo, new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
+
+	dependente := false
+	dtoIn := &dtos.RegistrarPacienteDTOIn{
+		Nome:           "Maria Silva",
+		Email:          "maria@example.com",
+		Senha:          "Senha123!",
+		DataNascimento: time.Now().AddDate(-25, 0, 0),
+		Dependente:     &dependente,
+		TokenConvite:   "abc123def456",
+	}
+	convite := &dominio.Convite{ID: 5, ProfissionalID: 3, Token: dtoIn.TokenConvite, DataExpiracao: time.Now().Add(time.Hour), MaxUsos: 1, EmailDestinatario: "maria@example.com"}
+
+	mockRepo.On("BuscarPorEmail", dtoIn.Email).Return(nil, gorm.ErrRecordNotFound)
+	mockConviteRepo.On("BuscarConvitePorToken", mock.Anything, dtoIn.TokenConvite).Return(convite, nil)
+	mockRepo.On("BuscarPorCPF", dtoIn.CPF).Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("CriarUsuario", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("CriarPaciente", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(1).(*dominio.Paciente).ID = 7
+	})
+	mockVinculoRepo.On("BuscarVinculo", mock.Anything, uint(7), uint(3)).Return(nil, gorm.ErrRecordNotFound)
+	mockVinculoRepo.On("CriarVinculo", mock.Anything, mock.AnythingOfType("*dominio.Vinculo")).Return(nil)
+	mockConviteRepo.On("MarcarConviteComoUsado", mock.Anything, convite).Return(nil)
+	mockConsentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(7), uint(3)).Return(nil, gorm.ErrRecordNotFound)
+	mockConsentimentoRepo.On("CriarConsentimento", mock.Anything, mock.AnythingOfType("*dominio.Consentimento")).Return(nil)
+
+	result, err := servico.RegistrarPaciente(dtoIn)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.True(t, convite.Usado)
+	assert.Equal(t, uint(7), *convite.PacienteID)
+	mockConviteRepo.AssertExpectations(t)
+	mockVinculoRepo.AssertExpectations(t)
+	mockConsentimentoRepo.AssertExpectations(t)
+}
+
+func TestUsuarioServico_RegistrarPaciente_ConviteExpiradoNaoCriaConta(t *testing.T) {
+	mockRepo := new(MockUsuarioRepositorio)
+	mockConviteRepo := new(MockConviteRepositorio)
+	db := setupTestDB(t)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), mockConviteRepo, new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
+
+	dependente := false
+	dtoIn := &dtos.RegistrarPacienteDTOIn{
+		Nome:           "Maria Silva",
+		Email:          "maria@example.com",
+		Senha:          "Senha123!",
+		DataNascimento: time.Now().AddDate(-25, 0, 0),
+		Dependente:     &dependente,
+		TokenConvite:   "abc123def456",
+	}
+
+	mockRepo.On("BuscarPorEmail", dtoIn.Email).Return(nil, gorm.ErrRecordNotFound)
+	mockConviteRepo.On("BuscarConvitePorToken", mock.Anything, dtoIn.TokenConvite).
+		Return(&dominio.Convite{ID: 5, ProfissionalID: 3, Token: dtoIn.TokenConvite, DataExpiracao: time.Now().Add(-time.Hour), MaxUsos: 1}, nil)
+
+	result, err := servico.RegistrarPaciente(dtoIn)
+
+	assert.Nil(t, result)
+	assert.Equal(t, dominio.ErrConviteExpirado, err)
+	mockRepo.AssertNotCalled(t, "CriarUsuario", mock.Anything, mock.Anything)
+}
+
 func TestUsuarioServico_RegistrarPaciente_EmailJaCadastrado(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	dtoIn := &dtos.RegistrarPacienteDTOIn{
 		Nome:           "Maria Silva",
@@ -341,10 +499,35 @@ func TestUsuarioServico_RegistrarPaciente_EmailJaCadastrado(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUsuarioServico_RegistrarPaciente_CPFJaCadastrado(t *testing.T) {
+	mockRepo := new(MockUsuarioRepositorio)
+	db := setupTestDB(t)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
+
+	dependente := false
+	dtoIn := &dtos.RegistrarPacienteDTOIn{
+		Nome:           "Maria Silva",
+		Email:          "maria@example.com",
+		Senha:          "Senha123!",
+		CPF:            "12345678909",
+		DataNascimento: time.Now().AddDate(-25, 0, 0),
+		Dependente:     &dependente,
+	}
+
+	mockRepo.On("BuscarPorEmail", dtoIn.Email).Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("BuscarPorCPF", dtoIn.CPF).Return(&dominio.Usuario{ID: 1}, nil)
+
+	result, err := servico.RegistrarPaciente(dtoIn)
+
+	assert.Equal(t, dominio.ErrCPFJaCadastrado, err)
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "CriarUsuario", mock.Anything, mock.Anything)
+}
+
 func TestUsuarioServico_RegistrarPaciente_DependenteSemResponsavel(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	dependente := true
 	dtoIn := &dtos.RegistrarPacienteDTOIn{
@@ -370,7 +553,32 @@ func TestUsuarioServico_RegistrarPaciente_DependenteSemResponsavel(t *testing.T)
 func TestUsuarioServico_Login_Sucesso(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
+
+	senha := "Senha123!"
+	hashSenha, _ := bcrypt.GenerateFromPassword([]byte(senha), bcrypt.DefaultCost)
+
+	usuario := &dominio.Usuario{
+		ID:          1,
+		Email:       "joao@example.com",
+		Senha:       string(hashSenha),
+		TipoUsuario: 2,
+	}
+
+	mockRepo.On("BuscarPorEmail", usuario.Email).Return(usuario, nil)
+
+	loginOut, err := servico.Login(usuario.Email, senha, "127.0.0.1")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, loginOut.Token)
+	assert.False(t, loginOut.DoisFatoresNecessario)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUsuarioServico_Login_DoisFatoresAtivo(t *testing.T) {
+	mockRepo := new(MockUsuarioRepositorio)
+	db := setupTestDB(t)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), &MockDoisFatoresServico{Ativo: true}, chavesJWTTeste)
 
 	senha := "Senha123!"
 	hashSenha, _ := bcrypt.GenerateFromPassword([]byte(senha), bcrypt.DefaultCost)
@@ -384,32 +592,113 @@ func TestUsuarioServico_Login_Sucesso(t *testing.T) {
 
 	mockRepo.On("BuscarPorEmail", usuario.Email).Return(usuario, nil)
 
-	token, err := servico.Login(usuario.Email, senha)
+	loginOut, err := servico.Login(usuario.Email, senha, "127.0.0.1")
+
+	assert.NoError(t, err)
+	assert.True(t, loginOut.DoisFatoresNecessario)
+	assert.Equal(t, "desafio-token", loginOut.DesafioToken)
+	assert.Empty(t, loginOut.Token)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUsuarioServico_ConcluirLoginDoisFatores(t *testing.T) {
+	mockRepo := new(MockUsuarioRepositorio)
+	db := setupTestDB(t)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), &MockDoisFatoresServico{Ativo: true}, chavesJWTTeste)
 
+	usuario := &dominio.Usuario{ID: 1, Email: "joao@example.com", TipoUsuario: 2}
+	mockRepo.On("BuscarUsuarioPorID", uint(1)).Return(usuario, nil)
+
+	loginOut, err := servico.ConcluirLoginDoisFatores(&dtos.ConcluirLoginDoisFatoresDTOIn{DesafioToken: "desafio-token", Codigo: "123456"}, "127.0.0.1")
 	assert.NoError(t, err)
-	assert.NotEmpty(t, token)
+	assert.NotEmpty(t, loginOut.Token)
+
+	loginOut, err = servico.ConcluirLoginDoisFatores(&dtos.ConcluirLoginDoisFatoresDTOIn{DesafioToken: "desafio-token", Codigo: "000000"}, "127.0.0.1")
+	assert.Equal(t, dominio.ErrCodigoDoisFatoresInvalido, err)
+	assert.Nil(t, loginOut)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUsuarioServico_LoginDoisFatores_ContadorDeFalhas(t *testing.T) {
+	mockRepo := new(MockUsuarioRepositorio)
+	db := setupTestDB(t)
+	bloqueioRepo := new(MockBloqueioLoginRepositorio)
+	bloqueioRepo.On("CriarBloqueio", mock.Anything).Return(nil)
+	protecao := servicos.NovoProtecaoLoginServico(memoria.NovoTentativaLoginRepositorio(), bloqueioRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), protecao, &MockDoisFatoresServico{Ativo: true}, chavesJWTTeste)
+
+	senha := "Senha123!"
+	hashSenha, _ := bcrypt.GenerateFromPassword([]byte(senha), bcrypt.MinCost)
+	usuario := &dominio.Usuario{ID: 1, Email: "joao@example.com", Senha: string(hashSenha), TipoUsuario: 2}
+	mockRepo.On("BuscarPorEmail", usuario.Email).Return(usuario, nil)
+	mockRepo.On("BuscarUsuarioPorID", uint(1)).Return(usuario, nil)
+
+	for i := 0; i < dominio.LimiteFalhasPorConta-2; i++ {
+		_, err := servico.Login(usuario.Email, "SenhaErrada!", "127.0.0.1")
+		assert.Equal(t, dominio.ErrCrendenciaisInvalidas, err)
+	}
+
+	// A senha correta so abre o desafio: o contador da conta continua valendo
+	loginOut, err := servico.Login(usuario.Email, senha, "127.0.0.1")
+	assert.NoError(t, err)
+	assert.True(t, loginOut.DoisFatoresNecessario)
+
+	// Os codigos incorretos contam na mesma chave e bloqueiam a conta
+	for i := 0; i < 2; i++ {
+		_, err = servico.ConcluirLoginDoisFatores(&dtos.ConcluirLoginDoisFatoresDTOIn{DesafioToken: "desafio-token", Codigo: "000000"}, "127.0.0.1")
+		assert.Equal(t, dominio.ErrCodigoDoisFatoresInvalido, err)
+	}
+	_, err = servico.ConcluirLoginDoisFatores(&dtos.ConcluirLoginDoisFatoresDTOIn{DesafioToken: "desafio-token", Codigo: "123456"}, "127.0.0.1")
+	assert.Equal(t, dominio.ErrLoginBloqueado, err)
+	bloqueioRepo.AssertNumberOfCalls(t, "CriarBloqueio", 1)
+}
+
+func TestUsuarioServico_LoginDoisFatores_SucessoZeraContador(t *testing.T) {
+	mockRepo := new(MockUsuarioRepositorio)
+	db := setupTestDB(t)
+	protecao := servicos.NovoProtecaoLoginServico(memoria.NovoTentativaLoginRepositorio(), new(MockBloqueioLoginRepositorio))
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), protecao, &MockDoisFatoresServico{Ativo: true}, chavesJWTTeste)
+
+	senha := "Senha123!"
+	hashSenha, _ := bcrypt.GenerateFromPassword([]byte(senha), bcrypt.MinCost)
+	usuario := &dominio.Usuario{ID: 1, Email: "joao@example.com", Senha: string(hashSenha), TipoUsuario: 2}
+	mockRepo.On("BuscarPorEmail", usuario.Email).Return(usuario, nil)
+	mockRepo.On("BuscarUsuarioPorID", uint(1)).Return(usuario, nil)
+
+	for i := 0; i < dominio.LimiteFalhasPorConta-1; i++ {
+		_, _ = servico.Login(usuario.Email, "SenhaErrada!", "127.0.0.1")
+	}
+	_, err := servico.Login(usuario.Email, senha, "127.0.0.1")
+	assert.NoError(t, err)
+	loginOut, err := servico.ConcluirLoginDoisFatores(&dtos.ConcluirLoginDoisFatoresDTOIn{DesafioToken: "desafio-token", Codigo: "123456"}, "127.0.0.1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, loginOut.Token)
+
+	// Com o login concluido o contador foi zerado e uma nova falha nao bloqueia
+	_, err = servico.Login(usuario.Email, "SenhaErrada!", "127.0.0.1")
+	assert.Equal(t, dominio.ErrCrendenciaisInvalidas, err)
+	assert.NoError(t, protecao.VerificarBloqueio(usuario.Email, "127.0.0.1"))
+}
+
 func TestUsuarioServico_Login_UsuarioNaoEncontrado(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	mockRepo.On("BuscarPorEmail", "invalido@example.com").Return(nil, gorm.ErrRecordNotFound)
 
-	token, err := servico.Login("invalido@example.com", "Senha123!")
+	loginOut, err := servico.Login("invalido@example.com", "Senha123!", "127.0.0.1")
 
 	assert.Error(t, err)
-	assert.Equal(t, dominio.ErrUsuarioNaoEncontrado, err)
-	assert.Empty(t, token)
+	assert.Equal(t, dominio.ErrCrendenciaisInvalidas, err)
+	assert.Nil(t, loginOut)
 	mockRepo.AssertExpectations(t)
 }
 
 func TestUsuarioServico_Login_SenhaInvalida(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	senhaCorreta := "Senha123!"
 	hashSenha, _ := bcrypt.GenerateFromPassword([]byte(senhaCorreta), bcrypt.DefaultCost)
@@ -423,11 +712,36 @@ func TestUsuarioServico_Login_SenhaInvalida(t *testing.T) {
 
 	mockRepo.On("BuscarPorEmail", usuario.Email).Return(usuario, nil)
 
-	token, err := servico.Login(usuario.Email, "SenhaErrada!")
+	loginOut, err := servico.Login(usuario.Email, "SenhaErrada!", "127.0.0.1")
 
 	assert.Error(t, err)
 	assert.Equal(t, dominio.ErrCrendenciaisInvalidas, err)
-	assert.Empty(t, token)
+	assert.Nil(t, loginOut)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUsuarioServico_Login_EmailNaoVerificado(t *testing.T) {
+	mockRepo := new(MockUsuarioRepositorio)
+	db := setupTestDB(t)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
+
+	senha := "Senha123!"
+	hashSenha, _ := bcrypt.GenerateFromPassword([]byte(senha), bcrypt.DefaultCost)
+
+	usuario := &dominio.Usuario{
+		ID:                       1,
+		Email:                    "joao@example.com",
+		Senha:                    string(hashSenha),
+		TipoUsuario:              2,
+		VerificacaoEmailPendente: true,
+	}
+
+	mockRepo.On("BuscarPorEmail", usuario.Email).Return(usuario, nil)
+
+	loginOut, err := servico.Login(usuario.Email, senha, "127.0.0.1")
+
+	assert.Equal(t, dominio.ErrEmailNaoVerificado, err)
+	assert.Nil(t, loginOut)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -436,7 +750,7 @@ func TestUsuarioServico_Login_SenhaInvalida(t *testing.T) {
 func TestUsuarioServico_BuscarUsuarioPorID_Sucesso(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	usuario := &dominio.Usuario{
 		ID:    1,
@@ -458,7 +772,7 @@ func TestUsuarioServico_BuscarUsuarioPorID_Sucesso(t *testing.T) {
 func TestUsuarioServico_BuscarUsuarioPorID_NaoEncontrado(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	mockRepo.On("BuscarUsuarioPorID", uint(999)).Return(nil, gorm.ErrRecordNotFound)
 
@@ -475,7 +789,7 @@ func TestUsuarioServico_BuscarUsuarioPorID_NaoEncontrado(t *testing.T) {
 func TestUsuarioServico_ProprioPerfilPaciente_Sucesso(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	paciente := &dominio.Paciente{
 		ID:        1,
@@ -501,7 +815,7 @@ func TestUsuarioServico_ProprioPerfilPaciente_Sucesso(t *testing.T) {
 func TestUsuarioServico_ProprioPerfilPaciente_NaoEncontrado(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	mockRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(999)).Return(nil, gorm.ErrRecordNotFound)
 
@@ -518,7 +832,7 @@ func TestUsuarioServico_ProprioPerfilPaciente_NaoEncontrado(t *testing.T) {
 func TestUsuarioServico_ProprioPerfilProfissional_Sucesso(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	profissional := &dominio.Profissional{
 		ID:        1,
@@ -546,7 +860,7 @@ func TestUsuarioServico_ProprioPerfilProfissional_Sucesso(t *testing.T) {
 func TestUsuarioServico_ProprioPerfilProfissional_NaoEncontrado(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	mockRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(999)).Return(nil, gorm.ErrRecordNotFound)
 
@@ -563,7 +877,7 @@ func TestUsuarioServico_ProprioPerfilProfissional_NaoEncontrado(t *testing.T) {
 func TestUsuarioServico_AtualizarPerfil_UsuarioSimples_Sucesso(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	usuario := &dominio.Usuario{
 		ID:          1,
@@ -590,7 +904,7 @@ func TestUsuarioServico_AtualizarPerfil_UsuarioSimples_Sucesso(t *testing.T) {
 func TestUsuarioServico_AtualizarPerfil_Profissional_Sucesso(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	usuario := &dominio.Usuario{
 		ID:          1,
@@ -627,7 +941,7 @@ func TestUsuarioServico_AtualizarPerfil_Profissional_Sucesso(t *testing.T) {
 func TestUsuarioServico_AtualizarPerfil_Paciente_Sucesso(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	usuario := &dominio.Usuario{
 		ID:          1,
@@ -664,7 +978,7 @@ func TestUsuarioServico_AtualizarPerfil_Paciente_Sucesso(t *testing.T) {
 func TestUsuarioServico_AtualizarPerfil_NomeVazio_Erro(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	usuario := &dominio.Usuario{
 		ID:          1,
@@ -691,7 +1005,7 @@ func TestUsuarioServico_AtualizarPerfil_NomeVazio_Erro(t *testing.T) {
 func TestUsuarioServico_AlterarSenha_Sucesso(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	senhaAtual := "Senha123!"
 	hashSenha, _ := bcrypt.GenerateFromPassword([]byte(senhaAtual), bcrypt.DefaultCost)
@@ -720,7 +1034,7 @@ func TestUsuarioServico_AlterarSenha_Sucesso(t *testing.T) {
 func TestUsuarioServico_AlterarSenha_SenhasNaoConferem(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	dtoIn := &dtos.AlterarSenhaDTOIn{
 		SenhaAtual:  "Senha123!",
@@ -737,7 +1051,7 @@ func TestUsuarioServico_AlterarSenha_SenhasNaoConferem(t *testing.T) {
 func TestUsuarioServico_AlterarSenha_SenhaAtualInvalida(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	senhaAtual := "Senha123!"
 	hashSenha, _ := bcrypt.GenerateFromPassword([]byte(senhaAtual), bcrypt.DefaultCost)
@@ -766,7 +1080,7 @@ func TestUsuarioServico_AlterarSenha_SenhaAtualInvalida(t *testing.T) {
 func TestUsuarioServico_AlterarSenha_NovaSenhaFraca(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	senhaAtual := "Senha123!"
 	hashSenha, _ := bcrypt.GenerateFromPassword([]byte(senhaAtual), bcrypt.DefaultCost)
@@ -796,8 +1110,9 @@ func TestUsuarioServico_AlterarSenha_NovaSenhaFraca(t *testing.T) {
 
 func TestUsuarioServico_ListarPacientesDoProfissional_Sucesso(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
+	mockVinculoRepo := new(MockVinculoRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, mockVinculoRepo, new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	profissional := &dominio.Profissional{
 		ID:        1,
@@ -827,6 +1142,7 @@ func TestUsuarioServico_ListarPacientesDoProfissional_Sucesso(t *testing.T) {
 
 	mockRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(1)).Return(profissional, nil)
 	mockRepo.On("BuscarPacientesDoProfissional", mock.Anything, uint(1)).Return(pacientes, nil)
+	mockVinculoRepo.On("ListarVinculosDoProfissional", mock.Anything, uint(1)).Return([]*dominio.Vinculo{}, nil)
 
 	result, err := servico.ListarPacientesDoProfissional(1)
 
@@ -840,7 +1156,7 @@ func TestUsuarioServico_ListarPacientesDoProfissional_Sucesso(t *testing.T) {
 func TestUsuarioServico_ListarPacientesDoProfissional_ProfissionalNaoEncontrado(t *testing.T) {
 	mockRepo := new(MockUsuarioRepositorio)
 	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
+	servico := servicos.NovoUsuarioServico(db, mockRepo, new(MockVinculoRepositorio), new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
 
 	mockRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(999)).Return(nil, gorm.ErrRecordNotFound)
 
@@ -852,61 +1168,3 @@ func TestUsuarioServico_ListarPacientesDoProfissional_ProfissionalNaoEncontrado(
 	assert.Len(t, result, 0)
 	mockRepo.AssertExpectations(t)
 }
-
-// ========== Testes para DeletarPerfil ==========
-
-func TestUsuarioServico_DeletarPerfil_Sucesso(t *testing.T) {
-	mockRepo := new(MockUsuarioRepositorio)
-	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
-
-	usuario := &dominio.Usuario{
-		ID:    1,
-		Nome:  "João Silva",
-		Email: "joao@example.com",
-	}
-
-	mockRepo.On("BuscarUsuarioPorID", uint(1)).Return(usuario, nil)
-	mockRepo.On("DeletarUsuario", mock.Anything, uint(1)).Return(nil)
-
-	err := servico.DeletarPerfil(1)
-
-	assert.NoError(t, err)
-	mockRepo.AssertExpectations(t)
-}
-
-func TestUsuarioServico_DeletarPerfil_UsuarioNaoEncontrado(t *testing.T) {
-	mockRepo := new(MockUsuarioRepositorio)
-	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
-
-	mockRepo.On("BuscarUsuarioPorID", uint(999)).Return(nil, gorm.ErrRecordNotFound)
-
-	err := servico.DeletarPerfil(999)
-
-	assert.Error(t, err)
-	assert.Equal(t, dominio.ErrUsuarioNaoEncontrado, err)
-	mockRepo.AssertExpectations(t)
-}
-
-func TestUsuarioServico_DeletarPerfil_ErroAoDeletar(t *testing.T) {
-	mockRepo := new(MockUsuarioRepositorio)
-	db := setupTestDB(t)
-	servico := servicos.NovoUsuarioServico(db, mockRepo)
-
-	usuario := &dominio.Usuario{
-		ID:    1,
-		Nome:  "João Silva",
-		Email: "joao@example.com",
-	}
-
-	erroDB := errors.New("erro ao deletar")
-	mockRepo.On("BuscarUsuarioPorID", uint(1)).Return(usuario, nil)
-	mockRepo.On("DeletarUsuario", mock.Anything, uint(1)).Return(erroDB)
-
-	err := servico.DeletarPerfil(1)
-
-	assert.Error(t, err)
-	assert.Equal(t, erroDB, err)
-	mockRepo.AssertExpectations(t)
-}
@@ -0,0 +1,250 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/servicos"
+	"mindtrace/backend/interno/dominio"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========== Mocks ==========
+
+// MockVinculoRepositorio simula o repositorio de vinculos
+type MockVinculoRepositorio struct {
+	mock.Mock
+}
+
+func (m *MockVinculoRepositorio) CriarVinculo(tx *gorm.DB, vinculo *dominio.Vinculo) error {
+	args := m.Called(tx, vinculo)
+	return args.Error(0)
+}
+
+func (m *MockVinculoRepositorio) AtualizarVinculo(tx *gorm.DB, vinculo *dominio.Vinculo) error {
+	args := m.Called(tx, vinculo)
+	return args.Error(0)
+}
+
+func (m *MockVinculoRepositorio) BuscarVinculo(tx *gorm.DB, pacienteID, profissionalID uint) (*dominio.Vinculo, error) {
+	args := m.Called(tx, pacienteID, profissionalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dominio.Vinculo), args.Error(1)
+}
+
+func (m *MockVinculoRepositorio) ListarVinculosDoProfissional(tx *gorm.DB, profissionalID uint) ([]*dominio.Vinculo, error) {
+	args := m.Called(tx, profissionalID)
+	return args.Get(0).([]*dominio.Vinculo), args.Error(1)
+}
+
+func (m *MockVinculoRepositorio) ListarProfissionaisAtivosDoPaciente(tx *gorm.DB, pacienteID uint) ([]*dominio.Profissional, error) {
+	args := m.Called(tx, pacienteID)
+	return args.Get(0).([]*dominio.Profissional), args.Error(1)
+}
+
+func (m *MockVinculoRepositorio) CriarEncerramento(tx *gorm.DB, encerramento *dominio.EncerramentoVinculo) error {
+	args := m.Called(tx, encerramento)
+	return args.Error(0)
+}
+
+func (m *MockVinculoRepositorio) CancelarAtribuicoesPendentes(tx *gorm.DB, pacienteID, profissionalID uint) (int64, error) {
+	args := m.Called(tx, pacienteID, profissionalID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// ========== Testes do Serviço ==========
+
+func TestVinculoServico_EncerrarVinculo_PeloPaciente(t *testing.T) {
+	usuarioRepo := new(MockUsuarioRepositorio)
+	vinculoRepo := new(MockVinculoRepositorio)
+	consentimentoRepo := new(MockConsentimentoRepositorio)
+	notificacaoRepo := new(MockNotificacaoRepositorio)
+	servico := servicos.NovoVinculoServico(setupTestDB(t), usuarioRepo, vinculoRepo, consentimentoRepo, notificacaoRepo)
+
+	vinculadoEm := time.Now().AddDate(0, -6, 0)
+	usuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).
+		Return(&dominio.Paciente{ID: 3, Usuario: dominio.Usuario{Nome: "Ana"}}, nil)
+	usuarioRepo.On("BuscarProfissionalPorID", mock.Anything, uint(7)).Return(&dominio.Profissional{ID: 7, UsuarioID: 20}, nil)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(3), uint(7)).
+		Return(&dominio.Vinculo{PacienteID: 3, ProfissionalID: 7, VinculadoEm: &vinculadoEm}, nil)
+	vinculoRepo.On("AtualizarVinculo", mock.Anything, mock.MatchedBy(func(v *dominio.Vinculo) bool {
+		return !v.Ativo() && v.EncerradoPor == dominio.EncerradoPeloPaciente
+	})).Return(nil)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(3), uint(7)).
+		Return(&dominio.Consentimento{PacienteID: 3, ProfissionalID: 7, Versao: 2, CompartilharHumor: true}, nil)
+	consentimentoRepo.On("CriarConsentimento", mock.Anything, mock.MatchedBy(func(c *dominio.Consentimento) bool {
+		return c.Revogado && c.Versao == 3
+	})).Return(nil)
+	vinculoRepo.On("CancelarAtribuicoesPendentes", mock.Anything, uint(3), uint(7)).Return(int64(2), nil)
+	vinculoRepo.On("CriarEncerramento", mock.Anything, mock.MatchedBy(func(e *dominio.EncerramentoVinculo) bool {
+		return e.Motivo == "Mudei de cidade" && e.AtribuicoesCanceladas == 2
+	})).Return(nil)
+	notificacaoRepo.On("CriarNotificacao", mock.Anything, mock.MatchedBy(func(n *dominio.Notificacao) bool {
+		return n.UsuarioID == 20
+	})).Return(nil)
+
+	encerramentoOut, err := servico.EncerrarVinculo(10, 7, &dtos.EncerrarVinculoDTOIn{Motivo: "Mudei de cidade"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, dominio.EncerradoPeloPaciente, encerramentoOut.EncerradoPor)
+	assert.Equal(t, int64(2), encerramentoOut.AtribuicoesCanceladas)
+	assert.Equal(t, &vinculadoEm, encerramentoOut.VinculadoEm)
+	vinculoRepo.AssertExpectations(t)
+	consentimentoRepo.AssertExpectations(t)
+	notificacaoRepo.AssertExpectations(t)
+}
+
+func TestVinculoServico_EncerrarVinculo_JaEncerrado(t *testing.T) {
+	usuarioRepo := new(MockUsuarioRepositorio)
+	vinculoRepo := new(MockVinculoRepositorio)
+	notificacaoRepo := new(MockNotificacaoRepositorio)
+	servico := servicos.NovoVinculoServico(setupTestDB(t), usuarioRepo, vinculoRepo, new(MockConsentimentoRepositorio), notificacaoRepo)
+
+	encerradoEm := time.Now().AddDate(0, 0, -1)
+	usuarioRepo.On("BuscarPacientePorUsuarioID", mock.Anything, uint(10)).Return(&dominio.Paciente{ID: 3}, nil)
+	usuarioRepo.On("BuscarProfissionalPorID", mock.Anything, uint(7)).Return(&dominio.Profissional{ID: 7}, nil)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(3), uint(7)).
+		Return(&dominio.Vinculo{PacienteID: 3, ProfissionalID: 7, EncerradoEm: &encerradoEm}, nil)
+
+	encerramentoOut, err := servico.EncerrarVinculo(10, 7, &dtos.EncerrarVinculoDTOIn{Motivo: "Encerrando"})
+
+	assert.Nil(t, encerramentoOut)
+	assert.Equal(t, dominio.ErrVinculoJaEncerrado, err)
+	vinculoRepo.AssertNotCalled(t, "CancelarAtribuicoesPendentes", mock.Anything, mock.Anything, mock.Anything)
+	notificacaoRepo.AssertNotCalled(t, "CriarNotificacao", mock.Anything, mock.Anything)
+}
+
+func TestVinculoServico_DarAlta_NotificaPaciente(t *testing.T) {
+	usuarioRepo := new(MockUsuarioRepositorio)
+	vinculoRepo := new(MockVinculoRepositorio)
+	notificacaoRepo := new(MockNotificacaoRepositorio)
+	consentimentoRepo := new(MockConsentimentoRepositorio)
+	servico := servicos.NovoVinculoServico(setupTestDB(t), usuarioRepo, vinculoRepo, consentimentoRepo, notificacaoRepo)
+
+	usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(20)).
+		Return(&dominio.Profissional{ID: 7, Usuario: dominio.Usuario{Nome: "Dra. Lima"}}, nil)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(3), uint(7)).Return(nil, gorm.ErrRecordNotFound)
+	usuarioRepo.On("BuscarPacientePorID", mock.Anything, uint(3)).Return(&dominio.Paciente{ID: 3, UsuarioID: 10}, nil)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(3), uint(7)).Return(&dominio.Vinculo{PacienteID: 3, ProfissionalID: 7}, nil)
+	vinculoRepo.On("AtualizarVinculo", mock.Anything, mock.AnythingOfType("*dominio.Vinculo")).Return(nil)
+	vinculoRepo.On("CancelarAtribuicoesPendentes", mock.Anything, uint(3), uint(7)).Return(int64(0), nil)
+	vinculoRepo.On("CriarEncerramento", mock.Anything, mock.AnythingOfType("*dominio.EncerramentoVinculo")).Return(nil)
+	notificacaoRepo.On("CriarNotificacao", mock.Anything, mock.MatchedBy(func(n *dominio.Notificacao) bool {
+		return n.UsuarioID == 10
+	})).Return(nil)
+
+	encerramentoOut, err := servico.DarAlta(20, 3, &dtos.EncerrarVinculoDTOIn{Motivo: "Objetivos terapeuticos atingidos"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, dominio.EncerradoPeloProfissional, encerramentoOut.EncerradoPor)
+	notificacaoRepo.AssertExpectations(t)
+}
+
+func TestVinculoServico_DarAlta_SemVinculo(t *testing.T) {
+	usuarioRepo := new(MockUsuarioRepositorio)
+	vinculoRepo := new(MockVinculoRepositorio)
+	servico := servicos.NovoVinculoServico(setupTestDB(t), usuarioRepo, vinculoRepo, new(MockConsentimentoRepositorio), new(MockNotificacaoRepositorio))
+
+	usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(20)).Return(&dominio.Profissional{ID: 7}, nil)
+	usuarioRepo.On("BuscarPacientePorID", mock.Anything, uint(3)).Return(&dominio.Paciente{ID: 3}, nil)
+	vinculoRepo.On("BuscarVinculo", mock.Anything, uint(3), uint(7)).Return(nil, gorm.ErrRecordNotFound)
+
+	_, err := servico.DarAlta(20, 3, &dtos.EncerrarVinculoDTOIn{Motivo: "Alta"})
+
+	assert.Equal(t, dominio.ErrVinculoNaoEncontrado, err)
+}
+
+func TestVinculoServico_DarAlta_EncerraAcessoAAnalise(t *testing.T) {
+	db := setupTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&dominio.Vinculo{}))
+	assert.NoError(t, db.AutoMigrate(&dominio.EncerramentoVinculo{}, &dominio.Consentimento{}, &dominio.Atribuicao{},
+		&dominio.Notificacao{}, &dominio.RegistroHumor{}))
+	assert.NoError(t, db.Create(&dominio.Vinculo{PacienteID: 3, ProfissionalID: 7}).Error)
+	assert.NoError(t, db.Omit(clause.Associations).Create(dominio.NovoConsentimentoPadrao(3, 7, time.Now().AddDate(0, 0, -30))).Error)
+
+	usuarioRepo := new(MockUsuarioRepositorio)
+	usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(20)).Return(&dominio.Profissional{ID: 7, UsuarioID: 20}, nil)
+	usuarioRepo.On("BuscarPacientePorID", mock.Anything, uint(3)).Return(&dominio.Paciente{ID: 3, UsuarioID: 10}, nil)
+	vinculoRepo := sqlite_repo.NovoGormVinculoRepositorio(db)
+	consentimentoRepo := sqlite_repo.NovoGormConsentimentoRepositorio(db)
+	vinculoSvc := servicos.NovoVinculoServico(db, usuarioRepo, vinculoRepo, consentimentoRepo, sqlite_repo.NovoGormNotificacaoRepositorio(db))
+	analiseSvc := servicos.NovoAnaliseServico(db, sqlite_repo.NovoGormRegistroHumorRepositorio(db), usuarioRepo, consentimentoRepo, vinculoRepo, new(MockNotificacaoRepositorio), new(MockResponsavelRepositorio), sqlite_repo.NovoGormMetricaPersonalizadaRepositorio(db))
+
+	_, err := analiseSvc.GerarAnaliseHistorica(20, 3, "profissional", 7)
+	assert.NoError(t, err)
+
+	_, err = vinculoSvc.DarAlta(20, 3, &dtos.EncerrarVinculoDTOIn{Motivo: "Alta"})
+	assert.NoError(t, err)
+
+	// Depois da alta o profissional perde o acesso e o consentimento fica revogado
+	_, err = analiseSvc.GerarAnaliseHistorica(20, 3, "profissional", 7)
+	assert.Equal(t, dominio.ErrVinculoNaoEncontrado, err)
+	var ultima dominio.Consentimento
+	assert.NoError(t, db.Where("paciente_id = ? AND profissional_id = ?", 3, 7).Order("versao DESC").First(&ultima).Error)
+	assert.Equal(t, uint(2), ultima.Versao)
+	assert.True(t, ultima.Revogado)
+}
+
+func TestUsuarioServico_ListarPacientesDoProfissional_PacienteComAltaInativo(t *testing.T) {
+	usuarioRepo := new(MockUsuarioRepositorio)
+	vinculoRepo := new(MockVinculoRepositorio)
+	servico := servicos.NovoUsuarioServico(setupTestDB(t), usuarioRepo, vinculoRepo, new(MockConviteRepositorio), new(MockConsentimentoRepositorio), new(MockVerificacaoEmailServico), new(MockProtecaoLoginServico), new(MockDoisFatoresServico), chavesJWTTeste)
+
+	encerradoEm := time.Now().AddDate(0, 0, -2)
+	usuarioRepo.On("BuscarProfissionalPorUsuarioID", mock.Anything, uint(20)).Return(&dominio.Profissional{ID: 7}, nil)
+	usuarioRepo.On("BuscarPacientesDoProfissional", mock.Anything, uint(7)).Return([]dominio.Paciente{{ID: 1}, {ID: 2}}, nil)
+	vinculoRepo.On("ListarVinculosDoProfissional", mock.Anything, uint(7)).Return([]*dominio.Vinculo{
+		{PacienteID: 1, ProfissionalID: 7, EncerradoEm: &encerradoEm, EncerradoPor: dominio.EncerradoPeloProfissional},
+		{PacienteID: 2, ProfissionalID: 7},
+	}, nil)
+
+	pacientesOut, err := servico.ListarPacientesDoProfissional(20)
+
+	assert.NoError(t, err)
+	assert.Len(t, pacientesOut, 2)
+	// O paciente ativo vem antes do paciente com alta
+	assert.Equal(t, uint(2), pacientesOut[0].ID)
+	assert.True(t, pacientesOut[0].Vinculo.Ativo)
+	assert.Equal(t, uint(1), pacientesOut[1].ID)
+	assert.False(t, pacientesOut[1].Vinculo.Ativo)
+	assert.Equal(t, dominio.EncerradoPeloProfissional, pacientesOut[1].Vinculo.EncerradoPor)
+}
+
+func TestAnaliseServico_ExecutarMonitoramento_AlertaApenasVinculosAtivosComConsentimento(t *testing.T) {
+	registroRepo := new(MockRegistroHumorRepositorioConsentimento)
+	consentimentoRepo := new(MockConsentimentoRepositorio)
+	vinculoRepo := new(MockVinculoRepositorio)
+	notificacaoRepo := new(MockNotificacaoRepositorio)
+	responsavelRepo := new(MockResponsavelRepositorio)
+	servico := servicos.NovoAnaliseServico(setupTestDB(t), registroRepo, new(MockUsuarioRepositorio), consentimentoRepo, vinculoRepo, notificacaoRepo, responsavelRepo, new(MockMetricaPersonalizadaRepositorio))
+
+	registroRepo.On("BuscarPorNUltimosRegistros", uint(3), 5).Return([]*dominio.RegistroHumor{
+		{PacienteID: 3, NivelHumor: 1, HorasSono: 3, NivelEnergia: 2, NivelStress: 9},
+	}, nil)
+	// O repositorio ja exclui profissionais que deram alta; o profissional 8 nao recebe o humor
+	vinculoRepo.On("ListarProfissionaisAtivosDoPaciente", mock.Anything, uint(3)).Return([]*dominio.Profissional{
+		{ID: 7, UsuarioID: 20},
+		{ID: 8, UsuarioID: 21},
+	}, nil)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(3), uint(7)).
+		Return(dominio.NovoConsentimentoPadrao(3, 7, time.Now().AddDate(0, -1, 0)), nil)
+	consentimentoRepo.On("BuscarConsentimentoAtual", mock.Anything, uint(3), uint(8)).
+		Return(dominio.NovoConsentimentoPadrao(3, 8, time.Now().AddDate(0, -1, 0)).Revogar(), nil)
+	notificacaoRepo.On("CriarNotificacao", mock.Anything, mock.MatchedBy(func(n *dominio.Notificacao) bool {
+		return n.UsuarioID == 20
+	})).Return(nil).Once()
+	responsavelRepo.On("ListarResponsaveisDoPaciente", mock.Anything, uint(3)).Return([]*dominio.VinculoResponsavel{}, nil)
+
+	err := servico.ExecutarMonitoramento(3)
+
+	assert.NoError(t, err)
+	notificacaoRepo.AssertExpectations(t)
+	notificacaoRepo.AssertNumberOfCalls(t, "CriarNotificacao", 1)
+}
@@ -0,0 +1,34 @@
+package servicos
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+)
+
+// gerarTokenAleatorio gera um token hexadecimal com n bytes de entropia
+func gerarTokenAleatorio(n int) (string, error) {
+	tokenBytes := make([]byte, n)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(tokenBytes), nil
+}
+
+// hashToken gera o hash sha256 de um token para armazenamento
+// O token em texto claro nunca e persistido
+func hashToken(token string) string {
+	soma := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(soma[:])
+}
+
+// urlFrontend retorna a url base do frontend usada nos links enviados por e-mail
+func urlFrontend() string {
+	url := strings.TrimSpace(os.Getenv("FRONTEND_URL"))
+	if url == "" {
+		url = "http://localhost:5173"
+	}
+	return strings.TrimRight(url, "/")
+}
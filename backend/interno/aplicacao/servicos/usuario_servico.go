@@ -2,11 +2,14 @@ package servicos
 
 import (
 	"errors"
+	"log"
 	"mindtrace/backend/interno/aplicacao/dtos"
 	"mindtrace/backend/interno/aplicacao/mappers"
 	"mindtrace/backend/interno/dominio"
 	"mindtrace/backend/interno/persistencia/repositorios"
-	"os"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -19,25 +22,52 @@ import (
 type UsuarioServico interface {
 	RegistrarProfissional(dtoIn *dtos.RegistrarProfissionalDTOIn) (*dtos.ProfissionalDTOOut, error)
 	RegistrarPaciente(dtoIn *dtos.RegistrarPacienteDTOIn) (*dtos.PacienteDTOOut, error)
-	Login(email, senha string) (string, error)
+	Login(email, senha, ip string) (*dtos.LoginDTOOut, error)
+	ConcluirLoginDoisFatores(dtoIn *dtos.ConcluirLoginDoisFatoresDTOIn, ip string) (*dtos.LoginDTOOut, error)
 	BuscarUsuarioPorID(userID uint) (*dtos.UsuarioDTOOut, error)
 	ProprioPerfilPaciente(pacID uint) (*dtos.PacienteDTOOut, error)
 	ProprioPerfilProfissional(profID uint) (*dtos.ProfissionalDTOOut, error)
 	ListarPacientesDoProfissional(userID uint) ([]dtos.PacienteDTOOut, error)
 	AtualizarPerfil(userID uint, dtoIn *dtos.AtualizarPerfilDTOIn) error
 	AlterarSenha(userID uint, dtoIn *dtos.AlterarSenhaDTOIn) error
-	DeletarPerfil(userID uint) error
 }
 
 // usuarioServico implementa a interface UsuarioServico
 type usuarioServico struct {
-	db          *gorm.DB
-	repositorio repositorios.UsuarioRepositorio
+	db                       *gorm.DB
+	repositorio              repositorios.UsuarioRepositorio
+	vinculoRepositorio       repositorios.VinculoRepositorio
+	conviteRepositorio       repositorios.ConviteRepositorio
+	consentimentoRepositorio repositorios.ConsentimentoRepositorio
+	verificacaoEmailServico  VerificacaoEmailServico
+	protecaoLoginServico     ProtecaoLoginServico
+	doisFatoresServico       DoisFatoresServico
+	chavesJWT                ChavesJWT
 }
 
 // NovoUsuarioServico cria uma nova instancia de UsuarioServico
-func NovoUsuarioServico(db *gorm.DB, repo repositorios.UsuarioRepositorio) UsuarioServico {
-	return &usuarioServico{db: db, repositorio: repo}
+func NovoUsuarioServico(db *gorm.DB, repo repositorios.UsuarioRepositorio, vr repositorios.VinculoRepositorio, cr repositorios.ConviteRepositorio, csr repositorios.ConsentimentoRepositorio, ves VerificacaoEmailServico, pls ProtecaoLoginServico, dfs DoisFatoresServico, cj ChavesJWT) UsuarioServico {
+	return &usuarioServico{db: db, repositorio: repo, vinculoRepositorio: vr, conviteRepositorio: cr, consentimentoRepositorio: csr, verificacaoEmailServico: ves, protecaoLoginServico: pls, doisFatoresServico: dfs, chavesJWT: cj}
+}
+
+// hashSenhaFicticio e comparado quando o e-mail nao existe, igualando o tempo de resposta
+// de contas inexistentes ao de senhas incorretas
+var hashSenhaFicticio = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("senha-ficticia-para-tempo-constante"), bcrypt.DefaultCost)
+	return hash
+})
+
+// verificarCPFDisponivel impede um segundo cadastro com o mesmo CPF
+// A busca usa o indice cego, ja que o CPF e gravado cifrado
+func verificarCPFDisponivel(ur repositorios.UsuarioRepositorio, cpf string) error {
+	_, err := ur.BuscarPorCPF(cpf)
+	if err == nil {
+		return dominio.ErrCPFJaCadastrado
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
 }
 
 // RegistrarProfissional registra um novo profissional no sistema
@@ -75,6 +105,10 @@ func (s *usuarioServico) RegistrarProfissional(dtoIn *dtos.RegistrarProfissional
 			return err
 		}
 
+		if err := verificarCPFDisponivel(s.repositorio, novoUsuario.CPF); err != nil {
+			return err
+		}
+
 		// Hash da senha
 		hashSenha, err := bcrypt.GenerateFromPassword([]byte(novoUsuario.Senha), bcrypt.DefaultCost)
 		if err != nil {
@@ -82,6 +116,8 @@ func (s *usuarioServico) RegistrarProfissional(dtoIn *dtos.RegistrarProfissional
 		}
 		novoUsuario.Senha = string(hashSenha)
 		novoUsuario.TipoUsuario = dominio.TipoUsuarioProfissional
+		// Conta so e liberada apos a confirmacao do e-mail
+		novoUsuario.VerificacaoEmailPendente = true
 
 		// Cria o usuario
 		if err := s.repositorio.CriarUsuario(tx, novoUsuario); err != nil {
@@ -100,7 +136,13 @@ func (s *usuarioServico) RegistrarProfissional(dtoIn *dtos.RegistrarProfissional
 		return nil
 	})
 
-	return mappers.ProfissionalParaDTOOut(profissionalRegistrado), err
+	if err != nil {
+		return nil, err
+	}
+
+	s.iniciarVerificacaoEmail(&profissionalRegistrado.Usuario)
+
+	return mappers.ProfissionalParaDTOOut(profissionalRegistrado), nil
 }
 
 func (s *usuarioServico) RegistrarPaciente(dtoIn *dtos.RegistrarPacienteDTOIn) (*dtos.PacienteDTOOut, error) {
@@ -116,6 +158,13 @@ func (s *usuarioServico) RegistrarPaciente(dtoIn *dtos.RegistrarPacienteDTOIn) (
 		if !errors.Is(err, gorm.ErrRecordNotFound) {
 			return err
 		}
+		// Um convite invalido impede o cadastro antes de criar a conta
+		var convite *dominio.Convite
+		if token := strings.TrimSpace(dtoIn.TokenConvite); token != "" {
+			if convite, err = buscarConviteDisponivel(tx, s.conviteRepositorio, token); err != nil {
+				return err
+			}
+		}
 		// Usa o mapper para criar as entidades a partir do DTOIn
 		novoUsuario, novoPaciente := mappers.RegistrarPacienteDTOInParaEntidade(dtoIn)
 
@@ -135,6 +184,10 @@ func (s *usuarioServico) RegistrarPaciente(dtoIn *dtos.RegistrarPacienteDTOIn) (
 			return err
 		}
 
+		if err := verificarCPFDisponivel(s.repositorio, novoUsuario.CPF); err != nil {
+			return err
+		}
+
 		// Hash da senha
 		hashSenha, err := bcrypt.GenerateFromPassword([]byte(novoUsuario.Senha), bcrypt.DefaultCost)
 		if err != nil {
@@ -142,6 +195,8 @@ func (s *usuarioServico) RegistrarPaciente(dtoIn *dtos.RegistrarPacienteDTOIn) (
 		}
 		novoUsuario.Senha = string(hashSenha)
 		novoUsuario.TipoUsuario = dominio.TipoUsuarioPaciente
+		// Conta so e liberada apos a confirmacao do e-mail
+		novoUsuario.VerificacaoEmailPendente = true
 		// Cria o usuario
 		if err := s.repositorio.CriarUsuario(tx, novoUsuario); err != nil {
 			return err
@@ -153,48 +208,138 @@ func (s *usuarioServico) RegistrarPaciente(dtoIn *dtos.RegistrarPacienteDTOIn) (
 			return err
 		}
 
+		// Com convite, o vinculo e criado junto com a conta
+		if convite != nil {
+			if err := resgatarConvite(tx, s.conviteRepositorio, s.vinculoRepositorio, s.consentimentoRepositorio, convite, novoPaciente); err != nil {
+				return err
+			}
+		}
+
 		// Prepara o objeto de retorno completo
 		pacienteCompleto = novoPaciente
 
 		return nil
 	})
 
-	return mappers.PacienteParaDTOOut(pacienteCompleto), err
+	if err != nil {
+		return nil, err
+	}
+
+	s.iniciarVerificacaoEmail(&pacienteCompleto.Usuario)
+
+	return mappers.PacienteParaDTOOut(pacienteCompleto), nil
+}
+
+// iniciarVerificacaoEmail envia o e-mail de confirmacao apos o cadastro
+// Falhas nao desfazem o cadastro, o usuario pode solicitar o reenvio
+func (s *usuarioServico) iniciarVerificacaoEmail(usuario *dominio.Usuario) {
+	if err := s.verificacaoEmailServico.IniciarVerificacao(usuario); err != nil {
+		log.Printf("falha ao iniciar verificacao de e-mail do usuario %d: %v", usuario.ID, err)
+	}
 }
 
 // Login autentica o usuario e retorna um token JWT
-func (s *usuarioServico) Login(email, senha string) (string, error) {
+// E-mail inexistente e senha incorreta retornam o mesmo erro, e falhas repetidas
+// por conta ou por IP bloqueiam temporariamente novas tentativas
+// Com dois fatores ativo, retorna apenas o token de desafio para a segunda etapa
+func (s *usuarioServico) Login(email, senha, ip string) (*dtos.LoginDTOOut, error) {
+	if err := s.protecaoLoginServico.VerificarBloqueio(email, ip); err != nil {
+		return nil, err
+	}
+
 	// Busca usuario pelo e-mail
 	usuario, err := s.repositorio.BuscarPorEmail(email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", dominio.ErrUsuarioNaoEncontrado
+			_ = bcrypt.CompareHashAndPassword(hashSenhaFicticio(), []byte(senha))
+			s.protecaoLoginServico.RegistrarFalha(email, ip)
+			return nil, dominio.ErrCrendenciaisInvalidas
 		}
-		return "", err
+		return nil, err
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(usuario.Senha), []byte(senha))
 	if err != nil {
-		return "", dominio.ErrCrendenciaisInvalidas
+		s.protecaoLoginServico.RegistrarFalha(email, ip)
+		return nil, dominio.ErrCrendenciaisInvalidas
 	}
 
-	// Gera o token JWT
+	// Contas com e-mail pendente de confirmacao nao recebem token
+	if !usuario.EmailVerificado() {
+		return nil, dominio.ErrEmailNaoVerificado
+	}
+
+	doisFatoresAtivo, err := s.doisFatoresServico.DoisFatoresAtivo(usuario.ID)
+	if err != nil {
+		return nil, err
+	}
+	if doisFatoresAtivo {
+		desafioToken, err := s.doisFatoresServico.IniciarDesafio(usuario.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &dtos.LoginDTOOut{DoisFatoresNecessario: true, DesafioToken: desafioToken}, nil
+	}
+
+	tokenString, err := s.gerarTokenJWT(usuario, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// O contador da conta so e zerado quando o login termina; com dois fatores, na segunda etapa
+	s.protecaoLoginServico.RegistrarSucesso(email, ip)
+	return &dtos.LoginDTOOut{Token: tokenString}, nil
+}
+
+// ConcluirLoginDoisFatores troca o token de desafio e o codigo do autenticador pelo token JWT
+// Codigos incorretos contam como falhas de login da conta, e a conta bloqueada nao conclui o desafio
+func (s *usuarioServico) ConcluirLoginDoisFatores(dtoIn *dtos.ConcluirLoginDoisFatoresDTOIn, ip string) (*dtos.LoginDTOOut, error) {
+	userID, err := s.doisFatoresServico.UsuarioDoDesafio(dtoIn.DesafioToken)
+	if err != nil {
+		return nil, err
+	}
+
+	usuario, err := s.repositorio.BuscarUsuarioPorID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrDesafioDoisFatoresInvalido
+		}
+		return nil, err
+	}
+
+	if err := s.protecaoLoginServico.VerificarBloqueio(usuario.Email, ip); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.doisFatoresServico.ConcluirDesafio(dtoIn.DesafioToken, dtoIn.Codigo); err != nil {
+		if errors.Is(err, dominio.ErrCodigoDoisFatoresInvalido) {
+			s.protecaoLoginServico.RegistrarFalha(usuario.Email, ip)
+		}
+		return nil, err
+	}
+
+	tokenString, err := s.gerarTokenJWT(usuario, true)
+	if err != nil {
+		return nil, err
+	}
+
+	s.protecaoLoginServico.RegistrarSucesso(usuario.Email, ip)
+	return &dtos.LoginDTOOut{Token: tokenString}, nil
+}
+
+// gerarTokenJWT emite o token de sessao do usuario
+// mfa indica se o login passou pela etapa do segundo fator
+func (s *usuarioServico) gerarTokenJWT(usuario *dominio.Usuario, mfa bool) (string, error) {
 	claims := jwt.MapClaims{
 		"sub":  usuario.ID,                                         // Subject com o ID do usuario
 		"role": dominio.TipoUsuarioParaString(usuario.TipoUsuario), // Adiciona o tipo de usuario como role (string)
+		"sv":   usuario.VersaoSessao,                               // Versao da sessao, permite revogar tokens emitidos
+		"mfa":  mfa,                                                // Indica login concluido com segundo fator
 		"iat":  time.Now().Unix(),                                  // Issued At indica quando o token foi criado
 		"exp":  time.Now().Add(time.Hour * 1).Unix(),               // Define expiracao do token em uma hora
 	}
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	tokenString, err := token.SignedString([]byte(jwtSecret))
-	if err != nil {
-		return "", err
-	}
-
-	return tokenString, nil
+	return s.chavesJWT.Assinar(claims)
 }
 
 // BuscarUsuarioPorID busca um usuario pelo ID
@@ -361,6 +506,7 @@ func (s *usuarioServico) AlterarSenha(userID uint, dtoIn *dtos.AlterarSenhaDTOIn
 
 func (s *usuarioServico) ListarPacientesDoProfissional(userID uint) ([]dtos.PacienteDTOOut, error) {
 	var pacientes []dominio.Paciente
+	var vinculos []*dominio.Vinculo
 	err := s.db.Transaction(func(tx *gorm.DB) error {
 		profissional, err := s.repositorio.BuscarProfissionalPorUsuarioID(tx, userID)
 		if err != nil {
@@ -370,22 +516,32 @@ func (s *usuarioServico) ListarPacientesDoProfissional(userID uint) ([]dtos.Paci
 			return err
 		}
 		pacientes, err = s.repositorio.BuscarPacientesDoProfissional(tx, profissional.ID)
+		if err != nil {
+			return err
+		}
+		vinculos, err = s.vinculoRepositorio.ListarVinculosDoProfissional(tx, profissional.ID)
 		return err
 	})
+	if err != nil {
+		return mappers.PacientesParaDTOOut(nil), err
+	}
 
-	return mappers.PacientesParaDTOOut(pacientes), err
+	// Pacientes com alta continuam na lista, marcados como inativos e depois dos ativos
+	vinculoPorPaciente := make(map[uint]*dominio.Vinculo, len(vinculos))
+	for _, v := range vinculos {
+		vinculoPorPaciente[v.PacienteID] = v
+	}
+	pacientesOut := mappers.PacientesParaDTOOut(pacientes)
+	for i := range pacientesOut {
+		pacientesOut[i].Vinculo = mappers.VinculoParaDTOOut(vinculoPorPaciente[pacientesOut[i].ID])
+	}
+	sort.SliceStable(pacientesOut, func(i, j int) bool {
+		return vinculoAtivo(pacientesOut[i].Vinculo) && !vinculoAtivo(pacientesOut[j].Vinculo)
+	})
+	return pacientesOut, nil
 }
 
-// DeletarPerfil deleta o perfil do usuario
-func (s *usuarioServico) DeletarPerfil(userID uint) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
-		_, err := s.repositorio.BuscarUsuarioPorID(userID)
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return dominio.ErrUsuarioNaoEncontrado
-			}
-			return err
-		}
-		return s.repositorio.DeletarUsuario(tx, userID)
-	})
+// vinculoAtivo considera ativo o paciente sem informacao de vinculo
+func vinculoAtivo(v *dtos.VinculoDTOOut) bool {
+	return v == nil || v.Ativo
 }
@@ -0,0 +1,140 @@
+package servicos
+
+import (
+	"errors"
+	"fmt"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// VerificacaoEmailServico define os metodos para confirmar a posse do e-mail informado no cadastro
+type VerificacaoEmailServico interface {
+	IniciarVerificacao(usuario *dominio.Usuario) error
+	ConfirmarEmail(token string) error
+	ReenviarVerificacao(email string) error
+}
+
+// verificacaoEmailServico implementa a interface VerificacaoEmailServico
+type verificacaoEmailServico struct {
+	db                     *gorm.DB
+	usuarioRepositorio     repositorios.UsuarioRepositorio
+	verificacaoRepositorio repositorios.VerificacaoEmailRepositorio
+	emailServico           EmailServico
+}
+
+// NovoVerificacaoEmailServico cria uma nova instancia de VerificacaoEmailServico
+func NovoVerificacaoEmailServico(db *gorm.DB, ur repositorios.UsuarioRepositorio, vr repositorios.VerificacaoEmailRepositorio, es EmailServico) VerificacaoEmailServico {
+	return &verificacaoEmailServico{
+		db:                     db,
+		usuarioRepositorio:     ur,
+		verificacaoRepositorio: vr,
+		emailServico:           es,
+	}
+}
+
+// IniciarVerificacao gera um novo token, invalida os anteriores e envia o link por e-mail
+func (s *verificacaoEmailServico) IniciarVerificacao(usuario *dominio.Usuario) error {
+	token, err := gerarTokenAleatorio(32)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.verificacaoRepositorio.InvalidarVerificacoesDoUsuario(tx, usuario.ID); err != nil {
+			return err
+		}
+
+		verificacao := &dominio.VerificacaoEmail{
+			UsuarioID:     usuario.ID,
+			TokenHash:     hashToken(token),
+			DataExpiracao: time.Now().Add(dominio.ValidadeTokenVerificacaoEmail),
+		}
+		if err := verificacao.Validar(); err != nil {
+			return err
+		}
+
+		return s.verificacaoRepositorio.CriarVerificacao(tx, verificacao)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.emailServico.Enviar(MensagemEmail{
+		Para:    usuario.Email,
+		Assunto: "MindTrace - Confirme seu e-mail",
+		Corpo: fmt.Sprintf(
+			"Ola, %s.\n\nPara ativar sua conta, confirme seu e-mail acessando o link abaixo:\n\n%s/verificar-email?token=%s\n\nO link expira em %d horas.",
+			usuario.Nome, urlFrontend(), token, int(dominio.ValidadeTokenVerificacaoEmail.Hours())),
+	})
+}
+
+// ConfirmarEmail valida o token recebido e marca o e-mail do usuario como verificado
+func (s *verificacaoEmailServico) ConfirmarEmail(token string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		verificacao, err := s.verificacaoRepositorio.BuscarVerificacaoPorTokenHash(tx, hashToken(token))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrTokenVerificacaoInvalido
+			}
+			return err
+		}
+
+		if err := verificacao.VerificarUso(); err != nil {
+			return err
+		}
+
+		usuario, err := s.usuarioRepositorio.BuscarUsuarioPorID(verificacao.UsuarioID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrTokenVerificacaoInvalido
+			}
+			return err
+		}
+
+		usuario.MarcarEmailComoVerificado()
+		if err := s.usuarioRepositorio.Atualizar(tx, usuario); err != nil {
+			return err
+		}
+
+		verificacao.Utilizar()
+		return s.verificacaoRepositorio.MarcarVerificacaoComoUsada(tx, verificacao)
+	})
+}
+
+// ReenviarVerificacao envia um novo link respeitando o limite de frequencia
+// E-mails desconhecidos ou ja verificados nao geram erro para nao revelar quais contas existem
+func (s *verificacaoEmailServico) ReenviarVerificacao(email string) error {
+	usuario, err := s.usuarioRepositorio.BuscarPorEmail(email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if usuario.EmailVerificado() {
+		return nil
+	}
+
+	var ultimoEnvio time.Time
+	ultima, err := s.verificacaoRepositorio.BuscarUltimaVerificacao(s.db, usuario.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if ultima != nil {
+		ultimoEnvio = ultima.CreatedAt
+	}
+
+	enviosUltimaHora, err := s.verificacaoRepositorio.ContarVerificacoesDesde(s.db, usuario.ID, time.Now().Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+
+	if err := dominio.VerificarLimiteReenvio(ultimoEnvio, enviosUltimaHora); err != nil {
+		return err
+	}
+
+	return s.IniciarVerificacao(usuario)
+}
@@ -0,0 +1,178 @@
+package servicos
+
+import (
+	"errors"
+	"fmt"
+	"mindtrace/backend/interno/aplicacao/dtos"
+	"mindtrace/backend/interno/aplicacao/mappers"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// VinculoServico define os metodos de encerramento do vinculo entre paciente e profissional
+type VinculoServico interface {
+	// EncerrarVinculo e usado pelo paciente para deixar de ser acompanhado pelo profissional
+	EncerrarVinculo(userID, profissionalID uint, dtoIn *dtos.EncerrarVinculoDTOIn) (*dtos.EncerramentoVinculoDTOOut, error)
+	// DarAlta e usado pelo profissional para encerrar o acompanhamento do paciente
+	DarAlta(userID, pacienteID uint, dtoIn *dtos.EncerrarVinculoDTOIn) (*dtos.EncerramentoVinculoDTOOut, error)
+}
+
+// vinculoServico implementa a interface VinculoServico
+type vinculoServico struct {
+	db                       *gorm.DB
+	usuarioRepositorio       repositorios.UsuarioRepositorio
+	vinculoRepositorio       repositorios.VinculoRepositorio
+	consentimentoRepositorio repositorios.ConsentimentoRepositorio
+	notificacaoRepositorio   repositorios.NotificacaoRepositorio
+}
+
+// NovoVinculoServico cria uma nova instancia de VinculoServico
+func NovoVinculoServico(db *gorm.DB, ur repositorios.UsuarioRepositorio, vr repositorios.VinculoRepositorio, cr repositorios.ConsentimentoRepositorio, nr repositorios.NotificacaoRepositorio) VinculoServico {
+	return &vinculoServico{
+		db:                       db,
+		usuarioRepositorio:       ur,
+		vinculoRepositorio:       vr,
+		consentimentoRepositorio: cr,
+		notificacaoRepositorio:   nr,
+	}
+}
+
+// EncerrarVinculo encerra o vinculo do paciente autenticado com o profissional informado
+func (s *vinculoServico) EncerrarVinculo(userID, profissionalID uint, dtoIn *dtos.EncerrarVinculoDTOIn) (*dtos.EncerramentoVinculoDTOOut, error) {
+	var encerramento *dominio.EncerramentoVinculo
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		paciente, err := s.usuarioRepositorio.BuscarPacientePorUsuarioID(tx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrUsuarioNaoEncontrado
+			}
+			return err
+		}
+		profissional, err := s.usuarioRepositorio.BuscarProfissionalPorID(tx, profissionalID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrVinculoNaoEncontrado
+			}
+			return err
+		}
+
+		encerramento, err = s.encerrar(tx, paciente.ID, profissional.ID, dominio.EncerradoPeloPaciente, dtoIn.Motivo)
+		if err != nil {
+			return err
+		}
+		return criarNotificacao(tx, s.notificacaoRepositorio, profissional.UsuarioID,
+			fmt.Sprintf("%s encerrou o vinculo com voce. Os questionarios pendentes foram cancelados.", paciente.Usuario.Nome))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.EncerramentoVinculoParaDTOOut(encerramento), nil
+}
+
+// DarAlta encerra o vinculo do profissional autenticado com o paciente informado
+func (s *vinculoServico) DarAlta(userID, pacienteID uint, dtoIn *dtos.EncerrarVinculoDTOIn) (*dtos.EncerramentoVinculoDTOOut, error) {
+	var encerramento *dominio.EncerramentoVinculo
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		profissional, err := s.usuarioRepositorio.BuscarProfissionalPorUsuarioID(tx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrUsuarioNaoEncontrado
+			}
+			return err
+		}
+		paciente, err := s.usuarioRepositorio.BuscarPacientePorID(tx, pacienteID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dominio.ErrVinculoNaoEncontrado
+			}
+			return err
+		}
+
+		encerramento, err = s.encerrar(tx, paciente.ID, profissional.ID, dominio.EncerradoPeloProfissional, dtoIn.Motivo)
+		if err != nil {
+			return err
+		}
+		return criarNotificacao(tx, s.notificacaoRepositorio, paciente.UsuarioID,
+			fmt.Sprintf("%s registrou sua alta. Seus registros deixaram de ser compartilhados com esse profissional.", profissional.Usuario.Nome))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappers.EncerramentoVinculoParaDTOOut(encerramento), nil
+}
+
+// encerrar marca o vinculo como encerrado, revoga o consentimento, cancela as atribuicoes pendentes do par e grava o historico
+func (s *vinculoServico) encerrar(tx *gorm.DB, pacienteID, profissionalID uint, origem, motivo string) (*dominio.EncerramentoVinculo, error) {
+	vinculo, err := s.vinculoRepositorio.BuscarVinculo(tx, pacienteID, profissionalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dominio.ErrVinculoNaoEncontrado
+		}
+		return nil, err
+	}
+
+	agora := time.Now()
+	encerramento, err := vinculo.Encerrar(origem, motivo, agora)
+	if err != nil {
+		return nil, err
+	}
+
+	// O compartilhamento termina com o vinculo; se o par voltar a se vincular, recebe a versao padrao
+	// A versao atual so e encontrada enquanto o vinculo esta ativo, por isso a revogacao vem antes
+	consentimento, err := s.consentimentoRepositorio.BuscarConsentimentoAtual(tx, pacienteID, profissionalID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if consentimento != nil && consentimento.Vigente(agora) {
+		if err := s.consentimentoRepositorio.CriarConsentimento(tx, consentimento.Revogar()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.vinculoRepositorio.AtualizarVinculo(tx, vinculo); err != nil {
+		return nil, err
+	}
+
+	encerramento.AtribuicoesCanceladas, err = s.vinculoRepositorio.CancelarAtribuicoesPendentes(tx, pacienteID, profissionalID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.vinculoRepositorio.CriarEncerramento(tx, encerramento); err != nil {
+		return nil, err
+	}
+	return encerramento, nil
+}
+
+// ativarVinculo cria o vinculo do par ou reativa um vinculo encerrado
+// Um vinculo ja ativo retorna ErrVinculoJaAtivo, para nao consumir o uso de um convite
+func ativarVinculo(tx *gorm.DB, repo repositorios.VinculoRepositorio, pacienteID, profissionalID uint, agora time.Time) error {
+	vinculo, err := repo.BuscarVinculo(tx, pacienteID, profissionalID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		return repo.CriarVinculo(tx, &dominio.Vinculo{
+			ProfissionalID: profissionalID,
+			PacienteID:     pacienteID,
+			VinculadoEm:    &agora,
+		})
+	}
+	if vinculo.Ativo() {
+		return dominio.ErrVinculoJaAtivo
+	}
+	vinculo.Reativar(agora)
+	return repo.AtualizarVinculo(tx, vinculo)
+}
+
+// criarNotificacao registra uma notificacao nao lida para o usuario
+func criarNotificacao(tx *gorm.DB, repo repositorios.NotificacaoRepositorio, usuarioID uint, conteudo string) error {
+	return repo.CriarNotificacao(tx, &dominio.Notificacao{
+		UsuarioID: usuarioID,
+		Conteudo:  conteudo,
+		Status:    dominio.NotificacaoNaoLida,
+		DataEnvio: time.Now(),
+	})
+}
@@ -0,0 +1,213 @@
+package dominio
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAgrupamentoInvalido    = errors.New("agrupamento deve ser DIA ou SEMANA")
+	ErrAgregacaoInvalida      = errors.New("agregacao deve ser MEDIA, MINIMO, MAXIMO ou ULTIMO")
+	ErrPeriodoAnaliseInvalido = errors.New("periodo da analise deve ter entre 1 e 365 dias")
+	ErrFusoInvalido           = errors.New("fuso horario desconhecido")
+)
+
+// Agrupamentos dos registros; a semana comeca na segunda-feira
+const (
+	AgrupamentoDia    = "DIA"
+	AgrupamentoSemana = "SEMANA"
+)
+
+// Valor que representa cada periodo agrupado
+const (
+	AgregacaoMedia  = "MEDIA"
+	AgregacaoMinimo = "MINIMO"
+	AgregacaoMaximo = "MAXIMO"
+	AgregacaoUltimo = "ULTIMO"
+)
+
+// JanelaMediaMovel e o numero de dias da media movel
+const JanelaMediaMovel = 7
+
+// ValidarAgrupamento normaliza o agrupamento; vazio vale DIA
+func ValidarAgrupamento(agrupamento string) (string, error) {
+	agrupamento = strings.ToUpper(strings.TrimSpace(agrupamento))
+	switch agrupamento {
+	case "":
+		return AgrupamentoDia, nil
+	case AgrupamentoDia, AgrupamentoSemana:
+		return agrupamento, nil
+	}
+	return "", ErrAgrupamentoInvalido
+}
+
+// ValidarAgregacao normaliza a agregacao; vazio vale MEDIA
+func ValidarAgregacao(agregacao string) (string, error) {
+	agregacao = strings.ToUpper(strings.TrimSpace(agregacao))
+	switch agregacao {
+	case "":
+		return AgregacaoMedia, nil
+	case AgregacaoMedia, AgregacaoMinimo, AgregacaoMaximo, AgregacaoUltimo:
+		return agregacao, nil
+	}
+	return "", ErrAgregacaoInvalida
+}
+
+// InicioDoDia retorna a meia-noite do dia do instante no fuso
+func InicioDoDia(instante time.Time, fuso *time.Location) time.Time {
+	local := instante.In(fuso)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, fuso)
+}
+
+// inicioDoPeriodo retorna o inicio do dia ou da semana (segunda-feira) do instante
+func inicioDoPeriodo(instante time.Time, agrupamento string, fuso *time.Location) time.Time {
+	dia := InicioDoDia(instante, fuso)
+	if agrupamento == AgrupamentoSemana {
+		// Weekday comeca no domingo (0); a semana do agrupamento comeca na segunda
+		return dia.AddDate(0, 0, -((int(dia.Weekday()) + 6) % 7))
+	}
+	return dia
+}
+
+// PeriodoAgregado e um dia ou semana da serie; sem registros, Valor fica nulo e marca a lacuna
+// MediaMovel e a media das medias diarias dos ultimos 7 dias com registro e existe apenas no agrupamento por dia
+type PeriodoAgregado struct {
+	Inicio     time.Time
+	Registros  int
+	Valor      *float64
+	MediaMovel *float64
+}
+
+// AgregarSerie divide o intervalo [inicio, fim] em dias ou semanas no fuso informado e resume os valores de cada um
+// Todos os periodos do intervalo aparecem, com ou sem registros; pontos fora do intervalo sao ignorados
+// A media da semana e a media das medias diarias
+func AgregarSerie(serie *SerieMetrica, inicio, fim time.Time, agrupamento, agregacao string, fuso *time.Location) []*PeriodoAgregado {
+	pontos := make([]PontoMetrica, 0, len(serie.Pontos))
+	for _, ponto := range serie.Pontos {
+		if !ponto.Instante.Before(inicio) && !ponto.Instante.After(fim) {
+			pontos = append(pontos, ponto)
+		}
+	}
+	sort.SliceStable(pontos, func(i, j int) bool { return pontos[i].Instante.Before(pontos[j].Instante) })
+
+	passo := 1
+	if agrupamento == AgrupamentoSemana {
+		passo = 7
+	}
+	periodos := make([]*PeriodoAgregado, 0)
+	porInicio := make(map[string]*PeriodoAgregado)
+	for atual := inicioDoPeriodo(inicio, agrupamento, fuso); !atual.After(fim); atual = atual.AddDate(0, 0, passo) {
+		periodo := &PeriodoAgregado{Inicio: atual}
+		periodos = append(periodos, periodo)
+		porInicio[atual.Format(formatoDia)] = periodo
+	}
+
+	for _, ponto := range pontos {
+		periodo, ok := porInicio[inicioDoPeriodo(ponto.Instante, agrupamento, fuso).Format(formatoDia)]
+		if !ok {
+			continue
+		}
+		valor := ponto.Valor
+		periodo.Registros++
+		switch {
+		case periodo.Valor == nil:
+			periodo.Valor = &valor
+		case agregacao == AgregacaoMinimo && valor < *periodo.Valor,
+			agregacao == AgregacaoMaximo && valor > *periodo.Valor,
+			agregacao == AgregacaoUltimo:
+			*periodo.Valor = valor
+		}
+	}
+
+	diaria := &SerieMetrica{Chave: serie.Chave, Rotulo: serie.Rotulo, Pontos: pontos}
+	medias := diaria.Diaria(fuso)
+	if agregacao == AgregacaoMedia {
+		somas := make(map[*PeriodoAgregado]float64)
+		dias := make(map[*PeriodoAgregado]int)
+		for _, dia := range medias.Dias() {
+			data, _ := time.ParseInLocation(formatoDia, dia, fuso)
+			periodo, ok := porInicio[inicioDoPeriodo(data, agrupamento, fuso).Format(formatoDia)]
+			if !ok {
+				continue
+			}
+			media, _ := medias.Media(dia)
+			somas[periodo] += media
+			dias[periodo]++
+		}
+		for periodo, soma := range somas {
+			media := soma / float64(dias[periodo])
+			periodo.Valor = &media
+		}
+	}
+
+	if agrupamento == AgrupamentoDia {
+		for _, periodo := range periodos {
+			periodo.MediaMovel = mediaMovel(medias, periodo.Inicio)
+		}
+	}
+	return periodos
+}
+
+// mediaMovel calcula a media das medias diarias da janela que termina no dia informado
+func mediaMovel(medias *SerieDiaria, dia time.Time) *float64 {
+	var soma float64
+	var dias int
+	for i := 0; i < JanelaMediaMovel; i++ {
+		if media, ok := medias.Media(dia.AddDate(0, 0, -i).Format(formatoDia)); ok {
+			soma += media
+			dias++
+		}
+	}
+	if dias == 0 {
+		return nil
+	}
+	media := soma / float64(dias)
+	return &media
+}
+
+// PerfilDiaSemana resume as medias diarias de um dia da semana
+type PerfilDiaSemana struct {
+	DiaSemana time.Weekday
+	Dias      int
+	Media     *float64
+}
+
+// PerfilSemanal calcula, para cada dia da semana a partir do domingo, a media das medias diarias
+// Cada dia conta uma vez, qualquer que seja o numero de registros
+func PerfilSemanal(medias *SerieDiaria) []*PerfilDiaSemana {
+	perfil := make([]*PerfilDiaSemana, 7)
+	somas := make([]float64, 7)
+	for i := range perfil {
+		perfil[i] = &PerfilDiaSemana{DiaSemana: time.Weekday(i)}
+	}
+	for _, dia := range medias.Dias() {
+		media, _ := medias.Media(dia)
+		data, _ := time.ParseInLocation(formatoDia, dia, medias.fuso)
+		somas[data.Weekday()] += media
+		perfil[data.Weekday()].Dias++
+	}
+	for i, p := range perfil {
+		if p.Dias > 0 {
+			media := somas[i] / float64(p.Dias)
+			p.Media = &media
+		}
+	}
+	return perfil
+}
+
+// MediaDasMediasDiarias evita que os dias com varios registros pesem mais na media do periodo
+func MediaDasMediasDiarias(medias *SerieDiaria) *float64 {
+	dias := medias.Dias()
+	if len(dias) == 0 {
+		return nil
+	}
+	var soma float64
+	for _, dia := range dias {
+		media, _ := medias.Media(dia)
+		soma += media
+	}
+	media := soma / float64(len(dias))
+	return &media
+}
@@ -12,11 +12,14 @@ const (
 	StatusPendente   = "PENDENTE"
 	StatusRespondido = "RESPONDIDO"
 	StatusExpirado   = "EXPIRADO"
+	// StatusCancelado marca atribuicoes pendentes de um vinculo encerrado
+	StatusCancelado = "CANCELADO"
 )
 
 var (
 	ErrAtribuicaoSemPaciente    = errors.New("atribuicao deve ter um paciente")
 	ErrAtribuicaoSemInstrumento = errors.New("atribuicao deve ter um instrumento")
+	ErrAtribuicaoCancelada      = errors.New("atribuicao cancelada pelo encerramento do vinculo")
 )
 
 // Atribuicao representa o envio de um questionário para um paciente
@@ -0,0 +1,155 @@
+package dominio
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Acoes registradas na trilha de auditoria de acesso aos dados de pacientes
+const (
+	AcaoAuditoriaListarPacientes          = "LISTAR_PACIENTES"
+	AcaoAuditoriaVerHistorico             = "VER_HISTORICO_HUMOR"
+	AcaoAuditoriaListarRegistrosHumor     = "LISTAR_REGISTROS_HUMOR"
+	AcaoAuditoriaVerResposta              = "VER_RESPOSTA"
+	AcaoAuditoriaListarNotas              = "LISTAR_NOTAS"
+	AcaoAuditoriaVerNota                  = "VER_NOTA"
+	AcaoAuditoriaVerConsentimento         = "VER_CONSENTIMENTO"
+	AcaoAuditoriaVerResumo                = "VER_RESUMO"
+	AcaoAuditoriaVerAtribuicao            = "VER_ATRIBUICAO"
+	AcaoAuditoriaListarAtribuicoes        = "LISTAR_ATRIBUICOES"
+	AcaoAuditoriaVerCorrelacaoAutoCuidado = "VER_CORRELACAO_AUTO_CUIDADO"
+	AcaoAuditoriaVerCorrelacaoMetricas    = "VER_CORRELACAO_METRICAS"
+	AcaoAuditoriaVerPainelPacientes       = "VER_PAINEL_PACIENTES"
+)
+
+// Tipos de recurso acessado
+const (
+	RecursoAuditoriaPaciente      = "PACIENTE"
+	RecursoAuditoriaRegistroHumor = "REGISTRO_HUMOR"
+	RecursoAuditoriaResposta      = "RESPOSTA"
+	RecursoAuditoriaNotaClinica   = "NOTA_CLINICA"
+	RecursoAuditoriaConsentimento = "CONSENTIMENTO"
+	RecursoAuditoriaAtribuicao    = "ATRIBUICAO"
+)
+
+// Resultado do acesso
+const (
+	ResultadoAuditoriaSucesso = "SUCESSO"
+	ResultadoAuditoriaNegado  = "NEGADO"
+	ResultadoAuditoriaFalha   = "FALHA"
+)
+
+var (
+	ErrAcaoAuditoriaVazia     = errors.New("acao de auditoria nao pode ser vazia")
+	ErrResultadoAuditoria     = errors.New("resultado de auditoria invalido")
+	ErrCadeiaAuditoriaViolada = errors.New("cadeia de auditoria violada")
+	ErrTamanhoCampoAuditoria  = errors.New("campo de auditoria excede o tamanho maximo")
+)
+
+const (
+	tamanhoMaximoUserAgent = 255
+	tamanhoMaximoIP        = 45
+)
+
+// RegistroAuditoria e uma entrada da trilha de acesso aos dados de pacientes
+// Os registros nunca sao alterados; cada um guarda o hash do anterior, e qualquer
+// alteracao ou remocao quebra a cadeia a partir dali
+type RegistroAuditoria struct {
+	ID           uint     `gorm:"primaryKey"`
+	AtorID       *uint    `gorm:"index"`
+	Ator         *Usuario `gorm:"foreignKey:AtorID"`
+	TipoAtor     string   `gorm:"type:varchar(20)"`
+	Acao         string   `gorm:"type:varchar(40);not null"`
+	TipoRecurso  string   `gorm:"type:varchar(30);not null"`
+	RecursoID    *uint
+	PacienteID   *uint     `gorm:"index"`
+	IP           string    `gorm:"type:varchar(45)"`
+	UserAgent    string    `gorm:"type:varchar(255)"`
+	Resultado    string    `gorm:"type:varchar(10);not null"`
+	CriadoEm     time.Time `gorm:"not null;index"`
+	HashAnterior string    `gorm:"type:char(64);not null"`
+	Hash         string    `gorm:"type:char(64);not null;uniqueIndex"`
+}
+
+func (RegistroAuditoria) TableName() string {
+	return "registros_auditoria"
+}
+
+// ResultadoAuditoriaPorStatus classifica a resposta HTTP do acesso
+// Recusas de autenticacao, permissao ou existencia contam como acesso negado
+func ResultadoAuditoriaPorStatus(status int) string {
+	switch {
+	case status < http.StatusBadRequest:
+		return ResultadoAuditoriaSucesso
+	case status == http.StatusUnauthorized, status == http.StatusForbidden, status == http.StatusNotFound:
+		return ResultadoAuditoriaNegado
+	default:
+		return ResultadoAuditoriaFalha
+	}
+}
+
+// Validar confere os campos obrigatorios e limita o user-agent ao tamanho da coluna
+func (r *RegistroAuditoria) Validar() error {
+	if r.Acao == "" || r.TipoRecurso == "" {
+		return ErrAcaoAuditoriaVazia
+	}
+	switch r.Resultado {
+	case ResultadoAuditoriaSucesso, ResultadoAuditoriaNegado, ResultadoAuditoriaFalha:
+	default:
+		return ErrResultadoAuditoria
+	}
+	if len(r.IP) > tamanhoMaximoIP {
+		return ErrTamanhoCampoAuditoria
+	}
+	if len(r.UserAgent) > tamanhoMaximoUserAgent {
+		r.UserAgent = r.UserAgent[:tamanhoMaximoUserAgent]
+	}
+	return nil
+}
+
+// Encadear fixa o momento do registro e calcula o hash a partir do hash do registro anterior
+// O primeiro registro da cadeia usa hash anterior vazio
+func (r *RegistroAuditoria) Encadear(hashAnterior string, agora time.Time) {
+	// O banco guarda microssegundos; truncar mantem o hash reproduzivel apos a leitura
+	r.CriadoEm = agora.UTC().Truncate(time.Microsecond)
+	r.HashAnterior = hashAnterior
+	r.Hash = r.CalcularHash()
+}
+
+// CalcularHash resume o conteudo do registro junto com o hash anterior (SHA-256 em hex)
+func (r *RegistroAuditoria) CalcularHash() string {
+	conteudo, _ := json.Marshal(struct {
+		HashAnterior string
+		AtorID       *uint
+		TipoAtor     string
+		Acao         string
+		TipoRecurso  string
+		RecursoID    *uint
+		PacienteID   *uint
+		IP           string
+		UserAgent    string
+		Resultado    string
+		CriadoEm     int64
+	}{
+		r.HashAnterior, r.AtorID, r.TipoAtor, r.Acao, r.TipoRecurso, r.RecursoID,
+		r.PacienteID, r.IP, r.UserAgent, r.Resultado, r.CriadoEm.UnixMicro(),
+	})
+	soma := sha256.Sum256(conteudo)
+	return hex.EncodeToString(soma[:])
+}
+
+// VerificarCadeia confere uma sequencia de registros em ordem de ID, partindo do hash
+// do registro que a precede. Retorna o primeiro registro adulterado, ou nil
+func VerificarCadeia(hashAnterior string, registros []*RegistroAuditoria) *RegistroAuditoria {
+	for _, r := range registros {
+		if r.HashAnterior != hashAnterior || r.CalcularHash() != r.Hash {
+			return r
+		}
+		hashAnterior = r.Hash
+	}
+	return nil
+}
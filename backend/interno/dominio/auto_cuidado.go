@@ -0,0 +1,240 @@
+package dominio
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAtividadeAutoCuidadoInvalida      = errors.New("nome da atividade de auto cuidado deve ter entre 3 e 60 caracteres")
+	ErrAtividadeAutoCuidadoDuplicada     = errors.New("atividade de auto cuidado ja existe no catalogo")
+	ErrAtividadeAutoCuidadoNaoEncontrada = errors.New("atividade de auto cuidado nao encontrada no catalogo")
+	ErrPeriodoCorrelacaoInvalido         = errors.New("periodo da correlacao deve ter entre 1 e 365 dias")
+)
+
+// AmostraMinimaCorrelacaoAutoCuidado e o numero de dias, com e sem a atividade, abaixo do qual
+// a comparacao do humor do dia seguinte e apenas indicativa
+const AmostraMinimaCorrelacaoAutoCuidado = 5
+
+const (
+	tamanhoMinimoNomeAtividade = 3
+	tamanhoMaximoNomeAtividade = 60
+)
+
+// AtividadesAutoCuidadoPadrao formam o catalogo do sistema, disponivel a todos os pacientes
+var AtividadesAutoCuidadoPadrao = []string{
+	"Exercício físico",
+	"Caminhada",
+	"Meditação",
+	"Yoga",
+	"Leitura",
+	"Música",
+	"Contato social",
+	"Tempo ao ar livre",
+	"Alimentação saudável",
+	"Hobby",
+	"Descanso",
+	"Terapia",
+}
+
+// AtividadeAutoCuidado e uma entrada do catalogo de auto cuidado
+// Sem profissional, a atividade e do sistema; com profissional, vale para os pacientes vinculados a ele
+type AtividadeAutoCuidado struct {
+	ID             uint          `gorm:"primaryKey"`
+	ProfissionalID *uint         `gorm:"index;uniqueIndex:idx_atividade_auto_cuidado_nome"`
+	Profissional   *Profissional `gorm:"foreignKey:ProfissionalID"`
+	Nome           string        `gorm:"type:varchar(60);not null"`
+	// NomeNormalizado ignora maiusculas, acentos e espacos repetidos, para que "Exercício" e "exercicio" coincidam
+	NomeNormalizado string `gorm:"type:varchar(60);not null;uniqueIndex:idx_atividade_auto_cuidado_nome"`
+	// Atividades desativadas saem do catalogo, mas continuam nos registros que as citam
+	Ativa     bool `gorm:"not null;default:true"`
+	CreatedAt time.Time
+}
+
+func (AtividadeAutoCuidado) TableName() string {
+	return "atividades_auto_cuidado"
+}
+
+// RegistroHumorAtividade liga um registro de humor as atividades do catalogo
+type RegistroHumorAtividade struct {
+	RegistroHumorID uint `gorm:"primaryKey"`
+	AtividadeID     uint `gorm:"primaryKey;index"`
+}
+
+func (RegistroHumorAtividade) TableName() string {
+	return "registros_humor_atividades"
+}
+
+var removedorAcentos = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// NormalizarNomeAtividade reduz o nome a minusculas sem acentos e com espacos simples
+func NormalizarNomeAtividade(nome string) string {
+	return removedorAcentos.Replace(strings.Join(strings.Fields(strings.ToLower(nome)), " "))
+}
+
+// NovaAtividadeAutoCuidado cria uma atividade personalizada do profissional
+func NovaAtividadeAutoCuidado(profissionalID uint, nome string) (*AtividadeAutoCuidado, error) {
+	nome = strings.Join(strings.Fields(nome), " ")
+	tamanho := len([]rune(nome))
+	if tamanho < tamanhoMinimoNomeAtividade || tamanho > tamanhoMaximoNomeAtividade {
+		return nil, ErrAtividadeAutoCuidadoInvalida
+	}
+	return &AtividadeAutoCuidado{
+		ProfissionalID:  &profissionalID,
+		Nome:            nome,
+		NomeNormalizado: NormalizarNomeAtividade(nome),
+		Ativa:           true,
+	}, nil
+}
+
+// ResolverAutoCuidado converte os IDs e os nomes enviados no registro em atividades do catalogo
+// Nomes sao aceitos para compatibilidade com versoes antigas do aplicativo e comparados ja normalizados
+// A ordem de envio e mantida e atividades repetidas aparecem uma vez
+func ResolverAutoCuidado(catalogo []*AtividadeAutoCuidado, ids []uint, nomes []string) ([]*AtividadeAutoCuidado, error) {
+	porID := make(map[uint]*AtividadeAutoCuidado, len(catalogo))
+	porNome := make(map[string]*AtividadeAutoCuidado, len(catalogo))
+	for _, atividade := range catalogo {
+		porID[atividade.ID] = atividade
+		if _, ok := porNome[atividade.NomeNormalizado]; !ok {
+			porNome[atividade.NomeNormalizado] = atividade
+		}
+	}
+
+	resolvidas := make([]*AtividadeAutoCuidado, 0, len(ids)+len(nomes))
+	vistas := make(map[uint]bool, len(ids)+len(nomes))
+	adicionar := func(atividade *AtividadeAutoCuidado, ok bool) error {
+		if !ok {
+			return ErrAtividadeAutoCuidadoNaoEncontrada
+		}
+		if !vistas[atividade.ID] {
+			vistas[atividade.ID] = true
+			resolvidas = append(resolvidas, atividade)
+		}
+		return nil
+	}
+	for _, id := range ids {
+		atividade, ok := porID[id]
+		if err := adicionar(atividade, ok); err != nil {
+			return nil, err
+		}
+	}
+	for _, nome := range nomes {
+		atividade, ok := porNome[NormalizarNomeAtividade(nome)]
+		if err := adicionar(atividade, ok); err != nil {
+			return nil, err
+		}
+	}
+	return resolvidas, nil
+}
+
+// AplicarAutoCuidado grava no registro os nomes do catalogo, que substituem o texto enviado
+func (rh *RegistroHumor) AplicarAutoCuidado(atividades []*AtividadeAutoCuidado) error {
+	nomes := make([]string, 0, len(atividades))
+	for _, atividade := range atividades {
+		nomes = append(nomes, atividade.Nome)
+	}
+	conteudo, err := json.Marshal(nomes)
+	if err != nil {
+		return err
+	}
+	rh.AutoCuidado = string(conteudo)
+	return nil
+}
+
+// CorrelacaoAutoCuidado compara o humor medio do dia seguinte aos dias com e sem a atividade
+type CorrelacaoAutoCuidado struct {
+	AtividadeID      uint
+	DiasCom          int
+	DiasSem          int
+	HumorSeguinteCom float64
+	HumorSeguinteSem float64
+	// Diferenca e HumorSeguinteCom - HumorSeguinteSem; positiva quando o dia seguinte costuma ser melhor
+	Diferenca         float64
+	AmostraSuficiente bool
+}
+
+// CorrelacionarAutoCuidado agrupa os registros por dia no fuso informado e, para cada atividade citada,
+// compara o humor medio do dia seguinte. Dias sem registro no dia seguinte ficam de fora
+// O resultado traz primeiro as atividades com amostra suficiente, da maior para a menor diferenca
+func CorrelacionarAutoCuidado(registros []*RegistroHumor, vinculos []*RegistroHumorAtividade, fuso *time.Location) []*CorrelacaoAutoCuidado {
+	type dia struct {
+		somaHumor  int
+		registros  int
+		atividades map[uint]bool
+	}
+	atividadesDoRegistro := make(map[uint][]uint)
+	for _, v := range vinculos {
+		atividadesDoRegistro[v.RegistroHumorID] = append(atividadesDoRegistro[v.RegistroHumorID], v.AtividadeID)
+	}
+
+	dias := make(map[string]*dia)
+	citadas := make(map[uint]bool)
+	for _, registro := range registros {
+		chave := registro.DataHoraRegistro.In(fuso).Format("2006-01-02")
+		d, ok := dias[chave]
+		if !ok {
+			d = &dia{atividades: make(map[uint]bool)}
+			dias[chave] = d
+		}
+		d.somaHumor += int(registro.NivelHumor)
+		d.registros++
+		for _, atividadeID := range atividadesDoRegistro[registro.ID] {
+			d.atividades[atividadeID] = true
+			citadas[atividadeID] = true
+		}
+	}
+
+	correlacoes := make([]*CorrelacaoAutoCuidado, 0, len(citadas))
+	for atividadeID := range citadas {
+		var somaCom, somaSem float64
+		c := &CorrelacaoAutoCuidado{AtividadeID: atividadeID}
+		for chave, d := range dias {
+			data, _ := time.ParseInLocation("2006-01-02", chave, fuso)
+			seguinte, ok := dias[data.AddDate(0, 0, 1).Format("2006-01-02")]
+			if !ok {
+				continue
+			}
+			humorSeguinte := float64(seguinte.somaHumor) / float64(seguinte.registros)
+			if d.atividades[atividadeID] {
+				c.DiasCom++
+				somaCom += humorSeguinte
+			} else {
+				c.DiasSem++
+				somaSem += humorSeguinte
+			}
+		}
+		if c.DiasCom > 0 {
+			c.HumorSeguinteCom = somaCom / float64(c.DiasCom)
+		}
+		if c.DiasSem > 0 {
+			c.HumorSeguinteSem = somaSem / float64(c.DiasSem)
+		}
+		if c.DiasCom > 0 && c.DiasSem > 0 {
+			c.Diferenca = c.HumorSeguinteCom - c.HumorSeguinteSem
+		}
+		c.AmostraSuficiente = c.DiasCom >= AmostraMinimaCorrelacaoAutoCuidado && c.DiasSem >= AmostraMinimaCorrelacaoAutoCuidado
+		correlacoes = append(correlacoes, c)
+	}
+
+	sort.Slice(correlacoes, func(i, j int) bool {
+		a, b := correlacoes[i], correlacoes[j]
+		if a.AmostraSuficiente != b.AmostraSuficiente {
+			return a.AmostraSuficiente
+		}
+		if a.Diferenca != b.Diferenca {
+			return a.Diferenca > b.Diferenca
+		}
+		return a.AtividadeID < b.AtividadeID
+	})
+	return correlacoes
+}
@@ -0,0 +1,168 @@
+package dominio
+
+import (
+	"errors"
+	"time"
+)
+
+// Categorias de dados que o paciente pode compartilhar com cada profissional
+// Humor cobre os niveis de humor, energia e stress do registro diario
+const (
+	CategoriaHumor         = "humor"
+	CategoriaSono          = "sono"
+	CategoriaObservacoes   = "observacoes"
+	CategoriaQuestionarios = "questionarios"
+)
+
+// Erros de validacao - Consentimento
+var (
+	ErrCategoriaConsentimentoInvalida = errors.New("categoria de consentimento invalida")
+	ErrExpiracaoConsentimentoInvalida = errors.New("data de expiracao do consentimento deve ser posterior a data de inicio e ao momento atual")
+	ErrConsentimentoNaoEncontrado     = errors.New("consentimento nao encontrado")
+	ErrConsentimentoNegado            = errors.New("paciente nao autorizou o compartilhamento destes dados")
+	ErrVinculoNaoEncontrado           = errors.New("profissional e paciente nao estao vinculados")
+)
+
+// Consentimento registra o que o paciente compartilha com um profissional
+// Cada alteracao grava uma nova versao; a de maior numero e a vigente
+type Consentimento struct {
+	ID                        uint         `gorm:"primaryKey"`
+	PacienteID                uint         `gorm:"not null;uniqueIndex:idx_consentimento_versao"`
+	ProfissionalID            uint         `gorm:"not null;uniqueIndex:idx_consentimento_versao;index"`
+	Profissional              Profissional `gorm:"foreignKey:ProfissionalID"`
+	Versao                    uint         `gorm:"not null;uniqueIndex:idx_consentimento_versao"`
+	CompartilharHumor         bool         `gorm:"not null;default:false"`
+	CompartilharSono          bool         `gorm:"not null;default:false"`
+	CompartilharObservacoes   bool         `gorm:"not null;default:false"`
+	CompartilharQuestionarios bool         `gorm:"not null;default:false"`
+	// DataInicio limita o historico visivel: dados anteriores a ela nao sao compartilhados
+	DataInicio    time.Time `gorm:"not null"`
+	DataExpiracao *time.Time
+	Revogado      bool `gorm:"not null;default:false"`
+	CreatedAt     time.Time
+}
+
+func (Consentimento) TableName() string {
+	return "consentimentos"
+}
+
+// NovoConsentimentoPadrao cria a primeira versao, gravada no momento do vinculo
+// Observacoes em texto livre ficam de fora ate o paciente autorizar
+func NovoConsentimentoPadrao(pacienteID, profissionalID uint, agora time.Time) *Consentimento {
+	return &Consentimento{
+		PacienteID:                pacienteID,
+		ProfissionalID:            profissionalID,
+		Versao:                    1,
+		CompartilharHumor:         true,
+		CompartilharSono:          true,
+		CompartilharQuestionarios: true,
+		DataInicio:                agora,
+	}
+}
+
+// NovaVersao cria a proxima versao com as categorias e o periodo informados
+func (c *Consentimento) NovaVersao(categorias []string, dataInicio time.Time, dataExpiracao *time.Time, agora time.Time) (*Consentimento, error) {
+	nova := &Consentimento{
+		PacienteID:     c.PacienteID,
+		ProfissionalID: c.ProfissionalID,
+		Versao:         c.Versao + 1,
+		DataInicio:     dataInicio,
+		DataExpiracao:  dataExpiracao,
+	}
+	for _, categoria := range categorias {
+		switch categoria {
+		case CategoriaHumor:
+			nova.CompartilharHumor = true
+		case CategoriaSono:
+			nova.CompartilharSono = true
+		case CategoriaObservacoes:
+			nova.CompartilharObservacoes = true
+		case CategoriaQuestionarios:
+			nova.CompartilharQuestionarios = true
+		default:
+			return nil, ErrCategoriaConsentimentoInvalida
+		}
+	}
+	if dataExpiracao != nil && (!dataExpiracao.After(dataInicio) || !dataExpiracao.After(agora)) {
+		return nil, ErrExpiracaoConsentimentoInvalida
+	}
+	return nova, nil
+}
+
+// Revogar cria a proxima versao sem nenhuma categoria compartilhada
+func (c *Consentimento) Revogar() *Consentimento {
+	return &Consentimento{
+		PacienteID:     c.PacienteID,
+		ProfissionalID: c.ProfissionalID,
+		Versao:         c.Versao + 1,
+		DataInicio:     c.DataInicio,
+		Revogado:       true,
+	}
+}
+
+// AmpliadoPor indica se a nova versao compartilha algo que esta versao nao compartilha:
+// uma categoria a mais, um historico mais antigo ou um prazo maior
+func (c *Consentimento) AmpliadoPor(nova *Consentimento, agora time.Time) bool {
+	for _, categoria := range nova.Categorias() {
+		if !c.Permite(categoria, agora) {
+			return true
+		}
+	}
+	if nova.DataInicio.Before(c.DataInicio) {
+		return true
+	}
+	if c.DataExpiracao != nil && (nova.DataExpiracao == nil || nova.DataExpiracao.After(*c.DataExpiracao)) {
+		return len(nova.Categorias()) > 0
+	}
+	return false
+}
+
+// Vigente indica se o consentimento nao foi revogado nem expirou
+func (c *Consentimento) Vigente(agora time.Time) bool {
+	if c.Revogado {
+		return false
+	}
+	return c.DataExpiracao == nil || agora.Before(*c.DataExpiracao)
+}
+
+// Permite indica se a categoria esta compartilhada no momento
+func (c *Consentimento) Permite(categoria string, agora time.Time) bool {
+	if !c.Vigente(agora) {
+		return false
+	}
+	switch categoria {
+	case CategoriaHumor:
+		return c.CompartilharHumor
+	case CategoriaSono:
+		return c.CompartilharSono
+	case CategoriaObservacoes:
+		return c.CompartilharObservacoes
+	case CategoriaQuestionarios:
+		return c.CompartilharQuestionarios
+	default:
+		return false
+	}
+}
+
+// CobreData indica se um dado registrado em data entra no periodo compartilhado
+func (c *Consentimento) CobreData(data time.Time) bool {
+	return !data.Before(c.DataInicio)
+}
+
+// Categorias lista as categorias compartilhadas nesta versao
+func (c *Consentimento) Categorias() []string {
+	categorias := make([]string, 0, 4)
+	if c.CompartilharHumor {
+		categorias = append(categorias, CategoriaHumor)
+	}
+	if c.CompartilharSono {
+		categorias = append(categorias, CategoriaSono)
+	}
+	if c.CompartilharObservacoes {
+		categorias = append(categorias, CategoriaObservacoes)
+	}
+	if c.CompartilharQuestionarios {
+		categorias = append(categorias, CategoriaQuestionarios)
+	}
+	return categorias
+}
@@ -0,0 +1,204 @@
+package dominio
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Status da consulta
+const (
+	StatusConsultaAgendada  = "AGENDADA"
+	StatusConsultaRealizada = "REALIZADA"
+	StatusConsultaFalta     = "FALTA"
+	StatusConsultaCancelada = "CANCELADA"
+)
+
+// Modalidades de atendimento
+const (
+	ModalidadePresencial = "PRESENCIAL"
+	ModalidadeOnline     = "ONLINE"
+)
+
+// Limites do agendamento
+const (
+	DuracaoMaximaConsulta = 8 * time.Hour
+	// MaximoRepeticoesSemanais limita uma serie recorrente a cerca de um ano
+	MaximoRepeticoesSemanais = 52
+	// AntecedenciaQuestionarioPreSessao e quanto antes da consulta o questionario e atribuido
+	AntecedenciaQuestionarioPreSessao = 24 * time.Hour
+	TamanhoMaximoLocalConsulta        = 255
+	// PeriodoPadraoAgenda e o intervalo listado quando nenhum periodo e informado
+	PeriodoPadraoAgenda = 30 * 24 * time.Hour
+	PeriodoMaximoAgenda = 366 * 24 * time.Hour
+)
+
+// Erros de validacao - Consulta
+var (
+	ErrConsultaNaoEncontrada     = errors.New("consulta nao encontrada")
+	ErrHorarioConsultaInvalido   = errors.New("fim da consulta deve ser depois do inicio")
+	ErrDuracaoConsultaInvalida   = errors.New("consulta deve durar no maximo 8 horas")
+	ErrConsultaNoPassado         = errors.New("consulta nao pode ser agendada no passado")
+	ErrModalidadeInvalida        = errors.New("modalidade de consulta invalida")
+	ErrLocalConsultaLongo        = errors.New("local da consulta deve ter no maximo 255 caracteres")
+	ErrRepeticoesInvalidas       = errors.New("repeticoes semanais devem estar entre 1 e 52")
+	ErrConflitoAgenda            = errors.New("horario conflita com outra consulta agendada")
+	ErrTransicaoConsultaInvalida = errors.New("apenas consultas agendadas podem mudar de status")
+	ErrConsultaAindaNaoOcorreu   = errors.New("consulta so pode ser marcada como realizada ou falta apos o inicio")
+	ErrStatusConsultaInvalido    = errors.New("status de consulta invalido")
+	ErrCalendarioLinkInvalido    = errors.New("link do calendario invalido")
+	ErrPeriodoAgendaInvalido     = errors.New("periodo da agenda deve ter de 1 a 366 dias")
+)
+
+// Consulta e uma sessao agendada entre profissional e paciente
+type Consulta struct {
+	ID             uint         `gorm:"primaryKey"`
+	ProfissionalID uint         `gorm:"not null;index:idx_consulta_agenda,priority:1"`
+	Profissional   Profissional `gorm:"foreignKey:ProfissionalID"`
+	PacienteID     uint         `gorm:"not null;index"`
+	Paciente       Paciente     `gorm:"foreignKey:PacienteID"`
+	Inicio         time.Time    `gorm:"not null;index:idx_consulta_agenda,priority:2"`
+	Fim            time.Time    `gorm:"not null"`
+	Modalidade     string       `gorm:"type:varchar(20);not null"`
+	Local          string       `gorm:"type:varchar(255)"`
+	Status         string       `gorm:"type:varchar(20);not null;default:'AGENDADA';index"`
+	// SerieID agrupa as consultas de uma recorrencia semanal; e o ID da primeira consulta da serie
+	SerieID *uint `gorm:"index"`
+	// InstrumentoID e o questionario atribuido automaticamente antes da sessao
+	InstrumentoID *uint
+	AtribuicaoID  *uint
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (Consulta) TableName() string {
+	return "consultas"
+}
+
+// CalendarioProfissional guarda o hash do token do link .ics de um profissional
+// Gerar um novo link invalida o anterior
+type CalendarioProfissional struct {
+	ProfissionalID uint   `gorm:"primaryKey"`
+	TokenHash      string `gorm:"type:varchar(64);not null;uniqueIndex"`
+	CriadoEm       time.Time
+}
+
+func (CalendarioProfissional) TableName() string {
+	return "calendarios_profissionais"
+}
+
+// ValidarModalidade confere se a modalidade e uma das aceitas
+func ValidarModalidade(modalidade string) error {
+	switch modalidade {
+	case ModalidadePresencial, ModalidadeOnline:
+		return nil
+	default:
+		return ErrModalidadeInvalida
+	}
+}
+
+// ValidarHorarioConsulta confere a duracao e se o horario ainda esta por vir
+func ValidarHorarioConsulta(inicio, fim, agora time.Time) error {
+	if !fim.After(inicio) {
+		return ErrHorarioConsultaInvalido
+	}
+	if fim.Sub(inicio) > DuracaoMaximaConsulta {
+		return ErrDuracaoConsultaInvalida
+	}
+	if inicio.Before(agora) {
+		return ErrConsultaNoPassado
+	}
+	return nil
+}
+
+// NovasConsultasSemanais monta a consulta e as repeticoes semanais seguintes, todas agendadas
+func NovasConsultasSemanais(profissionalID, pacienteID uint, inicio, fim time.Time, modalidade, local string, instrumentoID *uint, repeticoes int, agora time.Time) ([]*Consulta, error) {
+	if repeticoes < 1 || repeticoes > MaximoRepeticoesSemanais {
+		return nil, ErrRepeticoesInvalidas
+	}
+	if err := ValidarModalidade(modalidade); err != nil {
+		return nil, err
+	}
+	local = strings.TrimSpace(local)
+	if utf8.RuneCountInString(local) > TamanhoMaximoLocalConsulta {
+		return nil, ErrLocalConsultaLongo
+	}
+	if err := ValidarHorarioConsulta(inicio, fim, agora); err != nil {
+		return nil, err
+	}
+
+	consultas := make([]*Consulta, repeticoes)
+	for i := range consultas {
+		// AddDate mantem o horario local mesmo quando a serie atravessa uma mudanca de fuso
+		consultas[i] = &Consulta{
+			ProfissionalID: profissionalID,
+			PacienteID:     pacienteID,
+			Inicio:         inicio.AddDate(0, 0, 7*i),
+			Fim:            fim.AddDate(0, 0, 7*i),
+			Modalidade:     modalidade,
+			Local:          local,
+			Status:         StatusConsultaAgendada,
+			InstrumentoID:  instrumentoID,
+		}
+	}
+	return consultas, nil
+}
+
+// Conflita indica se os horarios se sobrepoem; encostar no fim da outra consulta nao e conflito
+func (c *Consulta) Conflita(inicio, fim time.Time) bool {
+	return c.Inicio.Before(fim) && c.Fim.After(inicio)
+}
+
+// Remarcar altera o horario de uma consulta ainda agendada
+func (c *Consulta) Remarcar(inicio, fim, agora time.Time) error {
+	if c.Status != StatusConsultaAgendada {
+		return ErrTransicaoConsultaInvalida
+	}
+	if err := ValidarHorarioConsulta(inicio, fim, agora); err != nil {
+		return err
+	}
+	c.Inicio, c.Fim = inicio, fim
+	return nil
+}
+
+// AlterarStatus aplica a transicao a partir de AGENDADA
+// Realizada e falta so fazem sentido depois do inicio; cancelar pode ser feito a qualquer momento
+func (c *Consulta) AlterarStatus(status string, agora time.Time) error {
+	switch status {
+	case StatusConsultaRealizada, StatusConsultaFalta, StatusConsultaCancelada:
+	default:
+		return ErrStatusConsultaInvalido
+	}
+	if c.Status != StatusConsultaAgendada {
+		return ErrTransicaoConsultaInvalida
+	}
+	if status != StatusConsultaCancelada && agora.Before(c.Inicio) {
+		return ErrConsultaAindaNaoOcorreu
+	}
+	c.Status = status
+	return nil
+}
+
+// AguardaQuestionario indica se o questionario pre-sessao ja deve ser atribuido
+func (c *Consulta) AguardaQuestionario(agora time.Time) bool {
+	return c.Status == StatusConsultaAgendada && c.InstrumentoID != nil && c.AtribuicaoID == nil &&
+		c.Inicio.After(agora) && !c.Inicio.After(agora.Add(AntecedenciaQuestionarioPreSessao))
+}
+
+// PeriodoAgenda resolve o intervalo [de, ate) da listagem a partir das datas opcionais da query
+// ate e inclusiva na query, entao o intervalo vai ate o fim desse dia
+func PeriodoAgenda(de, ate *time.Time, agora time.Time) (time.Time, time.Time, error) {
+	inicio := time.Date(agora.Year(), agora.Month(), agora.Day(), 0, 0, 0, 0, agora.Location())
+	if de != nil {
+		inicio = *de
+	}
+	fim := inicio.Add(PeriodoPadraoAgenda)
+	if ate != nil {
+		fim = ate.AddDate(0, 0, 1)
+	}
+	if !fim.After(inicio) || fim.Sub(inicio) > PeriodoMaximoAgenda {
+		return inicio, fim, ErrPeriodoAgendaInvalido
+	}
+	return inicio, fim, nil
+}
@@ -2,6 +2,7 @@ package dominio
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -15,20 +16,43 @@ var (
 	ErrDataExpiracaoNoPassado = errors.New("data de expiracao nao pode ser no passado")
 	ErrConviteExpirado        = errors.New("convite expirado")
 	ErrConviteJaUtilizado     = errors.New("convite ja foi utilizado")
+	ErrConviteRevogado        = errors.New("convite revogado")
+	ErrConviteNaoEncontrado   = errors.New("convite nao encontrado")
+	ErrMaxUsosConviteInvalido = errors.New("numero maximo de usos deve estar entre 1 e 500")
+	ErrConviteEmailVariosUsos = errors.New("convite vinculado a um e-mail deve ter uso unico")
+	ErrConviteOutroEmail      = errors.New("convite destinado a outro e-mail")
+	ErrEnvioConviteFalhou     = errors.New("nao foi possivel enviar o convite por e-mail")
+	ErrFormatoQRCodeInvalido  = errors.New("formato do QR code deve ser png ou svg")
 )
 
+// Status do convite na listagem do profissional
+const (
+	ConviteAtivo    = "ATIVO"
+	ConviteUsado    = "USADO"
+	ConviteExpirado = "EXPIRADO"
+	ConviteRevogado = "REVOGADO"
+)
+
+// MaxUsosConvite limita convites de uso coletivo, como programas em grupo
+const MaxUsosConvite = 500
+
 type Convite struct {
 	ID             uint         `gorm:"primarykey"`
 	ProfissionalID uint         `gorm:"not null"`
 	Profissional   Profissional `gorm:"foreignKey:ProfissionalID;constraint:OnDelete:CASCADE"`
 	Token          string       `gorm:"unique;not null"`
 	DataExpiracao  time.Time    `gorm:"not null"`
-	Usado          bool         `gorm:"default:false"`
-	PacienteID     *uint        // Ponteiro para permitir nulo, indica qual paciente usou o convite
+	Usado          bool         `gorm:"default:false"` // Indica que todos os usos foram consumidos
+	PacienteID     *uint        // Ponteiro para permitir nulo, indica o ultimo paciente que usou o convite
 	Paciente       Paciente     `gorm:"foreignKey:PacienteID;constraint:OnDelete:CASCADE"`
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	DeletedAt      gorm.DeletedAt `gorm:"index"`
+	MaxUsos        uint         `gorm:"not null;default:1"`
+	Usos           uint         `gorm:"not null;default:0"`
+	// EmailDestinatario restringe o resgate ao paciente com esse e-mail; vazio aceita qualquer paciente
+	EmailDestinatario string `gorm:"type:varchar(255)"`
+	RevogadoEm        *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         gorm.DeletedAt `gorm:"index"`
 }
 
 // Metodos de validacao - LOGICA DE NEGOCIO (Convite)
@@ -52,6 +76,16 @@ func (c *Convite) ValidarDataExpiracao() error {
 	return nil
 }
 
+func (c *Convite) ValidarUsos() error {
+	if c.MaxUsos > MaxUsosConvite {
+		return ErrMaxUsosConviteInvalido
+	}
+	if c.EmailDestinatario != "" && c.limiteUsos() > 1 {
+		return ErrConviteEmailVariosUsos
+	}
+	return nil
+}
+
 // Validacao completa do Convite
 func (c *Convite) Validar() error {
 	if err := c.ValidarToken(); err != nil {
@@ -60,12 +94,77 @@ func (c *Convite) Validar() error {
 	if err := c.ValidarDataExpiracao(); err != nil {
 		return err
 	}
+	if err := c.ValidarUsos(); err != nil {
+		return err
+	}
 	return nil
 }
 
 // EstaValido verifica se o convite ainda e valido
 func (c *Convite) EstaValido() bool {
-	return !c.Usado && c.DataExpiracao.After(time.Now())
+	return !c.Usado && !c.EstaRevogado() && c.DataExpiracao.After(time.Now())
+}
+
+// EstaRevogado verifica se o profissional revogou o convite
+func (c *Convite) EstaRevogado() bool {
+	return c.RevogadoEm != nil
+}
+
+// Status resume a situacao do convite; a revogacao prevalece sobre o uso e o uso sobre a expiracao
+func (c *Convite) Status(agora time.Time) string {
+	switch {
+	case c.EstaRevogado():
+		return ConviteRevogado
+	case c.Usado:
+		return ConviteUsado
+	case !c.DataExpiracao.After(agora):
+		return ConviteExpirado
+	default:
+		return ConviteAtivo
+	}
+}
+
+// Disponivel retorna o motivo pelo qual o convite nao pode mais ser usado, ou nil se estiver ativo
+func (c *Convite) Disponivel(agora time.Time) error {
+	switch c.Status(agora) {
+	case ConviteRevogado:
+		return ErrConviteRevogado
+	case ConviteUsado:
+		return ErrConviteJaUtilizado
+	case ConviteExpirado:
+		return ErrConviteExpirado
+	}
+	return nil
+}
+
+// Revogar impede novos usos de um convite ativo
+func (c *Convite) Revogar(agora time.Time) error {
+	if err := c.Disponivel(agora); err != nil {
+		return err
+	}
+	c.RevogadoEm = &agora
+	return nil
+}
+
+// AceitaEmail verifica se o paciente com o e-mail informado pode usar o convite
+func (c *Convite) AceitaEmail(email string) bool {
+	return c.EmailDestinatario == "" || strings.EqualFold(c.EmailDestinatario, strings.TrimSpace(email))
+}
+
+// UsosRestantes retorna quantos pacientes ainda podem usar o convite
+func (c *Convite) UsosRestantes() uint {
+	if c.Usado || c.Usos >= c.limiteUsos() {
+		return 0
+	}
+	return c.limiteUsos() - c.Usos
+}
+
+// limiteUsos trata convites sem MaxUsos, anteriores ao uso coletivo, como de uso unico
+func (c *Convite) limiteUsos() uint {
+	if c.MaxUsos == 0 {
+		return 1
+	}
+	return c.MaxUsos
 }
 
 // EstaExpirado verifica se o convite expirou
@@ -78,8 +177,10 @@ func (c *Convite) JaFoiUtilizado() bool {
 	return c.Usado
 }
 
-// UtilizarConvite marca o convite como utilizado por um paciente
+// UtilizarConvite registra o uso do convite por um paciente
+// O convite fica marcado como utilizado quando o ultimo uso disponivel e consumido
 func (c *Convite) UtilizarConvite(pacienteID uint) {
-	c.Usado = true
+	c.Usos++
+	c.Usado = c.Usos >= c.limiteUsos()
 	c.PacienteID = &pacienteID
 }
@@ -0,0 +1,241 @@
+package dominio
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Chaves das metricas fixas do registro de humor nas correlacoes
+// As metricas personalizadas usam "metrica_<id>"
+const (
+	ChaveMetricaHumor   = "humor"
+	ChaveMetricaSono    = "sono"
+	ChaveMetricaEnergia = "energia"
+	ChaveMetricaStress  = "stress"
+)
+
+// Avisos sobre a confiabilidade de uma correlacao
+const (
+	// AvisoAmostraInsuficiente indica menos pares de dias que AmostraMinimaCorrelacao
+	AvisoAmostraInsuficiente = "AMOSTRA_INSUFICIENTE"
+	// AvisoSemVariacao indica que uma das metricas nao variou nos dias comparados; o coeficiente fica indefinido
+	AvisoSemVariacao = "SEM_VARIACAO"
+)
+
+// AmostraMinimaCorrelacao e o numero de pares de dias abaixo do qual a correlacao e apenas indicativa
+// Com menos de 3 pares o coeficiente nao e calculado
+const AmostraMinimaCorrelacao = 10
+
+const paresMinimosCoeficiente = 3
+
+const formatoDia = "2006-01-02"
+
+// SerieDiaria guarda a media diaria de uma metrica, com os dias no formato 2006-01-02
+type SerieDiaria struct {
+	Chave    string
+	Rotulo   string
+	soma     map[string]float64
+	contagem map[string]int
+	fuso     *time.Location
+}
+
+func NovaSerieDiaria(chave, rotulo string, fuso *time.Location) *SerieDiaria {
+	return &SerieDiaria{Chave: chave, Rotulo: rotulo, soma: make(map[string]float64), contagem: make(map[string]int), fuso: fuso}
+}
+
+// Adicionar soma o valor ao dia do instante, no fuso da serie
+func (s *SerieDiaria) Adicionar(instante time.Time, valor float64) {
+	dia := instante.In(s.fuso).Format(formatoDia)
+	s.soma[dia] += valor
+	s.contagem[dia]++
+}
+
+// Media retorna a media do dia e se houve registro nele
+func (s *SerieDiaria) Media(dia string) (float64, bool) {
+	n := s.contagem[dia]
+	if n == 0 {
+		return 0, false
+	}
+	return s.soma[dia] / float64(n), true
+}
+
+// Dias retorna os dias com valor, em ordem cronologica
+func (s *SerieDiaria) Dias() []string {
+	dias := make([]string, 0, len(s.contagem))
+	for dia := range s.contagem {
+		dias = append(dias, dia)
+	}
+	sort.Strings(dias)
+	return dias
+}
+
+// PontoMetrica e o valor de uma metrica em um registro
+type PontoMetrica struct {
+	Instante time.Time
+	Valor    float64
+}
+
+// SerieMetrica guarda os valores de uma metrica registro a registro
+type SerieMetrica struct {
+	Chave  string
+	Rotulo string
+	Pontos []PontoMetrica
+}
+
+// Diaria agrupa os valores da serie pela media de cada dia no fuso informado
+func (s *SerieMetrica) Diaria(fuso *time.Location) *SerieDiaria {
+	diaria := NovaSerieDiaria(s.Chave, s.Rotulo, fuso)
+	for _, ponto := range s.Pontos {
+		diaria.Adicionar(ponto.Instante, ponto.Valor)
+	}
+	return diaria
+}
+
+// SeriesMetricasDosRegistros monta as series de humor, sono, energia e stress, nesta ordem
+func SeriesMetricasDosRegistros(registros []*RegistroHumor) []*SerieMetrica {
+	humor := &SerieMetrica{Chave: ChaveMetricaHumor, Rotulo: "Humor"}
+	sono := &SerieMetrica{Chave: ChaveMetricaSono, Rotulo: "Horas de sono"}
+	energia := &SerieMetrica{Chave: ChaveMetricaEnergia, Rotulo: "Energia"}
+	stress := &SerieMetrica{Chave: ChaveMetricaStress, Rotulo: "Stress"}
+	for _, registro := range registros {
+		humor.Pontos = append(humor.Pontos, PontoMetrica{Instante: registro.DataHoraRegistro, Valor: float64(registro.NivelHumor)})
+		sono.Pontos = append(sono.Pontos, PontoMetrica{Instante: registro.DataHoraRegistro, Valor: float64(registro.HorasSono)})
+		energia.Pontos = append(energia.Pontos, PontoMetrica{Instante: registro.DataHoraRegistro, Valor: float64(registro.NivelEnergia)})
+		stress.Pontos = append(stress.Pontos, PontoMetrica{Instante: registro.DataHoraRegistro, Valor: float64(registro.NivelStress)})
+	}
+	return []*SerieMetrica{humor, sono, energia, stress}
+}
+
+// SeriesDiariasDosRegistros monta as series diarias de humor, sono, energia e stress, nesta ordem
+func SeriesDiariasDosRegistros(registros []*RegistroHumor, fuso *time.Location) []*SerieDiaria {
+	series := SeriesMetricasDosRegistros(registros)
+	diarias := make([]*SerieDiaria, 0, len(series))
+	for _, serie := range series {
+		diarias = append(diarias, serie.Diaria(fuso))
+	}
+	return diarias
+}
+
+// CorrelacaoMetricas compara duas metricas dia a dia
+// Com Defasagem 1, a metrica A do dia t e comparada com a metrica B do dia t+1
+type CorrelacaoMetricas struct {
+	MetricaA          string
+	MetricaB          string
+	Defasagem         int
+	Pares             int
+	Pearson           *float64
+	Spearman          *float64
+	AmostraSuficiente bool
+	Avisos            []string
+}
+
+// CorrelacionarSeries calcula as correlacoes do mesmo dia entre cada par de series e as defasadas
+// de um dia entre cada par ordenado, incluindo a serie consigo mesma (persistencia de um dia para o outro)
+// O resultado traz primeiro as do mesmo dia, na ordem das series
+func CorrelacionarSeries(series []*SerieDiaria) []*CorrelacaoMetricas {
+	correlacoes := make([]*CorrelacaoMetricas, 0)
+	for i := range series {
+		for j := i + 1; j < len(series); j++ {
+			correlacoes = append(correlacoes, correlacionar(series[i], series[j], 0))
+		}
+	}
+	for _, a := range series {
+		for _, b := range series {
+			correlacoes = append(correlacoes, correlacionar(a, b, 1))
+		}
+	}
+	return correlacoes
+}
+
+func correlacionar(a, b *SerieDiaria, defasagem int) *CorrelacaoMetricas {
+	var x, y []float64
+	for _, dia := range a.Dias() {
+		valorA, _ := a.Media(dia)
+		data, _ := time.ParseInLocation(formatoDia, dia, a.fuso)
+		valorB, ok := b.Media(data.AddDate(0, 0, defasagem).Format(formatoDia))
+		if !ok {
+			continue
+		}
+		x = append(x, valorA)
+		y = append(y, valorB)
+	}
+
+	c := &CorrelacaoMetricas{MetricaA: a.Chave, MetricaB: b.Chave, Defasagem: defasagem, Pares: len(x), Avisos: make([]string, 0)}
+	c.AmostraSuficiente = c.Pares >= AmostraMinimaCorrelacao
+	if !c.AmostraSuficiente {
+		c.Avisos = append(c.Avisos, AvisoAmostraInsuficiente)
+	}
+	if c.Pares < paresMinimosCoeficiente {
+		return c
+	}
+	pearson, ok := Pearson(x, y)
+	if !ok {
+		c.Avisos = append(c.Avisos, AvisoSemVariacao)
+		return c
+	}
+	spearman, _ := Spearman(x, y)
+	c.Pearson, c.Spearman = &pearson, &spearman
+	return c
+}
+
+// Pearson calcula o coeficiente de correlacao linear entre x e y, de mesmo tamanho
+// Retorna false quando ha menos de 2 pares ou uma das series e constante
+func Pearson(x, y []float64) (float64, bool) {
+	n := len(x)
+	if n < 2 || n != len(y) {
+		return 0, false
+	}
+	var mediaX, mediaY float64
+	for i := 0; i < n; i++ {
+		mediaX += x[i]
+		mediaY += y[i]
+	}
+	mediaX /= float64(n)
+	mediaY /= float64(n)
+
+	var cov, varX, varY float64
+	for i := 0; i < n; i++ {
+		dx, dy := x[i]-mediaX, y[i]-mediaY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0, false
+	}
+	r := cov / math.Sqrt(varX*varY)
+	// Limita erros de arredondamento ao intervalo [-1, 1]
+	return math.Max(-1, math.Min(1, r)), true
+}
+
+// Spearman calcula a correlacao de postos entre x e y; empates recebem o posto medio
+func Spearman(x, y []float64) (float64, bool) {
+	if len(x) != len(y) {
+		return 0, false
+	}
+	return Pearson(postos(x), postos(y))
+}
+
+func postos(valores []float64) []float64 {
+	indices := make([]int, len(valores))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool { return valores[indices[i]] < valores[indices[j]] })
+
+	resultado := make([]float64, len(valores))
+	for inicio := 0; inicio < len(indices); {
+		fim := inicio
+		for fim+1 < len(indices) && valores[indices[fim+1]] == valores[indices[inicio]] {
+			fim++
+		}
+		// Postos comecam em 1; o grupo empatado recebe a media dos postos que ocupa
+		posto := float64(inicio+fim)/2 + 1
+		for k := inicio; k <= fim; k++ {
+			resultado[indices[k]] = posto
+		}
+		inicio = fim + 1
+	}
+	return resultado
+}
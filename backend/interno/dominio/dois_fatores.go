@@ -0,0 +1,122 @@
+package dominio
+
+import (
+	"errors"
+	"time"
+)
+
+// Parametros da autenticacao em dois fatores
+const (
+	EmissorDoisFatores           = "MindTrace"
+	QuantidadeCodigosRecuperacao = 10
+	BytesCodigoRecuperacao       = 10 // 80 bits por codigo de recuperacao
+	ValidadeDesafioDoisFatores   = 5 * time.Minute
+	LimiteTentativasDesafio      = 5
+)
+
+// Erros de validacao - DoisFatores
+var (
+	ErrDoisFatoresApenasProfissionais = errors.New("autenticacao em dois fatores disponivel apenas para profissionais")
+	ErrDoisFatoresJaAtivo             = errors.New("autenticacao em dois fatores ja esta ativa")
+	ErrDoisFatoresNaoIniciado         = errors.New("inscricao em dois fatores nao iniciada")
+	ErrDoisFatoresNaoAtivo            = errors.New("autenticacao em dois fatores nao esta ativa")
+	ErrDoisFatoresObrigatorio         = errors.New("autenticacao em dois fatores e obrigatoria para profissionais")
+	ErrCodigoDoisFatoresInvalido      = errors.New("codigo de verificacao invalido")
+	ErrDesafioDoisFatoresInvalido     = errors.New("desafio de login invalido")
+	ErrDesafioDoisFatoresExpirado     = errors.New("desafio de login expirado, entre novamente")
+)
+
+// DoisFatores guarda o segredo TOTP de um usuario
+// O segredo so passa a ser exigido no login depois de Ativo
+type DoisFatores struct {
+	UsuarioID uint    `gorm:"primaryKey"`
+	Usuario   Usuario `gorm:"foreignKey:UsuarioID;constraint:OnDelete:CASCADE"`
+	Segredo   string  `gorm:"type:text;serializer:cifrado;not null"`
+	Ativo     bool    `gorm:"not null;default:false"`
+	AtivadoEm *time.Time
+	// UltimoPassoUsado impede que o mesmo codigo seja aceito duas vezes
+	UltimoPassoUsado int64 `gorm:"not null;default:0"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+func (DoisFatores) TableName() string {
+	return "dois_fatores"
+}
+
+// VerificarCodigo valida um codigo TOTP e registra o passo consumido
+func (d *DoisFatores) VerificarCodigo(codigo string, agora time.Time) bool {
+	segredo, err := DecodificarSegredoTOTP(d.Segredo)
+	if err != nil {
+		return false
+	}
+	passo, ok := VerificarCodigoTOTP(segredo, codigo, agora, d.UltimoPassoUsado)
+	if !ok {
+		return false
+	}
+	d.UltimoPassoUsado = passo
+	return true
+}
+
+// Ativar passa a exigir o segundo fator nos proximos logins
+func (d *DoisFatores) Ativar(agora time.Time) {
+	d.Ativo = true
+	d.AtivadoEm = &agora
+}
+
+// CodigoRecuperacao e um codigo de uso unico para entrar sem o autenticador
+type CodigoRecuperacao struct {
+	ID         uint   `gorm:"primaryKey"`
+	UsuarioID  uint   `gorm:"not null;index"`
+	CodigoHash string `gorm:"type:varchar(72);not null"` // hash bcrypt do codigo normalizado
+	DataUso    *time.Time
+	CreatedAt  time.Time
+}
+
+func (CodigoRecuperacao) TableName() string {
+	return "codigos_recuperacao"
+}
+
+// Utilizar marca o codigo como consumido
+func (c *CodigoRecuperacao) Utilizar() {
+	agora := time.Now()
+	c.DataUso = &agora
+}
+
+// DesafioDoisFatores liga a primeira etapa do login (senha) a segunda (codigo)
+type DesafioDoisFatores struct {
+	ID            uint      `gorm:"primaryKey"`
+	UsuarioID     uint      `gorm:"not null;index"`
+	Usuario       Usuario   `gorm:"foreignKey:UsuarioID;constraint:OnDelete:CASCADE"`
+	TokenHash     string    `gorm:"type:varchar(64);unique;not null"`
+	DataExpiracao time.Time `gorm:"not null"`
+	Tentativas    int       `gorm:"not null;default:0"`
+	DataUso       *time.Time
+	CreatedAt     time.Time
+}
+
+func (DesafioDoisFatores) TableName() string {
+	return "desafios_dois_fatores"
+}
+
+// VerificarUso retorna o erro adequado caso o desafio nao possa mais ser usado
+func (d *DesafioDoisFatores) VerificarUso() error {
+	if d.DataUso != nil || d.Tentativas >= LimiteTentativasDesafio {
+		return ErrDesafioDoisFatoresInvalido
+	}
+	if d.DataExpiracao.Before(time.Now()) {
+		return ErrDesafioDoisFatoresExpirado
+	}
+	return nil
+}
+
+// RegistrarTentativa contabiliza um codigo incorreto
+func (d *DesafioDoisFatores) RegistrarTentativa() {
+	d.Tentativas++
+}
+
+// Utilizar marca o desafio como concluido
+func (d *DesafioDoisFatores) Utilizar() {
+	agora := time.Now()
+	d.DataUso = &agora
+}
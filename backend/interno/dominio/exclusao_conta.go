@@ -0,0 +1,79 @@
+package dominio
+
+import (
+	"errors"
+	"time"
+)
+
+// Status do pedido de exclusao de conta
+const (
+	StatusExclusaoAgendada  = "AGENDADA"
+	StatusExclusaoCancelada = "CANCELADA"
+	StatusExclusaoConcluida = "CONCLUIDA"
+)
+
+// PeriodoCarenciaExclusao e o prazo padrao em que o titular pode desistir da exclusao
+const PeriodoCarenciaExclusao = 30 * 24 * time.Hour
+
+// NomeUsuarioAnonimizado substitui o nome de contas excluidas
+const NomeUsuarioAnonimizado = "Usuario removido"
+
+// Erros de validacao - ExclusaoConta
+var (
+	ErrExclusaoJaAgendada  = errors.New("ja existe uma exclusao de conta agendada")
+	ErrExclusaoNaoAgendada = errors.New("nenhuma exclusao de conta agendada")
+)
+
+// ExclusaoConta registra o pedido de exclusao e o fim do periodo de carencia
+// O registro e mantido apos a anonimizacao como comprovante do atendimento ao titular
+type ExclusaoConta struct {
+	ID           uint   `gorm:"primaryKey"`
+	UsuarioID    uint   `gorm:"not null;index"`
+	Status       string `gorm:"type:varchar(20);not null;default:'AGENDADA';index"`
+	Motivo       string `gorm:"type:text"`
+	DataExecucao time.Time
+	CanceladaEm  *time.Time
+	ConcluidaEm  *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (ExclusaoConta) TableName() string {
+	return "exclusoes_conta"
+}
+
+// NovaExclusaoConta agenda a exclusao para o fim do periodo de carencia
+func NovaExclusaoConta(usuarioID uint, motivo string, agora time.Time, carencia time.Duration) *ExclusaoConta {
+	return &ExclusaoConta{
+		UsuarioID:    usuarioID,
+		Status:       StatusExclusaoAgendada,
+		Motivo:       motivo,
+		DataExecucao: agora.Add(carencia),
+	}
+}
+
+// Agendada indica se a exclusao ainda pode ser cancelada
+func (e *ExclusaoConta) Agendada() bool {
+	return e.Status == StatusExclusaoAgendada
+}
+
+// Vencida indica se o periodo de carencia terminou
+func (e *ExclusaoConta) Vencida(agora time.Time) bool {
+	return e.Agendada() && !agora.Before(e.DataExecucao)
+}
+
+// Cancelar interrompe a exclusao durante o periodo de carencia
+func (e *ExclusaoConta) Cancelar(agora time.Time) error {
+	if !e.Agendada() {
+		return ErrExclusaoNaoAgendada
+	}
+	e.Status = StatusExclusaoCancelada
+	e.CanceladaEm = &agora
+	return nil
+}
+
+// Concluir marca a exclusao como executada
+func (e *ExclusaoConta) Concluir(agora time.Time) {
+	e.Status = StatusExclusaoConcluida
+	e.ConcluidaEm = &agora
+}
@@ -0,0 +1,129 @@
+package dominio
+
+import (
+	"errors"
+	"time"
+)
+
+// Status da exportacao de dados do titular (LGPD)
+const (
+	StatusExportacaoPendente    = "PENDENTE"
+	StatusExportacaoProcessando = "PROCESSANDO"
+	StatusExportacaoConcluida   = "CONCLUIDA"
+	StatusExportacaoFalhou      = "FALHOU"
+)
+
+// Formatos do pacote exportado
+const (
+	FormatoExportacaoJSON = "json"
+	FormatoExportacaoZIP  = "zip"
+)
+
+// Parametros da exportacao
+const (
+	// ValidadeExportacao define por quanto tempo o arquivo e o link de download ficam disponiveis
+	ValidadeExportacao        = 24 * time.Hour
+	VersaoManifestoExportacao = "1"
+)
+
+// Erros de validacao - ExportacaoDados
+var (
+	ErrFormatoExportacaoInvalido = errors.New("formato de exportacao invalido, use json ou zip")
+	ErrExportacaoNaoEncontrada   = errors.New("exportacao nao encontrada")
+	ErrExportacaoEmAndamento     = errors.New("ja existe uma exportacao em andamento")
+	ErrExportacaoNaoConcluida    = errors.New("exportacao ainda nao foi concluida")
+	ErrExportacaoExpirada        = errors.New("link de download expirado, solicite uma nova exportacao")
+	ErrLinkDownloadInvalido      = errors.New("link de download invalido")
+)
+
+// ExportacaoDados acompanha a geracao assincrona do pacote com os dados do titular
+// Apenas o hash do token de download e persistido
+type ExportacaoDados struct {
+	ID                uint    `gorm:"primaryKey"`
+	UsuarioID         uint    `gorm:"not null;index"`
+	Usuario           Usuario `gorm:"foreignKey:UsuarioID;constraint:OnDelete:CASCADE"`
+	Formato           string  `gorm:"type:varchar(10);not null"`
+	Status            string  `gorm:"type:varchar(20);not null;default:'PENDENTE';index"`
+	CaminhoArquivo    string  `gorm:"type:text"`
+	TokenDownloadHash string  `gorm:"type:varchar(64);index"`
+	Erro              string  `gorm:"type:text"`
+	DataExpiracao     *time.Time
+	ConcluidaEm       *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+func (ExportacaoDados) TableName() string {
+	return "exportacoes_dados"
+}
+
+// ValidarFormatoExportacao aceita apenas os formatos suportados
+func ValidarFormatoExportacao(formato string) error {
+	if formato != FormatoExportacaoJSON && formato != FormatoExportacaoZIP {
+		return ErrFormatoExportacaoInvalido
+	}
+	return nil
+}
+
+// EmAndamento indica se o pacote ainda esta sendo gerado
+func (e *ExportacaoDados) EmAndamento() bool {
+	return e.Status == StatusExportacaoPendente || e.Status == StatusExportacaoProcessando
+}
+
+// IniciarProcessamento marca o inicio da geracao do pacote
+func (e *ExportacaoDados) IniciarProcessamento() {
+	e.Status = StatusExportacaoProcessando
+	e.Erro = ""
+}
+
+// Concluir registra o arquivo gerado e o inicio da validade do link
+func (e *ExportacaoDados) Concluir(caminho, tokenHash string, agora time.Time) {
+	expiracao := agora.Add(ValidadeExportacao)
+	e.Status = StatusExportacaoConcluida
+	e.CaminhoArquivo = caminho
+	e.TokenDownloadHash = tokenHash
+	e.ConcluidaEm = &agora
+	e.DataExpiracao = &expiracao
+}
+
+// Falhar registra o motivo da falha na geracao
+func (e *ExportacaoDados) Falhar(motivo string) {
+	e.Status = StatusExportacaoFalhou
+	e.Erro = motivo
+}
+
+// EstaExpirada indica se o arquivo ja passou da validade
+func (e *ExportacaoDados) EstaExpirada(agora time.Time) bool {
+	return e.DataExpiracao != nil && !agora.Before(*e.DataExpiracao)
+}
+
+// VerificarDownload retorna o erro adequado caso o pacote nao possa ser baixado
+func (e *ExportacaoDados) VerificarDownload(agora time.Time) error {
+	if e.Status != StatusExportacaoConcluida {
+		return ErrExportacaoNaoConcluida
+	}
+	if e.EstaExpirada(agora) {
+		return ErrExportacaoExpirada
+	}
+	return nil
+}
+
+// DadosTitular reune tudo o que esta ligado a um usuario para a exportacao
+// Profissional e Paciente sao nulos conforme o tipo do usuario
+type DadosTitular struct {
+	Usuario                Usuario
+	Profissional           *Profissional
+	Paciente               *Paciente
+	RegistrosHumor         []*RegistroHumor
+	RevisoesRegistrosHumor []*RevisaoRegistroHumor
+	ValoresMetricas        []*ValorMetricaRegistro
+	Atribuicoes            []*Atribuicao
+	Convites               []*Convite
+	Consentimentos         []*Consentimento
+	// EncerramentosVinculo traz o historico de vinculos encerrados, com o motivo informado
+	EncerramentosVinculo []*EncerramentoVinculo
+	Consultas            []*Consulta
+	Notificacoes         []*Notificacao
+	// AcessosDados sao os acessos de terceiros aos dados do paciente
+	AcessosDados []*RegistroAuditoria
+}
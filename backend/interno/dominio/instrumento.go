@@ -16,6 +16,7 @@ var (
 	ErrAlgoritmoPontuacaoVazio    = errors.New("algoritmo de pontuacao nao pode estar vazio")
 	ErrAlgoritmoPontuacaoInvalido = errors.New("algoritmo de pontuacao invalido")
 	ErrVersaoInvalida             = errors.New("versao deve ser maior que zero")
+	ErrInstrumentoNaoEncontrado   = errors.New("instrumento nao encontrado")
 	ErrInstrumentoSemPerguntas    = errors.New("instrumento deve ter ao menos uma pergunta")
 	ErrInstrumentoSemOpcoesEscala = errors.New("instrumento deve ter opcoes de escala definidas")
 	ErrInstrumentoPadraoImutavel  = errors.New("instrumentos padronizados nao podem ser editados")
@@ -0,0 +1,149 @@
+package dominio
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+)
+
+var (
+	ErrRotuloMetricaInvalido             = errors.New("rotulo da metrica deve ter entre 3 e 60 caracteres")
+	ErrTipoMetricaInvalido               = errors.New("tipo da metrica deve ser ESCALA, NUMERO ou SIM_NAO")
+	ErrFaixaMetricaPersonalizadaInvalida = errors.New("faixa da metrica invalida: o minimo deve ser menor que o maximo e, na escala, ambos inteiros")
+	ErrMetricaPersonalizadaDuplicada     = errors.New("paciente ja acompanha uma metrica com este rotulo")
+	ErrMetricaPersonalizadaNaoEncontrada = errors.New("metrica personalizada nao encontrada para o paciente")
+	ErrLimiteMetricasPersonalizadas      = errors.New("paciente ja acompanha o numero maximo de metricas personalizadas")
+	ErrValorMetricaInvalido              = errors.New("valor fora da faixa ou do tipo da metrica personalizada")
+	ErrValorMetricaDuplicado             = errors.New("metrica personalizada informada mais de uma vez no registro")
+)
+
+// Tipos de metrica personalizada
+// ESCALA aceita inteiros na faixa, NUMERO aceita decimais na faixa e SIM_NAO aceita 0 (nao) ou 1 (sim)
+const (
+	TipoMetricaEscala = "ESCALA"
+	TipoMetricaNumero = "NUMERO"
+	TipoMetricaSimNao = "SIM_NAO"
+)
+
+// LimiteMetricasPersonalizadas e o numero maximo de metricas ativas por paciente
+const LimiteMetricasPersonalizadas = 10
+
+const (
+	tamanhoMinimoRotuloMetrica = 3
+	tamanhoMaximoRotuloMetrica = 60
+)
+
+// MetricaPersonalizada e uma dimensao do diario definida por um profissional para um paciente,
+// como ansiedade, dor ou uso de medicacao, alem das metricas fixas do registro de humor
+type MetricaPersonalizada struct {
+	ID             uint          `gorm:"primaryKey"`
+	PacienteID     uint          `gorm:"not null;index"`
+	Paciente       *Paciente     `gorm:"foreignKey:PacienteID;constraint:OnDelete:CASCADE"`
+	ProfissionalID uint          `gorm:"not null;index"`
+	Profissional   *Profissional `gorm:"foreignKey:ProfissionalID"`
+	Rotulo         string        `gorm:"type:varchar(60);not null"`
+	Tipo           string        `gorm:"type:varchar(10);not null"`
+	Minimo         float64       `gorm:"not null"`
+	Maximo         float64       `gorm:"not null"`
+	// Metricas desativadas saem do formulario, mas os valores ja registrados continuam nos graficos
+	Ativa     bool `gorm:"not null;default:true"`
+	CreatedAt time.Time
+}
+
+func (MetricaPersonalizada) TableName() string {
+	return "metricas_personalizadas"
+}
+
+// ValorMetricaRegistro guarda o valor de uma metrica personalizada em um registro de humor
+type ValorMetricaRegistro struct {
+	RegistroHumorID uint                  `gorm:"primaryKey"`
+	MetricaID       uint                  `gorm:"primaryKey;index"`
+	Metrica         *MetricaPersonalizada `gorm:"foreignKey:MetricaID"`
+	Valor           float64               `gorm:"not null"`
+}
+
+func (ValorMetricaRegistro) TableName() string {
+	return "registros_humor_metricas"
+}
+
+// NovaMetricaPersonalizada cria uma metrica do profissional para o paciente
+// Em SIM_NAO a faixa informada e ignorada e fica entre 0 e 1
+func NovaMetricaPersonalizada(pacienteID, profissionalID uint, rotulo, tipo string, minimo, maximo float64) (*MetricaPersonalizada, error) {
+	rotulo = strings.Join(strings.Fields(rotulo), " ")
+	tamanho := len([]rune(rotulo))
+	if tamanho < tamanhoMinimoRotuloMetrica || tamanho > tamanhoMaximoRotuloMetrica {
+		return nil, ErrRotuloMetricaInvalido
+	}
+
+	tipo = strings.ToUpper(strings.TrimSpace(tipo))
+	switch tipo {
+	case TipoMetricaSimNao:
+		minimo, maximo = 0, 1
+	case TipoMetricaEscala:
+		if minimo != math.Trunc(minimo) || maximo != math.Trunc(maximo) {
+			return nil, ErrFaixaMetricaPersonalizadaInvalida
+		}
+	case TipoMetricaNumero:
+	default:
+		return nil, ErrTipoMetricaInvalido
+	}
+	if minimo >= maximo {
+		return nil, ErrFaixaMetricaPersonalizadaInvalida
+	}
+
+	return &MetricaPersonalizada{
+		PacienteID:     pacienteID,
+		ProfissionalID: profissionalID,
+		Rotulo:         rotulo,
+		Tipo:           tipo,
+		Minimo:         minimo,
+		Maximo:         maximo,
+		Ativa:          true,
+	}, nil
+}
+
+// MesmoRotulo compara rotulos ignorando maiusculas, acentos e espacos repetidos
+func (m *MetricaPersonalizada) MesmoRotulo(rotulo string) bool {
+	return NormalizarNomeAtividade(m.Rotulo) == NormalizarNomeAtividade(rotulo)
+}
+
+// ValidarValor confere se o valor respeita a faixa e o tipo da metrica
+func (m *MetricaPersonalizada) ValidarValor(valor float64) error {
+	if math.IsNaN(valor) || valor < m.Minimo || valor > m.Maximo {
+		return ErrValorMetricaInvalido
+	}
+	if m.Tipo != TipoMetricaNumero && valor != math.Trunc(valor) {
+		return ErrValorMetricaInvalido
+	}
+	return nil
+}
+
+// ResolverValoresMetricas valida os valores enviados no registro contra as metricas ativas do paciente
+// A ordem de envio e mantida; cada metrica pode aparecer uma vez
+func ResolverValoresMetricas(metricas []*MetricaPersonalizada, valores []*ValorMetricaRegistro) ([]*ValorMetricaRegistro, error) {
+	porID := make(map[uint]*MetricaPersonalizada, len(metricas))
+	for _, metrica := range metricas {
+		if metrica.Ativa {
+			porID[metrica.ID] = metrica
+		}
+	}
+
+	resolvidos := make([]*ValorMetricaRegistro, 0, len(valores))
+	vistas := make(map[uint]bool, len(valores))
+	for _, valor := range valores {
+		metrica, ok := porID[valor.MetricaID]
+		if !ok {
+			return nil, ErrMetricaPersonalizadaNaoEncontrada
+		}
+		if vistas[valor.MetricaID] {
+			return nil, ErrValorMetricaDuplicado
+		}
+		if err := metrica.ValidarValor(valor.Valor); err != nil {
+			return nil, err
+		}
+		vistas[valor.MetricaID] = true
+		resolvidos = append(resolvidos, &ValorMetricaRegistro{MetricaID: metrica.ID, Metrica: metrica, Valor: valor.Valor})
+	}
+	return resolvidos, nil
+}
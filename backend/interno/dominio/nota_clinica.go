@@ -0,0 +1,228 @@
+package dominio
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Tipos de sessao aceitos nas notas clinicas
+const (
+	TipoSessaoIndividual = "INDIVIDUAL"
+	TipoSessaoCasal      = "CASAL"
+	TipoSessaoFamilia    = "FAMILIA"
+	TipoSessaoGrupo      = "GRUPO"
+	TipoSessaoAvaliacao  = "AVALIACAO"
+	TipoSessaoDevolutiva = "DEVOLUTIVA"
+)
+
+// Limites do conteudo de uma nota clinica
+const (
+	TamanhoMaximoTextoNota = 20000
+	MaximoTagsNota         = 10
+	TamanhoMaximoTagNota   = 30
+)
+
+// Erros de validacao - Nota clinica
+var (
+	ErrNotaClinicaNaoEncontrada      = errors.New("nota clinica nao encontrada")
+	ErrTextoNotaVazio                = errors.New("texto da nota e obrigatorio")
+	ErrTextoNotaLongo                = errors.New("texto da nota deve ter no maximo 20000 caracteres")
+	ErrTipoSessaoInvalido            = errors.New("tipo de sessao invalido")
+	ErrDataSessaoInvalida            = errors.New("data da sessao nao pode estar no futuro")
+	ErrTagNotaInvalida               = errors.New("tags devem ter de 1 a 30 caracteres")
+	ErrMuitasTagsNota                = errors.New("nota pode ter no maximo 10 tags")
+	ErrNotaApenasAutor               = errors.New("apenas o autor pode alterar ou compartilhar a nota")
+	ErrCompartilhamentoNotaInvalido  = errors.New("nota so pode ser compartilhada com outro profissional que acompanha o paciente")
+	ErrCompartilhamentoNaoEncontrado = errors.New("nota nao esta compartilhada com este profissional")
+)
+
+// ConteudoNota e a parte da nota gravada cifrada
+type ConteudoNota struct {
+	Texto string   `json:"texto"`
+	Tags  []string `json:"tags"`
+}
+
+// Normalizar remove espacos, padroniza as tags em minusculas sem repeticao e valida os limites
+func (c *ConteudoNota) Normalizar() error {
+	c.Texto = strings.TrimSpace(c.Texto)
+	if c.Texto == "" {
+		return ErrTextoNotaVazio
+	}
+	if utf8.RuneCountInString(c.Texto) > TamanhoMaximoTextoNota {
+		return ErrTextoNotaLongo
+	}
+
+	tags := make([]string, 0, len(c.Tags))
+	vistas := make(map[string]bool, len(c.Tags))
+	for _, tag := range c.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || utf8.RuneCountInString(tag) > TamanhoMaximoTagNota {
+			return ErrTagNotaInvalida
+		}
+		if !vistas[tag] {
+			vistas[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > MaximoTagsNota {
+		return ErrMuitasTagsNota
+	}
+	c.Tags = tags
+	return nil
+}
+
+// Contem indica se o texto ou alguma tag contem o termo, sem diferenciar maiusculas
+func (c *ConteudoNota) Contem(termo string) bool {
+	termo = strings.ToLower(strings.TrimSpace(termo))
+	if termo == "" {
+		return true
+	}
+	if strings.Contains(strings.ToLower(c.Texto), termo) {
+		return true
+	}
+	for _, tag := range c.Tags {
+		if strings.Contains(tag, termo) {
+			return true
+		}
+	}
+	return false
+}
+
+// PossuiTag indica se a nota tem exatamente a tag informada
+func (c *ConteudoNota) PossuiTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, t := range c.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// NotaClinica e o registro do profissional sobre um paciente
+// O conteudo fica nas revisoes; a nota aponta apenas para a revisao atual
+type NotaClinica struct {
+	ID             uint `gorm:"primaryKey"`
+	ProfissionalID uint `gorm:"not null;index"`
+	PacienteID     uint `gorm:"not null;index"`
+	RevisaoAtual   uint `gorm:"not null"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+
+	Compartilhamentos []CompartilhamentoNota `gorm:"foreignKey:NotaID"`
+	// Atual e preenchida pelo repositorio nas listagens
+	Atual *RevisaoNotaClinica `gorm:"-"`
+}
+
+func (NotaClinica) TableName() string {
+	return "notas_clinicas"
+}
+
+// RevisaoNotaClinica guarda uma versao da nota
+// As revisoes nunca sao alteradas; editar a nota grava uma nova revisao
+type RevisaoNotaClinica struct {
+	ID              uint      `gorm:"primaryKey"`
+	NotaID          uint      `gorm:"not null;uniqueIndex:idx_revisao_nota"`
+	Revisao         uint      `gorm:"not null;uniqueIndex:idx_revisao_nota"`
+	DataSessao      time.Time `gorm:"not null;index"`
+	TipoSessao      string    `gorm:"type:varchar(20);not null"`
+	ConteudoCifrado []byte    `gorm:"not null"`
+	CreatedAt       time.Time
+
+	// Conteudo e preenchido pelo servico ao decifrar
+	Conteudo ConteudoNota `gorm:"-"`
+}
+
+func (RevisaoNotaClinica) TableName() string {
+	return "revisoes_notas_clinicas"
+}
+
+// CompartilhamentoNota libera a leitura da nota a um profissional que tambem acompanha o paciente
+type CompartilhamentoNota struct {
+	NotaID          uint      `gorm:"primaryKey"`
+	ProfissionalID  uint      `gorm:"primaryKey;index"`
+	CompartilhadoEm time.Time `gorm:"not null"`
+	RevogadoEm      *time.Time
+}
+
+func (CompartilhamentoNota) TableName() string {
+	return "compartilhamentos_notas"
+}
+
+// ValidarTipoSessao confere se o tipo e um dos aceitos
+func ValidarTipoSessao(tipo string) error {
+	switch tipo {
+	case TipoSessaoIndividual, TipoSessaoCasal, TipoSessaoFamilia, TipoSessaoGrupo, TipoSessaoAvaliacao, TipoSessaoDevolutiva:
+		return nil
+	default:
+		return ErrTipoSessaoInvalido
+	}
+}
+
+// NovaRevisaoNota valida os metadados da revisao; o conteudo e cifrado pelo servico
+func NovaRevisaoNota(nota *NotaClinica, dataSessao time.Time, tipoSessao string, agora time.Time) (*RevisaoNotaClinica, error) {
+	if err := ValidarTipoSessao(tipoSessao); err != nil {
+		return nil, err
+	}
+	if dataSessao.After(agora) {
+		return nil, ErrDataSessaoInvalida
+	}
+	return &RevisaoNotaClinica{
+		NotaID:     nota.ID,
+		Revisao:    nota.RevisaoAtual + 1,
+		DataSessao: dataSessao,
+		TipoSessao: tipoSessao,
+	}, nil
+}
+
+// Autor indica se o profissional escreveu a nota
+func (n *NotaClinica) Autor(profissionalID uint) bool {
+	return n.ProfissionalID == profissionalID
+}
+
+// CompartilhadaCom indica se a nota esta compartilhada com o profissional
+func (n *NotaClinica) CompartilhadaCom(profissionalID uint) bool {
+	for _, c := range n.Compartilhamentos {
+		if c.ProfissionalID == profissionalID && c.RevogadoEm == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Compartilhar libera a nota ao profissional, reaproveitando um compartilhamento revogado
+func (n *NotaClinica) Compartilhar(profissionalID uint, agora time.Time) (*CompartilhamentoNota, error) {
+	if n.Autor(profissionalID) {
+		return nil, ErrCompartilhamentoNotaInvalido
+	}
+	for i := range n.Compartilhamentos {
+		if n.Compartilhamentos[i].ProfissionalID == profissionalID {
+			c := &n.Compartilhamentos[i]
+			if c.RevogadoEm != nil {
+				c.CompartilhadoEm = agora
+				c.RevogadoEm = nil
+			}
+			return c, nil
+		}
+	}
+	n.Compartilhamentos = append(n.Compartilhamentos, CompartilhamentoNota{
+		NotaID:          n.ID,
+		ProfissionalID:  profissionalID,
+		CompartilhadoEm: agora,
+	})
+	return &n.Compartilhamentos[len(n.Compartilhamentos)-1], nil
+}
+
+// RevogarCompartilhamento retira a leitura do profissional
+func (n *NotaClinica) RevogarCompartilhamento(profissionalID uint, agora time.Time) (*CompartilhamentoNota, error) {
+	for i := range n.Compartilhamentos {
+		c := &n.Compartilhamentos[i]
+		if c.ProfissionalID == profissionalID && c.RevogadoEm == nil {
+			c.RevogadoEm = &agora
+			return c, nil
+		}
+	}
+	return nil, ErrCompartilhamentoNaoEncontrado
+}
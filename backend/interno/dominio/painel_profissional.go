@@ -0,0 +1,34 @@
+package dominio
+
+import "time"
+
+// PacienteDaCarteira e um paciente com vinculo ativo com o profissional
+type PacienteDaCarteira struct {
+	PacienteID  uint
+	Nome        string
+	VinculadoEm *time.Time
+}
+
+// UltimoRegistroPaciente e o instante do registro de humor mais recente do paciente
+type UltimoRegistroPaciente struct {
+	PacienteID       uint
+	DataHoraRegistro time.Time
+}
+
+// AtribuicoesPorStatus conta as atribuicoes do profissional a um paciente em um status
+type AtribuicoesPorStatus struct {
+	PacienteID uint
+	Status     string
+	Total      int
+}
+
+// UltimaPontuacao e a resposta mais recente do paciente a um instrumento atribuido pelo profissional
+type UltimaPontuacao struct {
+	PacienteID     uint
+	InstrumentoID  uint
+	Codigo         string
+	Nome           string
+	PontuacaoTotal float64
+	Classificacao  string
+	DataResposta   time.Time
+}
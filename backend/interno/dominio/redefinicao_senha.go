@@ -0,0 +1,76 @@
+package dominio
+
+import (
+	"errors"
+	"time"
+)
+
+// ValidadeTokenRedefinicaoSenha define por quanto tempo um token de redefinicao pode ser usado
+const ValidadeTokenRedefinicaoSenha = 1 * time.Hour
+
+// Erros de validacao - RedefinicaoSenha
+var (
+	ErrTokenRedefinicaoInvalido      = errors.New("token de redefinicao invalido")
+	ErrTokenRedefinicaoExpirado      = errors.New("token de redefinicao expirado")
+	ErrTokenRedefinicaoJaUtilizado   = errors.New("token de redefinicao ja foi utilizado")
+	ErrTokenRedefinicaoHashVazio     = errors.New("hash do token de redefinicao nao pode estar vazio")
+	ErrRedefinicaoSemUsuario         = errors.New("redefinicao de senha deve ter um usuario")
+	ErrRedefinicaoExpiracaoNoPassado = errors.New("data de expiracao da redefinicao nao pode ser no passado")
+)
+
+// RedefinicaoSenha representa uma solicitacao de "esqueci minha senha".
+// O token em texto claro so existe no e-mail enviado ao usuario; aqui fica apenas o hash.
+type RedefinicaoSenha struct {
+	ID            uint      `gorm:"primaryKey"`
+	UsuarioID     uint      `gorm:"not null;index"`
+	Usuario       Usuario   `gorm:"foreignKey:UsuarioID;constraint:OnDelete:CASCADE"`
+	TokenHash     string    `gorm:"type:varchar(64);unique;not null"`
+	DataExpiracao time.Time `gorm:"not null"`
+	DataUso       *time.Time
+	CreatedAt     time.Time
+}
+
+func (RedefinicaoSenha) TableName() string {
+	return "redefinicoes_senha"
+}
+
+// Validacao completa da RedefinicaoSenha
+func (r *RedefinicaoSenha) Validar() error {
+	if r.UsuarioID == 0 {
+		return ErrRedefinicaoSemUsuario
+	}
+	if r.TokenHash == "" {
+		return ErrTokenRedefinicaoHashVazio
+	}
+	if r.DataExpiracao.Before(time.Now()) {
+		return ErrRedefinicaoExpiracaoNoPassado
+	}
+	return nil
+}
+
+// EstaExpirada verifica se o token de redefinicao expirou
+func (r *RedefinicaoSenha) EstaExpirada() bool {
+	return r.DataExpiracao.Before(time.Now())
+}
+
+// JaFoiUtilizada verifica se o token ja foi consumido
+func (r *RedefinicaoSenha) JaFoiUtilizada() bool {
+	return r.DataUso != nil
+}
+
+// VerificarUso retorna o erro adequado caso o token nao possa mais ser usado
+func (r *RedefinicaoSenha) VerificarUso() error {
+	if r.JaFoiUtilizada() {
+		return ErrTokenRedefinicaoJaUtilizado
+	}
+	if r.EstaExpirada() {
+		return ErrTokenRedefinicaoExpirado
+	}
+	return nil
+}
+
+// Utilizar marca o token como consumido
+func (r *RedefinicaoSenha) Utilizar() {
+	agora := time.Now()
+	r.DataUso = &agora
+}
@@ -1,41 +1,224 @@
 package dominio
 
 import (
+	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 )
 
 // Erros de validacao - RegistroHumor
 var (
-	ErrNivelHumorInvalido       = errors.New("nivel de humor deve estar entre 1 e 5")
-	ErrHorasSonoInvalido        = errors.New("horas de sono deve estar entre 0 e 12")
-	ErrNivelEnergiaInvalido     = errors.New("nivel de energia deve estar entre 1 e 10")
-	ErrNivelStressInvalido      = errors.New("nivel de stress deve estar entre 1 e 10")
-	ErrAutoCuidadoVazio         = errors.New("auto cuidado nao pode estar vazio")
-	ErrAutoCuidadoInvalido      = errors.New("auto cuidado deve ter no minimo 3 caracteres")
-	ErrDataHoraRegistroVazia    = errors.New("data e hora do registro e obrigatoria")
-	ErrDataHoraRegistroNoFuturo = errors.New("data e hora do registro nao pode ser no futuro")
+	ErrNivelHumorInvalido         = errors.New("nivel de humor deve estar entre 1 e 5")
+	ErrHorasSonoInvalido          = errors.New("horas de sono deve estar entre 0 e 12")
+	ErrNivelEnergiaInvalido       = errors.New("nivel de energia deve estar entre 1 e 10")
+	ErrNivelStressInvalido        = errors.New("nivel de stress deve estar entre 1 e 10")
+	ErrAutoCuidadoVazio           = errors.New("auto cuidado nao pode estar vazio")
+	ErrAutoCuidadoInvalido        = errors.New("auto cuidado deve ter no minimo 3 caracteres")
+	ErrDataHoraRegistroVazia      = errors.New("data e hora do registro e obrigatoria")
+	ErrDataHoraRegistroNoFuturo   = errors.New("data e hora do registro nao pode ser no futuro")
+	ErrRegistroHumorNaoEncontrado = errors.New("registro de humor nao encontrado")
+	ErrJanelaCorrecaoEncerrada    = errors.New("prazo para corrigir o registro de humor encerrado")
+	ErrFaixaMetricaInvalida       = errors.New("faixa de metrica invalida: minimo maior que o maximo")
+	ErrCursorDiarioInvalido       = errors.New("cursor de paginacao invalido")
+	ErrDataHoraRegistroAntiga     = errors.New("data e hora do registro anterior ao prazo aceito para registros retroativos")
+	ErrChaveIdempotenciaInvalida  = errors.New("chave de idempotencia deve ter entre 1 e 64 caracteres")
+	ErrLoteRegistrosHumorInvalido = errors.New("lote deve ter entre 1 e 100 registros")
+)
+
+// JanelaCorrecaoRegistroHumor e o prazo padrao, contado da criacao, para o paciente corrigir ou apagar um registro
+const JanelaCorrecaoRegistroHumor = 24 * time.Hour
+
+// JanelaRetroativaRegistroHumor e o prazo padrao, contado do envio, aceito para registros feitos sem conexao
+const JanelaRetroativaRegistroHumor = 7 * 24 * time.Hour
+
+// Limites da sincronizacao em lote
+const (
+	TamanhoMaximoLoteRegistrosHumor = 100
+	tamanhoMaximoChaveIdempotencia  = 64
+)
+
+// Operacoes que geram uma revisao do registro de humor
+const (
+	OperacaoRevisaoEdicao   = "EDICAO"
+	OperacaoRevisaoExclusao = "EXCLUSAO"
 )
 
 // RegistroHumor armazena as entradas de humor do paciente.
 type RegistroHumor struct {
 	ID               uint      `gorm:"primaryKey"`
-	PacienteID       uint      `gorm:"not null;uniqueIndex:idx_registro_humor_completo"`
+	PacienteID       uint      `gorm:"not null;index;uniqueIndex:idx_registro_humor_chave"`
 	Paciente         Paciente  `gorm:"foreignKey:PacienteID;constraint:OnDelete:CASCADE"`
-	NivelHumor       int16     `gorm:"not null;check:nivel_humor >= 1 AND nivel_humor <= 5;uniqueIndex:idx_registro_humor_completo"`
-	HorasSono        int16     `gorm:"not null;check:horas_sono >= 0 AND horas_sono <= 12;uniqueIndex:idx_registro_humor_completo"`
-	NivelEnergia     int16     `gorm:"not null;check:nivel_energia >= 1 and nivel_energia <= 10;uniqueIndex:idx_registro_humor_completo"`
-	NivelStress      int16     `gorm:"not null;check:nivel_stress >= 1 and nivel_stress <= 10;uniqueIndex:idx_registro_humor_completo"`
-	AutoCuidado      string    `gorm:"type:jsonb;default:'[]';not null;uniqueIndex:idx_registro_humor_completo"`
-	Observacoes      string    `gorm:"type:text;uniqueIndex:idx_registro_humor_completo"`
+	NivelHumor       int16     `gorm:"not null;check:nivel_humor >= 1 AND nivel_humor <= 5"`
+	HorasSono        int16     `gorm:"not null;check:horas_sono >= 0 AND horas_sono <= 12"`
+	NivelEnergia     int16     `gorm:"not null;check:nivel_energia >= 1 and nivel_energia <= 10"`
+	NivelStress      int16     `gorm:"not null;check:nivel_stress >= 1 and nivel_stress <= 10"`
+	AutoCuidado      string    `gorm:"type:jsonb;default:'[]';not null"`
+	Observacoes      string    `gorm:"type:text;serializer:cifrado"`
 	DataHoraRegistro time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
-	CreatedAt        time.Time
+	// ChaveIdempotencia e gerada pelo aplicativo para cada registro feito sem conexao
+	// Reenvios com a mesma chave devolvem o registro ja gravado; registros criados online nao tem chave
+	ChaveIdempotencia *string `gorm:"type:varchar(64);uniqueIndex:idx_registro_humor_chave"`
+	CreatedAt         time.Time
+	// Metricas traz os valores das metricas personalizadas, gravados em registros_humor_metricas
+	Metricas []*ValorMetricaRegistro `gorm:"-"`
 }
 
 func (RegistroHumor) TableName() string {
 	return "registros_humor"
 }
 
+// RevisaoRegistroHumor guarda os valores de um registro antes de cada correcao pelo paciente
+// As revisoes nunca sao alteradas e permanecem quando o registro e apagado
+type RevisaoRegistroHumor struct {
+	ID               uint      `gorm:"primaryKey"`
+	RegistroHumorID  uint      `gorm:"not null;index"`
+	PacienteID       uint      `gorm:"not null;index"`
+	Revisao          uint      `gorm:"not null"`
+	Operacao         string    `gorm:"type:varchar(10);not null"`
+	NivelHumor       int16     `gorm:"not null"`
+	HorasSono        int16     `gorm:"not null"`
+	NivelEnergia     int16     `gorm:"not null"`
+	NivelStress      int16     `gorm:"not null"`
+	AutoCuidado      string    `gorm:"type:jsonb;default:'[]';not null"`
+	Observacoes      string    `gorm:"type:text;serializer:cifrado"`
+	DataHoraRegistro time.Time `gorm:"not null"`
+	CreatedAt        time.Time
+}
+
+func (RevisaoRegistroHumor) TableName() string {
+	return "revisoes_registros_humor"
+}
+
+// NovaRevisaoRegistroHumor copia os valores atuais do registro antes de uma edicao ou exclusao
+func NovaRevisaoRegistroHumor(rh *RegistroHumor, revisao uint, operacao string) *RevisaoRegistroHumor {
+	return &RevisaoRegistroHumor{
+		RegistroHumorID:  rh.ID,
+		PacienteID:       rh.PacienteID,
+		Revisao:          revisao,
+		Operacao:         operacao,
+		NivelHumor:       rh.NivelHumor,
+		HorasSono:        rh.HorasSono,
+		NivelEnergia:     rh.NivelEnergia,
+		NivelStress:      rh.NivelStress,
+		AutoCuidado:      rh.AutoCuidado,
+		Observacoes:      rh.Observacoes,
+		DataHoraRegistro: rh.DataHoraRegistro,
+	}
+}
+
+// PodeCorrigir indica se o registro ainda esta dentro do prazo de correcao, contado da criacao
+func (rh *RegistroHumor) PodeCorrigir(agora time.Time, janela time.Duration) error {
+	if agora.After(rh.CreatedAt.Add(janela)) {
+		return ErrJanelaCorrecaoEncerrada
+	}
+	return nil
+}
+
+// Corrigir troca os valores informados pelo paciente, mantendo o ID e o momento da criacao
+func (rh *RegistroHumor) Corrigir(correcao *RegistroHumor) {
+	rh.NivelHumor = correcao.NivelHumor
+	rh.HorasSono = correcao.HorasSono
+	rh.NivelEnergia = correcao.NivelEnergia
+	rh.NivelStress = correcao.NivelStress
+	rh.AutoCuidado = correcao.AutoCuidado
+	rh.Observacoes = correcao.Observacoes
+	rh.DataHoraRegistro = correcao.DataHoraRegistro
+	rh.Metricas = correcao.Metricas
+}
+
+// FaixaMetrica limita uma metrica do registro; extremos nulos nao restringem
+type FaixaMetrica struct {
+	Min *int16
+	Max *int16
+}
+
+func (f FaixaMetrica) Validar() error {
+	if f.Min != nil && f.Max != nil && *f.Min > *f.Max {
+		return ErrFaixaMetricaInvalida
+	}
+	return nil
+}
+
+// Restringe indica se a faixa filtra algum valor
+func (f FaixaMetrica) Restringe() bool {
+	return f.Min != nil || f.Max != nil
+}
+
+// FiltroRegistrosHumor seleciona os registros do diario de um paciente
+// Periodo, metricas e cursor sao aplicados no banco; auto cuidado e texto dependem do
+// conteudo decifrado e sao aplicados por AtendeFiltroConteudo
+type FiltroRegistrosHumor struct {
+	De          *time.Time
+	AntesDe     *time.Time
+	Humor       FaixaMetrica
+	Sono        FaixaMetrica
+	Energia     FaixaMetrica
+	Stress      FaixaMetrica
+	AutoCuidado []string
+	Busca       string
+	// Cursor: continua a listagem decrescente apos o registro com este momento e ID
+	CursorDataHora *time.Time
+	CursorID       uint
+}
+
+// Validar confere as faixas de metricas
+func (f *FiltroRegistrosHumor) Validar() error {
+	for _, faixa := range []FaixaMetrica{f.Humor, f.Sono, f.Energia, f.Stress} {
+		if err := faixa.Validar(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ItensAutoCuidado le a lista de auto cuidado gravada em JSON
+func (rh *RegistroHumor) ItensAutoCuidado() []string {
+	var itens []string
+	if err := json.Unmarshal([]byte(rh.AutoCuidado), &itens); err != nil {
+		return nil
+	}
+	return itens
+}
+
+// AtendeFiltroConteudo confere os filtros de auto cuidado e de texto nas observacoes
+// O registro precisa ter todos os itens de auto cuidado pedidos; as comparacoes ignoram maiusculas
+func (rh *RegistroHumor) AtendeFiltroConteudo(f *FiltroRegistrosHumor) bool {
+	if len(f.AutoCuidado) > 0 {
+		itens := rh.ItensAutoCuidado()
+		for _, pedido := range f.AutoCuidado {
+			encontrado := false
+			for _, item := range itens {
+				if strings.EqualFold(strings.TrimSpace(item), strings.TrimSpace(pedido)) {
+					encontrado = true
+					break
+				}
+			}
+			if !encontrado {
+				return false
+			}
+		}
+	}
+	termo := strings.ToLower(strings.TrimSpace(f.Busca))
+	return termo == "" || strings.Contains(strings.ToLower(rh.Observacoes), termo)
+}
+
+// ValidarChaveIdempotencia confere a chave enviada pelo aplicativo na sincronizacao
+func ValidarChaveIdempotencia(chave string) error {
+	if chave == "" || len(chave) > tamanhoMaximoChaveIdempotencia {
+		return ErrChaveIdempotenciaInvalida
+	}
+	return nil
+}
+
+// ValidarJanelaRetroativa recusa registros anteriores ao prazo aceito para envios sem conexao
+func (rh *RegistroHumor) ValidarJanelaRetroativa(agora time.Time, janela time.Duration) error {
+	if rh.DataHoraRegistro.Before(agora.Add(-janela)) {
+		return ErrDataHoraRegistroAntiga
+	}
+	return nil
+}
+
 // Metodos de validacao - LOGICA DE NEGOCIO (RegistroHumor)
 func (rh *RegistroHumor) ValidarNivelHumor() error {
 	if rh.NivelHumor < 1 || rh.NivelHumor > 5 {
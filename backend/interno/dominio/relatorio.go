@@ -1 +1,16 @@
 package dominio
+
+import "errors"
+
+// Erros do periodo da analise historica, informado em dias ou pelas datas de/ate
+var (
+	ErrPeriodoHistoricoInvalido = errors.New("periodo invalido")
+	ErrIntervaloAnaliseInvalido = errors.New("intervalo da analise invalido: 'de' deve ser anterior a 'ate' e a hoje, com no maximo 366 dias")
+)
+
+// Amostragem dos graficos da analise historica: um ponto por registro ou a media de cada dia ou semana
+const (
+	AmostragemRegistro = "REGISTRO"
+	AmostragemDia      = AgrupamentoDia
+	AmostragemSemana   = AgrupamentoSemana
+)
@@ -0,0 +1,162 @@
+package dominio
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IdadeMaioridade e a idade a partir da qual o paciente decide sozinho sobre o consentimento
+const IdadeMaioridade = 18
+
+// Origem do encerramento do vinculo com o responsavel
+const (
+	TutelaEncerradaPeloResponsavel = "RESPONSAVEL"
+	TutelaEncerradaPeloPaciente    = "PACIENTE"
+	TutelaEncerradaPorExclusao     = "EXCLUSAO_CONTA"
+)
+
+// Acoes do responsavel registradas na auditoria
+const (
+	AcaoResponsavelVerResumo              = "VER_RESUMO"
+	AcaoResponsavelListarAtribuicoes      = "LISTAR_ATRIBUICOES"
+	AcaoResponsavelVerAtribuicao          = "VER_ATRIBUICAO"
+	AcaoResponsavelResponderAtribuicao    = "RESPONDER_ATRIBUICAO"
+	AcaoResponsavelVerConsentimentos      = "VER_CONSENTIMENTOS"
+	AcaoResponsavelAtualizarConsentimento = "ATUALIZAR_CONSENTIMENTO"
+	AcaoResponsavelRevogarConsentimento   = "REVOGAR_CONSENTIMENTO"
+	AcaoResponsavelEncerrarVinculo        = "ENCERRAR_VINCULO"
+)
+
+// Erros de validacao - Responsavel
+var (
+	ErrResponsavelNaoEncontrado      = errors.New("responsavel nao encontrado")
+	ErrDependenteNaoEncontrado       = errors.New("dependente nao encontrado")
+	ErrPacienteNaoDependente         = errors.New("apenas pacientes dependentes ou menores de idade podem ter responsavel")
+	ErrResponsavelJaVinculado        = errors.New("responsavel ja esta vinculado a este paciente")
+	ErrParentescoLongo               = errors.New("parentesco deve ter no maximo 50 caracteres")
+	ErrMenorNaoRemoveResponsavel     = errors.New("paciente menor de idade nao pode remover o responsavel")
+	ErrConsentimentoExigeResponsavel = errors.New("consentimento de paciente menor de idade deve ser ampliado pelo responsavel")
+	ErrAtribuicaoDeOutroPaciente     = errors.New("atribuicao nao pertence ao dependente")
+	ErrAcaoResponsavelInvalida       = errors.New("acao do responsavel invalida")
+)
+
+// Responsavel e o usuario que acompanha um ou mais pacientes dependentes
+type Responsavel struct {
+	ID        uint    `gorm:"primaryKey"`
+	UsuarioID uint    `gorm:"unique;not null"`
+	Usuario   Usuario `gorm:"foreignKey:UsuarioID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (Responsavel) TableName() string {
+	return "responsaveis"
+}
+
+// Validar confere os dados do usuario do responsavel
+func (r *Responsavel) Validar() error {
+	return r.Usuario.Validar()
+}
+
+// VinculoResponsavel e a linha da tabela responsavel_paciente
+// Como o vinculo com o profissional, o encerrado permanece para o historico
+type VinculoResponsavel struct {
+	ResponsavelID uint        `gorm:"primaryKey"`
+	Responsavel   Responsavel `gorm:"foreignKey:ResponsavelID;constraint:OnDelete:CASCADE"`
+	PacienteID    uint        `gorm:"primaryKey"`
+	Paciente      Paciente    `gorm:"foreignKey:PacienteID;constraint:OnDelete:CASCADE"`
+	Parentesco    string      `gorm:"type:varchar(50)"`
+	VinculadoEm   time.Time   `gorm:"not null"`
+	EncerradoEm   *time.Time  `gorm:"index"`
+	EncerradoPor  string      `gorm:"type:varchar(20)"`
+}
+
+func (VinculoResponsavel) TableName() string {
+	return "responsavel_paciente"
+}
+
+// NovoVinculoResponsavel cria o vinculo autorizado pelo paciente
+func NovoVinculoResponsavel(responsavelID, pacienteID uint, parentesco string, agora time.Time) (*VinculoResponsavel, error) {
+	v := &VinculoResponsavel{
+		ResponsavelID: responsavelID,
+		PacienteID:    pacienteID,
+		Parentesco:    strings.TrimSpace(parentesco),
+		VinculadoEm:   agora,
+	}
+	if len(v.Parentesco) > 50 {
+		return nil, ErrParentescoLongo
+	}
+	return v, nil
+}
+
+// Ativo indica se o responsavel ainda acompanha o paciente
+func (v *VinculoResponsavel) Ativo() bool {
+	return v.EncerradoEm == nil
+}
+
+// Reativar reabre um vinculo encerrado, atualizando o parentesco informado
+func (v *VinculoResponsavel) Reativar(parentesco string, agora time.Time) error {
+	if v.Ativo() {
+		return ErrResponsavelJaVinculado
+	}
+	parentesco = strings.TrimSpace(parentesco)
+	if len(parentesco) > 50 {
+		return ErrParentescoLongo
+	}
+	v.Parentesco = parentesco
+	v.VinculadoEm = agora
+	v.EncerradoEm = nil
+	v.EncerradoPor = ""
+	return nil
+}
+
+// Encerrar finaliza o acompanhamento
+// O paciente menor de idade nao pode encerrar sozinho; o responsavel sempre pode
+func (v *VinculoResponsavel) Encerrar(origem string, paciente *Paciente, agora time.Time) error {
+	if !v.Ativo() {
+		return ErrVinculoJaEncerrado
+	}
+	switch origem {
+	case TutelaEncerradaPeloResponsavel, TutelaEncerradaPorExclusao:
+	case TutelaEncerradaPeloPaciente:
+		if paciente.MenorDeIdade(agora) {
+			return ErrMenorNaoRemoveResponsavel
+		}
+	default:
+		return ErrOrigemEncerramentoInvalida
+	}
+	v.EncerradoEm = &agora
+	v.EncerradoPor = origem
+	return nil
+}
+
+// AcaoResponsavel registra cada acesso ou alteracao feita pelo responsavel em nome do dependente
+// Os registros nunca sao alterados
+type AcaoResponsavel struct {
+	ID            uint      `gorm:"primaryKey"`
+	ResponsavelID uint      `gorm:"not null;index"`
+	PacienteID    uint      `gorm:"not null;index"`
+	Acao          string    `gorm:"type:varchar(40);not null"`
+	Detalhe       string    `gorm:"type:varchar(255)"`
+	CreatedAt     time.Time `gorm:"index"`
+}
+
+func (AcaoResponsavel) TableName() string {
+	return "acoes_responsaveis"
+}
+
+// Validar confere se a acao e uma das registradas pela auditoria
+func (a *AcaoResponsavel) Validar() error {
+	switch a.Acao {
+	case AcaoResponsavelVerResumo, AcaoResponsavelListarAtribuicoes, AcaoResponsavelVerAtribuicao,
+		AcaoResponsavelResponderAtribuicao, AcaoResponsavelVerConsentimentos, AcaoResponsavelAtualizarConsentimento,
+		AcaoResponsavelRevogarConsentimento, AcaoResponsavelEncerrarVinculo:
+		return nil
+	default:
+		return ErrAcaoResponsavelInvalida
+	}
+}
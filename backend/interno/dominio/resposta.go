@@ -16,9 +16,9 @@ type Resposta struct {
 	PontuacaoTotal float64 `gorm:"type:decimal(10,2);column:pontuacao_total"`
 	Classificacao  string  `gorm:"size:255;column:classificacao"` // Ex: "Depressão Moderada"
 
-	// Armazenamento Híbrido (JSONB)
 	// Guarda exatamente o que o front enviou: { "q1": 2, "q2": 0 ... }
-	DadosBrutos datatypes.JSON `gorm:"type:jsonb;column:dados_brutos"`
+	// Gravado cifrado; as consultas usam apenas os metadados relacionais acima
+	DadosBrutos datatypes.JSON `gorm:"type:text;serializer:cifrado;column:dados_brutos"`
 
 	DataResposta time.Time `gorm:"autoCreateTime;column:data_resposta"`
 	CreatedAt    time.Time
@@ -0,0 +1,101 @@
+package dominio
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Tipos de chave controladas pela protecao de login
+const (
+	TipoTentativaConta = "conta"
+	TipoTentativaIP    = "ip"
+
+	PrefixoChaveTentativas = "login:"
+)
+
+// Politica de bloqueio de login
+const (
+	LimiteFalhasPorConta  = 5
+	LimiteFalhasPorIP     = 20
+	JanelaFalhasLogin     = 15 * time.Minute
+	DuracaoBaseBloqueio   = 15 * time.Minute
+	DuracaoMaximaBloqueio = 24 * time.Hour
+	MemoriaBloqueiosLogin = 24 * time.Hour
+)
+
+var (
+	ErrLoginBloqueado = errors.New("muitas tentativas de login, tente novamente mais tarde")
+)
+
+// TentativaLogin acumula falhas de login de uma conta ou de um IP
+type TentativaLogin struct {
+	Chave           string `gorm:"primaryKey;type:varchar(320)"`
+	Falhas          int    `gorm:"not null;default:0"`
+	Bloqueios       int    `gorm:"not null;default:0"`
+	PrimeiraFalhaEm time.Time
+	UltimaFalhaEm   time.Time
+	BloqueadoAte    *time.Time
+}
+
+func (TentativaLogin) TableName() string {
+	return "tentativas_login"
+}
+
+// ChaveTentativa monta a chave de controle para uma conta (e-mail) ou IP
+func ChaveTentativa(tipo, valor string) string {
+	return PrefixoChaveTentativas + tipo + ":" + strings.ToLower(strings.TrimSpace(valor))
+}
+
+// EstaBloqueada indica se o bloqueio temporario ainda esta ativo
+func (t *TentativaLogin) EstaBloqueada(agora time.Time) bool {
+	return t.BloqueadoAte != nil && agora.Before(*t.BloqueadoAte)
+}
+
+// RegistrarFalha contabiliza uma falha e aplica bloqueio ao atingir o limite
+// O bloqueio dobra a cada reincidencia dentro de MemoriaBloqueiosLogin, ate DuracaoMaximaBloqueio
+// Retorna true quando a falha gerou um novo bloqueio
+func (t *TentativaLogin) RegistrarFalha(agora time.Time, limite int) bool {
+	// Reincidencias antigas deixam de agravar o bloqueio
+	if t.Bloqueios > 0 && !t.UltimaFalhaEm.IsZero() && agora.Sub(t.UltimaFalhaEm) > MemoriaBloqueiosLogin {
+		t.Bloqueios = 0
+	}
+	// Falhas fora da janela reiniciam a contagem
+	if t.PrimeiraFalhaEm.IsZero() || agora.Sub(t.PrimeiraFalhaEm) > JanelaFalhasLogin {
+		t.Falhas = 0
+		t.PrimeiraFalhaEm = agora
+	}
+
+	t.Falhas++
+	t.UltimaFalhaEm = agora
+
+	if t.Falhas < limite {
+		return false
+	}
+
+	t.Bloqueios++
+	duracao := DuracaoBaseBloqueio << (t.Bloqueios - 1)
+	if duracao <= 0 || duracao > DuracaoMaximaBloqueio {
+		duracao = DuracaoMaximaBloqueio
+	}
+	ate := agora.Add(duracao)
+	t.BloqueadoAte = &ate
+	t.Falhas = 0
+	t.PrimeiraFalhaEm = time.Time{}
+	return true
+}
+
+// BloqueioLogin registra para auditoria cada bloqueio aplicado
+type BloqueioLogin struct {
+	ID           uint      `gorm:"primaryKey"`
+	Chave        string    `gorm:"type:varchar(320);not null;index"`
+	Tipo         string    `gorm:"type:varchar(20);not null"`
+	IP           string    `gorm:"type:varchar(64)"`
+	Reincidencia int       `gorm:"not null"`
+	BloqueadoAte time.Time `gorm:"not null"`
+	CreatedAt    time.Time
+}
+
+func (BloqueioLogin) TableName() string {
+	return "bloqueios_login"
+}
@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== Testes para a agregacao das metricas ==========
+
+func valoresDosPeriodos(periodos []*dominio.PeriodoAgregado) []*float64 {
+	valores := make([]*float64, 0, len(periodos))
+	for _, p := range periodos {
+		valores = append(valores, p.Valor)
+	}
+	return valores
+}
+
+func ptrFloat(v float64) *float64 {
+	return &v
+}
+
+func TestValidarAgrupamentoEAgregacao(t *testing.T) {
+	agrupamento, err := dominio.ValidarAgrupamento("")
+	assert.NoError(t, err)
+	assert.Equal(t, dominio.AgrupamentoDia, agrupamento)
+	agrupamento, err = dominio.ValidarAgrupamento(" semana ")
+	assert.NoError(t, err)
+	assert.Equal(t, dominio.AgrupamentoSemana, agrupamento)
+	_, err = dominio.ValidarAgrupamento("MES")
+	assert.Equal(t, dominio.ErrAgrupamentoInvalido, err)
+
+	agregacao, err := dominio.ValidarAgregacao("")
+	assert.NoError(t, err)
+	assert.Equal(t, dominio.AgregacaoMedia, agregacao)
+	agregacao, err = dominio.ValidarAgregacao("ultimo")
+	assert.NoError(t, err)
+	assert.Equal(t, dominio.AgregacaoUltimo, agregacao)
+	_, err = dominio.ValidarAgregacao("MEDIANA")
+	assert.Equal(t, dominio.ErrAgregacaoInvalida, err)
+}
+
+func TestAgregarSerie_PorDia(t *testing.T) {
+	fuso := time.FixedZone("BRT", -3*3600)
+	dia := func(d, h int) time.Time { return time.Date(2024, 3, d, h, 0, 0, 0, fuso) }
+	// Tres registros no dia 4, nenhum no dia 5 e um no dia 6; o de 23h do dia 6 em UTC ainda e do dia 6 em BRT
+	serie := &dominio.SerieMetrica{Chave: "humor", Pontos: []dominio.PontoMetrica{
+		{Instante: dia(4, 20), Valor: 1},
+		{Instante: dia(4, 8), Valor: 4},
+		{Instante: dia(4, 13), Valor: 2},
+		{Instante: dia(6, 23).UTC(), Valor: 5},
+		{Instante: dia(1, 12), Valor: 5},
+	}}
+	inicio, fim := dia(4, 0), dia(6, 23)
+
+	tests := []struct {
+		agregacao string
+		want      []*float64
+	}{
+		{agregacao: dominio.AgregacaoMedia, want: []*float64{ptrFloat(7.0 / 3), nil, ptrFloat(5)}},
+		{agregacao: dominio.AgregacaoMinimo, want: []*float64{ptrFloat(1), nil, ptrFloat(5)}},
+		{agregacao: dominio.AgregacaoMaximo, want: []*float64{ptrFloat(4), nil, ptrFloat(5)}},
+		{agregacao: dominio.AgregacaoUltimo, want: []*float64{ptrFloat(1), nil, ptrFloat(5)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.agregacao, func(t *testing.T) {
+			periodos := dominio.AgregarSerie(serie, inicio, fim, dominio.AgrupamentoDia, tt.agregacao, fuso)
+			assert.Len(t, periodos, 3)
+			valores := valoresDosPeriodos(periodos)
+			for i := range tt.want {
+				if tt.want[i] == nil {
+					assert.Nil(t, valores[i])
+					continue
+				}
+				assert.InDelta(t, *tt.want[i], *valores[i], 1e-9)
+			}
+		})
+	}
+
+	periodos := dominio.AgregarSerie(serie, inicio, fim, dominio.AgrupamentoDia, dominio.AgregacaoMedia, fuso)
+	assert.Equal(t, []int{3, 0, 1}, []int{periodos[0].Registros, periodos[1].Registros, periodos[2].Registros})
+	assert.True(t, periodos[1].Inicio.Equal(dia(5, 0)))
+	// A media movel usa as medias diarias dos ultimos 7 dias com registro e atravessa a lacuna
+	assert.InDelta(t, 7.0/3, *periodos[1].MediaMovel, 1e-9)
+	assert.InDelta(t, (7.0/3+5)/2, *periodos[2].MediaMovel, 1e-9)
+}
+
+func TestAgregarSerie_PorSemanaComecaNaSegunda(t *testing.T) {
+	fuso := time.UTC
+	// 2024-03-06 e uma quarta-feira
+	inicio := time.Date(2024, 3, 6, 0, 0, 0, 0, fuso)
+	fim := time.Date(2024, 3, 20, 10, 0, 0, 0, fuso)
+	serie := &dominio.SerieMetrica{Chave: "sono", Pontos: []dominio.PontoMetrica{
+		{Instante: time.Date(2024, 3, 7, 9, 0, 0, 0, fuso), Valor: 6},
+		{Instante: time.Date(2024, 3, 10, 9, 0, 0, 0, fuso), Valor: 8},
+		{Instante: time.Date(2024, 3, 18, 9, 0, 0, 0, fuso), Valor: 5},
+	}}
+
+	periodos := dominio.AgregarSerie(serie, inicio, fim, dominio.AgrupamentoSemana, dominio.AgregacaoMaximo, fuso)
+	assert.Len(t, periodos, 3)
+	assert.True(t, periodos[0].Inicio.Equal(time.Date(2024, 3, 4, 0, 0, 0, 0, fuso)))
+	assert.Equal(t, time.Monday, periodos[1].Inicio.Weekday())
+	assert.Equal(t, 8.0, *periodos[0].Valor)
+	assert.Equal(t, 2, periodos[0].Registros)
+	assert.Nil(t, periodos[1].Valor)
+	assert.Equal(t, 5.0, *periodos[2].Valor)
+	assert.Nil(t, periodos[0].MediaMovel)
+}
+
+func TestAgregarSerie_MediaSemanalUsaMediasDiarias(t *testing.T) {
+	fuso := time.UTC
+	// Quatro registros na segunda-feira e um na quarta da mesma semana
+	inicio := time.Date(2024, 3, 4, 0, 0, 0, 0, fuso)
+	fim := time.Date(2024, 3, 10, 23, 0, 0, 0, fuso)
+	serie := &dominio.SerieMetrica{Chave: "humor", Pontos: []dominio.PontoMetrica{
+		{Instante: time.Date(2024, 3, 4, 8, 0, 0, 0, fuso), Valor: 1},
+		{Instante: time.Date(2024, 3, 4, 12, 0, 0, 0, fuso), Valor: 1},
+		{Instante: time.Date(2024, 3, 4, 16, 0, 0, 0, fuso), Valor: 2},
+		{Instante: time.Date(2024, 3, 4, 20, 0, 0, 0, fuso), Valor: 2},
+		{Instante: time.Date(2024, 3, 6, 9, 0, 0, 0, fuso), Valor: 5},
+	}}
+
+	periodos := dominio.AgregarSerie(serie, inicio, fim, dominio.AgrupamentoSemana, dominio.AgregacaoMedia, fuso)
+	assert.Len(t, periodos, 1)
+	assert.Equal(t, 5, periodos[0].Registros)
+	// (1,5 + 5) / 2, e nao (1 + 1 + 2 + 2 + 5) / 5
+	assert.InDelta(t, 3.25, *periodos[0].Valor, 1e-9)
+}
+
+func TestPerfilSemanalEMediaDasMediasDiarias(t *testing.T) {
+	fuso := time.UTC
+	medias := dominio.NovaSerieDiaria("humor", "Humor", fuso)
+	// Duas segundas-feiras e um domingo com tres registros
+	medias.Adicionar(time.Date(2024, 3, 4, 9, 0, 0, 0, fuso), 2)
+	medias.Adicionar(time.Date(2024, 3, 11, 9, 0, 0, 0, fuso), 4)
+	for _, valor := range []float64{5, 5, 2} {
+		medias.Adicionar(time.Date(2024, 3, 10, 9, 0, 0, 0, fuso), valor)
+	}
+
+	perfil := dominio.PerfilSemanal(medias)
+	assert.Len(t, perfil, 7)
+	assert.Equal(t, time.Sunday, perfil[0].DiaSemana)
+	assert.Equal(t, 1, perfil[0].Dias)
+	assert.Equal(t, 4.0, *perfil[0].Media)
+	assert.Equal(t, 2, perfil[1].Dias)
+	assert.Equal(t, 3.0, *perfil[1].Media)
+	assert.Nil(t, perfil[2].Media)
+
+	// Cada dia pesa o mesmo: (2 + 4 + 4) / 3
+	assert.InDelta(t, 10.0/3, *dominio.MediaDasMediasDiarias(medias), 1e-9)
+	assert.Nil(t, dominio.MediaDasMediasDiarias(dominio.NovaSerieDiaria("sono", "Sono", fuso)))
+}
@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultadoAuditoriaPorStatus(t *testing.T) {
+	assert.Equal(t, dominio.ResultadoAuditoriaSucesso, dominio.ResultadoAuditoriaPorStatus(http.StatusOK))
+	assert.Equal(t, dominio.ResultadoAuditoriaNegado, dominio.ResultadoAuditoriaPorStatus(http.StatusForbidden))
+	assert.Equal(t, dominio.ResultadoAuditoriaNegado, dominio.ResultadoAuditoriaPorStatus(http.StatusNotFound))
+	assert.Equal(t, dominio.ResultadoAuditoriaFalha, dominio.ResultadoAuditoriaPorStatus(http.StatusBadRequest))
+	assert.Equal(t, dominio.ResultadoAuditoriaFalha, dominio.ResultadoAuditoriaPorStatus(http.StatusInternalServerError))
+}
+
+func TestRegistroAuditoria_Validar(t *testing.T) {
+	r := &dominio.RegistroAuditoria{Acao: dominio.AcaoAuditoriaVerNota, TipoRecurso: dominio.RecursoAuditoriaNotaClinica, Resultado: "OK"}
+	assert.Equal(t, dominio.ErrResultadoAuditoria, r.Validar())
+
+	r.Resultado = dominio.ResultadoAuditoriaSucesso
+	r.UserAgent = strings.Repeat("a", 300)
+	assert.NoError(t, r.Validar())
+	assert.Len(t, r.UserAgent, 255)
+}
+
+func TestVerificarCadeia(t *testing.T) {
+	agora := time.Date(2026, 3, 1, 10, 0, 0, 123456789, time.UTC)
+	pacienteID := uint(4)
+	primeiro := &dominio.RegistroAuditoria{Acao: dominio.AcaoAuditoriaVerNota, TipoRecurso: dominio.RecursoAuditoriaNotaClinica, PacienteID: &pacienteID, Resultado: dominio.ResultadoAuditoriaSucesso}
+	segundo := &dominio.RegistroAuditoria{Acao: dominio.AcaoAuditoriaVerNota, TipoRecurso: dominio.RecursoAuditoriaNotaClinica, PacienteID: &pacienteID, Resultado: dominio.ResultadoAuditoriaSucesso}
+	primeiro.Encadear("", agora)
+	segundo.Encadear(primeiro.Hash, agora)
+
+	// O momento e gravado com precisao de microssegundos
+	assert.Equal(t, 123456000, primeiro.CriadoEm.Nanosecond())
+	assert.NotEqual(t, primeiro.Hash, segundo.Hash)
+	assert.Nil(t, dominio.VerificarCadeia("", []*dominio.RegistroAuditoria{primeiro, segundo}))
+
+	outroPaciente := uint(5)
+	segundo.PacienteID = &outroPaciente
+	assert.Equal(t, segundo, dominio.VerificarCadeia("", []*dominio.RegistroAuditoria{primeiro, segundo}))
+	assert.Equal(t, primeiro, dominio.VerificarCadeia("outro", []*dominio.RegistroAuditoria{primeiro, segundo}))
+}
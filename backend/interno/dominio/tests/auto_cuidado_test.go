@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== Testes para AtividadeAutoCuidado ==========
+
+func TestNormalizarNomeAtividade(t *testing.T) {
+	assert.Equal(t, "exercicio fisico", dominio.NormalizarNomeAtividade("  Exercício   FÍSICO "))
+	assert.Equal(t, "meditacao", dominio.NormalizarNomeAtividade("Meditação"))
+}
+
+func TestNovaAtividadeAutoCuidado(t *testing.T) {
+	atividade, err := dominio.NovaAtividadeAutoCuidado(3, "  Diário   de gratidão ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Diário de gratidão", atividade.Nome)
+	assert.Equal(t, "diario de gratidao", atividade.NomeNormalizado)
+	assert.Equal(t, uint(3), *atividade.ProfissionalID)
+	assert.True(t, atividade.Ativa)
+
+	_, err = dominio.NovaAtividadeAutoCuidado(3, " ab ")
+	assert.Equal(t, dominio.ErrAtividadeAutoCuidadoInvalida, err)
+}
+
+func catalogoTeste() []*dominio.AtividadeAutoCuidado {
+	return []*dominio.AtividadeAutoCuidado{
+		{ID: 1, Nome: "Caminhada", NomeNormalizado: "caminhada", Ativa: true},
+		{ID: 2, Nome: "Meditação", NomeNormalizado: "meditacao", Ativa: true},
+		{ID: 3, Nome: "Leitura", NomeNormalizado: "leitura", Ativa: true},
+	}
+}
+
+func TestResolverAutoCuidado(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     []uint
+		nomes   []string
+		want    []uint
+		wantErr error
+	}{
+		{name: "Por ID", ids: []uint{3, 1}, want: []uint{3, 1}},
+		{name: "Por nome normalizado", nomes: []string{"MEDITACAO", " caminhada"}, want: []uint{2, 1}},
+		{name: "Repetidas aparecem uma vez", ids: []uint{2}, nomes: []string{"meditação"}, want: []uint{2}},
+		{name: "Vazio", want: []uint{}},
+		{name: "ID fora do catalogo", ids: []uint{9}, wantErr: dominio.ErrAtividadeAutoCuidadoNaoEncontrada},
+		{name: "Nome fora do catalogo", nomes: []string{"natação"}, wantErr: dominio.ErrAtividadeAutoCuidadoNaoEncontrada},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atividades, err := dominio.ResolverAutoCuidado(catalogoTeste(), tt.ids, tt.nomes)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			ids := make([]uint, 0, len(atividades))
+			for _, atividade := range atividades {
+				ids = append(ids, atividade.ID)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func TestRegistroHumor_AplicarAutoCuidado(t *testing.T) {
+	registro := &dominio.RegistroHumor{AutoCuidado: `["meditacao"]`}
+	assert.NoError(t, registro.AplicarAutoCuidado(catalogoTeste()[1:]))
+	assert.Equal(t, `["Meditação","Leitura"]`, registro.AutoCuidado)
+
+	assert.NoError(t, registro.AplicarAutoCuidado(nil))
+	assert.Equal(t, `[]`, registro.AutoCuidado)
+}
+
+func TestCorrelacionarAutoCuidado(t *testing.T) {
+	inicio := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	var registros []*dominio.RegistroHumor
+	var vinculos []*dominio.RegistroHumorAtividade
+	// Caminhada em dias pares, seguidos de humor 5; nos dias impares o dia seguinte tem humor 2
+	for i := 0; i < 12; i++ {
+		humor := int16(2)
+		if i%2 == 1 {
+			humor = 5
+		}
+		registro := &dominio.RegistroHumor{ID: uint(i + 1), NivelHumor: humor, DataHoraRegistro: inicio.AddDate(0, 0, i)}
+		registros = append(registros, registro)
+		if i%2 == 0 {
+			vinculos = append(vinculos, &dominio.RegistroHumorAtividade{RegistroHumorID: registro.ID, AtividadeID: 1})
+		}
+	}
+	// Leitura aparece so uma vez
+	vinculos = append(vinculos, &dominio.RegistroHumorAtividade{RegistroHumorID: 2, AtividadeID: 3})
+
+	correlacoes := dominio.CorrelacionarAutoCuidado(registros, vinculos, time.UTC)
+	assert.Len(t, correlacoes, 2)
+
+	caminhada := correlacoes[0]
+	assert.Equal(t, uint(1), caminhada.AtividadeID)
+	// O ultimo dia nao tem dia seguinte e fica de fora
+	assert.Equal(t, 6, caminhada.DiasCom)
+	assert.Equal(t, 5, caminhada.DiasSem)
+	assert.InDelta(t, 5.0, caminhada.HumorSeguinteCom, 0.001)
+	assert.InDelta(t, 2.0, caminhada.HumorSeguinteSem, 0.001)
+	assert.InDelta(t, 3.0, caminhada.Diferenca, 0.001)
+	assert.True(t, caminhada.AmostraSuficiente)
+
+	leitura := correlacoes[1]
+	assert.Equal(t, uint(3), leitura.AtividadeID)
+	assert.Equal(t, 1, leitura.DiasCom)
+	assert.False(t, leitura.AmostraSuficiente)
+}
+
+func TestCorrelacionarAutoCuidado_AgrupaPorDiaNoFuso(t *testing.T) {
+	fuso := time.FixedZone("BRT", -3*60*60)
+	// 01:00 UTC do dia 2 ainda e dia 1 no fuso de Brasilia
+	registros := []*dominio.RegistroHumor{
+		{ID: 1, NivelHumor: 1, DataHoraRegistro: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{ID: 2, NivelHumor: 2, DataHoraRegistro: time.Date(2026, 3, 2, 1, 0, 0, 0, time.UTC)},
+		{ID: 3, NivelHumor: 4, DataHoraRegistro: time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)},
+	}
+	vinculos := []*dominio.RegistroHumorAtividade{{RegistroHumorID: 2, AtividadeID: 1}}
+
+	correlacoes := dominio.CorrelacionarAutoCuidado(registros, vinculos, fuso)
+	assert.Len(t, correlacoes, 1)
+	assert.Equal(t, 1, correlacoes[0].DiasCom)
+	assert.Equal(t, 0, correlacoes[0].DiasSem)
+	assert.InDelta(t, 4.0, correlacoes[0].HumorSeguinteCom, 0.001)
+	assert.Zero(t, correlacoes[0].Diferenca)
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsentimento_PadraoOmiteObservacoes(t *testing.T) {
+	agora := time.Now()
+	c := dominio.NovoConsentimentoPadrao(1, 2, agora)
+
+	assert.Equal(t, uint(1), c.Versao)
+	assert.True(t, c.Permite(dominio.CategoriaHumor, agora))
+	assert.True(t, c.Permite(dominio.CategoriaSono, agora))
+	assert.True(t, c.Permite(dominio.CategoriaQuestionarios, agora))
+	assert.False(t, c.Permite(dominio.CategoriaObservacoes, agora))
+	assert.False(t, c.CobreData(agora.Add(-time.Hour)))
+	assert.True(t, c.CobreData(agora))
+}
+
+func TestConsentimento_NovaVersao(t *testing.T) {
+	agora := time.Now()
+	c := dominio.NovoConsentimentoPadrao(1, 2, agora)
+	expiracao := agora.AddDate(0, 1, 0)
+
+	nova, err := c.NovaVersao([]string{dominio.CategoriaObservacoes}, time.Time{}, &expiracao, agora)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), nova.Versao)
+	assert.Equal(t, []string{dominio.CategoriaObservacoes}, nova.Categorias())
+	assert.True(t, nova.CobreData(agora.AddDate(-5, 0, 0)))
+	assert.True(t, nova.Permite(dominio.CategoriaObservacoes, agora))
+	assert.False(t, nova.Permite(dominio.CategoriaObservacoes, expiracao))
+}
+
+func TestConsentimento_NovaVersao_CategoriaInvalida(t *testing.T) {
+	c := dominio.NovoConsentimentoPadrao(1, 2, time.Now())
+
+	_, err := c.NovaVersao([]string{"diagnostico"}, time.Now(), nil, time.Now())
+
+	assert.Equal(t, dominio.ErrCategoriaConsentimentoInvalida, err)
+}
+
+func TestConsentimento_NovaVersao_ExpiracaoAntesDoInicio(t *testing.T) {
+	agora := time.Now()
+	c := dominio.NovoConsentimentoPadrao(1, 2, agora)
+	inicio := agora.AddDate(0, 2, 0)
+	expiracao := agora.AddDate(0, 1, 0)
+
+	_, err := c.NovaVersao([]string{dominio.CategoriaHumor}, inicio, &expiracao, agora)
+
+	assert.Equal(t, dominio.ErrExpiracaoConsentimentoInvalida, err)
+}
+
+func TestConsentimento_Revogar(t *testing.T) {
+	agora := time.Now()
+	revogado := dominio.NovoConsentimentoPadrao(1, 2, agora).Revogar()
+
+	assert.Equal(t, uint(2), revogado.Versao)
+	assert.False(t, revogado.Vigente(agora))
+	assert.False(t, revogado.Permite(dominio.CategoriaHumor, agora))
+	assert.Empty(t, revogado.Categorias())
+}
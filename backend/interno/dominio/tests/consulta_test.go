@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNovasConsultasSemanais(t *testing.T) {
+	agora := time.Now()
+	inicio := agora.Add(24 * time.Hour)
+
+	consultas, err := dominio.NovasConsultasSemanais(7, 3, inicio, inicio.Add(time.Hour), dominio.ModalidadePresencial, " Sala 1 ", nil, 4, agora)
+	assert.NoError(t, err)
+	assert.Len(t, consultas, 4)
+	assert.True(t, consultas[3].Inicio.Equal(inicio.AddDate(0, 0, 21)))
+	assert.Equal(t, "Sala 1", consultas[0].Local)
+
+	_, err = dominio.NovasConsultasSemanais(7, 3, inicio, inicio.Add(time.Hour), dominio.ModalidadePresencial, "", nil, 53, agora)
+	assert.Equal(t, dominio.ErrRepeticoesInvalidas, err)
+
+	_, err = dominio.NovasConsultasSemanais(7, 3, inicio, inicio.Add(9*time.Hour), dominio.ModalidadeOnline, "", nil, 1, agora)
+	assert.Equal(t, dominio.ErrDuracaoConsultaInvalida, err)
+
+	_, err = dominio.NovasConsultasSemanais(7, 3, agora.Add(-time.Hour), agora, dominio.ModalidadeOnline, "", nil, 1, agora)
+	assert.Equal(t, dominio.ErrConsultaNoPassado, err)
+
+	_, err = dominio.NovasConsultasSemanais(7, 3, inicio, inicio.Add(time.Hour), "TELEFONE", "", nil, 1, agora)
+	assert.Equal(t, dominio.ErrModalidadeInvalida, err)
+}
+
+func TestConsulta_Conflita(t *testing.T) {
+	inicio := time.Date(2030, 1, 7, 14, 0, 0, 0, time.UTC)
+	c := &dominio.Consulta{Inicio: inicio, Fim: inicio.Add(time.Hour)}
+
+	assert.True(t, c.Conflita(inicio.Add(30*time.Minute), inicio.Add(90*time.Minute)))
+	assert.True(t, c.Conflita(inicio.Add(-time.Hour), inicio.Add(2*time.Hour)))
+	assert.False(t, c.Conflita(inicio.Add(time.Hour), inicio.Add(2*time.Hour)))
+	assert.False(t, c.Conflita(inicio.Add(-time.Hour), inicio))
+}
+
+func TestConsulta_AlterarStatus(t *testing.T) {
+	agora := time.Now()
+	futura := &dominio.Consulta{Inicio: agora.Add(time.Hour), Status: dominio.StatusConsultaAgendada}
+
+	assert.Equal(t, dominio.ErrConsultaAindaNaoOcorreu, futura.AlterarStatus(dominio.StatusConsultaFalta, agora))
+	assert.Equal(t, dominio.ErrStatusConsultaInvalido, futura.AlterarStatus(dominio.StatusConsultaAgendada, agora))
+	assert.NoError(t, futura.AlterarStatus(dominio.StatusConsultaCancelada, agora))
+	assert.Equal(t, dominio.ErrTransicaoConsultaInvalida, futura.AlterarStatus(dominio.StatusConsultaCancelada, agora))
+
+	passada := &dominio.Consulta{Inicio: agora.Add(-time.Hour), Status: dominio.StatusConsultaAgendada}
+	assert.NoError(t, passada.AlterarStatus(dominio.StatusConsultaRealizada, agora))
+	assert.Equal(t, dominio.ErrTransicaoConsultaInvalida, passada.Remarcar(agora.Add(time.Hour), agora.Add(2*time.Hour), agora))
+}
+
+func TestConsulta_AguardaQuestionario(t *testing.T) {
+	agora := time.Now()
+	instrumentoID := uint(2)
+	c := &dominio.Consulta{Inicio: agora.Add(23 * time.Hour), Status: dominio.StatusConsultaAgendada, InstrumentoID: &instrumentoID}
+	assert.True(t, c.AguardaQuestionario(agora))
+
+	c.Inicio = agora.Add(25 * time.Hour)
+	assert.False(t, c.AguardaQuestionario(agora))
+
+	c.Inicio = agora.Add(time.Hour)
+	atribuicaoID := uint(9)
+	c.AtribuicaoID = &atribuicaoID
+	assert.False(t, c.AguardaQuestionario(agora))
+}
+
+func TestPeriodoAgenda(t *testing.T) {
+	agora := time.Date(2030, 5, 10, 15, 30, 0, 0, time.UTC)
+
+	de, ate, err := dominio.PeriodoAgenda(nil, nil, agora)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2030, 5, 10, 0, 0, 0, 0, time.UTC), de)
+	assert.Equal(t, de.Add(dominio.PeriodoPadraoAgenda), ate)
+
+	inicio := time.Date(2030, 5, 1, 0, 0, 0, 0, time.UTC)
+	fim := time.Date(2030, 5, 1, 0, 0, 0, 0, time.UTC)
+	_, ate, err = dominio.PeriodoAgenda(&inicio, &fim, agora)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2030, 5, 2, 0, 0, 0, 0, time.UTC), ate)
+
+	longe := inicio.AddDate(2, 0, 0)
+	_, _, err = dominio.PeriodoAgenda(&inicio, &longe, agora)
+	assert.Equal(t, dominio.ErrPeriodoAgendaInvalido, err)
+}
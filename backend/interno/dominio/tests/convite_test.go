@@ -460,3 +460,70 @@ func TestConvite_CombinacoesDeEstado(t *testing.T) {
 		})
 	}
 }
+
+func TestConvite_UtilizarConvite_VariosUsos(t *testing.T) {
+	convite := dominio.Convite{Token: "abc123def456", DataExpiracao: time.Now().Add(time.Hour), MaxUsos: 2}
+
+	convite.UtilizarConvite(1)
+	assert.False(t, convite.Usado)
+	assert.Equal(t, uint(1), convite.UsosRestantes())
+	assert.True(t, convite.EstaValido())
+
+	convite.UtilizarConvite(2)
+	assert.True(t, convite.Usado)
+	assert.Equal(t, uint(0), convite.UsosRestantes())
+	assert.Equal(t, uint(2), *convite.PacienteID)
+}
+
+func TestConvite_Status(t *testing.T) {
+	agora := time.Now()
+	ontem := agora.AddDate(0, 0, -1)
+
+	assert.Equal(t, dominio.ConviteAtivo, (&dominio.Convite{DataExpiracao: agora.Add(time.Hour)}).Status(agora))
+	assert.Equal(t, dominio.ConviteUsado, (&dominio.Convite{DataExpiracao: ontem, Usado: true}).Status(agora))
+	assert.Equal(t, dominio.ConviteExpirado, (&dominio.Convite{DataExpiracao: ontem}).Status(agora))
+	assert.Equal(t, dominio.ConviteRevogado, (&dominio.Convite{DataExpiracao: agora.Add(time.Hour), Usado: true, RevogadoEm: &ontem}).Status(agora))
+}
+
+func TestConvite_Revogar(t *testing.T) {
+	agora := time.Now()
+	convite := dominio.Convite{DataExpiracao: agora.Add(time.Hour)}
+
+	assert.NoError(t, convite.Revogar(agora))
+	assert.False(t, convite.EstaValido())
+	assert.Equal(t, dominio.ErrConviteRevogado, convite.Revogar(agora))
+
+	expirado := dominio.Convite{DataExpiracao: agora.Add(-time.Hour)}
+	assert.Equal(t, dominio.ErrConviteExpirado, expirado.Revogar(agora))
+}
+
+func TestConvite_Disponivel(t *testing.T) {
+	agora := time.Now()
+	ativo := dominio.Convite{DataExpiracao: agora.Add(time.Hour), MaxUsos: 1}
+	usado := dominio.Convite{DataExpiracao: agora.Add(time.Hour), MaxUsos: 1, Usos: 1, Usado: true}
+	expirado := dominio.Convite{DataExpiracao: agora.Add(-time.Hour)}
+
+	assert.NoError(t, ativo.Disponivel(agora))
+	assert.Equal(t, dominio.ErrConviteJaUtilizado, usado.Disponivel(agora))
+	assert.Equal(t, dominio.ErrConviteExpirado, expirado.Disponivel(agora))
+}
+
+func TestConvite_AceitaEmail(t *testing.T) {
+	aberto := dominio.Convite{}
+	destinado := dominio.Convite{EmailDestinatario: "ana@example.com"}
+
+	assert.True(t, aberto.AceitaEmail("qualquer@example.com"))
+	assert.True(t, destinado.AceitaEmail("Ana@Example.com"))
+	assert.False(t, destinado.AceitaEmail("bruno@example.com"))
+}
+
+func TestConvite_Validar_EmailComVariosUsos(t *testing.T) {
+	convite := dominio.Convite{
+		Token:             "abc123def456",
+		DataExpiracao:     time.Now().Add(time.Hour),
+		MaxUsos:           3,
+		EmailDestinatario: "ana@example.com",
+	}
+
+	assert.Equal(t, dominio.ErrConviteEmailVariosUsos, convite.Validar())
+}
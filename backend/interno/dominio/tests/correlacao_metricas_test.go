@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== Testes para CorrelacaoMetricas ==========
+
+func TestPearson(t *testing.T) {
+	r, ok := dominio.Pearson([]float64{1, 2, 3, 4}, []float64{2, 4, 6, 8})
+	assert.True(t, ok)
+	assert.InDelta(t, 1.0, r, 1e-9)
+
+	r, ok = dominio.Pearson([]float64{1, 2, 3, 4}, []float64{8, 6, 4, 2})
+	assert.True(t, ok)
+	assert.InDelta(t, -1.0, r, 1e-9)
+
+	_, ok = dominio.Pearson([]float64{1, 2, 3}, []float64{5, 5, 5})
+	assert.False(t, ok)
+	_, ok = dominio.Pearson([]float64{1}, []float64{1})
+	assert.False(t, ok)
+}
+
+func TestSpearman(t *testing.T) {
+	// Relacao monotona, mas nao linear
+	x := []float64{1, 2, 3, 4, 5}
+	y := []float64{1, 4, 9, 16, 100}
+	pearson, _ := dominio.Pearson(x, y)
+	spearman, ok := dominio.Spearman(x, y)
+	assert.True(t, ok)
+	assert.InDelta(t, 1.0, spearman, 1e-9)
+	assert.Less(t, pearson, 0.9)
+
+	// Empates recebem o posto medio
+	spearman, ok = dominio.Spearman([]float64{1, 2, 2, 3}, []float64{1, 2, 3, 4})
+	assert.True(t, ok)
+	assert.InDelta(t, 0.9487, spearman, 1e-4)
+}
+
+func TestSerieDiaria_MediaPorDia(t *testing.T) {
+	fuso := time.UTC
+	serie := dominio.NovaSerieDiaria("humor", "Humor", fuso)
+	serie.Adicionar(time.Date(2024, 3, 10, 8, 0, 0, 0, fuso), 2)
+	serie.Adicionar(time.Date(2024, 3, 10, 22, 0, 0, 0, fuso), 4)
+	serie.Adicionar(time.Date(2024, 3, 9, 23, 59, 0, 0, fuso), 5)
+
+	assert.Equal(t, []string{"2024-03-09", "2024-03-10"}, serie.Dias())
+	media, ok := serie.Media("2024-03-10")
+	assert.True(t, ok)
+	assert.Equal(t, 3.0, media)
+	_, ok = serie.Media("2024-03-11")
+	assert.False(t, ok)
+}
+
+func buscarCorrelacao(correlacoes []*dominio.CorrelacaoMetricas, a, b string, defasagem int) *dominio.CorrelacaoMetricas {
+	for _, c := range correlacoes {
+		if c.MetricaA == a && c.MetricaB == b && c.Defasagem == defasagem {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestCorrelacionarSeries(t *testing.T) {
+	fuso := time.UTC
+	inicio := time.Date(2024, 3, 1, 12, 0, 0, 0, fuso)
+
+	// O sono do dia t define o humor do dia t+1; a energia nao varia
+	registros := make([]*dominio.RegistroHumor, 0)
+	for i := 0; i < 12; i++ {
+		registros = append(registros, &dominio.RegistroHumor{
+			NivelHumor:       int16(1 + (i+2)%3),
+			HorasSono:        int16(5 + 2*(i%3)),
+			NivelEnergia:     5,
+			NivelStress:      int16(1 + i%4),
+			DataHoraRegistro: inicio.AddDate(0, 0, i),
+		})
+	}
+
+	correlacoes := dominio.CorrelacionarSeries(dominio.SeriesDiariasDosRegistros(registros, fuso))
+	// 6 pares no mesmo dia e 16 pares ordenados no dia seguinte
+	assert.Len(t, correlacoes, 22)
+	assert.Equal(t, 0, correlacoes[0].Defasagem)
+	assert.Equal(t, 1, correlacoes[len(correlacoes)-1].Defasagem)
+
+	sonoHumor := buscarCorrelacao(correlacoes, dominio.ChaveMetricaSono, dominio.ChaveMetricaHumor, 1)
+	assert.Equal(t, 11, sonoHumor.Pares)
+	assert.True(t, sonoHumor.AmostraSuficiente)
+	assert.Empty(t, sonoHumor.Avisos)
+	assert.InDelta(t, 1.0, *sonoHumor.Pearson, 1e-9)
+	assert.InDelta(t, 1.0, *sonoHumor.Spearman, 1e-9)
+
+	energia := buscarCorrelacao(correlacoes, dominio.ChaveMetricaHumor, dominio.ChaveMetricaEnergia, 0)
+	assert.Nil(t, energia.Pearson)
+	assert.Nil(t, energia.Spearman)
+	assert.Equal(t, []string{dominio.AvisoSemVariacao}, energia.Avisos)
+}
+
+func TestCorrelacionarSeries_AmostraPequena(t *testing.T) {
+	fuso := time.UTC
+	inicio := time.Date(2024, 3, 1, 12, 0, 0, 0, fuso)
+	registros := make([]*dominio.RegistroHumor, 0)
+	for i := 0; i < 4; i++ {
+		registros = append(registros, &dominio.RegistroHumor{NivelHumor: int16(i + 1), HorasSono: int16(4 + i), NivelEnergia: int16(i), NivelStress: int16(9 - i), DataHoraRegistro: inicio.AddDate(0, 0, 2*i)})
+	}
+
+	correlacoes := dominio.CorrelacionarSeries(dominio.SeriesDiariasDosRegistros(registros, fuso))
+	mesmoDia := buscarCorrelacao(correlacoes, dominio.ChaveMetricaHumor, dominio.ChaveMetricaSono, 0)
+	assert.Equal(t, 4, mesmoDia.Pares)
+	assert.False(t, mesmoDia.AmostraSuficiente)
+	assert.Equal(t, []string{dominio.AvisoAmostraInsuficiente}, mesmoDia.Avisos)
+	assert.NotNil(t, mesmoDia.Pearson)
+
+	// Registros em dias alternados nao formam pares com o dia seguinte
+	defasada := buscarCorrelacao(correlacoes, dominio.ChaveMetricaSono, dominio.ChaveMetricaHumor, 1)
+	assert.Equal(t, 0, defasada.Pares)
+	assert.Nil(t, defasada.Pearson)
+}
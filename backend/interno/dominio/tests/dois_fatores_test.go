@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== Testes para TOTP ==========
+
+// Vetores do apendice B da RFC 6238 (SHA1), truncados para 6 digitos
+func TestGerarCodigoTOTP_VetoresRFC6238(t *testing.T) {
+	segredo := []byte("12345678901234567890")
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, dominio.GerarCodigoTOTP(segredo, time.Unix(tt.unix, 0)))
+	}
+}
+
+func TestVerificarCodigoTOTP(t *testing.T) {
+	segredo := []byte("12345678901234567890")
+	agora := time.Unix(1234567890, 0)
+	passoAtual := dominio.PassoTOTPEm(agora)
+
+	t.Run("codigo atual", func(t *testing.T) {
+		passo, ok := dominio.VerificarCodigoTOTP(segredo, "005924", agora, 0)
+		assert.True(t, ok)
+		assert.Equal(t, passoAtual, passo)
+	})
+
+	t.Run("codigo do passo anterior dentro da tolerancia", func(t *testing.T) {
+		anterior := dominio.GerarCodigoTOTP(segredo, agora.Add(-dominio.PassoTOTP))
+		_, ok := dominio.VerificarCodigoTOTP(segredo, anterior, agora, 0)
+		assert.True(t, ok)
+	})
+
+	t.Run("codigo fora da tolerancia", func(t *testing.T) {
+		antigo := dominio.GerarCodigoTOTP(segredo, agora.Add(-3*dominio.PassoTOTP))
+		_, ok := dominio.VerificarCodigoTOTP(segredo, antigo, agora, 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("codigo ja utilizado", func(t *testing.T) {
+		_, ok := dominio.VerificarCodigoTOTP(segredo, "005924", agora, passoAtual)
+		assert.False(t, ok)
+	})
+
+	t.Run("formato invalido", func(t *testing.T) {
+		_, ok := dominio.VerificarCodigoTOTP(segredo, "5924", agora, 0)
+		assert.False(t, ok)
+	})
+}
+
+func TestSegredoTOTP_CodificarDecodificar(t *testing.T) {
+	segredo := []byte("12345678901234567890")
+	codificado := dominio.CodificarSegredoTOTP(segredo)
+
+	assert.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", codificado)
+
+	decodificado, err := dominio.DecodificarSegredoTOTP(strings.ToLower(codificado))
+	assert.NoError(t, err)
+	assert.Equal(t, segredo, decodificado)
+}
+
+func TestURIOtpauth(t *testing.T) {
+	uri := dominio.URIOtpauth("MindTrace", "joao@example.com", "GEZDGNBV")
+
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/MindTrace:joao@example.com?"))
+	assert.Contains(t, uri, "secret=GEZDGNBV")
+	assert.Contains(t, uri, "issuer=MindTrace")
+	assert.Contains(t, uri, "digits=6")
+	assert.Contains(t, uri, "period=30")
+}
+
+// ========== Testes para DoisFatores ==========
+
+func TestDoisFatores_VerificarCodigo_ImpedeReuso(t *testing.T) {
+	segredo := []byte("12345678901234567890")
+	agora := time.Now()
+	doisFatores := &dominio.DoisFatores{Segredo: dominio.CodificarSegredoTOTP(segredo)}
+	codigo := dominio.GerarCodigoTOTP(segredo, agora)
+
+	assert.True(t, doisFatores.VerificarCodigo(codigo, agora))
+	assert.False(t, doisFatores.VerificarCodigo(codigo, agora))
+}
+
+func TestDesafioDoisFatores_VerificarUso(t *testing.T) {
+	usado := time.Now()
+
+	tests := []struct {
+		name    string
+		desafio dominio.DesafioDoisFatores
+		wantErr error
+	}{
+		{
+			name:    "desafio pendente",
+			desafio: dominio.DesafioDoisFatores{DataExpiracao: time.Now().Add(time.Minute)},
+			wantErr: nil,
+		},
+		{
+			name:    "desafio expirado",
+			desafio: dominio.DesafioDoisFatores{DataExpiracao: time.Now().Add(-time.Second)},
+			wantErr: dominio.ErrDesafioDoisFatoresExpirado,
+		},
+		{
+			name:    "desafio ja utilizado",
+			desafio: dominio.DesafioDoisFatores{DataExpiracao: time.Now().Add(time.Minute), DataUso: &usado},
+			wantErr: dominio.ErrDesafioDoisFatoresInvalido,
+		},
+		{
+			name:    "tentativas esgotadas",
+			desafio: dominio.DesafioDoisFatores{DataExpiracao: time.Now().Add(time.Minute), Tentativas: dominio.LimiteTentativasDesafio},
+			wantErr: dominio.ErrDesafioDoisFatoresInvalido,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, tt.desafio.VerificarUso())
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== Testes para ExclusaoConta ==========
+
+func TestExclusaoConta_Ciclo(t *testing.T) {
+	agora := time.Now()
+	exclusao := dominio.NovaExclusaoConta(1, "motivo", agora, dominio.PeriodoCarenciaExclusao)
+
+	assert.True(t, exclusao.Agendada())
+	assert.False(t, exclusao.Vencida(agora))
+	assert.True(t, exclusao.Vencida(agora.Add(dominio.PeriodoCarenciaExclusao)))
+
+	assert.NoError(t, exclusao.Cancelar(agora))
+	assert.Equal(t, dominio.StatusExclusaoCancelada, exclusao.Status)
+	assert.False(t, exclusao.Vencida(agora.Add(dominio.PeriodoCarenciaExclusao)))
+	assert.ErrorIs(t, exclusao.Cancelar(agora), dominio.ErrExclusaoNaoAgendada)
+}
+
+func TestExclusaoConta_Concluida_NaoPodeSerCancelada(t *testing.T) {
+	agora := time.Now()
+	exclusao := dominio.NovaExclusaoConta(1, "", agora, time.Hour)
+	exclusao.Concluir(agora)
+
+	assert.Equal(t, dominio.StatusExclusaoConcluida, exclusao.Status)
+	assert.ErrorIs(t, exclusao.Cancelar(agora), dominio.ErrExclusaoNaoAgendada)
+}
+
+func TestUsuario_Anonimizar(t *testing.T) {
+	verificado := time.Now()
+	usuario := &dominio.Usuario{
+		ID: 7, Nome: "Maria", Email: "maria@example.com", CPF: "12345678901",
+		Contato: "11999999999", Bio: "bio", Senha: "hash", EmailVerificadoEm: &verificado,
+	}
+
+	usuario.Anonimizar("!inutilizavel")
+
+	assert.Equal(t, dominio.NomeUsuarioAnonimizado, usuario.Nome)
+	assert.Equal(t, "removido-7@anonimizado.invalid", usuario.Email)
+	assert.Empty(t, usuario.CPF)
+	assert.Empty(t, usuario.Contato)
+	assert.Empty(t, usuario.Bio)
+	assert.Equal(t, "!inutilizavel", usuario.Senha)
+	assert.Nil(t, usuario.EmailVerificadoEm)
+	assert.Equal(t, uint(1), usuario.VersaoSessao)
+}
+
+func TestPaciente_Anonimizar_MantemApenasAnoDeNascimento(t *testing.T) {
+	paciente := &dominio.Paciente{
+		DataNascimento:     time.Date(1990, time.June, 15, 0, 0, 0, 0, time.UTC),
+		NomeResponsavel:    "Ana",
+		ContatoResponsavel: "11988887777",
+	}
+
+	paciente.Anonimizar()
+
+	assert.Equal(t, time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC), paciente.DataNascimento)
+	assert.Empty(t, paciente.NomeResponsavel)
+	assert.Empty(t, paciente.ContatoResponsavel)
+}
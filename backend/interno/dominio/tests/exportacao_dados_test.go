@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== Testes para ExportacaoDados ==========
+
+func TestValidarFormatoExportacao(t *testing.T) {
+	assert.NoError(t, dominio.ValidarFormatoExportacao(dominio.FormatoExportacaoJSON))
+	assert.NoError(t, dominio.ValidarFormatoExportacao(dominio.FormatoExportacaoZIP))
+	assert.ErrorIs(t, dominio.ValidarFormatoExportacao("csv"), dominio.ErrFormatoExportacaoInvalido)
+	assert.ErrorIs(t, dominio.ValidarFormatoExportacao(""), dominio.ErrFormatoExportacaoInvalido)
+}
+
+func TestExportacaoDados_Ciclo(t *testing.T) {
+	agora := time.Now()
+	exportacao := &dominio.ExportacaoDados{Status: dominio.StatusExportacaoPendente}
+
+	assert.True(t, exportacao.EmAndamento())
+	assert.ErrorIs(t, exportacao.VerificarDownload(agora), dominio.ErrExportacaoNaoConcluida)
+
+	exportacao.IniciarProcessamento()
+	assert.True(t, exportacao.EmAndamento())
+
+	exportacao.Concluir("/tmp/pacote.zip", "hash", agora)
+	assert.False(t, exportacao.EmAndamento())
+	assert.Equal(t, dominio.StatusExportacaoConcluida, exportacao.Status)
+	assert.Equal(t, agora.Add(dominio.ValidadeExportacao), *exportacao.DataExpiracao)
+	assert.NoError(t, exportacao.VerificarDownload(agora))
+}
+
+func TestExportacaoDados_VerificarDownload_Expirada(t *testing.T) {
+	agora := time.Now()
+	exportacao := &dominio.ExportacaoDados{}
+	exportacao.Concluir("/tmp/pacote.json", "hash", agora)
+
+	assert.False(t, exportacao.EstaExpirada(agora.Add(dominio.ValidadeExportacao-time.Second)))
+	assert.True(t, exportacao.EstaExpirada(agora.Add(dominio.ValidadeExportacao)))
+	assert.ErrorIs(t, exportacao.VerificarDownload(agora.Add(dominio.ValidadeExportacao)), dominio.ErrExportacaoExpirada)
+}
+
+func TestExportacaoDados_Falhar(t *testing.T) {
+	exportacao := &dominio.ExportacaoDados{Status: dominio.StatusExportacaoProcessando}
+	exportacao.Falhar("erro ao gravar")
+
+	assert.False(t, exportacao.EmAndamento())
+	assert.Equal(t, dominio.StatusExportacaoFalhou, exportacao.Status)
+	assert.Equal(t, "erro ao gravar", exportacao.Erro)
+	assert.ErrorIs(t, exportacao.VerificarDownload(time.Now()), dominio.ErrExportacaoNaoConcluida)
+}
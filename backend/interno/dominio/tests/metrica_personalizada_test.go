@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"math"
+	"mindtrace/backend/interno/dominio"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== Testes para MetricaPersonalizada ==========
+
+func TestNovaMetricaPersonalizada(t *testing.T) {
+	tests := []struct {
+		name           string
+		rotulo         string
+		tipo           string
+		minimo, maximo float64
+		wantErr        error
+	}{
+		{name: "Escala valida", rotulo: "Ansiedade", tipo: "escala", minimo: 0, maximo: 10},
+		{name: "Numero com faixa decimal", rotulo: "Cafe (xicaras)", tipo: "NUMERO", minimo: 0, maximo: 7.5},
+		{name: "Sim ou nao ignora a faixa", rotulo: "Medicação tomada", tipo: "SIM_NAO", minimo: 5, maximo: 2},
+		{name: "Rotulo curto", rotulo: " ab ", tipo: "ESCALA", minimo: 0, maximo: 10, wantErr: dominio.ErrRotuloMetricaInvalido},
+		{name: "Tipo desconhecido", rotulo: "Ansiedade", tipo: "TEXTO", minimo: 0, maximo: 10, wantErr: dominio.ErrTipoMetricaInvalido},
+		{name: "Minimo maior que o maximo", rotulo: "Ansiedade", tipo: "NUMERO", minimo: 10, maximo: 0, wantErr: dominio.ErrFaixaMetricaPersonalizadaInvalida},
+		{name: "Escala com limite decimal", rotulo: "Ansiedade", tipo: "ESCALA", minimo: 0, maximo: 9.5, wantErr: dominio.ErrFaixaMetricaPersonalizadaInvalida},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrica, err := dominio.NovaMetricaPersonalizada(1, 5, tt.rotulo, tt.tipo, tt.minimo, tt.maximo)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.True(t, metrica.Ativa)
+				assert.Less(t, metrica.Minimo, metrica.Maximo)
+			}
+		})
+	}
+
+	simNao, _ := dominio.NovaMetricaPersonalizada(1, 5, "Medicação tomada", "sim_nao", 5, 2)
+	assert.Equal(t, dominio.TipoMetricaSimNao, simNao.Tipo)
+	assert.Equal(t, 0.0, simNao.Minimo)
+	assert.Equal(t, 1.0, simNao.Maximo)
+	assert.True(t, simNao.MesmoRotulo("  MEDICACAO   tomada"))
+}
+
+func TestMetricaPersonalizada_ValidarValor(t *testing.T) {
+	escala, _ := dominio.NovaMetricaPersonalizada(1, 5, "Ansiedade", "ESCALA", 0, 10)
+	numero, _ := dominio.NovaMetricaPersonalizada(1, 5, "Cafe", "NUMERO", 0, 7.5)
+
+	assert.NoError(t, escala.ValidarValor(0))
+	assert.NoError(t, escala.ValidarValor(10))
+	assert.Equal(t, dominio.ErrValorMetricaInvalido, escala.ValidarValor(2.5))
+	assert.Equal(t, dominio.ErrValorMetricaInvalido, escala.ValidarValor(11))
+	assert.NoError(t, numero.ValidarValor(2.5))
+	assert.Equal(t, dominio.ErrValorMetricaInvalido, numero.ValidarValor(-0.1))
+	assert.Equal(t, dominio.ErrValorMetricaInvalido, numero.ValidarValor(math.NaN()))
+}
+
+func TestResolverValoresMetricas(t *testing.T) {
+	metricas := []*dominio.MetricaPersonalizada{
+		{ID: 1, Rotulo: "Ansiedade", Tipo: dominio.TipoMetricaEscala, Minimo: 0, Maximo: 10, Ativa: true},
+		{ID: 2, Rotulo: "Medicação tomada", Tipo: dominio.TipoMetricaSimNao, Minimo: 0, Maximo: 1, Ativa: true},
+		{ID: 3, Rotulo: "Dor", Tipo: dominio.TipoMetricaEscala, Minimo: 0, Maximo: 10, Ativa: false},
+	}
+
+	tests := []struct {
+		name    string
+		valores []*dominio.ValorMetricaRegistro
+		wantErr error
+	}{
+		{name: "Sem valores", valores: nil},
+		{name: "Valores validos", valores: []*dominio.ValorMetricaRegistro{{MetricaID: 2, Valor: 1}, {MetricaID: 1, Valor: 7}}},
+		{name: "Metrica desativada", valores: []*dominio.ValorMetricaRegistro{{MetricaID: 3, Valor: 2}}, wantErr: dominio.ErrMetricaPersonalizadaNaoEncontrada},
+		{name: "Metrica de outro paciente", valores: []*dominio.ValorMetricaRegistro{{MetricaID: 99, Valor: 2}}, wantErr: dominio.ErrMetricaPersonalizadaNaoEncontrada},
+		{name: "Metrica repetida", valores: []*dominio.ValorMetricaRegistro{{MetricaID: 1, Valor: 2}, {MetricaID: 1, Valor: 3}}, wantErr: dominio.ErrValorMetricaDuplicado},
+		{name: "Valor fora da faixa", valores: []*dominio.ValorMetricaRegistro{{MetricaID: 2, Valor: 2}}, wantErr: dominio.ErrValorMetricaInvalido},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolvidos, err := dominio.ResolverValoresMetricas(metricas, tt.valores)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Len(t, resolvidos, len(tt.valores))
+				for i, valor := range resolvidos {
+					assert.Equal(t, tt.valores[i].MetricaID, valor.Metrica.ID)
+				}
+			}
+		})
+	}
+}
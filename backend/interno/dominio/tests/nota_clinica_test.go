@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConteudoNota_Normalizar(t *testing.T) {
+	c := dominio.ConteudoNota{Texto: "  Sessao tranquila ", Tags: []string{" Sono", "sono", "Rotina"}}
+	assert.NoError(t, c.Normalizar())
+	assert.Equal(t, "Sessao tranquila", c.Texto)
+	assert.Equal(t, []string{"sono", "rotina"}, c.Tags)
+
+	vazio := dominio.ConteudoNota{Texto: "   "}
+	assert.Equal(t, dominio.ErrTextoNotaVazio, vazio.Normalizar())
+
+	tagLonga := dominio.ConteudoNota{Texto: "x", Tags: []string{strings.Repeat("a", 31)}}
+	assert.Equal(t, dominio.ErrTagNotaInvalida, tagLonga.Normalizar())
+
+	muitasTags := dominio.ConteudoNota{Texto: "x", Tags: strings.Split("a b c d e f g h i j k", " ")}
+	assert.Equal(t, dominio.ErrMuitasTagsNota, muitasTags.Normalizar())
+}
+
+func TestNovaRevisaoNota(t *testing.T) {
+	agora := time.Now()
+	nota := &dominio.NotaClinica{ID: 4, RevisaoAtual: 2}
+
+	revisao, err := dominio.NovaRevisaoNota(nota, agora.AddDate(0, 0, -1), dominio.TipoSessaoCasal, agora)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(4), revisao.NotaID)
+	assert.Equal(t, uint(3), revisao.Revisao)
+
+	_, err = dominio.NovaRevisaoNota(nota, agora.Add(time.Hour), dominio.TipoSessaoCasal, agora)
+	assert.Equal(t, dominio.ErrDataSessaoInvalida, err)
+
+	_, err = dominio.NovaRevisaoNota(nota, agora, "TERAPIA", agora)
+	assert.Equal(t, dominio.ErrTipoSessaoInvalido, err)
+}
+
+func TestNotaClinica_Compartilhar(t *testing.T) {
+	agora := time.Now()
+	nota := &dominio.NotaClinica{ID: 4, ProfissionalID: 7}
+
+	_, err := nota.Compartilhar(7, agora)
+	assert.Equal(t, dominio.ErrCompartilhamentoNotaInvalido, err)
+
+	_, err = nota.Compartilhar(8, agora)
+	assert.NoError(t, err)
+	assert.True(t, nota.CompartilhadaCom(8))
+
+	_, err = nota.RevogarCompartilhamento(8, agora)
+	assert.NoError(t, err)
+	assert.False(t, nota.CompartilhadaCom(8))
+	_, err = nota.RevogarCompartilhamento(8, agora)
+	assert.Equal(t, dominio.ErrCompartilhamentoNaoEncontrado, err)
+
+	// Compartilhar de novo reaproveita o registro revogado
+	_, err = nota.Compartilhar(8, agora)
+	assert.NoError(t, err)
+	assert.True(t, nota.CompartilhadaCom(8))
+	assert.Len(t, nota.Compartilhamentos, 1)
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== Testes para RedefinicaoSenha ==========
+
+func TestRedefinicaoSenha_Validar(t *testing.T) {
+	futuro := time.Now().Add(dominio.ValidadeTokenRedefinicaoSenha)
+	passado := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		redefinicao dominio.RedefinicaoSenha
+		wantErr     error
+	}{
+		{
+			name:        "redefinicao valida",
+			redefinicao: dominio.RedefinicaoSenha{UsuarioID: 1, TokenHash: "abc123", DataExpiracao: futuro},
+			wantErr:     nil,
+		},
+		{
+			name:        "sem usuario",
+			redefinicao: dominio.RedefinicaoSenha{TokenHash: "abc123", DataExpiracao: futuro},
+			wantErr:     dominio.ErrRedefinicaoSemUsuario,
+		},
+		{
+			name:        "sem hash do token",
+			redefinicao: dominio.RedefinicaoSenha{UsuarioID: 1, DataExpiracao: futuro},
+			wantErr:     dominio.ErrTokenRedefinicaoHashVazio,
+		},
+		{
+			name:        "expiracao no passado",
+			redefinicao: dominio.RedefinicaoSenha{UsuarioID: 1, TokenHash: "abc123", DataExpiracao: passado},
+			wantErr:     dominio.ErrRedefinicaoExpiracaoNoPassado,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.redefinicao.Validar()
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestRedefinicaoSenha_VerificarUso(t *testing.T) {
+	usado := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		redefinicao dominio.RedefinicaoSenha
+		wantErr     error
+	}{
+		{
+			name:        "token pendente e dentro da validade",
+			redefinicao: dominio.RedefinicaoSenha{DataExpiracao: time.Now().Add(time.Hour)},
+			wantErr:     nil,
+		},
+		{
+			name:        "token expirado",
+			redefinicao: dominio.RedefinicaoSenha{DataExpiracao: time.Now().Add(-time.Second)},
+			wantErr:     dominio.ErrTokenRedefinicaoExpirado,
+		},
+		{
+			name:        "token ja utilizado",
+			redefinicao: dominio.RedefinicaoSenha{DataExpiracao: time.Now().Add(time.Hour), DataUso: &usado},
+			wantErr:     dominio.ErrTokenRedefinicaoJaUtilizado,
+		},
+		{
+			name:        "token utilizado e expirado reporta uso",
+			redefinicao: dominio.RedefinicaoSenha{DataExpiracao: time.Now().Add(-time.Hour), DataUso: &usado},
+			wantErr:     dominio.ErrTokenRedefinicaoJaUtilizado,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.redefinicao.VerificarUso()
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestRedefinicaoSenha_Utilizar(t *testing.T) {
+	redefinicao := &dominio.RedefinicaoSenha{DataExpiracao: time.Now().Add(time.Hour)}
+
+	assert.False(t, redefinicao.JaFoiUtilizada())
+	redefinicao.Utilizar()
+	assert.True(t, redefinicao.JaFoiUtilizada())
+	assert.Equal(t, dominio.ErrTokenRedefinicaoJaUtilizado, redefinicao.VerificarUso())
+}
+
+func TestUsuario_RevogarSessoes(t *testing.T) {
+	usuario := &dominio.Usuario{}
+
+	assert.Equal(t, uint(0), usuario.VersaoSessao)
+	usuario.RevogarSessoes()
+	usuario.RevogarSessoes()
+	assert.Equal(t, uint(2), usuario.VersaoSessao)
+}
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"mindtrace/backend/interno/dominio"
+	"strings"
 	"testing"
 	"time"
 
@@ -225,14 +226,14 @@ func TestRegistroHumor_ValidarAutoCuidado(t *testing.T) {
 			wantErr:     dominio.ErrAutoCuidadoVazio,
 		},
 		{
-			name:        "auto cuidado com menos de 3 caracteres",
-			autoCuidado: "Ok",
-			wantErr:     dominio.ErrAutoCuidadoInvalido,
+			name:        "auto cuidado como lista JSON",
+			autoCuidado: `["Ler","Caminhada"]`,
+			wantErr:     nil,
 		},
 		{
-			name:        "auto cuidado com 1 caractere",
-			autoCuidado: "X",
-			wantErr:     dominio.ErrAutoCuidadoInvalido,
+			name:        "auto cuidado como lista JSON vazia",
+			autoCuidado: "[]",
+			wantErr:     nil,
 		},
 	}
 
@@ -487,3 +488,52 @@ func TestRegistroHumor_ValidarTodosNiveisMinimos(t *testing.T) {
 	err := registro.Validar()
 	assert.NoError(t, err)
 }
+
+func TestRegistroHumor_PodeCorrigir(t *testing.T) {
+	criadoEm := time.Date(2026, 5, 10, 8, 0, 0, 0, time.UTC)
+	rh := &dominio.RegistroHumor{ID: 3, PacienteID: 1, NivelHumor: 1, CreatedAt: criadoEm}
+
+	assert.NoError(t, rh.PodeCorrigir(criadoEm.Add(24*time.Hour), 24*time.Hour))
+	assert.Equal(t, dominio.ErrJanelaCorrecaoEncerrada, rh.PodeCorrigir(criadoEm.Add(24*time.Hour+time.Second), 24*time.Hour))
+
+	revisao := dominio.NovaRevisaoRegistroHumor(rh, 1, dominio.OperacaoRevisaoEdicao)
+	rh.Corrigir(&dominio.RegistroHumor{NivelHumor: 5})
+	assert.Equal(t, int16(1), revisao.NivelHumor)
+	assert.Equal(t, uint(3), revisao.RegistroHumorID)
+	assert.Equal(t, int16(5), rh.NivelHumor)
+	assert.Equal(t, criadoEm, rh.CreatedAt)
+}
+
+func TestRegistroHumor_AtendeFiltroConteudo(t *testing.T) {
+	rh := &dominio.RegistroHumor{AutoCuidado: `["Caminhada","leitura"]`, Observacoes: "Dia cansativo no trabalho"}
+
+	assert.True(t, rh.AtendeFiltroConteudo(&dominio.FiltroRegistrosHumor{AutoCuidado: []string{"caminhada", "LEITURA"}, Busca: "trabalho"}))
+	assert.False(t, rh.AtendeFiltroConteudo(&dominio.FiltroRegistrosHumor{AutoCuidado: []string{"caminhada", "meditacao"}}))
+	assert.False(t, rh.AtendeFiltroConteudo(&dominio.FiltroRegistrosHumor{Busca: "ferias"}))
+}
+
+func TestFiltroRegistrosHumor_Validar(t *testing.T) {
+	minimo, maximo := int16(4), int16(2)
+	filtro := &dominio.FiltroRegistrosHumor{Humor: dominio.FaixaMetrica{Min: &minimo, Max: &maximo}}
+	assert.Equal(t, dominio.ErrFaixaMetricaInvalida, filtro.Validar())
+
+	filtro.Humor.Max = nil
+	assert.NoError(t, filtro.Validar())
+	assert.True(t, filtro.Humor.Restringe())
+	assert.False(t, filtro.Sono.Restringe())
+}
+
+func TestRegistroHumor_ValidarJanelaRetroativa(t *testing.T) {
+	agora := time.Date(2026, 5, 10, 8, 0, 0, 0, time.UTC)
+	rh := &dominio.RegistroHumor{DataHoraRegistro: agora.Add(-7 * 24 * time.Hour)}
+	assert.NoError(t, rh.ValidarJanelaRetroativa(agora, 7*24*time.Hour))
+
+	rh.DataHoraRegistro = rh.DataHoraRegistro.Add(-time.Second)
+	assert.Equal(t, dominio.ErrDataHoraRegistroAntiga, rh.ValidarJanelaRetroativa(agora, 7*24*time.Hour))
+}
+
+func TestValidarChaveIdempotencia(t *testing.T) {
+	assert.NoError(t, dominio.ValidarChaveIdempotencia("2f1c6a0e-9d8b-4c1e-b7a2-5e3f9c0d1a4b"))
+	assert.Equal(t, dominio.ErrChaveIdempotenciaInvalida, dominio.ValidarChaveIdempotencia(""))
+	assert.Equal(t, dominio.ErrChaveIdempotenciaInvalida, dominio.ValidarChaveIdempotencia(strings.Repeat("a", 65)))
+}
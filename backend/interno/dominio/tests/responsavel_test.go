@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaciente_MenorDeIdade(t *testing.T) {
+	agora := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	menor := &dominio.Paciente{DataNascimento: time.Date(2008, 6, 16, 0, 0, 0, 0, time.UTC)}
+	maior := &dominio.Paciente{DataNascimento: time.Date(2008, 6, 15, 0, 0, 0, 0, time.UTC)}
+	semData := &dominio.Paciente{}
+
+	assert.True(t, menor.MenorDeIdade(agora))
+	assert.False(t, maior.MenorDeIdade(agora))
+	assert.False(t, semData.MenorDeIdade(agora))
+	assert.False(t, maior.AceitaResponsavel(agora))
+
+	maior.Dependente = true
+	assert.True(t, maior.AceitaResponsavel(agora))
+}
+
+func TestVinculoResponsavel_Encerrar_MenorNaoRemove(t *testing.T) {
+	agora := time.Now()
+	paciente := &dominio.Paciente{ID: 3, DataNascimento: agora.AddDate(-15, 0, 0)}
+	v, err := dominio.NovoVinculoResponsavel(5, 3, "pai", agora)
+	assert.NoError(t, err)
+
+	err = v.Encerrar(dominio.TutelaEncerradaPeloPaciente, paciente, agora)
+	assert.Equal(t, dominio.ErrMenorNaoRemoveResponsavel, err)
+	assert.True(t, v.Ativo())
+
+	err = v.Encerrar(dominio.TutelaEncerradaPeloResponsavel, paciente, agora)
+	assert.NoError(t, err)
+	assert.False(t, v.Ativo())
+	assert.Equal(t, dominio.TutelaEncerradaPeloResponsavel, v.EncerradoPor)
+}
+
+func TestVinculoResponsavel_Reativar(t *testing.T) {
+	agora := time.Now()
+	v, _ := dominio.NovoVinculoResponsavel(5, 3, "mae", agora.AddDate(0, -2, 0))
+
+	assert.Equal(t, dominio.ErrResponsavelJaVinculado, v.Reativar("mae", agora))
+
+	_ = v.Encerrar(dominio.TutelaEncerradaPeloResponsavel, &dominio.Paciente{}, agora.AddDate(0, -1, 0))
+	assert.NoError(t, v.Reativar("avo", agora))
+	assert.True(t, v.Ativo())
+	assert.Equal(t, "avo", v.Parentesco)
+	assert.Empty(t, v.EncerradoPor)
+}
+
+func TestConsentimento_AmpliadoPor(t *testing.T) {
+	agora := time.Now()
+	atual := dominio.NovoConsentimentoPadrao(3, 7, agora.AddDate(0, -1, 0))
+
+	comObservacoes, _ := atual.NovaVersao([]string{dominio.CategoriaHumor, dominio.CategoriaObservacoes}, atual.DataInicio, nil, agora)
+	assert.True(t, atual.AmpliadoPor(comObservacoes, agora))
+
+	apenasHumor, _ := atual.NovaVersao([]string{dominio.CategoriaHumor}, atual.DataInicio, nil, agora)
+	assert.False(t, atual.AmpliadoPor(apenasHumor, agora))
+
+	historicoAntigo, _ := atual.NovaVersao([]string{dominio.CategoriaHumor}, atual.DataInicio.AddDate(-1, 0, 0), nil, agora)
+	assert.True(t, atual.AmpliadoPor(historicoAntigo, agora))
+
+	assert.False(t, atual.AmpliadoPor(atual.Revogar(), agora))
+}
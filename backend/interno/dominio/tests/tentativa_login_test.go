@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== Testes para TentativaLogin ==========
+
+func TestChaveTentativa(t *testing.T) {
+	assert.Equal(t, "login:conta:joao@example.com", dominio.ChaveTentativa(dominio.TipoTentativaConta, "  Joao@Example.com "))
+	assert.Equal(t, "login:ip:10.0.0.1", dominio.ChaveTentativa(dominio.TipoTentativaIP, "10.0.0.1"))
+}
+
+func TestTentativaLogin_RegistrarFalha_BloqueiaAoAtingirLimite(t *testing.T) {
+	agora := time.Now()
+	tentativa := &dominio.TentativaLogin{}
+
+	for i := 1; i < dominio.LimiteFalhasPorConta; i++ {
+		assert.False(t, tentativa.RegistrarFalha(agora, dominio.LimiteFalhasPorConta))
+		assert.False(t, tentativa.EstaBloqueada(agora))
+	}
+
+	assert.True(t, tentativa.RegistrarFalha(agora, dominio.LimiteFalhasPorConta))
+	assert.True(t, tentativa.EstaBloqueada(agora))
+	assert.Equal(t, agora.Add(dominio.DuracaoBaseBloqueio), *tentativa.BloqueadoAte)
+	assert.False(t, tentativa.EstaBloqueada(agora.Add(dominio.DuracaoBaseBloqueio)))
+}
+
+func TestTentativaLogin_RegistrarFalha_ReiniciaForaDaJanela(t *testing.T) {
+	agora := time.Now()
+	tentativa := &dominio.TentativaLogin{}
+
+	for i := 1; i < dominio.LimiteFalhasPorConta; i++ {
+		tentativa.RegistrarFalha(agora, dominio.LimiteFalhasPorConta)
+	}
+
+	depois := agora.Add(dominio.JanelaFalhasLogin + time.Minute)
+	assert.False(t, tentativa.RegistrarFalha(depois, dominio.LimiteFalhasPorConta))
+	assert.Equal(t, 1, tentativa.Falhas)
+}
+
+func TestTentativaLogin_RegistrarFalha_BloqueioProgressivo(t *testing.T) {
+	agora := time.Now()
+	tentativa := &dominio.TentativaLogin{}
+
+	bloquear := func() time.Duration {
+		for !tentativa.RegistrarFalha(agora, dominio.LimiteFalhasPorConta) {
+		}
+		duracao := tentativa.BloqueadoAte.Sub(agora)
+		agora = *tentativa.BloqueadoAte
+		return duracao
+	}
+
+	assert.Equal(t, dominio.DuracaoBaseBloqueio, bloquear())
+	assert.Equal(t, 2*dominio.DuracaoBaseBloqueio, bloquear())
+	assert.Equal(t, 4*dominio.DuracaoBaseBloqueio, bloquear())
+
+	for i := 0; i < 10; i++ {
+		bloquear()
+	}
+	assert.Equal(t, dominio.DuracaoMaximaBloqueio, bloquear())
+}
+
+func TestTentativaLogin_RegistrarFalha_EsqueceReincidenciasAntigas(t *testing.T) {
+	agora := time.Now()
+	tentativa := &dominio.TentativaLogin{Bloqueios: 3, UltimaFalhaEm: agora.Add(-2 * dominio.MemoriaBloqueiosLogin)}
+
+	for !tentativa.RegistrarFalha(agora, dominio.LimiteFalhasPorConta) {
+	}
+
+	assert.Equal(t, 1, tentativa.Bloqueios)
+	assert.Equal(t, agora.Add(dominio.DuracaoBaseBloqueio), *tentativa.BloqueadoAte)
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ========== Testes para VerificacaoEmail ==========
+
+func TestVerificacaoEmail_VerificarUso(t *testing.T) {
+	usado := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		verificacao dominio.VerificacaoEmail
+		wantErr     error
+	}{
+		{
+			name:        "token pendente e valido",
+			verificacao: dominio.VerificacaoEmail{DataExpiracao: time.Now().Add(time.Hour)},
+			wantErr:     nil,
+		},
+		{
+			name:        "token expirado",
+			verificacao: dominio.VerificacaoEmail{DataExpiracao: time.Now().Add(-time.Second)},
+			wantErr:     dominio.ErrTokenVerificacaoExpirado,
+		},
+		{
+			name:        "token ja utilizado",
+			verificacao: dominio.VerificacaoEmail{DataExpiracao: time.Now().Add(time.Hour), DataUso: &usado},
+			wantErr:     dominio.ErrTokenVerificacaoJaUtilizado,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.verificacao.VerificarUso()
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestVerificarLimiteReenvio(t *testing.T) {
+	tests := []struct {
+		name             string
+		ultimoEnvio      time.Time
+		enviosUltimaHora int64
+		wantErr          error
+	}{
+		{
+			name:             "primeiro envio",
+			ultimoEnvio:      time.Time{},
+			enviosUltimaHora: 0,
+			wantErr:          nil,
+		},
+		{
+			name:             "ultimo envio ha tempo suficiente",
+			ultimoEnvio:      time.Now().Add(-dominio.IntervaloMinimoReenvioVerificacao - time.Second),
+			enviosUltimaHora: 1,
+			wantErr:          nil,
+		},
+		{
+			name:             "ultimo envio muito recente",
+			ultimoEnvio:      time.Now().Add(-10 * time.Second),
+			enviosUltimaHora: 1,
+			wantErr:          dominio.ErrReenvioVerificacaoMuitoFrequente,
+		},
+		{
+			name:             "limite por hora atingido",
+			ultimoEnvio:      time.Now().Add(-30 * time.Minute),
+			enviosUltimaHora: dominio.LimiteReenviosVerificacaoPorHora,
+			wantErr:          dominio.ErrReenvioVerificacaoMuitoFrequente,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dominio.VerificarLimiteReenvio(tt.ultimoEnvio, tt.enviosUltimaHora)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestUsuario_MarcarEmailComoVerificado(t *testing.T) {
+	usuario := &dominio.Usuario{VerificacaoEmailPendente: true}
+
+	assert.False(t, usuario.EmailVerificado())
+	usuario.MarcarEmailComoVerificado()
+	assert.True(t, usuario.EmailVerificado())
+	assert.NotNil(t, usuario.EmailVerificadoEm)
+}
@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVinculo_Encerrar(t *testing.T) {
+	agora := time.Now()
+	vinculadoEm := agora.AddDate(0, -3, 0)
+	v := &dominio.Vinculo{PacienteID: 1, ProfissionalID: 2, VinculadoEm: &vinculadoEm}
+
+	encerramento, err := v.Encerrar(dominio.EncerradoPeloPaciente, "Mudei de cidade", agora)
+
+	assert.NoError(t, err)
+	assert.False(t, v.Ativo())
+	assert.Equal(t, dominio.EncerradoPeloPaciente, v.EncerradoPor)
+	assert.Equal(t, &vinculadoEm, encerramento.VinculadoEm)
+	assert.Equal(t, agora, encerramento.EncerradoEm)
+}
+
+func TestVinculo_Encerrar_SemMotivo(t *testing.T) {
+	v := &dominio.Vinculo{PacienteID: 1, ProfissionalID: 2}
+
+	_, err := v.Encerrar(dominio.EncerradoPeloProfissional, "", time.Now())
+
+	assert.Equal(t, dominio.ErrMotivoEncerramentoVazio, err)
+	assert.True(t, v.Ativo())
+}
+
+func TestVinculo_Encerrar_JaEncerrado(t *testing.T) {
+	v := &dominio.Vinculo{PacienteID: 1, ProfissionalID: 2}
+	_, _ = v.Encerrar(dominio.EncerradoPeloProfissional, "Alta", time.Now())
+
+	_, err := v.Encerrar(dominio.EncerradoPeloPaciente, "Encerrando", time.Now())
+
+	assert.Equal(t, dominio.ErrVinculoJaEncerrado, err)
+}
+
+func TestVinculo_Reativar(t *testing.T) {
+	agora := time.Now()
+	v := &dominio.Vinculo{PacienteID: 1, ProfissionalID: 2}
+	_, _ = v.Encerrar(dominio.EncerradoPeloProfissional, "Alta", agora.AddDate(0, -1, 0))
+
+	v.Reativar(agora)
+
+	assert.True(t, v.Ativo())
+	assert.Empty(t, v.EncerradoPor)
+	assert.Equal(t, agora, *v.VinculadoEm)
+}
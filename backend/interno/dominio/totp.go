@@ -0,0 +1,99 @@
+package dominio
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parametros TOTP (RFC 6238) compativeis com os aplicativos autenticadores comuns
+const (
+	DigitosTOTP          = 6
+	PassoTOTP            = 30 * time.Second
+	ToleranciaPassosTOTP = 1
+	TamanhoSegredoTOTP   = 20
+)
+
+var codificacaoSegredoTOTP = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// CodificarSegredoTOTP converte o segredo para base32 sem padding, formato esperado no otpauth
+func CodificarSegredoTOTP(segredo []byte) string {
+	return codificacaoSegredoTOTP.EncodeToString(segredo)
+}
+
+// DecodificarSegredoTOTP aceita o segredo em base32 com ou sem padding e espacos
+func DecodificarSegredoTOTP(segredo string) ([]byte, error) {
+	normalizado := strings.ToUpper(strings.ReplaceAll(segredo, " ", ""))
+	normalizado = strings.TrimRight(normalizado, "=")
+	return codificacaoSegredoTOTP.DecodeString(normalizado)
+}
+
+// PassoTOTPEm retorna o contador de passos de 30s desde a epoca Unix
+func PassoTOTPEm(instante time.Time) int64 {
+	return instante.Unix() / int64(PassoTOTP/time.Second)
+}
+
+// codigoHOTP calcula o codigo HOTP (RFC 4226) para o contador informado
+func codigoHOTP(segredo []byte, contador uint64) string {
+	var mensagem [8]byte
+	binary.BigEndian.PutUint64(mensagem[:], contador)
+
+	mac := hmac.New(sha1.New, segredo)
+	mac.Write(mensagem[:])
+	soma := mac.Sum(nil)
+
+	// Truncamento dinamico
+	deslocamento := soma[len(soma)-1] & 0x0f
+	binario := binary.BigEndian.Uint32(soma[deslocamento:deslocamento+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < DigitosTOTP; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", DigitosTOTP, binario%modulo)
+}
+
+// GerarCodigoTOTP calcula o codigo valido para o instante informado
+func GerarCodigoTOTP(segredo []byte, instante time.Time) string {
+	return codigoHOTP(segredo, uint64(PassoTOTPEm(instante)))
+}
+
+// VerificarCodigoTOTP compara o codigo com os passos dentro da tolerancia de relogio
+// Passos menores ou iguais a ultimoPasso sao recusados para impedir reuso do mesmo codigo
+// Retorna o passo aceito
+func VerificarCodigoTOTP(segredo []byte, codigo string, instante time.Time, ultimoPasso int64) (int64, bool) {
+	codigo = strings.TrimSpace(codigo)
+	if len(codigo) != DigitosTOTP {
+		return 0, false
+	}
+
+	atual := PassoTOTPEm(instante)
+	for passo := atual - ToleranciaPassosTOTP; passo <= atual+ToleranciaPassosTOTP; passo++ {
+		if passo <= ultimoPasso {
+			continue
+		}
+		esperado := codigoHOTP(segredo, uint64(passo))
+		if subtle.ConstantTimeCompare([]byte(esperado), []byte(codigo)) == 1 {
+			return passo, true
+		}
+	}
+	return 0, false
+}
+
+// URIOtpauth monta a URI otpauth://totp lida pelos aplicativos autenticadores via QR code
+func URIOtpauth(emissor, conta, segredoBase32 string) string {
+	rotulo := url.PathEscape(emissor + ":" + conta)
+	parametros := url.Values{}
+	parametros.Set("secret", segredoBase32)
+	parametros.Set("issuer", emissor)
+	parametros.Set("algorithm", "SHA1")
+	parametros.Set("digits", fmt.Sprint(DigitosTOTP))
+	parametros.Set("period", fmt.Sprint(int(PassoTOTP/time.Second)))
+	return "otpauth://totp/" + rotulo + "?" + parametros.Encode()
+}
@@ -2,6 +2,7 @@ package dominio
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 	"time"
 
@@ -12,10 +13,12 @@ import (
 const (
 	TipoUsuarioProfissional uint8 = 2
 	TipoUsuarioPaciente     uint8 = 3
+	TipoUsuarioResponsavel  uint8 = 4
 )
 
 var (
 	ErrEmailJaCadastrado     = errors.New("e-mail existente")
+	ErrCPFJaCadastrado       = errors.New("cpf existente")
 	ErrCrendenciaisInvalidas = errors.New("credenciais invalidas")
 	ErrUsuarioNaoEncontrado  = errors.New("usuario nao encontrado")
 	ErrSenhaNaoConfere       = errors.New("a nova senha e a senha de confirmacao nao conferem")
@@ -23,6 +26,7 @@ var (
 	ErrSenhaFraca            = errors.New("senha deve ter no minimo 8 caracteres")
 	ErrSenhaInvalida         = errors.New("senha com caracteres invalidos")
 	ErrNomeVazio             = errors.New("nome nao pode estar vazio")
+	ErrEmailNaoVerificado    = errors.New("e-mail ainda nao verificado")
 )
 
 // Usuario e a base para todos os tipos de usuarios.
@@ -32,12 +36,21 @@ type Usuario struct {
 	Nome        string `gorm:"type:varchar(255);not null"`
 	Email       string `gorm:"type:varchar(255);unique;not null"`
 	Senha       string `gorm:"type:text;not null"`
-	Contato     string `gorm:"type:varchar(11)"`
+	Contato     string `gorm:"type:text;serializer:cifrado"`
 	Bio         string `gorm:"type:text"`
-	CPF         string `gorm:"type:varchar(11);unique"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	CPF         string `gorm:"type:text;serializer:cifrado"`
+	// CPFIndice e o indice cego do CPF, preenchido pela camada de persistencia
+	// Garante a unicidade e permite a busca sem gravar o CPF em claro
+	CPFIndice *string `gorm:"type:varchar(64);uniqueIndex;indice_cego:CPF"`
+	// VersaoSessao e incrementada para invalidar todos os tokens JWT ja emitidos
+	VersaoSessao uint `gorm:"not null;default:0"`
+	// VerificacaoEmailPendente marca contas criadas apos a verificacao obrigatoria
+	// Contas anteriores permanecem com false e continuam acessiveis
+	VerificacaoEmailPendente bool `gorm:"not null;default:false"`
+	EmailVerificadoEm        *time.Time
+	CreatedAt                time.Time
+	UpdatedAt                time.Time
+	DeletedAt                gorm.DeletedAt `gorm:"index"`
 }
 
 func (Usuario) TableName() string {
@@ -51,6 +64,8 @@ func TipoUsuarioParaString(tipo uint8) string {
 		return "profissional"
 	case TipoUsuarioPaciente:
 		return "paciente"
+	case TipoUsuarioResponsavel:
+		return "responsavel"
 	default:
 		return "desconhecido"
 	}
@@ -63,6 +78,8 @@ func StringParaTipoUsuario(tipo string) uint8 {
 		return TipoUsuarioProfissional
 	case "paciente":
 		return TipoUsuarioPaciente
+	case "responsavel":
+		return TipoUsuarioResponsavel
 	default:
 		return 0
 	}
@@ -106,6 +123,23 @@ func (u *Usuario) Validar() error {
 	return nil
 }
 
+// RevogarSessoes invalida todos os tokens emitidos ate o momento
+func (u *Usuario) RevogarSessoes() {
+	u.VersaoSessao++
+}
+
+// EmailVerificado indica se a posse do e-mail foi confirmada
+func (u *Usuario) EmailVerificado() bool {
+	return !u.VerificacaoEmailPendente
+}
+
+// MarcarEmailComoVerificado registra a confirmacao do e-mail
+func (u *Usuario) MarcarEmailComoVerificado() {
+	agora := time.Now()
+	u.VerificacaoEmailPendente = false
+	u.EmailVerificadoEm = &agora
+}
+
 // Profissional tem seus proprios dados e uma referencia ao Usuario.
 type Profissional struct {
 	ID                   uint    `gorm:"primaryKey"`
@@ -276,6 +310,16 @@ func (pc *Paciente) Validar() error {
 	return nil
 }
 
+// MenorDeIdade indica se o paciente ainda nao completou a maioridade na data informada
+func (pc *Paciente) MenorDeIdade(agora time.Time) bool {
+	return !pc.DataNascimento.IsZero() && pc.DataNascimento.AddDate(IdadeMaioridade, 0, 0).After(agora)
+}
+
+// AceitaResponsavel indica se o paciente pode ter um responsavel com acesso delegado
+func (pc *Paciente) AceitaResponsavel(agora time.Time) bool {
+	return pc.Dependente || pc.MenorDeIdade(agora)
+}
+
 // PossuiProfissional verifica se o paciente ja esta associado a um profissional
 func (pc *Paciente) PossuiProfissional(profissionalID uint) bool {
 	for _, prof := range pc.Profissionais {
@@ -285,3 +329,37 @@ func (pc *Paciente) PossuiProfissional(profissionalID uint) bool {
 	}
 	return false
 }
+
+// Anonimizar remove os dados pessoais do usuario e revoga todas as sessoes
+// O CPF e seu indice sao gravados como nulos pelo repositorio, liberando-o para um novo cadastro
+func (u *Usuario) Anonimizar(senhaInutilizavel string) {
+	u.Nome = NomeUsuarioAnonimizado
+	u.Email = EmailAnonimizado(u.ID)
+	u.CPF = ""
+	u.Contato = ""
+	u.Bio = ""
+	u.Senha = senhaInutilizavel
+	u.EmailVerificadoEm = nil
+	u.RevogarSessoes()
+}
+
+// EmailAnonimizado gera um endereco unico e nao entregavel para contas excluidas
+func EmailAnonimizado(usuarioID uint) string {
+	return fmt.Sprintf("removido-%d@anonimizado.invalid", usuarioID)
+}
+
+// Anonimizar remove os dados do responsavel e reduz a data de nascimento ao ano
+// O ano e mantido para as estatisticas agregadas por faixa etaria
+func (p *Paciente) Anonimizar() {
+	p.NomeResponsavel = ""
+	p.ContatoResponsavel = ""
+	if !p.DataNascimento.IsZero() {
+		p.DataNascimento = time.Date(p.DataNascimento.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Anonimizar substitui o registro no conselho, liberando-o para um novo cadastro
+func (p *Profissional) Anonimizar() {
+	p.RegistroProfissional = fmt.Sprintf("ANON%d", p.ID)
+	p.DataNascimento = time.Date(p.DataNascimento.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+}
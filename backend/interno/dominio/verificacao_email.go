@@ -0,0 +1,79 @@
+package dominio
+
+import (
+	"errors"
+	"time"
+)
+
+// Parametros da verificacao de e-mail
+const (
+	ValidadeTokenVerificacaoEmail     = 48 * time.Hour
+	IntervaloMinimoReenvioVerificacao = 2 * time.Minute
+	LimiteReenviosVerificacaoPorHora  = 5
+)
+
+// Erros de validacao - VerificacaoEmail
+var (
+	ErrTokenVerificacaoInvalido         = errors.New("token de verificacao invalido")
+	ErrTokenVerificacaoExpirado         = errors.New("token de verificacao expirado")
+	ErrTokenVerificacaoJaUtilizado      = errors.New("token de verificacao ja foi utilizado")
+	ErrTokenVerificacaoHashVazio        = errors.New("hash do token de verificacao nao pode estar vazio")
+	ErrVerificacaoSemUsuario            = errors.New("verificacao de e-mail deve ter um usuario")
+	ErrReenvioVerificacaoMuitoFrequente = errors.New("aguarde antes de solicitar um novo e-mail de verificacao")
+	ErrEmailJaVerificado                = errors.New("e-mail ja verificado")
+)
+
+// VerificacaoEmail guarda o hash do token enviado para confirmar a posse do e-mail
+type VerificacaoEmail struct {
+	ID            uint      `gorm:"primaryKey"`
+	UsuarioID     uint      `gorm:"not null;index"`
+	Usuario       Usuario   `gorm:"foreignKey:UsuarioID;constraint:OnDelete:CASCADE"`
+	TokenHash     string    `gorm:"type:varchar(64);unique;not null"`
+	DataExpiracao time.Time `gorm:"not null"`
+	DataUso       *time.Time
+	CreatedAt     time.Time
+}
+
+func (VerificacaoEmail) TableName() string {
+	return "verificacoes_email"
+}
+
+// Validacao completa da VerificacaoEmail
+func (v *VerificacaoEmail) Validar() error {
+	if v.UsuarioID == 0 {
+		return ErrVerificacaoSemUsuario
+	}
+	if v.TokenHash == "" {
+		return ErrTokenVerificacaoHashVazio
+	}
+	return nil
+}
+
+// VerificarUso retorna o erro adequado caso o token nao possa mais ser usado
+func (v *VerificacaoEmail) VerificarUso() error {
+	if v.DataUso != nil {
+		return ErrTokenVerificacaoJaUtilizado
+	}
+	if v.DataExpiracao.Before(time.Now()) {
+		return ErrTokenVerificacaoExpirado
+	}
+	return nil
+}
+
+// Utilizar marca o token como consumido
+func (v *VerificacaoEmail) Utilizar() {
+	agora := time.Now()
+	v.DataUso = &agora
+}
+
+// VerificarLimiteReenvio aplica o controle de frequencia de reenvio do e-mail de verificacao
+// ultimoEnvio pode ser zero quando nenhum e-mail foi enviado ainda
+func VerificarLimiteReenvio(ultimoEnvio time.Time, enviosUltimaHora int64) error {
+	if !ultimoEnvio.IsZero() && time.Since(ultimoEnvio) < IntervaloMinimoReenvioVerificacao {
+		return ErrReenvioVerificacaoMuitoFrequente
+	}
+	if enviosUltimaHora >= LimiteReenviosVerificacaoPorHora {
+		return ErrReenvioVerificacaoMuitoFrequente
+	}
+	return nil
+}
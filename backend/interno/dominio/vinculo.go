@@ -0,0 +1,103 @@
+package dominio
+
+import (
+	"errors"
+	"time"
+)
+
+// Origem do encerramento de um vinculo
+const (
+	EncerradoPeloPaciente     = "PACIENTE"
+	EncerradoPeloProfissional = "PROFISSIONAL"
+	EncerradoPorExclusaoConta = "EXCLUSAO_CONTA"
+)
+
+// Erros de validacao - Vinculo
+var (
+	ErrVinculoJaEncerrado         = errors.New("vinculo ja foi encerrado")
+	ErrVinculoJaAtivo             = errors.New("paciente ja esta vinculado a este profissional")
+	ErrMotivoEncerramentoVazio    = errors.New("motivo do encerramento e obrigatorio")
+	ErrMotivoEncerramentoLongo    = errors.New("motivo do encerramento deve ter no maximo 1000 caracteres")
+	ErrOrigemEncerramentoInvalida = errors.New("origem do encerramento invalida")
+)
+
+// Vinculo e a linha da tabela profissional_paciente
+// O vinculo encerrado continua na tabela, marcado por EncerradoEm, para manter o historico
+type Vinculo struct {
+	ProfissionalID uint `gorm:"primaryKey"`
+	PacienteID     uint `gorm:"primaryKey"`
+	// VinculadoEm e nulo em vinculos anteriores ao registro da data
+	VinculadoEm  *time.Time
+	EncerradoEm  *time.Time `gorm:"index"`
+	EncerradoPor string     `gorm:"type:varchar(20)"`
+}
+
+func (Vinculo) TableName() string {
+	return "profissional_paciente"
+}
+
+// Ativo indica se o vinculo nao foi encerrado
+func (v *Vinculo) Ativo() bool {
+	return v.EncerradoEm == nil
+}
+
+// Reativar reabre um vinculo encerrado, como ao aceitar um novo convite do mesmo profissional
+func (v *Vinculo) Reativar(agora time.Time) {
+	v.VinculadoEm = &agora
+	v.EncerradoEm = nil
+	v.EncerradoPor = ""
+}
+
+// Encerrar finaliza o vinculo e retorna o registro de auditoria do encerramento
+func (v *Vinculo) Encerrar(origem, motivo string, agora time.Time) (*EncerramentoVinculo, error) {
+	if !v.Ativo() {
+		return nil, ErrVinculoJaEncerrado
+	}
+	encerramento := &EncerramentoVinculo{
+		ProfissionalID: v.ProfissionalID,
+		PacienteID:     v.PacienteID,
+		EncerradoPor:   origem,
+		Motivo:         motivo,
+		VinculadoEm:    v.VinculadoEm,
+		EncerradoEm:    agora,
+	}
+	if err := encerramento.Validar(); err != nil {
+		return nil, err
+	}
+	v.EncerradoEm = &agora
+	v.EncerradoPor = origem
+	return encerramento, nil
+}
+
+// EncerramentoVinculo registra cada fim de vinculo; nunca e alterado
+type EncerramentoVinculo struct {
+	ID                    uint   `gorm:"primaryKey"`
+	ProfissionalID        uint   `gorm:"not null;index"`
+	PacienteID            uint   `gorm:"not null;index"`
+	EncerradoPor          string `gorm:"type:varchar(20);not null"`
+	Motivo                string `gorm:"type:text;not null"`
+	VinculadoEm           *time.Time
+	EncerradoEm           time.Time `gorm:"not null"`
+	AtribuicoesCanceladas int64     `gorm:"not null;default:0"`
+	CreatedAt             time.Time
+}
+
+func (EncerramentoVinculo) TableName() string {
+	return "encerramentos_vinculo"
+}
+
+// Validar confere a origem e o motivo do encerramento
+func (e *EncerramentoVinculo) Validar() error {
+	switch e.EncerradoPor {
+	case EncerradoPeloPaciente, EncerradoPeloProfissional, EncerradoPorExclusaoConta:
+	default:
+		return ErrOrigemEncerramentoInvalida
+	}
+	if e.Motivo == "" {
+		return ErrMotivoEncerramentoVazio
+	}
+	if len(e.Motivo) > 1000 {
+		return ErrMotivoEncerramentoLongo
+	}
+	return nil
+}
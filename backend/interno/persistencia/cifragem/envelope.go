@@ -0,0 +1,174 @@
+package cifragem
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// prefixoEnvelope marca os valores cifrados; valores sem ele sao texto legado ainda nao cifrado
+const prefixoEnvelope = "v1:"
+
+var ErrTextoCifradoInvalido = errors.New("texto cifrado invalido ou adulterado")
+
+// Envelope cifra cada valor com uma chave de dados (DEK) aleatoria,
+// e a DEK com a chave mestra atual do provedor
+// Formato: v1:<id da chave mestra>:<DEK cifrada>:<valor cifrado>, em base64 sem padding
+// Rotacionar a chave mestra exige apenas recifrar a DEK, sem tocar no valor
+type Envelope struct {
+	provedor ProvedorChaves
+}
+
+// NovoEnvelope cria um Envelope com as chaves do provedor informado
+func NovoEnvelope(provedor ProvedorChaves) *Envelope {
+	return &Envelope{provedor: provedor}
+}
+
+// Cifrado informa se o valor ja esta no formato de envelope
+func Cifrado(valor []byte) bool {
+	return bytes.HasPrefix(valor, []byte(prefixoEnvelope))
+}
+
+// Cifrar protege o texto; dadosAssociados amarram o valor ao seu lugar no banco
+func (e *Envelope) Cifrar(texto, dadosAssociados []byte) ([]byte, error) {
+	idChave, chaveMestra, err := e.provedor.ChaveAtual()
+	if err != nil {
+		return nil, err
+	}
+
+	dek := aleatorio(TamanhoChave)
+	dekCifrada, err := selar(chaveMestra, dek, []byte(idChave))
+	if err != nil {
+		return nil, err
+	}
+	corpo, err := selar(dek, texto, dadosAssociados)
+	if err != nil {
+		return nil, err
+	}
+
+	return montar(idChave, dekCifrada, corpo), nil
+}
+
+// Decifrar recupera o texto de um valor cifrado por Cifrar
+func (e *Envelope) Decifrar(cifrado, dadosAssociados []byte) ([]byte, error) {
+	idChave, dekCifrada, corpo, err := desmontar(cifrado)
+	if err != nil {
+		return nil, err
+	}
+	dek, err := e.abrirDEK(idChave, dekCifrada)
+	if err != nil {
+		return nil, err
+	}
+	return abrir(dek, corpo, dadosAssociados)
+}
+
+// Reembalar recifra a DEK com a chave mestra atual
+// Retorna false quando o valor ja usa a chave atual
+func (e *Envelope) Reembalar(cifrado []byte) ([]byte, bool, error) {
+	idChave, dekCifrada, corpo, err := desmontar(cifrado)
+	if err != nil {
+		return nil, false, err
+	}
+	idAtual, chaveAtual, err := e.provedor.ChaveAtual()
+	if err != nil {
+		return nil, false, err
+	}
+	if idChave == idAtual {
+		return cifrado, false, nil
+	}
+
+	dek, err := e.abrirDEK(idChave, dekCifrada)
+	if err != nil {
+		return nil, false, err
+	}
+	novaDEK, err := selar(chaveAtual, dek, []byte(idAtual))
+	if err != nil {
+		return nil, false, err
+	}
+	return montar(idAtual, novaDEK, corpo), true, nil
+}
+
+// Indice calcula o indice cego (HMAC-SHA256 em hex) usado para buscar e garantir unicidade
+// sem gravar o valor em claro
+func (e *Envelope) Indice(valor string) (string, error) {
+	chave, err := e.provedor.ChaveIndice()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, chave)
+	mac.Write([]byte(strings.TrimSpace(valor)))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (e *Envelope) abrirDEK(idChave string, dekCifrada []byte) ([]byte, error) {
+	chaveMestra, err := e.provedor.Chave(idChave)
+	if err != nil {
+		return nil, err
+	}
+	return abrir(chaveMestra, dekCifrada, []byte(idChave))
+}
+
+func montar(idChave string, dekCifrada, corpo []byte) []byte {
+	return []byte(prefixoEnvelope + idChave + ":" +
+		base64.RawStdEncoding.EncodeToString(dekCifrada) + ":" +
+		base64.RawStdEncoding.EncodeToString(corpo))
+}
+
+func desmontar(cifrado []byte) (string, []byte, []byte, error) {
+	if !Cifrado(cifrado) {
+		return "", nil, nil, ErrTextoCifradoInvalido
+	}
+	partes := strings.Split(string(cifrado[len(prefixoEnvelope):]), ":")
+	if len(partes) != 3 || partes[0] == "" {
+		return "", nil, nil, ErrTextoCifradoInvalido
+	}
+	dekCifrada, err := base64.RawStdEncoding.DecodeString(partes[1])
+	if err != nil {
+		return "", nil, nil, ErrTextoCifradoInvalido
+	}
+	corpo, err := base64.RawStdEncoding.DecodeString(partes[2])
+	if err != nil {
+		return "", nil, nil, ErrTextoCifradoInvalido
+	}
+	return partes[0], dekCifrada, corpo, nil
+}
+
+// selar cifra com AES-256-GCM, com o nonce aleatorio no inicio
+func selar(chave, texto, dadosAssociados []byte) ([]byte, error) {
+	aead, err := novoAEAD(chave)
+	if err != nil {
+		return nil, err
+	}
+	nonce := aleatorio(aead.NonceSize())
+	return aead.Seal(nonce, nonce, texto, dadosAssociados), nil
+}
+
+func abrir(chave, cifrado, dadosAssociados []byte) ([]byte, error) {
+	aead, err := novoAEAD(chave)
+	if err != nil {
+		return nil, err
+	}
+	if len(cifrado) < aead.NonceSize() {
+		return nil, ErrTextoCifradoInvalido
+	}
+	nonce, corpo := cifrado[:aead.NonceSize()], cifrado[aead.NonceSize():]
+	texto, err := aead.Open(nil, nonce, corpo, dadosAssociados)
+	if err != nil {
+		return nil, ErrTextoCifradoInvalido
+	}
+	return texto, nil
+}
+
+func novoAEAD(chave []byte) (cipher.AEAD, error) {
+	bloco, err := aes.NewCipher(chave)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(bloco)
+}
@@ -0,0 +1,179 @@
+package cifragem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// NomeSerializador e o serializador GORM dos campos cifrados: `gorm:"serializer:cifrado"`
+// O campo continua string ou []byte no dominio; a coluna guarda o envelope em texto
+const NomeSerializador = "cifrado"
+
+// TagIndiceCego marca o campo que guarda o indice cego de outro: `gorm:"indice_cego:CPF"`
+const TagIndiceCego = "INDICE_CEGO"
+
+var ErrCifragemNaoConfigurada = errors.New("cifragem de campos nao configurada")
+
+// envelopePadrao e usado pelo serializador, que o GORM registra de forma global
+var envelopePadrao atomic.Pointer[Envelope]
+
+func init() {
+	schema.RegisterSerializer(NomeSerializador, serializadorCifrado{})
+}
+
+// Registrar ativa a cifragem transparente dos campos marcados e o preenchimento dos indices cegos
+func Registrar(db *gorm.DB, envelope *Envelope) error {
+	envelopePadrao.Store(envelope)
+
+	if err := db.Callback().Create().Before("gorm:create").
+		Register("cifragem:indices_cegos", preencherIndicesCegos); err != nil {
+		return err
+	}
+	return db.Callback().Update().Before("gorm:update").
+		Register("cifragem:indices_cegos", preencherIndicesCegos)
+}
+
+// IndiceCego calcula o indice de um valor com o envelope registrado, para buscas nos repositorios
+func IndiceCego(valor string) (string, error) {
+	envelope := envelopePadrao.Load()
+	if envelope == nil {
+		return "", ErrCifragemNaoConfigurada
+	}
+	return envelope.Indice(valor)
+}
+
+// DadosAssociados amarra o valor a tabela e coluna, impedindo que seja copiado para outro campo
+func DadosAssociados(tabela, coluna string) []byte {
+	return []byte(tabela + "." + coluna)
+}
+
+// serializadorCifrado cifra na gravacao e decifra na leitura
+// Valores vazios ficam vazios e valores sem o prefixo do envelope sao lidos como texto legado
+type serializadorCifrado struct{}
+
+func (serializadorCifrado) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	valor := reflect.New(field.FieldType).Elem()
+
+	var bruto []byte
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		bruto = []byte(v)
+	case []byte:
+		bruto = append([]byte(nil), v...)
+	default:
+		return fmt.Errorf("cifragem: tipo %T nao suportado em %s", dbValue, field.Name)
+	}
+
+	if Cifrado(bruto) {
+		envelope := envelopePadrao.Load()
+		if envelope == nil {
+			return ErrCifragemNaoConfigurada
+		}
+		texto, err := envelope.Decifrar(bruto, DadosAssociados(field.Schema.Table, field.DBName))
+		if err != nil {
+			return fmt.Errorf("cifragem: %s: %w", field.Name, err)
+		}
+		bruto = texto
+	}
+
+	if len(bruto) > 0 {
+		switch valor.Kind() {
+		case reflect.String:
+			valor.SetString(string(bruto))
+		case reflect.Slice:
+			valor.SetBytes(bruto)
+		default:
+			return fmt.Errorf("cifragem: campo %s deve ser string ou []byte", field.Name)
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).Set(valor)
+	return nil
+}
+
+func (serializadorCifrado) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	var texto []byte
+	switch v := reflect.ValueOf(fieldValue); v.Kind() {
+	case reflect.String:
+		if v.Len() == 0 {
+			return "", nil
+		}
+		texto = []byte(v.String())
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return nil, nil
+		}
+		texto = v.Bytes()
+	default:
+		return nil, fmt.Errorf("cifragem: campo %s deve ser string ou []byte", field.Name)
+	}
+
+	envelope := envelopePadrao.Load()
+	if envelope == nil {
+		return nil, ErrCifragemNaoConfigurada
+	}
+	cifrado, err := envelope.Cifrar(texto, DadosAssociados(field.Schema.Table, field.DBName))
+	if err != nil {
+		return nil, err
+	}
+	return string(cifrado), nil
+}
+
+// preencherIndicesCegos calcula os campos marcados com indice_cego a partir do campo de origem
+// Origem vazia grava NULL, liberando o indice unico
+func preencherIndicesCegos(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	// Atualizacoes por mapa nao carregam o campo de origem; quem usa mapa grava o indice explicitamente
+	if _, ok := db.Statement.Dest.(map[string]interface{}); ok {
+		return
+	}
+
+	for _, campo := range db.Statement.Schema.Fields {
+		nomeOrigem, ok := campo.TagSettings[TagIndiceCego]
+		if !ok {
+			continue
+		}
+		origem := db.Statement.Schema.LookUpField(nomeOrigem)
+		if origem == nil {
+			db.AddError(fmt.Errorf("cifragem: campo de origem %s do indice %s inexistente", nomeOrigem, campo.Name))
+			return
+		}
+
+		switch valor := db.Statement.ReflectValue; valor.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < valor.Len(); i++ {
+				preencherIndiceCego(db, campo, origem, reflect.Indirect(valor.Index(i)))
+			}
+		case reflect.Struct:
+			preencherIndiceCego(db, campo, origem, valor)
+		}
+	}
+}
+
+// preencherIndiceCego grava o indice no campo, que deve ser *string
+func preencherIndiceCego(db *gorm.DB, campo, origem *schema.Field, registro reflect.Value) {
+	ctx := db.Statement.Context
+	destino := campo.ReflectValueOf(ctx, registro)
+	texto, _ := origem.ReflectValueOf(ctx, registro).Interface().(string)
+	if strings.TrimSpace(texto) == "" {
+		destino.Set(reflect.Zero(destino.Type()))
+		return
+	}
+
+	indice, err := IndiceCego(texto)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	destino.Set(reflect.ValueOf(&indice))
+}
@@ -0,0 +1,223 @@
+package cifragem
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// TamanhoChave e o tamanho das chaves AES-256 e da chave do indice cego
+const TamanhoChave = 32
+
+// IDChaveAmbiente identifica a chave unica lida de CIFRAGEM_CHAVE
+const IDChaveAmbiente = "padrao"
+
+var (
+	ErrChaveCifragemAusente       = errors.New("defina CIFRAGEM_CHAVES_ARQUIVO ou CIFRAGEM_CHAVE")
+	ErrChaveCifragemInvalida      = errors.New("chave de cifragem deve ter 32 bytes codificados em base64")
+	ErrChaveDesconhecida          = errors.New("chave de cifragem desconhecida")
+	ErrArquivoChavesInvalido      = errors.New("arquivo de chaves de cifragem invalido")
+	ErrIdentificadorChaveInvalido = errors.New("identificador da chave deve ser preenchido e nao pode conter ':'")
+)
+
+// ProvedorChaves entrega as chaves mestras (KEK) que protegem as chaves de dados
+// Implementacoes podem ler de arquivo, variavel de ambiente ou de um servico de chaves externo
+type ProvedorChaves interface {
+	// ChaveAtual retorna a chave usada para cifrar novos valores
+	ChaveAtual() (id string, chave []byte, err error)
+	// Chave retorna uma chave pelo identificador gravado no valor cifrado
+	Chave(id string) ([]byte, error)
+	// ChaveIndice retorna a chave HMAC dos indices cegos, que nao muda na rotacao
+	ChaveIndice() ([]byte, error)
+}
+
+// ArquivoChaves e o formato JSON do arquivo local de chaves
+// As chaves antigas permanecem no arquivo enquanto houver valores cifrados com elas
+type ArquivoChaves struct {
+	Atual  string            `json:"atual"`
+	Chaves map[string]string `json:"chaves"`
+	Indice string            `json:"indice"`
+}
+
+// provedorMemoria implementa ProvedorChaves com as chaves ja decodificadas
+type provedorMemoria struct {
+	atual  string
+	chaves map[string][]byte
+	indice []byte
+}
+
+// NovoProvedorChaveUnica cria um provedor com uma unica chave mestra
+// A chave do indice cego e derivada da chave mestra, por isso esta nao pode ser trocada
+func NovoProvedorChaveUnica(id string, chave []byte) (ProvedorChaves, error) {
+	if !identificadorValido(id) {
+		return nil, ErrIdentificadorChaveInvalido
+	}
+	if len(chave) != TamanhoChave {
+		return nil, ErrChaveCifragemInvalida
+	}
+	return &provedorMemoria{
+		atual:  id,
+		chaves: map[string][]byte{id: chave},
+		indice: derivarChaveIndice(chave),
+	}, nil
+}
+
+// NovoProvedorArquivo le as chaves do arquivo JSON local
+func NovoProvedorArquivo(caminho string) (ProvedorChaves, error) {
+	arquivo, err := LerArquivoChaves(caminho)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &provedorMemoria{atual: arquivo.Atual, chaves: make(map[string][]byte, len(arquivo.Chaves))}
+	for id, valor := range arquivo.Chaves {
+		if !identificadorValido(id) {
+			return nil, ErrIdentificadorChaveInvalido
+		}
+		chave, err := decodificarChave(valor)
+		if err != nil {
+			return nil, fmt.Errorf("chave %s: %w", id, err)
+		}
+		p.chaves[id] = chave
+	}
+	if _, ok := p.chaves[p.atual]; !ok {
+		return nil, fmt.Errorf("%w: chave atual %q ausente", ErrArquivoChavesInvalido, p.atual)
+	}
+	if p.indice, err = decodificarChave(arquivo.Indice); err != nil {
+		return nil, fmt.Errorf("chave do indice: %w", err)
+	}
+	return p, nil
+}
+
+// ChaveDoAmbiente le a chave unica de CIFRAGEM_CHAVE, ou nil se nao definida
+func ChaveDoAmbiente() ([]byte, error) {
+	valor := strings.TrimSpace(os.Getenv("CIFRAGEM_CHAVE"))
+	if valor == "" {
+		return nil, nil
+	}
+	return decodificarChave(valor)
+}
+
+// NovoProvedorDoAmbiente escolhe o provedor pelas variaveis de ambiente
+// CIFRAGEM_CHAVES_ARQUIVO aponta o arquivo de chaves e permite rotacao
+// Sem ele, CIFRAGEM_CHAVE e usada como chave unica
+func NovoProvedorDoAmbiente() (ProvedorChaves, error) {
+	if caminho := strings.TrimSpace(os.Getenv("CIFRAGEM_CHAVES_ARQUIVO")); caminho != "" {
+		return NovoProvedorArquivo(caminho)
+	}
+	chave, err := ChaveDoAmbiente()
+	if err != nil {
+		return nil, err
+	}
+	if chave == nil {
+		return nil, ErrChaveCifragemAusente
+	}
+	return NovoProvedorChaveUnica(IDChaveAmbiente, chave)
+}
+
+func (p *provedorMemoria) ChaveAtual() (string, []byte, error) {
+	return p.atual, p.chaves[p.atual], nil
+}
+
+func (p *provedorMemoria) Chave(id string) ([]byte, error) {
+	chave, ok := p.chaves[id]
+	if !ok {
+		return nil, ErrChaveDesconhecida
+	}
+	return chave, nil
+}
+
+func (p *provedorMemoria) ChaveIndice() ([]byte, error) {
+	return p.indice, nil
+}
+
+// LerArquivoChaves carrega o arquivo de chaves sem validar o conteudo
+func LerArquivoChaves(caminho string) (*ArquivoChaves, error) {
+	conteudo, err := os.ReadFile(caminho)
+	if err != nil {
+		return nil, err
+	}
+	var arquivo ArquivoChaves
+	if err := json.Unmarshal(conteudo, &arquivo); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrArquivoChavesInvalido, err)
+	}
+	return &arquivo, nil
+}
+
+// AdicionarChave gera uma nova chave mestra e a torna a atual
+// Na primeira chave tambem gera a chave do indice cego
+func (a *ArquivoChaves) AdicionarChave(id string) (string, error) {
+	if id == "" {
+		id = time.Now().UTC().Format("20060102") + "-" + base64.RawURLEncoding.EncodeToString(aleatorio(4))
+	}
+	if !identificadorValido(id) {
+		return "", ErrIdentificadorChaveInvalido
+	}
+	if a.Chaves == nil {
+		a.Chaves = map[string]string{}
+	}
+	if _, existe := a.Chaves[id]; existe {
+		return "", fmt.Errorf("chave %s ja existe", id)
+	}
+	a.Chaves[id] = base64.StdEncoding.EncodeToString(aleatorio(TamanhoChave))
+	a.Atual = id
+	if a.Indice == "" {
+		a.Indice = base64.StdEncoding.EncodeToString(aleatorio(TamanhoChave))
+	}
+	return id, nil
+}
+
+// ImportarChaveUnica leva a chave de CIFRAGEM_CHAVE para o arquivo, com o mesmo identificador
+// e a mesma chave de indice, para que os valores ja gravados continuem legiveis e localizaveis
+func (a *ArquivoChaves) ImportarChaveUnica(chave []byte) {
+	if a.Chaves == nil {
+		a.Chaves = map[string]string{}
+	}
+	a.Chaves[IDChaveAmbiente] = base64.StdEncoding.EncodeToString(chave)
+	a.Atual = IDChaveAmbiente
+	a.Indice = base64.StdEncoding.EncodeToString(derivarChaveIndice(chave))
+}
+
+// Salvar grava o arquivo de chaves legivel apenas pelo dono
+func (a *ArquivoChaves) Salvar(caminho string) error {
+	conteudo, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(caminho, append(conteudo, '\n'), 0o600)
+}
+
+// derivarChaveIndice obtem a chave do indice cego a partir da chave unica
+func derivarChaveIndice(chave []byte) []byte {
+	mac := hmac.New(sha256.New, chave)
+	mac.Write([]byte("indice-cego"))
+	return mac.Sum(nil)
+}
+
+// identificadorValido impede ':' no identificador, que separa as partes do envelope
+func identificadorValido(id string) bool {
+	return id != "" && !strings.Contains(id, ":")
+}
+
+func decodificarChave(valor string) ([]byte, error) {
+	chave, err := base64.StdEncoding.DecodeString(strings.TrimSpace(valor))
+	if err != nil || len(chave) != TamanhoChave {
+		return nil, ErrChaveCifragemInvalida
+	}
+	return chave, nil
+}
+
+func aleatorio(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
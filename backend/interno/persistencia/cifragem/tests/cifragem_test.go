@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"bytes"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/cifragem"
+	sqlite_repo "mindtrace/backend/interno/persistencia/sqlite"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func novoEnvelope(t *testing.T, id string, byteChave byte) *cifragem.Envelope {
+	provedor, err := cifragem.NovoProvedorChaveUnica(id, bytes.Repeat([]byte{byteChave}, cifragem.TamanhoChave))
+	assert.NoError(t, err)
+	return cifragem.NovoEnvelope(provedor)
+}
+
+func TestEnvelope_CifrarDecifrar(t *testing.T) {
+	envelope := novoEnvelope(t, "k1", 1)
+
+	cifrado, err := envelope.Cifrar([]byte("12345678909"), []byte("usuarios.cpf"))
+	assert.NoError(t, err)
+	assert.True(t, cifragem.Cifrado(cifrado))
+	assert.True(t, strings.HasPrefix(string(cifrado), "v1:k1:"))
+	assert.NotContains(t, string(cifrado), "12345678909")
+
+	texto, err := envelope.Decifrar(cifrado, []byte("usuarios.cpf"))
+	assert.NoError(t, err)
+	assert.Equal(t, "12345678909", string(texto))
+
+	// Outro lugar no banco nao decifra o mesmo valor
+	_, err = envelope.Decifrar(cifrado, []byte("usuarios.contato"))
+	assert.Equal(t, cifragem.ErrTextoCifradoInvalido, err)
+}
+
+func TestEnvelope_Reembalar(t *testing.T) {
+	dir := t.TempDir()
+	caminho := filepath.Join(dir, "chaves.json")
+
+	arquivo := &cifragem.ArquivoChaves{}
+	_, err := arquivo.AdicionarChave("k1")
+	assert.NoError(t, err)
+	assert.NoError(t, arquivo.Salvar(caminho))
+	provedorAntigo, err := cifragem.NovoProvedorArquivo(caminho)
+	assert.NoError(t, err)
+	antigo := cifragem.NovoEnvelope(provedorAntigo)
+
+	cifrado, err := antigo.Cifrar([]byte("dormi mal"), []byte("registros_humor.observacoes"))
+	assert.NoError(t, err)
+
+	_, err = arquivo.AdicionarChave("k2")
+	assert.NoError(t, err)
+	assert.NoError(t, arquivo.Salvar(caminho))
+	provedorNovo, err := cifragem.NovoProvedorArquivo(caminho)
+	assert.NoError(t, err)
+	novo := cifragem.NovoEnvelope(provedorNovo)
+
+	reembalado, alterado, err := novo.Reembalar(cifrado)
+	assert.NoError(t, err)
+	assert.True(t, alterado)
+	assert.True(t, strings.HasPrefix(string(reembalado), "v1:k2:"))
+
+	texto, err := novo.Decifrar(reembalado, []byte("registros_humor.observacoes"))
+	assert.NoError(t, err)
+	assert.Equal(t, "dormi mal", string(texto))
+
+	_, alterado, err = novo.Reembalar(reembalado)
+	assert.NoError(t, err)
+	assert.False(t, alterado)
+
+	// O indice cego nao muda com a rotacao da chave mestra
+	indiceAntigo, _ := antigo.Indice("12345678909")
+	indiceNovo, _ := novo.Indice("12345678909")
+	assert.Equal(t, indiceAntigo, indiceNovo)
+}
+
+func TestRegistrar_CamposCifradosEIndiceCego(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, cifragem.Registrar(db, novoEnvelope(t, "k1", 2)))
+	assert.NoError(t, db.AutoMigrate(&dominio.Usuario{}))
+
+	usuario := &dominio.Usuario{TipoUsuario: dominio.TipoUsuarioPaciente, Nome: "Ana", Email: "ana@example.com", Senha: "x", CPF: "12345678909", Contato: "11999999999"}
+	assert.NoError(t, db.Create(usuario).Error)
+	assert.NotNil(t, usuario.CPFIndice)
+
+	var bruto struct {
+		CPF     string
+		Contato string
+	}
+	assert.NoError(t, db.Table("usuarios").Select("cpf, contato").Where("id = ?", usuario.ID).Scan(&bruto).Error)
+	assert.True(t, cifragem.Cifrado([]byte(bruto.CPF)))
+	assert.True(t, cifragem.Cifrado([]byte(bruto.Contato)))
+
+	repo := sqlite_repo.NovoGormUsuarioRepositorio(db)
+	encontrado, err := repo.BuscarPorCPF("12345678909")
+	assert.NoError(t, err)
+	assert.Equal(t, usuario.ID, encontrado.ID)
+	assert.Equal(t, "11999999999", encontrado.Contato)
+
+	// O indice unico barra o mesmo CPF em outra conta
+	outro := &dominio.Usuario{TipoUsuario: dominio.TipoUsuarioPaciente, Nome: "Bia", Email: "bia@example.com", Senha: "x", CPF: "12345678909"}
+	assert.Error(t, db.Create(outro).Error)
+
+	// Valores gravados antes da cifragem continuam legiveis
+	assert.NoError(t, db.Table("usuarios").Where("id = ?", usuario.ID).Update("contato", "11888888888").Error)
+	encontrado, err = repo.BuscarUsuarioPorID(usuario.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "11888888888", encontrado.Contato)
+}
+
+func TestArquivoChaves_ImportarChaveUnica(t *testing.T) {
+	chave := bytes.Repeat([]byte{3}, cifragem.TamanhoChave)
+	provedorUnico, err := cifragem.NovoProvedorChaveUnica(cifragem.IDChaveAmbiente, chave)
+	assert.NoError(t, err)
+	unico := cifragem.NovoEnvelope(provedorUnico)
+	cifrado, err := unico.Cifrar([]byte("{\"q1\":2}"), []byte("respostas.dados_brutos"))
+	assert.NoError(t, err)
+
+	caminho := filepath.Join(t.TempDir(), "chaves.json")
+	arquivo := &cifragem.ArquivoChaves{}
+	arquivo.ImportarChaveUnica(chave)
+	_, err = arquivo.AdicionarChave("k2")
+	assert.NoError(t, err)
+	assert.NoError(t, arquivo.Salvar(caminho))
+	provedorArquivo, err := cifragem.NovoProvedorArquivo(caminho)
+	assert.NoError(t, err)
+	doArquivo := cifragem.NovoEnvelope(provedorArquivo)
+
+	texto, err := doArquivo.Decifrar(cifrado, []byte("respostas.dados_brutos"))
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"q1\":2}", string(texto))
+
+	indiceUnico, _ := unico.Indice("12345678909")
+	indiceArquivo, _ := doArquivo.Indice("12345678909")
+	assert.Equal(t, indiceUnico, indiceArquivo)
+}
@@ -0,0 +1,64 @@
+package memoria
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"sync"
+	"time"
+)
+
+// limiteEntradasAntesDeLimpeza evita que o mapa cresca sem controle sob ataque
+const limiteEntradasAntesDeLimpeza = 10000
+
+type tentativaLoginRepositorio struct {
+	mu         sync.Mutex
+	tentativas map[string]dominio.TentativaLogin
+}
+
+// NovoTentativaLoginRepositorio cria um contador de tentativas em memoria
+// Adequado para uma unica instancia da api; os contadores sao perdidos ao reiniciar
+func NovoTentativaLoginRepositorio() repositorios.TentativaLoginRepositorio {
+	return &tentativaLoginRepositorio{tentativas: make(map[string]dominio.TentativaLogin)}
+}
+
+func (r *tentativaLoginRepositorio) BuscarTentativa(chave string) (*dominio.TentativaLogin, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tentativa, ok := r.tentativas[chave]
+	if !ok {
+		return &dominio.TentativaLogin{Chave: chave}, nil
+	}
+	return &tentativa, nil
+}
+
+// RegistrarFalhaTentativa le, contabiliza e grava sob a mesma trava
+func (r *tentativaLoginRepositorio) RegistrarFalhaTentativa(chave string, agora time.Time, limite int) (*dominio.TentativaLogin, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tentativa, ok := r.tentativas[chave]
+	if !ok {
+		if len(r.tentativas) >= limiteEntradasAntesDeLimpeza {
+			r.limparExpiradas(agora)
+		}
+		tentativa = dominio.TentativaLogin{Chave: chave}
+	}
+	bloqueou := tentativa.RegistrarFalha(agora, limite)
+	r.tentativas[chave] = tentativa
+	return &tentativa, bloqueou, nil
+}
+
+func (r *tentativaLoginRepositorio) RemoverTentativa(chave string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tentativas, chave)
+	return nil
+}
+
+// limparExpiradas descarta contadores sem bloqueio ativo e sem falhas recentes
+func (r *tentativaLoginRepositorio) limparExpiradas(agora time.Time) {
+	for chave, tentativa := range r.tentativas {
+		if !tentativa.EstaBloqueada(agora) && agora.Sub(tentativa.UltimaFalhaEm) > dominio.MemoriaBloqueiosLogin {
+			delete(r.tentativas, chave)
+		}
+	}
+}
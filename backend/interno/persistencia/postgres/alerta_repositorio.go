@@ -1 +1 @@
-package postgres
\ No newline at end of file
+package postgres
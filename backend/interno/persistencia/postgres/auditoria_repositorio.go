@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"errors"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type gormAuditoriaRepositorio struct{ db *gorm.DB }
+
+func NovoGormAuditoriaRepositorio(db *gorm.DB) repositorios.AuditoriaRepositorio {
+	return &gormAuditoriaRepositorio{db: db}
+}
+
+// chaveTravaAuditoria identifica o advisory lock da cadeia de auditoria
+const chaveTravaAuditoria = 410041
+
+// TravarCadeia usa um advisory lock de transacao, liberado no commit ou rollback,
+// para que duas instancias da api nao encadeiem registros no mesmo hash anterior
+func (r *gormAuditoriaRepositorio) TravarCadeia(tx *gorm.DB) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(?)", chaveTravaAuditoria).Error
+}
+
+func (r *gormAuditoriaRepositorio) BuscarUltimoRegistro(tx *gorm.DB) (*dominio.RegistroAuditoria, error) {
+	var registro dominio.RegistroAuditoria
+	if err := tx.Order("id DESC").First(&registro).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &registro, nil
+}
+
+func (r *gormAuditoriaRepositorio) CriarRegistro(tx *gorm.DB, registro *dominio.RegistroAuditoria) error {
+	return tx.Omit("Ator").Create(registro).Error
+}
+
+func (r *gormAuditoriaRepositorio) ListarAcessosDoPaciente(tx *gorm.DB, pacienteID, ignorarUsuarioID uint, de, ate *time.Time, limite int) ([]*dominio.RegistroAuditoria, error) {
+	consulta := tx.Preload("Ator", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("paciente_id = ?", pacienteID).
+		Where("ator_id IS NULL OR ator_id <> ?", ignorarUsuarioID)
+	if de != nil {
+		consulta = consulta.Where("criado_em >= ?", *de)
+	}
+	if ate != nil {
+		consulta = consulta.Where("criado_em < ?", *ate)
+	}
+
+	var registros []*dominio.RegistroAuditoria
+	err := consulta.Order("criado_em DESC, id DESC").Limit(limite).Find(&registros).Error
+	return registros, err
+}
+
+func (r *gormAuditoriaRepositorio) ListarRegistrosAposID(tx *gorm.DB, aposID uint, limite int) ([]*dominio.RegistroAuditoria, error) {
+	var registros []*dominio.RegistroAuditoria
+	err := tx.Where("id > ?", aposID).Order("id").Limit(limite).Find(&registros).Error
+	return registros, err
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+
+	"gorm.io/gorm"
+)
+
+type gormAutoCuidadoRepositorio struct{ db *gorm.DB }
+
+func NovoGormAutoCuidadoRepositorio(db *gorm.DB) repositorios.AutoCuidadoRepositorio {
+	return &gormAutoCuidadoRepositorio{db: db}
+}
+
+func (r *gormAutoCuidadoRepositorio) CriarAtividade(tx *gorm.DB, atividade *dominio.AtividadeAutoCuidado) error {
+	return tx.Omit("Profissional").Create(atividade).Error
+}
+
+func (r *gormAutoCuidadoRepositorio) AtualizarAtividade(tx *gorm.DB, atividade *dominio.AtividadeAutoCuidado) error {
+	return tx.Omit("Profissional").Save(atividade).Error
+}
+
+func (r *gormAutoCuidadoRepositorio) BuscarAtividadePorID(tx *gorm.DB, atividadeID uint) (*dominio.AtividadeAutoCuidado, error) {
+	var atividade dominio.AtividadeAutoCuidado
+	if err := tx.First(&atividade, atividadeID).Error; err != nil {
+		return nil, err
+	}
+	return &atividade, nil
+}
+
+func (r *gormAutoCuidadoRepositorio) BuscarAtividadeDoProfissionalPorNome(tx *gorm.DB, profissionalID uint, nomeNormalizado string) (*dominio.AtividadeAutoCuidado, error) {
+	var atividade dominio.AtividadeAutoCuidado
+	err := tx.Where("profissional_id = ? AND nome_normalizado = ?", profissionalID, nomeNormalizado).First(&atividade).Error
+	if err != nil {
+		return nil, err
+	}
+	return &atividade, nil
+}
+
+func (r *gormAutoCuidadoRepositorio) BuscarAtividadesPorIDs(tx *gorm.DB, ids []uint) ([]*dominio.AtividadeAutoCuidado, error) {
+	var atividades []*dominio.AtividadeAutoCuidado
+	if len(ids) == 0 {
+		return atividades, nil
+	}
+	err := tx.Where("id IN ?", ids).Order("id").Find(&atividades).Error
+	return atividades, err
+}
+
+func (r *gormAutoCuidadoRepositorio) ListarCatalogoDoProfissional(tx *gorm.DB, profissionalID uint) ([]*dominio.AtividadeAutoCuidado, error) {
+	var atividades []*dominio.AtividadeAutoCuidado
+	err := tx.Where("ativa = ? AND (profissional_id IS NULL OR profissional_id = ?)", true, profissionalID).
+		Order("profissional_id IS NOT NULL, nome").Find(&atividades).Error
+	return atividades, err
+}
+
+func (r *gormAutoCuidadoRepositorio) ListarCatalogoDoPaciente(tx *gorm.DB, pacienteID uint) ([]*dominio.AtividadeAutoCuidado, error) {
+	var atividades []*dominio.AtividadeAutoCuidado
+	vinculados := tx.Session(&gorm.Session{NewDB: true}).Model(&dominio.Vinculo{}).
+		Select("profissional_id").Where("paciente_id = ? AND encerrado_em IS NULL", pacienteID)
+	err := tx.Where("ativa = ? AND (profissional_id IS NULL OR profissional_id IN (?))", true, vinculados).
+		Order("profissional_id IS NOT NULL, nome").Find(&atividades).Error
+	return atividades, err
+}
+
+func (r *gormAutoCuidadoRepositorio) SubstituirAtividadesDoRegistro(tx *gorm.DB, registroID uint, atividadeIDs []uint) error {
+	if err := tx.Where("registro_humor_id = ?", registroID).Delete(&dominio.RegistroHumorAtividade{}).Error; err != nil {
+		return err
+	}
+	if len(atividadeIDs) == 0 {
+		return nil
+	}
+	vinculos := make([]*dominio.RegistroHumorAtividade, 0, len(atividadeIDs))
+	for _, atividadeID := range atividadeIDs {
+		vinculos = append(vinculos, &dominio.RegistroHumorAtividade{RegistroHumorID: registroID, AtividadeID: atividadeID})
+	}
+	return tx.Create(&vinculos).Error
+}
+
+func (r *gormAutoCuidadoRepositorio) ListarAtividadesDosRegistros(tx *gorm.DB, registroIDs []uint) ([]*dominio.RegistroHumorAtividade, error) {
+	var vinculos []*dominio.RegistroHumorAtividade
+	if len(registroIDs) == 0 {
+		return vinculos, nil
+	}
+	err := tx.Where("registro_humor_id IN ?", registroIDs).Find(&vinculos).Error
+	return vinculos, err
+}
@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type gormConsentimentoRepositorio struct{ db *gorm.DB }
+
+func NovoGormConsentimentoRepositorio(db *gorm.DB) repositorios.ConsentimentoRepositorio {
+	return &gormConsentimentoRepositorio{db: db}
+}
+
+// filtroVersaoAtual restringe a busca a ultima versao de pares que continuam vinculados
+const filtroVersaoAtual = `EXISTS (SELECT 1 FROM profissional_paciente
+	WHERE profissional_paciente.paciente_id = consentimentos.paciente_id
+	AND profissional_paciente.profissional_id = consentimentos.profissional_id
+	AND profissional_paciente.encerrado_em IS NULL)
+	AND NOT EXISTS (SELECT 1 FROM consentimentos AS posterior
+	WHERE posterior.paciente_id = consentimentos.paciente_id
+	AND posterior.profissional_id = consentimentos.profissional_id
+	AND posterior.versao > consentimentos.versao)`
+
+func (r *gormConsentimentoRepositorio) CriarConsentimento(tx *gorm.DB, consentimento *dominio.Consentimento) error {
+	return tx.Omit("Profissional").Create(consentimento).Error
+}
+
+func (r *gormConsentimentoRepositorio) BuscarConsentimentoAtual(tx *gorm.DB, pacienteID, profissionalID uint) (*dominio.Consentimento, error) {
+	var consentimento dominio.Consentimento
+	err := tx.Where("paciente_id = ? AND profissional_id = ?", pacienteID, profissionalID).
+		Where(filtroVersaoAtual).
+		First(&consentimento).Error
+	if err != nil {
+		return nil, err
+	}
+	return &consentimento, nil
+}
+
+func (r *gormConsentimentoRepositorio) ListarConsentimentosAtuaisDoPaciente(tx *gorm.DB, pacienteID uint) ([]*dominio.Consentimento, error) {
+	var consentimentos []*dominio.Consentimento
+	err := tx.Preload("Profissional.Usuario").
+		Where("paciente_id = ?", pacienteID).
+		Where(filtroVersaoAtual).
+		Order("profissional_id").
+		Find(&consentimentos).Error
+	return consentimentos, err
+}
+
+func (r *gormConsentimentoRepositorio) ListarConsentimentosAtuaisDoProfissional(tx *gorm.DB, profissionalID uint) ([]*dominio.Consentimento, error) {
+	var consentimentos []*dominio.Consentimento
+	err := tx.Where("profissional_id = ?", profissionalID).
+		Where(filtroVersaoAtual).
+		Find(&consentimentos).Error
+	return consentimentos, err
+}
+
+func (r *gormConsentimentoRepositorio) ListarVersoesConsentimento(tx *gorm.DB, pacienteID, profissionalID uint) ([]*dominio.Consentimento, error) {
+	var consentimentos []*dominio.Consentimento
+	err := tx.Where("paciente_id = ? AND profissional_id = ?", pacienteID, profissionalID).
+		Order("versao DESC").
+		Find(&consentimentos).Error
+	return consentimentos, err
+}
+
+// CriarConsentimentosLegados preserva o acesso que os vinculos antigos ja tinham:
+// categorias padrao sem limite de data, ate o paciente definir o proprio consentimento
+func (r *gormConsentimentoRepositorio) CriarConsentimentosLegados(tx *gorm.DB, agora time.Time) (int64, error) {
+	padrao := dominio.NovoConsentimentoPadrao(0, 0, time.Time{})
+	resultado := tx.Exec(`INSERT INTO consentimentos
+		(paciente_id, profissional_id, versao, compartilhar_humor, compartilhar_sono,
+		compartilhar_observacoes, compartilhar_questionarios, data_inicio, revogado, created_at)
+		SELECT profissional_paciente.paciente_id, profissional_paciente.profissional_id, ?, ?, ?, ?, ?, ?, ?, ?
+		FROM profissional_paciente
+		WHERE profissional_paciente.encerrado_em IS NULL
+		AND NOT EXISTS (SELECT 1 FROM consentimentos
+		WHERE consentimentos.paciente_id = profissional_paciente.paciente_id
+		AND consentimentos.profissional_id = profissional_paciente.profissional_id)`,
+		padrao.Versao, padrao.CompartilharHumor, padrao.CompartilharSono,
+		padrao.CompartilharObservacoes, padrao.CompartilharQuestionarios, padrao.DataInicio, false, agora)
+	return resultado.RowsAffected, resultado.Error
+}
@@ -25,9 +25,37 @@ func (r *gormConviteRepositorio) BuscarConvitePorToken(tx *gorm.DB, token string
 	return &convite, nil
 }
 
+func (r *gormConviteRepositorio) BuscarConvitePorID(tx *gorm.DB, id uint) (*dominio.Convite, error) {
+	var convite dominio.Convite
+	if err := tx.First(&convite, id).Error; err != nil {
+		return nil, err
+	}
+	return &convite, nil
+}
+
+func (r *gormConviteRepositorio) ListarConvitesDoProfissional(tx *gorm.DB, profissionalID uint) ([]*dominio.Convite, error) {
+	var convites []*dominio.Convite
+	err := tx.Where("profissional_id = ?", profissionalID).Order("created_at DESC").Find(&convites).Error
+	return convites, err
+}
+
 func (r *gormConviteRepositorio) MarcarConviteComoUsado(tx *gorm.DB, convite *dominio.Convite) error {
-	return tx.Model(&dominio.Convite{}).Where("id = ?", convite.ID).Updates(map[string]interface{}{
-		"usado":       convite.Usado,
-		"paciente_id": convite.PacienteID,
-	}).Error
+	resultado := tx.Model(&dominio.Convite{}).
+		Where("id = ? AND usos = ? AND usado = ? AND revogado_em IS NULL", convite.ID, convite.Usos-1, false).
+		Updates(map[string]interface{}{
+			"usos":        convite.Usos,
+			"usado":       convite.Usado,
+			"paciente_id": convite.PacienteID,
+		})
+	if resultado.Error != nil {
+		return resultado.Error
+	}
+	if resultado.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *gormConviteRepositorio) RevogarConvite(tx *gorm.DB, convite *dominio.Convite) error {
+	return tx.Model(&dominio.Convite{}).Where("id = ?", convite.ID).Update("revogado_em", convite.RevogadoEm).Error
 }
@@ -0,0 +1,271 @@
+package postgres
+
+import (
+	"errors"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormExclusaoContaRepositorio struct{ db *gorm.DB }
+
+func NovoGormExclusaoContaRepositorio(db *gorm.DB) repositorios.ExclusaoContaRepositorio {
+	return &gormExclusaoContaRepositorio{db: db}
+}
+
+func (r *gormExclusaoContaRepositorio) CriarExclusao(tx *gorm.DB, exclusao *dominio.ExclusaoConta) error {
+	return tx.Create(exclusao).Error
+}
+
+func (r *gormExclusaoContaRepositorio) AtualizarExclusao(tx *gorm.DB, exclusao *dominio.ExclusaoConta) error {
+	return tx.Save(exclusao).Error
+}
+
+func (r *gormExclusaoContaRepositorio) BuscarExclusaoAgendada(tx *gorm.DB, usuarioID uint) (*dominio.ExclusaoConta, error) {
+	var exclusao dominio.ExclusaoConta
+	err := tx.Where("usuario_id = ? AND status = ?", usuarioID, dominio.StatusExclusaoAgendada).
+		First(&exclusao).Error
+	if err != nil {
+		return nil, err
+	}
+	return &exclusao, nil
+}
+
+func (r *gormExclusaoContaRepositorio) ListarExclusoesVencidas(tx *gorm.DB, agora time.Time) ([]*dominio.ExclusaoConta, error) {
+	var exclusoes []*dominio.ExclusaoConta
+	err := tx.Where("status = ? AND data_execucao <= ?", dominio.StatusExclusaoAgendada, agora).
+		Order("data_execucao").
+		Find(&exclusoes).Error
+	return exclusoes, err
+}
+
+// ListarUsuariosVinculados retorna os usuarios do outro lado dos vinculos ativos profissional-paciente
+func (r *gormExclusaoContaRepositorio) ListarUsuariosVinculados(tx *gorm.DB, usuarioID uint) ([]*dominio.Usuario, error) {
+	var profissionais []*dominio.Usuario
+	err := tx.
+		Joins("JOIN profissionais ON profissionais.usuario_id = usuarios.id AND profissionais.deleted_at IS NULL").
+		Joins("JOIN profissional_paciente ON profissional_paciente.profissional_id = profissionais.id AND profissional_paciente.encerrado_em IS NULL").
+		Joins("JOIN pacientes ON pacientes.id = profissional_paciente.paciente_id").
+		Where("pacientes.usuario_id = ?", usuarioID).
+		Find(&profissionais).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var pacientes []*dominio.Usuario
+	err = tx.
+		Joins("JOIN pacientes ON pacientes.usuario_id = usuarios.id AND pacientes.deleted_at IS NULL").
+		Joins("JOIN profissional_paciente ON profissional_paciente.paciente_id = pacientes.id AND profissional_paciente.encerrado_em IS NULL").
+		Joins("JOIN profissionais ON profissionais.id = profissional_paciente.profissional_id").
+		Where("profissionais.usuario_id = ?", usuarioID).
+		Find(&pacientes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var responsaveis []*dominio.Usuario
+	err = tx.
+		Joins("JOIN responsaveis ON responsaveis.usuario_id = usuarios.id AND responsaveis.deleted_at IS NULL").
+		Joins("JOIN responsavel_paciente ON responsavel_paciente.responsavel_id = responsaveis.id AND responsavel_paciente.encerrado_em IS NULL").
+		Joins("JOIN pacientes ON pacientes.id = responsavel_paciente.paciente_id").
+		Where("pacientes.usuario_id = ?", usuarioID).
+		Find(&responsaveis).Error
+	if err != nil {
+		return nil, err
+	}
+
+	usuarios := append(profissionais, pacientes...)
+	return append(usuarios, responsaveis...), nil
+}
+
+func (r *gormExclusaoContaRepositorio) AnonimizarTitular(tx *gorm.DB, usuario *dominio.Usuario, emailOriginal string) ([]string, error) {
+	var paciente dominio.Paciente
+	err := tx.Where("usuario_id = ?", usuario.ID).First(&paciente).Error
+	if err == nil {
+		if err := encerrarVinculosDoTitular(tx, "paciente_id", paciente.ID); err != nil {
+			return nil, err
+		}
+		if err := encerrarResponsaveisDoTitular(tx, "paciente_id", paciente.ID); err != nil {
+			return nil, err
+		}
+		paciente.Anonimizar()
+		if err := tx.Omit(clause.Associations).Save(&paciente).Error; err != nil {
+			return nil, err
+		}
+		// Os valores numericos permanecem para as estatisticas; o texto livre pode identificar o paciente
+		// NULL evita conflito no indice unico que inclui as observacoes
+		if err := tx.Model(&dominio.RegistroHumor{}).
+			Where("paciente_id = ?", paciente.ID).
+			Update("observacoes", gorm.Expr("NULL")).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Model(&dominio.RevisaoRegistroHumor{}).
+			Where("paciente_id = ?", paciente.ID).
+			Update("observacoes", gorm.Expr("NULL")).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Where("paciente_id = ? AND status = ?", paciente.ID, dominio.StatusPendente).
+			Delete(&dominio.Atribuicao{}).Error; err != nil {
+			return nil, err
+		}
+		// As metricas saem do formulario; os valores ja registrados ficam junto dos registros de humor
+		if err := tx.Model(&dominio.MetricaPersonalizada{}).
+			Where("paciente_id = ?", paciente.ID).
+			Update("ativa", false).Error; err != nil {
+			return nil, err
+		}
+		if err := cancelarConsultasFuturas(tx, "paciente_id", paciente.ID); err != nil {
+			return nil, err
+		}
+		// O historico de encerramentos fica para o profissional, mas o motivo e texto livre
+		if err := tx.Model(&dominio.EncerramentoVinculo{}).
+			Where("paciente_id = ?", paciente.ID).
+			Update("motivo", "").Error; err != nil {
+			return nil, err
+		}
+		// Sem vinculos, os consentimentos nao liberam mais nada e so revelariam as escolhas do paciente
+		if err := tx.Where("paciente_id = ?", paciente.ID).Delete(&dominio.Consentimento{}).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Delete(&paciente).Error; err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var profissional dominio.Profissional
+	err = tx.Where("usuario_id = ?", usuario.ID).First(&profissional).Error
+	if err == nil {
+		if err := encerrarVinculosDoTitular(tx, "profissional_id", profissional.ID); err != nil {
+			return nil, err
+		}
+		profissional.Anonimizar()
+		if err := tx.Omit(clause.Associations).Save(&profissional).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Where("profissional_id = ? AND status = ?", profissional.ID, dominio.StatusPendente).
+			Delete(&dominio.Atribuicao{}).Error; err != nil {
+			return nil, err
+		}
+		if err := cancelarConsultasFuturas(tx, "profissional_id", profissional.ID); err != nil {
+			return nil, err
+		}
+		// O link .ics deixa de funcionar junto com a conta
+		if err := tx.Where("profissional_id = ?", profissional.ID).Delete(&dominio.CalendarioProfissional{}).Error; err != nil {
+			return nil, err
+		}
+		// Convites ainda nao utilizados sao revogados
+		if err := tx.Where("profissional_id = ? AND usado = ?", profissional.ID, false).
+			Delete(&dominio.Convite{}).Error; err != nil {
+			return nil, err
+		}
+		// Os e-mails dos destinatarios tambem saem dos convites ja apagados
+		if err := tx.Unscoped().Model(&dominio.Convite{}).
+			Where("profissional_id = ? AND email_destinatario <> ''", profissional.ID).
+			Update("email_destinatario", "").Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Delete(&profissional).Error; err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var responsavel dominio.Responsavel
+	err = tx.Where("usuario_id = ?", usuario.ID).First(&responsavel).Error
+	if err == nil {
+		if err := encerrarResponsaveisDoTitular(tx, "responsavel_id", responsavel.ID); err != nil {
+			return nil, err
+		}
+		if err := tx.Delete(&responsavel).Error; err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Convites enderecados ao titular perdem o e-mail; os nao utilizados sao revogados,
+	// ja que um convite sem destinatario poderia ser resgatado por qualquer paciente
+	if err := tx.Unscoped().Model(&dominio.Convite{}).
+		Where("LOWER(email_destinatario) = LOWER(?)", emailOriginal).
+		Updates(map[string]any{
+			"email_destinatario": "",
+			"revogado_em":        gorm.Expr("COALESCE(revogado_em, ?)", time.Now()),
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	var arquivos []string
+	if err := tx.Model(&dominio.ExportacaoDados{}).
+		Where("usuario_id = ? AND caminho_arquivo <> ''", usuario.ID).
+		Pluck("caminho_arquivo", &arquivos).Error; err != nil {
+		return nil, err
+	}
+
+	// Credenciais, tokens pendentes e dados auxiliares sao apagados definitivamente
+	for _, modelo := range []any{
+		&dominio.ExportacaoDados{},
+		&dominio.Notificacao{},
+		&dominio.RedefinicaoSenha{},
+		&dominio.VerificacaoEmail{},
+		&dominio.DesafioDoisFatores{},
+		&dominio.CodigoRecuperacao{},
+		&dominio.DoisFatores{},
+	} {
+		if err := tx.Where("usuario_id = ?", usuario.ID).Delete(modelo).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Where("chave = ?", dominio.ChaveTentativa(dominio.TipoTentativaConta, emailOriginal)).
+		Delete(&dominio.BloqueioLogin{}).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Omit(clause.Associations).Save(usuario).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&dominio.Usuario{}).Where("id = ?", usuario.ID).
+		Updates(map[string]interface{}{"cpf": gorm.Expr("NULL"), "cpf_indice": gorm.Expr("NULL")}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Delete(usuario).Error; err != nil {
+		return nil, err
+	}
+
+	return arquivos, nil
+}
+
+// encerrarVinculosDoTitular marca os vinculos ativos como encerrados pela exclusao da conta
+// A linha do vinculo permanece para o historico, ligada apenas ao titular ja anonimizado
+func encerrarVinculosDoTitular(tx *gorm.DB, coluna string, id uint) error {
+	return tx.Model(&dominio.Vinculo{}).
+		Where(coluna+" = ? AND encerrado_em IS NULL", id).
+		Updates(map[string]any{
+			"encerrado_em":  time.Now(),
+			"encerrado_por": dominio.EncerradoPorExclusaoConta,
+		}).Error
+}
+
+// encerrarResponsaveisDoTitular encerra os vinculos ativos entre responsaveis e dependentes do titular
+// A auditoria das acoes do responsavel permanece
+func encerrarResponsaveisDoTitular(tx *gorm.DB, coluna string, id uint) error {
+	return tx.Model(&dominio.VinculoResponsavel{}).
+		Where(coluna+" = ? AND encerrado_em IS NULL", id).
+		Updates(map[string]any{
+			"encerrado_em":  time.Now(),
+			"encerrado_por": dominio.TutelaEncerradaPorExclusao,
+		}).Error
+}
+
+// cancelarConsultasFuturas cancela as consultas ainda agendadas do titular
+// Consultas passadas permanecem no historico do outro lado, ligadas ao ID anonimizado
+func cancelarConsultasFuturas(tx *gorm.DB, coluna string, id uint) error {
+	return tx.Model(&dominio.Consulta{}).
+		Where(coluna+" = ? AND status = ? AND inicio > ?", id, dominio.StatusConsultaAgendada, time.Now()).
+		Update("status", dominio.StatusConsultaCancelada).Error
+}
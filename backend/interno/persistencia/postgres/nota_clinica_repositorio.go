@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+
+	"gorm.io/gorm"
+)
+
+type gormNotaClinicaRepositorio struct{ db *gorm.DB }
+
+func NovoGormNotaClinicaRepositorio(db *gorm.DB) repositorios.NotaClinicaRepositorio {
+	return &gormNotaClinicaRepositorio{db: db}
+}
+
+func (r *gormNotaClinicaRepositorio) CriarNota(tx *gorm.DB, nota *dominio.NotaClinica) error {
+	return tx.Omit("Compartilhamentos").Create(nota).Error
+}
+
+func (r *gormNotaClinicaRepositorio) AtualizarRevisaoAtual(tx *gorm.DB, nota *dominio.NotaClinica) error {
+	return tx.Model(nota).Update("revisao_atual", nota.RevisaoAtual).Error
+}
+
+func (r *gormNotaClinicaRepositorio) CriarRevisao(tx *gorm.DB, revisao *dominio.RevisaoNotaClinica) error {
+	return tx.Create(revisao).Error
+}
+
+func (r *gormNotaClinicaRepositorio) BuscarNotaPorID(tx *gorm.DB, notaID uint) (*dominio.NotaClinica, error) {
+	var nota dominio.NotaClinica
+	if err := tx.Preload("Compartilhamentos").First(&nota, notaID).Error; err != nil {
+		return nil, err
+	}
+	if err := r.carregarRevisoesAtuais(tx, []*dominio.NotaClinica{&nota}); err != nil {
+		return nil, err
+	}
+	return &nota, nil
+}
+
+func (r *gormNotaClinicaRepositorio) ListarRevisoes(tx *gorm.DB, notaID uint) ([]*dominio.RevisaoNotaClinica, error) {
+	var revisoes []*dominio.RevisaoNotaClinica
+	err := tx.Where("nota_id = ?", notaID).Order("revisao DESC").Find(&revisoes).Error
+	return revisoes, err
+}
+
+func (r *gormNotaClinicaRepositorio) ListarNotasVisiveis(tx *gorm.DB, profissionalID, pacienteID uint) ([]*dominio.NotaClinica, error) {
+	var notas []*dominio.NotaClinica
+	compartilhadas := tx.Model(&dominio.CompartilhamentoNota{}).
+		Select("nota_id").
+		Where("profissional_id = ? AND revogado_em IS NULL", profissionalID)
+	err := tx.Preload("Compartilhamentos").
+		Where("paciente_id = ? AND (profissional_id = ? OR id IN (?))", pacienteID, profissionalID, compartilhadas).
+		Find(&notas).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := r.carregarRevisoesAtuais(tx, notas); err != nil {
+		return nil, err
+	}
+	return notas, nil
+}
+
+func (r *gormNotaClinicaRepositorio) SalvarCompartilhamento(tx *gorm.DB, compartilhamento *dominio.CompartilhamentoNota) error {
+	return tx.Save(compartilhamento).Error
+}
+
+// carregarRevisoesAtuais busca, em uma unica consulta, a revisao atual de cada nota
+func (r *gormNotaClinicaRepositorio) carregarRevisoesAtuais(tx *gorm.DB, notas []*dominio.NotaClinica) error {
+	if len(notas) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(notas))
+	for i, nota := range notas {
+		ids[i] = nota.ID
+	}
+
+	var revisoes []*dominio.RevisaoNotaClinica
+	err := tx.Joins("JOIN notas_clinicas ON notas_clinicas.id = revisoes_notas_clinicas.nota_id AND notas_clinicas.revisao_atual = revisoes_notas_clinicas.revisao").
+		Where("revisoes_notas_clinicas.nota_id IN ?", ids).
+		Find(&revisoes).Error
+	if err != nil {
+		return err
+	}
+
+	porNota := make(map[uint]*dominio.RevisaoNotaClinica, len(revisoes))
+	for _, revisao := range revisoes {
+		porNota[revisao.NotaID] = revisao
+	}
+	for _, nota := range notas {
+		nota.Atual = porNota[nota.ID]
+	}
+	return nil
+}
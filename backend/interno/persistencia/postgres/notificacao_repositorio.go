@@ -1 +1,27 @@
 package postgres
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+
+	"gorm.io/gorm"
+)
+
+type gormNotificacaoRepositorio struct{ db *gorm.DB }
+
+func NovoGormNotificacaoRepositorio(db *gorm.DB) repositorios.NotificacaoRepositorio {
+	return &gormNotificacaoRepositorio{db: db}
+}
+
+func (r *gormNotificacaoRepositorio) CriarNotificacao(tx *gorm.DB, notificacao *dominio.Notificacao) error {
+	return tx.Omit("Usuario").Create(notificacao).Error
+}
+
+func (r *gormNotificacaoRepositorio) ListarNotificacoesDoUsuario(tx *gorm.DB, usuarioID uint, limite int) ([]*dominio.Notificacao, error) {
+	var notificacoes []*dominio.Notificacao
+	err := tx.Where("usuario_id = ?", usuarioID).
+		Order("data_envio DESC").
+		Limit(limite).
+		Find(&notificacoes).Error
+	return notificacoes, err
+}
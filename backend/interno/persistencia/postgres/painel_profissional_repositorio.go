@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type gormPainelProfissionalRepositorio struct{ db *gorm.DB }
+
+func NovoGormPainelProfissionalRepositorio(db *gorm.DB) repositorios.PainelProfissionalRepositorio {
+	return &gormPainelProfissionalRepositorio{db: db}
+}
+
+func (r *gormPainelProfissionalRepositorio) ListarPacientesDaCarteira(tx *gorm.DB, profissionalID uint) ([]*dominio.PacienteDaCarteira, error) {
+	var pacientes []*dominio.PacienteDaCarteira
+	err := tx.Model(&dominio.Vinculo{}).
+		Select("pacientes.id AS paciente_id, usuarios.nome, profissional_paciente.vinculado_em").
+		Joins("JOIN pacientes ON pacientes.id = profissional_paciente.paciente_id AND pacientes.deleted_at IS NULL").
+		Joins("JOIN usuarios ON usuarios.id = pacientes.usuario_id AND usuarios.deleted_at IS NULL").
+		Where("profissional_paciente.profissional_id = ? AND profissional_paciente.encerrado_em IS NULL", profissionalID).
+		Order("usuarios.nome, pacientes.id").
+		Scan(&pacientes).Error
+	return pacientes, err
+}
+
+func (r *gormPainelProfissionalRepositorio) ListarUltimosRegistros(tx *gorm.DB, pacienteIDs []uint) ([]*dominio.UltimoRegistroPaciente, error) {
+	var ultimos []*dominio.UltimoRegistroPaciente
+	if len(pacienteIDs) == 0 {
+		return ultimos, nil
+	}
+	err := tx.Model(&dominio.RegistroHumor{}).
+		Select("paciente_id, data_hora_registro").
+		Where("paciente_id IN ?", pacienteIDs).
+		Where("data_hora_registro = (SELECT MAX(recentes.data_hora_registro) FROM registros_humor recentes WHERE recentes.paciente_id = registros_humor.paciente_id)").
+		Scan(&ultimos).Error
+	return ultimos, err
+}
+
+func (r *gormPainelProfissionalRepositorio) ListarRegistrosDosPacientes(tx *gorm.DB, pacienteIDs []uint, inicio, fim time.Time) ([]*dominio.RegistroHumor, error) {
+	var registros []*dominio.RegistroHumor
+	if len(pacienteIDs) == 0 {
+		return registros, nil
+	}
+	err := tx.Select("id", "paciente_id", "nivel_humor", "horas_sono", "nivel_energia", "nivel_stress", "data_hora_registro").
+		Where("paciente_id IN ? AND data_hora_registro BETWEEN ? AND ?", pacienteIDs, inicio, fim).
+		Order("paciente_id, data_hora_registro").
+		Find(&registros).Error
+	return registros, err
+}
+
+func (r *gormPainelProfissionalRepositorio) ContarAtribuicoesAbertas(tx *gorm.DB, profissionalID uint) ([]*dominio.AtribuicoesPorStatus, error) {
+	var contagens []*dominio.AtribuicoesPorStatus
+	err := tx.Model(&dominio.Atribuicao{}).
+		Select("paciente_id, status, COUNT(*) AS total").
+		Where("profissional_id = ? AND status IN ?", profissionalID, []string{dominio.StatusPendente, dominio.StatusExpirado}).
+		Group("paciente_id, status").
+		Scan(&contagens).Error
+	return contagens, err
+}
+
+func (r *gormPainelProfissionalRepositorio) ListarUltimasPontuacoes(tx *gorm.DB, profissionalID uint) ([]*dominio.UltimaPontuacao, error) {
+	var pontuacoes []*dominio.UltimaPontuacao
+	err := tx.Model(&dominio.Resposta{}).
+		Select("atribuicoes.paciente_id, atribuicoes.instrumento_id, instrumentos.codigo, instrumentos.nome, "+
+			"respostas.pontuacao_total, respostas.classificacao, respostas.data_resposta").
+		Joins("JOIN atribuicoes ON atribuicoes.id = respostas.atribuicao_id AND atribuicoes.deleted_at IS NULL").
+		Joins("JOIN instrumentos ON instrumentos.id = atribuicoes.instrumento_id").
+		Where("atribuicoes.profissional_id = ?", profissionalID).
+		Where(`respostas.data_resposta = (SELECT MAX(anteriores.data_resposta) FROM respostas anteriores
+			JOIN atribuicoes atribuicoes_anteriores ON atribuicoes_anteriores.id = anteriores.atribuicao_id
+			WHERE anteriores.deleted_at IS NULL AND atribuicoes_anteriores.deleted_at IS NULL
+			AND atribuicoes_anteriores.profissional_id = atribuicoes.profissional_id
+			AND atribuicoes_anteriores.paciente_id = atribuicoes.paciente_id
+			AND atribuicoes_anteriores.instrumento_id = atribuicoes.instrumento_id)`).
+		Order("atribuicoes.paciente_id, instrumentos.codigo").
+		Scan(&pontuacoes).Error
+	return pontuacoes, err
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+
+	"gorm.io/gorm"
+)
+
+type gormResponsavelRepositorio struct{ db *gorm.DB }
+
+func NovoGormResponsavelRepositorio(db *gorm.DB) repositorios.ResponsavelRepositorio {
+	return &gormResponsavelRepositorio{db: db}
+}
+
+func (r *gormResponsavelRepositorio) CriarResponsavel(tx *gorm.DB, responsavel *dominio.Responsavel) error {
+	return tx.Omit("Usuario").Create(responsavel).Error
+}
+
+func (r *gormResponsavelRepositorio) BuscarResponsavelPorUsuarioID(tx *gorm.DB, usuarioID uint) (*dominio.Responsavel, error) {
+	var responsavel dominio.Responsavel
+	if err := tx.Preload("Usuario").Where("usuario_id = ?", usuarioID).First(&responsavel).Error; err != nil {
+		return nil, err
+	}
+	return &responsavel, nil
+}
+
+func (r *gormResponsavelRepositorio) CriarVinculoResponsavel(tx *gorm.DB, vinculo *dominio.VinculoResponsavel) error {
+	return tx.Omit("Responsavel", "Paciente").Create(vinculo).Error
+}
+
+func (r *gormResponsavelRepositorio) AtualizarVinculoResponsavel(tx *gorm.DB, vinculo *dominio.VinculoResponsavel) error {
+	return tx.Model(vinculo).
+		Where("responsavel_id = ? AND paciente_id = ?", vinculo.ResponsavelID, vinculo.PacienteID).
+		Select("Parentesco", "VinculadoEm", "EncerradoEm", "EncerradoPor").
+		Updates(vinculo).Error
+}
+
+func (r *gormResponsavelRepositorio) BuscarVinculoResponsavel(tx *gorm.DB, responsavelID, pacienteID uint) (*dominio.VinculoResponsavel, error) {
+	var vinculo dominio.VinculoResponsavel
+	err := tx.Preload("Responsavel.Usuario").Preload("Paciente.Usuario").
+		Where("responsavel_id = ? AND paciente_id = ?", responsavelID, pacienteID).
+		First(&vinculo).Error
+	if err != nil {
+		return nil, err
+	}
+	return &vinculo, nil
+}
+
+func (r *gormResponsavelRepositorio) ListarDependentes(tx *gorm.DB, responsavelID uint) ([]*dominio.VinculoResponsavel, error) {
+	var vinculos []*dominio.VinculoResponsavel
+	err := tx.Preload("Paciente.Usuario").
+		Where("responsavel_id = ? AND encerrado_em IS NULL", responsavelID).
+		Order("vinculado_em").
+		Find(&vinculos).Error
+	return vinculos, err
+}
+
+func (r *gormResponsavelRepositorio) ListarResponsaveisDoPaciente(tx *gorm.DB, pacienteID uint) ([]*dominio.VinculoResponsavel, error) {
+	var vinculos []*dominio.VinculoResponsavel
+	err := tx.Preload("Responsavel.Usuario").Preload("Paciente.Usuario").
+		Where("paciente_id = ? AND encerrado_em IS NULL", pacienteID).
+		Order("vinculado_em").
+		Find(&vinculos).Error
+	return vinculos, err
+}
+
+func (r *gormResponsavelRepositorio) CriarAcao(tx *gorm.DB, acao *dominio.AcaoResponsavel) error {
+	return tx.Create(acao).Error
+}
+
+func (r *gormResponsavelRepositorio) ListarAcoesSobrePaciente(tx *gorm.DB, pacienteID uint, limite int) ([]*dominio.AcaoResponsavel, error) {
+	var acoes []*dominio.AcaoResponsavel
+	err := tx.Where("paciente_id = ?", pacienteID).
+		Order("created_at DESC, id DESC").
+		Limit(limite).
+		Find(&acoes).Error
+	return acoes, err
+}
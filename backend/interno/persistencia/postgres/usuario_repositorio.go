@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/cifragem"
 	"mindtrace/backend/interno/persistencia/repositorios"
 
 	"gorm.io/gorm"
@@ -36,6 +37,19 @@ func (r *gormUsuarioRepositorio) BuscarPorEmail(email string) (*dominio.Usuario,
 	return &usuario, nil
 }
 
+// BuscarPorCPF localiza o usuario pelo indice cego, ja que o CPF e gravado cifrado
+func (r *gormUsuarioRepositorio) BuscarPorCPF(cpf string) (*dominio.Usuario, error) {
+	indice, err := cifragem.IndiceCego(cpf)
+	if err != nil {
+		return nil, err
+	}
+	var usuario dominio.Usuario
+	if err := r.db.Where("cpf_indice = ?", indice).First(&usuario).Error; err != nil {
+		return nil, err
+	}
+	return &usuario, nil
+}
+
 func (r *gormUsuarioRepositorio) BuscarUsuarioPorID(id uint) (*dominio.Usuario, error) {
 	var usuario dominio.Usuario
 	if err := r.db.First(&usuario, id).Error; err != nil {
@@ -97,8 +111,3 @@ func (r *gormUsuarioRepositorio) AtualizarProfissional(tx *gorm.DB, profissional
 func (r *gormUsuarioRepositorio) AtualizarPaciente(tx *gorm.DB, paciente *dominio.Paciente) error {
 	return tx.Save(paciente).Error
 }
-
-func (r *gormUsuarioRepositorio) DeletarUsuario(tx *gorm.DB, id uint) error {
-	// Deleta o usuario com hard delete ignorando soft delete
-	return tx.Unscoped().Delete(&dominio.Usuario{}, id).Error
-}
@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type gormVerificacaoEmailRepositorio struct{ db *gorm.DB }
+
+func NovoGormVerificacaoEmailRepositorio(db *gorm.DB) repositorios.VerificacaoEmailRepositorio {
+	return &gormVerificacaoEmailRepositorio{db: db}
+}
+
+func (r *gormVerificacaoEmailRepositorio) CriarVerificacao(tx *gorm.DB, verificacao *dominio.VerificacaoEmail) error {
+	return tx.Create(verificacao).Error
+}
+
+func (r *gormVerificacaoEmailRepositorio) BuscarVerificacaoPorTokenHash(tx *gorm.DB, tokenHash string) (*dominio.VerificacaoEmail, error) {
+	var verificacao dominio.VerificacaoEmail
+	if err := tx.Where("token_hash = ?", tokenHash).First(&verificacao).Error; err != nil {
+		return nil, err
+	}
+	return &verificacao, nil
+}
+
+func (r *gormVerificacaoEmailRepositorio) BuscarUltimaVerificacao(tx *gorm.DB, usuarioID uint) (*dominio.VerificacaoEmail, error) {
+	var verificacao dominio.VerificacaoEmail
+	if err := tx.Where("usuario_id = ?", usuarioID).Order("created_at DESC").First(&verificacao).Error; err != nil {
+		return nil, err
+	}
+	return &verificacao, nil
+}
+
+func (r *gormVerificacaoEmailRepositorio) ContarVerificacoesDesde(tx *gorm.DB, usuarioID uint, desde time.Time) (int64, error) {
+	var total int64
+	err := tx.Model(&dominio.VerificacaoEmail{}).Where("usuario_id = ? AND created_at >= ?", usuarioID, desde).Count(&total).Error
+	return total, err
+}
+
+func (r *gormVerificacaoEmailRepositorio) MarcarVerificacaoComoUsada(tx *gorm.DB, verificacao *dominio.VerificacaoEmail) error {
+	return tx.Model(&dominio.VerificacaoEmail{}).Where("id = ?", verificacao.ID).Update("data_uso", verificacao.DataUso).Error
+}
+
+func (r *gormVerificacaoEmailRepositorio) InvalidarVerificacoesDoUsuario(tx *gorm.DB, usuarioID uint) error {
+	return tx.Model(&dominio.VerificacaoEmail{}).
+		Where("usuario_id = ? AND data_uso IS NULL", usuarioID).
+		Update("data_uso", time.Now()).Error
+}
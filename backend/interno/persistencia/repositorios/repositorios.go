@@ -10,7 +10,12 @@ import (
 type ConviteRepositorio interface {
 	CriarConvite(tx *gorm.DB, convite *dominio.Convite) error
 	BuscarConvitePorToken(tx *gorm.DB, token string) (*dominio.Convite, error)
+	BuscarConvitePorID(tx *gorm.DB, id uint) (*dominio.Convite, error)
+	ListarConvitesDoProfissional(tx *gorm.DB, profissionalID uint) ([]*dominio.Convite, error)
+	// MarcarConviteComoUsado grava um novo uso apenas se nenhum outro uso foi registrado desde a leitura
+	// Retorna gorm.ErrRecordNotFound quando o convite foi consumido ou revogado nesse intervalo
 	MarcarConviteComoUsado(tx *gorm.DB, convite *dominio.Convite) error
+	RevogarConvite(tx *gorm.DB, convite *dominio.Convite) error
 }
 
 type RegistroHumorRepositorio interface {
@@ -18,6 +23,19 @@ type RegistroHumorRepositorio interface {
 	BuscarPorPacienteEPeriodo(pacienteID uint, inicio, fim time.Time) ([]*dominio.RegistroHumor, error)
 	BuscarUltimoRegistroDePaciente(pacienteID uint) (*dominio.RegistroHumor, error)
 	BuscarPorNUltimosRegistros(pacienteID uint, numLimite int) ([]*dominio.RegistroHumor, error)
+	BuscarRegistroHumorPorID(tx *gorm.DB, registroID uint) (*dominio.RegistroHumor, error)
+	AtualizarRegistroHumor(tx *gorm.DB, registro *dominio.RegistroHumor) error
+	ExcluirRegistroHumor(tx *gorm.DB, registroID uint) error
+	CriarRevisaoRegistroHumor(tx *gorm.DB, revisao *dominio.RevisaoRegistroHumor) error
+	// ListarRevisoesRegistroHumor retorna as revisoes da mais recente a mais antiga
+	ListarRevisoesRegistroHumor(tx *gorm.DB, registroID uint) ([]*dominio.RevisaoRegistroHumor, error)
+	// ListarRegistrosHumor aplica o periodo, as faixas de metricas e o cursor do filtro
+	// e retorna os registros do mais recente ao mais antigo
+	ListarRegistrosHumor(tx *gorm.DB, pacienteID uint, filtro *dominio.FiltroRegistrosHumor, limite int) ([]*dominio.RegistroHumor, error)
+	// CriarRegistroHumorSeNovo ignora o registro quando o paciente ja tem um com a mesma chave de idempotencia
+	// e indica se o registro foi gravado
+	CriarRegistroHumorSeNovo(tx *gorm.DB, registro *dominio.RegistroHumor) (bool, error)
+	BuscarRegistrosHumorPorChaves(tx *gorm.DB, pacienteID uint, chaves []string) ([]*dominio.RegistroHumor, error)
 }
 
 type UsuarioRepositorio interface {
@@ -25,6 +43,7 @@ type UsuarioRepositorio interface {
 	CriarProfissional(tx *gorm.DB, profissional *dominio.Profissional) error
 	CriarPaciente(tx *gorm.DB, paciente *dominio.Paciente) error
 	BuscarPorEmail(email string) (*dominio.Usuario, error)
+	BuscarPorCPF(cpf string) (*dominio.Usuario, error)
 	BuscarUsuarioPorID(id uint) (*dominio.Usuario, error)
 	BuscarProfissionalPorID(tx *gorm.DB, id uint) (*dominio.Profissional, error)
 	BuscarPacientePorID(tx *gorm.DB, id uint) (*dominio.Paciente, error)
@@ -34,7 +53,173 @@ type UsuarioRepositorio interface {
 	Atualizar(tx *gorm.DB, usuario *dominio.Usuario) error
 	AtualizarProfissional(tx *gorm.DB, profissional *dominio.Profissional) error
 	AtualizarPaciente(tx *gorm.DB, paciente *dominio.Paciente) error
-	DeletarUsuario(tx *gorm.DB, id uint) error
+}
+
+type RedefinicaoSenhaRepositorio interface {
+	CriarRedefinicao(tx *gorm.DB, redefinicao *dominio.RedefinicaoSenha) error
+	BuscarRedefinicaoPorTokenHash(tx *gorm.DB, tokenHash string) (*dominio.RedefinicaoSenha, error)
+	MarcarRedefinicaoComoUsada(tx *gorm.DB, redefinicao *dominio.RedefinicaoSenha) error
+	InvalidarRedefinicoesDoUsuario(tx *gorm.DB, usuarioID uint) error
+}
+
+type VerificacaoEmailRepositorio interface {
+	CriarVerificacao(tx *gorm.DB, verificacao *dominio.VerificacaoEmail) error
+	BuscarVerificacaoPorTokenHash(tx *gorm.DB, tokenHash string) (*dominio.VerificacaoEmail, error)
+	BuscarUltimaVerificacao(tx *gorm.DB, usuarioID uint) (*dominio.VerificacaoEmail, error)
+	ContarVerificacoesDesde(tx *gorm.DB, usuarioID uint, desde time.Time) (int64, error)
+	MarcarVerificacaoComoUsada(tx *gorm.DB, verificacao *dominio.VerificacaoEmail) error
+	InvalidarVerificacoesDoUsuario(tx *gorm.DB, usuarioID uint) error
+}
+
+// TentativaLoginRepositorio guarda os contadores de falhas de login
+// BuscarTentativa retorna um contador zerado quando a chave nao existe
+type TentativaLoginRepositorio interface {
+	BuscarTentativa(chave string) (*dominio.TentativaLogin, error)
+	// RegistrarFalhaTentativa contabiliza a falha de forma atomica e retorna true quando ela gerou um novo bloqueio
+	RegistrarFalhaTentativa(chave string, agora time.Time, limite int) (*dominio.TentativaLogin, bool, error)
+	RemoverTentativa(chave string) error
+}
+
+type BloqueioLoginRepositorio interface {
+	CriarBloqueio(bloqueio *dominio.BloqueioLogin) error
+}
+
+type DoisFatoresRepositorio interface {
+	BuscarDoisFatores(tx *gorm.DB, usuarioID uint) (*dominio.DoisFatores, error)
+	SalvarDoisFatores(tx *gorm.DB, doisFatores *dominio.DoisFatores) error
+	RemoverDoisFatores(tx *gorm.DB, usuarioID uint) error
+
+	SubstituirCodigosRecuperacao(tx *gorm.DB, usuarioID uint, codigos []*dominio.CodigoRecuperacao) error
+	ListarCodigosRecuperacaoDisponiveis(tx *gorm.DB, usuarioID uint) ([]*dominio.CodigoRecuperacao, error)
+	MarcarCodigoRecuperacaoComoUsado(tx *gorm.DB, codigo *dominio.CodigoRecuperacao) error
+
+	CriarDesafio(tx *gorm.DB, desafio *dominio.DesafioDoisFatores) error
+	BuscarDesafioPorTokenHash(tx *gorm.DB, tokenHash string) (*dominio.DesafioDoisFatores, error)
+	AtualizarDesafio(tx *gorm.DB, desafio *dominio.DesafioDoisFatores) error
+}
+
+type ExportacaoDadosRepositorio interface {
+	CriarExportacao(tx *gorm.DB, exportacao *dominio.ExportacaoDados) error
+	AtualizarExportacao(tx *gorm.DB, exportacao *dominio.ExportacaoDados) error
+	BuscarExportacaoPorID(tx *gorm.DB, id uint) (*dominio.ExportacaoDados, error)
+	BuscarExportacaoPorTokenHash(tx *gorm.DB, tokenHash string) (*dominio.ExportacaoDados, error)
+	BuscarExportacaoEmAndamento(tx *gorm.DB, usuarioID uint) (*dominio.ExportacaoDados, error)
+	ListarExportacoesPendentes(tx *gorm.DB) ([]*dominio.ExportacaoDados, error)
+	ListarExportacoesExpiradas(tx *gorm.DB, agora time.Time) ([]*dominio.ExportacaoDados, error)
+
+	BuscarDadosTitular(tx *gorm.DB, usuarioID uint) (*dominio.DadosTitular, error)
+}
+
+type ExclusaoContaRepositorio interface {
+	CriarExclusao(tx *gorm.DB, exclusao *dominio.ExclusaoConta) error
+	AtualizarExclusao(tx *gorm.DB, exclusao *dominio.ExclusaoConta) error
+	BuscarExclusaoAgendada(tx *gorm.DB, usuarioID uint) (*dominio.ExclusaoConta, error)
+	ListarExclusoesVencidas(tx *gorm.DB, agora time.Time) ([]*dominio.ExclusaoConta, error)
+	ListarUsuariosVinculados(tx *gorm.DB, usuarioID uint) ([]*dominio.Usuario, error)
+
+	// AnonimizarTitular persiste o usuario ja anonimizado e remove ou desidentifica os dados ligados a ele
+	// Retorna os arquivos de exportacao que devem ser apagados do disco
+	AnonimizarTitular(tx *gorm.DB, usuario *dominio.Usuario, emailOriginal string) ([]string, error)
+}
+
+// ConsentimentoRepositorio guarda as versoes do consentimento de cada paciente por profissional
+// As buscas de versao atual consideram apenas pares com vinculo ativo
+type ConsentimentoRepositorio interface {
+	CriarConsentimento(tx *gorm.DB, consentimento *dominio.Consentimento) error
+	BuscarConsentimentoAtual(tx *gorm.DB, pacienteID, profissionalID uint) (*dominio.Consentimento, error)
+	ListarConsentimentosAtuaisDoPaciente(tx *gorm.DB, pacienteID uint) ([]*dominio.Consentimento, error)
+	ListarConsentimentosAtuaisDoProfissional(tx *gorm.DB, profissionalID uint) ([]*dominio.Consentimento, error)
+	ListarVersoesConsentimento(tx *gorm.DB, pacienteID, profissionalID uint) ([]*dominio.Consentimento, error)
+
+	// CriarConsentimentosLegados grava a versao inicial para vinculos anteriores ao consentimento
+	CriarConsentimentosLegados(tx *gorm.DB, agora time.Time) (int64, error)
+}
+
+// VinculoRepositorio guarda os vinculos entre profissional e paciente
+// Vinculos encerrados continuam gravados; o historico de encerramentos nunca e alterado
+type VinculoRepositorio interface {
+	CriarVinculo(tx *gorm.DB, vinculo *dominio.Vinculo) error
+	AtualizarVinculo(tx *gorm.DB, vinculo *dominio.Vinculo) error
+	BuscarVinculo(tx *gorm.DB, pacienteID, profissionalID uint) (*dominio.Vinculo, error)
+	ListarVinculosDoProfissional(tx *gorm.DB, profissionalID uint) ([]*dominio.Vinculo, error)
+	ListarProfissionaisAtivosDoPaciente(tx *gorm.DB, pacienteID uint) ([]*dominio.Profissional, error)
+
+	CriarEncerramento(tx *gorm.DB, encerramento *dominio.EncerramentoVinculo) error
+	// CancelarAtribuicoesPendentes retorna quantas atribuicoes pendentes do par foram canceladas
+	CancelarAtribuicoesPendentes(tx *gorm.DB, pacienteID, profissionalID uint) (int64, error)
+}
+
+type NotificacaoRepositorio interface {
+	CriarNotificacao(tx *gorm.DB, notificacao *dominio.Notificacao) error
+	// ListarNotificacoesDoUsuario retorna as notificacoes mais recentes primeiro
+	ListarNotificacoesDoUsuario(tx *gorm.DB, usuarioID uint, limite int) ([]*dominio.Notificacao, error)
+}
+
+// ResponsavelRepositorio guarda os responsaveis, os vinculos com os dependentes e a auditoria das acoes
+type ResponsavelRepositorio interface {
+	CriarResponsavel(tx *gorm.DB, responsavel *dominio.Responsavel) error
+	BuscarResponsavelPorUsuarioID(tx *gorm.DB, usuarioID uint) (*dominio.Responsavel, error)
+
+	CriarVinculoResponsavel(tx *gorm.DB, vinculo *dominio.VinculoResponsavel) error
+	AtualizarVinculoResponsavel(tx *gorm.DB, vinculo *dominio.VinculoResponsavel) error
+	BuscarVinculoResponsavel(tx *gorm.DB, responsavelID, pacienteID uint) (*dominio.VinculoResponsavel, error)
+	// ListarDependentes retorna os vinculos ativos do responsavel com o paciente carregado
+	ListarDependentes(tx *gorm.DB, responsavelID uint) ([]*dominio.VinculoResponsavel, error)
+	// ListarResponsaveisDoPaciente retorna os vinculos ativos do paciente com o responsavel carregado
+	ListarResponsaveisDoPaciente(tx *gorm.DB, pacienteID uint) ([]*dominio.VinculoResponsavel, error)
+
+	CriarAcao(tx *gorm.DB, acao *dominio.AcaoResponsavel) error
+	// ListarAcoesSobrePaciente retorna as acoes de todos os responsaveis, das mais recentes as mais antigas
+	ListarAcoesSobrePaciente(tx *gorm.DB, pacienteID uint, limite int) ([]*dominio.AcaoResponsavel, error)
+}
+
+// AuditoriaRepositorio grava a trilha encadeada de acessos aos dados de pacientes
+// Nao ha operacoes de alteracao ou remocao: a trilha so cresce
+type AuditoriaRepositorio interface {
+	// TravarCadeia serializa a gravacao de registros ate o fim da transacao
+	TravarCadeia(tx *gorm.DB) error
+	// BuscarUltimoRegistro retorna o registro mais recente da cadeia, ou nil se ela estiver vazia
+	BuscarUltimoRegistro(tx *gorm.DB) (*dominio.RegistroAuditoria, error)
+	CriarRegistro(tx *gorm.DB, registro *dominio.RegistroAuditoria) error
+	// ListarAcessosDoPaciente retorna os acessos de outros usuarios aos dados do paciente,
+	// dos mais recentes aos mais antigos, com o ator carregado
+	ListarAcessosDoPaciente(tx *gorm.DB, pacienteID, ignorarUsuarioID uint, de, ate *time.Time, limite int) ([]*dominio.RegistroAuditoria, error)
+	// ListarRegistrosAposID retorna os registros seguintes ao ID informado, em ordem de ID
+	ListarRegistrosAposID(tx *gorm.DB, aposID uint, limite int) ([]*dominio.RegistroAuditoria, error)
+}
+
+type NotaClinicaRepositorio interface {
+	CriarNota(tx *gorm.DB, nota *dominio.NotaClinica) error
+	AtualizarRevisaoAtual(tx *gorm.DB, nota *dominio.NotaClinica) error
+	CriarRevisao(tx *gorm.DB, revisao *dominio.RevisaoNotaClinica) error
+	// BuscarNotaPorID carrega os compartilhamentos e a revisao atual
+	BuscarNotaPorID(tx *gorm.DB, notaID uint) (*dominio.NotaClinica, error)
+	// ListarRevisoes retorna as revisoes da mais recente a mais antiga
+	ListarRevisoes(tx *gorm.DB, notaID uint) ([]*dominio.RevisaoNotaClinica, error)
+	// ListarNotasVisiveis retorna as notas do paciente escritas pelo profissional ou compartilhadas com ele
+	ListarNotasVisiveis(tx *gorm.DB, profissionalID, pacienteID uint) ([]*dominio.NotaClinica, error)
+	SalvarCompartilhamento(tx *gorm.DB, compartilhamento *dominio.CompartilhamentoNota) error
+}
+
+type ConsultaRepositorio interface {
+	CriarConsulta(tx *gorm.DB, consulta *dominio.Consulta) error
+	AtualizarConsulta(tx *gorm.DB, consulta *dominio.Consulta) error
+	// BuscarConsultaPorID carrega o paciente e o profissional com os usuarios
+	BuscarConsultaPorID(tx *gorm.DB, consultaID uint) (*dominio.Consulta, error)
+	// ListarConsultasDoProfissional retorna as consultas que comecam no periodo, em ordem de inicio
+	ListarConsultasDoProfissional(tx *gorm.DB, profissionalID uint, de, ate time.Time) ([]*dominio.Consulta, error)
+	// ListarConsultasDoPaciente retorna as consultas que comecam no periodo, em ordem de inicio
+	ListarConsultasDoPaciente(tx *gorm.DB, pacienteID uint, de, ate time.Time) ([]*dominio.Consulta, error)
+	// BuscarConflitos retorna as consultas agendadas do profissional que se sobrepoem ao horario
+	BuscarConflitos(tx *gorm.DB, profissionalID uint, inicio, fim time.Time, ignorarID uint) ([]*dominio.Consulta, error)
+	// ListarConsultasFuturasDaSerie retorna as consultas agendadas da serie a partir do horario informado
+	ListarConsultasFuturasDaSerie(tx *gorm.DB, serieID uint, aPartirDe time.Time) ([]*dominio.Consulta, error)
+	// ListarConsultasAguardandoQuestionario retorna as consultas agendadas ate o limite ainda sem questionario atribuido
+	ListarConsultasAguardandoQuestionario(tx *gorm.DB, agora, limite time.Time) ([]*dominio.Consulta, error)
+	CancelarAtribuicaoPendente(tx *gorm.DB, atribuicaoID uint) error
+
+	SalvarCalendario(tx *gorm.DB, calendario *dominio.CalendarioProfissional) error
+	BuscarCalendarioPorTokenHash(tx *gorm.DB, tokenHash string) (*dominio.CalendarioProfissional, error)
 }
 
 type InstrumentoRepositorio interface {
@@ -49,3 +234,44 @@ type InstrumentoRepositorio interface {
 	BuscarRespostaPorAtribuicaoID(tx *gorm.DB, atribuicaoID uint) (*dominio.Resposta, error)
 	BuscarRespostaCompletaPorAtribuicaoID(tx *gorm.DB, atribuicaoID uint) (*dominio.Resposta, error)
 }
+
+type AutoCuidadoRepositorio interface {
+	CriarAtividade(tx *gorm.DB, atividade *dominio.AtividadeAutoCuidado) error
+	AtualizarAtividade(tx *gorm.DB, atividade *dominio.AtividadeAutoCuidado) error
+	BuscarAtividadePorID(tx *gorm.DB, atividadeID uint) (*dominio.AtividadeAutoCuidado, error)
+	// BuscarAtividadeDoProfissionalPorNome inclui as atividades desativadas, que podem ser reativadas
+	BuscarAtividadeDoProfissionalPorNome(tx *gorm.DB, profissionalID uint, nomeNormalizado string) (*dominio.AtividadeAutoCuidado, error)
+	BuscarAtividadesPorIDs(tx *gorm.DB, ids []uint) ([]*dominio.AtividadeAutoCuidado, error)
+	// ListarCatalogoDoProfissional retorna as atividades ativas do sistema e as do profissional
+	ListarCatalogoDoProfissional(tx *gorm.DB, profissionalID uint) ([]*dominio.AtividadeAutoCuidado, error)
+	// ListarCatalogoDoPaciente retorna as atividades ativas do sistema e as dos profissionais com vinculo ativo
+	ListarCatalogoDoPaciente(tx *gorm.DB, pacienteID uint) ([]*dominio.AtividadeAutoCuidado, error)
+
+	SubstituirAtividadesDoRegistro(tx *gorm.DB, registroID uint, atividadeIDs []uint) error
+	ListarAtividadesDosRegistros(tx *gorm.DB, registroIDs []uint) ([]*dominio.RegistroHumorAtividade, error)
+}
+
+type MetricaPersonalizadaRepositorio interface {
+	CriarMetrica(tx *gorm.DB, metrica *dominio.MetricaPersonalizada) error
+	AtualizarMetrica(tx *gorm.DB, metrica *dominio.MetricaPersonalizada) error
+	BuscarMetricaPorID(tx *gorm.DB, metricaID uint) (*dominio.MetricaPersonalizada, error)
+	ListarMetricasAtivasDoPaciente(tx *gorm.DB, pacienteID uint) ([]*dominio.MetricaPersonalizada, error)
+
+	SubstituirValoresDoRegistro(tx *gorm.DB, registroID uint, valores []*dominio.ValorMetricaRegistro) error
+	// ListarValoresDosRegistros carrega a metrica de cada valor, inclusive as desativadas
+	ListarValoresDosRegistros(tx *gorm.DB, registroIDs []uint) ([]*dominio.ValorMetricaRegistro, error)
+}
+
+// PainelProfissionalRepositorio reune os dados da carteira do profissional em consultas agregadas,
+// uma por informacao e nao uma por paciente
+type PainelProfissionalRepositorio interface {
+	// ListarPacientesDaCarteira retorna os pacientes com vinculo ativo, em ordem de nome
+	ListarPacientesDaCarteira(tx *gorm.DB, profissionalID uint) ([]*dominio.PacienteDaCarteira, error)
+	ListarUltimosRegistros(tx *gorm.DB, pacienteIDs []uint) ([]*dominio.UltimoRegistroPaciente, error)
+	// ListarRegistrosDosPacientes carrega apenas as metricas dos registros, sem observacoes
+	ListarRegistrosDosPacientes(tx *gorm.DB, pacienteIDs []uint, inicio, fim time.Time) ([]*dominio.RegistroHumor, error)
+	// ContarAtribuicoesAbertas conta as atribuicoes pendentes e expiradas do profissional por paciente
+	ContarAtribuicoesAbertas(tx *gorm.DB, profissionalID uint) ([]*dominio.AtribuicoesPorStatus, error)
+	// ListarUltimasPontuacoes retorna a resposta mais recente de cada paciente a cada instrumento atribuido pelo profissional
+	ListarUltimasPontuacoes(tx *gorm.DB, profissionalID uint) ([]*dominio.UltimaPontuacao, error)
+}
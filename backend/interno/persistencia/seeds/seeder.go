@@ -3,6 +3,7 @@ package seeds
 import (
 	_ "embed"
 	"log"
+	"mindtrace/backend/interno/dominio"
 	"os"
 	"strings"
 
@@ -24,6 +25,23 @@ func ExecutarSeeds(db *gorm.DB) {
 	}
 
 	log.Println("Seed de instrumentos realizado com sucesso.")
+
+	if err := semearAtividadesAutoCuidado(db); err != nil {
+		log.Fatalf("Error ao executar seed de atividades de auto cuidado: %v", err)
+	}
+}
+
+// semearAtividadesAutoCuidado cria as atividades do catalogo do sistema que ainda nao existem
+func semearAtividadesAutoCuidado(db *gorm.DB) error {
+	for _, nome := range dominio.AtividadesAutoCuidadoPadrao {
+		atividade := dominio.AtividadeAutoCuidado{Nome: nome, NomeNormalizado: dominio.NormalizarNomeAtividade(nome), Ativa: true}
+		err := db.Where("profissional_id IS NULL AND nome_normalizado = ?", atividade.NomeNormalizado).
+			FirstOrCreate(&atividade).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // ExecutarSeedsMock executa seeds de dados mockados apenas em ambiente de desenvolvimento
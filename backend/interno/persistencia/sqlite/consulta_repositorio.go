@@ -0,0 +1,95 @@
+package sqlite
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type gormConsultaRepositorio struct{ db *gorm.DB }
+
+func NovoGormConsultaRepositorio(db *gorm.DB) repositorios.ConsultaRepositorio {
+	return &gormConsultaRepositorio{db: db}
+}
+
+func (r *gormConsultaRepositorio) CriarConsulta(tx *gorm.DB, consulta *dominio.Consulta) error {
+	return tx.Omit("Profissional", "Paciente").Create(consulta).Error
+}
+
+func (r *gormConsultaRepositorio) AtualizarConsulta(tx *gorm.DB, consulta *dominio.Consulta) error {
+	return tx.Omit("Profissional", "Paciente").Save(consulta).Error
+}
+
+func (r *gormConsultaRepositorio) BuscarConsultaPorID(tx *gorm.DB, consultaID uint) (*dominio.Consulta, error) {
+	var consulta dominio.Consulta
+	err := tx.Preload("Paciente.Usuario").Preload("Profissional.Usuario").First(&consulta, consultaID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &consulta, nil
+}
+
+func (r *gormConsultaRepositorio) ListarConsultasDoProfissional(tx *gorm.DB, profissionalID uint, de, ate time.Time) ([]*dominio.Consulta, error) {
+	var consultas []*dominio.Consulta
+	err := tx.Preload("Paciente.Usuario").
+		Where("profissional_id = ? AND inicio >= ? AND inicio < ?", profissionalID, de, ate).
+		Order("inicio ASC").
+		Find(&consultas).Error
+	return consultas, err
+}
+
+func (r *gormConsultaRepositorio) ListarConsultasDoPaciente(tx *gorm.DB, pacienteID uint, de, ate time.Time) ([]*dominio.Consulta, error) {
+	var consultas []*dominio.Consulta
+	err := tx.Preload("Profissional.Usuario").
+		Where("paciente_id = ? AND inicio >= ? AND inicio < ?", pacienteID, de, ate).
+		Order("inicio ASC").
+		Find(&consultas).Error
+	return consultas, err
+}
+
+func (r *gormConsultaRepositorio) BuscarConflitos(tx *gorm.DB, profissionalID uint, inicio, fim time.Time, ignorarID uint) ([]*dominio.Consulta, error) {
+	var consultas []*dominio.Consulta
+	err := tx.Where("profissional_id = ? AND status = ? AND inicio < ? AND fim > ? AND id <> ?",
+		profissionalID, dominio.StatusConsultaAgendada, fim, inicio, ignorarID).
+		Order("inicio ASC").
+		Find(&consultas).Error
+	return consultas, err
+}
+
+func (r *gormConsultaRepositorio) ListarConsultasFuturasDaSerie(tx *gorm.DB, serieID uint, aPartirDe time.Time) ([]*dominio.Consulta, error) {
+	var consultas []*dominio.Consulta
+	err := tx.Where("serie_id = ? AND status = ? AND inicio >= ?", serieID, dominio.StatusConsultaAgendada, aPartirDe).
+		Order("inicio ASC").
+		Find(&consultas).Error
+	return consultas, err
+}
+
+func (r *gormConsultaRepositorio) ListarConsultasAguardandoQuestionario(tx *gorm.DB, agora, limite time.Time) ([]*dominio.Consulta, error) {
+	var consultas []*dominio.Consulta
+	err := tx.Preload("Paciente.Usuario").Preload("Profissional.Usuario").
+		Where("status = ? AND instrumento_id IS NOT NULL AND atribuicao_id IS NULL AND inicio > ? AND inicio <= ?",
+			dominio.StatusConsultaAgendada, agora, limite).
+		Order("inicio ASC").
+		Find(&consultas).Error
+	return consultas, err
+}
+
+func (r *gormConsultaRepositorio) CancelarAtribuicaoPendente(tx *gorm.DB, atribuicaoID uint) error {
+	return tx.Model(&dominio.Atribuicao{}).
+		Where("id = ? AND status = ?", atribuicaoID, dominio.StatusPendente).
+		Update("status", dominio.StatusCancelado).Error
+}
+
+func (r *gormConsultaRepositorio) SalvarCalendario(tx *gorm.DB, calendario *dominio.CalendarioProfissional) error {
+	return tx.Save(calendario).Error
+}
+
+func (r *gormConsultaRepositorio) BuscarCalendarioPorTokenHash(tx *gorm.DB, tokenHash string) (*dominio.CalendarioProfissional, error) {
+	var calendario dominio.CalendarioProfissional
+	if err := tx.Where("token_hash = ?", tokenHash).First(&calendario).Error; err != nil {
+		return nil, err
+	}
+	return &calendario, nil
+}
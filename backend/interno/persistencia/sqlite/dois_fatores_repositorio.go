@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+
+	"gorm.io/gorm"
+)
+
+type gormDoisFatoresRepositorio struct{ db *gorm.DB }
+
+func NovoGormDoisFatoresRepositorio(db *gorm.DB) repositorios.DoisFatoresRepositorio {
+	return &gormDoisFatoresRepositorio{db: db}
+}
+
+func (r *gormDoisFatoresRepositorio) BuscarDoisFatores(tx *gorm.DB, usuarioID uint) (*dominio.DoisFatores, error) {
+	var doisFatores dominio.DoisFatores
+	if err := tx.Where("usuario_id = ?", usuarioID).First(&doisFatores).Error; err != nil {
+		return nil, err
+	}
+	return &doisFatores, nil
+}
+
+func (r *gormDoisFatoresRepositorio) SalvarDoisFatores(tx *gorm.DB, doisFatores *dominio.DoisFatores) error {
+	return tx.Save(doisFatores).Error
+}
+
+func (r *gormDoisFatoresRepositorio) RemoverDoisFatores(tx *gorm.DB, usuarioID uint) error {
+	if err := tx.Where("usuario_id = ?", usuarioID).Delete(&dominio.CodigoRecuperacao{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("usuario_id = ?", usuarioID).Delete(&dominio.DoisFatores{}).Error
+}
+
+func (r *gormDoisFatoresRepositorio) SubstituirCodigosRecuperacao(tx *gorm.DB, usuarioID uint, codigos []*dominio.CodigoRecuperacao) error {
+	// Codigos antigos deixam de existir ao gerar um novo conjunto
+	if err := tx.Where("usuario_id = ?", usuarioID).Delete(&dominio.CodigoRecuperacao{}).Error; err != nil {
+		return err
+	}
+	if len(codigos) == 0 {
+		return nil
+	}
+	return tx.Create(&codigos).Error
+}
+
+func (r *gormDoisFatoresRepositorio) ListarCodigosRecuperacaoDisponiveis(tx *gorm.DB, usuarioID uint) ([]*dominio.CodigoRecuperacao, error) {
+	var codigos []*dominio.CodigoRecuperacao
+	if err := tx.Where("usuario_id = ? AND data_uso IS NULL", usuarioID).Find(&codigos).Error; err != nil {
+		return nil, err
+	}
+	return codigos, nil
+}
+
+func (r *gormDoisFatoresRepositorio) MarcarCodigoRecuperacaoComoUsado(tx *gorm.DB, codigo *dominio.CodigoRecuperacao) error {
+	return tx.Model(&dominio.CodigoRecuperacao{}).Where("id = ?", codigo.ID).Update("data_uso", codigo.DataUso).Error
+}
+
+func (r *gormDoisFatoresRepositorio) CriarDesafio(tx *gorm.DB, desafio *dominio.DesafioDoisFatores) error {
+	return tx.Create(desafio).Error
+}
+
+func (r *gormDoisFatoresRepositorio) BuscarDesafioPorTokenHash(tx *gorm.DB, tokenHash string) (*dominio.DesafioDoisFatores, error) {
+	var desafio dominio.DesafioDoisFatores
+	if err := tx.Where("token_hash = ?", tokenHash).First(&desafio).Error; err != nil {
+		return nil, err
+	}
+	return &desafio, nil
+}
+
+func (r *gormDoisFatoresRepositorio) AtualizarDesafio(tx *gorm.DB, desafio *dominio.DesafioDoisFatores) error {
+	return tx.Model(&dominio.DesafioDoisFatores{}).Where("id = ?", desafio.ID).Updates(map[string]interface{}{
+		"tentativas": desafio.Tentativas,
+		"data_uso":   desafio.DataUso,
+	}).Error
+}
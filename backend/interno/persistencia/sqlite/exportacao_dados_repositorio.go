@@ -0,0 +1,199 @@
+package sqlite
+
+import (
+	"errors"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type gormExportacaoDadosRepositorio struct{ db *gorm.DB }
+
+func NovoGormExportacaoDadosRepositorio(db *gorm.DB) repositorios.ExportacaoDadosRepositorio {
+	return &gormExportacaoDadosRepositorio{db: db}
+}
+
+func (r *gormExportacaoDadosRepositorio) CriarExportacao(tx *gorm.DB, exportacao *dominio.ExportacaoDados) error {
+	return tx.Create(exportacao).Error
+}
+
+func (r *gormExportacaoDadosRepositorio) AtualizarExportacao(tx *gorm.DB, exportacao *dominio.ExportacaoDados) error {
+	return tx.Save(exportacao).Error
+}
+
+func (r *gormExportacaoDadosRepositorio) BuscarExportacaoPorID(tx *gorm.DB, id uint) (*dominio.ExportacaoDados, error) {
+	var exportacao dominio.ExportacaoDados
+	if err := tx.First(&exportacao, id).Error; err != nil {
+		return nil, err
+	}
+	return &exportacao, nil
+}
+
+func (r *gormExportacaoDadosRepositorio) BuscarExportacaoPorTokenHash(tx *gorm.DB, tokenHash string) (*dominio.ExportacaoDados, error) {
+	var exportacao dominio.ExportacaoDados
+	if err := tx.Where("token_download_hash = ?", tokenHash).First(&exportacao).Error; err != nil {
+		return nil, err
+	}
+	return &exportacao, nil
+}
+
+func (r *gormExportacaoDadosRepositorio) BuscarExportacaoEmAndamento(tx *gorm.DB, usuarioID uint) (*dominio.ExportacaoDados, error) {
+	var exportacao dominio.ExportacaoDados
+	err := tx.Where("usuario_id = ? AND status IN ?", usuarioID,
+		[]string{dominio.StatusExportacaoPendente, dominio.StatusExportacaoProcessando}).
+		First(&exportacao).Error
+	if err != nil {
+		return nil, err
+	}
+	return &exportacao, nil
+}
+
+func (r *gormExportacaoDadosRepositorio) ListarExportacoesPendentes(tx *gorm.DB) ([]*dominio.ExportacaoDados, error) {
+	var exportacoes []*dominio.ExportacaoDados
+	err := tx.Where("status IN ?", []string{dominio.StatusExportacaoPendente, dominio.StatusExportacaoProcessando}).
+		Order("created_at").
+		Find(&exportacoes).Error
+	return exportacoes, err
+}
+
+func (r *gormExportacaoDadosRepositorio) ListarExportacoesExpiradas(tx *gorm.DB, agora time.Time) ([]*dominio.ExportacaoDados, error) {
+	var exportacoes []*dominio.ExportacaoDados
+	err := tx.Where("data_expiracao IS NOT NULL AND data_expiracao <= ? AND caminho_arquivo <> ''", agora).
+		Find(&exportacoes).Error
+	return exportacoes, err
+}
+
+func (r *gormExportacaoDadosRepositorio) BuscarDadosTitular(tx *gorm.DB, usuarioID uint) (*dominio.DadosTitular, error) {
+	dados := &dominio.DadosTitular{}
+	if err := tx.First(&dados.Usuario, usuarioID).Error; err != nil {
+		return nil, err
+	}
+
+	var profissional dominio.Profissional
+	err := tx.Preload("Pacientes").Where("usuario_id = ?", usuarioID).First(&profissional).Error
+	if err == nil {
+		dados.Profissional = &profissional
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var paciente dominio.Paciente
+	err = tx.Preload("Profissionais.Usuario").Where("usuario_id = ?", usuarioID).First(&paciente).Error
+	if err == nil {
+		dados.Paciente = &paciente
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if dados.Paciente != nil {
+		if err := tx.Where("paciente_id = ?", dados.Paciente.ID).
+			Order("data_hora_registro").
+			Find(&dados.RegistrosHumor).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Where("paciente_id = ?", dados.Paciente.ID).
+			Order("registro_humor_id, revisao").
+			Find(&dados.RevisoesRegistrosHumor).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.
+			Preload("Metrica").
+			Joins("JOIN registros_humor ON registros_humor.id = registros_humor_metricas.registro_humor_id").
+			Where("registros_humor.paciente_id = ?", dados.Paciente.ID).
+			Order("registros_humor_metricas.registro_humor_id, registros_humor_metricas.metrica_id").
+			Find(&dados.ValoresMetricas).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.
+			Preload("Instrumento").
+			Preload("Profissional.Usuario").
+			Preload("Resposta").
+			Where("paciente_id = ?", dados.Paciente.ID).
+			Order("created_at").
+			Find(&dados.Atribuicoes).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Where("paciente_id = ?", dados.Paciente.ID).Find(&dados.Convites).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.
+			Preload("Profissional.Usuario").
+			Where("paciente_id = ?", dados.Paciente.ID).
+			Order("profissional_id, versao").
+			Find(&dados.Consentimentos).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Where("paciente_id = ?", dados.Paciente.ID).
+			Order("encerrado_em").
+			Find(&dados.EncerramentosVinculo).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.
+			Preload("Profissional.Usuario").
+			Where("paciente_id = ?", dados.Paciente.ID).
+			Order("inicio").
+			Find(&dados.Consultas).Error; err != nil {
+			return nil, err
+		}
+		// Os proprios acessos do titular ficam de fora, como na consulta do registro de acessos
+		if err := tx.Preload("Ator", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+			Where("paciente_id = ?", dados.Paciente.ID).
+			Where("ator_id IS NULL OR ator_id <> ?", usuarioID).
+			Order("criado_em, id").
+			Find(&dados.AcessosDados).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if dados.Profissional != nil {
+		var atribuicoes []*dominio.Atribuicao
+		if err := tx.
+			Preload("Instrumento").
+			Where("profissional_id = ?", dados.Profissional.ID).
+			Order("created_at").
+			Find(&atribuicoes).Error; err != nil {
+			return nil, err
+		}
+		dados.Atribuicoes = append(dados.Atribuicoes, atribuicoes...)
+
+		var convites []*dominio.Convite
+		if err := tx.Where("profissional_id = ?", dados.Profissional.ID).Find(&convites).Error; err != nil {
+			return nil, err
+		}
+		dados.Convites = append(dados.Convites, convites...)
+
+		// Os consentimentos dos pacientes aparecem sem os dados cadastrais deles
+		var consentimentos []*dominio.Consentimento
+		if err := tx.Where("profissional_id = ?", dados.Profissional.ID).
+			Order("paciente_id, versao").
+			Find(&consentimentos).Error; err != nil {
+			return nil, err
+		}
+		dados.Consentimentos = append(dados.Consentimentos, consentimentos...)
+
+		var encerramentos []*dominio.EncerramentoVinculo
+		if err := tx.Where("profissional_id = ?", dados.Profissional.ID).
+			Order("encerrado_em").
+			Find(&encerramentos).Error; err != nil {
+			return nil, err
+		}
+		dados.EncerramentosVinculo = append(dados.EncerramentosVinculo, encerramentos...)
+
+		// As consultas da agenda aparecem sem os dados cadastrais dos pacientes
+		var consultas []*dominio.Consulta
+		if err := tx.Where("profissional_id = ?", dados.Profissional.ID).
+			Order("inicio").
+			Find(&consultas).Error; err != nil {
+			return nil, err
+		}
+		dados.Consultas = append(dados.Consultas, consultas...)
+	}
+
+	if err := tx.Where("usuario_id = ?", usuarioID).Order("data_envio").Find(&dados.Notificacoes).Error; err != nil {
+		return nil, err
+	}
+
+	return dados, nil
+}
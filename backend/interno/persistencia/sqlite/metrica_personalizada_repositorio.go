@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+
+	"gorm.io/gorm"
+)
+
+type gormMetricaPersonalizadaRepositorio struct{ db *gorm.DB }
+
+func NovoGormMetricaPersonalizadaRepositorio(db *gorm.DB) repositorios.MetricaPersonalizadaRepositorio {
+	return &gormMetricaPersonalizadaRepositorio{db: db}
+}
+
+func (r *gormMetricaPersonalizadaRepositorio) CriarMetrica(tx *gorm.DB, metrica *dominio.MetricaPersonalizada) error {
+	return tx.Omit("Paciente", "Profissional").Create(metrica).Error
+}
+
+func (r *gormMetricaPersonalizadaRepositorio) AtualizarMetrica(tx *gorm.DB, metrica *dominio.MetricaPersonalizada) error {
+	return tx.Omit("Paciente", "Profissional").Save(metrica).Error
+}
+
+func (r *gormMetricaPersonalizadaRepositorio) BuscarMetricaPorID(tx *gorm.DB, metricaID uint) (*dominio.MetricaPersonalizada, error) {
+	var metrica dominio.MetricaPersonalizada
+	if err := tx.First(&metrica, metricaID).Error; err != nil {
+		return nil, err
+	}
+	return &metrica, nil
+}
+
+func (r *gormMetricaPersonalizadaRepositorio) ListarMetricasAtivasDoPaciente(tx *gorm.DB, pacienteID uint) ([]*dominio.MetricaPersonalizada, error) {
+	var metricas []*dominio.MetricaPersonalizada
+	err := tx.Where("paciente_id = ? AND ativa = ?", pacienteID, true).Order("id").Find(&metricas).Error
+	return metricas, err
+}
+
+func (r *gormMetricaPersonalizadaRepositorio) SubstituirValoresDoRegistro(tx *gorm.DB, registroID uint, valores []*dominio.ValorMetricaRegistro) error {
+	if err := tx.Where("registro_humor_id = ?", registroID).Delete(&dominio.ValorMetricaRegistro{}).Error; err != nil {
+		return err
+	}
+	if len(valores) == 0 {
+		return nil
+	}
+	for _, valor := range valores {
+		valor.RegistroHumorID = registroID
+	}
+	return tx.Omit("Metrica").Create(&valores).Error
+}
+
+func (r *gormMetricaPersonalizadaRepositorio) ListarValoresDosRegistros(tx *gorm.DB, registroIDs []uint) ([]*dominio.ValorMetricaRegistro, error) {
+	var valores []*dominio.ValorMetricaRegistro
+	if len(registroIDs) == 0 {
+		return valores, nil
+	}
+	err := tx.Preload("Metrica").Where("registro_humor_id IN ?", registroIDs).Order("metrica_id").Find(&valores).Error
+	return valores, err
+}
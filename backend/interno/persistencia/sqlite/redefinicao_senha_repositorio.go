@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type gormRedefinicaoSenhaRepositorio struct{ db *gorm.DB }
+
+func NovoGormRedefinicaoSenhaRepositorio(db *gorm.DB) repositorios.RedefinicaoSenhaRepositorio {
+	return &gormRedefinicaoSenhaRepositorio{db: db}
+}
+
+func (r *gormRedefinicaoSenhaRepositorio) CriarRedefinicao(tx *gorm.DB, redefinicao *dominio.RedefinicaoSenha) error {
+	return tx.Create(redefinicao).Error
+}
+
+func (r *gormRedefinicaoSenhaRepositorio) BuscarRedefinicaoPorTokenHash(tx *gorm.DB, tokenHash string) (*dominio.RedefinicaoSenha, error) {
+	var redefinicao dominio.RedefinicaoSenha
+	if err := tx.Where("token_hash = ?", tokenHash).First(&redefinicao).Error; err != nil {
+		return nil, err
+	}
+	return &redefinicao, nil
+}
+
+// MarcarRedefinicaoComoUsada so marca um token ainda nao utilizado; em requisicoes simultaneas
+// com o mesmo token, apenas a primeira atualiza a linha e as demais recebem token invalido
+func (r *gormRedefinicaoSenhaRepositorio) MarcarRedefinicaoComoUsada(tx *gorm.DB, redefinicao *dominio.RedefinicaoSenha) error {
+	resultado := tx.Model(&dominio.RedefinicaoSenha{}).
+		Where("id = ? AND data_uso IS NULL", redefinicao.ID).
+		Update("data_uso", redefinicao.DataUso)
+	if resultado.Error != nil {
+		return resultado.Error
+	}
+	if resultado.RowsAffected != 1 {
+		return dominio.ErrTokenRedefinicaoInvalido
+	}
+	return nil
+}
+
+func (r *gormRedefinicaoSenhaRepositorio) InvalidarRedefinicoesDoUsuario(tx *gorm.DB, usuarioID uint) error {
+	// Marca como usados os tokens pendentes para que apenas o mais recente seja valido
+	return tx.Model(&dominio.RedefinicaoSenha{}).
+		Where("usuario_id = ? AND data_uso IS NULL", usuarioID).
+		Update("data_uso", time.Now()).Error
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type gormRegistroHumorRepositorio struct {
@@ -20,6 +21,20 @@ func (r *gormRegistroHumorRepositorio) CriarRegistroHumor(tx *gorm.DB, registro
 	return tx.Create(registro).Error
 }
 
+func (r *gormRegistroHumorRepositorio) CriarRegistroHumorSeNovo(tx *gorm.DB, registro *dominio.RegistroHumor) (bool, error) {
+	resultado := tx.Omit("Paciente").Clauses(clause.OnConflict{DoNothing: true}).Create(registro)
+	return resultado.RowsAffected == 1, resultado.Error
+}
+
+func (r *gormRegistroHumorRepositorio) BuscarRegistrosHumorPorChaves(tx *gorm.DB, pacienteID uint, chaves []string) ([]*dominio.RegistroHumor, error) {
+	var registros []*dominio.RegistroHumor
+	if len(chaves) == 0 {
+		return registros, nil
+	}
+	err := tx.Where("paciente_id = ? AND chave_idempotencia IN ?", pacienteID, chaves).Find(&registros).Error
+	return registros, err
+}
+
 func (r *gormRegistroHumorRepositorio) BuscarPorPacienteEPeriodo(pacienteID uint, inicio, fim time.Time) ([]*dominio.RegistroHumor, error) {
 	var registros []*dominio.RegistroHumor
 	err := r.db.Where("paciente_id = ? AND data_hora_registro BETWEEN ? AND ?", pacienteID, inicio, fim).Find(&registros).Error
@@ -34,6 +49,73 @@ func (r *gormRegistroHumorRepositorio) BuscarUltimoRegistroDePaciente(pacienteID
 
 func (r *gormRegistroHumorRepositorio) BuscarPorNUltimosRegistros(pacienteID uint, numLimite int) ([]*dominio.RegistroHumor, error) {
 	var registros []*dominio.RegistroHumor
-	err := r.db.Where("paciente_id = ", pacienteID).Order("created_at DESC").Limit(numLimite).Find(&registros).Error
+	err := r.db.Where("paciente_id = ?", pacienteID).Order("created_at DESC").Limit(numLimite).Find(&registros).Error
+	return registros, err
+}
+
+func (r *gormRegistroHumorRepositorio) BuscarRegistroHumorPorID(tx *gorm.DB, registroID uint) (*dominio.RegistroHumor, error) {
+	var registro dominio.RegistroHumor
+	if err := tx.First(&registro, registroID).Error; err != nil {
+		return nil, err
+	}
+	return &registro, nil
+}
+
+func (r *gormRegistroHumorRepositorio) AtualizarRegistroHumor(tx *gorm.DB, registro *dominio.RegistroHumor) error {
+	return tx.Omit("Paciente").Save(registro).Error
+}
+
+func (r *gormRegistroHumorRepositorio) ExcluirRegistroHumor(tx *gorm.DB, registroID uint) error {
+	if err := tx.Where("registro_humor_id = ?", registroID).Delete(&dominio.RegistroHumorAtividade{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("registro_humor_id = ?", registroID).Delete(&dominio.ValorMetricaRegistro{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&dominio.RegistroHumor{}, registroID).Error
+}
+
+func (r *gormRegistroHumorRepositorio) CriarRevisaoRegistroHumor(tx *gorm.DB, revisao *dominio.RevisaoRegistroHumor) error {
+	return tx.Create(revisao).Error
+}
+
+func (r *gormRegistroHumorRepositorio) ListarRevisoesRegistroHumor(tx *gorm.DB, registroID uint) ([]*dominio.RevisaoRegistroHumor, error) {
+	var revisoes []*dominio.RevisaoRegistroHumor
+	err := tx.Where("registro_humor_id = ?", registroID).Order("revisao DESC").Find(&revisoes).Error
+	return revisoes, err
+}
+
+func (r *gormRegistroHumorRepositorio) ListarRegistrosHumor(tx *gorm.DB, pacienteID uint, filtro *dominio.FiltroRegistrosHumor, limite int) ([]*dominio.RegistroHumor, error) {
+	consulta := tx.Where("paciente_id = ?", pacienteID)
+	if filtro.De != nil {
+		consulta = consulta.Where("data_hora_registro >= ?", *filtro.De)
+	}
+	if filtro.AntesDe != nil {
+		consulta = consulta.Where("data_hora_registro < ?", *filtro.AntesDe)
+	}
+	faixas := []struct {
+		coluna string
+		faixa  dominio.FaixaMetrica
+	}{
+		{"nivel_humor", filtro.Humor},
+		{"horas_sono", filtro.Sono},
+		{"nivel_energia", filtro.Energia},
+		{"nivel_stress", filtro.Stress},
+	}
+	for _, f := range faixas {
+		if f.faixa.Min != nil {
+			consulta = consulta.Where(f.coluna+" >= ?", *f.faixa.Min)
+		}
+		if f.faixa.Max != nil {
+			consulta = consulta.Where(f.coluna+" <= ?", *f.faixa.Max)
+		}
+	}
+	if filtro.CursorDataHora != nil {
+		consulta = consulta.Where("data_hora_registro < ? OR (data_hora_registro = ? AND id < ?)",
+			*filtro.CursorDataHora, *filtro.CursorDataHora, filtro.CursorID)
+	}
+
+	var registros []*dominio.RegistroHumor
+	err := consulta.Order("data_hora_registro DESC, id DESC").Limit(limite).Find(&registros).Error
 	return registros, err
 }
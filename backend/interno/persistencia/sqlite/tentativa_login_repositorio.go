@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"errors"
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormTentativaLoginRepositorio struct{ db *gorm.DB }
+
+func NovoGormTentativaLoginRepositorio(db *gorm.DB) repositorios.TentativaLoginRepositorio {
+	return &gormTentativaLoginRepositorio{db: db}
+}
+
+func (r *gormTentativaLoginRepositorio) BuscarTentativa(chave string) (*dominio.TentativaLogin, error) {
+	var tentativa dominio.TentativaLogin
+	if err := r.db.Where("chave = ?", chave).First(&tentativa).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &dominio.TentativaLogin{Chave: chave}, nil
+		}
+		return nil, err
+	}
+	return &tentativa, nil
+}
+
+// RegistrarFalhaTentativa le e grava a chave na mesma transacao
+// O SQLite nao tem FOR UPDATE: o insert inicial ja reserva a escrita do banco, e falhas simultaneas esperam a vez
+func (r *gormTentativaLoginRepositorio) RegistrarFalhaTentativa(chave string, agora time.Time, limite int) (*dominio.TentativaLogin, bool, error) {
+	var tentativa dominio.TentativaLogin
+	var bloqueou bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&dominio.TentativaLogin{Chave: chave}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("chave = ?", chave).First(&tentativa).Error; err != nil {
+			return err
+		}
+		bloqueou = tentativa.RegistrarFalha(agora, limite)
+		return tx.Save(&tentativa).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &tentativa, bloqueou, nil
+}
+
+func (r *gormTentativaLoginRepositorio) RemoverTentativa(chave string) error {
+	return r.db.Where("chave = ?", chave).Delete(&dominio.TentativaLogin{}).Error
+}
+
+type gormBloqueioLoginRepositorio struct{ db *gorm.DB }
+
+func NovoGormBloqueioLoginRepositorio(db *gorm.DB) repositorios.BloqueioLoginRepositorio {
+	return &gormBloqueioLoginRepositorio{db: db}
+}
+
+func (r *gormBloqueioLoginRepositorio) CriarBloqueio(bloqueio *dominio.BloqueioLogin) error {
+	return r.db.Create(bloqueio).Error
+}
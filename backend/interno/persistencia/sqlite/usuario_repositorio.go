@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/cifragem"
 	"mindtrace/backend/interno/persistencia/repositorios"
 
 	"gorm.io/gorm"
@@ -36,6 +37,19 @@ func (r *gormUsuarioRepositorio) BuscarPorEmail(email string) (*dominio.Usuario,
 	return &usuario, nil
 }
 
+// BuscarPorCPF localiza o usuario pelo indice cego, ja que o CPF e gravado cifrado
+func (r *gormUsuarioRepositorio) BuscarPorCPF(cpf string) (*dominio.Usuario, error) {
+	indice, err := cifragem.IndiceCego(cpf)
+	if err != nil {
+		return nil, err
+	}
+	var usuario dominio.Usuario
+	if err := r.db.Where("cpf_indice = ?", indice).First(&usuario).Error; err != nil {
+		return nil, err
+	}
+	return &usuario, nil
+}
+
 func (r *gormUsuarioRepositorio) BuscarUsuarioPorID(id uint) (*dominio.Usuario, error) {
 	var usuario dominio.Usuario
 	if err := r.db.First(&usuario, id).Error; err != nil {
@@ -97,46 +111,3 @@ func (r *gormUsuarioRepositorio) AtualizarProfissional(tx *gorm.DB, profissional
 func (r *gormUsuarioRepositorio) AtualizarPaciente(tx *gorm.DB, paciente *dominio.Paciente) error {
 	return tx.Save(paciente).Error
 }
-
-func (r *gormUsuarioRepositorio) DeletarUsuario(tx *gorm.DB, id uint) error {
-	// Busca o usuario para determinar o tipo
-	usuario, err := r.BuscarUsuarioPorID(id)
-	if err != nil {
-		return err
-	}
-
-	// Deleta registros especificos conforme o tipo
-	switch usuario.TipoUsuario {
-	case 2: // tipo de usuario 2 = profissional
-		// Busca o profissional para limpar associacoes
-		profissional, err := r.BuscarProfissionalPorUsuarioID(tx, id)
-		if err != nil {
-			return err
-		}
-		// Limpa associacoes many-to-many removendo entradas da tabela profissional_paciente
-		if err := tx.Model(profissional).Association("Pacientes").Clear(); err != nil {
-			return err
-		}
-		// Deleta o profissional
-		if err := tx.Where("usuario_id = ?", id).Delete(&dominio.Profissional{}).Error; err != nil {
-			return err
-		}
-	case 3: // tipo de usuario 3 = paciente
-		// Busca o paciente para limpar associacoes
-		paciente, err := r.BuscarPacientePorUsuarioID(tx, id)
-		if err != nil {
-			return err
-		}
-		// Limpa associacoes many-to-many removendo entradas da tabela profissional_paciente
-		if err := tx.Model(paciente).Association("Profissionais").Clear(); err != nil {
-			return err
-		}
-		// Deleta o paciente
-		if err := tx.Where("usuario_id = ?", id).Delete(&dominio.Paciente{}).Error; err != nil {
-			return err
-		}
-	}
-
-	// Deleta o usuario com hard delete ignorando soft delete
-	return tx.Unscoped().Delete(&dominio.Usuario{}, id).Error
-}
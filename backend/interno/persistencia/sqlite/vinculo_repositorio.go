@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"mindtrace/backend/interno/dominio"
+	"mindtrace/backend/interno/persistencia/repositorios"
+
+	"gorm.io/gorm"
+)
+
+type gormVinculoRepositorio struct{ db *gorm.DB }
+
+func NovoGormVinculoRepositorio(db *gorm.DB) repositorios.VinculoRepositorio {
+	return &gormVinculoRepositorio{db: db}
+}
+
+func (r *gormVinculoRepositorio) CriarVinculo(tx *gorm.DB, vinculo *dominio.Vinculo) error {
+	return tx.Create(vinculo).Error
+}
+
+func (r *gormVinculoRepositorio) AtualizarVinculo(tx *gorm.DB, vinculo *dominio.Vinculo) error {
+	return tx.Model(vinculo).
+		Where("paciente_id = ? AND profissional_id = ?", vinculo.PacienteID, vinculo.ProfissionalID).
+		Select("VinculadoEm", "EncerradoEm", "EncerradoPor").
+		Updates(vinculo).Error
+}
+
+func (r *gormVinculoRepositorio) BuscarVinculo(tx *gorm.DB, pacienteID, profissionalID uint) (*dominio.Vinculo, error) {
+	var vinculo dominio.Vinculo
+	err := tx.Where("paciente_id = ? AND profissional_id = ?", pacienteID, profissionalID).First(&vinculo).Error
+	if err != nil {
+		return nil, err
+	}
+	return &vinculo, nil
+}
+
+func (r *gormVinculoRepositorio) ListarVinculosDoProfissional(tx *gorm.DB, profissionalID uint) ([]*dominio.Vinculo, error) {
+	var vinculos []*dominio.Vinculo
+	err := tx.Where("profissional_id = ?", profissionalID).Find(&vinculos).Error
+	return vinculos, err
+}
+
+func (r *gormVinculoRepositorio) ListarProfissionaisAtivosDoPaciente(tx *gorm.DB, pacienteID uint) ([]*dominio.Profissional, error) {
+	var profissionais []*dominio.Profissional
+	err := tx.Preload("Usuario").
+		Joins("JOIN profissional_paciente ON profissional_paciente.profissional_id = profissionais.id").
+		Where("profissional_paciente.paciente_id = ? AND profissional_paciente.encerrado_em IS NULL", pacienteID).
+		Find(&profissionais).Error
+	return profissionais, err
+}
+
+func (r *gormVinculoRepositorio) CriarEncerramento(tx *gorm.DB, encerramento *dominio.EncerramentoVinculo) error {
+	return tx.Create(encerramento).Error
+}
+
+func (r *gormVinculoRepositorio) CancelarAtribuicoesPendentes(tx *gorm.DB, pacienteID, profissionalID uint) (int64, error) {
+	resultado := tx.Model(&dominio.Atribuicao{}).
+		Where("paciente_id = ? AND profissional_id = ? AND status = ?", pacienteID, profissionalID, dominio.StatusPendente).
+		Update("status", dominio.StatusCancelado)
+	return resultado.RowsAffected, resultado.Error
+}